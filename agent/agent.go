@@ -44,6 +44,8 @@ const (
 	ProtocolReconnectingPTY = "reconnecting-pty"
 	ProtocolSSH             = "ssh"
 	ProtocolDial            = "dial"
+	ProtocolAPI             = "api"
+	ProtocolExec            = "exec"
 
 	// MagicSessionErrorCode indicates that something went wrong with the session, rather than the
 	// command just returning a nonzero exit code, and is chosen as an arbitrary, high number
@@ -58,6 +60,8 @@ var (
 	tailnetIP                  = netip.MustParseAddr("fd7a:115c:a1e0:49d6:b259:b7ac:b1b2:48f4")
 	tailnetSSHPort             = 1
 	tailnetReconnectingPTYPort = 2
+	tailnetAPIPort             = 3
+	tailnetExecPort            = 4
 )
 
 type Options struct {
@@ -65,6 +69,24 @@ type Options struct {
 	WebRTCDialer      WebRTCDialer
 	FetchMetadata     FetchMetadata
 
+	// SendStartupLogs is optional. When set, the output of the startup
+	// script is sent to coderd.
+	SendStartupLogs SendStartupLogs
+	// ReportLifecycle is optional. When set, lifecycle state changes are
+	// reported to coderd.
+	ReportLifecycle ReportLifecycle
+	// UploadSessionRecording is optional. When set, interactive terminal
+	// sessions are recorded and uploaded to coderd if the template enables
+	// session recording.
+	UploadSessionRecording UploadSessionRecording
+	// ReportConnection is optional. When set, SSH sessions, reconnecting
+	// PTYs and port forwards are reported to coderd.
+	ReportConnection ReportConnection
+	// StartupScriptTimeout is how long the startup script may run before the
+	// agent is considered to have timed out starting. The script keeps
+	// running. Zero disables the timeout.
+	StartupScriptTimeout time.Duration
+
 	StatsReporter          StatsReporter
 	ReconnectingPTYTimeout time.Duration
 	EnvironmentVariables   map[string]string
@@ -75,7 +97,9 @@ type Metadata struct {
 	DERPMap              *tailcfg.DERPMap  `json:"derpmap"`
 	EnvironmentVariables map[string]string `json:"environment_variables"`
 	StartupScript        string            `json:"startup_script"`
+	ShutdownScript       string            `json:"shutdown_script"`
 	Directory            string            `json:"directory"`
+	SessionRecording     bool              `json:"session_recording"`
 }
 
 type WebRTCDialer func(ctx context.Context, logger slog.Logger) (*peerbroker.Listener, error)
@@ -92,6 +116,7 @@ func New(options Options) io.Closer {
 		options.ReconnectingPTYTimeout = 5 * time.Minute
 	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
+	lifecycleCtx, lifecycleCancel := context.WithCancel(context.Background())
 	server := &agent{
 		webrtcDialer:           options.WebRTCDialer,
 		reconnectingPTYTimeout: options.ReconnectingPTYTimeout,
@@ -102,7 +127,20 @@ func New(options Options) io.Closer {
 		coordinatorDialer:      options.CoordinatorDialer,
 		fetchMetadata:          options.FetchMetadata,
 		stats:                  &Stats{},
+		resources:              newResourceSampler(),
 		statsReporter:          options.StatsReporter,
+		sendStartupLogs:        options.SendStartupLogs,
+		reportLifecycle:        options.ReportLifecycle,
+		uploadSessionRecording: options.UploadSessionRecording,
+		reportConnection:       options.ReportConnection,
+		startupScriptTimeout:   options.StartupScriptTimeout,
+		lifecycleCancel:        lifecycleCancel,
+		lifecycleState:         LifecycleStateCreated,
+		lifecycleUpdate:        make(chan struct{}, 1),
+		lifecycleReported:      make(chan struct{}),
+	}
+	if server.reportLifecycle != nil {
+		go server.reportLifecycleLoop(lifecycleCtx)
 	}
 	server.init(ctx)
 	return server
@@ -129,7 +167,22 @@ type agent struct {
 	network           *tailnet.Conn
 	coordinatorDialer CoordinatorDialer
 	stats             *Stats
+	resources         *resourceSampler
 	statsReporter     StatsReporter
+	sendStartupLogs   SendStartupLogs
+	// uploadSessionRecording is nil when sessions aren't recorded.
+	uploadSessionRecording UploadSessionRecording
+	// reportConnection is nil when connections aren't reported.
+	reportConnection ReportConnection
+
+	startupScriptTimeout time.Duration
+	reportLifecycle      ReportLifecycle
+	lifecycleCancel      context.CancelFunc
+	lifecycleMutex       sync.Mutex
+	lifecycleState       LifecycleState
+	lifecycleUpdate      chan struct{}
+	// lifecycleReported is closed when the reporter exits.
+	lifecycleReported chan struct{}
 }
 
 func (a *agent) run(ctx context.Context) {
@@ -161,14 +214,36 @@ func (a *agent) run(ctx context.Context) {
 	a.metadata.Store(metadata)
 
 	// The startup script has not ran yet!
+	a.setLifecycle(ctx, LifecycleStateStarting)
 	go func() {
-		err := a.runStartupScript(ctx, metadata.StartupScript)
+		done := make(chan error, 1)
+		go func() {
+			done <- a.runStartupScript(ctx, metadata.StartupScript)
+		}()
+
+		var timeout <-chan time.Time
+		if a.startupScriptTimeout > 0 {
+			timer := time.NewTimer(a.startupScriptTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		var err error
+		select {
+		case err = <-done:
+		case <-timeout:
+			a.logger.Warn(ctx, "startup script timed out", slog.F("timeout", a.startupScriptTimeout))
+			a.setLifecycle(ctx, LifecycleStateStartTimeout)
+			err = <-done
+		}
 		if errors.Is(err, context.Canceled) {
 			return
 		}
 		if err != nil {
 			a.logger.Warn(ctx, "agent script failed", slog.Error(err))
+			a.setLifecycle(ctx, LifecycleStateStartError)
+			return
 		}
+		a.setLifecycle(ctx, LifecycleStateReady)
 	}()
 
 	if a.webrtcDialer != nil {
@@ -204,7 +279,11 @@ func (a *agent) runTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) {
 			// If a listener already exists, we would double-wrap the conn.
 			return conn
 		}
-		return a.stats.wrapConn(conn)
+		target := ""
+		if addr, ok := conn.LocalAddr().(*net.TCPAddr); ok {
+			target = net.JoinHostPort("localhost", strconv.Itoa(addr.Port))
+		}
+		return a.trackConnection(ctx, ConnectionTypePortForward, target, a.stats.wrapConn(conn))
 	})
 	go a.runCoordinator(ctx)
 
@@ -219,7 +298,7 @@ func (a *agent) runTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) {
 			if err != nil {
 				return
 			}
-			go a.sshServer.HandleConn(a.stats.wrapConn(conn))
+			go a.sshServer.HandleConn(a.trackConnection(ctx, ConnectionTypeSSH, "", a.stats.wrapConn(conn)))
 		}
 	}()
 	reconnectingPTYListener, err := a.network.Listen("tcp", ":"+strconv.Itoa(tailnetReconnectingPTYPort))
@@ -256,6 +335,28 @@ func (a *agent) runTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) {
 			go a.handleReconnectingPTY(ctx, msg, conn)
 		}
 	}()
+	apiListener, err := a.network.Listen("tcp", ":"+strconv.Itoa(tailnetAPIPort))
+	if err != nil {
+		a.logger.Critical(ctx, "listen for api", slog.Error(err))
+		return
+	}
+	go func() {
+		_ = a.apiServer().Serve(apiListener)
+	}()
+	execListener, err := a.network.Listen("tcp", ":"+strconv.Itoa(tailnetExecPort))
+	if err != nil {
+		a.logger.Critical(ctx, "listen for exec", slog.Error(err))
+		return
+	}
+	go func() {
+		for {
+			conn, err := execListener.Accept()
+			if err != nil {
+				return
+			}
+			go a.handleExec(ctx, a.stats.wrapConn(conn))
+		}
+	}()
 }
 
 // runCoordinator listens for nodes and updates the self-node as it changes.
@@ -359,12 +460,21 @@ func (a *agent) runStartupScript(ctx context.Context, script string) error {
 		_ = writer.Close()
 	}()
 
+	var output io.Writer = writer
+	if a.sendStartupLogs != nil {
+		logWriter := newStartupLogWriter(ctx, a.logger, a.sendStartupLogs)
+		defer func() {
+			_ = logWriter.Close()
+		}()
+		output = io.MultiWriter(writer, logWriter)
+	}
+
 	cmd, err := a.createCommand(ctx, script, nil)
 	if err != nil {
 		return xerrors.Errorf("create command: %w", err)
 	}
-	cmd.Stdout = writer
-	cmd.Stderr = writer
+	cmd.Stdout = output
+	cmd.Stderr = output
 	err = cmd.Run()
 	if err != nil {
 		// cmd.Run does not return a context canceled error, it returns "signal: killed".
@@ -401,7 +511,7 @@ func (a *agent) handlePeerConn(ctx context.Context, peerConn *peer.Conn) {
 
 		switch channel.Protocol() {
 		case ProtocolSSH:
-			go a.sshServer.HandleConn(a.stats.wrapConn(conn))
+			go a.sshServer.HandleConn(a.trackConnection(ctx, ConnectionTypeSSH, "", a.stats.wrapConn(conn)))
 		case ProtocolReconnectingPTY:
 			rawID := channel.Label()
 			// The ID format is referenced in conn.go.
@@ -436,7 +546,11 @@ func (a *agent) handlePeerConn(ctx context.Context, peerConn *peer.Conn) {
 				Command: idParts[3],
 			}, a.stats.wrapConn(conn))
 		case ProtocolDial:
-			go a.handleDial(ctx, channel.Label(), a.stats.wrapConn(conn))
+			go a.handleDial(ctx, channel.Label(), a.trackConnection(ctx, ConnectionTypePortForward, channel.Label(), a.stats.wrapConn(conn)))
+		case ProtocolAPI:
+			go a.handleAPIConn(conn)
+		case ProtocolExec:
+			go a.handleExec(ctx, a.stats.wrapConn(conn))
 		default:
 			a.logger.Warn(ctx, "unhandled protocol from channel",
 				slog.F("protocol", channel.Protocol()),
@@ -470,7 +584,7 @@ func (a *agent) init(ctx context.Context) {
 			sshLogger.Info(ctx, "ssh connection ended", slog.Error(err))
 		},
 		Handler: func(session ssh.Session) {
-			err := a.handleSSHSession(session)
+			err := a.handleSSHSession(ctx, session)
 			var exitError *exec.ExitError
 			if xerrors.As(err, &exitError) {
 				a.logger.Debug(ctx, "ssh session returned", slog.Error(exitError))
@@ -526,13 +640,22 @@ func (a *agent) init(ctx context.Context) {
 				}
 				a.logger.Debug(session.Context(), "sftp server exited with error", slog.Error(err))
 			},
+			ShutdownScriptSubsystem: a.handleShutdownScriptSession,
 		},
 	}
 
 	go a.run(ctx)
 	if a.statsReporter != nil {
+		// Prime the sampler so the first report includes CPU usage.
+		_, _ = a.resources.sample()
 		cl, err := a.statsReporter(ctx, a.logger, func() *Stats {
-			return a.stats.Copy()
+			stats := a.stats.Copy()
+			resources, err := a.resources.sample()
+			if err != nil {
+				a.logger.Debug(ctx, "sample resource usage", slog.Error(err))
+			}
+			stats.Resources = resources
+			return stats
 		})
 		if err != nil {
 			a.logger.Error(ctx, "report stats", slog.Error(err))
@@ -637,7 +760,7 @@ func (a *agent) createCommand(ctx context.Context, rawCommand string, env []stri
 	return cmd, nil
 }
 
-func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
+func (a *agent) handleSSHSession(ctx context.Context, session ssh.Session) (retErr error) {
 	cmd, err := a.createCommand(session.Context(), session.RawCommand(), session.Environ())
 	if err != nil {
 		return err
@@ -676,21 +799,40 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 		if err != nil {
 			return xerrors.Errorf("resize ptty: %w", err)
 		}
+		var output io.Writer = session
+		recorder := a.startSessionRecording(ctx, SessionRecordingTypeSSH, uint16(sshPty.Window.Width), uint16(sshPty.Window.Height), sshPty.Term)
+		if recorder != nil {
+			defer a.finishSessionRecording(ctx, recorder)
+			output = io.MultiWriter(session, recorder)
+		}
 		go func() {
 			for win := range windowSize {
 				resizeErr := ptty.Resize(uint16(win.Height), uint16(win.Width))
 				if resizeErr != nil {
 					a.logger.Warn(context.Background(), "failed to resize tty", slog.Error(resizeErr))
 				}
+				if recorder != nil {
+					recorder.Resize(uint16(win.Width), uint16(win.Height))
+				}
 			}
 		}()
 		go func() {
 			_, _ = io.Copy(ptty.Input(), session)
 		}()
+		outputDone := make(chan struct{})
 		go func() {
-			_, _ = io.Copy(session, ptty.Output())
+			_, _ = io.Copy(output, ptty.Output())
+			close(outputDone)
 		}()
 		err = process.Wait()
+		if recorder != nil {
+			// Record the last output of the process before the recording
+			// ends.
+			select {
+			case <-outputDone:
+			case <-time.After(time.Second):
+			}
+		}
 		var exitErr *exec.ExitError
 		// ExitErrors just mean the command we run returned a non-zero exit code, which is normal
 		// and not something to be concerned about.  But, if it's something else, we should log it.
@@ -721,6 +863,7 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 }
 
 func (a *agent) handleReconnectingPTY(ctx context.Context, msg reconnectingPTYInit, conn net.Conn) {
+	conn = a.trackConnection(ctx, ConnectionTypeReconnectingPTY, msg.ID, conn)
 	defer conn.Close()
 
 	var rpty *reconnectingPTY
@@ -750,10 +893,12 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg reconnectingPTYIn
 			a.logger.Warn(ctx, "create circular buffer", slog.Error(err))
 			return
 		}
+		recorder := a.startSessionRecording(ctx, SessionRecordingTypeReconnectingPTY, msg.Width, msg.Height, "xterm-256color")
 
 		a.closeMutex.Lock()
 		a.connCloseWait.Add(1)
 		a.closeMutex.Unlock()
+		agentCtx := ctx
 		ctx, cancelFunc := context.WithCancel(ctx)
 		rpty = &reconnectingPTY{
 			activeConns: make(map[string]net.Conn),
@@ -761,6 +906,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg reconnectingPTYIn
 			// Timeouts created with an after func can be reset!
 			timeout:        time.AfterFunc(a.reconnectingPTYTimeout, cancelFunc),
 			circularBuffer: circularBuffer,
+			recorder:       recorder,
 		}
 		a.reconnectingPTYs.Store(msg.ID, rpty)
 		go func() {
@@ -787,6 +933,9 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg reconnectingPTYIn
 					break
 				}
 				part := buffer[:read]
+				if rpty.recorder != nil {
+					_, _ = rpty.recorder.Write(part)
+				}
 				rpty.circularBufferMutex.Lock()
 				_, err = rpty.circularBuffer.Write(part)
 				rpty.circularBufferMutex.Unlock()
@@ -806,6 +955,9 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg reconnectingPTYIn
 			_ = process.Kill()
 			rpty.Close()
 			a.reconnectingPTYs.Delete(msg.ID)
+			if rpty.recorder != nil {
+				a.finishSessionRecording(agentCtx, rpty.recorder)
+			}
 			a.connCloseWait.Done()
 		}()
 	}
@@ -883,6 +1035,9 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg reconnectingPTYIn
 			// We can continue after this, it's not fatal!
 			a.logger.Error(ctx, "resize reconnecting pty", slog.F("id", msg.ID), slog.Error(err))
 		}
+		if rpty.recorder != nil {
+			rpty.recorder.Resize(req.Width, req.Height)
+		}
 	}
 }
 
@@ -966,6 +1121,9 @@ func (a *agent) Close() error {
 	if a.isClosed() {
 		return nil
 	}
+	ctx := context.Background()
+	a.setLifecycle(ctx, LifecycleStateShuttingDown)
+
 	close(a.closed)
 	a.closeCancel()
 	if a.network != nil {
@@ -973,6 +1131,16 @@ func (a *agent) Close() error {
 	}
 	_ = a.sshServer.Close()
 	a.connCloseWait.Wait()
+
+	a.setLifecycle(ctx, LifecycleStateOff)
+	if a.reportLifecycle != nil {
+		select {
+		case <-a.lifecycleReported:
+		case <-time.After(lifecycleReportTimeout):
+			a.logger.Warn(ctx, "timed out reporting lifecycle state")
+		}
+	}
+	a.lifecycleCancel()
 	return nil
 }
 
@@ -984,6 +1152,8 @@ type reconnectingPTY struct {
 	circularBufferMutex sync.RWMutex
 	timeout             *time.Timer
 	ptty                pty.PTY
+	// recorder is nil when the session isn't recorded.
+	recorder *sessionRecorder
 }
 
 // Close ends all connections to the reconnecting
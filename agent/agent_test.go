@@ -2,6 +2,7 @@ package agent_test
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -65,10 +66,28 @@ func TestAgent(t *testing.T) {
 					conn, stats := setupAgent(t, agent.Metadata{
 						DERPMap: derpMap,
 					}, 0)
-					assert.Empty(t, <-stats)
+					initial := <-stats
+					assert.Zero(t, initial.NumConns)
+					assert.Zero(t, initial.RxBytes)
+					assert.Zero(t, initial.TxBytes)
 					return conn, stats
 				}
 
+				t.Run("Resources", func(t *testing.T) {
+					t.Parallel()
+					if runtime.GOOS != "linux" {
+						t.Skip("Resource usage is only supported on Linux.")
+					}
+					_, stats := setupAgent(t)
+
+					s := <-stats
+					require.NotNil(t, s.Resources)
+					assert.Greater(t, s.Resources.CPUTotalCores, float64(0))
+					assert.Greater(t, s.Resources.MemoryUsedBytes, int64(0))
+					assert.GreaterOrEqual(t, s.Resources.MemoryTotalBytes, s.Resources.MemoryUsedBytes)
+					assert.Greater(t, s.Resources.DiskTotalBytes, int64(0))
+				})
+
 				t.Run("SSH", func(t *testing.T) {
 					t.Parallel()
 					conn, stats := setupAgent(t)
@@ -374,6 +393,140 @@ func TestAgent(t *testing.T) {
 		require.Equal(t, content, strings.TrimSpace(gotContent))
 	})
 
+	t.Run("ShutdownScript", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("The exit code syntax is specific to POSIX shells.")
+		}
+		session := setupSSHSession(t, agent.Metadata{
+			ShutdownScript: "echo saving; exit 3",
+		})
+		var output bytes.Buffer
+		session.Stdout = &output
+		err := session.RequestSubsystem(agent.ShutdownScriptSubsystem)
+		require.NoError(t, err)
+		err = session.Wait()
+		exitErr := &ssh.ExitError{}
+		require.True(t, xerrors.As(err, &exitErr))
+		require.Equal(t, 3, exitErr.ExitStatus())
+		require.Equal(t, "saving", strings.TrimSpace(output.String()))
+	})
+
+	t.Run("ListeningPorts", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS != "linux" {
+			t.Skip("Listening ports are only supported on Linux.")
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		tcpAddr, valid := listener.Addr().(*net.TCPAddr)
+		require.True(t, valid)
+
+		hasPort := func(t *testing.T, conn agent.Conn) {
+			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+			defer cancel()
+			res, err := conn.ListeningPorts(ctx)
+			require.NoError(t, err)
+			for _, port := range res.Ports {
+				if int(port.Port) == tcpAddr.Port {
+					require.Equal(t, "tcp", port.Network)
+					return
+				}
+			}
+			t.Fatalf("port %d not found in %+v", tcpAddr.Port, res.Ports)
+		}
+
+		t.Run("WebRTC", func(t *testing.T) {
+			t.Parallel()
+			conn, _ := setupAgent(t, agent.Metadata{}, 0)
+			hasPort(t, conn)
+		})
+
+		t.Run("Tailnet", func(t *testing.T) {
+			t.Parallel()
+			conn, _ := setupAgent(t, agent.Metadata{
+				DERPMap: tailnettest.RunDERPAndSTUN(t),
+			}, 0)
+			require.Eventually(t, func() bool {
+				_, err := conn.Ping()
+				return err == nil
+			}, testutil.WaitMedium, testutil.IntervalFast)
+			hasPort(t, conn)
+		})
+	})
+
+	t.Run("Exec", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("The commands are written for a POSIX shell.")
+		}
+		dir := t.TempDir()
+
+		run := func(t *testing.T, conn agent.Conn) {
+			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+			defer cancel()
+
+			var stdout, stderr bytes.Buffer
+			exitCode, err := conn.Exec(ctx, agent.ExecRequest{
+				Command: "echo $EXEC_VAR; echo error >&2; pwd; exit 3",
+				Env:     []string{"EXEC_VAR=value"},
+				Dir:     dir,
+			}, &stdout, &stderr)
+			require.NoError(t, err)
+			require.Equal(t, 3, exitCode)
+			require.Equal(t, "value\n"+dir+"\n", stdout.String())
+			require.Equal(t, "error\n", stderr.String())
+
+			stdout.Reset()
+			exitCode, err = conn.Exec(ctx, agent.ExecRequest{
+				Command: "head -c 100000 /dev/zero",
+			}, &stdout, io.Discard)
+			require.NoError(t, err)
+			require.Equal(t, 0, exitCode)
+			require.Equal(t, 100000, stdout.Len())
+
+			stdout.Reset()
+			exitCode, err = conn.Exec(ctx, agent.ExecRequest{
+				Args: []string{"printf", "%s\n", "two words", "it's", "$EXEC_VAR", ""},
+				Env:  []string{"EXEC_VAR=value"},
+			}, &stdout, io.Discard)
+			require.NoError(t, err)
+			require.Equal(t, 0, exitCode)
+			require.Equal(t, "two words\nit's\n$EXEC_VAR\n\n", stdout.String())
+
+			exitCode, err = conn.Exec(ctx, agent.ExecRequest{
+				Command: "kill -KILL $$",
+			}, io.Discard, io.Discard)
+			require.NoError(t, err)
+			require.Equal(t, 137, exitCode)
+
+			_, err = conn.Exec(ctx, agent.ExecRequest{
+				Command: "true",
+				Dir:     filepath.Join(dir, "missing"),
+			}, io.Discard, io.Discard)
+			require.Error(t, err)
+		}
+
+		t.Run("WebRTC", func(t *testing.T) {
+			t.Parallel()
+			conn, _ := setupAgent(t, agent.Metadata{}, 0)
+			run(t, conn)
+		})
+
+		t.Run("Tailnet", func(t *testing.T) {
+			t.Parallel()
+			conn, _ := setupAgent(t, agent.Metadata{
+				DERPMap: tailnettest.RunDERPAndSTUN(t),
+			}, 0)
+			require.Eventually(t, func() bool {
+				_, err := conn.Ping()
+				return err == nil
+			}, testutil.WaitMedium, testutil.IntervalFast)
+			run(t, conn)
+		})
+	})
+
 	t.Run("ReconnectingPTY", func(t *testing.T) {
 		t.Parallel()
 		if runtime.GOOS == "windows" {
@@ -599,6 +752,75 @@ func setupSSHSession(t *testing.T, options agent.Metadata) *ssh.Session {
 	return session
 }
 
+func TestAgentLifecycle(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, script string, timeout time.Duration) (io.Closer, func() []agent.LifecycleState) {
+		t.Helper()
+		var (
+			mu     sync.Mutex
+			states []agent.LifecycleState
+		)
+		closer := agent.New(agent.Options{
+			FetchMetadata: func(ctx context.Context) (agent.Metadata, error) {
+				return agent.Metadata{StartupScript: script}, nil
+			},
+			Logger: slogtest.Make(t, nil).Leveled(slog.LevelDebug),
+			ReportLifecycle: func(ctx context.Context, state agent.LifecycleState) error {
+				mu.Lock()
+				defer mu.Unlock()
+				states = append(states, state)
+				return nil
+			},
+			StartupScriptTimeout: timeout,
+		})
+		t.Cleanup(func() {
+			_ = closer.Close()
+		})
+		return closer, func() []agent.LifecycleState {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]agent.LifecycleState{}, states...)
+		}
+	}
+	lastState := func(states []agent.LifecycleState) agent.LifecycleState {
+		if len(states) == 0 {
+			return ""
+		}
+		return states[len(states)-1]
+	}
+
+	t.Run("Ready", func(t *testing.T) {
+		t.Parallel()
+		closer, states := setup(t, "echo hello", 0)
+		require.Eventually(t, func() bool {
+			return lastState(states()) == agent.LifecycleStateReady
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		require.NoError(t, closer.Close())
+		require.Equal(t, agent.LifecycleStateOff, lastState(states()))
+	})
+
+	t.Run("StartError", func(t *testing.T) {
+		t.Parallel()
+		_, states := setup(t, "exit 1", 0)
+		require.Eventually(t, func() bool {
+			return lastState(states()) == agent.LifecycleStateStartError
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("StartTimeout", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("sleep isn't available on Windows")
+		}
+		_, states := setup(t, "sleep 30", time.Millisecond)
+		require.Eventually(t, func() bool {
+			return lastState(states()) == agent.LifecycleStateStartTimeout
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+}
+
 type closeFunc func() error
 
 func (c closeFunc) Close() error {
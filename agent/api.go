@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// apiServer returns a server for the HTTP API of the agent.
+func (*agent) apiServer() *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v0/listening-ports", func(rw http.ResponseWriter, r *http.Request) {
+		ports, err := listeningPorts()
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(ListeningPortsResponse{Ports: ports})
+	})
+	return &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 20 * time.Second,
+	}
+}
+
+// handleAPIConn serves the HTTP API on a single connection, e.g. a WebRTC
+// channel.
+func (a *agent) handleAPIConn(conn net.Conn) {
+	// Serve returns after the connection is accepted, but the connection
+	// is served until the client closes it.
+	_ = a.apiServer().Serve(&singleConnListener{conn: conn})
+}
+
+// singleConnListener is a net.Listener that accepts a single connection.
+type singleConnListener struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil, io.EOF
+	}
+	conn := l.conn
+	l.conn = nil
+	return conn, nil
+}
+
+func (*singleConnListener) Close() error {
+	return nil
+}
+
+func (l *singleConnListener) Addr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return &net.TCPAddr{}
+	}
+	return l.conn.LocalAddr()
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"net/netip"
 	"net/url"
 	"strconv"
@@ -41,6 +42,8 @@ type Conn interface {
 	SSH() (net.Conn, error)
 	SSHClient() (*ssh.Client, error)
 	DialContext(ctx context.Context, network string, addr string) (net.Conn, error)
+	ListeningPorts(ctx context.Context) (ListeningPortsResponse, error)
+	Exec(ctx context.Context, req ExecRequest, stdout, stderr io.Writer) (int, error)
 }
 
 // Conn wraps a peer connection with helper functions to
@@ -132,6 +135,32 @@ func (c *WebRTCConn) DialContext(ctx context.Context, network string, addr strin
 	return channel.NetConn(), nil
 }
 
+// ListeningPorts returns the TCP ports that processes in the workspace are
+// listening on.
+func (c *WebRTCConn) ListeningPorts(ctx context.Context) (ListeningPortsResponse, error) {
+	return requestListeningPorts(ctx, func(ctx context.Context) (net.Conn, error) {
+		channel, err := c.CreateChannel(ctx, "api", &peer.ChannelOptions{
+			Protocol: ProtocolAPI,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("create datachannel: %w", err)
+		}
+		return channel.NetConn(), nil
+	})
+}
+
+// Exec runs a command in the workspace, copying its output to stdout and
+// stderr, and returns its exit code once it exits.
+func (c *WebRTCConn) Exec(ctx context.Context, req ExecRequest, stdout, stderr io.Writer) (int, error) {
+	channel, err := c.CreateChannel(ctx, "exec", &peer.ChannelOptions{
+		Protocol: ProtocolExec,
+	})
+	if err != nil {
+		return 0, xerrors.Errorf("create datachannel: %w", err)
+	}
+	return runExecRequest(ctx, channel.NetConn(), req, stdout, stderr)
+}
+
 func (c *WebRTCConn) Close() error {
 	_ = c.Negotiator.DRPCConn().Close()
 	return c.Conn.Close()
@@ -236,3 +265,50 @@ func (c *TailnetConn) DialContext(ctx context.Context, network string, addr stri
 	}
 	return c.Conn.DialContextTCP(ctx, ipp)
 }
+
+// ListeningPorts returns the TCP ports that processes in the workspace are
+// listening on.
+func (c *TailnetConn) ListeningPorts(ctx context.Context) (ListeningPortsResponse, error) {
+	return requestListeningPorts(ctx, func(ctx context.Context) (net.Conn, error) {
+		return c.DialContextTCP(ctx, netip.AddrPortFrom(tailnetIP, uint16(tailnetAPIPort)))
+	})
+}
+
+// Exec runs a command in the workspace, copying its output to stdout and
+// stderr, and returns its exit code once it exits.
+func (c *TailnetConn) Exec(ctx context.Context, req ExecRequest, stdout, stderr io.Writer) (int, error) {
+	conn, err := c.DialContextTCP(ctx, netip.AddrPortFrom(tailnetIP, uint16(tailnetExecPort)))
+	if err != nil {
+		return 0, xerrors.Errorf("dial: %w", err)
+	}
+	return runExecRequest(ctx, conn, req, stdout, stderr)
+}
+
+// requestListeningPorts requests the listening ports from the HTTP API of the
+// agent over a connection returned by dial.
+func requestListeningPorts(ctx context.Context, dial func(ctx context.Context) (net.Conn, error)) (ListeningPortsResponse, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dial(ctx)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+	// The host is ignored, since the connection is dialed directly.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://agent/api/v0/listening-ports", nil)
+	if err != nil {
+		return ListeningPortsResponse{}, xerrors.Errorf("create request: %w", err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return ListeningPortsResponse{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return ListeningPortsResponse{}, xerrors.Errorf("unexpected status code %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var resp ListeningPortsResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cdr.dev/slog"
+	"github.com/coder/retry"
+)
+
+// ConnectionType is the kind of connection made to the agent.
+type ConnectionType string
+
+const (
+	ConnectionTypeSSH             ConnectionType = "ssh"
+	ConnectionTypeReconnectingPTY ConnectionType = "reconnecting_pty"
+	ConnectionTypePortForward     ConnectionType = "port_forward"
+)
+
+// Connection describes a connection made to the agent. It's reported once
+// when the connection is opened, and again with the bytes transferred when
+// it's closed.
+type Connection struct {
+	ID         uuid.UUID      `json:"id"`
+	Type       ConnectionType `json:"type"`
+	RemoteAddr string         `json:"remote_addr"`
+	// Target is the forwarded address of port forwards, or the ID of
+	// reconnecting PTYs.
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"started_at"`
+	// EndedAt is zero while the connection is open.
+	EndedAt time.Time `json:"ended_at"`
+	RxBytes int64     `json:"rx_bytes"`
+	TxBytes int64     `json:"tx_bytes"`
+}
+
+// ReportConnection reports a connection to coderd.
+type ReportConnection func(ctx context.Context, connection Connection) error
+
+// trackedConn counts the bytes transferred over a connection and signals
+// when it's closed.
+type trackedConn struct {
+	net.Conn
+	rxBytes int64
+	txBytes int64
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (c *trackedConn) Read(b []byte) (n int, err error) {
+	n, err = c.Conn.Read(b)
+	atomic.AddInt64(&c.rxBytes, int64(n))
+	return n, err
+}
+
+func (c *trackedConn) Write(b []byte) (n int, err error) {
+	n, err = c.Conn.Write(b)
+	atomic.AddInt64(&c.txBytes, int64(n))
+	return n, err
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return c.Conn.Close()
+}
+
+// trackConnection reports conn to coderd when it's opened and when it's
+// closed. It returns conn unchanged when connections aren't reported.
+func (a *agent) trackConnection(ctx context.Context, typ ConnectionType, target string, conn net.Conn) net.Conn {
+	if a.reportConnection == nil {
+		return conn
+	}
+	tracked := &trackedConn{
+		Conn:   conn,
+		closed: make(chan struct{}),
+	}
+	connection := Connection{
+		ID:        uuid.New(),
+		Type:      typ,
+		Target:    target,
+		StartedAt: time.Now(),
+	}
+	if addr := conn.RemoteAddr(); addr != nil {
+		connection.RemoteAddr = addr.String()
+	}
+	go func() {
+		a.sendConnection(ctx, connection)
+		select {
+		case <-tracked.closed:
+		case <-ctx.Done():
+		}
+		connection.EndedAt = time.Now()
+		connection.RxBytes = atomic.LoadInt64(&tracked.rxBytes)
+		connection.TxBytes = atomic.LoadInt64(&tracked.txBytes)
+		// The agent may be closing, so the end of the connection is sent
+		// with a fresh context.
+		sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		a.sendConnection(sendCtx, connection)
+	}()
+	return tracked
+}
+
+// sendConnection reports a connection, retrying until it succeeds or ctx is
+// done.
+func (a *agent) sendConnection(ctx context.Context, connection Connection) {
+	for retrier := retry.New(50*time.Millisecond, 30*time.Second); retrier.Wait(ctx); {
+		err := a.reportConnection(ctx, connection)
+		if err == nil {
+			return
+		}
+		a.logger.Warn(ctx, "report connection", slog.F("id", connection.ID), slog.F("type", connection.Type), slog.Error(err))
+	}
+}
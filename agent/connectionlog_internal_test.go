@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTrackConnection(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	reports := make(chan Connection, 2)
+	a := &agent{
+		logger: slogtest.Make(t, nil),
+		reportConnection: func(_ context.Context, connection Connection) error {
+			reports <- connection
+			return nil
+		},
+	}
+	client, server := net.Pipe()
+	defer client.Close()
+	conn := a.trackConnection(ctx, ConnectionTypeSSH, "", server)
+
+	var started Connection
+	select {
+	case started = <-reports:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the start of the connection")
+	}
+	require.Equal(t, ConnectionTypeSSH, started.Type)
+	require.True(t, started.EndedAt.IsZero())
+
+	go func() {
+		_, _ = client.Write([]byte("hello"))
+		_, _ = io.ReadFull(client, make([]byte, 2))
+	}()
+	_, err := io.ReadFull(conn, make([]byte, 5))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("hi"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	var ended Connection
+	select {
+	case ended = <-reports:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the end of the connection")
+	}
+	require.Equal(t, started.ID, ended.ID)
+	require.False(t, ended.EndedAt.IsZero())
+	require.EqualValues(t, 5, ended.RxBytes)
+	require.EqualValues(t, 2, ended.TxBytes)
+}
@@ -0,0 +1,239 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// ExecRequest is sent by the client to run a command with the exec
+// protocol. Unlike commands run over SSH, output is streamed back without
+// a PTY, with stdout and stderr kept apart, and the exit code is reported
+// as is.
+type ExecRequest struct {
+	// Command is run with the user's shell, like commands run over SSH.
+	Command string `json:"command"`
+	// Args are quoted for the shell and appended to Command, so each one is
+	// passed to the command as a single argument.
+	Args []string `json:"args,omitempty"`
+	// Env is a list of "KEY=value" pairs that override the environment of
+	// the command.
+	Env []string `json:"env,omitempty"`
+	// Dir overrides the working directory of the command, which defaults to
+	// the directory of the agent. A leading "~" expands to the home
+	// directory.
+	Dir string `json:"dir,omitempty"`
+}
+
+type execMessageType string
+
+const (
+	execMessageStdout execMessageType = "stdout"
+	execMessageStderr execMessageType = "stderr"
+	execMessageExit   execMessageType = "exit"
+)
+
+// execMessage is sent by the agent for each chunk of output, and once
+// when the command exits.
+type execMessage struct {
+	Type execMessageType `json:"type"`
+	Data []byte          `json:"data,omitempty"`
+	// ExitCode is set on the exit message. Commands killed by a signal
+	// report 128 plus the signal number, like shells do.
+	ExitCode int `json:"exit_code,omitempty"`
+	// Error is set on the exit message when the command couldn't be run,
+	// as opposed to exiting with a non-zero code.
+	Error string `json:"error,omitempty"`
+}
+
+// execMaxChunkSize keeps messages below the maximum size of a WebRTC
+// message once the output is base64 encoded.
+const execMaxChunkSize = 16 * 1024
+
+// execWriter writes messages to an exec connection. It's safe for
+// concurrent use, since stdout and stderr are copied concurrently.
+type execWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func (w *execWriter) write(msg execMessage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.encoder.Encode(msg)
+}
+
+// stream returns a writer that sends output of the type.
+func (w *execWriter) stream(typ execMessageType) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		for written := 0; written < len(p); {
+			end := written + execMaxChunkSize
+			if end > len(p) {
+				end = len(p)
+			}
+			err := w.write(execMessage{Type: typ, Data: p[written:end]})
+			if err != nil {
+				return written, err
+			}
+			written = end
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// handleExec runs the command requested on the connection. Closing the
+// connection stops the command.
+func (a *agent) handleExec(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	var req ExecRequest
+	err := json.NewDecoder(conn).Decode(&req)
+	if err != nil {
+		a.logger.Debug(ctx, "read exec request", slog.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// Nothing else is sent by the client, so this returns when the
+		// connection is closed.
+		_, _ = io.Copy(io.Discard, conn)
+		cancel()
+	}()
+
+	writer := &execWriter{encoder: json.NewEncoder(conn)}
+	exitCode, err := a.runExec(ctx, req, writer)
+	msg := execMessage{
+		Type:     execMessageExit,
+		ExitCode: exitCode,
+	}
+	if err != nil {
+		a.logger.Debug(ctx, "exec failed", slog.F("command", req.Command), slog.F("args", req.Args), slog.Error(err))
+		msg.Error = err.Error()
+	}
+	err = writer.write(msg)
+	if err != nil {
+		a.logger.Debug(ctx, "write exec exit", slog.Error(err))
+	}
+}
+
+// runExec runs the command of the request, returning its exit code.
+func (a *agent) runExec(ctx context.Context, req ExecRequest, writer *execWriter) (int, error) {
+	command := req.Command
+	for _, arg := range req.Args {
+		if command != "" {
+			command += " "
+		}
+		command += shellQuote(arg)
+	}
+	if command == "" {
+		return 0, xerrors.New("a command is required")
+	}
+	cmd, err := a.createCommand(ctx, command, nil)
+	if err != nil {
+		return 0, xerrors.Errorf("create command: %w", err)
+	}
+	// Later values take precedence, so these override the environment set
+	// by the agent.
+	cmd.Env = append(cmd.Env, req.Env...)
+	if req.Dir != "" {
+		cmd.Dir, err = ExpandRelativeHomePath(req.Dir)
+		if err != nil {
+			return 0, xerrors.Errorf("expand directory %q: %w", req.Dir, err)
+		}
+	}
+	cmd.Stdout = writer.stream(execMessageStdout)
+	cmd.Stderr = writer.stream(execMessageStderr)
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if xerrors.As(err, &exitErr) {
+		// ExitCode is -1 for commands killed by a signal.
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal()), nil
+		}
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, xerrors.Errorf("run command: %w", err)
+	}
+	return 0, nil
+}
+
+// shellQuote quotes arg so the shell of createCommand passes it to the
+// command as is.
+func shellQuote(arg string) string {
+	if runtime.GOOS == "windows" {
+		if arg != "" && !strings.ContainsAny(arg, " \t\"") {
+			return arg
+		}
+		return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+	}
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%_+=:,./-") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+}
+
+// runExecRequest sends the request over conn and copies the output of the
+// command to stdout and stderr until it exits.
+func runExecRequest(ctx context.Context, conn net.Conn, req ExecRequest, stdout, stderr io.Writer) (int, error) {
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// The agent stops the command when the connection is closed.
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	err := json.NewEncoder(conn).Encode(req)
+	if err != nil {
+		return 0, xerrors.Errorf("write request: %w", err)
+	}
+	decoder := json.NewDecoder(conn)
+	for {
+		var msg execMessage
+		err := decoder.Decode(&msg)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			return 0, xerrors.Errorf("read message: %w", err)
+		}
+		switch msg.Type {
+		case execMessageStdout:
+			_, err = stdout.Write(msg.Data)
+		case execMessageStderr:
+			_, err = stderr.Write(msg.Data)
+		case execMessageExit:
+			if msg.Error != "" {
+				return 0, xerrors.New(msg.Error)
+			}
+			return msg.ExitCode, nil
+		}
+		if err != nil {
+			return 0, xerrors.Errorf("write output: %w", err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"cdr.dev/slog"
+	"github.com/coder/retry"
+)
+
+// LifecycleState is the stage an agent is in, from starting up to shutting
+// down. The agent reports changes to coderd.
+type LifecycleState string
+
+const (
+	LifecycleStateCreated      LifecycleState = "created"
+	LifecycleStateStarting     LifecycleState = "starting"
+	LifecycleStateStartTimeout LifecycleState = "start_timeout"
+	LifecycleStateStartError   LifecycleState = "start_error"
+	LifecycleStateReady        LifecycleState = "ready"
+	LifecycleStateShuttingDown LifecycleState = "shutting_down"
+	LifecycleStateOff          LifecycleState = "off"
+)
+
+// lifecycleReportTimeout is how long closing the agent waits for the final
+// lifecycle state to be reported.
+const lifecycleReportTimeout = 5 * time.Second
+
+// ReportLifecycle reports the lifecycle state of the agent to coderd.
+type ReportLifecycle func(ctx context.Context, state LifecycleState) error
+
+// setLifecycle changes the lifecycle state and wakes up the reporter. The
+// state can't change once the agent is off.
+func (a *agent) setLifecycle(ctx context.Context, state LifecycleState) {
+	a.lifecycleMutex.Lock()
+	defer a.lifecycleMutex.Unlock()
+	if a.lifecycleState == state || a.lifecycleState == LifecycleStateOff {
+		return
+	}
+	a.logger.Debug(ctx, "lifecycle state changed", slog.F("state", state))
+	a.lifecycleState = state
+	select {
+	case a.lifecycleUpdate <- struct{}{}:
+	default:
+	}
+}
+
+func (a *agent) lifecycle() LifecycleState {
+	a.lifecycleMutex.Lock()
+	defer a.lifecycleMutex.Unlock()
+	return a.lifecycleState
+}
+
+// reportLifecycleLoop reports the latest lifecycle state whenever it changes,
+// retrying on failure. It returns once the agent is off and that has been
+// reported.
+func (a *agent) reportLifecycleLoop(ctx context.Context) {
+	defer close(a.lifecycleReported)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.lifecycleUpdate:
+		}
+
+		// Changes that happen while reporting leave a pending update, so
+		// intermediate states may be skipped but the latest is always sent.
+		for r := retry.New(time.Second, 15*time.Second); r.Wait(ctx); {
+			state := a.lifecycle()
+			err := a.reportLifecycle(ctx, state)
+			if err != nil {
+				a.logger.Warn(ctx, "report lifecycle state", slog.F("state", state), slog.Error(err))
+				continue
+			}
+			if state == LifecycleStateOff {
+				return
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// ListeningPort is a TCP port that a process in the workspace is listening
+// on.
+type ListeningPort struct {
+	Network string `json:"network"`
+	Port    uint16 `json:"port"`
+	// ProcessName is empty when the process can't be determined, e.g.
+	// because it's owned by another user.
+	ProcessName string `json:"process_name,omitempty"`
+}
+
+// ListeningPortsResponse is returned by the listening ports endpoint of the
+// agent.
+type ListeningPortsResponse struct {
+	Ports []ListeningPort `json:"ports"`
+}
+
+// tcpListenState is the state of a listening socket in /proc/net/tcp.
+const tcpListenState = "0A"
+
+// procNetTCPEntry is a listening socket parsed from /proc/net/tcp.
+type procNetTCPEntry struct {
+	Port  uint16
+	Inode uint64
+}
+
+// parseProcNetTCP returns the listening sockets from the contents of
+// /proc/net/tcp or /proc/net/tcp6.
+func parseProcNetTCP(r io.Reader) ([]procNetTCPEntry, error) {
+	var entries []procNetTCPEntry
+	scanner := bufio.NewScanner(r)
+	// The first line is a header.
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		if fields[3] != tcpListenState {
+			continue
+		}
+		// The local address is formatted as <hex ip>:<hex port>.
+		_, rawPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			return nil, xerrors.Errorf("invalid local address %q", fields[1])
+		}
+		portBytes, err := hex.DecodeString(rawPort)
+		if err != nil || len(portBytes) != 2 {
+			return nil, xerrors.Errorf("invalid port %q", rawPort)
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid inode %q: %w", fields[9], err)
+		}
+		entries = append(entries, procNetTCPEntry{
+			Port:  uint16(portBytes[0])<<8 | uint16(portBytes[1]),
+			Inode: inode,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("scan: %w", err)
+	}
+	return entries, nil
+}
+
+// sortListeningPorts removes duplicate ports, which are common when a process
+// listens on both IPv4 and IPv6, and sorts them by port.
+func sortListeningPorts(ports []ListeningPort) []ListeningPort {
+	seen := make(map[uint16]struct{}, len(ports))
+	deduped := make([]ListeningPort, 0, len(ports))
+	for _, port := range ports {
+		if _, ok := seen[port.Port]; ok {
+			continue
+		}
+		seen[port.Port] = struct{}{}
+		deduped = append(deduped, port)
+	}
+	sort.Slice(deduped, func(i, j int) bool {
+		return deduped[i].Port < deduped[j].Port
+	})
+	return deduped
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcNetTCP(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Listening", func(t *testing.T) {
+		t.Parallel()
+		// Sockets in the listen state (0A) are returned, the established
+		// connection (01) is not.
+		entries, err := parseProcNetTCP(strings.NewReader(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41226 1 0000000000000000 100 0 0 10 0
+   1: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 20571 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:1F90 0100007F:D4C2 01 00000000:00000000 00:00000000 00000000  1000        0 41227 1 0000000000000000 20 4 30 10 -1
+`))
+		require.NoError(t, err)
+		require.Equal(t, []procNetTCPEntry{
+			{Port: 8080, Inode: 41226},
+			{Port: 22, Inode: 20571},
+		}, entries)
+	})
+
+	t.Run("InvalidPort", func(t *testing.T) {
+		t.Parallel()
+		_, err := parseProcNetTCP(strings.NewReader(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:ZZ 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 41226 1 0000000000000000 100 0 0 10 0
+`))
+		require.Error(t, err)
+	})
+}
+
+func TestSortListeningPorts(t *testing.T) {
+	t.Parallel()
+	ports := sortListeningPorts([]ListeningPort{
+		{Network: "tcp", Port: 8080, ProcessName: "node"},
+		{Network: "tcp", Port: 22},
+		{Network: "tcp", Port: 8080},
+	})
+	require.Equal(t, []ListeningPort{
+		{Network: "tcp", Port: 22},
+		{Network: "tcp", Port: 8080, ProcessName: "node"},
+	}, ports)
+}
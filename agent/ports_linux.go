@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// listeningPorts returns the TCP ports that processes in the workspace are
+// listening on.
+func listeningPorts() ([]ListeningPort, error) {
+	var entries []procNetTCPEntry
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(path)
+		if os.IsNotExist(err) {
+			// IPv6 may be disabled.
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("open %s: %w", path, err)
+		}
+		parsed, err := parseProcNetTCP(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("parse %s: %w", path, err)
+		}
+		entries = append(entries, parsed...)
+	}
+
+	processNames := socketProcessNames()
+	ports := make([]ListeningPort, 0, len(entries))
+	for _, entry := range entries {
+		ports = append(ports, ListeningPort{
+			Network:     "tcp",
+			Port:        entry.Port,
+			ProcessName: processNames[entry.Inode],
+		})
+	}
+	return sortListeningPorts(ports), nil
+}
+
+// socketProcessNames maps socket inodes to the name of the process that
+// holds them. Processes that can't be inspected are skipped.
+func socketProcessNames() map[uint64]string {
+	names := map[uint64]string{}
+	fdDirs, _ := filepath.Glob("/proc/[0-9]*/fd")
+	for _, fdDir := range fdDirs {
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		var name string
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if name == "" {
+				comm, err := os.ReadFile(filepath.Join(filepath.Dir(fdDir), "comm"))
+				if err != nil {
+					break
+				}
+				name = strings.TrimSpace(string(comm))
+			}
+			names[inode] = name
+		}
+	}
+	return names
+}
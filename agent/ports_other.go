@@ -0,0 +1,9 @@
+//go:build !linux
+
+package agent
+
+import "golang.org/x/xerrors"
+
+func listeningPorts() ([]ListeningPort, error) {
+	return nil, xerrors.New("listening ports are only supported on Linux")
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"sync"
+	"time"
+)
+
+// ResourceStats is a sample of the resource usage of the workspace. Usage
+// is read from the cgroup of the agent when it's limited, and from the host
+// otherwise.
+type ResourceStats struct {
+	// CPUUsedCores is the average number of CPU cores used since the
+	// previous sample.
+	CPUUsedCores float64 `json:"cpu_used_cores"`
+	// CPUTotalCores is the CPU limit of the cgroup, or the number of cores
+	// of the host if it isn't limited.
+	CPUTotalCores    float64 `json:"cpu_total_cores"`
+	MemoryUsedBytes  int64   `json:"memory_used_bytes"`
+	MemoryTotalBytes int64   `json:"memory_total_bytes"`
+	// DiskUsedBytes and DiskTotalBytes are the usage of the filesystem of
+	// the home directory.
+	DiskUsedBytes  int64 `json:"disk_used_bytes"`
+	DiskTotalBytes int64 `json:"disk_total_bytes"`
+}
+
+// resourceUsage is a reading of resource usage. CPUTime is cumulative, so
+// CPU usage is computed from the difference between two readings.
+type resourceUsage struct {
+	CPUTime          time.Duration
+	CPUTotalCores    float64
+	MemoryUsedBytes  int64
+	MemoryTotalBytes int64
+	DiskUsedBytes    int64
+	DiskTotalBytes   int64
+}
+
+// resourceSampler turns successive readings of resource usage into samples.
+type resourceSampler struct {
+	read func() (resourceUsage, error)
+	now  func() time.Time
+
+	mu          sync.Mutex
+	lastCPUTime time.Duration
+	lastRead    time.Time
+}
+
+func newResourceSampler() *resourceSampler {
+	return &resourceSampler{
+		read: readResourceUsage,
+		now:  time.Now,
+	}
+}
+
+// sample reads the current resource usage. CPU usage is zero for the first
+// sample, since there's nothing to compare it to.
+func (s *resourceSampler) sample() (*ResourceStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	stats := &ResourceStats{
+		CPUTotalCores:    usage.CPUTotalCores,
+		MemoryUsedBytes:  usage.MemoryUsedBytes,
+		MemoryTotalBytes: usage.MemoryTotalBytes,
+		DiskUsedBytes:    usage.DiskUsedBytes,
+		DiskTotalBytes:   usage.DiskTotalBytes,
+	}
+	elapsed := now.Sub(s.lastRead)
+	// The CPU time goes backwards if the agent moves to another cgroup.
+	if !s.lastRead.IsZero() && elapsed > 0 && usage.CPUTime >= s.lastCPUTime {
+		stats.CPUUsedCores = float64(usage.CPUTime-s.lastCPUTime) / float64(elapsed)
+	}
+	s.lastCPUTime = usage.CPUTime
+	s.lastRead = now
+	return stats, nil
+}
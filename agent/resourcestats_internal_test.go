@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceSampler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now   = time.Now()
+		usage = resourceUsage{
+			CPUTotalCores:    4,
+			MemoryUsedBytes:  1024,
+			MemoryTotalBytes: 4096,
+		}
+	)
+	sampler := &resourceSampler{
+		read: func() (resourceUsage, error) {
+			return usage, nil
+		},
+		now: func() time.Time {
+			return now
+		},
+	}
+
+	// There's nothing to compare the first sample to.
+	stats, err := sampler.sample()
+	require.NoError(t, err)
+	require.Equal(t, &ResourceStats{
+		CPUTotalCores:    4,
+		MemoryUsedBytes:  1024,
+		MemoryTotalBytes: 4096,
+	}, stats)
+
+	// Three seconds of CPU time in two seconds is one and a half cores.
+	now = now.Add(2 * time.Second)
+	usage.CPUTime += 3 * time.Second
+	stats, err = sampler.sample()
+	require.NoError(t, err)
+	require.Equal(t, 1.5, stats.CPUUsedCores)
+
+	// CPU time going backwards is ignored.
+	now = now.Add(2 * time.Second)
+	usage.CPUTime = time.Second
+	stats, err = sampler.sample()
+	require.NoError(t, err)
+	require.Zero(t, stats.CPUUsedCores)
+}
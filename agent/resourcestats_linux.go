@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// userHZ is the unit of the CPU times in /proc/stat. It's 100 on every
+// architecture Linux supports.
+const userHZ = 100
+
+// readResourceUsage reads the resource usage of the workspace.
+func readResourceUsage() (resourceUsage, error) {
+	usage, err := readCPUAndMemoryUsage("/sys/fs/cgroup", "/proc")
+	if err != nil {
+		return resourceUsage{}, err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return resourceUsage{}, xerrors.Errorf("get home directory: %w", err)
+	}
+	var statfs syscall.Statfs_t
+	err = syscall.Statfs(home, &statfs)
+	if err != nil {
+		return resourceUsage{}, xerrors.Errorf("statfs %s: %w", home, err)
+	}
+	usage.DiskTotalBytes = int64(statfs.Blocks) * int64(statfs.Bsize)
+	usage.DiskUsedBytes = int64(statfs.Blocks-statfs.Bfree) * int64(statfs.Bsize)
+	return usage, nil
+}
+
+// readCPUAndMemoryUsage reads CPU and memory usage from the cgroup mounted
+// at cgroupRoot, falling back to the host-wide usage in procRoot for
+// anything the cgroup doesn't limit or account for.
+func readCPUAndMemoryUsage(cgroupRoot, procRoot string) (resourceUsage, error) {
+	memInfo, err := readKeyValues(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return resourceUsage{}, xerrors.Errorf("read meminfo: %w", err)
+	}
+	// Values in /proc/meminfo are in kB.
+	memTotal := memInfo["MemTotal"] * 1024
+	usage := resourceUsage{
+		CPUTotalCores:    float64(runtime.NumCPU()),
+		MemoryTotalBytes: memTotal,
+		MemoryUsedBytes:  memTotal - memInfo["MemAvailable"]*1024,
+	}
+
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		readCgroupV2Usage(cgroupRoot, &usage)
+	} else {
+		readCgroupV1Usage(cgroupRoot, &usage)
+	}
+
+	if usage.CPUTime == 0 {
+		usage.CPUTime, err = readProcStatCPUTime(filepath.Join(procRoot, "stat"))
+		if err != nil {
+			return resourceUsage{}, xerrors.Errorf("read cpu time: %w", err)
+		}
+	}
+	return usage, nil
+}
+
+func readCgroupV2Usage(root string, usage *resourceUsage) {
+	cpuStat, err := readKeyValues(filepath.Join(root, "cpu.stat"))
+	if err == nil {
+		usage.CPUTime = time.Duration(cpuStat["usage_usec"]) * time.Microsecond
+	}
+	// cpu.max is formatted as "<quota> <period>", where quota is "max" when
+	// the CPU isn't limited.
+	cpuMax, err := os.ReadFile(filepath.Join(root, "cpu.max"))
+	if err == nil {
+		fields := strings.Fields(string(cpuMax))
+		if len(fields) == 2 {
+			setCPUQuota(usage, fields[0], fields[1])
+		}
+	}
+	if current, err := readInt(filepath.Join(root, "memory.current")); err == nil {
+		usage.MemoryUsedBytes = current
+	}
+	// memory.max is "max" when memory isn't limited, which fails to parse.
+	if limit, err := readInt(filepath.Join(root, "memory.max")); err == nil && limit < usage.MemoryTotalBytes {
+		usage.MemoryTotalBytes = limit
+	}
+}
+
+func readCgroupV1Usage(root string, usage *resourceUsage) {
+	// The controllers are mounted separately or together depending on the
+	// distribution.
+	for _, dir := range []string{"cpuacct", "cpu,cpuacct"} {
+		if cpuTime, err := readInt(filepath.Join(root, dir, "cpuacct.usage")); err == nil {
+			usage.CPUTime = time.Duration(cpuTime)
+			break
+		}
+	}
+	for _, dir := range []string{"cpu", "cpu,cpuacct"} {
+		quota, err := os.ReadFile(filepath.Join(root, dir, "cpu.cfs_quota_us"))
+		if err != nil {
+			continue
+		}
+		period, err := os.ReadFile(filepath.Join(root, dir, "cpu.cfs_period_us"))
+		if err != nil {
+			continue
+		}
+		setCPUQuota(usage, strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+		break
+	}
+	if current, err := readInt(filepath.Join(root, "memory", "memory.usage_in_bytes")); err == nil {
+		usage.MemoryUsedBytes = current
+	}
+	// The limit is a very large number when memory isn't limited.
+	if limit, err := readInt(filepath.Join(root, "memory", "memory.limit_in_bytes")); err == nil && limit < usage.MemoryTotalBytes {
+		usage.MemoryTotalBytes = limit
+	}
+}
+
+// setCPUQuota sets the CPU limit from a CFS quota and period. Quotas that
+// don't limit the CPU, like "max" or "-1", are ignored.
+func setCPUQuota(usage *resourceUsage, rawQuota, rawPeriod string) {
+	quota, err := strconv.ParseFloat(rawQuota, 64)
+	if err != nil || quota <= 0 {
+		return
+	}
+	period, err := strconv.ParseFloat(rawPeriod, 64)
+	if err != nil || period <= 0 {
+		return
+	}
+	usage.CPUTotalCores = quota / period
+}
+
+// readProcStatCPUTime returns the time the host's CPUs spent busy from the
+// contents of /proc/stat.
+func readProcStatCPUTime(path string) (time.Duration, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[0] != "cpu" {
+			continue
+		}
+		// The columns are user, nice, system, idle, iowait, irq, softirq
+		// and optionally steal. Idle and iowait aren't busy.
+		var busy int64
+		for i, field := range fields[1:] {
+			if i == 3 || i == 4 || i > 7 {
+				continue
+			}
+			value, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return 0, xerrors.Errorf("parse %q: %w", field, err)
+			}
+			busy += value
+		}
+		return time.Duration(busy) * time.Second / userHZ, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, xerrors.New("cpu line not found")
+}
+
+// readKeyValues reads a file of "<key> <value>" lines, like cpu.stat or
+// /proc/meminfo. Keys may end with a colon, and units are ignored.
+func readKeyValues(path string) (map[string]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	values := map[string]int64{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	return values, scanner.Err()
+}
+
+func readInt(path string) (int64, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+}
@@ -0,0 +1,98 @@
+//go:build linux
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCPUAndMemoryUsage(t *testing.T) {
+	t.Parallel()
+
+	writeFiles := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		}
+		return dir
+	}
+	procRoot := writeFiles(t, map[string]string{
+		"meminfo": "MemTotal:       8192 kB\nMemFree:        1024 kB\nMemAvailable:   6144 kB\n",
+		"stat":    "cpu  100 20 30 1000 50 5 5 10 0 0\ncpu0 100 20 30 1000 50 5 5 10 0 0\n",
+	})
+
+	t.Run("Host", func(t *testing.T) {
+		t.Parallel()
+		usage, err := readCPUAndMemoryUsage(writeFiles(t, nil), procRoot)
+		require.NoError(t, err)
+		require.Equal(t, resourceUsage{
+			// user + nice + system + irq + softirq + steal jiffies.
+			CPUTime:          170 * time.Second / userHZ,
+			CPUTotalCores:    float64(runtime.NumCPU()),
+			MemoryUsedBytes:  2048 * 1024,
+			MemoryTotalBytes: 8192 * 1024,
+		}, usage)
+	})
+
+	t.Run("CgroupV1", func(t *testing.T) {
+		t.Parallel()
+		cgroupRoot := writeFiles(t, map[string]string{
+			"cpu,cpuacct/cpuacct.usage":     "5000000000\n",
+			"cpu,cpuacct/cpu.cfs_quota_us":  "150000\n",
+			"cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+			"memory/memory.usage_in_bytes":  "1048576\n",
+			"memory/memory.limit_in_bytes":  "2097152\n",
+		})
+		usage, err := readCPUAndMemoryUsage(cgroupRoot, procRoot)
+		require.NoError(t, err)
+		require.Equal(t, resourceUsage{
+			CPUTime:          5 * time.Second,
+			CPUTotalCores:    1.5,
+			MemoryUsedBytes:  1048576,
+			MemoryTotalBytes: 2097152,
+		}, usage)
+	})
+
+	t.Run("CgroupV1Unlimited", func(t *testing.T) {
+		t.Parallel()
+		cgroupRoot := writeFiles(t, map[string]string{
+			"cpuacct/cpuacct.usage":        "5000000000\n",
+			"cpu/cpu.cfs_quota_us":         "-1\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.usage_in_bytes": "1048576\n",
+			"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		})
+		usage, err := readCPUAndMemoryUsage(cgroupRoot, procRoot)
+		require.NoError(t, err)
+		require.Equal(t, float64(runtime.NumCPU()), usage.CPUTotalCores)
+		require.EqualValues(t, 8192*1024, usage.MemoryTotalBytes)
+	})
+
+	t.Run("CgroupV2", func(t *testing.T) {
+		t.Parallel()
+		cgroupRoot := writeFiles(t, map[string]string{
+			"cgroup.controllers": "cpu memory\n",
+			"cpu.stat":           "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n",
+			"cpu.max":            "200000 100000\n",
+			"memory.current":     "1048576\n",
+			"memory.max":         "max\n",
+		})
+		usage, err := readCPUAndMemoryUsage(cgroupRoot, procRoot)
+		require.NoError(t, err)
+		require.Equal(t, resourceUsage{
+			CPUTime:          2500 * time.Millisecond,
+			CPUTotalCores:    2,
+			MemoryUsedBytes:  1048576,
+			MemoryTotalBytes: 8192 * 1024,
+		}, usage)
+	})
+}
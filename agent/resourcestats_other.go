@@ -0,0 +1,9 @@
+//go:build !linux
+
+package agent
+
+import "golang.org/x/xerrors"
+
+func readResourceUsage() (resourceUsage, error) {
+	return resourceUsage{}, xerrors.New("resource usage is only supported on Linux")
+}
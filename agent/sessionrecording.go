@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/retry"
+)
+
+// SessionRecordingType is the kind of session that was recorded.
+type SessionRecordingType string
+
+const (
+	SessionRecordingTypeSSH             SessionRecordingType = "ssh"
+	SessionRecordingTypeReconnectingPTY SessionRecordingType = "reconnecting_pty"
+)
+
+// SessionRecording is a recording of an interactive terminal session in the
+// asciicast v2 format.
+type SessionRecording struct {
+	Type      SessionRecordingType
+	StartedAt time.Time
+	EndedAt   time.Time
+	Data      []byte
+}
+
+// UploadSessionRecording uploads a session recording to coderd.
+type UploadSessionRecording func(ctx context.Context, recording SessionRecording) error
+
+// maxSessionRecordingSize is the size after which output is no longer
+// recorded. It matches the maximum size coderd accepts.
+const maxSessionRecordingSize = 100 << 20
+
+// asciicastHeader is the first line of an asciicast v2 recording.
+// See https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// sessionRecorder records the output of a terminal to a temporary file. The
+// file is removed once the recording is closed.
+type sessionRecorder struct {
+	typ       SessionRecordingType
+	startedAt time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+	// pending holds an incomplete UTF-8 sequence at the end of the last
+	// write, since events must be valid strings.
+	pending []byte
+	err     error
+}
+
+func newSessionRecorder(typ SessionRecordingType, width, height uint16, term string) (*sessionRecorder, error) {
+	file, err := os.CreateTemp("", "coder-session-*.cast")
+	if err != nil {
+		return nil, xerrors.Errorf("create recording file: %w", err)
+	}
+	r := &sessionRecorder{
+		typ:       typ,
+		startedAt: time.Now(),
+		file:      file,
+		writer:    bufio.NewWriter(file),
+	}
+	header := asciicastHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.startedAt.Unix(),
+	}
+	if term != "" {
+		header.Env = map[string]string{"TERM": term}
+	}
+	r.writeLine(header)
+	if r.err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, xerrors.Errorf("write header: %w", r.err)
+	}
+	return r, nil
+}
+
+// Write records output of the terminal. It never fails, so the session
+// isn't interrupted when recording fails.
+func (r *sessionRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data := append(r.pending, p...)
+	// Hold back an incomplete UTF-8 sequence until the rest of it is
+	// written.
+	end := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[end:]...)
+	if end > 0 {
+		r.event("o", string(data[:end]))
+	}
+	return len(p), nil
+}
+
+// Resize records a change of the terminal size.
+func (r *sessionRecorder) Resize(width, height uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+func (r *sessionRecorder) event(code, data string) {
+	elapsed := time.Since(r.startedAt).Seconds()
+	r.writeLine([]interface{}{elapsed, code, data})
+}
+
+func (r *sessionRecorder) writeLine(v interface{}) {
+	if r.err != nil || r.size >= maxSessionRecordingSize {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.err = err
+		return
+	}
+	n, err := r.writer.Write(append(data, '\n'))
+	r.size += int64(n)
+	if err != nil {
+		r.err = err
+	}
+}
+
+// Close ends the recording and returns it.
+func (r *sessionRecorder) Close() (SessionRecording, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer func() {
+		_ = r.file.Close()
+		_ = os.Remove(r.file.Name())
+	}()
+	if len(r.pending) > 0 {
+		r.event("o", string(r.pending))
+		r.pending = nil
+	}
+	if r.err != nil {
+		return SessionRecording{}, r.err
+	}
+	err := r.writer.Flush()
+	if err != nil {
+		return SessionRecording{}, xerrors.Errorf("flush: %w", err)
+	}
+	data, err := os.ReadFile(r.file.Name())
+	if err != nil {
+		return SessionRecording{}, xerrors.Errorf("read recording: %w", err)
+	}
+	return SessionRecording{
+		Type:      r.typ,
+		StartedAt: r.startedAt,
+		EndedAt:   time.Now(),
+		Data:      data,
+	}, nil
+}
+
+// startSessionRecording returns a recorder for an interactive session when
+// the template enables session recording, or nil otherwise.
+func (a *agent) startSessionRecording(ctx context.Context, typ SessionRecordingType, width, height uint16, term string) *sessionRecorder {
+	if a.uploadSessionRecording == nil {
+		return nil
+	}
+	metadata, valid := a.metadata.Load().(Metadata)
+	if !valid || !metadata.SessionRecording {
+		return nil
+	}
+	recorder, err := newSessionRecorder(typ, width, height, term)
+	if err != nil {
+		a.logger.Error(ctx, "start session recording", slog.Error(err))
+		return nil
+	}
+	return recorder
+}
+
+// finishSessionRecording closes the recorder and uploads the recording in
+// the background, retrying until it succeeds or the agent is closed.
+func (a *agent) finishSessionRecording(ctx context.Context, recorder *sessionRecorder) {
+	recording, err := recorder.Close()
+	if err != nil {
+		a.logger.Error(ctx, "close session recording", slog.Error(err))
+		return
+	}
+	go func() {
+		for retrier := retry.New(time.Second, 30*time.Second); retrier.Wait(ctx); {
+			err := a.uploadSessionRecording(ctx, recording)
+			if err == nil {
+				return
+			}
+			a.logger.Warn(ctx, "upload session recording", slog.F("type", recording.Type), slog.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRecorder(t *testing.T) {
+	t.Parallel()
+
+	// readEvents returns the header and the events of a recording.
+	readEvents := func(t *testing.T, data []byte) (asciicastHeader, [][]interface{}) {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		require.True(t, scanner.Scan())
+		var header asciicastHeader
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+		var events [][]interface{}
+		for scanner.Scan() {
+			var event []interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+			require.Len(t, event, 3)
+			events = append(events, event)
+		}
+		require.NoError(t, scanner.Err())
+		return header, events
+	}
+
+	t.Run("Events", func(t *testing.T) {
+		t.Parallel()
+		recorder, err := newSessionRecorder(SessionRecordingTypeSSH, 80, 24, "xterm-256color")
+		require.NoError(t, err)
+		path := recorder.file.Name()
+
+		_, err = recorder.Write([]byte("hello\r\n"))
+		require.NoError(t, err)
+		recorder.Resize(120, 40)
+		_, err = recorder.Write([]byte("world"))
+		require.NoError(t, err)
+
+		recording, err := recorder.Close()
+		require.NoError(t, err)
+		require.Equal(t, SessionRecordingTypeSSH, recording.Type)
+		require.False(t, recording.EndedAt.Before(recording.StartedAt))
+		_, err = os.Stat(path)
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		header, events := readEvents(t, recording.Data)
+		require.Equal(t, 2, header.Version)
+		require.Equal(t, uint16(80), header.Width)
+		require.Equal(t, uint16(24), header.Height)
+		require.Equal(t, "xterm-256color", header.Env["TERM"])
+		require.Len(t, events, 3)
+		require.Equal(t, []interface{}{"o", "hello\r\n"}, events[0][1:])
+		require.Equal(t, []interface{}{"r", "120x40"}, events[1][1:])
+		require.Equal(t, []interface{}{"o", "world"}, events[2][1:])
+	})
+
+	t.Run("SplitRune", func(t *testing.T) {
+		t.Parallel()
+		recorder, err := newSessionRecorder(SessionRecordingTypeReconnectingPTY, 80, 24, "")
+		require.NoError(t, err)
+
+		// "é" is two bytes, which are written separately.
+		data := []byte("café")
+		_, err = recorder.Write(data[:4])
+		require.NoError(t, err)
+		_, err = recorder.Write(data[4:])
+		require.NoError(t, err)
+
+		recording, err := recorder.Close()
+		require.NoError(t, err)
+		header, events := readEvents(t, recording.Data)
+		require.Empty(t, header.Env)
+		require.Len(t, events, 2)
+		require.Equal(t, "caf", events[0][2])
+		require.Equal(t, "é", events[1][2])
+	})
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// ShutdownScriptSubsystem is the SSH subsystem coderd requests to run the
+// shutdown script before the workspace is stopped or deleted. Output is
+// written to the session, and the session exits with the exit code of the
+// script.
+const ShutdownScriptSubsystem = "coder-shutdown-script"
+
+func (a *agent) handleShutdownScriptSession(session ssh.Session) {
+	ctx := session.Context()
+	var script string
+	if metadata, valid := a.metadata.Load().(Metadata); valid {
+		script = metadata.ShutdownScript
+	}
+
+	a.logger.Info(ctx, "running shutdown script")
+	err := a.runShutdownScript(ctx, script, session)
+	var exitErr *exec.ExitError
+	if xerrors.As(err, &exitErr) {
+		a.logger.Warn(ctx, "shutdown script failed", slog.F("exit_code", exitErr.ExitCode()))
+		_ = session.Exit(exitErr.ExitCode())
+		return
+	}
+	if err != nil {
+		a.logger.Warn(ctx, "shutdown script failed", slog.Error(err))
+		_, _ = fmt.Fprintf(session.Stderr(), "%s\n", err)
+		_ = session.Exit(MagicSessionErrorCode)
+		return
+	}
+	_ = session.Exit(0)
+}
+
+func (a *agent) runShutdownScript(ctx context.Context, script string, output io.Writer) error {
+	if script == "" {
+		return nil
+	}
+
+	writer, err := os.OpenFile(filepath.Join(os.TempDir(), "coder-shutdown-script.log"), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return xerrors.Errorf("open shutdown script log file: %w", err)
+	}
+	defer func() {
+		_ = writer.Close()
+	}()
+	output = io.MultiWriter(writer, output)
+
+	cmd, err := a.createCommand(ctx, script, nil)
+	if err != nil {
+		return xerrors.Errorf("create command: %w", err)
+	}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err = cmd.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return xerrors.Errorf("run: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"cdr.dev/slog"
+)
+
+const (
+	// maxStartupLogLength matches the length of the output column in the
+	// database. Longer lines are split.
+	maxStartupLogLength = 1024
+	// maxStartupLogQueue is the number of lines kept while coderd is
+	// unreachable. New lines are dropped when it's exceeded.
+	maxStartupLogQueue  = 10000
+	startupLogBatchSize = 100
+	startupLogInterval  = 250 * time.Millisecond
+)
+
+// StartupLog is a line of output from the startup script.
+type StartupLog struct {
+	CreatedAt time.Time `json:"created_at"`
+	Output    string    `json:"output"`
+}
+
+// SendStartupLogs sends lines of startup script output to coderd.
+type SendStartupLogs func(ctx context.Context, logs []StartupLog) error
+
+// startupLogWriter splits startup script output into lines and sends them
+// to coderd in batches.
+type startupLogWriter struct {
+	send   SendStartupLogs
+	logger slog.Logger
+
+	mu      sync.Mutex
+	partial []byte
+	queue   []StartupLog
+
+	flush     chan struct{}
+	closed    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newStartupLogWriter(ctx context.Context, logger slog.Logger, send SendStartupLogs) *startupLogWriter {
+	w := &startupLogWriter{
+		send:   send,
+		logger: logger,
+		flush:  make(chan struct{}, 1),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run(ctx)
+	return w
+}
+
+func (w *startupLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.enqueueLocked(w.partial[:i])
+		w.partial = w.partial[i+1:]
+	}
+	for len(w.partial) >= maxStartupLogLength {
+		w.enqueueLocked(w.partial[:maxStartupLogLength])
+		w.partial = w.partial[maxStartupLogLength:]
+	}
+	if len(w.queue) >= startupLogBatchSize {
+		select {
+		case w.flush <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+func (w *startupLogWriter) enqueueLocked(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	now := time.Now()
+	for {
+		if len(w.queue) >= maxStartupLogQueue {
+			return
+		}
+		n := len(line)
+		if n > maxStartupLogLength {
+			n = maxStartupLogLength
+		}
+		w.queue = append(w.queue, StartupLog{
+			CreatedAt: now,
+			// Postgres rejects text that isn't valid UTF-8.
+			Output: strings.ToValidUTF8(string(line[:n]), "\uFFFD"),
+		})
+		line = line[n:]
+		if len(line) == 0 {
+			return
+		}
+	}
+}
+
+// Close sends any remaining output and waits for it to be delivered.
+func (w *startupLogWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		if len(w.partial) > 0 {
+			w.enqueueLocked(w.partial)
+			w.partial = nil
+		}
+		w.mu.Unlock()
+		close(w.closed)
+	})
+	<-w.done
+	return nil
+}
+
+func (w *startupLogWriter) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(startupLogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.closed:
+			// Give the final batch a chance to be delivered, even if the
+			// agent is shutting down.
+			sendCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			err := w.sendQueued(sendCtx)
+			cancel()
+			if err != nil {
+				w.logger.Warn(ctx, "send startup logs", slog.Error(err))
+			}
+			return
+		case <-w.flush:
+		case <-ticker.C:
+		}
+
+		err := w.sendQueued(ctx)
+		if err != nil {
+			// The logs remain queued and are retried on the next tick.
+			w.logger.Debug(ctx, "send startup logs", slog.Error(err))
+		}
+	}
+}
+
+// sendQueued sends queued logs until the queue is empty or sending fails.
+func (w *startupLogWriter) sendQueued(ctx context.Context) error {
+	for {
+		w.mu.Lock()
+		n := len(w.queue)
+		if n > startupLogBatchSize {
+			n = startupLogBatchSize
+		}
+		batch := make([]StartupLog, n)
+		copy(batch, w.queue)
+		w.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := w.send(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		// Lines are only appended, so the batch is still at the head of the
+		// queue.
+		w.mu.Lock()
+		w.queue = w.queue[len(batch):]
+		w.mu.Unlock()
+	}
+}
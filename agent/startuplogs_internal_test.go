@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/testutil"
+)
+
+func TestStartupLogWriter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Lines", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		var (
+			mu   sync.Mutex
+			sent []string
+		)
+		w := newStartupLogWriter(ctx, slogtest.Make(t, nil), func(ctx context.Context, logs []StartupLog) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, log := range logs {
+				sent = append(sent, log.Output)
+			}
+			return nil
+		})
+		_, err := w.Write([]byte("hello\r\nwor"))
+		require.NoError(t, err)
+		_, err = w.Write([]byte("ld\n\xffpartial"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		require.Equal(t, []string{"hello", "world", "�partial"}, sent)
+	})
+
+	t.Run("LongLine", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		var sent []string
+		w := newStartupLogWriter(ctx, slogtest.Make(t, nil), func(ctx context.Context, logs []StartupLog) error {
+			for _, log := range logs {
+				sent = append(sent, log.Output)
+			}
+			return nil
+		})
+		_, err := w.Write([]byte(strings.Repeat("a", maxStartupLogLength*2+1) + "\n"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		require.Len(t, sent, 3)
+		require.Len(t, sent[0], maxStartupLogLength)
+		require.Len(t, sent[1], maxStartupLogLength)
+		require.Equal(t, "a", sent[2])
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		var (
+			mu       sync.Mutex
+			attempts int
+			sent     []string
+		)
+		w := newStartupLogWriter(ctx, slogtest.Make(t, nil), func(ctx context.Context, logs []StartupLog) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			if attempts == 1 {
+				return context.DeadlineExceeded
+			}
+			for _, log := range logs {
+				sent = append(sent, log.Output)
+			}
+			return nil
+		})
+		_, err := w.Write([]byte("first\n"))
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(sent) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.NoError(t, w.Close())
+		require.Equal(t, []string{"first"}, sent)
+	})
+}
@@ -38,6 +38,9 @@ type Stats struct {
 	NumConns int64 `json:"num_comms"`
 	RxBytes  int64 `json:"rx_bytes"`
 	TxBytes  int64 `json:"tx_bytes"`
+	// Resources is only set on the copies passed to the StatsReporter. It's
+	// nil when resource usage can't be read.
+	Resources *ResourceStats `json:"resources,omitempty"`
 }
 
 func (s *Stats) Copy() *Stats {
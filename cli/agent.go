@@ -33,6 +33,8 @@ func workspaceAgent() *cobra.Command {
 		pprofAddress string
 		noReap       bool
 		wireguard    bool
+
+		startupScriptTimeout time.Duration
 	)
 	cmd := &cobra.Command{
 		Use: "agent",
@@ -191,8 +193,13 @@ func workspaceAgent() *cobra.Command {
 					// shells so "gitssh" works!
 					"CODER_AGENT_TOKEN": client.SessionToken,
 				},
-				CoordinatorDialer: client.ListenWorkspaceAgentTailnet,
-				StatsReporter:     client.AgentReportStats,
+				CoordinatorDialer:      client.ListenWorkspaceAgentTailnet,
+				StatsReporter:          client.AgentReportStats,
+				SendStartupLogs:        client.PatchWorkspaceAgentStartupLogs,
+				ReportLifecycle:        client.PostWorkspaceAgentLifecycle,
+				UploadSessionRecording: client.PostWorkspaceAgentSessionRecording,
+				ReportConnection:       client.PostWorkspaceAgentConnection,
+				StartupScriptTimeout:   startupScriptTimeout,
 			})
 			<-cmd.Context().Done()
 			return closer.Close()
@@ -204,5 +211,6 @@ func workspaceAgent() *cobra.Command {
 	cliflag.BoolVarP(cmd.Flags(), &noReap, "no-reap", "", "", false, "Do not start a process reaper.")
 	cliflag.StringVarP(cmd.Flags(), &pprofAddress, "pprof-address", "", "CODER_AGENT_PPROF_ADDRESS", "127.0.0.1:6060", "The address to serve pprof.")
 	cliflag.BoolVarP(cmd.Flags(), &wireguard, "wireguard", "", "CODER_AGENT_WIREGUARD", true, "Whether to start the Wireguard interface.")
+	cliflag.DurationVarP(cmd.Flags(), &startupScriptTimeout, "startup-script-timeout", "", "CODER_AGENT_STARTUP_SCRIPT_TIMEOUT", 0, "How long the startup script may run before the agent reports that it timed out starting. The script keeps running. Set to 0 to disable.")
 	return cmd
 }
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func auditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Short: "Inspect the audit log",
+		Use:   "audit",
+		Example: formatExamples(
+			example{
+				Description: "List the most recent audit logs",
+				Command:     "coder audit list",
+			},
+			example{
+				Description: "List failed requests made by a user since the start of the month",
+				Command:     "coder audit list --search \"username:alice status_code:500 date_from:2022-10-01\"",
+			},
+		),
+	}
+	cmd.AddCommand(
+		auditList(),
+	)
+	return cmd
+}
+
+type auditLogRow struct {
+	Time         string `table:"time"`
+	User         string `table:"user"`
+	Action       string `table:"action"`
+	ResourceType string `table:"resource type"`
+	Resource     string `table:"resource"`
+	StatusCode   int32  `table:"status code"`
+	IP           string `table:"ip"`
+}
+
+func auditList() *cobra.Command {
+	var (
+		auditColumns = []string{"Time", "User", "Action", "Resource Type", "Resource", "Status Code"}
+		columns      []string
+		outputFormat string
+		searchQuery  string
+		limit        int
+		offset       int
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List audit logs, most recent first",
+		Long: "List audit logs, most recent first. The search query supports the " +
+			"keys action, resource_type, username, date_from, date_to (YYYY-MM-DD) and status_code.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			res, err := client.AuditLogs(cmd.Context(), codersdk.AuditLogsRequest{
+				SearchQuery: searchQuery,
+				Pagination: codersdk.Pagination{
+					Limit:  limit,
+					Offset: offset,
+				},
+			})
+			if err != nil {
+				return xerrors.Errorf("get audit logs: %w", err)
+			}
+
+			out := ""
+			switch outputFormat {
+			case "table", "":
+				out, err = displayAuditLogs(columns, res.AuditLogs)
+				if err != nil {
+					return xerrors.Errorf("render table: %w", err)
+				}
+			case "json":
+				buf := new(bytes.Buffer)
+				enc := json.NewEncoder(buf)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(res)
+				if err != nil {
+					return xerrors.Errorf("marshal audit logs to JSON: %w", err)
+				}
+				out = buf.String()
+			default:
+				return xerrors.Errorf(`unknown output format %q, only "table" and "json" are supported`, outputFormat)
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&columns, "column", "c", auditColumns,
+		fmt.Sprintf("Specify a column to filter in the table. Available columns are: %s, IP",
+			strings.Join(auditColumns, ", ")))
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format. Available formats are: table, json.")
+	cmd.Flags().StringVar(&searchQuery, "search", "", "Filter audit logs with a query.")
+	cmd.Flags().IntVar(&limit, "limit", 25, "Maximum number of audit logs to return. 0 returns all audit logs.")
+	cmd.Flags().IntVar(&offset, "offset", 0, "Number of audit logs to skip.")
+	return cmd
+}
+
+// displayAuditLogs will return a table displaying all audit logs passed in.
+// filterColumns must be a subset of the audit log row fields and will
+// determine which columns to display.
+func displayAuditLogs(filterColumns []string, logs []codersdk.AuditLog) (string, error) {
+	rows := make([]auditLogRow, 0, len(logs))
+	for _, alog := range logs {
+		user := "<unknown>"
+		if alog.User != nil {
+			user = alog.User.Username
+		}
+		rows = append(rows, auditLogRow{
+			Time:         alog.Time.Local().Format(time.Stamp),
+			User:         user,
+			Action:       string(alog.Action),
+			ResourceType: string(alog.ResourceType),
+			Resource:     alog.ResourceTarget,
+			StatusCode:   alog.StatusCode,
+			IP:           alog.IP.String(),
+		})
+	}
+
+	return cliui.DisplayTable(rows, "", filterColumns)
+}
@@ -0,0 +1,82 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestAuditList(t *testing.T) {
+	t.Parallel()
+	t.Run("Table", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db := databasefake.New()
+		client := coderdtest.New(t, &coderdtest.Options{Database: db})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdtest.InsertAuditLog(t, db, database.InsertAuditLogParams{
+			UserID:       user.UserID,
+			Action:       database.AuditActionCreate,
+			ResourceType: database.ResourceTypeWorkspace,
+		})
+
+		cmd, root := clitest.New(t, "audit", "list")
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetOut(pty.Output())
+		errC := make(chan error)
+		go func() {
+			errC <- cmd.ExecuteContext(ctx)
+		}()
+		require.NoError(t, <-errC)
+		pty.ExpectMatch("testuser")
+		pty.ExpectMatch("create")
+		pty.ExpectMatch("workspace")
+	})
+	t.Run("JSON", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db := databasefake.New()
+		client := coderdtest.New(t, &coderdtest.Options{Database: db})
+		user := coderdtest.CreateFirstUser(t, client)
+		for _, action := range []database.AuditAction{database.AuditActionCreate, database.AuditActionDelete} {
+			coderdtest.InsertAuditLog(t, db, database.InsertAuditLogParams{
+				UserID: user.UserID,
+				Action: action,
+			})
+		}
+
+		cmd, root := clitest.New(t, "audit", "list", "--search", "action:delete", "-o", "json")
+		clitest.SetupConfig(t, client, root)
+		buf := bytes.NewBuffer(nil)
+		cmd.SetOut(buf)
+		err := cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+
+		var res codersdk.AuditLogResponse
+		err = json.Unmarshal(buf.Bytes(), &res)
+		require.NoError(t, err, "unmarshal JSON output")
+		assert.EqualValues(t, 1, res.Count)
+		require.Len(t, res.AuditLogs, 1)
+		assert.Equal(t, codersdk.AuditActionDelete, res.AuditLogs[0].Action)
+	})
+}
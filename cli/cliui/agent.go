@@ -20,9 +20,13 @@ type AgentOptions struct {
 	Fetch         func(context.Context) (codersdk.WorkspaceAgent, error)
 	FetchInterval time.Duration
 	WarnInterval  time.Duration
+	// WaitForReady waits until the agent has finished running its startup
+	// script, not only until it has connected.
+	WaitForReady bool
 }
 
 // Agent displays a spinning indicator that waits for a workspace agent to connect.
+// With WaitForReady, it also waits for the startup script to finish.
 func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 	if opts.FetchInterval == 0 {
 		opts.FetchInterval = 500 * time.Millisecond
@@ -35,7 +39,8 @@ func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 	if err != nil {
 		return xerrors.Errorf("fetch: %w", err)
 	}
-	if agent.Status == codersdk.WorkspaceAgentConnected {
+	if agentDone(agent, opts.WaitForReady) {
+		warnLifecycle(writer, opts.WorkspaceName, agent)
 		return nil
 	}
 	if agent.Status == codersdk.WorkspaceAgentDisconnected {
@@ -44,7 +49,7 @@ func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 	spin := spinner.New(spinner.CharSets[78], 100*time.Millisecond, spinner.WithColor("fgHiGreen"))
 	spin.Writer = writer
 	spin.ForceOutput = true
-	spin.Suffix = " Waiting for connection from " + Styles.Field.Render(agent.Name) + "..."
+	spin.Suffix = agentWaitingMessage(agent)
 	spin.Start()
 	defer spin.Stop()
 
@@ -80,6 +85,8 @@ func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 		message := "Don't panic, your workspace is booting up!"
 		if agent.Status == codersdk.WorkspaceAgentDisconnected {
 			message = "The workspace agent lost connection! Wait for it to reconnect or restart your workspace."
+		} else if agent.Status == codersdk.WorkspaceAgentConnected {
+			message = "The startup script is still running. Run " + Styles.Code.Render("coder startup-logs "+opts.WorkspaceName) + " to see its output."
 		}
 		// This saves the cursor position, then defers clearing from the cursor
 		// position to the end of the screen.
@@ -97,11 +104,56 @@ func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 		if err != nil {
 			return xerrors.Errorf("fetch: %w", err)
 		}
-		if agent.Status != codersdk.WorkspaceAgentConnected {
+		if !agentDone(agent, opts.WaitForReady) {
+			spin.Lock()
+			spin.Suffix = agentWaitingMessage(agent)
+			spin.Unlock()
 			resourceMutex.Unlock()
 			continue
 		}
 		resourceMutex.Unlock()
+		spin.Stop()
+		warnLifecycle(writer, opts.WorkspaceName, agent)
 		return nil
 	}
 }
+
+// agentDone returns whether there's no need to wait for the agent any longer.
+func agentDone(agent codersdk.WorkspaceAgent, waitForReady bool) bool {
+	if agent.Status != codersdk.WorkspaceAgentConnected {
+		return false
+	}
+	if !waitForReady {
+		return true
+	}
+	switch agent.LifecycleState {
+	case codersdk.WorkspaceAgentLifecycleCreated, codersdk.WorkspaceAgentLifecycleStarting:
+		return false
+	default:
+		return true
+	}
+}
+
+func agentWaitingMessage(agent codersdk.WorkspaceAgent) string {
+	if agent.Status == codersdk.WorkspaceAgentConnected {
+		return " Waiting for " + Styles.Field.Render(agent.Name) + " to finish running the startup script..."
+	}
+	return " Waiting for connection from " + Styles.Field.Render(agent.Name) + "..."
+}
+
+// warnLifecycle warns when the agent didn't start cleanly, since the
+// workspace may not be fully set up.
+func warnLifecycle(writer io.Writer, workspaceName string, agent codersdk.WorkspaceAgent) {
+	var message string
+	switch agent.LifecycleState {
+	case codersdk.WorkspaceAgentLifecycleStartTimeout:
+		message = "The startup script is taking longer than expected to finish."
+	case codersdk.WorkspaceAgentLifecycleStartError:
+		message = "The startup script exited with an error."
+	default:
+		return
+	}
+	_, _ = fmt.Fprintln(writer, Styles.Warn.Render(Styles.Wrap.Render(
+		message+" Your workspace may be incomplete. Run "+Styles.Code.Render("coder startup-logs "+workspaceName)+" to see its output.",
+	)))
+}
@@ -49,3 +49,42 @@ func TestAgent(t *testing.T) {
 	disconnected.Store(true)
 	<-done
 }
+
+func TestAgent_WaitForReady(t *testing.T) {
+	t.Parallel()
+	var ready atomic.Bool
+	ptty := ptytest.New(t)
+	cmd := &cobra.Command{
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cliui.Agent(cmd.Context(), cmd.OutOrStdout(), cliui.AgentOptions{
+				WorkspaceName: "example",
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					agent := codersdk.WorkspaceAgent{
+						Status:         codersdk.WorkspaceAgentConnected,
+						LifecycleState: codersdk.WorkspaceAgentLifecycleStarting,
+					}
+					if ready.Load() {
+						agent.LifecycleState = codersdk.WorkspaceAgentLifecycleStartError
+					}
+					return agent, nil
+				},
+				FetchInterval: time.Millisecond,
+				WarnInterval:  10 * time.Millisecond,
+				WaitForReady:  true,
+			})
+			return err
+		},
+	}
+	cmd.SetOutput(ptty.Output())
+	cmd.SetIn(ptty.Input())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := cmd.Execute()
+		assert.NoError(t, err)
+	}()
+	ptty.ExpectMatch("startup script is still running")
+	ready.Store(true)
+	ptty.ExpectMatch("exited with an error")
+	<-done
+}
@@ -0,0 +1,86 @@
+package cliui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressInterval limits how often progress is rendered, so fast
+// transfers don't spend their time writing to the terminal.
+const progressInterval = 100 * time.Millisecond
+
+// Progress displays the progress of a transfer on a single line, which is
+// rewritten as bytes are written to it. Write the transferred bytes to it,
+// e.g. with io.TeeReader, and call Done once the transfer finishes.
+type Progress struct {
+	writer io.Writer
+	name   string
+	total  int64
+
+	mu         sync.Mutex
+	current    int64
+	lastRender time.Time
+	lastWidth  int
+}
+
+// NewProgress returns a Progress for a transfer of total bytes, of which
+// offset bytes were already transferred, e.g. when resuming a download.
+func NewProgress(writer io.Writer, name string, offset, total int64) *Progress {
+	return &Progress{
+		writer:  writer,
+		name:    name,
+		total:   total,
+		current: offset,
+	}
+}
+
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current += int64(len(b))
+	if now := time.Now(); now.Sub(p.lastRender) >= progressInterval {
+		p.lastRender = now
+		p.render()
+	}
+	return len(b), nil
+}
+
+// Done renders the final progress and ends the line.
+func (p *Progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.render()
+	_, _ = fmt.Fprintln(p.writer)
+}
+
+func (p *Progress) render() {
+	percent := 100
+	if p.total > 0 {
+		percent = int(p.current * 100 / p.total)
+	}
+	line := fmt.Sprintf("%s  %s / %s  %s", p.name, FormatBytes(p.current), FormatBytes(p.total), Styles.Keyword.Render(fmt.Sprintf("%d%%", percent)))
+	// Pad the line to clear what's left of a longer line rendered before.
+	width := len(line)
+	if width < p.lastWidth {
+		line += strings.Repeat(" ", p.lastWidth-width)
+	}
+	p.lastWidth = width
+	_, _ = fmt.Fprint(p.writer, "\r"+line)
+}
+
+// FormatBytes formats a number of bytes with a binary unit, e.g. "1.5 MiB".
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,53 @@
+package cliui_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/cliui"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+	t.Run("Done", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		progress := cliui.NewProgress(&buf, "file.txt", 1024, 4096)
+		_, err := io.Copy(progress, strings.NewReader(strings.Repeat("a", 3072)))
+		require.NoError(t, err)
+		progress.Done()
+		lines := strings.Split(buf.String(), "\r")
+		last := lines[len(lines)-1]
+		require.Contains(t, last, "file.txt")
+		require.Contains(t, last, "4.0 KiB / 4.0 KiB")
+		require.Contains(t, last, "100%")
+		require.True(t, strings.HasSuffix(last, "\n"))
+	})
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		progress := cliui.NewProgress(&buf, "empty.txt", 0, 0)
+		progress.Done()
+		require.Contains(t, buf.String(), "0 B / 0 B")
+		require.Contains(t, buf.String(), "100%")
+	})
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+	for bytes, expected := range map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KiB",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 30:         "3.0 GiB",
+		1<<40 + 512<<30: "1.5 TiB",
+	} {
+		require.Equal(t, expected, cliui.FormatBytes(bytes))
+	}
+}
@@ -53,6 +53,9 @@ func ProvisionerJob(ctx context.Context, writer io.Writer, opts ProvisionerJobOp
 		currentStage          = "Queued"
 		currentStageStartedAt = time.Now().UTC()
 		didLogBetweenStage    = false
+		// printedQueuePosition is the queue position shown with the
+		// "Queued" stage, or -1 before the stage is printed.
+		printedQueuePosition = -1
 
 		errChan  = make(chan error, 1)
 		job      codersdk.ProvisionerJob
@@ -60,14 +63,21 @@ func ProvisionerJob(ctx context.Context, writer io.Writer, opts ProvisionerJobOp
 	)
 
 	printStage := func() {
-		_, _ = fmt.Fprintf(writer, Styles.Prompt.Render("⧗")+"%s\n", Styles.Field.Render(currentStage))
+		position := ""
+		if currentStage == "Queued" {
+			printedQueuePosition = job.QueuePosition
+			if job.QueuePosition > 0 {
+				position = Styles.Placeholder.Render(fmt.Sprintf(" (position %d of %d)", job.QueuePosition, job.QueueSize))
+			}
+		}
+		_, _ = fmt.Fprintf(writer, Styles.Prompt.Render("⧗")+"%s%s\n", Styles.Field.Render(currentStage), position)
 	}
 
 	updateStage := func(stage string, startedAt time.Time) {
 		if currentStage != "" {
 			prefix := ""
 			if !didLogBetweenStage {
-				prefix = "\033[1A\r"
+				prefix = "\033[1A\r\033[2K"
 			}
 			mark := Styles.Checkmark
 			if job.CompletedAt != nil && job.Status != codersdk.ProvisionerJobSucceeded {
@@ -94,6 +104,13 @@ func ProvisionerJob(ctx context.Context, writer io.Writer, opts ProvisionerJobOp
 			return
 		}
 		if job.StartedAt == nil {
+			// Reprint the queue position as jobs ahead of this one are
+			// picked up, unless logs were written below it.
+			if currentStage == "Queued" && printedQueuePosition != -1 && job.CompletedAt == nil &&
+				job.QueuePosition != printedQueuePosition && !didLogBetweenStage {
+				_, _ = fmt.Fprint(writer, "\033[1A\r\033[2K")
+				printStage()
+			}
 			return
 		}
 		if currentStage != "Queued" {
@@ -130,7 +147,9 @@ func ProvisionerJob(ctx context.Context, writer io.Writer, opts ProvisionerJobOp
 	}
 
 	// The initial stage needs to print after the signal handler has been registered.
+	jobMutex.Lock()
 	printStage()
+	jobMutex.Unlock()
 
 	logs, err := opts.Logs()
 	if err != nil {
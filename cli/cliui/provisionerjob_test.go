@@ -78,6 +78,38 @@ func TestProvisionerJob(t *testing.T) {
 		test.PTY.ExpectMatch("Something")
 	})
 
+	t.Run("QueuePosition", func(t *testing.T) {
+		t.Parallel()
+
+		test := newProvisionerJob(t)
+		test.JobMutex.Lock()
+		test.Job.QueuePosition = 2
+		test.Job.QueueSize = 2
+		test.JobMutex.Unlock()
+		go func() {
+			<-test.Next
+			test.JobMutex.Lock()
+			test.Job.QueuePosition = 1
+			test.Job.QueueSize = 1
+			test.JobMutex.Unlock()
+			<-test.Next
+			test.JobMutex.Lock()
+			test.Job.Status = codersdk.ProvisionerJobSucceeded
+			now := database.Now()
+			test.Job.StartedAt = &now
+			test.Job.CompletedAt = &now
+			test.Job.QueuePosition = 0
+			test.Job.QueueSize = 0
+			close(test.Logs)
+			test.JobMutex.Unlock()
+		}()
+		test.PTY.ExpectMatch("position 2 of 2")
+		test.Next <- struct{}{}
+		test.PTY.ExpectMatch("position 1 of 1")
+		test.Next <- struct{}{}
+		test.PTY.ExpectMatch("Running")
+	})
+
 	// This cannot be ran in parallel because it uses a signal.
 	// nolint:paralleltest
 	t.Run("Cancel", func(t *testing.T) {
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func connections() *cobra.Command {
+	cmd := &cobra.Command{
+		Short: "Inspect the log of connections made to workspaces",
+		Use:   "connections",
+		Example: formatExamples(
+			example{
+				Description: "List the most recent connections",
+				Command:     "coder connections list",
+			},
+			example{
+				Description: "List SSH sessions a user opened to a workspace since the start of the month",
+				Command:     "coder connections list --workspace alice/dev --search \"type:ssh username:alice date_from:2022-10-01\"",
+			},
+		),
+	}
+	cmd.AddCommand(
+		connectionsList(),
+	)
+	return cmd
+}
+
+type connectionRow struct {
+	StartedAt  string `table:"started at"`
+	EndedAt    string `table:"ended at"`
+	User       string `table:"user"`
+	Workspace  string `table:"workspace"`
+	Agent      string `table:"agent"`
+	Type       string `table:"type"`
+	Target     string `table:"target"`
+	RxBytes    int64  `table:"rx bytes"`
+	TxBytes    int64  `table:"tx bytes"`
+	RemoteAddr string `table:"remote addr"`
+}
+
+func connectionsList() *cobra.Command {
+	var (
+		connectionColumns = []string{"Started At", "Ended At", "User", "Workspace", "Type", "Target", "Rx Bytes", "Tx Bytes"}
+		columns           []string
+		outputFormat      string
+		searchQuery       string
+		workspaceName     string
+		limit             int
+		offset            int
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List connections, most recent first",
+		Long: "List connections, most recent first. The search query supports the " +
+			"keys username, workspace_id, type, date_from and date_to (YYYY-MM-DD).",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			if workspaceName != "" {
+				workspace, err := namedWorkspace(cmd, client, workspaceName)
+				if err != nil {
+					return xerrors.Errorf("get workspace: %w", err)
+				}
+				searchQuery = strings.TrimSpace(searchQuery + " workspace_id:" + workspace.ID.String())
+			}
+			res, err := client.WorkspaceConnections(cmd.Context(), codersdk.WorkspaceConnectionsRequest{
+				SearchQuery: searchQuery,
+				Pagination: codersdk.Pagination{
+					Limit:  limit,
+					Offset: offset,
+				},
+			})
+			if err != nil {
+				return xerrors.Errorf("get connections: %w", err)
+			}
+
+			out := ""
+			switch outputFormat {
+			case "table", "":
+				out, err = displayConnections(columns, res.Connections)
+				if err != nil {
+					return xerrors.Errorf("render table: %w", err)
+				}
+			case "json":
+				buf := new(bytes.Buffer)
+				enc := json.NewEncoder(buf)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(res)
+				if err != nil {
+					return xerrors.Errorf("marshal connections to JSON: %w", err)
+				}
+				out = buf.String()
+			default:
+				return xerrors.Errorf(`unknown output format %q, only "table" and "json" are supported`, outputFormat)
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&columns, "column", "c", connectionColumns,
+		fmt.Sprintf("Specify a column to filter in the table. Available columns are: %s, Agent, Remote Addr",
+			strings.Join(connectionColumns, ", ")))
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format. Available formats are: table, json.")
+	cmd.Flags().StringVar(&searchQuery, "search", "", "Filter connections with a query.")
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Only list connections to a workspace, specified as <name> or <owner>/<name>.")
+	cmd.Flags().IntVar(&limit, "limit", 25, "Maximum number of connections to return. 0 returns all connections.")
+	cmd.Flags().IntVar(&offset, "offset", 0, "Number of connections to skip.")
+	return cmd
+}
+
+// displayConnections will return a table displaying all connections passed
+// in. filterColumns must be a subset of the connection row fields and will
+// determine which columns to display.
+func displayConnections(filterColumns []string, connections []codersdk.WorkspaceConnection) (string, error) {
+	rows := make([]connectionRow, 0, len(connections))
+	for _, connection := range connections {
+		user := connection.Username
+		if user == "" {
+			user = "<unknown>"
+		}
+		endedAt := "<open>"
+		if connection.EndedAt != nil {
+			endedAt = connection.EndedAt.Local().Format(time.Stamp)
+		}
+		rows = append(rows, connectionRow{
+			StartedAt:  connection.StartedAt.Local().Format(time.Stamp),
+			EndedAt:    endedAt,
+			User:       user,
+			Workspace:  connection.WorkspaceName,
+			Agent:      connection.AgentName,
+			Type:       string(connection.Type),
+			Target:     connection.Target,
+			RxBytes:    connection.RxBytes,
+			TxBytes:    connection.TxBytes,
+			RemoteAddr: connection.RemoteAddr,
+		})
+	}
+
+	return cliui.DisplayTable(rows, "", filterColumns)
+}
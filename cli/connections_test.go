@@ -0,0 +1,109 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestConnectionsList(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	for _, connectionType := range []agent.ConnectionType{agent.ConnectionTypeSSH, agent.ConnectionTypePortForward} {
+		err := agentClient.PostWorkspaceAgentConnection(ctx, agent.Connection{
+			ID:        uuid.New(),
+			Type:      connectionType,
+			Target:    "localhost:8080",
+			StartedAt: time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, "connections", "list", "--workspace", workspace.Name)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetOut(pty.Output())
+		errC := make(chan error)
+		go func() {
+			errC <- cmd.ExecuteContext(ctx)
+		}()
+		require.NoError(t, <-errC)
+		pty.ExpectMatch(workspace.Name)
+		pty.ExpectMatch("localhost:8080")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, "connections", "list", "--search", "type:ssh", "-o", "json")
+		clitest.SetupConfig(t, client, root)
+		buf := bytes.NewBuffer(nil)
+		cmd.SetOut(buf)
+		err := cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+
+		var res codersdk.WorkspaceConnectionsResponse
+		err = json.Unmarshal(buf.Bytes(), &res)
+		require.NoError(t, err, "unmarshal JSON output")
+		assert.EqualValues(t, 1, res.Count)
+		require.Len(t, res.Connections, 1)
+		assert.Equal(t, codersdk.WorkspaceConnectionTypeSSH, res.Connections[0].Type)
+		assert.Equal(t, workspace.ID, res.Connections[0].WorkspaceID)
+	})
+}
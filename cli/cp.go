@@ -0,0 +1,419 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"cdr.dev/slog"
+	"github.com/pkg/sftp"
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func cp() *cobra.Command {
+	var (
+		recursive bool
+		resume    bool
+		wireguard bool
+	)
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "cp <source> <destination>",
+		Short:       "Copy files to and from a workspace",
+		Long: "Copy files to and from a workspace. Paths in a workspace are written as <workspace>:<path>, " +
+			"and relative paths in a workspace are relative to the home directory. Prefix local paths that contain " +
+			"a colon with ./ to copy them.",
+		Args: cobra.ExactArgs(2),
+		Example: formatExamples(
+			example{
+				Description: "Copy a file to the home directory of a workspace",
+				Command:     "coder cp ./dump.sql <workspace>:",
+			},
+			example{
+				Description: "Copy a directory from a workspace",
+				Command:     "coder cp --recursive <workspace>:project/dist ./dist",
+			},
+			example{
+				Description: "Resume a copy of a large file that was interrupted",
+				Command:     "coder cp --resume <workspace>:backup.tar.gz .",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			src, err := parseCopyLocation(args[0])
+			if err != nil {
+				return err
+			}
+			dst, err := parseCopyLocation(args[1])
+			if err != nil {
+				return err
+			}
+			if (src.Workspace == "") == (dst.Workspace == "") {
+				return xerrors.New("either the source or the destination must be in a workspace, formatted as <workspace>:<path>")
+			}
+			remote := &dst
+			if src.Workspace != "" {
+				remote = &src
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, remote.Workspace, false)
+			if err != nil {
+				return err
+			}
+			err = cliui.Agent(ctx, cmd.ErrOrStderr(), cliui.AgentOptions{
+				WorkspaceName: workspace.Name,
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+				},
+			})
+			if err != nil {
+				return xerrors.Errorf("await agent: %w", err)
+			}
+
+			var conn agent.Conn
+			if !wireguard {
+				conn, err = client.DialWorkspaceAgent(ctx, workspaceAgent.ID, nil)
+			} else {
+				conn, err = client.DialWorkspaceAgentTailnet(ctx, slog.Logger{}, workspaceAgent.ID)
+			}
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			sshClient, err := conn.SSHClient()
+			if err != nil {
+				return xerrors.Errorf("ssh client: %w", err)
+			}
+			defer sshClient.Close()
+			sftpClient, err := sftp.NewClient(sshClient)
+			if err != nil {
+				return xerrors.Errorf("sftp client: %w", err)
+			}
+			defer sftpClient.Close()
+
+			if !path.IsAbs(remote.Path) {
+				home, err := remoteHome(sshClient, workspaceAgent.OperatingSystem)
+				if err != nil {
+					return xerrors.Errorf("get home directory: %w", err)
+				}
+				remote.Path = resolveRemotePath(home, remote.Path)
+			}
+
+			var srcFS, dstFS copyFS = localFS{}, &remoteFS{client: sftpClient}
+			if src.Workspace != "" {
+				srcFS, dstFS = dstFS, srcFS
+			}
+			c := &copier{
+				recursive: recursive,
+				resume:    resume,
+				output:    cmd.ErrOrStderr(),
+			}
+			return c.copy(srcFS, src.Path, dstFS, dst.Path)
+		},
+	}
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Copy directories recursively.")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Resume partially copied files by appending to the destination when it's smaller than the source, instead of copying the whole file again.")
+	cmd.Flags().BoolVarP(&wireguard, "wireguard", "", false, "Specifies whether to use wireguard networking or not.")
+	_ = cmd.Flags().MarkHidden("wireguard")
+	return cmd
+}
+
+// copyLocation is the source or destination of a copy.
+type copyLocation struct {
+	// Workspace is empty for local paths.
+	Workspace string
+	Path      string
+}
+
+// copyWorkspacePattern matches the workspace of a path in a workspace, which
+// may include the owner and the agent, e.g. "owner/workspace.agent".
+var copyWorkspacePattern = regexp.MustCompile(`^([\w-]+/)?[\w-]+(\.[\w-]+)?$`)
+
+// parseCopyLocation parses a path formatted as [<workspace>:]<path>. The
+// path is local when the text before the colon isn't a workspace, so local
+// paths that contain a colon can be prefixed with "./".
+func parseCopyLocation(raw string) (copyLocation, error) {
+	workspace, filePath, ok := strings.Cut(raw, ":")
+	if !ok || !copyWorkspacePattern.MatchString(workspace) {
+		return copyLocation{Path: raw}, nil
+	}
+	// Single letters are drive letters on Windows, e.g. "C:\Users".
+	if runtime.GOOS == "windows" && len(workspace) == 1 {
+		return copyLocation{Path: raw}, nil
+	}
+	return copyLocation{
+		Workspace: workspace,
+		Path:      filePath,
+	}, nil
+}
+
+// remoteHome returns the home directory of the user in the workspace.
+func remoteHome(sshClient *gossh.Client, operatingSystem string) (string, error) {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return "", xerrors.Errorf("create session: %w", err)
+	}
+	defer session.Close()
+	command := "echo $HOME"
+	if operatingSystem == "windows" {
+		command = "echo %USERPROFILE%"
+	}
+	output, err := session.Output(command)
+	if err != nil {
+		return "", err
+	}
+	home := strings.TrimSpace(string(output))
+	if home == "" {
+		return "", xerrors.New("home directory is empty")
+	}
+	return home, nil
+}
+
+// resolveRemotePath resolves a relative path in a workspace against the home
+// directory, like scp.
+func resolveRemotePath(home, remotePath string) string {
+	remotePath = strings.TrimPrefix(strings.TrimPrefix(remotePath, "~"), "/")
+	return path.Join(home, remotePath)
+}
+
+// copyFS is the local file system, or the file system of a workspace.
+type copyFS interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error)
+	Open(name string) (io.ReadSeekCloser, error)
+	// OpenWriter opens a file for writing at offset. The file is created
+	// with perm, or truncated when offset is zero.
+	OpenWriter(name string, offset int64, perm os.FileMode) (io.WriteCloser, error)
+	MkdirAll(name string, perm os.FileMode) error
+	Join(elem ...string) string
+	Base(name string) string
+}
+
+type localFS struct{}
+
+func (localFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (localFS) ReadDir(name string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (localFS) Open(name string) (io.ReadSeekCloser, error) {
+	return os.Open(name)
+}
+
+func (localFS) OpenWriter(name string, offset int64, perm os.FileMode) (io.WriteCloser, error) {
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(name, flags, perm)
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (localFS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (localFS) Join(elem ...string) string {
+	return filepath.Join(elem...)
+}
+
+func (localFS) Base(name string) string {
+	return filepath.Base(name)
+}
+
+type remoteFS struct {
+	client *sftp.Client
+}
+
+func (r *remoteFS) Stat(name string) (os.FileInfo, error) {
+	return r.client.Stat(name)
+}
+
+func (r *remoteFS) ReadDir(name string) ([]os.FileInfo, error) {
+	return r.client.ReadDir(name)
+}
+
+func (r *remoteFS) Open(name string) (io.ReadSeekCloser, error) {
+	return r.client.Open(name)
+}
+
+func (r *remoteFS) OpenWriter(name string, offset int64, perm os.FileMode) (io.WriteCloser, error) {
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	file, err := r.client.OpenFile(name, flags)
+	if err != nil {
+		return nil, err
+	}
+	if offset == 0 {
+		err = file.Chmod(perm)
+	} else {
+		_, err = file.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (r *remoteFS) MkdirAll(name string, _ os.FileMode) error {
+	return r.client.MkdirAll(name)
+}
+
+func (*remoteFS) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (*remoteFS) Base(name string) string {
+	return path.Base(name)
+}
+
+// copier copies files and directories between file systems.
+type copier struct {
+	recursive bool
+	resume    bool
+	// output is where progress is written.
+	output io.Writer
+}
+
+// copy copies srcPath to dstPath. Like cp, a source copied to an existing
+// directory is copied into the directory.
+func (c *copier) copy(src copyFS, srcPath string, dst copyFS, dstPath string) error {
+	info, err := src.Stat(srcPath)
+	if err != nil {
+		return xerrors.Errorf("stat %s: %w", srcPath, err)
+	}
+	if info.IsDir() && !c.recursive {
+		return xerrors.Errorf("%s is a directory, use --recursive to copy it", srcPath)
+	}
+	dstInfo, err := dst.Stat(dstPath)
+	if err == nil && dstInfo.IsDir() {
+		dstPath = dst.Join(dstPath, src.Base(srcPath))
+	}
+	return c.copyTree(src, srcPath, info, dst, dstPath)
+}
+
+func (c *copier) copyTree(src copyFS, srcPath string, info os.FileInfo, dst copyFS, dstPath string) error {
+	if !info.IsDir() {
+		return c.copyFile(src, srcPath, info, dst, dstPath)
+	}
+	err := dst.MkdirAll(dstPath, info.Mode().Perm())
+	if err != nil {
+		return xerrors.Errorf("create directory %s: %w", dstPath, err)
+	}
+	entries, err := src.ReadDir(srcPath)
+	if err != nil {
+		return xerrors.Errorf("read directory %s: %w", srcPath, err)
+	}
+	for _, entry := range entries {
+		entryPath := src.Join(srcPath, entry.Name())
+		// Follow symlinks, like scp.
+		if entry.Mode()&os.ModeSymlink != 0 {
+			entry, err = src.Stat(entryPath)
+			if err != nil {
+				return xerrors.Errorf("stat %s: %w", entryPath, err)
+			}
+		}
+		if !entry.IsDir() && !entry.Mode().IsRegular() {
+			_, _ = fmt.Fprintf(c.output, "Skipping %s, which isn't a regular file.\n", entryPath)
+			continue
+		}
+		err = c.copyTree(src, entryPath, entry, dst, dst.Join(dstPath, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *copier) copyFile(src copyFS, srcPath string, info os.FileInfo, dst copyFS, dstPath string) error {
+	// Resuming assumes the destination is a partial copy of the source, like
+	// rsync --append.
+	var offset int64
+	if c.resume {
+		dstInfo, err := dst.Stat(dstPath)
+		if err == nil && dstInfo.Mode().IsRegular() && dstInfo.Size() <= info.Size() {
+			offset = dstInfo.Size()
+		}
+	}
+
+	reader, err := src.Open(srcPath)
+	if err != nil {
+		return xerrors.Errorf("open %s: %w", srcPath, err)
+	}
+	defer reader.Close()
+	_, err = reader.Seek(offset, io.SeekStart)
+	if err != nil {
+		return xerrors.Errorf("seek %s: %w", srcPath, err)
+	}
+	writer, err := dst.OpenWriter(dstPath, offset, info.Mode().Perm())
+	if err != nil {
+		return xerrors.Errorf("open %s: %w", dstPath, err)
+	}
+	defer writer.Close()
+
+	progress := cliui.NewProgress(c.output, srcPath, offset, info.Size())
+	_, err = copyData(writer, reader, progress)
+	progress.Done()
+	if err != nil {
+		return xerrors.Errorf("copy %s to %s: %w", srcPath, dstPath, err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return xerrors.Errorf("close %s: %w", dstPath, err)
+	}
+	return nil
+}
+
+// copyData copies src to dst while writing the copied bytes to progress.
+// SFTP files are only fast when they drive the copy with concurrent
+// requests, so the copy is driven by the SFTP file on either side.
+func copyData(dst io.Writer, src io.Reader, progress io.Writer) (int64, error) {
+	if _, ok := src.(*sftp.File); ok {
+		return io.Copy(io.MultiWriter(dst, progress), src)
+	}
+	return io.Copy(dst, io.TeeReader(src, progress))
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCopyLocation(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		Raw       string
+		Workspace string
+		Path      string
+	}{
+		{"file.txt", "", "file.txt"},
+		{"/tmp/file.txt", "", "/tmp/file.txt"},
+		{"ws:file.txt", "ws", "file.txt"},
+		{"ws:", "ws", ""},
+		{"ws.agent:/tmp/file.txt", "ws.agent", "/tmp/file.txt"},
+		{"owner/ws:~/file.txt", "owner/ws", "~/file.txt"},
+		{"./file:txt", "", "./file:txt"},
+		{"a/b/c:txt", "", "a/b/c:txt"},
+	} {
+		testCase := testCase
+		t.Run(testCase.Raw, func(t *testing.T) {
+			t.Parallel()
+			location, err := parseCopyLocation(testCase.Raw)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.Workspace, location.Workspace)
+			assert.Equal(t, testCase.Path, location.Path)
+		})
+	}
+}
+
+func TestResolveRemotePath(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "/home/coder", resolveRemotePath("/home/coder", ""))
+	assert.Equal(t, "/home/coder", resolveRemotePath("/home/coder", "~"))
+	assert.Equal(t, "/home/coder/file.txt", resolveRemotePath("/home/coder", "~/file.txt"))
+	assert.Equal(t, "/home/coder/project/file.txt", resolveRemotePath("/home/coder", "project/file.txt"))
+}
+
+func TestCopier(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("File modes aren't supported on Windows.")
+	}
+
+	newCopier := func(recursive, resume bool) *copier {
+		return &copier{
+			recursive: recursive,
+			resume:    resume,
+			output:    io.Discard,
+		}
+	}
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+		src := filepath.Join(t.TempDir(), "file.txt")
+		require.NoError(t, os.WriteFile(src, []byte("content"), 0o640))
+		dst := filepath.Join(t.TempDir(), "copy.txt")
+
+		err := newCopier(false, false).copy(localFS{}, src, localFS{}, dst)
+		require.NoError(t, err)
+		content, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "content", string(content))
+		info, err := os.Stat(dst)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	})
+
+	t.Run("IntoDirectory", func(t *testing.T) {
+		t.Parallel()
+		src := filepath.Join(t.TempDir(), "file.txt")
+		require.NoError(t, os.WriteFile(src, []byte("content"), 0o600))
+		dst := t.TempDir()
+
+		err := newCopier(false, false).copy(localFS{}, src, localFS{}, dst)
+		require.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(dst, "file.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "content", string(content))
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		t.Parallel()
+		src := filepath.Join(t.TempDir(), "dir")
+		require.NoError(t, os.MkdirAll(filepath.Join(src, "nested"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(src, "nested", "b.txt"), []byte("b"), 0o600))
+		dst := filepath.Join(t.TempDir(), "copy")
+
+		err := newCopier(false, false).copy(localFS{}, src, localFS{}, dst)
+		require.ErrorContains(t, err, "--recursive")
+
+		err = newCopier(true, false).copy(localFS{}, src, localFS{}, dst)
+		require.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(dst, "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "a", string(content))
+		content, err = os.ReadFile(filepath.Join(dst, "nested", "b.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "b", string(content))
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		t.Parallel()
+		data := bytes.Repeat([]byte("0123456789"), 10000)
+		src := filepath.Join(t.TempDir(), "large.bin")
+		require.NoError(t, os.WriteFile(src, data, 0o600))
+		dst := filepath.Join(t.TempDir(), "large.bin")
+		require.NoError(t, os.WriteFile(dst, data[:12345], 0o600))
+
+		var output strings.Builder
+		c := newCopier(false, true)
+		c.output = &output
+		err := c.copy(localFS{}, src, localFS{}, dst)
+		require.NoError(t, err)
+		content, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, data, content)
+		require.Contains(t, output.String(), "100%")
+	})
+
+	t.Run("ResumeLargerDestination", func(t *testing.T) {
+		t.Parallel()
+		src := filepath.Join(t.TempDir(), "file.txt")
+		require.NoError(t, os.WriteFile(src, []byte("short"), 0o600))
+		dst := filepath.Join(t.TempDir(), "file.txt")
+		require.NoError(t, os.WriteFile(dst, []byte("much longer content"), 0o600))
+
+		err := newCopier(false, true).copy(localFS{}, src, localFS{}, dst)
+		require.NoError(t, err)
+		content, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "short", string(content))
+	})
+}
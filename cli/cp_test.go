@@ -0,0 +1,65 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+)
+
+func TestCp(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("Paths in the workspace are written as POSIX paths.")
+	}
+
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	_, workspace := runAgent(t, client, user.UserID)
+
+	// The agent runs on this machine, so paths in the workspace are local
+	// paths too.
+	t.Run("Upload", func(t *testing.T) {
+		t.Parallel()
+		src := filepath.Join(t.TempDir(), "file.txt")
+		require.NoError(t, os.WriteFile(src, []byte("upload"), 0o600))
+		dst := filepath.Join(t.TempDir(), "uploaded.txt")
+
+		cmd, root := clitest.New(t, "cp", src, workspace.Name+":"+dst)
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		content, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "upload", string(content))
+	})
+
+	t.Run("DownloadDirectory", func(t *testing.T) {
+		t.Parallel()
+		src := filepath.Join(t.TempDir(), "dir")
+		require.NoError(t, os.MkdirAll(filepath.Join(src, "nested"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(src, "nested", "file.txt"), []byte("download"), 0o600))
+		dst := t.TempDir()
+
+		cmd, root := clitest.New(t, "cp", "--recursive", workspace.Name+":"+src, dst)
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(dst, "dir", "nested", "file.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "download", string(content))
+	})
+
+	t.Run("BothLocal", func(t *testing.T) {
+		t.Parallel()
+		cmd, root := clitest.New(t, "cp", "a.txt", "b.txt")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.ErrorContains(t, err, "<workspace>:<path>")
+	})
+}
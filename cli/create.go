@@ -173,6 +173,9 @@ type prepWorkspaceBuildArgs struct {
 	ExistingParams   []codersdk.Parameter
 	ParameterFile    string
 	NewWorkspaceName string
+	// PromptMutable prompts for the values of existing params that are
+	// mutable, so they can be changed by the build.
+	PromptMutable bool
 }
 
 // prepWorkspaceBuild will ensure a workspace build will succeed on the latest template version.
@@ -214,7 +217,7 @@ PromptParamLoop:
 		// Param file is all or nothing
 		if !useParamFile {
 			for _, e := range args.ExistingParams {
-				if e.Name == parameterSchema.Name {
+				if e.Name == parameterSchema.Name && !(args.PromptMutable && parameterSchema.Mutable) {
 					// If the param already exists, we do not need to prompt it again.
 					// The workspace scope will reuse params for each build.
 					continue PromptParamLoop
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"cdr.dev/slog"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func execCmd() *cobra.Command {
+	var (
+		env       []string
+		dir       string
+		wireguard bool
+		noWait    bool
+	)
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "exec <workspace> -- <command>",
+		Short:       "Run a command in a workspace",
+		Long: "Run a command in a workspace without a terminal. The output of the command is written to " +
+			"stdout and stderr as the command writes it, and coder exits with the exit code of the command. " +
+			"Arguments are passed to the command as is; use \"sh -c\" for shell syntax.",
+		Args: cobra.MinimumNArgs(2),
+		Example: formatExamples(
+			example{
+				Description: "Run the tests of a project in a workspace",
+				Command:     "coder exec <workspace> --dir ~/project -- make test",
+			},
+			example{
+				Description: "Set environment variables for the command",
+				Command:     "coder exec <workspace> -e GOFLAGS=-count=1 -e CI=true -- go test ./...",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			for _, kv := range env {
+				if !strings.Contains(kv, "=") {
+					return xerrors.Errorf("environment variable %q must be formatted as KEY=value", kv)
+				}
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, args[0], false)
+			if err != nil {
+				return err
+			}
+
+			err = cliui.Agent(ctx, cmd.ErrOrStderr(), cliui.AgentOptions{
+				WorkspaceName: workspace.Name,
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+				},
+				WaitForReady: workspace.TemplateWaitForAgentReady && !noWait,
+			})
+			if err != nil {
+				return xerrors.Errorf("await agent: %w", err)
+			}
+
+			var conn agent.Conn
+			if !wireguard {
+				conn, err = client.DialWorkspaceAgent(ctx, workspaceAgent.ID, nil)
+			} else {
+				conn, err = client.DialWorkspaceAgentTailnet(ctx, slog.Logger{}, workspaceAgent.ID)
+			}
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			exitCode, err := conn.Exec(ctx, agent.ExecRequest{
+				Args: args[1:],
+				Env:  env,
+				Dir:  dir,
+			}, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			if err != nil {
+				return xerrors.Errorf("exec: %w", err)
+			}
+			if exitCode != 0 {
+				return &ExitError{Code: exitCode}
+			}
+			return nil
+		},
+	}
+	cliflag.StringArrayVarP(cmd.Flags(), &env, "env", "e", "", []string{}, "Set an environment variable for the command, formatted as KEY=value.")
+	cliflag.StringVarP(cmd.Flags(), &dir, "dir", "d", "CODER_EXEC_DIR", "", "Run the command in this directory. Defaults to the directory of the agent.")
+	cliflag.BoolVarP(cmd.Flags(), &wireguard, "wireguard", "", "CODER_EXEC_WIREGUARD", false, "Whether to use Wireguard for the connection.")
+	_ = cmd.Flags().MarkHidden("wireguard")
+	cliflag.BoolVarP(cmd.Flags(), &noWait, "no-wait", "", "CODER_EXEC_NO_WAIT", false, "Run the command without waiting for the startup script to finish, even if the template requires it.")
+	return cmd
+}
@@ -0,0 +1,76 @@
+package cli_test
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+)
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The commands are written for a POSIX shell.")
+	}
+
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	_, workspace := runAgent(t, client, user.UserID)
+
+	t.Run("Output", func(t *testing.T) {
+		t.Parallel()
+		cmd, root := clitest.New(t, "exec", workspace.Name, "-e", "EXEC_VAR=value", "--", "sh", "-c", "echo $EXEC_VAR; echo error >&2")
+		clitest.SetupConfig(t, client, root)
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		require.Equal(t, "value\n", stdout.String())
+		require.Contains(t, stderr.String(), "error\n")
+	})
+
+	t.Run("ExitCode", func(t *testing.T) {
+		t.Parallel()
+		cmd, root := clitest.New(t, "exec", workspace.Name, "--", "exit", "42")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		var exitErr *cli.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		require.Equal(t, 42, exitErr.Code)
+	})
+
+	t.Run("Arguments", func(t *testing.T) {
+		t.Parallel()
+		cmd, root := clitest.New(t, "exec", workspace.Name, "--", "printf", "%s\n", "two words", "it's", "$HOME")
+		clitest.SetupConfig(t, client, root)
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		require.Equal(t, "two words\nit's\n$HOME\n", stdout.String())
+	})
+
+	t.Run("Signal", func(t *testing.T) {
+		t.Parallel()
+		cmd, root := clitest.New(t, "exec", workspace.Name, "--", "sh", "-c", "kill -TERM $$")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		var exitErr *cli.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		require.Equal(t, 143, exitErr.Code)
+	})
+
+	t.Run("InvalidEnv", func(t *testing.T) {
+		t.Parallel()
+		cmd, root := clitest.New(t, "exec", workspace.Name, "-e", "EXEC_VAR", "--", "true")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.ErrorContains(t, err, "KEY=value")
+	})
+}
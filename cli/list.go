@@ -120,7 +120,8 @@ func list() *cobra.Command {
 	}
 	cmd.Flags().StringArrayVarP(&columns, "column", "c", nil,
 		"Specify a column to filter in the table.")
-	cmd.Flags().StringVar(&searchQuery, "search", "", "Search for a workspace with a query.")
+	cmd.Flags().StringVar(&searchQuery, "search", "", "Search for a workspace with a query, e.g. \"status:running outdated:true\". "+
+		"Supports the owner, name, template, template_version, status, outdated, last_used_before, last_used_after and has_agent filters.")
 	cmd.Flags().BoolVar(&me, "me", false, "Only show workspaces owned by the current user.")
 	return cmd
 }
@@ -19,6 +19,7 @@ import (
 
 	"github.com/coder/coder/agent"
 	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -28,6 +29,7 @@ func portForward() *cobra.Command {
 		udpForwards  []string // <port>:<port>
 		unixForwards []string // <path>:<path> OR <port>:<path>
 		wireguard    bool
+		list         bool
 	)
 	cmd := &cobra.Command{
 		Use:     "port-forward <workspace>",
@@ -55,6 +57,10 @@ func portForward() *cobra.Command {
 				Description: "Port forward multiple TCP ports and a UDP port",
 				Command:     "coder port-forward <workspace> --tcp 8080:8080 --tcp 9000:3000 --udp 5353:53",
 			},
+			example{
+				Description: "List the TCP ports that processes in the workspace are listening on, with a URL to open each one in the browser",
+				Command:     "coder port-forward <workspace> --list",
+			},
 		),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, cancel := context.WithCancel(cmd.Context())
@@ -64,7 +70,7 @@ func portForward() *cobra.Command {
 			if err != nil {
 				return xerrors.Errorf("parse port-forward specs: %w", err)
 			}
-			if len(specs) == 0 {
+			if len(specs) == 0 && !list {
 				err = cmd.Help()
 				if err != nil {
 					return xerrors.Errorf("generate help output: %w", err)
@@ -100,6 +106,9 @@ func portForward() *cobra.Command {
 			if err != nil {
 				return xerrors.Errorf("await agent: %w", err)
 			}
+			if list {
+				return listPorts(ctx, cmd, client, workspace, workspaceAgent)
+			}
 
 			var conn agent.Conn
 			if !wireguard {
@@ -168,9 +177,64 @@ func portForward() *cobra.Command {
 	cmd.Flags().StringArrayVar(&unixForwards, "unix", []string{}, "Forward a Unix socket in the workspace to a local Unix socket or TCP port")
 	cmd.Flags().BoolVarP(&wireguard, "wireguard", "", false, "Specifies whether to use wireguard networking or not.")
 	_ = cmd.Flags().MarkHidden("wireguard")
+	cmd.Flags().BoolVarP(&list, "list", "l", false, "List the TCP ports that processes in the workspace are listening on instead of forwarding ports")
 	return cmd
 }
 
+type listeningPortRow struct {
+	Port    uint16 `table:"port"`
+	Process string `table:"process"`
+	URL     string `table:"url"`
+}
+
+// listPorts prints the ports the agent is listening on, with a URL that
+// opens each one through the workspace application proxy.
+func listPorts(ctx context.Context, cmd *cobra.Command, client *codersdk.Client, workspace codersdk.Workspace, workspaceAgent codersdk.WorkspaceAgent) error {
+	ports, err := client.WorkspaceAgentListeningPorts(ctx, workspaceAgent.ID)
+	if err != nil {
+		return xerrors.Errorf("get listening ports: %w", err)
+	}
+	appHost, err := client.AppHost(ctx)
+	if err != nil {
+		return xerrors.Errorf("get app host: %w", err)
+	}
+
+	rows := make([]listeningPortRow, 0, len(ports.Ports))
+	for _, port := range ports.Ports {
+		rows = append(rows, listeningPortRow{
+			Port:    port.Port,
+			Process: port.ProcessName,
+			URL:     portURL(client, appHost.Host, workspace, workspaceAgent, port.Port),
+		})
+	}
+	out, err := cliui.DisplayTable(rows, "port", nil)
+	if err != nil {
+		return xerrors.Errorf("render table: %w", err)
+	}
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+	return err
+}
+
+// portURL returns the URL of a port in the workspace. Subdomain URLs are
+// preferred because applications served on a path prefix often break.
+func portURL(client *codersdk.Client, appHost string, workspace codersdk.Workspace, workspaceAgent codersdk.WorkspaceAgent, port uint16) string {
+	appName := strconv.Itoa(int(port))
+	if appHost != "" {
+		u := *client.URL
+		u.Host = httpapi.SubdomainAppHost(appHost, httpapi.ApplicationURL{
+			AppName:       appName,
+			AgentName:     workspaceAgent.Name,
+			WorkspaceName: workspace.Name,
+			Username:      workspace.OwnerName,
+		})
+		u.Path = "/"
+		return u.String()
+	}
+	u := *client.URL
+	u.Path = fmt.Sprintf("/@%s/%s.%s/apps/%s/", workspace.OwnerName, workspace.Name, workspaceAgent.Name, appName)
+	return u.String()
+}
+
 func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn agent.Conn, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
 	_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
 
@@ -285,6 +285,26 @@ func TestPortForward(t *testing.T) {
 		require.ErrorIs(t, err, context.Canceled)
 	})
 
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS != "linux" {
+			t.Skip("Listening ports are only supported on Linux.")
+		}
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err, "create TCP listener")
+		p := setupTestListener(t, l)
+
+		cmd, root := clitest.New(t, "port-forward", workspace.Name, "--list")
+		clitest.SetupConfig(t, client, root)
+		buf := newThreadSafeBuffer()
+		cmd.SetOut(buf)
+		err = cmd.Execute()
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), workspace.Name)
+		require.Contains(t, buf.String(), fmt.Sprintf("/apps/%s/", p))
+	})
+
 	// Test doing TCP, UDP and Unix at the same time.
 	//nolint:paralleltest
 	t.Run("All", func(t *testing.T) {
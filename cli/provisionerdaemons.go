@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisionerd/proto"
+)
+
+func provisionerDaemons() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "provisionerd",
+		Short: "Manage provisioner daemons",
+		Example: formatExamples(
+			example{
+				Description: "Run a provisioner daemon that only picks up jobs tagged with environment=on-prem",
+				Command:     "coder provisionerd start --psk <key> --tag environment=on-prem",
+			},
+		),
+	}
+	cmd.AddCommand(
+		provisionerDaemonStart(),
+	)
+
+	return cmd
+}
+
+func provisionerDaemonStart() *cobra.Command {
+	var (
+		cacheDir        string
+		name            string
+		psk             string
+		rawTags         []string
+		echoProvisioner bool
+		verbose         bool
+	)
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Run a provisioner daemon that connects to a Coder deployment",
+		Long: "Run a provisioner daemon outside of the Coder server. The daemon authenticates " +
+			"with the pre-shared key configured on the server, and only runs jobs whose tags " +
+			"are a subset of the tags it was started with.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if psk == "" {
+				return xerrors.New("A pre-shared key must be provided with --psk.")
+			}
+			tags, err := parseProvisionerTags(rawTags)
+			if err != nil {
+				return err
+			}
+			client, err := createUnauthenticatedClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			logger := slog.Make(sloghuman.Sink(cmd.ErrOrStderr()))
+			if verbose {
+				logger = logger.Leveled(slog.LevelDebug)
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			notifyCtx, notifyStop := signal.NotifyContext(ctx, interruptSignals...)
+			defer notifyStop()
+
+			provisioners := []codersdk.ProvisionerType{codersdk.ProvisionerTypeTerraform}
+			if echoProvisioner {
+				provisioners = append(provisioners, codersdk.ProvisionerTypeEcho)
+			}
+
+			errCh := make(chan error, 1)
+			daemon, err := newProvisionerDaemon(ctx, func(ctx context.Context) (proto.DRPCProvisionerDaemonClient, error) {
+				return client.ServeProvisionerDaemon(ctx, name, provisioners, tags, psk)
+			}, logger, cacheDir, errCh, echoProvisioner)
+			if err != nil {
+				return xerrors.Errorf("create provisioner daemon: %w", err)
+			}
+			defer daemon.Close()
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Started provisioner daemon connected to %s\n", cliui.Styles.Field.Render(client.URL.String()))
+
+			var exitErr error
+			select {
+			case <-notifyCtx.Done():
+				exitErr = notifyCtx.Err()
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), cliui.Styles.Bold.Render(
+					"Interrupt caught, gracefully exiting. Use ctrl+\\ to force quit",
+				))
+			case exitErr = <-errCh:
+			}
+			if exitErr != nil && !xerrors.Is(exitErr, context.Canceled) {
+				cmd.Printf("Unexpected error, shutting down provisioner daemon: %s\n", exitErr)
+			}
+
+			err = shutdownWithTimeout(daemon, 5*time.Second)
+			if err != nil {
+				return xerrors.Errorf("shutdown provisioner daemon: %w", err)
+			}
+			return nil
+		},
+	}
+
+	defaultCacheDir := filepath.Join(os.TempDir(), "coder-cache")
+	if dir := os.Getenv("CACHE_DIRECTORY"); dir != "" {
+		// For compatibility with systemd.
+		defaultCacheDir = dir
+	}
+	cliflag.StringVarP(cmd.Flags(), &cacheDir, "cache-dir", "", "CODER_CACHE_DIRECTORY", defaultCacheDir, "Specifies a directory to cache binaries for provision operations. If unspecified and $CACHE_DIRECTORY is set, it will be used for compatibility with systemd.")
+	cliflag.StringVarP(cmd.Flags(), &name, "name", "", "CODER_PROVISIONER_DAEMON_NAME", "", "Specifies a name for the provisioner daemon. A random name is generated if unset.")
+	cliflag.StringVarP(cmd.Flags(), &psk, "psk", "", "CODER_PROVISIONER_DAEMON_PSK", "", "Specifies the pre-shared key configured on the Coder server with --provisioner-daemon-psk.")
+	cliflag.StringArrayVarP(cmd.Flags(), &rawTags, "tag", "t", "CODER_PROVISIONER_DAEMON_TAGS", nil, "Specifies tags to match jobs against. Formatted as: key=value.")
+	cliflag.BoolVarP(cmd.Flags(), &verbose, "verbose", "v", "CODER_VERBOSE", false, "Enables verbose logging.")
+	// The echo provisioner is only useful for testing.
+	cliflag.BoolVarP(cmd.Flags(), &echoProvisioner, "echo-provisioner", "", "CODER_PROVISIONER_DAEMON_ECHO", false, "Serve the echo provisioner in addition to Terraform.")
+	_ = cmd.Flags().MarkHidden("echo-provisioner")
+	return cmd
+}
+
+// parseProvisionerTags parses a list of key=value pairs into a map.
+func parseProvisionerTags(rawTags []string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, rawTag := range rawTags {
+		parts := strings.SplitN(rawTag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, xerrors.Errorf("invalid tag %q, must be in the format key=value", rawTag)
+		}
+		tags[parts[0]] = parts[1]
+	}
+	return tags, nil
+}
@@ -0,0 +1,79 @@
+package cli_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestProvisionerDaemonStart(t *testing.T) {
+	t.Parallel()
+	t.Run("MissingPSK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{ProvisionerDaemonPSK: "psk"})
+		cmd, root := clitest.New(t, "provisionerd", "start")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.ErrorContains(t, err, "pre-shared key")
+	})
+
+	t.Run("InvalidTag", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{ProvisionerDaemonPSK: "psk"})
+		cmd, root := clitest.New(t, "provisionerd", "start", "--psk", "psk", "--tag", "environment")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.ErrorContains(t, err, "key=value")
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{ProvisionerDaemonPSK: "psk"})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, "provisionerd", "start",
+			"--psk", "psk",
+			"--tag", "environment=on-prem",
+			"--echo-provisioner",
+			"--cache-dir", t.TempDir(),
+		)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetOut(pty.Output())
+		errC := make(chan error, 1)
+		go func() {
+			errC <- cmd.ExecuteContext(ctx)
+		}()
+		pty.ExpectMatch("Started provisioner daemon")
+
+		data, err := echo.Tar(nil)
+		require.NoError(t, err)
+		file, err := client.Upload(ctx, codersdk.ContentTypeTar, data)
+		require.NoError(t, err)
+		version, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageSource: file.Hash,
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			ProvisionerTags: map[string]string{
+				"environment": "on-prem",
+			},
+		})
+		require.NoError(t, err)
+		version = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, version.Job.Status)
+
+		cancel()
+		require.NoError(t, <-errC)
+	})
+}
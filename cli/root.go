@@ -64,35 +64,44 @@ func init() {
 func Core() []*cobra.Command {
 	return []*cobra.Command{
 		configSSH(),
+		cp(),
 		create(),
 		deleteWorkspace(),
 		dotfiles(),
+		execCmd(),
 		gitssh(),
 		list(),
 		login(),
 		logout(),
 		parameters(),
 		portForward(),
+		provisionerDaemons(),
 		publickey(),
 		resetPassword(),
 		schedules(),
 		show(),
 		ssh(),
 		start(),
+		startupLogs(),
 		state(),
 		stop(),
 		rename(),
 		templates(),
+		tokens(),
 		update(),
 		users(),
 		versionCmd(),
 		workspaceAgent(),
 		features(),
+		auditCmd(),
+		connections(),
 	}
 }
 
 func AGPL() []*cobra.Command {
-	all := append(Core(), Server(coderd.New))
+	all := append(Core(), Server(func(o *coderd.Options) (*coderd.API, error) {
+		return coderd.New(o), nil
+	}))
 	return all
 }
 
@@ -194,18 +203,7 @@ func isTest() bool {
 // It reads from global configuration files if flags are not set.
 func CreateClient(cmd *cobra.Command) (*codersdk.Client, error) {
 	root := createConfig(cmd)
-	rawURL, err := cmd.Flags().GetString(varURL)
-	if err != nil || rawURL == "" {
-		rawURL, err = root.URL().Read()
-		if err != nil {
-			// If the configuration files are absent, the user is logged out
-			if os.IsNotExist(err) {
-				return nil, errUnauthenticated
-			}
-			return nil, err
-		}
-	}
-	serverURL, err := url.Parse(strings.TrimSpace(rawURL))
+	client, err := createUnauthenticatedClient(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -220,11 +218,31 @@ func CreateClient(cmd *cobra.Command) (*codersdk.Client, error) {
 			return nil, err
 		}
 	}
-	client := codersdk.New(serverURL)
 	client.SessionToken = strings.TrimSpace(token)
 	return client, nil
 }
 
+// createUnauthenticatedClient returns a new client without a session token.
+// The URL is read from global configuration files if the flag is not set.
+func createUnauthenticatedClient(cmd *cobra.Command) (*codersdk.Client, error) {
+	rawURL, err := cmd.Flags().GetString(varURL)
+	if err != nil || rawURL == "" {
+		rawURL, err = createConfig(cmd).URL().Read()
+		if err != nil {
+			// If the configuration files are absent, the user is logged out
+			if os.IsNotExist(err) {
+				return nil, errUnauthenticated
+			}
+			return nil, err
+		}
+	}
+	serverURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	return codersdk.New(serverURL), nil
+}
+
 // createAgentClient returns a new client from the command context.
 // It works just like CreateClient, but uses the agent token and URL instead.
 func createAgentClient(cmd *cobra.Command) (*codersdk.Client, error) {
@@ -462,6 +480,16 @@ func FormatCobraError(err error, cmd *cobra.Command) string {
 	return cliui.Styles.Error.Render(output.String())
 }
 
+// ExitError is returned by commands that exit with the exit code of a
+// command run in a workspace. The error isn't printed.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
 func checkVersions(cmd *cobra.Command, client *codersdk.Client) error {
 	if cliflag.IsSetBool(cmd, varNoVersionCheck) {
 		return nil
@@ -50,11 +50,13 @@ import (
 	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/cli/config"
 	"github.com/coder/coder/coderd"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/autobuild/executor"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/databasefake"
 	"github.com/coder/coder/coderd/devtunnel"
 	"github.com/coder/coder/coderd/gitsshkey"
+	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/prometheusmetrics"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/tracing"
@@ -70,9 +72,10 @@ import (
 )
 
 // nolint:gocyclo
-func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
+func Server(newAPI func(*coderd.Options) (*coderd.API, error)) *cobra.Command {
 	var (
 		accessURL             string
+		wildcardAccessURL     string
 		address               string
 		autobuildPollInterval time.Duration
 		derpServerEnabled     bool
@@ -89,6 +92,7 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 		inMemoryDatabase      bool
 		// provisionerDaemonCount is a uint8 to ensure a number > 0.
 		provisionerDaemonCount           uint8
+		provisionerDaemonPSK             string
 		postgresURL                      string
 		oauth2GithubClientID             string
 		oauth2GithubClientSecret         string
@@ -100,6 +104,7 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 		oidcClientID                     string
 		oidcClientSecret                 string
 		oidcEmailDomain                  string
+		oidcGroupField                   string
 		oidcIssuerURL                    string
 		oidcScopes                       []string
 		tailscaleEnable                  bool
@@ -122,6 +127,18 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 		verbose                          bool
 		metricsCacheRefreshInterval      time.Duration
 		agentStatRefreshInterval         time.Duration
+		auditWebhookURL                  string
+		auditWebhookSecret               string
+		auditWebhookQueueDir             string
+		auditWebhookQueueSize            int
+		auditSyslogAddress               string
+		auditSyslogTLS                   bool
+		auditSyslogTLSCAFile             string
+		agentShutdownScriptTimeout       time.Duration
+		provisionerJobHeartbeatTimeout   time.Duration
+		templateGitUsername              string
+		templateGitPassword              string
+		templateGitHosts                 []string
 	)
 
 	root := &cobra.Command{
@@ -281,6 +298,16 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 
 			cmd.Printf("View the Web UI: %s\n", accessURLParsed.String())
 
+			// The wildcard access URL may be provided with or without a
+			// scheme, but only the hostname is used for routing.
+			appHostname := strings.TrimPrefix(strings.TrimPrefix(wildcardAccessURL, "https://"), "http://")
+			if appHostname != "" {
+				err = httpapi.ValidateWildcardHostname(appHostname)
+				if err != nil {
+					return xerrors.Errorf("parse wildcard access url: %w", err)
+				}
+			}
+
 			// Used for zero-trust instance identity with Google Cloud.
 			googleTokenValidator, err := idtoken.NewValidator(ctx, option.WithoutAuthentication())
 			if err != nil {
@@ -350,6 +377,12 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 				return xerrors.Errorf("create derp map: %w", err)
 			}
 
+			// Template authors choose the repositories of template versions,
+			// so credentials without hosts would never be sent.
+			if (templateGitUsername != "" || templateGitPassword != "") && len(templateGitHosts) == 0 {
+				return xerrors.New("--template-git-hosts must be specified with --template-git-username or --template-git-password")
+			}
+
 			options := &coderd.Options{
 				AccessURL:                   accessURLParsed,
 				ICEServers:                  iceServers,
@@ -368,6 +401,19 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 				AutoImportTemplates:         validatedAutoImportTemplates,
 				MetricsCacheRefreshInterval: metricsCacheRefreshInterval,
 				AgentStatsRefreshInterval:   agentStatRefreshInterval,
+				ProvisionerDaemonPSK:        provisionerDaemonPSK,
+				AppHostname:                 appHostname,
+				AgentShutdownScriptTimeout:  agentShutdownScriptTimeout,
+
+				ProvisionerJobHeartbeatTimeout: provisionerJobHeartbeatTimeout,
+				TemplateGitUsername:            templateGitUsername,
+				TemplateGitPassword:            templateGitPassword,
+				TemplateGitHosts:               templateGitHosts,
+			}
+
+			options.AuditExport, err = configureAuditExport(cacheDir, auditWebhookURL, auditWebhookSecret, auditWebhookQueueDir, auditWebhookQueueSize, auditSyslogAddress, auditSyslogTLS, auditSyslogTLSCAFile)
+			if err != nil {
+				return xerrors.Errorf("configure audit export: %w", err)
 			}
 
 			if oauth2GithubClientSecret != "" {
@@ -406,6 +452,7 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 					}),
 					EmailDomain:  oidcEmailDomain,
 					AllowSignups: oidcAllowSignups,
+					GroupField:   oidcGroupField,
 				}
 			}
 
@@ -499,13 +546,22 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 				}
 				defer closeWorkspacesFunc()
 
+				closeAgentResourcesFunc, err := prometheusmetrics.AgentResources(ctx, options.PrometheusRegistry, options.Database, 0)
+				if err != nil {
+					return xerrors.Errorf("register agent resources prometheus metric: %w", err)
+				}
+				defer closeAgentResourcesFunc()
+
 				//nolint:revive
 				defer serveHandler(ctx, logger, promhttp.InstrumentMetricHandler(
 					options.PrometheusRegistry, promhttp.HandlerFor(options.PrometheusRegistry, promhttp.HandlerOpts{}),
 				), promAddress, "prometheus")()
 			}
 
-			coderAPI := newAPI(options)
+			coderAPI, err := newAPI(options)
+			if err != nil {
+				return xerrors.Errorf("create coder API: %w", err)
+			}
 			defer coderAPI.Close()
 
 			client := codersdk.New(localURL)
@@ -534,7 +590,7 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 				}
 			}()
 			for i := 0; uint8(i) < provisionerDaemonCount; i++ {
-				daemon, err := newProvisionerDaemon(ctx, coderAPI, logger, cacheDir, errCh, false)
+				daemon, err := newProvisionerDaemon(ctx, coderAPI.ListenProvisionerDaemon, logger, cacheDir, errCh, false)
 				if err != nil {
 					return xerrors.Errorf("create provisioner daemon: %w", err)
 				}
@@ -748,8 +804,33 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 		},
 	})
 
+	cliflag.StringVarP(root.Flags(), &auditWebhookURL, "audit-webhook-url", "", "CODER_AUDIT_WEBHOOK_URL", "",
+		"Specifies a URL that audit logs are exported to in batches as JSON. Requires an enterprise license.")
+	cliflag.StringVarP(root.Flags(), &auditWebhookSecret, "audit-webhook-secret", "", "CODER_AUDIT_WEBHOOK_SECRET", "",
+		"Specifies a secret used to sign audit webhook requests. The hex encoded HMAC-SHA256 of the body is sent in the X-Coder-Signature header.")
+	cliflag.StringVarP(root.Flags(), &auditWebhookQueueDir, "audit-webhook-queue-dir", "", "CODER_AUDIT_WEBHOOK_QUEUE_DIR", "",
+		"Specifies a directory that stores audit logs until they are delivered to the webhook. Defaults to a directory in --cache-dir.")
+	cliflag.IntVarP(root.Flags(), &auditWebhookQueueSize, "audit-webhook-queue-size", "", "CODER_AUDIT_WEBHOOK_QUEUE_SIZE", 10000,
+		"Specifies the maximum number of undelivered audit logs to store for the webhook.")
+	cliflag.StringVarP(root.Flags(), &auditSyslogAddress, "audit-syslog-address", "", "CODER_AUDIT_SYSLOG_ADDRESS", "",
+		"Specifies the host:port of a syslog receiver that audit logs are exported to over TCP in RFC 5424 format. Requires an enterprise license.")
+	cliflag.BoolVarP(root.Flags(), &auditSyslogTLS, "audit-syslog-tls", "", "CODER_AUDIT_SYSLOG_TLS", false,
+		"Specifies if TLS is used to connect to the syslog receiver.")
+	cliflag.StringVarP(root.Flags(), &auditSyslogTLSCAFile, "audit-syslog-tls-ca-file", "", "CODER_AUDIT_SYSLOG_TLS_CA_FILE", "",
+		"Specifies a PEM encoded CA certificate used to verify the syslog receiver. The system certificates are used if unspecified.")
+	cliflag.DurationVarP(root.Flags(), &agentShutdownScriptTimeout, "agent-shutdown-script-timeout", "", "CODER_AGENT_SHUTDOWN_SCRIPT_TIMEOUT", 5*time.Minute,
+		"Specifies how long stop and delete builds wait for workspace agent shutdown scripts to finish.")
+	cliflag.DurationVarP(root.Flags(), &provisionerJobHeartbeatTimeout, "provisioner-job-heartbeat-timeout", "", "CODER_PROVISIONER_JOB_HEARTBEAT_TIMEOUT", 5*time.Minute,
+		"Specifies how long a running provisioner job may go without an update from its provisioner daemon before it is considered orphaned and failed.")
+	cliflag.StringVarP(root.Flags(), &templateGitUsername, "template-git-username", "", "CODER_TEMPLATE_GIT_USERNAME", "",
+		"Specifies the username built-in provisioner daemons authenticate with over HTTP when they clone template versions from git repositories.")
+	cliflag.StringVarP(root.Flags(), &templateGitPassword, "template-git-password", "", "CODER_TEMPLATE_GIT_PASSWORD", "",
+		"Specifies the password or access token built-in provisioner daemons authenticate with over HTTP when they clone template versions from git repositories.")
+	cliflag.StringArrayVarP(root.Flags(), &templateGitHosts, "template-git-hosts", "", "CODER_TEMPLATE_GIT_HOSTS", nil,
+		"Specifies the hosts template versions may be cloned from, such as github.com. Any host is allowed if unspecified, but the template git credentials are only sent to the hosts listed.")
 	cliflag.DurationVarP(root.Flags(), &autobuildPollInterval, "autobuild-poll-interval", "", "CODER_AUTOBUILD_POLL_INTERVAL", time.Minute, "Specifies the interval at which to poll for and execute automated workspace build operations.")
 	cliflag.StringVarP(root.Flags(), &accessURL, "access-url", "", "CODER_ACCESS_URL", "", "Specifies the external URL to access Coder.")
+	cliflag.StringVarP(root.Flags(), &wildcardAccessURL, "wildcard-access-url", "", "CODER_WILDCARD_ACCESS_URL", "", "Specifies the wildcard hostname to use for workspace applications in the form \"*.example.com\". Applications are served at app--agent--workspace--user.example.com.")
 	cliflag.StringVarP(root.Flags(), &address, "address", "a", "CODER_ADDRESS", "127.0.0.1:3000", "The address to serve the API and dashboard.")
 	cliflag.StringVarP(root.Flags(), &derpConfigURL, "derp-config-url", "", "CODER_DERP_CONFIG_URL", "",
 		"Specifies a URL to periodically fetch a DERP map. See: https://tailscale.com/kb/1118/custom-derp-servers/")
@@ -784,6 +865,8 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 	_ = root.Flags().MarkHidden("in-memory")
 	cliflag.StringVarP(root.Flags(), &postgresURL, "postgres-url", "", "CODER_PG_CONNECTION_URL", "", "The URL of a PostgreSQL database to connect to. If empty, PostgreSQL binaries will be downloaded from Maven (https://repo1.maven.org/maven2) and store all data in the config root. Access the built-in database with \"coder server postgres-builtin-url\"")
 	cliflag.Uint8VarP(root.Flags(), &provisionerDaemonCount, "provisioner-daemons", "", "CODER_PROVISIONER_DAEMONS", 3, "The amount of provisioner daemons to create on start.")
+	cliflag.StringVarP(root.Flags(), &provisionerDaemonPSK, "provisioner-daemon-psk", "", "CODER_PROVISIONER_DAEMON_PSK", "",
+		"Specifies a pre-shared key that external provisioner daemons use to authenticate. External provisioner daemons are disabled if unset.")
 	cliflag.StringVarP(root.Flags(), &oauth2GithubClientID, "oauth2-github-client-id", "", "CODER_OAUTH2_GITHUB_CLIENT_ID", "",
 		"Specifies a client ID to use for oauth2 with GitHub.")
 	cliflag.StringVarP(root.Flags(), &oauth2GithubClientSecret, "oauth2-github-client-secret", "", "CODER_OAUTH2_GITHUB_CLIENT_SECRET", "",
@@ -804,6 +887,8 @@ func Server(newAPI func(*coderd.Options) *coderd.API) *cobra.Command {
 		"Specifies a client secret to use for OIDC.")
 	cliflag.StringVarP(root.Flags(), &oidcEmailDomain, "oidc-email-domain", "", "CODER_OIDC_EMAIL_DOMAIN", "",
 		"Specifies an email domain that clients authenticating with OIDC must match.")
+	cliflag.StringVarP(root.Flags(), &oidcGroupField, "oidc-group-field", "", "CODER_OIDC_GROUP_FIELD", "groups",
+		"Specifies the OIDC claim containing the names of the groups a user belongs to. Users are added to and removed from existing groups with matching names on login.")
 	cliflag.StringVarP(root.Flags(), &oidcIssuerURL, "oidc-issuer-url", "", "CODER_OIDC_ISSUER_URL", "",
 		"Specifies an issuer URL to use for OIDC.")
 	cliflag.StringArrayVarP(root.Flags(), &oidcScopes, "oidc-scopes", "", "CODER_OIDC_SCOPES", []string{oidc.ScopeOpenID, "profile", "email"},
@@ -911,7 +996,7 @@ func shutdownWithTimeout(s interface{ Shutdown(context.Context) error }, timeout
 }
 
 // nolint:revive
-func newProvisionerDaemon(ctx context.Context, coderAPI *coderd.API,
+func newProvisionerDaemon(ctx context.Context, dialer provisionerd.Dialer,
 	logger slog.Logger, cacheDir string, errCh chan error, dev bool,
 ) (srv *provisionerd.Server, err error) {
 	ctx, cancel := context.WithCancel(ctx)
@@ -979,7 +1064,7 @@ func newProvisionerDaemon(ctx context.Context, coderAPI *coderd.API,
 		}()
 		provisioners[string(database.ProvisionerTypeEcho)] = proto.NewDRPCProvisionerClient(provisionersdk.Conn(echoClient))
 	}
-	return provisionerd.New(coderAPI.ListenProvisionerDaemon, &provisionerd.Options{
+	return provisionerd.New(dialer, &provisionerd.Options{
 		Logger:         logger,
 		PollInterval:   500 * time.Millisecond,
 		UpdateInterval: 500 * time.Millisecond,
@@ -1085,6 +1170,50 @@ func configureTLS(listener net.Listener, tlsMinVersion, tlsClientAuth, tlsCertFi
 	return tls.NewListener(listener, tlsConfig), nil
 }
 
+func configureAuditExport(cacheDir, webhookURL, webhookSecret, webhookQueueDir string, webhookQueueSize int, syslogAddress string, syslogTLS bool, syslogTLSCAFile string) (audit.ExportOptions, error) {
+	export := audit.ExportOptions{
+		WebhookSecret:    webhookSecret,
+		WebhookQueueDir:  webhookQueueDir,
+		WebhookQueueSize: webhookQueueSize,
+		SyslogAddress:    syslogAddress,
+	}
+	if webhookURL != "" {
+		parsed, err := url.Parse(webhookURL)
+		if err != nil {
+			return audit.ExportOptions{}, xerrors.Errorf("parse audit webhook url: %w", err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return audit.ExportOptions{}, xerrors.Errorf("audit webhook url %q must be http or https", webhookURL)
+		}
+		export.WebhookURL = parsed
+		if export.WebhookQueueDir == "" {
+			export.WebhookQueueDir = filepath.Join(cacheDir, "audit-webhook-queue")
+		}
+	}
+	if syslogAddress != "" {
+		if _, _, err := net.SplitHostPort(syslogAddress); err != nil {
+			return audit.ExportOptions{}, xerrors.Errorf("parse audit syslog address: %w", err)
+		}
+	}
+	if syslogTLS {
+		export.SyslogTLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+		if syslogTLSCAFile != "" {
+			data, err := os.ReadFile(syslogTLSCAFile)
+			if err != nil {
+				return audit.ExportOptions{}, xerrors.Errorf("read %q: %w", syslogTLSCAFile, err)
+			}
+			caPool := x509.NewCertPool()
+			if !caPool.AppendCertsFromPEM(data) {
+				return audit.ExportOptions{}, xerrors.Errorf("failed to parse CA certificate in audit-syslog-tls-ca-file")
+			}
+			export.SyslogTLSConfig.RootCAs = caPool
+		}
+	}
+	return export, nil
+}
+
 func configureGithubOAuth2(accessURL *url.URL, clientID, clientSecret string, allowSignups bool, allowOrgs []string, rawTeams []string, enterpriseBaseURL string) (*coderd.GithubOAuth2Config, error) {
 	redirectURL, err := accessURL.Parse("/api/v2/users/oauth2/github/callback")
 	if err != nil {
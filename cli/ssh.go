@@ -44,6 +44,7 @@ func ssh() *cobra.Command {
 		identityAgent  string
 		wsPollInterval time.Duration
 		wireguard      bool
+		noWait         bool
 	)
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
@@ -83,6 +84,7 @@ func ssh() *cobra.Command {
 				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
 					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
 				},
+				WaitForReady: workspace.TemplateWaitForAgentReady && !noWait,
 			})
 			if err != nil {
 				return xerrors.Errorf("await agent: %w", err)
@@ -216,6 +218,7 @@ func ssh() *cobra.Command {
 	cliflag.DurationVarP(cmd.Flags(), &wsPollInterval, "workspace-poll-interval", "", "CODER_WORKSPACE_POLL_INTERVAL", workspacePollInterval, "Specifies how often to poll for workspace automated shutdown.")
 	cliflag.BoolVarP(cmd.Flags(), &wireguard, "wireguard", "", "CODER_SSH_WIREGUARD", false, "Whether to use Wireguard for SSH tunneling.")
 	_ = cmd.Flags().MarkHidden("wireguard")
+	cliflag.BoolVarP(cmd.Flags(), &noWait, "no-wait", "", "CODER_SSH_NO_WAIT", false, "Connect without waiting for the startup script to finish, even if the template requires it.")
 
 	return cmd
 }
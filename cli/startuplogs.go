@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func startupLogs() *cobra.Command {
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "startup-logs <workspace>[.<agent>]",
+		Short: "Print the output of a workspace agent's startup script",
+		Args:  cobra.ExactArgs(1),
+		Example: formatExamples(
+			example{
+				Description: "Print the startup script output of a workspace",
+				Command:     "coder startup-logs my-workspace",
+			},
+			example{
+				Description: "Follow the output of a specific agent while the script runs",
+				Command:     "coder startup-logs my-workspace.main --follow",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			_, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, args[0], false)
+			if err != nil {
+				return err
+			}
+
+			if !follow {
+				logs, err := client.WorkspaceAgentStartupLogs(ctx, workspaceAgent.ID, 0)
+				if err != nil {
+					return xerrors.Errorf("fetch startup logs: %w", err)
+				}
+				for _, log := range logs {
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), log.Output)
+				}
+				return nil
+			}
+
+			logs, err := client.WorkspaceAgentStartupLogsAfter(ctx, workspaceAgent.ID, 0)
+			if err != nil {
+				return xerrors.Errorf("follow startup logs: %w", err)
+			}
+			for log := range logs {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), log.Output)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Stream new output until the startup script finishes.")
+	return cmd
+}
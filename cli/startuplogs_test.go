@@ -0,0 +1,39 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestStartupLogs(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	client, workspace, agentToken := setupWorkspaceForSSH(t)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = agentToken
+	err := agentClient.PatchWorkspaceAgentStartupLogs(ctx, []agent.StartupLog{
+		{CreatedAt: time.Now(), Output: "installing dependencies"},
+		{CreatedAt: time.Now(), Output: "done"},
+	})
+	require.NoError(t, err)
+
+	cmd, root := clitest.New(t, "startup-logs", workspace.Name)
+	clitest.SetupConfig(t, client, root)
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "installing dependencies\ndone\n", buf.String())
+}
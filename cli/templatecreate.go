@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -24,6 +25,10 @@ func templateCreate() *cobra.Command {
 		directory            string
 		provisioner          string
 		parameterFile        string
+		provisionerTags      []string
+		gitURL               string
+		gitRef               string
+		gitSubdirectory      string
 		maxTTL               time.Duration
 		minAutostartInterval time.Duration
 	)
@@ -43,9 +48,12 @@ func templateCreate() *cobra.Command {
 			}
 
 			var templateName string
-			if len(args) == 0 {
+			switch {
+			case len(args) == 0 && gitURL != "":
+				templateName = gitTemplateName(gitURL, gitSubdirectory)
+			case len(args) == 0:
 				templateName = filepath.Base(directory)
-			} else {
+			default:
 				templateName = args[0]
 			}
 
@@ -54,39 +62,49 @@ func templateCreate() *cobra.Command {
 				return xerrors.Errorf("A template already exists named %q!", templateName)
 			}
 
-			// Confirm upload of the directory.
-			prettyDir := prettyDirectoryPath(directory)
-			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
-				Text:      fmt.Sprintf("Create and upload %q?", prettyDir),
-				IsConfirm: true,
-				Default:   cliui.ConfirmYes,
-			})
-			if err != nil {
-				return err
-			}
-
-			spin := spinner.New(spinner.CharSets[5], 100*time.Millisecond)
-			spin.Writer = cmd.OutOrStdout()
-			spin.Suffix = cliui.Styles.Keyword.Render(" Uploading directory...")
-			spin.Start()
-			defer spin.Stop()
-			archive, err := provisionersdk.Tar(directory, provisionersdk.TemplateArchiveLimit)
+			tags, err := parseProvisionerTags(provisionerTags)
 			if err != nil {
 				return err
 			}
 
-			resp, err := client.Upload(cmd.Context(), codersdk.ContentTypeTar, archive)
-			if err != nil {
-				return err
+			var fileHash string
+			if gitURL != "" {
+				_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+					Text:      fmt.Sprintf("Create from %q?", prettyGitSource(gitURL, gitRef, gitSubdirectory)),
+					IsConfirm: true,
+					Default:   cliui.ConfirmYes,
+				})
+				if err != nil {
+					return err
+				}
+			} else {
+				// Confirm upload of the directory.
+				prettyDir := prettyDirectoryPath(directory)
+				_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+					Text:      fmt.Sprintf("Create and upload %q?", prettyDir),
+					IsConfirm: true,
+					Default:   cliui.ConfirmYes,
+				})
+				if err != nil {
+					return err
+				}
+
+				fileHash, err = uploadTemplateDirectory(cmd, client, directory)
+				if err != nil {
+					return err
+				}
 			}
-			spin.Stop()
 
 			job, _, err := createValidTemplateVersion(cmd, createValidTemplateVersionArgs{
-				Client:        client,
-				Organization:  organization,
-				Provisioner:   database.ProvisionerType(provisioner),
-				FileHash:      resp.Hash,
-				ParameterFile: parameterFile,
+				Client:          client,
+				Organization:    organization,
+				Provisioner:     database.ProvisionerType(provisioner),
+				FileHash:        fileHash,
+				GitURL:          gitURL,
+				GitRef:          gitRef,
+				GitSubdirectory: gitSubdirectory,
+				ParameterFile:   parameterFile,
+				ProvisionerTags: tags,
 			})
 			if err != nil {
 				return err
@@ -126,6 +144,10 @@ func templateCreate() *cobra.Command {
 	cmd.Flags().StringVarP(&directory, "directory", "d", currentDirectory, "Specify the directory to create from")
 	cmd.Flags().StringVarP(&provisioner, "test.provisioner", "", "terraform", "Customize the provisioner backend")
 	cmd.Flags().StringVarP(&parameterFile, "parameter-file", "", "", "Specify a file path with parameter values.")
+	cmd.Flags().StringArrayVarP(&provisionerTags, "provisioner-tag", "", nil, "Specify a set of tags to target provisioner daemons. Formatted as: key=value.")
+	cmd.Flags().StringVarP(&gitURL, "git-url", "", "", "Specify the URL of a git repository to create from instead of a directory. The repository is cloned by the provisioner daemon.")
+	cmd.Flags().StringVarP(&gitRef, "git-ref", "", "", "Specify the branch, tag or commit of the git repository to create from. Defaults to the default branch.")
+	cmd.Flags().StringVarP(&gitSubdirectory, "git-subdirectory", "", "", "Specify the directory of the git repository the template is in.")
 	cmd.Flags().DurationVarP(&maxTTL, "max-ttl", "", 24*time.Hour, "Specify a maximum TTL for workspaces created from this template.")
 	cmd.Flags().DurationVarP(&minAutostartInterval, "min-autostart-interval", "", time.Hour, "Specify a minimum autostart interval for workspaces created from this template.")
 	// This is for testing!
@@ -138,11 +160,19 @@ func templateCreate() *cobra.Command {
 }
 
 type createValidTemplateVersionArgs struct {
-	Client        *codersdk.Client
-	Organization  codersdk.Organization
-	Provisioner   database.ProvisionerType
-	FileHash      string
-	ParameterFile string
+	Client       *codersdk.Client
+	Organization codersdk.Organization
+	Provisioner  database.ProvisionerType
+	FileHash     string
+	// GitURL imports the version from a git repository instead of the
+	// uploaded file of FileHash.
+	GitURL          string
+	GitRef          string
+	GitSubdirectory string
+	ParameterFile   string
+	// ProvisionerTags restricts the version to provisioner daemons
+	// started with matching tags.
+	ProvisionerTags map[string]string
 	// Template is only required if updating a template's active version.
 	Template *codersdk.Template
 	// ReuseParameters will attempt to reuse params from the Template field
@@ -160,6 +190,13 @@ func createValidTemplateVersion(cmd *cobra.Command, args createValidTemplateVers
 		StorageSource:   args.FileHash,
 		Provisioner:     codersdk.ProvisionerType(args.Provisioner),
 		ParameterValues: parameters,
+		ProvisionerTags: args.ProvisionerTags,
+	}
+	if args.GitURL != "" {
+		req.StorageMethod = codersdk.ProvisionerStorageMethodGit
+		req.StorageSource = args.GitURL
+		req.GitRef = args.GitRef
+		req.GitSubdirectory = args.GitSubdirectory
 	}
 	if args.Template != nil {
 		req.TemplateID = args.Template.ID
@@ -329,3 +366,43 @@ func prettyDirectoryPath(dir string) string {
 	}
 	return pretty
 }
+
+// uploadTemplateDirectory archives and uploads a template directory, and
+// returns the hash of the uploaded file.
+func uploadTemplateDirectory(cmd *cobra.Command, client *codersdk.Client, directory string) (string, error) {
+	spin := spinner.New(spinner.CharSets[5], 100*time.Millisecond)
+	spin.Writer = cmd.OutOrStdout()
+	spin.Suffix = cliui.Styles.Keyword.Render(" Uploading directory...")
+	spin.Start()
+	defer spin.Stop()
+	archive, err := provisionersdk.Tar(directory, provisionersdk.TemplateArchiveLimit)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Upload(cmd.Context(), codersdk.ContentTypeTar, archive)
+	if err != nil {
+		return "", err
+	}
+	return resp.Hash, nil
+}
+
+// prettyGitSource formats a git repository source as url@ref//subdirectory.
+func prettyGitSource(url, ref, subdirectory string) string {
+	pretty := url
+	if ref != "" {
+		pretty += "@" + ref
+	}
+	if subdirectory != "" {
+		pretty += "//" + subdirectory
+	}
+	return pretty
+}
+
+// gitTemplateName returns the default name of a template in a git
+// repository, which is the name of its subdirectory or of the repository.
+func gitTemplateName(url, subdirectory string) string {
+	if subdirectory != "" {
+		return path.Base(subdirectory)
+	}
+	return strings.TrimSuffix(path.Base(strings.TrimRight(url, "/")), ".git")
+}
@@ -1,6 +1,7 @@
 package cli_test
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -85,6 +86,49 @@ func TestTemplateCreate(t *testing.T) {
 		require.NoError(t, <-execDone)
 	})
 
+	t.Run("FromGit", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		url, commit := coderdtest.CreateGitRepository(t, &echo.Responses{
+			Parse:     echo.ParseComplete,
+			Provision: provisionCompleteWithAgent,
+		})
+		cmd, root := clitest.New(t, "templates", "create", "my-template", "--git-url", url, "--test.provisioner", string(database.ProvisionerTypeEcho))
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetOut(pty.Output())
+
+		execDone := make(chan error)
+		go func() {
+			execDone <- cmd.Execute()
+		}()
+
+		matches := []struct {
+			match string
+			write string
+		}{
+			{match: "Create from", write: "yes"},
+			{match: "smith (linux, i386)"},
+			{match: "Confirm create?", write: "yes"},
+		}
+		for _, m := range matches {
+			pty.ExpectMatch(m.match)
+			if len(m.write) > 0 {
+				pty.WriteLine(m.write)
+			}
+		}
+
+		require.NoError(t, <-execDone)
+
+		template, err := client.TemplateByName(context.Background(), user.OrganizationID, "my-template")
+		require.NoError(t, err)
+		version, err := client.TemplateVersion(context.Background(), template.ActiveVersionID)
+		require.NoError(t, err)
+		require.Equal(t, commit, version.GitCommitSHA)
+	})
+
 	t.Run("WithParameter", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
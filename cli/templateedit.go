@@ -18,6 +18,10 @@ func templateEdit() *cobra.Command {
 		icon                 string
 		maxTTL               time.Duration
 		minAutostartInterval time.Duration
+		inactivityTTL        time.Duration
+		maxJobDuration       time.Duration
+		waitForAgentReady    bool
+		sessionRecording     bool
 	)
 
 	cmd := &cobra.Command{
@@ -38,6 +42,15 @@ func templateEdit() *cobra.Command {
 				return xerrors.Errorf("get workspace template: %w", err)
 			}
 
+			// A zero inactivity TTL disables inactivity-based autostop, so
+			// keep the current value unless the flag was provided.
+			if !cmd.Flags().Changed("inactivity-ttl") {
+				inactivityTTL = time.Duration(template.InactivityTTLMillis) * time.Millisecond
+			}
+			if !cmd.Flags().Changed("max-job-duration") {
+				maxJobDuration = time.Duration(template.MaxJobDurationMillis) * time.Millisecond
+			}
+
 			// NOTE: coderd will ignore empty fields.
 			req := codersdk.UpdateTemplateMeta{
 				Name:                       name,
@@ -45,6 +58,14 @@ func templateEdit() *cobra.Command {
 				Icon:                       icon,
 				MaxTTLMillis:               maxTTL.Milliseconds(),
 				MinAutostartIntervalMillis: minAutostartInterval.Milliseconds(),
+				InactivityTTLMillis:        inactivityTTL.Milliseconds(),
+				MaxJobDurationMillis:       maxJobDuration.Milliseconds(),
+			}
+			if cmd.Flags().Changed("wait-for-agent-ready") {
+				req.WaitForAgentReady = &waitForAgentReady
+			}
+			if cmd.Flags().Changed("session-recording") {
+				req.SessionRecording = &sessionRecording
 			}
 
 			_, err = client.UpdateTemplateMeta(cmd.Context(), template.ID, req)
@@ -61,6 +82,10 @@ func templateEdit() *cobra.Command {
 	cmd.Flags().StringVarP(&icon, "icon", "", "", "Edit the template icon path")
 	cmd.Flags().DurationVarP(&maxTTL, "max-ttl", "", 0, "Edit the template maximum time before shutdown - workspaces created from this template cannot stay running longer than this.")
 	cmd.Flags().DurationVarP(&minAutostartInterval, "min-autostart-interval", "", 0, "Edit the template minimum autostart interval - workspaces created from this template must wait at least this long between autostarts.")
+	cmd.Flags().DurationVarP(&inactivityTTL, "inactivity-ttl", "", 0, "Edit the template inactivity TTL - running workspaces created from this template are stopped once they have been idle this long. Activity such as SSH sessions, terminals and app traffic pushes the deadline back. Set to 0 to disable.")
+	cmd.Flags().DurationVarP(&maxJobDuration, "max-job-duration", "", 0, "Edit the template maximum job duration - builds and imports for this template are failed when they run longer than this. Set to 0 to disable.")
+	cmd.Flags().BoolVarP(&waitForAgentReady, "wait-for-agent-ready", "", false, "Edit whether \"coder ssh\" waits for the workspace agent's startup script to finish before connecting.")
+	cmd.Flags().BoolVarP(&sessionRecording, "session-recording", "", false, "Edit whether the workspace agent records interactive terminal sessions. Recordings can be downloaded by admins.")
 	cliui.AllowSkipPrompt(cmd)
 
 	return cmd
@@ -36,6 +36,8 @@ func TestTemplateEdit(t *testing.T) {
 		icon := "/icons/new-icon.png"
 		maxTTL := 12 * time.Hour
 		minAutostartInterval := time.Minute
+		inactivityTTL := 2 * time.Hour
+		maxJobDuration := 30 * time.Minute
 		cmdArgs := []string{
 			"templates",
 			"edit",
@@ -45,6 +47,10 @@ func TestTemplateEdit(t *testing.T) {
 			"--icon", icon,
 			"--max-ttl", maxTTL.String(),
 			"--min-autostart-interval", minAutostartInterval.String(),
+			"--inactivity-ttl", inactivityTTL.String(),
+			"--max-job-duration", maxJobDuration.String(),
+			"--wait-for-agent-ready",
+			"--session-recording",
 		}
 		cmd, root := clitest.New(t, cmdArgs...)
 		clitest.SetupConfig(t, client, root)
@@ -61,6 +67,10 @@ func TestTemplateEdit(t *testing.T) {
 		assert.Equal(t, icon, updated.Icon)
 		assert.Equal(t, maxTTL.Milliseconds(), updated.MaxTTLMillis)
 		assert.Equal(t, minAutostartInterval.Milliseconds(), updated.MinAutostartIntervalMillis)
+		assert.Equal(t, inactivityTTL.Milliseconds(), updated.InactivityTTLMillis)
+		assert.Equal(t, maxJobDuration.Milliseconds(), updated.MaxJobDurationMillis)
+		assert.True(t, updated.WaitForAgentReady)
+		assert.True(t, updated.SessionRecording)
 	})
 
 	t.Run("NotModified", func(t *testing.T) {
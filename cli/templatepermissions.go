@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func templatePermissions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "permissions",
+		Short:   "Manage which users and groups can use or administer a template",
+		Aliases: []string{"permission", "acl"},
+		Example: formatExamples(
+			example{
+				Description: "List the users and groups that have access to a template",
+				Command:     "coder templates permissions list my-template",
+			},
+			example{
+				Description: "Allow a group to use a template and revoke a user's access",
+				Command:     "coder templates permissions set my-template --group developers=use --user alice=none",
+			},
+		),
+	}
+	cmd.AddCommand(
+		templatePermissionsList(),
+		templatePermissionsSet(),
+	)
+
+	return cmd
+}
+
+func templatePermissionsList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list <template>",
+		Args:  cobra.ExactArgs(1),
+		Short: "List the users and groups that have access to a template",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create client: %w", err)
+			}
+			organization, err := currentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(cmd.Context(), organization.ID, args[0])
+			if err != nil {
+				return xerrors.Errorf("get template by name: %w", err)
+			}
+			acl, err := client.TemplateACL(cmd.Context(), template.ID)
+			if err != nil {
+				return xerrors.Errorf("get template acl: %w", err)
+			}
+
+			out, err := displayTemplateACL(acl)
+			if err != nil {
+				return xerrors.Errorf("render table: %w", err)
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+}
+
+func templatePermissionsSet() *cobra.Command {
+	var (
+		userPerms  []string
+		groupPerms []string
+	)
+	cmd := &cobra.Command{
+		Use:   "set <template>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Grant or revoke access to a template",
+		Long: "Grant or revoke access to a template. Roles are \"use\", which allows creating " +
+			"workspaces from the template, \"admin\", which also allows managing the template, " +
+			"and \"none\", which revokes access.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(userPerms) == 0 && len(groupPerms) == 0 {
+				return xerrors.New("at least one --user or --group must be specified")
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create client: %w", err)
+			}
+			organization, err := currentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(cmd.Context(), organization.ID, args[0])
+			if err != nil {
+				return xerrors.Errorf("get template by name: %w", err)
+			}
+
+			req := codersdk.UpdateTemplateACL{
+				UserPerms:  map[string]codersdk.TemplateRole{},
+				GroupPerms: map[string]codersdk.TemplateRole{},
+			}
+			for _, perm := range userPerms {
+				name, role, err := parseTemplatePermission(perm)
+				if err != nil {
+					return xerrors.Errorf("parse --user %q: %w", perm, err)
+				}
+				user, err := client.User(cmd.Context(), name)
+				if err != nil {
+					return xerrors.Errorf("get user %q: %w", name, err)
+				}
+				req.UserPerms[user.ID.String()] = role
+			}
+			if len(groupPerms) > 0 {
+				groups, err := client.GroupsByOrganization(cmd.Context(), organization.ID)
+				if err != nil {
+					return xerrors.Errorf("get groups: %w", err)
+				}
+				for _, perm := range groupPerms {
+					name, role, err := parseTemplatePermission(perm)
+					if err != nil {
+						return xerrors.Errorf("parse --group %q: %w", perm, err)
+					}
+					found := false
+					for _, group := range groups {
+						if group.Name == name {
+							req.GroupPerms[group.ID.String()] = role
+							found = true
+							break
+						}
+					}
+					if !found {
+						return xerrors.Errorf("group %q not found", name)
+					}
+				}
+			}
+
+			err = client.UpdateTemplateACL(cmd.Context(), template.ID, req)
+			if err != nil {
+				return xerrors.Errorf("update template acl: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Updated permissions of template %s!\n", cliui.Styles.Keyword.Render(template.Name))
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVarP(&userPerms, "user", "u", nil, "Specifies a user's role on the template. Formatted as: username=use|admin|none.")
+	cmd.Flags().StringArrayVarP(&groupPerms, "group", "g", nil, "Specifies a group's role on the template. Formatted as: name=use|admin|none.")
+	return cmd
+}
+
+// parseTemplatePermission parses a "name=role" pair.
+func parseTemplatePermission(perm string) (string, codersdk.TemplateRole, error) {
+	parts := strings.SplitN(perm, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", xerrors.New("must be formatted as name=role")
+	}
+	switch role := codersdk.TemplateRole(parts[1]); role {
+	case codersdk.TemplateRoleUse, codersdk.TemplateRoleAdmin:
+		return parts[0], role, nil
+	case "none":
+		return parts[0], codersdk.TemplateRoleDeleted, nil
+	default:
+		return "", "", xerrors.Errorf("role must be one of \"use\", \"admin\" or \"none\", got %q", parts[1])
+	}
+}
+
+type templateACLRow struct {
+	Type string `table:"type"`
+	Name string `table:"name"`
+	Role string `table:"role"`
+}
+
+// displayTemplateACL will return a table displaying the users and groups
+// with access to a template.
+func displayTemplateACL(acl codersdk.TemplateACL) (string, error) {
+	rows := make([]templateACLRow, 0, len(acl.Users)+len(acl.Groups))
+	for _, group := range acl.Groups {
+		rows = append(rows, templateACLRow{
+			Type: "group",
+			Name: group.Name,
+			Role: string(group.Role),
+		})
+	}
+	for _, user := range acl.Users {
+		rows = append(rows, templateACLRow{
+			Type: "user",
+			Name: user.Username,
+			Role: string(user.Role),
+		})
+	}
+
+	return cliui.DisplayTable(rows, "type", nil)
+}
@@ -6,22 +6,24 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/codersdk"
-	"github.com/coder/coder/provisionersdk"
 )
 
 func templatePush() *cobra.Command {
 	var (
-		directory     string
-		provisioner   string
-		parameterFile string
-		alwaysPrompt  bool
+		directory       string
+		provisioner     string
+		parameterFile   string
+		provisionerTags []string
+		gitURL          string
+		gitRef          string
+		gitSubdirectory string
+		alwaysPrompt    bool
 	)
 
 	cmd := &cobra.Command{
@@ -39,8 +41,11 @@ func templatePush() *cobra.Command {
 			}
 
 			name := filepath.Base(directory)
-			if len(args) > 0 {
+			switch {
+			case len(args) > 0:
 				name = args[0]
+			case gitURL != "":
+				name = gitTemplateName(gitURL, gitSubdirectory)
 			}
 
 			template, err := client.TemplateByName(cmd.Context(), organization.ID, name)
@@ -48,38 +53,49 @@ func templatePush() *cobra.Command {
 				return err
 			}
 
-			// Confirm upload of the directory.
-			prettyDir := prettyDirectoryPath(directory)
-			_, err = cliui.Prompt(cmd, cliui.PromptOptions{
-				Text:      fmt.Sprintf("Upload %q?", prettyDir),
-				IsConfirm: true,
-				Default:   cliui.ConfirmYes,
-			})
+			tags, err := parseProvisionerTags(provisionerTags)
 			if err != nil {
 				return err
 			}
 
-			spin := spinner.New(spinner.CharSets[5], 100*time.Millisecond)
-			spin.Writer = cmd.OutOrStdout()
-			spin.Suffix = cliui.Styles.Keyword.Render(" Uploading directory...")
-			spin.Start()
-			defer spin.Stop()
-			content, err := provisionersdk.Tar(directory, provisionersdk.TemplateArchiveLimit)
-			if err != nil {
-				return err
-			}
-			resp, err := client.Upload(cmd.Context(), codersdk.ContentTypeTar, content)
-			if err != nil {
-				return err
+			var fileHash string
+			if gitURL != "" {
+				_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+					Text:      fmt.Sprintf("Push %q?", prettyGitSource(gitURL, gitRef, gitSubdirectory)),
+					IsConfirm: true,
+					Default:   cliui.ConfirmYes,
+				})
+				if err != nil {
+					return err
+				}
+			} else {
+				// Confirm upload of the directory.
+				prettyDir := prettyDirectoryPath(directory)
+				_, err = cliui.Prompt(cmd, cliui.PromptOptions{
+					Text:      fmt.Sprintf("Upload %q?", prettyDir),
+					IsConfirm: true,
+					Default:   cliui.ConfirmYes,
+				})
+				if err != nil {
+					return err
+				}
+
+				fileHash, err = uploadTemplateDirectory(cmd, client, directory)
+				if err != nil {
+					return err
+				}
 			}
-			spin.Stop()
 
 			job, _, err := createValidTemplateVersion(cmd, createValidTemplateVersionArgs{
 				Client:          client,
 				Organization:    organization,
 				Provisioner:     database.ProvisionerType(provisioner),
-				FileHash:        resp.Hash,
+				FileHash:        fileHash,
+				GitURL:          gitURL,
+				GitRef:          gitRef,
+				GitSubdirectory: gitSubdirectory,
 				ParameterFile:   parameterFile,
+				ProvisionerTags: tags,
 				Template:        &template,
 				ReuseParameters: !alwaysPrompt,
 			})
@@ -107,6 +123,10 @@ func templatePush() *cobra.Command {
 	cmd.Flags().StringVarP(&directory, "directory", "d", currentDirectory, "Specify the directory to create from")
 	cmd.Flags().StringVarP(&provisioner, "test.provisioner", "", "terraform", "Customize the provisioner backend")
 	cmd.Flags().StringVarP(&parameterFile, "parameter-file", "", "", "Specify a file path with parameter values.")
+	cmd.Flags().StringArrayVarP(&provisionerTags, "provisioner-tag", "", nil, "Specify a set of tags to target provisioner daemons. Formatted as: key=value.")
+	cmd.Flags().StringVarP(&gitURL, "git-url", "", "", "Specify the URL of a git repository to push from instead of a directory. The repository is cloned by the provisioner daemon.")
+	cmd.Flags().StringVarP(&gitRef, "git-ref", "", "", "Specify the branch, tag or commit of the git repository to push from. Defaults to the default branch.")
+	cmd.Flags().StringVarP(&gitSubdirectory, "git-subdirectory", "", "", "Specify the directory of the git repository the template is in.")
 	cmd.Flags().BoolVar(&alwaysPrompt, "always-prompt", false, "Always prompt all parameters. Does not pull parameter values from active template version")
 	cliui.AllowSkipPrompt(cmd)
 	// This is for testing!
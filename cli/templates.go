@@ -36,6 +36,7 @@ func templates() *cobra.Command {
 		templateEdit(),
 		templateInit(),
 		templateList(),
+		templatePermissions(),
 		templatePlan(),
 		templatePush(),
 		templateVersions(),
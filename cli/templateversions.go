@@ -23,10 +23,15 @@ func templateVersions() *cobra.Command {
 				Description: "List versions of a specific template",
 				Command:     "coder templates versions list my-template",
 			},
+			example{
+				Description: "Show the changes from a version of a template to its active version",
+				Command:     "coder templates versions diff my-template my-version",
+			},
 		),
 	}
 	cmd.AddCommand(
 		templateVersionsList(),
+		templateVersionsDiff(),
 	)
 
 	return cmd
@@ -99,3 +104,110 @@ func displayTemplateVersions(activeVersionID uuid.UUID, templateVersions ...code
 
 	return cliui.DisplayTable(rows, "name", nil)
 }
+
+func templateVersionsDiff() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff <template> <from-version> [to-version]",
+		Args:  cobra.RangeArgs(2, 3),
+		Short: "Show the changes between two versions of the specified template. Compares to the active version when only one version is specified",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create client: %w", err)
+			}
+			organization, err := currentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(cmd.Context(), organization.ID, args[0])
+			if err != nil {
+				return xerrors.Errorf("get template by name: %w", err)
+			}
+			from, err := client.TemplateVersionByName(cmd.Context(), template.ID, args[1])
+			if err != nil {
+				return xerrors.Errorf("get template version %q: %w", args[1], err)
+			}
+			toID := template.ActiveVersionID
+			if len(args) > 2 {
+				to, err := client.TemplateVersionByName(cmd.Context(), template.ID, args[2])
+				if err != nil {
+					return xerrors.Errorf("get template version %q: %w", args[2], err)
+				}
+				toID = to.ID
+			}
+
+			diff, err := client.TemplateVersionDiff(cmd.Context(), from.ID, toID)
+			if err != nil {
+				return xerrors.Errorf("diff template versions: %w", err)
+			}
+			_, err = fmt.Fprint(cmd.OutOrStdout(), displayTemplateVersionDiff(diff))
+			return err
+		},
+	}
+}
+
+// displayTemplateVersionDiff renders the changes between two template
+// versions, with added lines in green and removed lines in red.
+func displayTemplateVersionDiff(diff codersdk.TemplateVersionDiff) string {
+	if len(diff.Files) == 0 && len(diff.ParameterSchemas) == 0 && len(diff.Resources) == 0 {
+		return "No changes.\n"
+	}
+	marker := func(status codersdk.TemplateVersionDiffStatus) string {
+		switch status {
+		case codersdk.TemplateVersionDiffStatusAdded:
+			return cliui.Styles.Keyword.Render("+")
+		case codersdk.TemplateVersionDiffStatusRemoved:
+			return cliui.Styles.Error.Render("-")
+		default:
+			return cliui.Styles.Warn.Render("~")
+		}
+	}
+
+	var out strings.Builder
+	for _, file := range diff.Files {
+		_, _ = fmt.Fprintf(&out, "%s %s\n", marker(file.Status), cliui.Styles.Bold.Render(file.Path))
+		if file.Binary {
+			_, _ = fmt.Fprintln(&out, cliui.Styles.Placeholder.Render("Binary files differ"))
+			continue
+		}
+		for _, line := range strings.Split(file.Diff, "\n") {
+			switch {
+			case line == "":
+				continue
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+				line = cliui.Styles.Bold.Render(line)
+			case strings.HasPrefix(line, "@@"):
+				line = cliui.Styles.Placeholder.Render(line)
+			case strings.HasPrefix(line, "+"):
+				line = cliui.Styles.Keyword.Render(line)
+			case strings.HasPrefix(line, "-"):
+				line = cliui.Styles.Error.Render(line)
+			}
+			_, _ = fmt.Fprintln(&out, line)
+		}
+		_, _ = fmt.Fprintln(&out)
+	}
+	if len(diff.ParameterSchemas) > 0 {
+		_, _ = fmt.Fprintln(&out, cliui.Styles.Bold.Render("Parameters"))
+		for _, schema := range diff.ParameterSchemas {
+			line := fmt.Sprintf("  %s %s", marker(schema.Status), schema.Name)
+			if len(schema.Fields) > 0 {
+				line += cliui.Styles.Placeholder.Render(fmt.Sprintf(" (%s)", strings.Join(schema.Fields, ", ")))
+			}
+			_, _ = fmt.Fprintln(&out, line)
+		}
+		_, _ = fmt.Fprintln(&out)
+	}
+	if len(diff.Resources) > 0 {
+		_, _ = fmt.Fprintln(&out, cliui.Styles.Bold.Render("Resources"))
+		for _, resource := range diff.Resources {
+			line := fmt.Sprintf("  %s %s.%s", marker(resource.Status), resource.Type, resource.Name)
+			if len(resource.Fields) > 0 {
+				line += cliui.Styles.Placeholder.Render(fmt.Sprintf(" (%s)", strings.Join(resource.Fields, ", ")))
+			}
+			_, _ = fmt.Fprintln(&out, line)
+		}
+		_, _ = fmt.Fprintln(&out)
+	}
+	return out.String()
+}
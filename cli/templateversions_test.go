@@ -7,6 +7,8 @@ import (
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
 	"github.com/coder/coder/pty/ptytest"
 )
 
@@ -38,4 +40,44 @@ func TestTemplateVersions(t *testing.T) {
 		pty.ExpectMatch(version.CreatedByName)
 		pty.ExpectMatch("Active")
 	})
+
+	t.Run("Diff", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		newVersion := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse: echo.ParseComplete,
+			Provision: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{
+							Name: "new",
+							Type: "example",
+						}},
+					},
+				},
+			}},
+		}, template.ID)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, newVersion.ID)
+
+		cmd, root := clitest.New(t, "templates", "versions", "diff", template.Name, version.Name, newVersion.Name)
+		clitest.SetupConfig(t, client, root)
+
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetOut(pty.Output())
+
+		errC := make(chan error)
+		go func() {
+			errC <- cmd.Execute()
+		}()
+
+		require.NoError(t, <-errC)
+
+		pty.ExpectMatch("Resources")
+		pty.ExpectMatch("example.new")
+	})
 }
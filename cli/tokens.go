@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func tokens() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tokens",
+		Aliases: []string{"token"},
+		Short:   "Manage personal access tokens",
+		Long: "Tokens are long-lived API keys that can be revoked individually. " +
+			"Use them to authenticate scripts and CI pipelines.",
+		Example: formatExamples(
+			example{
+				Description: "Create a token for a CI pipeline that expires in a week",
+				Command:     "coder tokens create --name ci --lifetime 168h",
+			},
+			example{
+				Description: "List your tokens",
+				Command:     "coder tokens list",
+			},
+			example{
+				Description: "Revoke a token",
+				Command:     "coder tokens remove ci",
+			},
+		),
+	}
+	cmd.AddCommand(
+		createToken(),
+		listTokens(),
+		removeToken(),
+	)
+	return cmd
+}
+
+func createToken() *cobra.Command {
+	var (
+		name     string
+		lifetime time.Duration
+		scope    string
+	)
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a token",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			res, err := client.CreateToken(cmd.Context(), codersdk.Me, codersdk.CreateTokenRequest{
+				TokenName: name,
+				Lifetime:  lifetime,
+				Scope:     codersdk.APIKeyScope(scope),
+			})
+			if err != nil {
+				return xerrors.Errorf("create token: %w", err)
+			}
+
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), cliui.Styles.Wrap.Render(
+				"Here is your token. 🪄 It won't be shown again, so store it somewhere safe.",
+			))
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), res.Key)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&name, "name", "n", "", "A unique name to identify the token.")
+	cmd.Flags().DurationVar(&lifetime, "lifetime", 30*24*time.Hour, "How long the token is valid for.")
+	cmd.Flags().StringVar(&scope, "scope", string(codersdk.APIKeyScopeAll),
+		fmt.Sprintf("Restrict what the token can be used for. Available scopes are: %s, %s.",
+			codersdk.APIKeyScopeAll, codersdk.APIKeyScopeApplicationConnect))
+	return cmd
+}
+
+type tokenRow struct {
+	ID        string `table:"id"`
+	Name      string `table:"name"`
+	Scope     string `table:"scope"`
+	LastUsed  string `table:"last used"`
+	ExpiresAt string `table:"expires at"`
+	CreatedAt string `table:"created at"`
+}
+
+func listTokens() *cobra.Command {
+	var (
+		tokenColumns = []string{"ID", "Name", "Scope", "Last Used", "Expires At", "Created At"}
+		columns      []string
+		outputFormat string
+	)
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List your tokens",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			keys, err := client.Tokens(cmd.Context(), codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("list tokens: %w", err)
+			}
+
+			out := ""
+			switch outputFormat {
+			case "table", "":
+				rows := make([]tokenRow, 0, len(keys))
+				for _, key := range keys {
+					lastUsed := "never"
+					if !key.LastUsed.IsZero() {
+						lastUsed = key.LastUsed.Local().Format(time.Stamp)
+					}
+					rows = append(rows, tokenRow{
+						ID:        key.ID,
+						Name:      key.TokenName,
+						Scope:     string(key.Scope),
+						LastUsed:  lastUsed,
+						ExpiresAt: key.ExpiresAt.Local().Format(time.Stamp),
+						CreatedAt: key.CreatedAt.Local().Format(time.Stamp),
+					})
+				}
+				out, err = cliui.DisplayTable(rows, "", columns)
+				if err != nil {
+					return xerrors.Errorf("render table: %w", err)
+				}
+			case "json":
+				buf := new(bytes.Buffer)
+				enc := json.NewEncoder(buf)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(keys)
+				if err != nil {
+					return xerrors.Errorf("marshal tokens to JSON: %w", err)
+				}
+				out = buf.String()
+			default:
+				return xerrors.Errorf(`unknown output format %q, only "table" and "json" are supported`, outputFormat)
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+	cmd.Flags().StringArrayVarP(&columns, "column", "c", tokenColumns,
+		fmt.Sprintf("Specify a column to filter in the table. Available columns are: %s",
+			strings.Join(tokenColumns, ", ")))
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format. Available formats are: table, json.")
+	return cmd
+}
+
+func removeToken() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <id|name>",
+		Aliases: []string{"rm", "delete"},
+		Short:   "Revoke a token",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			keys, err := client.Tokens(cmd.Context(), codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("list tokens: %w", err)
+			}
+			var key *codersdk.APIKey
+			for i := range keys {
+				if keys[i].ID == args[0] || (keys[i].TokenName != "" && keys[i].TokenName == args[0]) {
+					key = &keys[i]
+					break
+				}
+			}
+			if key == nil {
+				return xerrors.Errorf("no token found with id or name %q", args[0])
+			}
+
+			err = client.DeleteAPIKey(cmd.Context(), codersdk.Me, key.ID)
+			if err != nil {
+				return xerrors.Errorf("delete token: %w", err)
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Revoked token "+cliui.Styles.Code.Render(args[0])+" at "+cliui.Styles.DateTimeStamp.Render(time.Now().Format(time.Stamp))+"!")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	client := coderdtest.New(t, nil)
+	_ = coderdtest.CreateFirstUser(t, client)
+
+	cmd, root := clitest.New(t, "tokens", "create", "--name", "ci", "--lifetime", "1h")
+	clitest.SetupConfig(t, client, root)
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err := cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+	token := strings.TrimSpace(buf.String())
+	require.Len(t, strings.Split(token, "-"), 2)
+
+	cmd, root = clitest.New(t, "tokens", "list", "--output", "json")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+	var keys []codersdk.APIKey
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &keys))
+	require.Len(t, keys, 1)
+	require.Equal(t, strings.Split(token, "-")[0], keys[0].ID)
+	require.Equal(t, "ci", keys[0].TokenName)
+
+	cmd, root = clitest.New(t, "tokens", "remove", "ci")
+	clitest.SetupConfig(t, client, root)
+	err = cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+
+	keys, err = client.Tokens(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
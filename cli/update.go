@@ -14,6 +14,7 @@ func update() *cobra.Command {
 	var (
 		parameterFile string
 		alwaysPrompt  bool
+		promptMutable bool
 	)
 
 	cmd := &cobra.Command{
@@ -30,7 +31,7 @@ func update() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if !workspace.Outdated && !alwaysPrompt {
+			if !workspace.Outdated && !alwaysPrompt && !promptMutable {
 				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Workspace isn't outdated!\n")
 				return nil
 			}
@@ -52,15 +53,28 @@ func update() *cobra.Command {
 				ExistingParams:   existingParams,
 				ParameterFile:    parameterFile,
 				NewWorkspaceName: workspace.Name,
+				PromptMutable:    promptMutable,
 			})
 			if err != nil {
 				return nil
 			}
 
+			// Parameters can only be changed by start builds, so the
+			// workspace is started if a mutable parameter was prompted.
+			transition := workspace.LatestBuild.Transition
+			if promptMutable {
+				for _, param := range parameters {
+					for _, existing := range existingParams {
+						if param.Name == existing.Name {
+							transition = codersdk.WorkspaceTransitionStart
+						}
+					}
+				}
+			}
 			before := time.Now()
 			build, err := client.CreateWorkspaceBuild(cmd.Context(), workspace.ID, codersdk.CreateWorkspaceBuildRequest{
 				TemplateVersionID: template.ActiveVersionID,
-				Transition:        workspace.LatestBuild.Transition,
+				Transition:        transition,
 				ParameterValues:   parameters,
 			})
 			if err != nil {
@@ -82,6 +96,7 @@ func update() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&alwaysPrompt, "always-prompt", false, "Always prompt all parameters. Does not pull parameter values from existing workspace")
+	cmd.Flags().BoolVar(&promptMutable, "parameter-prompt", false, "Prompt for new values of mutable parameters, and start the workspace if any are given.")
 	cliflag.StringVarP(cmd.Flags(), &parameterFile, "parameter-file", "", "CODER_PARAMETER_FILE", "", "Specify a file path with parameter values.")
 	return cmd
 }
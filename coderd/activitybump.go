@@ -0,0 +1,34 @@
+package coderd
+
+import (
+	"context"
+	"time"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+)
+
+// activityBumpInterval throttles how often coderd-observed activity (e.g.
+// app proxy requests) writes workspaces.last_used_at, so that busy apps
+// do not cause a database write per request.
+const activityBumpInterval = time.Minute
+
+// activityBumpWorkspace records that a workspace was just used. The
+// autobuild executor reads last_used_at to push back the autostop deadline
+// of workspaces whose template has an inactivity TTL.
+func (api *API) activityBumpWorkspace(ctx context.Context, workspace database.Workspace) {
+	now := database.Now()
+	if now.Sub(workspace.LastUsedAt) < activityBumpInterval {
+		return
+	}
+	err := api.Database.UpdateWorkspaceLastUsedAt(ctx, database.UpdateWorkspaceLastUsedAtParams{
+		ID:         workspace.ID,
+		LastUsedAt: now,
+	})
+	if err != nil && ctx.Err() == nil {
+		api.Logger.Warn(ctx, "bump workspace last used at",
+			slog.F("workspace_id", workspace.ID),
+			slog.Error(err),
+		)
+	}
+}
@@ -0,0 +1,179 @@
+package coderd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// defaultTokenLifetime is used when a token is created without a lifetime.
+const defaultTokenLifetime = 30 * 24 * time.Hour
+
+// Creates a new long-lived token for the user. Unlike session keys, tokens
+// can be named, listed and revoked individually.
+func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
+	var (
+		user        = httpmw.UserParam(r)
+		auditParams = &audit.RequestParams{
+			Audit:        api.Auditor,
+			Log:          api.Logger,
+			Request:      r,
+			Action:       database.AuditActionCreate,
+			ResourceType: database.ResourceTypeAPIKey,
+			ResourceID:   user.ID,
+			Actor:        httpmw.APIKey(r).UserID,
+		}
+		aReq, commitAudit = audit.InitRequest[database.APIKey](rw, auditParams)
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.CreateTokenRequest
+	if !httpapi.Read(rw, r, &req) {
+		return
+	}
+	if req.Lifetime < 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Token lifetime must not be negative.",
+		})
+		return
+	}
+	lifetime := req.Lifetime
+	if lifetime == 0 {
+		lifetime = defaultTokenLifetime
+	}
+	scope := database.APIKeyScopeAll
+	if req.Scope != "" {
+		scope = database.APIKeyScope(req.Scope)
+	}
+
+	cookie, key, err := api.createAPIKey(r, createAPIKeyParams{
+		UserID:          user.ID,
+		LoginType:       database.LoginTypeToken,
+		ExpiresAt:       database.Now().Add(lifetime),
+		LifetimeSeconds: int64(lifetime.Seconds()),
+		Scope:           scope,
+		TokenName:       req.TokenName,
+	})
+	if database.IsUniqueViolation(err, database.UniqueApiKeysUserIDTokenNameIndex) {
+		httpapi.Write(rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A token named %q already exists.", req.TokenName),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to create token.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	auditParams.ResourceTarget = key.ID
+	aReq.New = *key
+
+	httpapi.Write(rw, http.StatusCreated, codersdk.GenerateAPIKeyResponse{Key: cookie.Value})
+}
+
+// Lists the tokens of a user. Session keys created by logging in are not
+// included.
+func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	keys, err := api.Database.GetAPIKeysByUserID(ctx, database.GetAPIKeysByUserIDParams{
+		LoginType: database.LoginTypeToken,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching tokens.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiKeys := make([]codersdk.APIKey, 0, len(keys))
+	for _, key := range keys {
+		apiKeys = append(apiKeys, convertAPIKey(key))
+	}
+
+	httpapi.Write(rw, http.StatusOK, apiKeys)
+}
+
+// Revokes an API key of a user.
+func (api *API) deleteAPIKey(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx         = r.Context()
+		user        = httpmw.UserParam(r)
+		keyID       = chi.URLParam(r, "keyid")
+		auditParams = &audit.RequestParams{
+			Audit:          api.Auditor,
+			Log:            api.Logger,
+			Request:        r,
+			Action:         database.AuditActionDelete,
+			ResourceType:   database.ResourceTypeAPIKey,
+			ResourceID:     user.ID,
+			ResourceTarget: keyID,
+			Actor:          httpmw.APIKey(r).UserID,
+		}
+		aReq, commitAudit = audit.InitRequest[database.APIKey](rw, auditParams)
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	key, err := api.Database.GetAPIKeyByID(ctx, keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Don't leak the existence of keys that belong to other users.
+	if key.UserID != user.ID {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	aReq.Old = key
+
+	err = api.Database.DeleteAPIKeyByID(ctx, key.ID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
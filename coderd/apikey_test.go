@@ -0,0 +1,144 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CRUD", func(t *testing.T) {
+		t.Parallel()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		keys, err := client.Tokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+
+		res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "ci",
+			Lifetime:  time.Hour,
+		})
+		require.NoError(t, err)
+		keyID := strings.Split(res.Key, "-")[0]
+
+		keys, err = client.Tokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, keyID, keys[0].ID)
+		require.Equal(t, "ci", keys[0].TokenName)
+		require.Equal(t, codersdk.LoginTypeToken, keys[0].LoginType)
+		require.Equal(t, codersdk.APIKeyScopeAll, keys[0].Scope)
+		require.EqualValues(t, time.Hour.Seconds(), keys[0].LifetimeSeconds)
+
+		// The token authenticates requests.
+		tokenClient := codersdk.New(client.URL)
+		tokenClient.SessionToken = res.Key
+		me, err := tokenClient.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Equal(t, user.UserID, me.ID)
+
+		err = client.DeleteAPIKey(ctx, codersdk.Me, keyID)
+		require.NoError(t, err)
+		keys, err = client.Tokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+
+		_, err = tokenClient.User(ctx, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		alogs := auditor.AuditLogs()
+		require.GreaterOrEqual(t, len(alogs), 2)
+		alog := alogs[len(alogs)-1]
+		require.Equal(t, database.AuditActionDelete, alog.Action)
+		require.Equal(t, database.ResourceTypeAPIKey, alog.ResourceType)
+		require.Equal(t, keyID, alog.ResourceTarget)
+		alog = alogs[len(alogs)-2]
+		require.Equal(t, database.AuditActionCreate, alog.Action)
+		require.Equal(t, keyID, alog.ResourceTarget)
+	})
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "ci",
+		})
+		require.NoError(t, err)
+		_, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "ci",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		// Unnamed tokens don't conflict.
+		for i := 0; i < 2; i++ {
+			_, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("ApplicationConnectScope", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Scope: codersdk.APIKeyScopeApplicationConnect,
+		})
+		require.NoError(t, err)
+
+		tokenClient := codersdk.New(client.URL)
+		tokenClient.SessionToken = res.Key
+		_, err = tokenClient.User(ctx, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("DeleteOtherUser", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		require.NoError(t, err)
+
+		err = other.DeleteAPIKey(ctx, codersdk.Me, strings.Split(res.Key, "-")[0])
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
@@ -0,0 +1,257 @@
+package coderd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// auditLogs returns a page of audit logs matching the search query.
+func (api *API) auditLogs(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAuditLog) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	queryStr := r.URL.Query().Get("q")
+	filter, errs := auditSearchQuery(queryStr)
+	if len(errs) > 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid audit search query.",
+			Validations: errs,
+		})
+		return
+	}
+
+	page, ok := parsePagination(rw, r)
+	if !ok {
+		return
+	}
+	filter.OffsetOpt = int32(page.Offset)
+	filter.LimitOpt = int32(page.Limit)
+
+	dblogs, err := api.Database.GetAuditLogsOffset(ctx, filter)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching audit logs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	count, err := api.Database.GetAuditLogCount(ctx, database.GetAuditLogCountParams{
+		Action:       filter.Action,
+		ResourceType: filter.ResourceType,
+		Username:     filter.Username,
+		DateFrom:     filter.DateFrom,
+		DateTo:       filter.DateTo,
+		StatusCode:   filter.StatusCode,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching audit log count.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(dblogs))
+	for _, dblog := range dblogs {
+		userIDs = append(userIDs, dblog.UserID)
+	}
+	users, err := api.Database.GetUsersByIDs(ctx, userIDs)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching users.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	organizationIDsByMemberIDsRows, err := api.Database.GetOrganizationIDsByMemberIDs(ctx, userIDs)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user's organizations.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	organizationIDsByUserID := map[uuid.UUID][]uuid.UUID{}
+	for _, row := range organizationIDsByMemberIDsRows {
+		organizationIDsByUserID[row.UserID] = row.OrganizationIDs
+	}
+	usersByID := make(map[uuid.UUID]codersdk.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = convertUser(user, organizationIDsByUserID[user.ID])
+	}
+
+	logs := make([]codersdk.AuditLog, 0, len(dblogs))
+	for _, dblog := range dblogs {
+		var user *codersdk.User
+		if u, ok := usersByID[dblog.UserID]; ok {
+			user = &u
+		}
+		logs = append(logs, convertAuditLog(dblog, user))
+	}
+
+	httpapi.Write(rw, http.StatusOK, codersdk.AuditLogResponse{
+		AuditLogs: logs,
+		Count:     count,
+	})
+}
+
+func convertAuditLog(dblog database.AuditLog, user *codersdk.User) codersdk.AuditLog {
+	ip, _ := netip.AddrFromSlice(dblog.Ip.IPNet.IP)
+
+	diff := codersdk.AuditDiff{}
+	_ = json.Unmarshal(dblog.Diff, &diff)
+
+	return codersdk.AuditLog{
+		ID:               dblog.ID,
+		RequestID:        dblog.RequestID,
+		Time:             dblog.Time,
+		OrganizationID:   dblog.OrganizationID,
+		IP:               ip.Unmap(),
+		UserAgent:        dblog.UserAgent,
+		ResourceType:     codersdk.ResourceType(dblog.ResourceType),
+		ResourceID:       dblog.ResourceID,
+		ResourceTarget:   dblog.ResourceTarget,
+		ResourceIcon:     dblog.ResourceIcon,
+		Action:           codersdk.AuditAction(dblog.Action),
+		Diff:             diff,
+		StatusCode:       dblog.StatusCode,
+		AdditionalFields: dblog.AdditionalFields,
+		Description:      auditLogDescription(dblog, user),
+		User:             user,
+	}
+}
+
+// auditLogDescription returns a human readable summary of the audit log, e.g.
+// "admin created workspace dev".
+func auditLogDescription(dblog database.AuditLog, user *codersdk.User) string {
+	actor := "Unknown user"
+	if user != nil {
+		actor = user.Username
+	}
+
+	verb := string(dblog.Action)
+	switch dblog.Action {
+	case database.AuditActionCreate:
+		verb = "created"
+	case database.AuditActionWrite:
+		verb = "updated"
+	case database.AuditActionDelete:
+		verb = "deleted"
+	case database.AuditActionStart:
+		verb = "started"
+	case database.AuditActionStop:
+		verb = "stopped"
+	case database.AuditActionLogin:
+		// Failed logins may not have an actor, so describe them by the
+		// username or email the login was attempted with.
+		if dblog.StatusCode >= http.StatusBadRequest {
+			return fmt.Sprintf("Failed login attempt as %s", dblog.ResourceTarget)
+		}
+		return fmt.Sprintf("%s logged in", actor)
+	}
+
+	resource := strings.ReplaceAll(string(dblog.ResourceType), "_", " ")
+	return fmt.Sprintf("%s %s %s %s", actor, verb, resource, dblog.ResourceTarget)
+}
+
+// auditSearchQuery takes a query string and returns the audit log filter.
+// It also can return the list of validation errors to return to the api.
+func auditSearchQuery(query string) (database.GetAuditLogsOffsetParams, []codersdk.ValidationError) {
+	searchParams := make(url.Values)
+	if query == "" {
+		// No filter
+		return database.GetAuditLogsOffsetParams{}, nil
+	}
+	query = strings.ToLower(query)
+	// Because we do this in 2 passes, we want to maintain quotes on the first
+	// pass.Further splitting occurs on the second pass and quotes will be
+	// dropped.
+	elements := splitQueryParameterByDelimiter(query, ' ', true)
+	for _, element := range elements {
+		parts := splitQueryParameterByDelimiter(element, ':', false)
+		switch len(parts) {
+		case 1:
+			// No key:value pair. It is the username of the actor.
+			searchParams.Set("username", parts[0])
+		case 2:
+			searchParams.Set(parts[0], parts[1])
+		default:
+			return database.GetAuditLogsOffsetParams{}, []codersdk.ValidationError{
+				{Field: "q", Detail: fmt.Sprintf("Query element %q can only contain 1 ':'", element)},
+			}
+		}
+	}
+
+	// Using the query param parser here just returns consistent errors with
+	// other parsing.
+	parser := httpapi.NewQueryParamParser()
+	filter := database.GetAuditLogsOffsetParams{
+		Action:       string(httpapi.ParseCustom(parser, searchParams, "", "action", parseAuditAction)),
+		ResourceType: string(httpapi.ParseCustom(parser, searchParams, "", "resource_type", parseResourceType)),
+		Username:     parser.String(searchParams, "", "username"),
+		DateFrom:     httpapi.ParseCustom(parser, searchParams, time.Time{}, "date_from", parseAuditDate),
+		DateTo:       httpapi.ParseCustom(parser, searchParams, time.Time{}, "date_to", parseAuditDate),
+		StatusCode:   int32(parser.Int(searchParams, 0, "status_code")),
+	}
+	// The date range is inclusive, so include the entire end day.
+	if !filter.DateTo.IsZero() {
+		filter.DateTo = filter.DateTo.Add(24 * time.Hour)
+	}
+
+	return filter, parser.Errors
+}
+
+func parseAuditAction(v string) (database.AuditAction, error) {
+	switch action := database.AuditAction(v); action {
+	case database.AuditActionCreate,
+		database.AuditActionWrite,
+		database.AuditActionDelete,
+		database.AuditActionStart,
+		database.AuditActionStop,
+		database.AuditActionLogin:
+		return action, nil
+	}
+	return "", xerrors.Errorf("%q is not a valid audit action", v)
+}
+
+func parseResourceType(v string) (database.ResourceType, error) {
+	switch resourceType := database.ResourceType(v); resourceType {
+	case database.ResourceTypeOrganization,
+		database.ResourceTypeTemplate,
+		database.ResourceTypeTemplateVersion,
+		database.ResourceTypeUser,
+		database.ResourceTypeWorkspace,
+		database.ResourceTypeGitSSHKey,
+		database.ResourceTypeAPIKey,
+		database.ResourceTypeWorkspaceBuild:
+		return resourceType, nil
+	}
+	return "", xerrors.Errorf("%q is not a valid resource type", v)
+}
+
+// parseAuditDate parses dates formatted as YYYY-MM-DD in UTC.
+func parseAuditDate(v string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("%q is not a valid date, must be formatted as YYYY-MM-DD", v)
+	}
+	return t, nil
+}
@@ -2,6 +2,7 @@ package audit
 
 import (
 	"context"
+	"sync"
 
 	"github.com/coder/coder/coderd/database"
 )
@@ -22,3 +23,30 @@ func (nop) Export(context.Context, database.AuditLog) error {
 }
 
 func (nop) diff(any, any) Map { return Map{} }
+
+// NewMock returns an Auditor that records every exported audit log. It is
+// intended for tests.
+func NewMock() *MockAuditor {
+	return &MockAuditor{}
+}
+
+type MockAuditor struct {
+	mu        sync.Mutex
+	auditLogs []database.AuditLog
+}
+
+// AuditLogs returns a copy of the audit logs exported so far.
+func (a *MockAuditor) AuditLogs() []database.AuditLog {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]database.AuditLog(nil), a.auditLogs...)
+}
+
+func (a *MockAuditor) Export(_ context.Context, alog database.AuditLog) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.auditLogs = append(a.auditLogs, alog)
+	return nil
+}
+
+func (*MockAuditor) diff(any, any) Map { return Map{} }
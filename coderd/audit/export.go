@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"crypto/tls"
+	"net/url"
+)
+
+// ExportOptions configures external destinations that audit logs are exported
+// to in addition to the Coder database. Exporting is performed by the
+// enterprise auditor, the AGPL auditor ignores these options.
+type ExportOptions struct {
+	// WebhookURL receives batches of audit logs as JSON. Exporting to a
+	// webhook is disabled when it is nil.
+	WebhookURL *url.URL
+	// WebhookSecret signs the body of each webhook request with HMAC-SHA256.
+	WebhookSecret string
+	// WebhookQueueDir persists audit logs that have not been delivered to the
+	// webhook, so they survive restarts. Undelivered audit logs are only kept
+	// in memory when it is empty.
+	WebhookQueueDir string
+	// WebhookQueueSize is the maximum number of undelivered audit logs.
+	WebhookQueueSize int
+
+	// SyslogAddress is the host:port of an RFC 5424 syslog receiver.
+	// Exporting to syslog is disabled when it is empty.
+	SyslogAddress string
+	// SyslogTLSConfig enables TLS for the syslog connection when set.
+	SyslogTLSConfig *tls.Config
+}
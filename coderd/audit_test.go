@@ -0,0 +1,158 @@
+package coderd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestAuditLogs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db := databasefake.New()
+		client := coderdtest.New(t, &coderdtest.Options{Database: db})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		coderdtest.InsertAuditLog(t, db, database.InsertAuditLogParams{
+			UserID:     user.UserID,
+			ResourceID: user.UserID,
+			Diff:       json.RawMessage(`{"foo":{"old":"bar","new":"baz","secret":false}}`),
+		})
+
+		res, err := client.AuditLogs(ctx, codersdk.AuditLogsRequest{
+			Pagination: codersdk.Pagination{Limit: 1},
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, res.Count)
+		require.Len(t, res.AuditLogs, 1)
+		require.NotNil(t, res.AuditLogs[0].User)
+		require.Equal(t, user.UserID, res.AuditLogs[0].User.ID)
+		require.Equal(t, codersdk.AuditActionWrite, res.AuditLogs[0].Action)
+		require.Contains(t, res.AuditLogs[0].Diff, "foo")
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		_, err := member.AuditLogs(ctx, codersdk.AuditLogsRequest{})
+		require.Error(t, err)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		db := databasefake.New()
+		client := coderdtest.New(t, &coderdtest.Options{Database: db})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		for i := 0; i < 3; i++ {
+			coderdtest.InsertAuditLog(t, db, database.InsertAuditLogParams{
+				UserID: user.UserID,
+			})
+		}
+
+		res, err := client.AuditLogs(ctx, codersdk.AuditLogsRequest{
+			Pagination: codersdk.Pagination{Limit: 2, Offset: 2},
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 3, res.Count)
+		require.Len(t, res.AuditLogs, 1)
+	})
+}
+
+func TestAuditLogsFilter(t *testing.T) {
+	t.Parallel()
+
+	db := databasefake.New()
+	client := coderdtest.New(t, &coderdtest.Options{Database: db})
+	user := coderdtest.CreateFirstUser(t, client)
+
+	yesterday := time.Now().UTC().Add(-24 * time.Hour)
+	coderdtest.InsertAuditLog(t, db, database.InsertAuditLogParams{
+		UserID:       user.UserID,
+		Action:       database.AuditActionCreate,
+		ResourceType: database.ResourceTypeTemplate,
+		Time:         yesterday,
+	})
+	coderdtest.InsertAuditLog(t, db, database.InsertAuditLogParams{
+		UserID:       user.UserID,
+		Action:       database.AuditActionDelete,
+		ResourceType: database.ResourceTypeWorkspace,
+		StatusCode:   http.StatusInternalServerError,
+	})
+
+	testCases := []struct {
+		Name          string
+		SearchQuery   string
+		ExpectedCount int
+		ExpectError   bool
+	}{
+		{Name: "All", SearchQuery: "", ExpectedCount: 2},
+		{Name: "Action", SearchQuery: "action:create", ExpectedCount: 1},
+		{Name: "ResourceType", SearchQuery: "resource_type:workspace", ExpectedCount: 1},
+		{Name: "Username", SearchQuery: "username:testuser", ExpectedCount: 2},
+		{Name: "UsernameNoKey", SearchQuery: "TestUser", ExpectedCount: 2},
+		{Name: "UnknownUsername", SearchQuery: "username:nobody", ExpectedCount: 0},
+		{Name: "StatusCode", SearchQuery: "status_code:500", ExpectedCount: 1},
+		{Name: "DateFrom", SearchQuery: "date_from:" + time.Now().UTC().Format("2006-01-02"), ExpectedCount: 1},
+		{Name: "DateTo", SearchQuery: "date_to:" + yesterday.Format("2006-01-02"), ExpectedCount: 1},
+		{Name: "Combined", SearchQuery: "action:delete resource_type:template", ExpectedCount: 0},
+		{Name: "InvalidAction", SearchQuery: "action:explode", ExpectError: true},
+		{Name: "InvalidResourceType", SearchQuery: "resource_type:moon", ExpectError: true},
+		{Name: "InvalidDate", SearchQuery: "date_from:yesterday", ExpectError: true},
+		{Name: "InvalidStatusCode", SearchQuery: "status_code:ok", ExpectError: true},
+		{Name: "ExtraColon", SearchQuery: "action:create:delete", ExpectError: true},
+	}
+	for _, c := range testCases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+			defer cancel()
+
+			res, err := client.AuditLogs(ctx, codersdk.AuditLogsRequest{
+				SearchQuery: c.SearchQuery,
+			})
+			if c.ExpectError {
+				require.Error(t, err)
+				var apiErr *codersdk.Error
+				require.ErrorAs(t, err, &apiErr)
+				require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, res.AuditLogs, c.ExpectedCount)
+			require.EqualValues(t, c.ExpectedCount, res.Count)
+		})
+	}
+}
@@ -100,6 +100,11 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	// NOTE: If a workspace build is created with a given TTL and then the user either
 	//       changes or unsets the TTL, the deadline for the workspace build will not
 	//       have changed. This behavior is as expected per #2229.
+	//
+	// If the workspace's template has an inactivity TTL, activity on the
+	// workspace (tracked by workspaces.last_used_at) pushes the deadline back
+	// so that workspaces are only stopped once they have been idle for that
+	// long.
 	workspaces, err := e.db.GetWorkspaces(e.ctx, database.GetWorkspacesParams{
 		Deleted: false,
 	})
@@ -108,9 +113,19 @@ func (e *Executor) runOnce(t time.Time) Stats {
 		return stats
 	}
 
+	templates, err := e.db.GetTemplates(e.ctx)
+	if err != nil {
+		e.log.Error(e.ctx, "get templates for autostart or autostop", slog.Error(err))
+		return stats
+	}
+	templatesByID := make(map[uuid.UUID]database.Template, len(templates))
+	for _, template := range templates {
+		templatesByID[template.ID] = template
+	}
+
 	var eligibleWorkspaceIDs []uuid.UUID
 	for _, ws := range workspaces {
-		if isEligibleForAutoStartStop(ws) {
+		if isEligibleForAutoStartStop(ws, templatesByID[ws.TemplateID]) {
 			eligibleWorkspaceIDs = append(eligibleWorkspaceIDs, ws.ID)
 		}
 	}
@@ -134,7 +149,12 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					log.Error(e.ctx, "get workspace autostart failed", slog.Error(err))
 					return nil
 				}
-				if !isEligibleForAutoStartStop(ws) {
+				template, err := db.GetTemplateByID(e.ctx, ws.TemplateID)
+				if err != nil {
+					log.Warn(e.ctx, "get workspace template", slog.Error(err))
+					return nil
+				}
+				if !isEligibleForAutoStartStop(ws, template) {
 					return nil
 				}
 
@@ -151,13 +171,31 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					return nil
 				}
 
-				validTransition, nextTransition, err := getNextTransition(ws, priorHistory, priorJob)
+				validTransition, nextTransition, err := getNextTransition(ws, template, priorHistory, priorJob)
 				if err != nil {
 					log.Debug(e.ctx, "skipping workspace", slog.Error(err))
 					return nil
 				}
 
 				if currentTick.Before(nextTransition) {
+					if validTransition == database.WorkspaceTransitionStop && !nextTransition.Equal(priorHistory.Deadline) {
+						// Activity pushed the deadline back. Persist it so that
+						// it is reflected to users and when extending the deadline.
+						log.Debug(e.ctx, "bumping workspace deadline",
+							slog.F("old_deadline", priorHistory.Deadline),
+							slog.F("new_deadline", nextTransition),
+							slog.F("last_used_at", ws.LastUsedAt),
+						)
+						err = db.UpdateWorkspaceBuildByID(e.ctx, database.UpdateWorkspaceBuildByIDParams{
+							ID:               priorHistory.ID,
+							UpdatedAt:        database.Now(),
+							ProvisionerState: priorHistory.ProvisionerState,
+							Deadline:         nextTransition,
+						})
+						if err != nil {
+							log.Error(e.ctx, "bump workspace deadline", slog.Error(err))
+						}
+					}
 					log.Debug(e.ctx, "skipping workspace: too early",
 						slog.F("next_transition_at", nextTransition),
 						slog.F("transition", validTransition),
@@ -195,12 +233,13 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	return stats
 }
 
-func isEligibleForAutoStartStop(ws database.Workspace) bool {
-	return !ws.Deleted && (ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0)
+func isEligibleForAutoStartStop(ws database.Workspace, template database.Template) bool {
+	return !ws.Deleted && (ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0 || template.InactivityTtl > 0)
 }
 
 func getNextTransition(
 	ws database.Workspace,
+	template database.Template,
 	priorHistory database.WorkspaceBuild,
 	priorJob database.ProvisionerJob,
 ) (
@@ -214,12 +253,33 @@ func getNextTransition(
 
 	switch priorHistory.Transition {
 	case database.WorkspaceTransitionStart:
-		if priorHistory.Deadline.IsZero() {
+		deadline := priorHistory.Deadline
+		if template.InactivityTtl > 0 {
+			// Activity bumps the deadline: the workspace stays running for at
+			// least the inactivity TTL after it was last used. A workspace
+			// that was never used counts from when its build completed.
+			lastActive := priorJob.CompletedAt.Time
+			if ws.LastUsedAt.After(lastActive) {
+				lastActive = ws.LastUsedAt
+			}
+			idleDeadline := lastActive.Add(time.Duration(template.InactivityTtl))
+			// Activity never keeps a workspace running for longer than the
+			// max TTL of its template.
+			if template.MaxTtl > 0 {
+				if maxDeadline := priorHistory.CreatedAt.Add(time.Duration(template.MaxTtl)); idleDeadline.After(maxDeadline) {
+					idleDeadline = maxDeadline
+				}
+			}
+			if idleDeadline.After(deadline) {
+				deadline = idleDeadline
+			}
+		}
+		if deadline.IsZero() {
 			return "", time.Time{}, xerrors.Errorf("latest workspace build has zero deadline")
 		}
 		// For stopping, do not truncate. This is inconsistent with autostart, but
 		// it ensures we will not stop too early.
-		return database.WorkspaceTransitionStop, priorHistory.Deadline, nil
+		return database.WorkspaceTransitionStop, deadline, nil
 	case database.WorkspaceTransitionStop:
 		sched, err := schedule.Weekly(ws.AutostartSchedule.String)
 		if err != nil {
@@ -279,6 +339,7 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 		StorageMethod:  priorJob.StorageMethod,
 		StorageSource:  priorJob.StorageSource,
 		Input:          input,
+		Tags:           priorJob.Tags,
 	})
 	if err != nil {
 		return xerrors.Errorf("insert provisioner job: %w", err)
@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+func TestGetNextTransitionInactivity(t *testing.T) {
+	t.Parallel()
+
+	var (
+		completedAt = time.Date(2022, 9, 1, 9, 0, 0, 0, time.UTC)
+		job         = database.ProvisionerJob{
+			CompletedAt: sql.NullTime{Time: completedAt, Valid: true},
+		}
+		inactivityTTL = time.Hour
+	)
+
+	testCases := []struct {
+		Name          string
+		InactivityTTL time.Duration
+		MaxTTL        time.Duration
+		Deadline      time.Time
+		LastUsedAt    time.Time
+		Expected      time.Time
+		ExpectedErr   bool
+	}{
+		{
+			Name:        "NoDeadlineNoInactivity",
+			ExpectedErr: true,
+		},
+		{
+			Name:     "DeadlineOnly",
+			Deadline: completedAt.Add(8 * time.Hour),
+			Expected: completedAt.Add(8 * time.Hour),
+		},
+		{
+			Name:          "NeverUsed",
+			InactivityTTL: inactivityTTL,
+			Expected:      completedAt.Add(inactivityTTL),
+		},
+		{
+			Name:          "UsedBeforeBuild",
+			InactivityTTL: inactivityTTL,
+			LastUsedAt:    completedAt.Add(-time.Hour),
+			Expected:      completedAt.Add(inactivityTTL),
+		},
+		{
+			Name:          "RecentlyUsed",
+			InactivityTTL: inactivityTTL,
+			LastUsedAt:    completedAt.Add(3 * time.Hour),
+			Expected:      completedAt.Add(3 * time.Hour).Add(inactivityTTL),
+		},
+		{
+			Name:          "ActivityBumpsDeadline",
+			InactivityTTL: inactivityTTL,
+			Deadline:      completedAt.Add(8 * time.Hour),
+			LastUsedAt:    completedAt.Add(7*time.Hour + 30*time.Minute),
+			Expected:      completedAt.Add(7*time.Hour + 30*time.Minute).Add(inactivityTTL),
+		},
+		{
+			Name:          "DeadlineIsFloor",
+			InactivityTTL: inactivityTTL,
+			Deadline:      completedAt.Add(8 * time.Hour),
+			LastUsedAt:    completedAt.Add(time.Hour),
+			Expected:      completedAt.Add(8 * time.Hour),
+		},
+		{
+			Name:          "MaxTTLLimitsBump",
+			InactivityTTL: inactivityTTL,
+			MaxTTL:        8 * time.Hour,
+			Deadline:      completedAt.Add(8 * time.Hour),
+			LastUsedAt:    completedAt.Add(7*time.Hour + 30*time.Minute),
+			Expected:      completedAt.Add(8 * time.Hour),
+		},
+		{
+			Name:          "MaxTTLWithoutDeadline",
+			InactivityTTL: inactivityTTL,
+			MaxTTL:        8 * time.Hour,
+			LastUsedAt:    completedAt.Add(10 * time.Hour),
+			Expected:      completedAt.Add(8 * time.Hour),
+		},
+		{
+			Name:          "ActivityWithinMaxTTL",
+			InactivityTTL: inactivityTTL,
+			MaxTTL:        8 * time.Hour,
+			LastUsedAt:    completedAt.Add(3 * time.Hour),
+			Expected:      completedAt.Add(3 * time.Hour).Add(inactivityTTL),
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			ws := database.Workspace{LastUsedAt: c.LastUsedAt}
+			template := database.Template{
+				InactivityTtl: int64(c.InactivityTTL),
+				MaxTtl:        int64(c.MaxTTL),
+			}
+			build := database.WorkspaceBuild{
+				CreatedAt:  completedAt,
+				Transition: database.WorkspaceTransitionStart,
+				Deadline:   c.Deadline,
+			}
+
+			transition, next, err := getNextTransition(ws, template, build, job)
+			if c.ExpectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, database.WorkspaceTransitionStop, transition)
+			require.Equal(t, c.Expected, next)
+		})
+	}
+}
+
+func TestIsEligibleForAutoStartStop(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, isEligibleForAutoStartStop(database.Workspace{}, database.Template{}))
+	require.True(t, isEligibleForAutoStartStop(database.Workspace{}, database.Template{InactivityTtl: int64(time.Hour)}))
+	require.False(t, isEligibleForAutoStartStop(database.Workspace{Deleted: true}, database.Template{InactivityTtl: int64(time.Hour)}))
+	require.True(t, isEligibleForAutoStartStop(database.Workspace{Ttl: sql.NullInt64{Int64: int64(time.Hour), Valid: true}}, database.Template{}))
+}
@@ -333,6 +333,66 @@ func TestExecutorAutostopNotEnabled(t *testing.T) {
 	assert.Len(t, stats.Transitions, 0)
 }
 
+func TestExecutorAutostopInactivity(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:     tickCh,
+			IncludeProvisionerD: true,
+			AutobuildStats:      statsCh,
+		})
+		// Given: we have a user with a workspace that has no TTL
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.TTLMillis = nil
+		})
+		inactivityTTL = time.Hour
+	)
+	require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	require.False(t, workspace.LatestBuild.Deadline.Valid)
+
+	// Given: the template stops workspaces after an hour of inactivity
+	template, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis: inactivityTTL.Milliseconds(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, inactivityTTL.Milliseconds(), template.InactivityTTLMillis)
+	completedAt := *workspace.LatestBuild.Job.CompletedAt
+
+	// When: the autobuild executor ticks before the workspace has been idle
+	// for the inactivity TTL
+	go func() {
+		tickCh <- completedAt.Add(inactivityTTL / 2)
+	}()
+
+	// Then: nothing should happen, but the deadline should be set
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.True(t, workspace.LatestBuild.Deadline.Valid)
+	assert.WithinDuration(t, completedAt.Add(inactivityTTL), workspace.LatestBuild.Deadline.Time, time.Second)
+
+	// When: the autobuild executor ticks after the workspace has been idle
+	// for the inactivity TTL
+	go func() {
+		tickCh <- completedAt.Add(inactivityTTL + time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be stopped
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonAutostop, workspace.LatestBuild.Reason)
+}
+
 func TestExecutorWorkspaceDeleted(t *testing.T) {
 	t.Parallel()
 
@@ -1,6 +1,7 @@
 package coderd
 
 import (
+	"context"
 	"crypto/x509"
 	"io"
 	"net/http"
@@ -25,6 +26,7 @@ import (
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/buildinfo"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/awsidentity"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/gitsshkey"
@@ -53,6 +55,9 @@ type Options struct {
 
 	AgentConnectionUpdateFrequency time.Duration
 	AgentInactiveDisconnectTimeout time.Duration
+	// AgentShutdownScriptTimeout is how long stop and delete builds wait
+	// for the shutdown scripts of workspace agents to finish.
+	AgentShutdownScriptTimeout time.Duration
 	// APIRateLimit is the minutely throughput rate limit per user or ip.
 	// Setting a rate limit <0 will disable the rate limiter across the entire
 	// app. Specific routes may have their own limiters.
@@ -73,13 +78,43 @@ type Options struct {
 	AutoImportTemplates  []AutoImportTemplate
 	LicenseHandler       http.Handler
 	FeaturesService      FeaturesService
+	// ProvisionerDaemonPSK is the pre-shared key external provisioner
+	// daemons must present to connect. External daemons are rejected
+	// when it is empty.
+	ProvisionerDaemonPSK string
+	// AppHostname is the wildcard hostname used to serve workspace
+	// applications on subdomains, e.g. "*.coder.example.com". Subdomain
+	// applications are disabled when it is empty.
+	AppHostname string
+	// Auditor records audit logs for requests that modify resources.
+	Auditor audit.Auditor
+	// AuditExport configures external destinations for audit logs.
+	AuditExport audit.ExportOptions
 
 	TailscaleEnable    bool
-	TailnetCoordinator *tailnet.Coordinator
+	TailnetCoordinator tailnet.Coordinator
 	DERPMap            *tailcfg.DERPMap
 
 	MetricsCacheRefreshInterval time.Duration
 	AgentStatsRefreshInterval   time.Duration
+
+	// ProvisionerJobHeartbeatTimeout is how long a running provisioner job
+	// may go without an update from its provisioner daemon before it's
+	// considered orphaned and failed.
+	ProvisionerJobHeartbeatTimeout time.Duration
+	// ProvisionerJobReapInterval is how often running provisioner jobs are
+	// checked for being orphaned or exceeding their maximum duration.
+	ProvisionerJobReapInterval time.Duration
+	// TemplateGitUsername and TemplateGitPassword are sent to the built-in
+	// provisioner daemons to authenticate over HTTP when they clone template
+	// versions from git repositories on TemplateGitHosts. External daemons
+	// use credentials of their own.
+	TemplateGitUsername string
+	TemplateGitPassword string
+	// TemplateGitHosts are the hosts template versions may be cloned from.
+	// Any host is allowed when it's empty, but the credentials are only sent
+	// to the hosts listed.
+	TemplateGitHosts []string
 }
 
 // New constructs a Coder API handler.
@@ -91,6 +126,9 @@ func New(options *Options) *API {
 		// Multiply the update by two to allow for some lag-time.
 		options.AgentInactiveDisconnectTimeout = options.AgentConnectionUpdateFrequency * 2
 	}
+	if options.AgentShutdownScriptTimeout == 0 {
+		options.AgentShutdownScriptTimeout = 5 * time.Minute
+	}
 	if options.APIRateLimit == 0 {
 		options.APIRateLimit = 512
 	}
@@ -115,6 +153,15 @@ func New(options *Options) *API {
 	if options.FeaturesService == nil {
 		options.FeaturesService = featuresService{}
 	}
+	if options.Auditor == nil {
+		options.Auditor = audit.NewNop()
+	}
+	if options.ProvisionerJobHeartbeatTimeout == 0 {
+		options.ProvisionerJobHeartbeatTimeout = 5 * time.Minute
+	}
+	if options.ProvisionerJobReapInterval == 0 {
+		options.ProvisionerJobReapInterval = 30 * time.Second
+	}
 
 	siteCacheDir := options.CacheDir
 	if siteCacheDir != "" {
@@ -140,7 +187,9 @@ func New(options *Options) *API {
 			Authorizer: options.Authorizer,
 			Logger:     options.Logger,
 		},
-		metricsCache: metricsCache,
+		metricsCache:  metricsCache,
+		agentClients:  newAgentClients(),
+		jobReaperDone: make(chan struct{}),
 	}
 	if options.TailscaleEnable {
 		api.workspaceAgentCache = wsconncache.New(api.dialWorkspaceAgentTailnet, 0)
@@ -148,6 +197,10 @@ func New(options *Options) *API {
 		api.workspaceAgentCache = wsconncache.New(api.dialWorkspaceAgent, 0)
 	}
 	api.derpServer = derp.NewServer(key.NewNode(), tailnet.Logger(options.Logger))
+	var jobReaperCtx context.Context
+	jobReaperCtx, api.jobReaperCancel = context.WithCancel(context.Background())
+	go api.runJobReaper(jobReaperCtx)
+	api.shutdownScriptsCtx, api.shutdownScriptsCancel = context.WithCancel(context.Background())
 	oauthConfigs := &httpmw.OAuth2Configs{
 		Github: options.GithubOAuth2Config,
 		OIDC:   options.OIDCConfig,
@@ -166,13 +219,23 @@ func New(options *Options) *API {
 				next.ServeHTTP(w, r)
 			})
 		},
+		// Subdomain applications are matched by hostname, so they must
+		// be handled before any routes.
+		api.handleSubdomainApplications(
+			httpmw.RateLimitPerMinute(options.APIRateLimit),
+			tracing.HTTPMW(api.TracerProvider, "coderd.http"),
+			httpmw.ExtractAPIKeyOptional(options.Database, oauthConfigs),
+		),
 	)
 
 	apps := func(r chi.Router) {
 		r.Use(
 			httpmw.RateLimitPerMinute(options.APIRateLimit),
 			tracing.HTTPMW(api.TracerProvider, "coderd.http"),
-			httpmw.ExtractAPIKey(options.Database, oauthConfigs, true),
+			// Public applications are served to anonymous users, and
+			// authorizeWorkspaceApp checks the sharing level of others.
+			httpmw.ExtractAPIKeyOptional(options.Database, oauthConfigs),
+			redirectAnonymousUserMe,
 			httpmw.ExtractUserParam(api.Database),
 			// Extracts the <workspace.agent> from the url
 			httpmw.ExtractWorkspaceAndAgentParam(api.Database),
@@ -220,6 +283,26 @@ func New(options *Options) *API {
 				})
 			})
 		})
+		r.Route("/applications", func(r chi.Router) {
+			r.Route("/auth-redirect", func(r chi.Router) {
+				// Users are redirected here from subdomain applications,
+				// so unauthenticated users are sent to the login page.
+				r.Use(httpmw.ExtractAPIKey(options.Database, oauthConfigs, true))
+				r.Get("/", api.workspaceApplicationAuth)
+			})
+			r.Route("/host", func(r chi.Router) {
+				r.Use(apiKeyMiddleware)
+				r.Get("/", api.appHost)
+			})
+		})
+		r.Route("/connections", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.workspaceConnections)
+		})
+		r.Route("/audit", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.auditLogs)
+		})
 		r.Route("/files", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -230,11 +313,20 @@ func New(options *Options) *API {
 			r.Get("/{hash}", api.fileByHash)
 			r.Post("/", api.postFile)
 		})
+		r.Route("/provisionerjobs", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.activeProvisionerJobs)
+		})
 		r.Route("/provisionerdaemons", func(r chi.Router) {
-			r.Use(
-				apiKeyMiddleware,
-			)
-			r.Get("/", api.provisionerDaemons)
+			// External provisioner daemons authenticate with a
+			// pre-shared key instead of a session token.
+			r.Get("/serve", api.serveProvisionerDaemon)
+			r.Group(func(r chi.Router) {
+				r.Use(
+					apiKeyMiddleware,
+				)
+				r.Get("/", api.provisionerDaemons)
+			})
 		})
 		r.Route("/organizations", func(r chi.Router) {
 			r.Use(
@@ -253,6 +345,10 @@ func New(options *Options) *API {
 					r.Get("/{templatename}", api.templateByOrganizationAndName)
 				})
 				r.Post("/workspaces", api.postWorkspacesByOrganization)
+				r.Route("/groups", func(r chi.Router) {
+					r.Post("/", api.postGroupByOrganization)
+					r.Get("/", api.groupsByOrganization)
+				})
 				r.Route("/members", func(r chi.Router) {
 					r.Get("/roles", api.assignableOrgRoles)
 					r.Route("/{user}", func(r chi.Router) {
@@ -273,6 +369,15 @@ func New(options *Options) *API {
 				r.Delete("/", api.deleteParameter)
 			})
 		})
+		r.Route("/groups/{group}", func(r chi.Router) {
+			r.Use(
+				apiKeyMiddleware,
+				httpmw.ExtractGroupParam(options.Database),
+			)
+			r.Get("/", api.group)
+			r.Patch("/", api.patchGroup)
+			r.Delete("/", api.deleteGroup)
+		})
 		r.Route("/templates/{template}", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -282,6 +387,8 @@ func New(options *Options) *API {
 			r.Get("/", api.template)
 			r.Delete("/", api.deleteTemplate)
 			r.Patch("/", api.patchTemplateMeta)
+			r.Get("/acl", api.templateACL)
+			r.Patch("/acl", api.patchTemplateACL)
 			r.Route("/versions", func(r chi.Router) {
 				r.Get("/", api.templateVersionsByTemplate)
 				r.Patch("/", api.patchActiveTemplateVersion)
@@ -300,6 +407,7 @@ func New(options *Options) *API {
 			r.Get("/parameters", api.templateVersionParameters)
 			r.Get("/resources", api.templateVersionResources)
 			r.Get("/logs", api.templateVersionLogs)
+			r.Get("/diff/{othertemplateversion}", api.templateVersionDiff)
 			r.Route("/dry-run", func(r chi.Router) {
 				r.Post("/", api.postTemplateVersionDryRun)
 				r.Get("/{jobID}", api.templateVersionDryRun)
@@ -353,7 +461,14 @@ func New(options *Options) *API {
 
 					r.Route("/keys", func(r chi.Router) {
 						r.Post("/", api.postAPIKey)
-						r.Get("/{keyid}", api.apiKey)
+						r.Route("/tokens", func(r chi.Router) {
+							r.Post("/", api.postToken)
+							r.Get("/", api.tokens)
+						})
+						r.Route("/{keyid}", func(r chi.Router) {
+							r.Get("/", api.apiKey)
+							r.Delete("/", api.deleteAPIKey)
+						})
 					})
 
 					r.Route("/organizations", func(r chi.Router) {
@@ -377,6 +492,7 @@ func New(options *Options) *API {
 				r.Use(httpmw.ExtractWorkspaceAgent(options.Database))
 				r.Get("/metadata", api.workspaceAgentMetadata)
 				r.Post("/version", api.postWorkspaceAgentVersion)
+				r.Patch("/startup-logs", api.patchWorkspaceAgentStartupLogs)
 				r.Get("/listen", api.workspaceAgentListen)
 
 				r.Get("/gitsshkey", api.agentGitSSHKey)
@@ -386,6 +502,9 @@ func New(options *Options) *API {
 				r.Get("/coordinate", api.workspaceAgentCoordinate)
 
 				r.Get("/report-stats", api.workspaceAgentReportStats)
+				r.Post("/report-lifecycle", api.postWorkspaceAgentLifecycle)
+				r.Post("/session-recordings", api.postWorkspaceAgentSessionRecording)
+				r.Post("/connections", api.postWorkspaceAgentConnection)
 			})
 			r.Route("/{workspaceagent}", func(r chi.Router) {
 				r.Use(
@@ -397,6 +516,9 @@ func New(options *Options) *API {
 				r.Get("/dial", api.workspaceAgentDial)
 				r.Get("/turn", api.userWorkspaceAgentTurn)
 				r.Get("/pty", api.workspaceAgentPTY)
+				r.Get("/startup-logs", api.workspaceAgentStartupLogs)
+				r.Get("/listening-ports", api.workspaceAgentListeningPorts)
+				r.Get("/resource-stats", api.workspaceAgentResourceStats)
 				r.Get("/iceservers", api.workspaceAgentICEServers)
 
 				r.Get("/connection", api.workspaceAgentConnection)
@@ -435,8 +557,13 @@ func New(options *Options) *API {
 				})
 				r.Get("/watch", api.watchWorkspace)
 				r.Put("/extend", api.putExtendWorkspace)
+				r.Get("/session-recordings", api.workspaceSessionRecordings)
 			})
 		})
+		r.Route("/session-recordings/{sessionrecording}", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.workspaceSessionRecording)
+		})
 		r.Route("/workspacebuilds/{workspacebuild}", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -446,6 +573,7 @@ func New(options *Options) *API {
 			r.Get("/", api.workspaceBuild)
 			r.Patch("/cancel", api.patchCancelWorkspaceBuild)
 			r.Get("/logs", api.workspaceBuildLogs)
+			r.Get("/parameters", api.workspaceBuildParameters)
 			r.Get("/resources", api.workspaceBuildResources)
 			r.Get("/state", api.workspaceBuildState)
 		})
@@ -476,6 +604,16 @@ type API struct {
 	httpAuth            *HTTPAuthorizer
 
 	metricsCache *metricscache.Cache
+	agentClients *agentClients
+
+	jobReaperCancel context.CancelFunc
+	jobReaperDone   chan struct{}
+
+	// shutdownScriptsCtx is canceled on close, which stops the shutdown
+	// scripts that are running.
+	shutdownScriptsCtx       context.Context
+	shutdownScriptsCancel    context.CancelFunc
+	shutdownScriptsWaitGroup sync.WaitGroup
 }
 
 // Close waits for all WebSocket connections to drain before returning.
@@ -485,6 +623,14 @@ func (api *API) Close() error {
 	api.websocketWaitMutex.Unlock()
 
 	api.metricsCache.Close()
+	api.jobReaperCancel()
+	<-api.jobReaperDone
+	api.shutdownScriptsCancel()
+	api.shutdownScriptsWaitGroup.Wait()
+	_ = api.TailnetCoordinator.Close()
+	if closer, ok := api.Auditor.(io.Closer); ok {
+		_ = closer.Close()
+	}
 
 	return api.workspaceAgentCache.Close()
 }
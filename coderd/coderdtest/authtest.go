@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/xerrors"
@@ -44,6 +45,7 @@ type AuthTester struct {
 	File                  codersdk.UploadResponse
 	TemplateVersionDryRun codersdk.ProvisionerJob
 	TemplateParam         codersdk.Parameter
+	Group                 codersdk.Group
 	URLParams             map[string]string
 }
 
@@ -119,24 +121,32 @@ func NewAuthTester(ctx context.Context, t *testing.T, options *Options) *AuthTes
 	})
 	require.NoError(t, err, "create template param")
 
+	group, err := client.CreateGroup(ctx, admin.OrganizationID, codersdk.CreateGroupRequest{
+		Name: "testgroup",
+	})
+	require.NoError(t, err, "create group")
+
 	urlParameters := map[string]string{
-		"{organization}":        admin.OrganizationID.String(),
-		"{user}":                admin.UserID.String(),
-		"{organizationname}":    organization.Name,
-		"{workspace}":           workspace.ID.String(),
-		"{workspacebuild}":      workspace.LatestBuild.ID.String(),
-		"{workspacename}":       workspace.Name,
-		"{workspacebuildname}":  workspace.LatestBuild.Name,
-		"{workspaceagent}":      workspaceResources[0].Agents[0].ID.String(),
-		"{buildnumber}":         strconv.FormatInt(int64(workspace.LatestBuild.BuildNumber), 10),
-		"{template}":            template.ID.String(),
-		"{hash}":                file.Hash,
-		"{workspaceresource}":   workspaceResources[0].ID.String(),
-		"{workspaceapp}":        workspaceResources[0].Agents[0].Apps[0].Name,
-		"{templateversion}":     version.ID.String(),
-		"{jobID}":               templateVersionDryRun.ID.String(),
-		"{templatename}":        template.Name,
-		"{workspace_and_agent}": workspace.Name + "." + workspaceResources[0].Agents[0].Name,
+		"{organization}":         admin.OrganizationID.String(),
+		"{user}":                 admin.UserID.String(),
+		"{organizationname}":     organization.Name,
+		"{workspace}":            workspace.ID.String(),
+		"{workspacebuild}":       workspace.LatestBuild.ID.String(),
+		"{workspacename}":        workspace.Name,
+		"{workspacebuildname}":   workspace.LatestBuild.Name,
+		"{workspaceagent}":       workspaceResources[0].Agents[0].ID.String(),
+		"{buildnumber}":          strconv.FormatInt(int64(workspace.LatestBuild.BuildNumber), 10),
+		"{template}":             template.ID.String(),
+		"{hash}":                 file.Hash,
+		"{workspaceresource}":    workspaceResources[0].ID.String(),
+		"{workspaceapp}":         workspaceResources[0].Agents[0].Apps[0].Name,
+		"{templateversion}":      version.ID.String(),
+		"{othertemplateversion}": version.ID.String(),
+		"{jobID}":                templateVersionDryRun.ID.String(),
+		"{templatename}":         template.Name,
+		"{group}":                group.ID.String(),
+		"{sessionrecording}":     uuid.NewString(),
+		"{workspace_and_agent}":  workspace.Name + "." + workspaceResources[0].Agents[0].Name,
 		// Only checking template scoped params here
 		"parameters/{scope}/{id}": fmt.Sprintf("parameters/%s/%s",
 			string(templateParam.Scope), templateParam.ScopeID.String()),
@@ -156,6 +166,7 @@ func NewAuthTester(ctx context.Context, t *testing.T, options *Options) *AuthTes
 		File:                  file,
 		TemplateVersionDryRun: templateVersionDryRun,
 		TemplateParam:         templateParam,
+		Group:                 group,
 		URLParams:             urlParameters,
 	}
 }
@@ -197,9 +208,20 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		"GET:/api/v2/workspaceagents/me/turn":                     {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/me/coordinate":               {NoAuthorize: true},
 		"POST:/api/v2/workspaceagents/me/version":                 {NoAuthorize: true},
+		"PATCH:/api/v2/workspaceagents/me/startup-logs":           {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/me/report-stats":             {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/report-lifecycle":        {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/session-recordings":      {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/connections":             {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/{workspaceagent}/iceservers": {NoAuthorize: true},
 
+		// External provisioner daemons authenticate with a pre-shared key.
+		"GET:/api/v2/provisionerdaemons/serve": {NoAuthorize: true},
+
+		// Subdomain applications authorize when proxying.
+		"GET:/api/v2/applications/auth-redirect": {NoAuthorize: true},
+		"GET:/api/v2/applications/host":          {NoAuthorize: true},
+
 		// These endpoints have more assertions. This is good, add more endpoints to assert if you can!
 		"GET:/api/v2/organizations/{organization}": {AssertObject: rbac.ResourceOrganization.InOrg(a.Admin.OrganizationID)},
 		"GET:/api/v2/users/{user}/organizations":   {StatusCode: http.StatusOK, AssertObject: rbac.ResourceOrganization},
@@ -231,6 +253,14 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
+		"GET:/api/v2/workspaces/{workspace}/session-recordings": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/session-recordings/{sessionrecording}": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceWorkspaceSessionRecording,
+		},
 		"PUT:/api/v2/workspaces/{workspace}/autostart": {
 			AssertAction: rbac.ActionUpdate,
 			AssertObject: workspaceRBACObj,
@@ -255,10 +285,26 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
+		"GET:/api/v2/workspacebuilds/{workspacebuild}/parameters": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
 		"GET:/api/v2/workspaceagents/{workspaceagent}": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/startup-logs": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/listening-ports": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/resource-stats": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
 		"GET:/api/v2/workspaceagents/{workspaceagent}/dial": {
 			AssertAction: rbac.ActionCreate,
 			AssertObject: workspaceExecObj,
@@ -297,11 +343,41 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
 		},
-		"POST:/api/v2/files": {AssertAction: rbac.ActionCreate, AssertObject: rbac.ResourceFile},
+		"GET:/api/v2/audit":       {AssertAction: rbac.ActionRead, AssertObject: rbac.ResourceAuditLog},
+		"GET:/api/v2/connections": {AssertAction: rbac.ActionRead, AssertObject: rbac.ResourceWorkspaceConnection},
+		"POST:/api/v2/files":      {AssertAction: rbac.ActionCreate, AssertObject: rbac.ResourceFile},
 		"GET:/api/v2/files/{hash}": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceFile.WithOwner(a.Admin.UserID.String()),
 		},
+		"GET:/api/v2/templates/{template}/acl": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
+		},
+		"PATCH:/api/v2/templates/{template}/acl": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
+		},
+		"POST:/api/v2/organizations/{organization}/groups": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: rbac.ResourceGroup.InOrg(a.Organization.ID),
+		},
+		"GET:/api/v2/organizations/{organization}/groups": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceGroup.InOrg(a.Organization.ID),
+		},
+		"GET:/api/v2/groups/{group}": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceGroup.InOrg(a.Group.OrganizationID),
+		},
+		"PATCH:/api/v2/groups/{group}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceGroup.InOrg(a.Group.OrganizationID),
+		},
+		"DELETE:/api/v2/groups/{group}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceGroup.InOrg(a.Group.OrganizationID),
+		},
 		"GET:/api/v2/templates/{template}/versions": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
@@ -322,6 +398,10 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionUpdate,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
 		},
+		"GET:/api/v2/templateversions/{templateversion}/diff/{othertemplateversion}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
+		},
 		"GET:/api/v2/templateversions/{templateversion}/logs": {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
@@ -363,6 +443,11 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			StatusCode:   http.StatusOK,
 			AssertObject: rbac.ResourceProvisionerDaemon,
 		},
+		"GET:/api/v2/provisionerjobs": {
+			StatusCode:   http.StatusOK,
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceProvisionerJob,
+		},
 
 		"POST:/api/v2/parameters/{scope}/{id}": {
 			AssertAction: rbac.ActionUpdate,
@@ -523,6 +608,7 @@ func (a *AuthTester) Test(ctx context.Context, assertRoute map[string]RouteCheck
 type authCall struct {
 	SubjectID string
 	Roles     []string
+	Groups    []string
 	Action    rbac.Action
 	Object    rbac.Object
 }
@@ -532,21 +618,23 @@ type recordingAuthorizer struct {
 	AlwaysReturn error
 }
 
-func (r *recordingAuthorizer) ByRoleName(_ context.Context, subjectID string, roleNames []string, action rbac.Action, object rbac.Object) error {
+func (r *recordingAuthorizer) ByRoleName(_ context.Context, subjectID string, roleNames []string, groups []string, action rbac.Action, object rbac.Object) error {
 	r.Called = &authCall{
 		SubjectID: subjectID,
 		Roles:     roleNames,
+		Groups:    groups,
 		Action:    action,
 		Object:    object,
 	}
 	return r.AlwaysReturn
 }
 
-func (r *recordingAuthorizer) PrepareByRoleName(_ context.Context, subjectID string, roles []string, action rbac.Action, _ string) (rbac.PreparedAuthorized, error) {
+func (r *recordingAuthorizer) PrepareByRoleName(_ context.Context, subjectID string, roles []string, groups []string, action rbac.Action, _ string) (rbac.PreparedAuthorized, error) {
 	return &fakePreparedAuthorizer{
 		Original:  r,
 		SubjectID: subjectID,
 		Roles:     roles,
+		Groups:    groups,
 		Action:    action,
 	}, nil
 }
@@ -559,9 +647,10 @@ type fakePreparedAuthorizer struct {
 	Original  *recordingAuthorizer
 	SubjectID string
 	Roles     []string
+	Groups    []string
 	Action    rbac.Action
 }
 
 func (f *fakePreparedAuthorizer) Authorize(ctx context.Context, object rbac.Object) error {
-	return f.Original.ByRoleName(ctx, f.SubjectID, f.Roles, f.Action, object)
+	return f.Original.ByRoleName(ctx, f.SubjectID, f.Roles, f.Groups, f.Action, object)
 }
@@ -1,6 +1,7 @@
 package coderdtest
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
 	"crypto"
@@ -13,14 +14,18 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"net"
 	"net/http"
+	"net/http/cgi"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -42,6 +47,7 @@ import (
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/coderd"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/autobuild/executor"
 	"github.com/coder/coder/coderd/awsidentity"
 	"github.com/coder/coder/coderd/database"
@@ -56,6 +62,7 @@ import (
 	"github.com/coder/coder/cryptorand"
 	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionerd"
+	provisionerdproto "github.com/coder/coder/provisionerd/proto"
 	"github.com/coder/coder/provisionersdk"
 	"github.com/coder/coder/provisionersdk/proto"
 	"github.com/coder/coder/testutil"
@@ -73,10 +80,19 @@ type Options struct {
 	AutoImportTemplates  []coderd.AutoImportTemplate
 	AutobuildTicker      <-chan time.Time
 	AutobuildStats       chan<- executor.Stats
+	ProvisionerDaemonPSK string
+	AppHostname          string
+	Auditor              audit.Auditor
+	// Database is used instead of an in-memory database when set, so tests
+	// can insert data that the API doesn't create.
+	Database database.Store
+	// ProvisionerJobHeartbeatTimeout defaults to the coderd default.
+	ProvisionerJobHeartbeatTimeout time.Duration
+	TemplateGitHosts               []string
 
 	// IncludeProvisionerD when true means to start an in-memory provisionerD
 	IncludeProvisionerD bool
-	APIBuilder          func(*coderd.Options) *coderd.API
+	APIBuilder          func(*coderd.Options) (*coderd.API, error)
 }
 
 // New constructs a codersdk client connected to an in-memory API instance.
@@ -135,13 +151,17 @@ func newWithAPI(t *testing.T, options *Options) (*codersdk.Client, io.Closer, *c
 		})
 	}
 	if options.APIBuilder == nil {
-		options.APIBuilder = coderd.New
+		options.APIBuilder = func(o *coderd.Options) (*coderd.API, error) {
+			return coderd.New(o), nil
+		}
 	}
 
 	// This can be hotswapped for a live database instance.
 	db := databasefake.New()
 	pubsub := database.NewPubsubInMemory()
-	if os.Getenv("DB") != "" {
+	if options.Database != nil {
+		db = options.Database
+	} else if os.Getenv("DB") != "" {
 		connectionURL, closePg, err := postgres.Open()
 		require.NoError(t, err)
 		t.Cleanup(closePg)
@@ -195,7 +215,7 @@ func newWithAPI(t *testing.T, options *Options) (*codersdk.Client, io.Closer, *c
 	})
 
 	// We set the handler after server creation for the access URL.
-	coderAPI := options.APIBuilder(&coderd.Options{
+	coderAPI, err := options.APIBuilder(&coderd.Options{
 		AgentConnectionUpdateFrequency: 150 * time.Millisecond,
 		// Force a long disconnection timeout to ensure
 		// agents are not marked as disconnected during slow tests.
@@ -216,6 +236,9 @@ func newWithAPI(t *testing.T, options *Options) (*codersdk.Client, io.Closer, *c
 		APIRateLimit:         options.APIRateLimit,
 		Authorizer:           options.Authorizer,
 		Telemetry:            telemetry.NewNoop(),
+		ProvisionerDaemonPSK: options.ProvisionerDaemonPSK,
+		AppHostname:          options.AppHostname,
+		Auditor:              options.Auditor,
 		DERPMap: &tailcfg.DERPMap{
 			Regions: map[int]*tailcfg.DERPRegion{
 				1: {
@@ -237,7 +260,12 @@ func newWithAPI(t *testing.T, options *Options) (*codersdk.Client, io.Closer, *c
 		AutoImportTemplates:         options.AutoImportTemplates,
 		MetricsCacheRefreshInterval: time.Millisecond * 100,
 		AgentStatsRefreshInterval:   time.Millisecond * 100,
+
+		ProvisionerJobHeartbeatTimeout: options.ProvisionerJobHeartbeatTimeout,
+		ProvisionerJobReapInterval:     time.Millisecond * 100,
+		TemplateGitHosts:               options.TemplateGitHosts,
 	})
+	require.NoError(t, err)
 	t.Cleanup(func() {
 		_ = coderAPI.Close()
 	})
@@ -290,6 +318,44 @@ func NewProvisionerDaemon(t *testing.T, coderAPI *coderd.API) io.Closer {
 	return closer
 }
 
+// NewExternalProvisionerDaemon launches a provisionerd instance that connects
+// to coderd over the API, authenticating with the pre-shared key provided.
+// It registers the "echo" provisioner and only acquires jobs matching tags.
+func NewExternalProvisionerDaemon(t *testing.T, client *codersdk.Client, psk string, tags map[string]string) io.Closer {
+	echoClient, echoServer := provisionersdk.TransportPipe()
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		_ = echoClient.Close()
+		_ = echoServer.Close()
+		cancelFunc()
+	})
+	fs := afero.NewMemMapFs()
+	go func() {
+		err := echo.Serve(ctx, fs, &provisionersdk.ServeOptions{
+			Listener: echoServer,
+		})
+		assert.NoError(t, err)
+	}()
+
+	closer := provisionerd.New(func(ctx context.Context) (provisionerdproto.DRPCProvisionerDaemonClient, error) {
+		return client.ServeProvisionerDaemon(ctx, "", []codersdk.ProvisionerType{codersdk.ProvisionerTypeEcho}, tags, psk)
+	}, &provisionerd.Options{
+		Filesystem:          fs,
+		Logger:              slogtest.Make(t, nil).Named("provisionerd").Leveled(slog.LevelDebug),
+		PollInterval:        10 * time.Millisecond,
+		UpdateInterval:      25 * time.Millisecond,
+		ForceCancelInterval: time.Second,
+		Provisioners: provisionerd.Provisioners{
+			string(database.ProvisionerTypeEcho): proto.NewDRPCProvisionerClient(provisionersdk.Conn(echoClient)),
+		},
+		WorkDirectory: t.TempDir(),
+	})
+	t.Cleanup(func() {
+		_ = closer.Close()
+	})
+	return closer
+}
+
 var FirstUserParams = codersdk.CreateFirstUserRequest{
 	Email:            "testuser@coder.com",
 	Username:         "testuser",
@@ -401,6 +467,63 @@ func CreateTemplateVersion(t *testing.T, client *codersdk.Client, organizationID
 	return templateVersion
 }
 
+// CreateGitRepository commits the echo responses provided to a new bare git
+// repository, serves it over HTTP, and returns its URL and the commit. The
+// test is skipped when git isn't installed.
+func CreateGitRepository(t *testing.T, res *echo.Responses) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@coder.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@coder.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	data, err := echo.Tar(res)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	require.NoError(t, os.MkdirAll(work, 0o700))
+	reader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(work, header.Name), content, 0o600))
+	}
+	// Git doesn't track empty directories, so there's always something to
+	// commit.
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README.md"), []byte("# Echo"), 0o600))
+	git(work, "init", "--quiet")
+	git(work, "add", ".")
+	git(work, "commit", "--quiet", "--message", "Initial commit")
+	commit := git(work, "rev-parse", "HEAD")
+	bare := filepath.Join(dir, "repository.git")
+	git(dir, "clone", "--quiet", "--bare", work, bare)
+
+	path, err := exec.LookPath("git")
+	require.NoError(t, err)
+	server := httptest.NewServer(&cgi.Handler{
+		Path: path,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + dir, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	t.Cleanup(server.Close)
+	return server.URL + "/repository.git", commit
+}
+
 // CreateWorkspaceBuild creates a workspace build for the given workspace and transition.
 func CreateWorkspaceBuild(
 	t *testing.T,
@@ -520,6 +643,36 @@ func CreateWorkspace(t *testing.T, client *codersdk.Client, organization uuid.UU
 	return workspace
 }
 
+// InsertAuditLog inserts an audit log into the database. Fields that aren't
+// set in log are filled with defaults.
+func InsertAuditLog(t *testing.T, db database.Store, log database.InsertAuditLogParams) database.AuditLog {
+	t.Helper()
+	if log.ID == uuid.Nil {
+		log.ID = uuid.New()
+	}
+	if log.Time.IsZero() {
+		log.Time = database.Now()
+	}
+	if log.Action == "" {
+		log.Action = database.AuditActionWrite
+	}
+	if log.ResourceType == "" {
+		log.ResourceType = database.ResourceTypeUser
+	}
+	if log.StatusCode == 0 {
+		log.StatusCode = http.StatusOK
+	}
+	if log.Diff == nil {
+		log.Diff = json.RawMessage("{}")
+	}
+	if log.AdditionalFields == nil {
+		log.AdditionalFields = json.RawMessage("{}")
+	}
+	auditLog, err := db.InsertAuditLog(context.Background(), log)
+	require.NoError(t, err)
+	return auditLog
+}
+
 // TransitionWorkspace is a convenience method for transitioning a workspace from one state to another.
 func MustTransitionWorkspace(t *testing.T, client *codersdk.Client, workspaceID uuid.UUID, from, to database.WorkspaceTransition) codersdk.Workspace {
 	t.Helper()
@@ -0,0 +1,393 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet"
+)
+
+// agentClients tracks the users coderd authenticated for connections to
+// each agent. Agents only know the address a connection came from, so
+// clients are keyed by the addresses of their tailnet nodes, and reconnecting
+// PTYs coderd opens are keyed by their ID. Nothing the client sends with a
+// connection is used to attribute it.
+type agentClients struct {
+	mu sync.Mutex
+	// peers maps agent IDs to tailnet client IDs to user IDs.
+	peers map[uuid.UUID]map[uuid.UUID]uuid.UUID
+	// ptys maps agent IDs to reconnecting PTY IDs to user IDs.
+	ptys map[uuid.UUID]map[string]uuid.UUID
+}
+
+func newAgentClients() *agentClients {
+	return &agentClients{
+		peers: map[uuid.UUID]map[uuid.UUID]uuid.UUID{},
+		ptys:  map[uuid.UUID]map[string]uuid.UUID{},
+	}
+}
+
+// addPeer records the tailnet client a user coordinates with an agent
+// through. The returned function must be called when the client disconnects.
+func (c *agentClients) addPeer(agentID, clientID, userID uuid.UUID) func() {
+	return addAgentClient(&c.mu, c.peers, agentID, clientID, userID)
+}
+
+// addPTY records a reconnecting PTY coderd opens to an agent for a user. The
+// returned function must be called when the PTY is closed.
+func (c *agentClients) addPTY(agentID uuid.UUID, reconnectID string, userID uuid.UUID) func() {
+	return addAgentClient(&c.mu, c.ptys, agentID, reconnectID, userID)
+}
+
+func addAgentClient[K comparable](mu *sync.Mutex, clients map[uuid.UUID]map[K]uuid.UUID, agentID uuid.UUID, key K, userID uuid.UUID) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	agentClients, ok := clients[agentID]
+	if !ok {
+		agentClients = map[K]uuid.UUID{}
+		clients[agentID] = agentClients
+	}
+	agentClients[key] = userID
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(agentClients, key)
+		if len(agentClients) == 0 {
+			delete(clients, agentID)
+		}
+	}
+}
+
+// user returns the user who made a connection reported by an agent: the user
+// of the tailnet client it came from, or the user coderd opened the
+// reconnecting PTY for. Other connections, like the ones coderd makes to
+// proxy workspace apps, aren't attributed to anyone.
+func (c *agentClients) user(coordinator tailnet.Coordinator, agentID uuid.UUID, connection agent.Connection) (uuid.UUID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if addrPort, err := netip.ParseAddrPort(connection.RemoteAddr); err == nil {
+		addr := addrPort.Addr().Unmap()
+		for clientID, userID := range c.peers[agentID] {
+			node := coordinator.Node(clientID)
+			if node == nil {
+				continue
+			}
+			for _, prefix := range node.Addresses {
+				if prefix.Contains(addr) {
+					return userID, true
+				}
+			}
+		}
+	}
+	if connection.Type == agent.ConnectionTypeReconnectingPTY {
+		userID, ok := c.ptys[agentID][connection.Target]
+		return userID, ok
+	}
+	return uuid.Nil, false
+}
+
+func (api *API) postWorkspaceAgentConnection(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	var req agent.Connection
+	if !httpapi.Read(rw, r, &req) {
+		return
+	}
+	connectionType, err := parseWorkspaceConnectionType(string(req.Type))
+	if err != nil || connectionType == database.WorkspaceConnectionTypeWorkspaceApp {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid connection type.",
+			Detail:  fmt.Sprintf("invalid connection type %q", req.Type),
+		})
+		return
+	}
+	if req.ID == uuid.Nil || req.StartedAt.IsZero() {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "A connection must have an ID and a start time.",
+		})
+		return
+	}
+
+	workspace, err := api.workspaceByAgent(ctx, workspaceAgent)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	var userID uuid.NullUUID
+	userID.UUID, userID.Valid = api.agentClients.user(api.TailnetCoordinator, workspaceAgent.ID, req)
+	_, err = api.Database.UpsertWorkspaceConnection(ctx, database.UpsertWorkspaceConnectionParams{
+		ID:          req.ID,
+		WorkspaceID: workspace.ID,
+		AgentID:     workspaceAgent.ID,
+		UserID:      userID,
+		Type:        connectionType,
+		RemoteAddr:  req.RemoteAddr,
+		Target:      req.Target,
+		StartedAt:   req.StartedAt,
+		EndedAt: sql.NullTime{
+			Time:  req.EndedAt,
+			Valid: !req.EndedAt.IsZero(),
+		},
+		RxBytes: req.RxBytes,
+		TxBytes: req.TxBytes,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "The connection was reported by another agent.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error recording connection.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (api *API) workspaceConnections(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceWorkspaceConnection) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	filter, errs := workspaceConnectionSearchQuery(r.URL.Query().Get("q"))
+	if len(errs) > 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid connection search query.",
+			Validations: errs,
+		})
+		return
+	}
+
+	page, ok := parsePagination(rw, r)
+	if !ok {
+		return
+	}
+	filter.OffsetOpt = int32(page.Offset)
+	filter.LimitOpt = int32(page.Limit)
+
+	connections, err := api.Database.GetWorkspaceConnectionsOffset(ctx, filter)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching connections.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	count, err := api.Database.GetWorkspaceConnectionCount(ctx, database.GetWorkspaceConnectionCountParams{
+		WorkspaceID: filter.WorkspaceID,
+		Username:    filter.Username,
+		Type:        filter.Type,
+		DateFrom:    filter.DateFrom,
+		DateTo:      filter.DateTo,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching connection count.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiConnections := make([]codersdk.WorkspaceConnection, 0, len(connections))
+	for _, connection := range connections {
+		apiConnections = append(apiConnections, convertWorkspaceConnection(connection))
+	}
+	httpapi.Write(rw, http.StatusOK, codersdk.WorkspaceConnectionsResponse{
+		Connections: apiConnections,
+		Count:       count,
+	})
+}
+
+// logWorkspaceAppConnection records a request proxied to a workspace app. It
+// returns a function that records the end of the request, which must be
+// called once it has been proxied.
+func (api *API) logWorkspaceAppConnection(r *http.Request, workspace database.Workspace, workspaceAgent database.WorkspaceAgent, app database.WorkspaceApp) (*appTraffic, func()) {
+	params := database.UpsertWorkspaceConnectionParams{
+		ID:          uuid.New(),
+		WorkspaceID: workspace.ID,
+		AgentID:     workspaceAgent.ID,
+		Type:        database.WorkspaceConnectionTypeWorkspaceApp,
+		RemoteAddr:  r.RemoteAddr,
+		Target:      app.Name,
+		StartedAt:   database.Now(),
+	}
+	if apiKey, ok := httpmw.APIKeyOptional(r); ok {
+		params.UserID = uuid.NullUUID{UUID: apiKey.UserID, Valid: true}
+	}
+	_, err := api.Database.UpsertWorkspaceConnection(r.Context(), params)
+	if err != nil {
+		api.Logger.Warn(r.Context(), "record workspace app connection", slog.F("app", app.Name), slog.Error(err))
+	}
+
+	traffic := &appTraffic{}
+	return traffic, func() {
+		params.EndedAt = sql.NullTime{Time: database.Now(), Valid: true}
+		params.RxBytes = atomic.LoadInt64(&traffic.rxBytes)
+		params.TxBytes = atomic.LoadInt64(&traffic.txBytes)
+		// The request context may be canceled once the client has gone.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := api.Database.UpsertWorkspaceConnection(ctx, params)
+		if err != nil {
+			api.Logger.Warn(ctx, "record workspace app connection", slog.F("app", app.Name), slog.Error(err))
+		}
+	}
+}
+
+// appTraffic counts the bytes proxied to and from a workspace app.
+type appTraffic struct {
+	rxBytes int64
+	txBytes int64
+}
+
+// wrapRequest counts the bytes of the request body, which are received by
+// the workspace.
+func (t *appTraffic) wrapRequest(r *http.Request) {
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = &countingReadWriteCloser{ReadCloser: r.Body, read: &t.rxBytes}
+	}
+}
+
+// wrapResponse counts the bytes of the response body, which are sent by the
+// workspace. Upgraded connections are read and written, so both directions
+// are counted.
+func (t *appTraffic) wrapResponse(res *http.Response) error {
+	body := &countingReadWriteCloser{ReadCloser: res.Body, read: &t.txBytes}
+	if writer, ok := res.Body.(io.Writer); ok && res.StatusCode == http.StatusSwitchingProtocols {
+		body.writer = writer
+		body.written = &t.rxBytes
+		res.Body = body
+		return nil
+	}
+	res.Body = struct{ io.ReadCloser }{body}
+	return nil
+}
+
+type countingReadWriteCloser struct {
+	io.ReadCloser
+	read *int64
+
+	writer  io.Writer
+	written *int64
+}
+
+func (c *countingReadWriteCloser) Read(b []byte) (int, error) {
+	n, err := c.ReadCloser.Read(b)
+	atomic.AddInt64(c.read, int64(n))
+	return n, err
+}
+
+func (c *countingReadWriteCloser) Write(b []byte) (int, error) {
+	if c.writer == nil {
+		return 0, xerrors.New("body is not writable")
+	}
+	n, err := c.writer.Write(b)
+	atomic.AddInt64(c.written, int64(n))
+	return n, err
+}
+
+func convertWorkspaceConnection(connection database.GetWorkspaceConnectionsOffsetRow) codersdk.WorkspaceConnection {
+	apiConnection := codersdk.WorkspaceConnection{
+		ID:            connection.ID,
+		WorkspaceID:   connection.WorkspaceID,
+		WorkspaceName: connection.WorkspaceName,
+		AgentID:       connection.AgentID,
+		AgentName:     connection.AgentName,
+		Username:      connection.Username,
+		Type:          codersdk.WorkspaceConnectionType(connection.Type),
+		RemoteAddr:    connection.RemoteAddr,
+		Target:        connection.Target,
+		StartedAt:     connection.StartedAt,
+		RxBytes:       connection.RxBytes,
+		TxBytes:       connection.TxBytes,
+	}
+	if connection.UserID.Valid {
+		apiConnection.UserID = &connection.UserID.UUID
+	}
+	if connection.EndedAt.Valid {
+		apiConnection.EndedAt = &connection.EndedAt.Time
+	}
+	return apiConnection
+}
+
+func workspaceConnectionSearchQuery(query string) (database.GetWorkspaceConnectionsOffsetParams, []codersdk.ValidationError) {
+	searchParams := make(url.Values)
+	if query == "" {
+		// No filter
+		return database.GetWorkspaceConnectionsOffsetParams{}, nil
+	}
+	query = strings.ToLower(query)
+	elements := splitQueryParameterByDelimiter(query, ' ', true)
+	for _, element := range elements {
+		parts := splitQueryParameterByDelimiter(element, ':', false)
+		switch len(parts) {
+		case 1:
+			// No key:value pair. It is the username of the user.
+			searchParams.Set("username", parts[0])
+		case 2:
+			searchParams.Set(parts[0], parts[1])
+		default:
+			return database.GetWorkspaceConnectionsOffsetParams{}, []codersdk.ValidationError{
+				{Field: "q", Detail: fmt.Sprintf("Query element %q can only contain 1 ':'", element)},
+			}
+		}
+	}
+
+	parser := httpapi.NewQueryParamParser()
+	filter := database.GetWorkspaceConnectionsOffsetParams{
+		WorkspaceID: parser.UUID(searchParams, uuid.Nil, "workspace_id"),
+		Username:    parser.String(searchParams, "", "username"),
+		Type:        string(httpapi.ParseCustom(parser, searchParams, "", "type", parseWorkspaceConnectionType)),
+		DateFrom:    httpapi.ParseCustom(parser, searchParams, time.Time{}, "date_from", parseAuditDate),
+		DateTo:      httpapi.ParseCustom(parser, searchParams, time.Time{}, "date_to", parseAuditDate),
+	}
+	// The date range is inclusive, so include the entire end day.
+	if !filter.DateTo.IsZero() {
+		filter.DateTo = filter.DateTo.Add(24 * time.Hour)
+	}
+
+	return filter, parser.Errors
+}
+
+func parseWorkspaceConnectionType(v string) (database.WorkspaceConnectionType, error) {
+	switch connectionType := database.WorkspaceConnectionType(v); connectionType {
+	case database.WorkspaceConnectionTypeSsh,
+		database.WorkspaceConnectionTypeReconnectingPty,
+		database.WorkspaceConnectionTypePortForward,
+		database.WorkspaceConnectionTypeWorkspaceApp:
+		return connectionType, nil
+	}
+	return "", xerrors.Errorf("%q is not a valid connection type", v)
+}
@@ -0,0 +1,252 @@
+package coderd_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceConnections(t *testing.T) {
+	t.Parallel()
+	// #nosec
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	server := http.Server{
+		ReadHeaderTimeout: time.Minute,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("hello"))
+		}),
+	}
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = ln.Close()
+	})
+	go server.Serve(ln)
+	tcpAddr, _ := ln.Addr().(*net.TCPAddr)
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							Apps: []*proto.App{{
+								Name: "example",
+								Url:  fmt.Sprintf("http://127.0.0.1:%d", tcpAddr.Port),
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		FetchMetadata:     agentClient.WorkspaceAgentMetadata,
+		CoordinatorDialer: agentClient.ListenWorkspaceAgentTailnet,
+		WebRTCDialer:      agentClient.ListenWorkspaceAgent,
+		ReportConnection:  agentClient.PostWorkspaceAgentConnection,
+		Logger:            slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.LatestBuild.ID)
+
+	// connections returns the connections of the workspace matching the
+	// search query.
+	connections := func(ctx context.Context, query string) ([]codersdk.WorkspaceConnection, error) {
+		res, err := client.WorkspaceConnections(ctx, codersdk.WorkspaceConnectionsRequest{
+			SearchQuery: fmt.Sprintf("workspace_id:%s %s", workspace.ID, query),
+		})
+		return res.Connections, err
+	}
+	// connectionByID returns a connection of the workspace, or nil if it
+	// doesn't exist.
+	connectionByID := func(ctx context.Context, t *testing.T, id uuid.UUID) *codersdk.WorkspaceConnection {
+		found, err := connections(ctx, "type:port_forward")
+		require.NoError(t, err)
+		for _, connection := range found {
+			if connection.ID == id {
+				return &connection
+			}
+		}
+		return nil
+	}
+
+	t.Run("Agent", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		connection := agent.Connection{
+			ID:         uuid.New(),
+			Type:       agent.ConnectionTypePortForward,
+			RemoteAddr: "127.0.0.1:1234",
+			Target:     "localhost:8080",
+			StartedAt:  time.Now(),
+		}
+		err := agentClient.PostWorkspaceAgentConnection(ctx, connection)
+		require.NoError(t, err)
+		found := connectionByID(ctx, t, connection.ID)
+		require.NotNil(t, found)
+		require.Equal(t, "localhost:8080", found.Target)
+		require.Nil(t, found.EndedAt)
+		require.Nil(t, found.UserID)
+
+		connection.EndedAt = time.Now()
+		connection.RxBytes = 10
+		connection.TxBytes = 20
+		err = agentClient.PostWorkspaceAgentConnection(ctx, connection)
+		require.NoError(t, err)
+		found = connectionByID(ctx, t, connection.ID)
+		require.NotNil(t, found)
+		require.NotNil(t, found.EndedAt)
+		require.EqualValues(t, 10, found.RxBytes)
+		require.EqualValues(t, 20, found.TxBytes)
+	})
+
+	t.Run("InvalidType", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Agents can't report workspace apps, which are logged by coderd.
+		err := agentClient.PostWorkspaceAgentConnection(ctx, agent.Connection{
+			ID:        uuid.New(),
+			Type:      "workspace_app",
+			StartedAt: time.Now(),
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("ReconnectingPTY", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("ConPTY appears to be inconsistent on Windows.")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		conn, err := client.WorkspaceAgentReconnectingPTY(ctx, resources[0].Agents[0].ID, uuid.New(), 80, 80, "/bin/bash")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// The agent reports the PTY by its ID, and coderd attributes it to
+		// the user it opened the PTY for.
+		require.Eventually(t, func() bool {
+			found, err := connections(ctx, "type:reconnecting_pty")
+			return err == nil && len(found) == 1 && found[0].Username == coderdtest.FirstUserParams.Username
+		}, testutil.WaitLong, testutil.IntervalFast)
+	})
+
+	t.Run("SSH", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		conn, err := client.DialWorkspaceAgentTailnet(ctx, slogtest.Make(t, nil).Named("client"), resources[0].Agents[0].ID)
+		require.NoError(t, err)
+		defer conn.Close()
+		// The SSH user is chosen by the client, so it isn't used to
+		// attribute the connection.
+		sshConn, err := conn.SSH()
+		require.NoError(t, err)
+		defer sshConn.Close()
+		_, _, _, err = gossh.NewClientConn(sshConn, "localhost:22", &gossh.ClientConfig{
+			User: "someone-else",
+			// #nosec
+			HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		})
+		require.NoError(t, err)
+
+		// coderd attributes the connection to the user of the tailnet
+		// client it came from.
+		require.Eventually(t, func() bool {
+			found, err := connections(ctx, "type:ssh")
+			return err == nil && len(found) == 1 && found[0].Username == coderdtest.FirstUserParams.Username
+		}, testutil.WaitLong, testutil.IntervalFast)
+	})
+
+	t.Run("WorkspaceApp", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		resp, err := client.Request(ctx, http.MethodGet, "/@me/"+workspace.Name+"/apps/example/", nil)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(body))
+
+		// The end of the request is recorded after the response is sent.
+		require.Eventually(t, func() bool {
+			found, err := connections(ctx, "type:workspace_app "+coderdtest.FirstUserParams.Username)
+			return err == nil && len(found) == 1 && found[0].EndedAt != nil && found[0].TxBytes == int64(len("hello"))
+		}, testutil.WaitLong, testutil.IntervalFast)
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.WorkspaceConnections(ctx, codersdk.WorkspaceConnectionsRequest{
+			SearchQuery: "type:telnet",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err := member.WorkspaceConnections(ctx, codersdk.WorkspaceConnectionsRequest{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+}
@@ -3,6 +3,7 @@ package databasefake
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"sort"
 	"strings"
 	"sync"
@@ -12,6 +13,7 @@ import (
 	"github.com/lib/pq"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/rbac"
@@ -32,6 +34,8 @@ func New() database.Store {
 			auditLogs:                      make([]database.AuditLog, 0),
 			files:                          make([]database.File, 0),
 			gitSSHKey:                      make([]database.GitSSHKey, 0),
+			groups:                         make([]database.Group, 0),
+			groupMembers:                   make([]database.GroupMember, 0),
 			parameterSchemas:               make([]database.ParameterSchema, 0),
 			parameterValues:                make([]database.ParameterValue, 0),
 			provisionerDaemons:             make([]database.ProvisionerDaemon, 0),
@@ -42,8 +46,13 @@ func New() database.Store {
 			provisionerJobs:                make([]database.ProvisionerJob, 0),
 			templateVersions:               make([]database.TemplateVersion, 0),
 			templates:                      make([]database.Template, 0),
+			workspaceAgentResourceStats:    make([]database.WorkspaceAgentResourceStat, 0),
+			workspaceAgentStartupLogs:      make([]database.WorkspaceAgentStartupLog, 0),
 			workspaceBuilds:                make([]database.WorkspaceBuild, 0),
+			workspaceBuildParameters:       make([]database.WorkspaceBuildParameter, 0),
+			workspaceConnections:           make([]database.WorkspaceConnection, 0),
 			workspaceApps:                  make([]database.WorkspaceApp, 0),
+			workspaceSessionRecordings:     make([]database.WorkspaceSessionRecording, 0),
 			workspaces:                     make([]database.Workspace, 0),
 			licenses:                       make([]database.License, 0),
 		},
@@ -84,6 +93,8 @@ type data struct {
 	auditLogs                      []database.AuditLog
 	files                          []database.File
 	gitSSHKey                      []database.GitSSHKey
+	groups                         []database.Group
+	groupMembers                   []database.GroupMember
 	parameterSchemas               []database.ParameterSchema
 	parameterValues                []database.ParameterValue
 	provisionerDaemons             []database.ProvisionerDaemon
@@ -94,13 +105,19 @@ type data struct {
 	provisionerJobs                []database.ProvisionerJob
 	templateVersions               []database.TemplateVersion
 	templates                      []database.Template
+	workspaceAgentResourceStats    []database.WorkspaceAgentResourceStat
+	workspaceAgentStartupLogs      []database.WorkspaceAgentStartupLog
 	workspaceBuilds                []database.WorkspaceBuild
+	workspaceBuildParameters       []database.WorkspaceBuildParameter
+	workspaceConnections           []database.WorkspaceConnection
 	workspaceApps                  []database.WorkspaceApp
+	workspaceSessionRecordings     []database.WorkspaceSessionRecording
 	workspaces                     []database.Workspace
 	licenses                       []database.License
 
-	deploymentID  string
-	lastLicenseID int32
+	deploymentID                   string
+	lastLicenseID                  int32
+	lastWorkspaceAgentStartupLogID int64
 }
 
 // InTx doesn't rollback data properly for in-memory yet.
@@ -114,6 +131,14 @@ func (q *fakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.Acqu
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
+	tags := map[string]string{}
+	if len(arg.Tags) > 0 {
+		err := json.Unmarshal(arg.Tags, &tags)
+		if err != nil {
+			return database.ProvisionerJob{}, xerrors.Errorf("unmarshal: %w", err)
+		}
+	}
+
 	for index, provisionerJob := range q.provisionerJobs {
 		if provisionerJob.StartedAt.Valid {
 			continue
@@ -129,6 +154,16 @@ func (q *fakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.Acqu
 		if !found {
 			continue
 		}
+		missing := false
+		for key, value := range provisionerJob.Tags {
+			if tags[key] != value {
+				missing = true
+				break
+			}
+		}
+		if missing {
+			continue
+		}
 		provisionerJob.StartedAt = arg.StartedAt
 		provisionerJob.UpdatedAt = arg.StartedAt.Time
 		provisionerJob.WorkerID = arg.WorkerID
@@ -196,6 +231,132 @@ func (q *fakeQuerier) GetTemplateDAUs(_ context.Context, templateID uuid.UUID) (
 	return rs, nil
 }
 
+func (q *fakeQuerier) InsertWorkspaceAgentResourceStat(_ context.Context, arg database.InsertWorkspaceAgentResourceStatParams) (database.WorkspaceAgentResourceStat, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	stat := database.WorkspaceAgentResourceStat{
+		ID:               arg.ID,
+		CreatedAt:        arg.CreatedAt,
+		AgentID:          arg.AgentID,
+		WorkspaceID:      arg.WorkspaceID,
+		TemplateID:       arg.TemplateID,
+		CpuUsedCores:     arg.CpuUsedCores,
+		CpuTotalCores:    arg.CpuTotalCores,
+		MemoryUsedBytes:  arg.MemoryUsedBytes,
+		MemoryTotalBytes: arg.MemoryTotalBytes,
+		DiskUsedBytes:    arg.DiskUsedBytes,
+		DiskTotalBytes:   arg.DiskTotalBytes,
+	}
+	q.workspaceAgentResourceStats = append(q.workspaceAgentResourceStats, stat)
+	return stat, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentResourceStats(_ context.Context, arg database.GetWorkspaceAgentResourceStatsParams) ([]database.WorkspaceAgentResourceStat, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	stats := make([]database.WorkspaceAgentResourceStat, 0)
+	for _, stat := range q.workspaceAgentResourceStats {
+		if stat.AgentID != arg.AgentID || !stat.CreatedAt.After(arg.CreatedAfter) {
+			continue
+		}
+		stats = append(stats, stat)
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].CreatedAt.Before(stats[j].CreatedAt)
+	})
+	return stats, nil
+}
+
+func (q *fakeQuerier) GetLatestWorkspaceAgentResourceStats(_ context.Context, createdAfter time.Time) ([]database.GetLatestWorkspaceAgentResourceStatsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	latest := make(map[uuid.UUID]database.WorkspaceAgentResourceStat)
+	for _, stat := range q.workspaceAgentResourceStats {
+		if !stat.CreatedAt.After(createdAfter) {
+			continue
+		}
+		if existing, ok := latest[stat.AgentID]; ok && existing.CreatedAt.After(stat.CreatedAt) {
+			continue
+		}
+		latest[stat.AgentID] = stat
+	}
+
+	rows := make([]database.GetLatestWorkspaceAgentResourceStatsRow, 0, len(latest))
+	for _, stat := range latest {
+		row := database.GetLatestWorkspaceAgentResourceStatsRow{
+			ID:               stat.ID,
+			CreatedAt:        stat.CreatedAt,
+			AgentID:          stat.AgentID,
+			WorkspaceID:      stat.WorkspaceID,
+			TemplateID:       stat.TemplateID,
+			CpuUsedCores:     stat.CpuUsedCores,
+			CpuTotalCores:    stat.CpuTotalCores,
+			MemoryUsedBytes:  stat.MemoryUsedBytes,
+			MemoryTotalBytes: stat.MemoryTotalBytes,
+			DiskUsedBytes:    stat.DiskUsedBytes,
+			DiskTotalBytes:   stat.DiskTotalBytes,
+		}
+		var agentFound, workspaceFound, userFound, templateFound bool
+		for _, agent := range q.provisionerJobAgents {
+			if agent.ID == stat.AgentID {
+				row.AgentName = agent.Name
+				agentFound = true
+				break
+			}
+		}
+		var ownerID uuid.UUID
+		for _, workspace := range q.workspaces {
+			if workspace.ID == stat.WorkspaceID && !workspace.Deleted {
+				row.WorkspaceName = workspace.Name
+				ownerID = workspace.OwnerID
+				workspaceFound = true
+				break
+			}
+		}
+		for _, user := range q.users {
+			if user.ID == ownerID {
+				row.Username = user.Username
+				userFound = true
+				break
+			}
+		}
+		for _, template := range q.templates {
+			if template.ID == stat.TemplateID {
+				row.TemplateName = template.Name
+				templateFound = true
+				break
+			}
+		}
+		if !agentFound || !workspaceFound || !userFound || !templateFound {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].AgentID.String() < rows[j].AgentID.String()
+	})
+	return rows, nil
+}
+
+func (q *fakeQuerier) DeleteOldWorkspaceAgentResourceStats(_ context.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	threshold := database.Now().Add(-24 * time.Hour)
+	stats := make([]database.WorkspaceAgentResourceStat, 0, len(q.workspaceAgentResourceStats))
+	for _, stat := range q.workspaceAgentResourceStats {
+		if stat.CreatedAt.Before(threshold) {
+			continue
+		}
+		stats = append(stats, stat)
+	}
+	q.workspaceAgentResourceStats = stats
+	return nil
+}
+
 func (q *fakeQuerier) ParameterValue(_ context.Context, id uuid.UUID) (database.ParameterValue, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -249,6 +410,19 @@ func (q *fakeQuerier) GetAPIKeysLastUsedAfter(_ context.Context, after time.Time
 	return apiKeys, nil
 }
 
+func (q *fakeQuerier) GetAPIKeysByUserID(_ context.Context, arg database.GetAPIKeysByUserIDParams) ([]database.APIKey, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	apiKeys := make([]database.APIKey, 0)
+	for _, key := range q.apiKeys {
+		if key.LoginType == arg.LoginType && key.UserID == arg.UserID {
+			apiKeys = append(apiKeys, key)
+		}
+	}
+	return apiKeys, nil
+}
+
 func (q *fakeQuerier) DeleteAPIKeyByID(_ context.Context, id string) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -438,10 +612,19 @@ func (q *fakeQuerier) GetAuthorizationUserRoles(_ context.Context, userID uuid.U
 		}
 	}
 
+	groups := make([]string, 0)
+	for _, member := range q.groupMembers {
+		if member.UserID == userID {
+			groups = append(groups, member.GroupID.String())
+		}
+	}
+
 	for _, mem := range q.organizationMembers {
 		if mem.UserID == userID {
 			roles = append(roles, mem.Roles...)
 			roles = append(roles, "organization-member:"+mem.OrganizationID.String())
+			// The "Everyone" group shares the organization's ID.
+			groups = append(groups, mem.OrganizationID.String())
 		}
 	}
 
@@ -454,10 +637,11 @@ func (q *fakeQuerier) GetAuthorizationUserRoles(_ context.Context, userID uuid.U
 		Username: user.Username,
 		Status:   user.Status,
 		Roles:    roles,
+		Groups:   groups,
 	}, nil
 }
 
-func (q *fakeQuerier) GetWorkspaces(_ context.Context, arg database.GetWorkspacesParams) ([]database.Workspace, error) {
+func (q *fakeQuerier) GetWorkspaces(ctx context.Context, arg database.GetWorkspacesParams) ([]database.Workspace, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
 
@@ -497,12 +681,98 @@ func (q *fakeQuerier) GetWorkspaces(_ context.Context, arg database.GetWorkspace
 				continue
 			}
 		}
+		if !arg.LastUsedBefore.IsZero() && !workspace.LastUsedAt.Before(arg.LastUsedBefore) {
+			continue
+		}
+		if !arg.LastUsedAfter.IsZero() && workspace.LastUsedAt.Before(arg.LastUsedAfter) {
+			continue
+		}
+		if arg.Status != "" || arg.TemplateVersion != "" || arg.Outdated != "" || arg.HasAgent != "" {
+			// These filters match on the latest build, so workspaces without
+			// one never match.
+			build, err := q.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+			if err != nil {
+				continue
+			}
+			job, err := q.GetProvisionerJobByID(ctx, build.JobID)
+			if err != nil {
+				continue
+			}
+			if arg.Status != "" && !fakeWorkspaceStatusMatches(arg.Status, build, job) {
+				continue
+			}
+			if arg.TemplateVersion != "" {
+				version, err := q.GetTemplateVersionByID(ctx, build.TemplateVersionID)
+				if err != nil || !strings.EqualFold(arg.TemplateVersion, version.Name) {
+					continue
+				}
+			}
+			if arg.Outdated != "" {
+				template, err := q.GetTemplateByID(ctx, workspace.TemplateID)
+				if err != nil {
+					continue
+				}
+				outdated := build.TemplateVersionID != template.ActiveVersionID
+				if outdated != (arg.Outdated == "true") {
+					continue
+				}
+			}
+			if arg.HasAgent != "" {
+				timeout := time.Duration(arg.AgentInactiveDisconnectTimeoutSeconds) * time.Second
+				match := false
+				for _, resource := range q.provisionerJobResources {
+					if resource.JobID != job.ID {
+						continue
+					}
+					for _, agent := range q.provisionerJobAgents {
+						if agent.ResourceID == resource.ID && fakeWorkspaceAgentStatus(agent, timeout) == arg.HasAgent {
+							match = true
+						}
+					}
+				}
+				if !match {
+					continue
+				}
+			}
+		}
 		workspaces = append(workspaces, workspace)
 	}
 
 	return workspaces, nil
 }
 
+// fakeWorkspaceStatusMatches mirrors the status filter of GetWorkspaces.
+func fakeWorkspaceStatusMatches(status string, build database.WorkspaceBuild, job database.ProvisionerJob) bool {
+	succeeded := job.CompletedAt.Valid && !job.CanceledAt.Valid && job.Error.String == ""
+	switch status {
+	case "pending":
+		return !job.StartedAt.Valid && !job.CanceledAt.Valid
+	case "failed":
+		return job.CompletedAt.Valid && job.Error.String != ""
+	case "running":
+		return build.Transition == database.WorkspaceTransitionStart && succeeded
+	case "stopped":
+		return build.Transition == database.WorkspaceTransitionStop && succeeded
+	default:
+		return true
+	}
+}
+
+// fakeWorkspaceAgentStatus mirrors the agent status computed by the has_agent
+// filter of GetWorkspaces.
+func fakeWorkspaceAgentStatus(agent database.WorkspaceAgent, inactiveTimeout time.Duration) string {
+	switch {
+	case !agent.FirstConnectedAt.Valid:
+		return "connecting"
+	case agent.DisconnectedAt.Valid && agent.DisconnectedAt.Time.After(agent.LastConnectedAt.Time):
+		return "disconnected"
+	case database.Now().Sub(agent.LastConnectedAt.Time) > inactiveTimeout:
+		return "disconnected"
+	default:
+		return "connected"
+	}
+}
+
 func (q *fakeQuerier) GetWorkspaceByID(_ context.Context, id uuid.UUID) (database.Workspace, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -806,6 +1076,22 @@ func (q *fakeQuerier) GetWorkspaceBuildByWorkspaceIDAndBuildNumber(_ context.Con
 	return database.WorkspaceBuild{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) GetWorkspaceBuildParameters(_ context.Context, workspaceBuildID uuid.UUID) ([]database.WorkspaceBuildParameter, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	params := make([]database.WorkspaceBuildParameter, 0)
+	for _, param := range q.workspaceBuildParameters {
+		if param.WorkspaceBuildID == workspaceBuildID {
+			params = append(params, param)
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params, nil
+}
+
 func (q *fakeQuerier) GetWorkspaceBuildsCreatedAfter(_ context.Context, after time.Time) ([]database.WorkspaceBuild, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -950,6 +1236,10 @@ func (q *fakeQuerier) UpdateTemplateMetaByID(_ context.Context, arg database.Upd
 		tpl.Icon = arg.Icon
 		tpl.MaxTtl = arg.MaxTtl
 		tpl.MinAutostartInterval = arg.MinAutostartInterval
+		tpl.InactivityTtl = arg.InactivityTtl
+		tpl.WaitForAgentReady = arg.WaitForAgentReady
+		tpl.SessionRecording = arg.SessionRecording
+		tpl.MaxJobDuration = arg.MaxJobDuration
 		q.templates[idx] = tpl
 		return nil
 	}
@@ -1310,6 +1600,23 @@ func (q *fakeQuerier) GetWorkspaceAgentByInstanceID(_ context.Context, instanceI
 	return database.WorkspaceAgent{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) GetWorkspaceAgentStartupLogsAfter(_ context.Context, arg database.GetWorkspaceAgentStartupLogsAfterParams) ([]database.WorkspaceAgentStartupLog, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	logs := make([]database.WorkspaceAgentStartupLog, 0)
+	for _, log := range q.workspaceAgentStartupLogs {
+		if log.AgentID != arg.AgentID {
+			continue
+		}
+		if log.ID <= arg.AfterID {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
 func (q *fakeQuerier) GetWorkspaceAgentsByResourceIDs(_ context.Context, resourceIDs []uuid.UUID) ([]database.WorkspaceAgent, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1468,6 +1775,43 @@ func (q *fakeQuerier) GetWorkspaceResourceMetadataByResourceIDs(_ context.Contex
 	return metadata, nil
 }
 
+func (q *fakeQuerier) GetProvisionerJobQueuePositionsByIDs(_ context.Context, ids []uuid.UUID) ([]database.GetProvisionerJobQueuePositionsByIDsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	queued := make([]database.ProvisionerJob, 0)
+	for _, job := range q.provisionerJobs {
+		if !job.StartedAt.Valid && !job.CanceledAt.Valid && !job.CompletedAt.Valid {
+			queued = append(queued, job)
+		}
+	}
+	sort.SliceStable(queued, func(i, j int) bool {
+		return queued[i].CreatedAt.Before(queued[j].CreatedAt)
+	})
+
+	rows := make([]database.GetProvisionerJobQueuePositionsByIDsRow, 0)
+	for _, job := range queued {
+		if !slices.Contains(ids, job.ID) {
+			continue
+		}
+		// Jobs are only queued behind jobs with the same provisioner and tags.
+		row := database.GetProvisionerJobQueuePositionsByIDsRow{
+			ID: job.ID,
+		}
+		for _, other := range queued {
+			if other.Provisioner != job.Provisioner || !maps.Equal(other.Tags, job.Tags) {
+				continue
+			}
+			row.QueueSize++
+			if other.ID == job.ID {
+				row.QueuePosition = row.QueueSize
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 func (q *fakeQuerier) GetProvisionerJobsByIDs(_ context.Context, ids []uuid.UUID) ([]database.ProvisionerJob, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1501,6 +1845,35 @@ func (q *fakeQuerier) GetProvisionerJobsCreatedAfter(_ context.Context, after ti
 	return jobs, nil
 }
 
+func (q *fakeQuerier) GetActiveProvisionerJobs(_ context.Context) ([]database.ProvisionerJob, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	jobs := make([]database.ProvisionerJob, 0)
+	for _, job := range q.provisionerJobs {
+		if !job.CompletedAt.Valid {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
+
+func (q *fakeQuerier) GetRunningProvisionerJobs(_ context.Context) ([]database.ProvisionerJob, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	jobs := make([]database.ProvisionerJob, 0)
+	for _, job := range q.provisionerJobs {
+		if job.StartedAt.Valid && !job.CompletedAt.Valid {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
 func (q *fakeQuerier) GetProvisionerLogsByIDBetween(_ context.Context, arg database.GetProvisionerLogsByIDBetweenParams) ([]database.ProvisionerJobLog, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1531,6 +1904,21 @@ func (q *fakeQuerier) InsertAPIKey(_ context.Context, arg database.InsertAPIKeyP
 	if arg.LifetimeSeconds == 0 {
 		arg.LifetimeSeconds = 86400
 	}
+	if arg.Scope == "" {
+		arg.Scope = database.APIKeyScopeAll
+	}
+
+	if arg.TokenName != "" {
+		for _, key := range q.apiKeys {
+			if key.UserID == arg.UserID && key.TokenName == arg.TokenName {
+				return database.APIKey{}, &pq.Error{
+					Code:       "23505",
+					Message:    "duplicate key value violates unique constraint",
+					Constraint: string(database.UniqueApiKeysUserIDTokenNameIndex),
+				}
+			}
+		}
+	}
 
 	//nolint:gosimple
 	key := database.APIKey{
@@ -1544,6 +1932,8 @@ func (q *fakeQuerier) InsertAPIKey(_ context.Context, arg database.InsertAPIKeyP
 		UpdatedAt:       arg.UpdatedAt,
 		LastUsed:        arg.LastUsed,
 		LoginType:       arg.LoginType,
+		Scope:           arg.Scope,
+		TokenName:       arg.TokenName,
 	}
 	q.apiKeys = append(q.apiKeys, key)
 	return key, nil
@@ -1636,6 +2026,8 @@ func (q *fakeQuerier) InsertTemplate(_ context.Context, arg database.InsertTempl
 		MaxTtl:               arg.MaxTtl,
 		MinAutostartInterval: arg.MinAutostartInterval,
 		CreatedBy:            arg.CreatedBy,
+		UserACL:              arg.UserACL,
+		GroupACL:             arg.GroupACL,
 	}
 	q.templates = append(q.templates, template)
 	return template, nil
@@ -1704,6 +2096,7 @@ func (q *fakeQuerier) InsertParameterSchema(_ context.Context, arg database.Inse
 		ValidationTypeSystem:     arg.ValidationTypeSystem,
 		ValidationValueType:      arg.ValidationValueType,
 		Index:                    arg.Index,
+		Mutable:                  arg.Mutable,
 	}
 	q.parameterSchemas = append(q.parameterSchemas, param)
 	return param, nil
@@ -1718,6 +2111,7 @@ func (q *fakeQuerier) InsertProvisionerDaemon(_ context.Context, arg database.In
 		CreatedAt:    arg.CreatedAt,
 		Name:         arg.Name,
 		Provisioners: arg.Provisioners,
+		Tags:         arg.Tags,
 	}
 	q.provisionerDaemons = append(q.provisionerDaemons, daemon)
 	return daemon, nil
@@ -1738,6 +2132,7 @@ func (q *fakeQuerier) InsertProvisionerJob(_ context.Context, arg database.Inser
 		StorageSource:  arg.StorageSource,
 		Type:           arg.Type,
 		Input:          arg.Input,
+		Tags:           arg.Tags,
 	}
 	q.provisionerJobs = append(q.provisionerJobs, job)
 	return job, nil
@@ -1760,8 +2155,10 @@ func (q *fakeQuerier) InsertWorkspaceAgent(_ context.Context, arg database.Inser
 		OperatingSystem:      arg.OperatingSystem,
 		Directory:            arg.Directory,
 		StartupScript:        arg.StartupScript,
+		ShutdownScript:       arg.ShutdownScript,
 		InstanceMetadata:     arg.InstanceMetadata,
 		ResourceMetadata:     arg.ResourceMetadata,
+		LifecycleState:       database.WorkspaceAgentLifecycleStateCreated,
 	}
 
 	q.provisionerJobAgents = append(q.provisionerJobAgents, agent)
@@ -1800,6 +2197,192 @@ func (q *fakeQuerier) InsertWorkspaceResourceMetadata(_ context.Context, arg dat
 	return metadatum, nil
 }
 
+func (q *fakeQuerier) UpsertWorkspaceConnection(_ context.Context, arg database.UpsertWorkspaceConnectionParams) (database.WorkspaceConnection, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, connection := range q.workspaceConnections {
+		if connection.ID != arg.ID {
+			continue
+		}
+		if connection.AgentID != arg.AgentID {
+			return database.WorkspaceConnection{}, sql.ErrNoRows
+		}
+		connection.EndedAt = arg.EndedAt
+		connection.RxBytes = arg.RxBytes
+		connection.TxBytes = arg.TxBytes
+		q.workspaceConnections[i] = connection
+		return connection, nil
+	}
+
+	//nolint:gosimple
+	connection := database.WorkspaceConnection{
+		ID:          arg.ID,
+		WorkspaceID: arg.WorkspaceID,
+		AgentID:     arg.AgentID,
+		UserID:      arg.UserID,
+		Type:        arg.Type,
+		RemoteAddr:  arg.RemoteAddr,
+		Target:      arg.Target,
+		StartedAt:   arg.StartedAt,
+		EndedAt:     arg.EndedAt,
+		RxBytes:     arg.RxBytes,
+		TxBytes:     arg.TxBytes,
+	}
+	q.workspaceConnections = append(q.workspaceConnections, connection)
+	return connection, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceConnectionsOffset(_ context.Context, arg database.GetWorkspaceConnectionsOffsetParams) ([]database.GetWorkspaceConnectionsOffsetRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := q.filterWorkspaceConnections(database.GetWorkspaceConnectionCountParams{
+		WorkspaceID: arg.WorkspaceID,
+		Username:    arg.Username,
+		Type:        arg.Type,
+		DateFrom:    arg.DateFrom,
+		DateTo:      arg.DateTo,
+	})
+
+	if arg.OffsetOpt > 0 {
+		if int(arg.OffsetOpt) > len(rows)-1 {
+			return []database.GetWorkspaceConnectionsOffsetRow{}, nil
+		}
+		rows = rows[arg.OffsetOpt:]
+	}
+	if arg.LimitOpt > 0 && int(arg.LimitOpt) < len(rows) {
+		rows = rows[:arg.LimitOpt]
+	}
+
+	return rows, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceConnectionCount(_ context.Context, arg database.GetWorkspaceConnectionCountParams) (int64, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return int64(len(q.filterWorkspaceConnections(arg))), nil
+}
+
+// filterWorkspaceConnections returns the connections matching the filters,
+// ordered from newest to oldest. The caller must hold the lock.
+func (q *fakeQuerier) filterWorkspaceConnections(arg database.GetWorkspaceConnectionCountParams) []database.GetWorkspaceConnectionsOffsetRow {
+	rows := make([]database.GetWorkspaceConnectionsOffsetRow, 0)
+	for _, connection := range q.workspaceConnections {
+		if arg.WorkspaceID != uuid.Nil && connection.WorkspaceID != arg.WorkspaceID {
+			continue
+		}
+		if arg.Type != "" && string(connection.Type) != arg.Type {
+			continue
+		}
+		if !arg.DateFrom.IsZero() && connection.StartedAt.Before(arg.DateFrom) {
+			continue
+		}
+		if !arg.DateTo.IsZero() && !connection.StartedAt.Before(arg.DateTo) {
+			continue
+		}
+
+		row := database.GetWorkspaceConnectionsOffsetRow{
+			ID:          connection.ID,
+			WorkspaceID: connection.WorkspaceID,
+			AgentID:     connection.AgentID,
+			UserID:      connection.UserID,
+			Type:        connection.Type,
+			RemoteAddr:  connection.RemoteAddr,
+			Target:      connection.Target,
+			StartedAt:   connection.StartedAt,
+			EndedAt:     connection.EndedAt,
+			RxBytes:     connection.RxBytes,
+			TxBytes:     connection.TxBytes,
+		}
+		for _, user := range q.users {
+			if connection.UserID.Valid && user.ID == connection.UserID.UUID {
+				row.Username = user.Username
+				break
+			}
+		}
+		if arg.Username != "" && !strings.EqualFold(row.Username, arg.Username) {
+			continue
+		}
+		for _, workspace := range q.workspaces {
+			if workspace.ID == connection.WorkspaceID {
+				row.WorkspaceName = workspace.Name
+				break
+			}
+		}
+		for _, agent := range q.provisionerJobAgents {
+			if agent.ID == connection.AgentID {
+				row.AgentName = agent.Name
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].StartedAt.After(rows[j].StartedAt)
+	})
+	return rows
+}
+
+func (q *fakeQuerier) InsertWorkspaceSessionRecording(_ context.Context, arg database.InsertWorkspaceSessionRecordingParams) (database.WorkspaceSessionRecording, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	recording := database.WorkspaceSessionRecording{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		WorkspaceID: arg.WorkspaceID,
+		AgentID:     arg.AgentID,
+		Type:        arg.Type,
+		StartedAt:   arg.StartedAt,
+		EndedAt:     arg.EndedAt,
+		Size:        arg.Size,
+		Data:        arg.Data,
+	}
+	q.workspaceSessionRecordings = append(q.workspaceSessionRecordings, recording)
+	return recording, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceSessionRecordingByID(_ context.Context, id uuid.UUID) (database.WorkspaceSessionRecording, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, recording := range q.workspaceSessionRecordings {
+		if recording.ID == id {
+			return recording, nil
+		}
+	}
+	return database.WorkspaceSessionRecording{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceSessionRecordingsByWorkspaceID(_ context.Context, workspaceID uuid.UUID) ([]database.GetWorkspaceSessionRecordingsByWorkspaceIDRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetWorkspaceSessionRecordingsByWorkspaceIDRow, 0)
+	for _, recording := range q.workspaceSessionRecordings {
+		if recording.WorkspaceID != workspaceID {
+			continue
+		}
+		rows = append(rows, database.GetWorkspaceSessionRecordingsByWorkspaceIDRow{
+			ID:          recording.ID,
+			CreatedAt:   recording.CreatedAt,
+			WorkspaceID: recording.WorkspaceID,
+			AgentID:     recording.AgentID,
+			Type:        recording.Type,
+			StartedAt:   recording.StartedAt,
+			EndedAt:     recording.EndedAt,
+			Size:        recording.Size,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].StartedAt.After(rows[j].StartedAt)
+	})
+	return rows, nil
+}
+
 func (q *fakeQuerier) InsertUser(_ context.Context, arg database.InsertUserParams) (database.User, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -1939,6 +2522,41 @@ func (q *fakeQuerier) InsertWorkspaceBuild(_ context.Context, arg database.Inser
 	return workspaceBuild, nil
 }
 
+func (q *fakeQuerier) InsertWorkspaceBuildParameter(_ context.Context, arg database.InsertWorkspaceBuildParameterParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, param := range q.workspaceBuildParameters {
+		if param.WorkspaceBuildID == arg.WorkspaceBuildID && param.Name == arg.Name {
+			return &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+		}
+	}
+	q.workspaceBuildParameters = append(q.workspaceBuildParameters, database.WorkspaceBuildParameter{
+		WorkspaceBuildID: arg.WorkspaceBuildID,
+		Name:             arg.Name,
+		Value:            arg.Value,
+	})
+	return nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceAgentStartupLogs(_ context.Context, arg database.InsertWorkspaceAgentStartupLogsParams) ([]database.WorkspaceAgentStartupLog, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	logs := make([]database.WorkspaceAgentStartupLog, 0, len(arg.Output))
+	for index, output := range arg.Output {
+		q.lastWorkspaceAgentStartupLogID++
+		logs = append(logs, database.WorkspaceAgentStartupLog{
+			ID:        q.lastWorkspaceAgentStartupLogID,
+			AgentID:   arg.AgentID,
+			CreatedAt: arg.CreatedAt[index],
+			Output:    output,
+		})
+	}
+	q.workspaceAgentStartupLogs = append(q.workspaceAgentStartupLogs, logs...)
+	return logs, nil
+}
+
 func (q *fakeQuerier) InsertWorkspaceApp(_ context.Context, arg database.InsertWorkspaceAppParams) (database.WorkspaceApp, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -1953,6 +2571,7 @@ func (q *fakeQuerier) InsertWorkspaceApp(_ context.Context, arg database.InsertW
 		Command:      arg.Command,
 		Url:          arg.Url,
 		RelativePath: arg.RelativePath,
+		SharingLevel: arg.SharingLevel,
 	}
 	q.workspaceApps = append(q.workspaceApps, workspaceApp)
 	return workspaceApp, nil
@@ -2007,6 +2626,22 @@ func (q *fakeQuerier) UpdateTemplateDeletedByID(_ context.Context, arg database.
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateTemplateACLByID(_ context.Context, arg database.UpdateTemplateACLByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, t := range q.templates {
+		if t.ID == arg.ID {
+			t.GroupACL = arg.GroupACL
+			t.UserACL = arg.UserACL
+			q.templates[i] = t
+			return nil
+		}
+	}
+
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateTemplateVersionByID(_ context.Context, arg database.UpdateTemplateVersionByIDParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2039,6 +2674,22 @@ func (q *fakeQuerier) UpdateTemplateVersionDescriptionByJobID(_ context.Context,
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateTemplateVersionGitCommitSHAByJobID(_ context.Context, arg database.UpdateTemplateVersionGitCommitSHAByJobIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, templateVersion := range q.templateVersions {
+		if templateVersion.JobID != arg.JobID {
+			continue
+		}
+		templateVersion.GitCommitSHA = arg.GitCommitSHA
+		templateVersion.UpdatedAt = arg.UpdatedAt
+		q.templateVersions[index] = templateVersion
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateProvisionerDaemonByID(_ context.Context, arg database.UpdateProvisionerDaemonByIDParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2073,6 +2724,22 @@ func (q *fakeQuerier) UpdateWorkspaceAgentConnectionByID(_ context.Context, arg
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceAgentLifecycleStateByID(_ context.Context, arg database.UpdateWorkspaceAgentLifecycleStateByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, agent := range q.provisionerJobAgents {
+		if agent.ID != arg.ID {
+			continue
+		}
+
+		agent.LifecycleState = arg.LifecycleState
+		q.provisionerJobAgents[index] = agent
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateWorkspaceAgentVersionByID(_ context.Context, arg database.UpdateWorkspaceAgentVersionByIDParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2089,6 +2756,27 @@ func (q *fakeQuerier) UpdateWorkspaceAgentVersionByID(_ context.Context, arg dat
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) ReleaseProvisionerJob(_ context.Context, arg database.ReleaseProvisionerJobParams) (database.ProvisionerJob, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, job := range q.provisionerJobs {
+		if arg.ID != job.ID {
+			continue
+		}
+		if job.CanceledAt.Valid || job.CompletedAt.Valid {
+			return database.ProvisionerJob{}, sql.ErrNoRows
+		}
+		job.StartedAt = sql.NullTime{}
+		job.UpdatedAt = arg.UpdatedAt
+		job.WorkerID = uuid.NullUUID{}
+		job.Input = arg.Input
+		q.provisionerJobs[index] = job
+		return job, nil
+	}
+	return database.ProvisionerJob{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateProvisionerJobByID(_ context.Context, arg database.UpdateProvisionerJobByIDParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2104,7 +2792,7 @@ func (q *fakeQuerier) UpdateProvisionerJobByID(_ context.Context, arg database.U
 	return sql.ErrNoRows
 }
 
-func (q *fakeQuerier) UpdateProvisionerJobWithCancelByID(_ context.Context, arg database.UpdateProvisionerJobWithCancelByIDParams) error {
+func (q *fakeQuerier) UpdateProvisionerJobWithCancelByID(_ context.Context, arg database.UpdateProvisionerJobWithCancelByIDParams) (database.ProvisionerJob, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
@@ -2113,10 +2801,13 @@ func (q *fakeQuerier) UpdateProvisionerJobWithCancelByID(_ context.Context, arg
 			continue
 		}
 		job.CanceledAt = arg.CanceledAt
+		if !job.StartedAt.Valid {
+			job.CompletedAt = arg.CanceledAt
+		}
 		q.provisionerJobs[index] = job
-		return nil
+		return job, nil
 	}
-	return sql.ErrNoRows
+	return database.ProvisionerJob{}, sql.ErrNoRows
 }
 
 func (q *fakeQuerier) UpdateProvisionerJobWithCompleteByID(_ context.Context, arg database.UpdateProvisionerJobWithCompleteByIDParams) error {
@@ -2339,6 +3030,79 @@ func (q *fakeQuerier) GetAuditLogsBefore(_ context.Context, arg database.GetAudi
 	return logs, nil
 }
 
+func (q *fakeQuerier) GetAuditLogsOffset(_ context.Context, arg database.GetAuditLogsOffsetParams) ([]database.AuditLog, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	logs := q.filterAuditLogs(database.GetAuditLogCountParams{
+		Action:       arg.Action,
+		ResourceType: arg.ResourceType,
+		Username:     arg.Username,
+		DateFrom:     arg.DateFrom,
+		DateTo:       arg.DateTo,
+		StatusCode:   arg.StatusCode,
+	})
+
+	if arg.OffsetOpt > 0 {
+		if int(arg.OffsetOpt) > len(logs)-1 {
+			return []database.AuditLog{}, nil
+		}
+		logs = logs[arg.OffsetOpt:]
+	}
+	if arg.LimitOpt > 0 && int(arg.LimitOpt) < len(logs) {
+		logs = logs[:arg.LimitOpt]
+	}
+
+	return logs, nil
+}
+
+func (q *fakeQuerier) GetAuditLogCount(_ context.Context, arg database.GetAuditLogCountParams) (int64, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return int64(len(q.filterAuditLogs(arg))), nil
+}
+
+// filterAuditLogs returns the audit logs matching the filters, ordered from
+// newest to oldest. The caller must hold the lock.
+func (q *fakeQuerier) filterAuditLogs(arg database.GetAuditLogCountParams) []database.AuditLog {
+	userID := uuid.Nil
+	if arg.Username != "" {
+		for _, user := range q.users {
+			if strings.EqualFold(user.Username, arg.Username) {
+				userID = user.ID
+				break
+			}
+		}
+	}
+
+	logs := make([]database.AuditLog, 0)
+	// q.auditLogs are sorted by time ASC, so iterate backwards.
+	for i := len(q.auditLogs) - 1; i >= 0; i-- {
+		alog := q.auditLogs[i]
+		if arg.Action != "" && string(alog.Action) != arg.Action {
+			continue
+		}
+		if arg.ResourceType != "" && string(alog.ResourceType) != arg.ResourceType {
+			continue
+		}
+		if arg.Username != "" && alog.UserID != userID {
+			continue
+		}
+		if !arg.DateFrom.IsZero() && alog.Time.Before(arg.DateFrom) {
+			continue
+		}
+		if !arg.DateTo.IsZero() && !alog.Time.Before(arg.DateTo) {
+			continue
+		}
+		if arg.StatusCode != 0 && alog.StatusCode != arg.StatusCode {
+			continue
+		}
+		logs = append(logs, alog)
+	}
+	return logs
+}
+
 func (q *fakeQuerier) InsertAuditLog(_ context.Context, arg database.InsertAuditLogParams) (database.AuditLog, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2499,3 +3263,204 @@ func (q *fakeQuerier) UpdateUserLink(_ context.Context, params database.UpdateUs
 
 	return database.UserLink{}, sql.ErrNoRows
 }
+
+func (q *fakeQuerier) GetGroupByID(_ context.Context, id uuid.UUID) (database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, group := range q.groups {
+		if group.ID == id {
+			return group, nil
+		}
+	}
+
+	return database.Group{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetGroupByOrgAndName(_ context.Context, arg database.GetGroupByOrgAndNameParams) (database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, group := range q.groups {
+		if group.OrganizationID == arg.OrganizationID &&
+			group.Name == arg.Name {
+			return group, nil
+		}
+	}
+
+	return database.Group{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetGroupsByOrganizationID(_ context.Context, organizationID uuid.UUID) ([]database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var groups []database.Group
+	for _, group := range q.groups {
+		if group.OrganizationID == organizationID {
+			groups = append(groups, group)
+		}
+	}
+	slices.SortFunc(groups, func(a, b database.Group) bool {
+		return a.Name < b.Name
+	})
+
+	return groups, nil
+}
+
+func (q *fakeQuerier) GetGroupMembers(_ context.Context, groupID uuid.UUID) ([]database.User, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var users []database.User
+	for _, member := range q.groupMembers {
+		if member.GroupID != groupID {
+			continue
+		}
+		for _, user := range q.users {
+			if user.ID == member.UserID {
+				users = append(users, user)
+				break
+			}
+		}
+	}
+
+	return users, nil
+}
+
+func (q *fakeQuerier) GetUserGroups(_ context.Context, arg database.GetUserGroupsParams) ([]database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var groups []database.Group
+	for _, member := range q.groupMembers {
+		if member.UserID != arg.UserID {
+			continue
+		}
+		for _, group := range q.groups {
+			if group.ID == member.GroupID && group.OrganizationID == arg.OrganizationID {
+				groups = append(groups, group)
+				break
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+func (q *fakeQuerier) InsertAllUsersGroup(ctx context.Context, organizationID uuid.UUID) (database.Group, error) {
+	return q.InsertGroup(ctx, database.InsertGroupParams{
+		ID:             organizationID,
+		Name:           database.AllUsersGroup,
+		OrganizationID: organizationID,
+	})
+}
+
+func (q *fakeQuerier) InsertGroup(_ context.Context, arg database.InsertGroupParams) (database.Group, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, group := range q.groups {
+		if group.OrganizationID == arg.OrganizationID &&
+			group.Name == arg.Name {
+			return database.Group{}, &pq.Error{
+				Code:       "23505",
+				Message:    "duplicate key value violates unique constraint",
+				Constraint: string(database.UniqueGroupsNameOrganizationIDKey),
+			}
+		}
+	}
+
+	//nolint:gosimple
+	group := database.Group{
+		ID:             arg.ID,
+		Name:           arg.Name,
+		OrganizationID: arg.OrganizationID,
+	}
+
+	q.groups = append(q.groups, group)
+
+	return group, nil
+}
+
+func (q *fakeQuerier) UpdateGroupByID(_ context.Context, arg database.UpdateGroupByIDParams) (database.Group, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, group := range q.groups {
+		if group.ID != arg.ID {
+			continue
+		}
+		for _, other := range q.groups {
+			if other.ID != group.ID && other.OrganizationID == group.OrganizationID && other.Name == arg.Name {
+				return database.Group{}, &pq.Error{
+					Code:       "23505",
+					Message:    "duplicate key value violates unique constraint",
+					Constraint: string(database.UniqueGroupsNameOrganizationIDKey),
+				}
+			}
+		}
+		group.Name = arg.Name
+		q.groups[i] = group
+		return group, nil
+	}
+
+	return database.Group{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteGroupByID(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, group := range q.groups {
+		if group.ID == id {
+			q.groups = append(q.groups[:i], q.groups[i+1:]...)
+			members := make([]database.GroupMember, 0, len(q.groupMembers))
+			for _, member := range q.groupMembers {
+				if member.GroupID != id {
+					members = append(members, member)
+				}
+			}
+			q.groupMembers = members
+			return nil
+		}
+	}
+
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertGroupMember(_ context.Context, arg database.InsertGroupMemberParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, member := range q.groupMembers {
+		if member.GroupID == arg.GroupID &&
+			member.UserID == arg.UserID {
+			return &pq.Error{
+				Code:       "23505",
+				Message:    "duplicate key value violates unique constraint",
+				Constraint: string(database.UniqueGroupMembersUserIDGroupIDKey),
+			}
+		}
+	}
+
+	q.groupMembers = append(q.groupMembers, database.GroupMember{
+		GroupID: arg.GroupID,
+		UserID:  arg.UserID,
+	})
+
+	return nil
+}
+
+func (q *fakeQuerier) DeleteGroupMember(_ context.Context, arg database.DeleteGroupMemberParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, member := range q.groupMembers {
+		if member.GroupID == arg.GroupID && member.UserID == arg.UserID {
+			q.groupMembers = append(q.groupMembers[:i], q.groupMembers[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -1,9 +1,23 @@
 -- Code generated by 'make coderd/database/generate'. DO NOT EDIT.
 
+CREATE TYPE api_key_scope AS ENUM (
+    'all',
+    'application_connect'
+);
+
+CREATE TYPE app_sharing_level AS ENUM (
+    'owner',
+    'authenticated',
+    'public'
+);
+
 CREATE TYPE audit_action AS ENUM (
     'create',
     'write',
-    'delete'
+    'delete',
+    'start',
+    'stop',
+    'login'
 );
 
 CREATE TYPE build_reason AS ENUM (
@@ -28,7 +42,8 @@ CREATE TYPE log_source AS ENUM (
 CREATE TYPE login_type AS ENUM (
     'password',
     'github',
-    'oidc'
+    'oidc',
+    'token'
 );
 
 CREATE TYPE parameter_destination_scheme AS ENUM (
@@ -60,7 +75,8 @@ CREATE TYPE provisioner_job_type AS ENUM (
 );
 
 CREATE TYPE provisioner_storage_method AS ENUM (
-    'file'
+    'file',
+    'git'
 );
 
 CREATE TYPE provisioner_type AS ENUM (
@@ -73,7 +89,10 @@ CREATE TYPE resource_type AS ENUM (
     'template',
     'template_version',
     'user',
-    'workspace'
+    'workspace',
+    'git_ssh_key',
+    'api_key',
+    'workspace_build'
 );
 
 CREATE TYPE user_status AS ENUM (
@@ -81,6 +100,28 @@ CREATE TYPE user_status AS ENUM (
     'suspended'
 );
 
+CREATE TYPE workspace_agent_lifecycle_state AS ENUM (
+    'created',
+    'starting',
+    'start_timeout',
+    'start_error',
+    'ready',
+    'shutting_down',
+    'off'
+);
+
+CREATE TYPE workspace_connection_type AS ENUM (
+    'ssh',
+    'reconnecting_pty',
+    'port_forward',
+    'workspace_app'
+);
+
+CREATE TYPE workspace_session_recording_type AS ENUM (
+    'ssh',
+    'reconnecting_pty'
+);
+
 CREATE TYPE workspace_transition AS ENUM (
     'start',
     'stop',
@@ -107,7 +148,9 @@ CREATE TABLE api_keys (
     updated_at timestamp with time zone NOT NULL,
     login_type login_type NOT NULL,
     lifetime_seconds bigint DEFAULT 86400 NOT NULL,
-    ip_address inet DEFAULT '0.0.0.0'::inet NOT NULL
+    ip_address inet DEFAULT '0.0.0.0'::inet NOT NULL,
+    scope api_key_scope DEFAULT 'all'::api_key_scope NOT NULL,
+    token_name text DEFAULT ''::text NOT NULL
 );
 
 CREATE TABLE audit_logs (
@@ -144,6 +187,17 @@ CREATE TABLE gitsshkeys (
     public_key text NOT NULL
 );
 
+CREATE TABLE group_members (
+    user_id uuid NOT NULL,
+    group_id uuid NOT NULL
+);
+
+CREATE TABLE groups (
+    id uuid NOT NULL,
+    name text NOT NULL,
+    organization_id uuid NOT NULL
+);
+
 CREATE TABLE licenses (
     id integer NOT NULL,
     uploaded_at timestamp with time zone NOT NULL,
@@ -196,9 +250,12 @@ CREATE TABLE parameter_schemas (
     validation_condition character varying(512) NOT NULL,
     validation_type_system parameter_type_system NOT NULL,
     validation_value_type character varying(64) NOT NULL,
-    index integer NOT NULL
+    index integer NOT NULL,
+    mutable boolean DEFAULT false NOT NULL
 );
 
+COMMENT ON COLUMN parameter_schemas.mutable IS 'Mutable parameters can be changed by start builds after the workspace was created.';
+
 CREATE TABLE parameter_values (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -216,7 +273,8 @@ CREATE TABLE provisioner_daemons (
     created_at timestamp with time zone NOT NULL,
     updated_at timestamp with time zone,
     name character varying(64) NOT NULL,
-    provisioners provisioner_type[] NOT NULL
+    provisioners provisioner_type[] NOT NULL,
+    tags jsonb DEFAULT '{}'::jsonb NOT NULL
 );
 
 CREATE TABLE provisioner_job_logs (
@@ -244,7 +302,8 @@ CREATE TABLE provisioner_jobs (
     storage_source text NOT NULL,
     type provisioner_job_type NOT NULL,
     input jsonb NOT NULL,
-    worker_id uuid
+    worker_id uuid,
+    tags jsonb DEFAULT '{}'::jsonb NOT NULL
 );
 
 CREATE TABLE site_configs (
@@ -261,9 +320,12 @@ CREATE TABLE template_versions (
     name character varying(64) NOT NULL,
     readme character varying(1048576) NOT NULL,
     job_id uuid NOT NULL,
-    created_by uuid
+    created_by uuid,
+    git_commit_sha text DEFAULT ''::text NOT NULL
 );
 
+COMMENT ON COLUMN template_versions.git_commit_sha IS 'The commit the template source was cloned at, for versions imported from a git repository.';
+
 CREATE TABLE templates (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -277,9 +339,23 @@ CREATE TABLE templates (
     max_ttl bigint DEFAULT '604800000000000'::bigint NOT NULL,
     min_autostart_interval bigint DEFAULT '3600000000000'::bigint NOT NULL,
     created_by uuid NOT NULL,
-    icon character varying(256) DEFAULT ''::character varying NOT NULL
+    icon character varying(256) DEFAULT ''::character varying NOT NULL,
+    inactivity_ttl bigint DEFAULT 0 NOT NULL,
+    user_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
+    group_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
+    wait_for_agent_ready boolean DEFAULT false NOT NULL,
+    session_recording boolean DEFAULT false NOT NULL,
+    max_job_duration bigint DEFAULT 0 NOT NULL
 );
 
+COMMENT ON COLUMN templates.inactivity_ttl IS 'Inactivity TTL is the duration a running workspace may go without activity before it is automatically stopped. Zero disables inactivity-based autostop.';
+
+COMMENT ON COLUMN templates.wait_for_agent_ready IS 'Clients wait for the workspace agent to be ready, meaning the startup script has finished, before connecting.';
+
+COMMENT ON COLUMN templates.session_recording IS 'Workspace agents record interactive terminal sessions and upload the recordings to coderd.';
+
+COMMENT ON COLUMN templates.max_job_duration IS 'Max job duration is the longest a provisioner job for the template may run before it is failed. Zero disables the limit.';
+
 CREATE TABLE user_links (
     user_id uuid NOT NULL,
     login_type login_type NOT NULL,
@@ -301,6 +377,40 @@ CREATE TABLE users (
     login_type login_type DEFAULT 'password'::public.login_type NOT NULL
 );
 
+CREATE TABLE workspace_agent_resource_stats (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    agent_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    template_id uuid NOT NULL,
+    cpu_used_cores double precision NOT NULL,
+    cpu_total_cores double precision NOT NULL,
+    memory_used_bytes bigint NOT NULL,
+    memory_total_bytes bigint NOT NULL,
+    disk_used_bytes bigint NOT NULL,
+    disk_total_bytes bigint NOT NULL
+);
+
+COMMENT ON COLUMN workspace_agent_resource_stats.cpu_used_cores IS 'Average number of CPU cores used since the previous sample.';
+
+COMMENT ON COLUMN workspace_agent_resource_stats.cpu_total_cores IS 'CPU limit of the workspace, or the number of cores of the host if it is not limited.';
+
+CREATE TABLE workspace_agent_startup_logs (
+    agent_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    output character varying(1024) NOT NULL,
+    id bigint NOT NULL
+);
+
+CREATE SEQUENCE workspace_agent_startup_logs_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE workspace_agent_startup_logs_id_seq OWNED BY workspace_agent_startup_logs.id;
+
 CREATE TABLE workspace_agents (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -319,11 +429,17 @@ CREATE TABLE workspace_agents (
     instance_metadata jsonb,
     resource_metadata jsonb,
     directory character varying(4096) DEFAULT ''::character varying NOT NULL,
-    version text DEFAULT ''::text NOT NULL
+    version text DEFAULT ''::text NOT NULL,
+    lifecycle_state workspace_agent_lifecycle_state DEFAULT 'created'::workspace_agent_lifecycle_state NOT NULL,
+    shutdown_script character varying(65534)
 );
 
 COMMENT ON COLUMN workspace_agents.version IS 'Version tracks the version of the currently running workspace agent. Workspace agents register their version upon start.';
 
+COMMENT ON COLUMN workspace_agents.lifecycle_state IS 'The current lifecycle state reported by the workspace agent.';
+
+COMMENT ON COLUMN workspace_agents.shutdown_script IS 'Script run by the agent before the workspace is stopped or deleted.';
+
 CREATE TABLE workspace_apps (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -332,9 +448,18 @@ CREATE TABLE workspace_apps (
     icon character varying(256) NOT NULL,
     command character varying(65534),
     url character varying(65534),
-    relative_path boolean DEFAULT false NOT NULL
+    relative_path boolean DEFAULT false NOT NULL,
+    sharing_level app_sharing_level DEFAULT 'owner'::app_sharing_level NOT NULL
 );
 
+CREATE TABLE workspace_build_parameters (
+    workspace_build_id uuid NOT NULL,
+    name character varying(64) NOT NULL,
+    value text NOT NULL
+);
+
+COMMENT ON TABLE workspace_build_parameters IS 'The parameter values used by each workspace build. Values of parameters that are not redisplayed are not recorded.';
+
 CREATE TABLE workspace_builds (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -351,6 +476,30 @@ CREATE TABLE workspace_builds (
     reason build_reason DEFAULT 'initiator'::public.build_reason NOT NULL
 );
 
+CREATE TABLE workspace_connections (
+    id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    agent_id uuid NOT NULL,
+    user_id uuid,
+    type workspace_connection_type NOT NULL,
+    remote_addr text DEFAULT ''::text NOT NULL,
+    target text DEFAULT ''::text NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone,
+    rx_bytes bigint DEFAULT 0 NOT NULL,
+    tx_bytes bigint DEFAULT 0 NOT NULL
+);
+
+COMMENT ON COLUMN workspace_connections.user_id IS 'The user that made the connection. It is null when the user could not be determined.';
+
+COMMENT ON COLUMN workspace_connections.target IS 'The forwarded address for port forwards, or the app name for workspace apps.';
+
+COMMENT ON COLUMN workspace_connections.ended_at IS 'Null while the connection is open.';
+
+COMMENT ON COLUMN workspace_connections.rx_bytes IS 'Bytes received by the workspace.';
+
+COMMENT ON COLUMN workspace_connections.tx_bytes IS 'Bytes sent by the workspace.';
+
 CREATE TABLE workspace_resource_metadata (
     workspace_resource_id uuid NOT NULL,
     key character varying(1024) NOT NULL,
@@ -367,6 +516,22 @@ CREATE TABLE workspace_resources (
     name character varying(64) NOT NULL
 );
 
+CREATE TABLE workspace_session_recordings (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    workspace_id uuid NOT NULL,
+    agent_id uuid NOT NULL,
+    type workspace_session_recording_type NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone NOT NULL,
+    size bigint NOT NULL,
+    data bytea NOT NULL
+);
+
+COMMENT ON COLUMN workspace_session_recordings.size IS 'Size of the recording in bytes, so recordings can be listed without reading their data.';
+
+COMMENT ON COLUMN workspace_session_recordings.data IS 'The recording in the asciicast v2 format.';
+
 CREATE TABLE workspaces (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -383,6 +548,8 @@ CREATE TABLE workspaces (
 
 ALTER TABLE ONLY licenses ALTER COLUMN id SET DEFAULT nextval('public.licenses_id_seq'::regclass);
 
+ALTER TABLE ONLY workspace_agent_startup_logs ALTER COLUMN id SET DEFAULT nextval('workspace_agent_startup_logs_id_seq'::regclass);
+
 ALTER TABLE ONLY agent_stats
     ADD CONSTRAINT agent_stats_pkey PRIMARY KEY (id);
 
@@ -398,6 +565,15 @@ ALTER TABLE ONLY files
 ALTER TABLE ONLY gitsshkeys
     ADD CONSTRAINT gitsshkeys_pkey PRIMARY KEY (user_id);
 
+ALTER TABLE ONLY group_members
+    ADD CONSTRAINT group_members_user_id_group_id_key UNIQUE (user_id, group_id);
+
+ALTER TABLE ONLY groups
+    ADD CONSTRAINT groups_name_organization_id_key UNIQUE (name, organization_id);
+
+ALTER TABLE ONLY groups
+    ADD CONSTRAINT groups_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY licenses
     ADD CONSTRAINT licenses_jwt_key UNIQUE (jwt);
 
@@ -452,6 +628,12 @@ ALTER TABLE ONLY user_links
 ALTER TABLE ONLY users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_agent_resource_stats
+    ADD CONSTRAINT workspace_agent_resource_stats_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY workspace_agent_startup_logs
+    ADD CONSTRAINT workspace_agent_startup_logs_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspace_agents
     ADD CONSTRAINT workspace_agents_pkey PRIMARY KEY (id);
 
@@ -461,6 +643,9 @@ ALTER TABLE ONLY workspace_apps
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_build_parameters
+    ADD CONSTRAINT workspace_build_parameters_pkey PRIMARY KEY (workspace_build_id, name);
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_key UNIQUE (job_id);
 
@@ -473,15 +658,23 @@ ALTER TABLE ONLY workspace_builds
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_workspace_id_name_key UNIQUE (workspace_id, name);
 
+ALTER TABLE ONLY workspace_connections
+    ADD CONSTRAINT workspace_connections_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspace_resource_metadata
     ADD CONSTRAINT workspace_resource_metadata_pkey PRIMARY KEY (workspace_resource_id, key);
 
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_pkey PRIMARY KEY (id);
 
+CREATE UNIQUE INDEX api_keys_user_id_token_name_idx ON api_keys USING btree (user_id, token_name) WHERE (token_name <> ''::text);
+
 CREATE INDEX idx_agent_stats_created_at ON agent_stats USING btree (created_at);
 
 CREATE INDEX idx_agent_stats_user_id ON agent_stats USING btree (user_id);
@@ -512,6 +705,14 @@ CREATE UNIQUE INDEX templates_organization_id_name_idx ON templates USING btree
 
 CREATE UNIQUE INDEX users_username_lower_idx ON users USING btree (lower(username));
 
+CREATE INDEX workspace_agent_resource_stats_agent_id_created_at_idx ON workspace_agent_resource_stats USING btree (agent_id, created_at);
+
+CREATE INDEX workspace_agent_startup_logs_id_agent_id_idx ON workspace_agent_startup_logs USING btree (agent_id, id);
+
+CREATE INDEX workspace_connections_started_at_idx ON workspace_connections USING btree (started_at DESC);
+
+CREATE INDEX workspace_session_recordings_workspace_id_started_at_idx ON workspace_session_recordings USING btree (workspace_id, started_at);
+
 CREATE UNIQUE INDEX workspaces_owner_id_lower_idx ON workspaces USING btree (owner_id, lower((name)::text)) WHERE (deleted = false);
 
 ALTER TABLE ONLY api_keys
@@ -520,6 +721,15 @@ ALTER TABLE ONLY api_keys
 ALTER TABLE ONLY gitsshkeys
     ADD CONSTRAINT gitsshkeys_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
 
+ALTER TABLE ONLY group_members
+    ADD CONSTRAINT group_members_group_id_fkey FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY group_members
+    ADD CONSTRAINT group_members_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY groups
+    ADD CONSTRAINT groups_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY organization_members
     ADD CONSTRAINT organization_members_organization_id_uuid_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
@@ -553,12 +763,21 @@ ALTER TABLE ONLY templates
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_agent_resource_stats
+    ADD CONSTRAINT workspace_agent_resource_stats_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_agent_startup_logs
+    ADD CONSTRAINT workspace_agent_startup_logs_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_agents
     ADD CONSTRAINT workspace_agents_resource_id_fkey FOREIGN KEY (resource_id) REFERENCES workspace_resources(id) ON DELETE CASCADE;
 
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_build_parameters
+    ADD CONSTRAINT workspace_build_parameters_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
@@ -568,12 +787,27 @@ ALTER TABLE ONLY workspace_builds
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_connections
+    ADD CONSTRAINT workspace_connections_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_connections
+    ADD CONSTRAINT workspace_connections_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_connections
+    ADD CONSTRAINT workspace_connections_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_resource_metadata
     ADD CONSTRAINT workspace_resource_metadata_workspace_resource_id_fkey FOREIGN KEY (workspace_resource_id) REFERENCES workspace_resources(id) ON DELETE CASCADE;
 
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
 
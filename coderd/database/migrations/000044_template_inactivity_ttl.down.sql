@@ -0,0 +1 @@
+ALTER TABLE templates DROP COLUMN inactivity_ttl;
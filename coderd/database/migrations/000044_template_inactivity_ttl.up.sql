@@ -0,0 +1,2 @@
+ALTER TABLE templates ADD COLUMN inactivity_ttl bigint NOT NULL DEFAULT 0;
+COMMENT ON COLUMN templates.inactivity_ttl IS 'Inactivity TTL is the duration a running workspace may go without activity before it is automatically stopped. Zero disables inactivity-based autostop.';
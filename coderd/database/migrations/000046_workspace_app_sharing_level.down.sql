@@ -0,0 +1,2 @@
+ALTER TABLE workspace_apps DROP COLUMN sharing_level;
+DROP TYPE app_sharing_level;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE templates DROP COLUMN user_acl;
+ALTER TABLE templates DROP COLUMN group_acl;
+
+DROP TABLE group_members;
+DROP TABLE groups;
+
+COMMIT;
@@ -0,0 +1,31 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS groups (
+	id uuid NOT NULL,
+	name text NOT NULL,
+	organization_id uuid NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	PRIMARY KEY(id),
+	UNIQUE(name, organization_id)
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+	user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	group_id uuid NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+	UNIQUE(user_id, group_id)
+);
+
+-- Templates are no longer readable by every organization member. Access is
+-- instead granted through per-user and per-group access control lists.
+ALTER TABLE templates ADD COLUMN user_acl jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE templates ADD COLUMN group_acl jsonb NOT NULL DEFAULT '{}';
+
+-- Every organization has an "Everyone" group that shares its ID. Membership
+-- of this group is implied by organization membership.
+INSERT INTO groups (id, name, organization_id)
+SELECT id, 'Everyone', id FROM organizations;
+
+-- Preserve existing behavior by granting everyone in the organization
+-- access to existing templates.
+UPDATE templates SET group_acl = jsonb_build_object(organization_id, '["read"]'::jsonb);
+
+COMMIT;
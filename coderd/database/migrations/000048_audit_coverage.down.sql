@@ -0,0 +1,10 @@
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
+
+-- Delete all audit logs that use the new enum values.
+DELETE FROM
+    audit_logs
+WHERE
+    action IN ('start', 'stop', 'login')
+    OR resource_type IN ('git_ssh_key', 'api_key', 'workspace_build')
+;
@@ -0,0 +1,7 @@
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'start';
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'stop';
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'login';
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'git_ssh_key';
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'api_key';
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'workspace_build';
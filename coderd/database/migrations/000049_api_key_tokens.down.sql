@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS api_keys_user_id_token_name_idx;
+
+ALTER TABLE api_keys
+    DROP COLUMN IF EXISTS token_name,
+    DROP COLUMN IF EXISTS scope;
+
+DROP TYPE IF EXISTS api_key_scope;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
+
+-- Delete all API keys that use the new enum value.
+DELETE FROM
+    api_keys
+WHERE
+    login_type = 'token'
+;
@@ -0,0 +1,13 @@
+ALTER TYPE login_type ADD VALUE IF NOT EXISTS 'token';
+
+CREATE TYPE api_key_scope AS ENUM (
+    'all',
+    'application_connect'
+);
+
+ALTER TABLE api_keys
+    ADD COLUMN scope api_key_scope NOT NULL DEFAULT 'all',
+    ADD COLUMN token_name text NOT NULL DEFAULT '';
+
+-- Token names are optional, but must be unique for a user when set.
+CREATE UNIQUE INDEX api_keys_user_id_token_name_idx ON api_keys USING btree (user_id, token_name) WHERE (token_name != '');
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS workspace_agent_startup_logs (
+    agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL,
+    output varchar(1024) NOT NULL,
+    -- The ID is used to paginate and follow logs in the order they were
+    -- inserted.
+    id BIGSERIAL PRIMARY KEY
+);
+
+CREATE INDEX workspace_agent_startup_logs_id_agent_id_idx ON workspace_agent_startup_logs USING btree (agent_id, id ASC);
@@ -0,0 +1,3 @@
+ALTER TABLE templates DROP COLUMN wait_for_agent_ready;
+ALTER TABLE workspace_agents DROP COLUMN lifecycle_state;
+DROP TYPE workspace_agent_lifecycle_state;
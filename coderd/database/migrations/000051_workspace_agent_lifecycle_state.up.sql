@@ -0,0 +1,7 @@
+CREATE TYPE workspace_agent_lifecycle_state AS ENUM ('created', 'starting', 'start_timeout', 'start_error', 'ready', 'shutting_down', 'off');
+
+ALTER TABLE workspace_agents ADD COLUMN lifecycle_state workspace_agent_lifecycle_state NOT NULL DEFAULT 'created';
+COMMENT ON COLUMN workspace_agents.lifecycle_state IS 'The current lifecycle state reported by the workspace agent.';
+
+ALTER TABLE templates ADD COLUMN wait_for_agent_ready boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN templates.wait_for_agent_ready IS 'Clients wait for the workspace agent to be ready, meaning the startup script has finished, before connecting.';
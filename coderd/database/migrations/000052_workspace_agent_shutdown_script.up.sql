@@ -0,0 +1,2 @@
+ALTER TABLE workspace_agents ADD COLUMN shutdown_script varchar(65534);
+COMMENT ON COLUMN workspace_agents.shutdown_script IS 'Script run by the agent before the workspace is stopped or deleted.';
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS workspace_agent_resource_stats (
+    id uuid NOT NULL PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+    workspace_id uuid NOT NULL,
+    template_id uuid NOT NULL,
+    cpu_used_cores double precision NOT NULL,
+    cpu_total_cores double precision NOT NULL,
+    memory_used_bytes bigint NOT NULL,
+    memory_total_bytes bigint NOT NULL,
+    disk_used_bytes bigint NOT NULL,
+    disk_total_bytes bigint NOT NULL
+);
+
+COMMENT ON COLUMN workspace_agent_resource_stats.cpu_used_cores IS 'Average number of CPU cores used since the previous sample.';
+COMMENT ON COLUMN workspace_agent_resource_stats.cpu_total_cores IS 'CPU limit of the workspace, or the number of cores of the host if it is not limited.';
+
+CREATE INDEX workspace_agent_resource_stats_agent_id_created_at_idx ON workspace_agent_resource_stats USING btree (agent_id, created_at);
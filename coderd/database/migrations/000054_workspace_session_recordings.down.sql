@@ -0,0 +1,3 @@
+DROP TABLE workspace_session_recordings;
+DROP TYPE workspace_session_recording_type;
+ALTER TABLE templates DROP COLUMN session_recording;
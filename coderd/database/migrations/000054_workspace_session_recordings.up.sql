@@ -0,0 +1,21 @@
+ALTER TABLE templates ADD COLUMN session_recording boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN templates.session_recording IS 'Workspace agents record interactive terminal sessions and upload the recordings to coderd.';
+
+CREATE TYPE workspace_session_recording_type AS ENUM ('ssh', 'reconnecting_pty');
+
+CREATE TABLE IF NOT EXISTS workspace_session_recordings (
+    id uuid NOT NULL PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+    type workspace_session_recording_type NOT NULL,
+    started_at timestamptz NOT NULL,
+    ended_at timestamptz NOT NULL,
+    size bigint NOT NULL,
+    data bytea NOT NULL
+);
+
+COMMENT ON COLUMN workspace_session_recordings.size IS 'Size of the recording in bytes, so recordings can be listed without reading their data.';
+COMMENT ON COLUMN workspace_session_recordings.data IS 'The recording in the asciicast v2 format.';
+
+CREATE INDEX workspace_session_recordings_workspace_id_started_at_idx ON workspace_session_recordings USING btree (workspace_id, started_at);
@@ -0,0 +1,2 @@
+DROP TABLE workspace_connections;
+DROP TYPE workspace_connection_type;
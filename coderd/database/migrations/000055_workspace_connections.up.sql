@@ -0,0 +1,23 @@
+CREATE TYPE workspace_connection_type AS ENUM ('ssh', 'reconnecting_pty', 'port_forward', 'workspace_app');
+
+CREATE TABLE IF NOT EXISTS workspace_connections (
+    id uuid NOT NULL PRIMARY KEY,
+    workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+    user_id uuid REFERENCES users (id) ON DELETE CASCADE,
+    type workspace_connection_type NOT NULL,
+    remote_addr text NOT NULL DEFAULT '',
+    target text NOT NULL DEFAULT '',
+    started_at timestamptz NOT NULL,
+    ended_at timestamptz,
+    rx_bytes bigint NOT NULL DEFAULT 0,
+    tx_bytes bigint NOT NULL DEFAULT 0
+);
+
+COMMENT ON COLUMN workspace_connections.user_id IS 'The user that made the connection. It is null when the user could not be determined.';
+COMMENT ON COLUMN workspace_connections.target IS 'The forwarded address for port forwards, or the app name for workspace apps.';
+COMMENT ON COLUMN workspace_connections.ended_at IS 'Null while the connection is open.';
+COMMENT ON COLUMN workspace_connections.rx_bytes IS 'Bytes received by the workspace.';
+COMMENT ON COLUMN workspace_connections.tx_bytes IS 'Bytes sent by the workspace.';
+
+CREATE INDEX workspace_connections_started_at_idx ON workspace_connections USING btree (started_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE templates ADD COLUMN max_job_duration bigint NOT NULL DEFAULT 0;
+COMMENT ON COLUMN templates.max_job_duration IS 'Max job duration is the longest a provisioner job for the template may run before it is failed. Zero disables the limit.';
@@ -0,0 +1,4 @@
+ALTER TYPE provisioner_storage_method ADD VALUE IF NOT EXISTS 'git';
+
+ALTER TABLE template_versions ADD COLUMN git_commit_sha text NOT NULL DEFAULT '';
+COMMENT ON COLUMN template_versions.git_commit_sha IS 'The commit the template source was cloned at, for versions imported from a git repository.';
@@ -0,0 +1,2 @@
+DROP TABLE workspace_build_parameters;
+ALTER TABLE parameter_schemas DROP COLUMN mutable;
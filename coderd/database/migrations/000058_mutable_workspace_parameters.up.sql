@@ -0,0 +1,10 @@
+ALTER TABLE parameter_schemas ADD COLUMN mutable boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN parameter_schemas.mutable IS 'Mutable parameters can be changed by start builds after the workspace was created.';
+
+CREATE TABLE workspace_build_parameters (
+	workspace_build_id uuid NOT NULL REFERENCES workspace_builds (id) ON DELETE CASCADE,
+	name character varying(64) NOT NULL,
+	value text NOT NULL,
+	PRIMARY KEY (workspace_build_id, name)
+);
+COMMENT ON TABLE workspace_build_parameters IS 'The parameter values used by each workspace build. Values of parameters that are not redisplayed are not recorded.';
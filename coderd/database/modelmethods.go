@@ -4,13 +4,28 @@ import (
 	"github.com/coder/coder/coderd/rbac"
 )
 
+// AllUsersGroup is the name of the group every organization member is
+// implicitly a member of. The group shares its ID with the organization.
+const AllUsersGroup = "Everyone"
+
 func (t Template) RBACObject() rbac.Object {
-	return rbac.ResourceTemplate.InOrg(t.OrganizationID)
+	return rbac.ResourceTemplate.InOrg(t.OrganizationID).
+		WithACLUserList(t.UserACL).
+		WithGroupACL(t.GroupACL)
+}
+
+// RBACObject uses the parent template resource for controlling versions,
+// so the template's access control lists apply. Versions that are not yet
+// attached to a template are only accessible to template administrators.
+func (t TemplateVersion) RBACObject(template Template) rbac.Object {
+	if !t.TemplateID.Valid {
+		return rbac.ResourceTemplate.InOrg(t.OrganizationID)
+	}
+	return template.RBACObject()
 }
 
-func (t TemplateVersion) RBACObject() rbac.Object {
-	// Just use the parent template resource for controlling versions
-	return rbac.ResourceTemplate.InOrg(t.OrganizationID)
+func (g Group) RBACObject() rbac.Object {
+	return rbac.ResourceGroup.InOrg(g.OrganizationID)
 }
 
 func (w Workspace) RBACObject() rbac.Object {
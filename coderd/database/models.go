@@ -14,12 +14,54 @@ import (
 	"github.com/tabbed/pqtype"
 )
 
+type APIKeyScope string
+
+const (
+	APIKeyScopeAll                APIKeyScope = "all"
+	APIKeyScopeApplicationConnect APIKeyScope = "application_connect"
+)
+
+func (e *APIKeyScope) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = APIKeyScope(s)
+	case string:
+		*e = APIKeyScope(s)
+	default:
+		return fmt.Errorf("unsupported scan type for APIKeyScope: %T", src)
+	}
+	return nil
+}
+
+type AppSharingLevel string
+
+const (
+	AppSharingLevelOwner         AppSharingLevel = "owner"
+	AppSharingLevelAuthenticated AppSharingLevel = "authenticated"
+	AppSharingLevelPublic        AppSharingLevel = "public"
+)
+
+func (e *AppSharingLevel) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AppSharingLevel(s)
+	case string:
+		*e = AppSharingLevel(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AppSharingLevel: %T", src)
+	}
+	return nil
+}
+
 type AuditAction string
 
 const (
 	AuditActionCreate AuditAction = "create"
 	AuditActionWrite  AuditAction = "write"
 	AuditActionDelete AuditAction = "delete"
+	AuditActionStart  AuditAction = "start"
+	AuditActionStop   AuditAction = "stop"
+	AuditActionLogin  AuditAction = "login"
 )
 
 func (e *AuditAction) Scan(src interface{}) error {
@@ -101,6 +143,7 @@ const (
 	LoginTypePassword LoginType = "password"
 	LoginTypeGithub   LoginType = "github"
 	LoginTypeOIDC     LoginType = "oidc"
+	LoginTypeToken    LoginType = "token"
 )
 
 func (e *LoginType) Scan(src interface{}) error {
@@ -217,6 +260,7 @@ type ProvisionerStorageMethod string
 
 const (
 	ProvisionerStorageMethodFile ProvisionerStorageMethod = "file"
+	ProvisionerStorageMethodGit  ProvisionerStorageMethod = "git"
 )
 
 func (e *ProvisionerStorageMethod) Scan(src interface{}) error {
@@ -258,6 +302,9 @@ const (
 	ResourceTypeTemplateVersion ResourceType = "template_version"
 	ResourceTypeUser            ResourceType = "user"
 	ResourceTypeWorkspace       ResourceType = "workspace"
+	ResourceTypeGitSSHKey       ResourceType = "git_ssh_key"
+	ResourceTypeAPIKey          ResourceType = "api_key"
+	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
 )
 
 func (e *ResourceType) Scan(src interface{}) error {
@@ -291,6 +338,70 @@ func (e *UserStatus) Scan(src interface{}) error {
 	return nil
 }
 
+type WorkspaceAgentLifecycleState string
+
+const (
+	WorkspaceAgentLifecycleStateCreated      WorkspaceAgentLifecycleState = "created"
+	WorkspaceAgentLifecycleStateStarting     WorkspaceAgentLifecycleState = "starting"
+	WorkspaceAgentLifecycleStateStartTimeout WorkspaceAgentLifecycleState = "start_timeout"
+	WorkspaceAgentLifecycleStateStartError   WorkspaceAgentLifecycleState = "start_error"
+	WorkspaceAgentLifecycleStateReady        WorkspaceAgentLifecycleState = "ready"
+	WorkspaceAgentLifecycleStateShuttingDown WorkspaceAgentLifecycleState = "shutting_down"
+	WorkspaceAgentLifecycleStateOff          WorkspaceAgentLifecycleState = "off"
+)
+
+func (e *WorkspaceAgentLifecycleState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceAgentLifecycleState(s)
+	case string:
+		*e = WorkspaceAgentLifecycleState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceAgentLifecycleState: %T", src)
+	}
+	return nil
+}
+
+type WorkspaceConnectionType string
+
+const (
+	WorkspaceConnectionTypeSsh             WorkspaceConnectionType = "ssh"
+	WorkspaceConnectionTypeReconnectingPty WorkspaceConnectionType = "reconnecting_pty"
+	WorkspaceConnectionTypePortForward     WorkspaceConnectionType = "port_forward"
+	WorkspaceConnectionTypeWorkspaceApp    WorkspaceConnectionType = "workspace_app"
+)
+
+func (e *WorkspaceConnectionType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceConnectionType(s)
+	case string:
+		*e = WorkspaceConnectionType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceConnectionType: %T", src)
+	}
+	return nil
+}
+
+type WorkspaceSessionRecordingType string
+
+const (
+	WorkspaceSessionRecordingTypeSsh             WorkspaceSessionRecordingType = "ssh"
+	WorkspaceSessionRecordingTypeReconnectingPty WorkspaceSessionRecordingType = "reconnecting_pty"
+)
+
+func (e *WorkspaceSessionRecordingType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceSessionRecordingType(s)
+	case string:
+		*e = WorkspaceSessionRecordingType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceSessionRecordingType: %T", src)
+	}
+	return nil
+}
+
 type WorkspaceTransition string
 
 const (
@@ -322,6 +433,8 @@ type APIKey struct {
 	LoginType       LoginType   `db:"login_type" json:"login_type"`
 	LifetimeSeconds int64       `db:"lifetime_seconds" json:"lifetime_seconds"`
 	IPAddress       pqtype.Inet `db:"ip_address" json:"ip_address"`
+	Scope           APIKeyScope `db:"scope" json:"scope"`
+	TokenName       string      `db:"token_name" json:"token_name"`
 }
 
 type AgentStat struct {
@@ -368,6 +481,17 @@ type GitSSHKey struct {
 	PublicKey  string    `db:"public_key" json:"public_key"`
 }
 
+type Group struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	Name           string    `db:"name" json:"name"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+}
+
+type GroupMember struct {
+	UserID  uuid.UUID `db:"user_id" json:"user_id"`
+	GroupID uuid.UUID `db:"group_id" json:"group_id"`
+}
+
 type License struct {
 	ID         int32     `db:"id" json:"id"`
 	UploadedAt time.Time `db:"uploaded_at" json:"uploaded_at"`
@@ -410,6 +534,7 @@ type ParameterSchema struct {
 	ValidationTypeSystem     ParameterTypeSystem        `db:"validation_type_system" json:"validation_type_system"`
 	ValidationValueType      string                     `db:"validation_value_type" json:"validation_value_type"`
 	Index                    int32                      `db:"index" json:"index"`
+	Mutable                  bool                       `db:"mutable" json:"mutable"`
 }
 
 type ParameterValue struct {
@@ -430,6 +555,7 @@ type ProvisionerDaemon struct {
 	UpdatedAt    sql.NullTime      `db:"updated_at" json:"updated_at"`
 	Name         string            `db:"name" json:"name"`
 	Provisioners []ProvisionerType `db:"provisioners" json:"provisioners"`
+	Tags         StringMap         `db:"tags" json:"tags"`
 }
 
 type ProvisionerJob struct {
@@ -448,6 +574,7 @@ type ProvisionerJob struct {
 	Type           ProvisionerJobType       `db:"type" json:"type"`
 	Input          json.RawMessage          `db:"input" json:"input"`
 	WorkerID       uuid.NullUUID            `db:"worker_id" json:"worker_id"`
+	Tags           StringMap                `db:"tags" json:"tags"`
 }
 
 type ProvisionerJobLog struct {
@@ -479,6 +606,15 @@ type Template struct {
 	MinAutostartInterval int64           `db:"min_autostart_interval" json:"min_autostart_interval"`
 	CreatedBy            uuid.UUID       `db:"created_by" json:"created_by"`
 	Icon                 string          `db:"icon" json:"icon"`
+	InactivityTtl        int64           `db:"inactivity_ttl" json:"inactivity_ttl"`
+	UserACL              TemplateACL     `db:"user_acl" json:"user_acl"`
+	GroupACL             TemplateACL     `db:"group_acl" json:"group_acl"`
+	// Clients wait for the workspace agent to be ready, meaning the startup script has finished, before connecting.
+	WaitForAgentReady bool `db:"wait_for_agent_ready" json:"wait_for_agent_ready"`
+	// Workspace agents record interactive terminal sessions and upload the recordings to coderd.
+	SessionRecording bool `db:"session_recording" json:"session_recording"`
+	// Max job duration is the longest a provisioner job for the template may run before it is failed. Zero disables the limit.
+	MaxJobDuration int64 `db:"max_job_duration" json:"max_job_duration"`
 }
 
 type TemplateVersion struct {
@@ -491,6 +627,8 @@ type TemplateVersion struct {
 	Readme         string        `db:"readme" json:"readme"`
 	JobID          uuid.UUID     `db:"job_id" json:"job_id"`
 	CreatedBy      uuid.NullUUID `db:"created_by" json:"created_by"`
+	// The commit the template source was cloned at, for versions imported from a git repository.
+	GitCommitSHA string `db:"git_commit_sha" json:"git_commit_sha"`
 }
 
 type User struct {
@@ -548,17 +686,45 @@ type WorkspaceAgent struct {
 	Directory            string                `db:"directory" json:"directory"`
 	// Version tracks the version of the currently running workspace agent. Workspace agents register their version upon start.
 	Version string `db:"version" json:"version"`
+	// The current lifecycle state reported by the workspace agent.
+	LifecycleState WorkspaceAgentLifecycleState `db:"lifecycle_state" json:"lifecycle_state"`
+	// Script run by the agent before the workspace is stopped or deleted.
+	ShutdownScript sql.NullString `db:"shutdown_script" json:"shutdown_script"`
+}
+
+type WorkspaceAgentResourceStat struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	AgentID     uuid.UUID `db:"agent_id" json:"agent_id"`
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	TemplateID  uuid.UUID `db:"template_id" json:"template_id"`
+	// Average number of CPU cores used since the previous sample.
+	CpuUsedCores float64 `db:"cpu_used_cores" json:"cpu_used_cores"`
+	// CPU limit of the workspace, or the number of cores of the host if it is not limited.
+	CpuTotalCores    float64 `db:"cpu_total_cores" json:"cpu_total_cores"`
+	MemoryUsedBytes  int64   `db:"memory_used_bytes" json:"memory_used_bytes"`
+	MemoryTotalBytes int64   `db:"memory_total_bytes" json:"memory_total_bytes"`
+	DiskUsedBytes    int64   `db:"disk_used_bytes" json:"disk_used_bytes"`
+	DiskTotalBytes   int64   `db:"disk_total_bytes" json:"disk_total_bytes"`
+}
+
+type WorkspaceAgentStartupLog struct {
+	AgentID   uuid.UUID `db:"agent_id" json:"agent_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	Output    string    `db:"output" json:"output"`
+	ID        int64     `db:"id" json:"id"`
 }
 
 type WorkspaceApp struct {
-	ID           uuid.UUID      `db:"id" json:"id"`
-	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
-	AgentID      uuid.UUID      `db:"agent_id" json:"agent_id"`
-	Name         string         `db:"name" json:"name"`
-	Icon         string         `db:"icon" json:"icon"`
-	Command      sql.NullString `db:"command" json:"command"`
-	Url          sql.NullString `db:"url" json:"url"`
-	RelativePath bool           `db:"relative_path" json:"relative_path"`
+	ID           uuid.UUID       `db:"id" json:"id"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+	AgentID      uuid.UUID       `db:"agent_id" json:"agent_id"`
+	Name         string          `db:"name" json:"name"`
+	Icon         string          `db:"icon" json:"icon"`
+	Command      sql.NullString  `db:"command" json:"command"`
+	Url          sql.NullString  `db:"url" json:"url"`
+	RelativePath bool            `db:"relative_path" json:"relative_path"`
+	SharingLevel AppSharingLevel `db:"sharing_level" json:"sharing_level"`
 }
 
 type WorkspaceBuild struct {
@@ -577,6 +743,31 @@ type WorkspaceBuild struct {
 	Reason            BuildReason         `db:"reason" json:"reason"`
 }
 
+type WorkspaceBuildParameter struct {
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	Name             string    `db:"name" json:"name"`
+	Value            string    `db:"value" json:"value"`
+}
+
+type WorkspaceConnection struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID `db:"agent_id" json:"agent_id"`
+	// The user that made the connection. It is null when the user could not be determined.
+	UserID     uuid.NullUUID           `db:"user_id" json:"user_id"`
+	Type       WorkspaceConnectionType `db:"type" json:"type"`
+	RemoteAddr string                  `db:"remote_addr" json:"remote_addr"`
+	// The forwarded address for port forwards, or the app name for workspace apps.
+	Target    string    `db:"target" json:"target"`
+	StartedAt time.Time `db:"started_at" json:"started_at"`
+	// Null while the connection is open.
+	EndedAt sql.NullTime `db:"ended_at" json:"ended_at"`
+	// Bytes received by the workspace.
+	RxBytes int64 `db:"rx_bytes" json:"rx_bytes"`
+	// Bytes sent by the workspace.
+	TxBytes int64 `db:"tx_bytes" json:"tx_bytes"`
+}
+
 type WorkspaceResource struct {
 	ID         uuid.UUID           `db:"id" json:"id"`
 	CreatedAt  time.Time           `db:"created_at" json:"created_at"`
@@ -592,3 +783,17 @@ type WorkspaceResourceMetadatum struct {
 	Value               sql.NullString `db:"value" json:"value"`
 	Sensitive           bool           `db:"sensitive" json:"sensitive"`
 }
+
+type WorkspaceSessionRecording struct {
+	ID          uuid.UUID                     `db:"id" json:"id"`
+	CreatedAt   time.Time                     `db:"created_at" json:"created_at"`
+	WorkspaceID uuid.UUID                     `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID                     `db:"agent_id" json:"agent_id"`
+	Type        WorkspaceSessionRecordingType `db:"type" json:"type"`
+	StartedAt   time.Time                     `db:"started_at" json:"started_at"`
+	EndedAt     time.Time                     `db:"ended_at" json:"ended_at"`
+	// Size of the recording in bytes, so recordings can be listed without reading their data.
+	Size int64 `db:"size" json:"size"`
+	// The recording in the asciicast v2 format.
+	Data []byte `db:"data" json:"data"`
+}
@@ -24,6 +24,99 @@ func (q *sqlQuerier) DeleteOldAgentStats(ctx context.Context) error {
 	return err
 }
 
+const deleteOldWorkspaceAgentResourceStats = `-- name: DeleteOldWorkspaceAgentResourceStats :exec
+DELETE FROM workspace_agent_resource_stats WHERE created_at < now() - interval '1 day'
+`
+
+func (q *sqlQuerier) DeleteOldWorkspaceAgentResourceStats(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteOldWorkspaceAgentResourceStats)
+	return err
+}
+
+const getLatestWorkspaceAgentResourceStats = `-- name: GetLatestWorkspaceAgentResourceStats :many
+SELECT
+	DISTINCT ON (workspace_agent_resource_stats.agent_id)
+	workspace_agent_resource_stats.id, workspace_agent_resource_stats.created_at, workspace_agent_resource_stats.agent_id, workspace_agent_resource_stats.workspace_id, workspace_agent_resource_stats.template_id, workspace_agent_resource_stats.cpu_used_cores, workspace_agent_resource_stats.cpu_total_cores, workspace_agent_resource_stats.memory_used_bytes, workspace_agent_resource_stats.memory_total_bytes, workspace_agent_resource_stats.disk_used_bytes, workspace_agent_resource_stats.disk_total_bytes,
+	workspace_agents.name AS agent_name,
+	workspaces.name AS workspace_name,
+	users.username AS username,
+	templates.name AS template_name
+FROM
+	workspace_agent_resource_stats
+JOIN
+	workspace_agents ON workspace_agents.id = workspace_agent_resource_stats.agent_id
+JOIN
+	workspaces ON workspaces.id = workspace_agent_resource_stats.workspace_id
+JOIN
+	users ON users.id = workspaces.owner_id
+JOIN
+	templates ON templates.id = workspace_agent_resource_stats.template_id
+WHERE
+	workspaces.deleted = false
+	AND workspace_agent_resource_stats.created_at > $1
+ORDER BY
+	workspace_agent_resource_stats.agent_id, workspace_agent_resource_stats.created_at DESC
+`
+
+type GetLatestWorkspaceAgentResourceStatsRow struct {
+	ID               uuid.UUID `db:"id" json:"id"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	AgentID          uuid.UUID `db:"agent_id" json:"agent_id"`
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	TemplateID       uuid.UUID `db:"template_id" json:"template_id"`
+	CpuUsedCores     float64   `db:"cpu_used_cores" json:"cpu_used_cores"`
+	CpuTotalCores    float64   `db:"cpu_total_cores" json:"cpu_total_cores"`
+	MemoryUsedBytes  int64     `db:"memory_used_bytes" json:"memory_used_bytes"`
+	MemoryTotalBytes int64     `db:"memory_total_bytes" json:"memory_total_bytes"`
+	DiskUsedBytes    int64     `db:"disk_used_bytes" json:"disk_used_bytes"`
+	DiskTotalBytes   int64     `db:"disk_total_bytes" json:"disk_total_bytes"`
+	AgentName        string    `db:"agent_name" json:"agent_name"`
+	WorkspaceName    string    `db:"workspace_name" json:"workspace_name"`
+	Username         string    `db:"username" json:"username"`
+	TemplateName     string    `db:"template_name" json:"template_name"`
+}
+
+// Returns the latest sample of each agent of a workspace that isn't deleted,
+// with the names that label its metrics.
+func (q *sqlQuerier) GetLatestWorkspaceAgentResourceStats(ctx context.Context, createdAfter time.Time) ([]GetLatestWorkspaceAgentResourceStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLatestWorkspaceAgentResourceStats, createdAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLatestWorkspaceAgentResourceStatsRow
+	for rows.Next() {
+		var i GetLatestWorkspaceAgentResourceStatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.AgentID,
+			&i.WorkspaceID,
+			&i.TemplateID,
+			&i.CpuUsedCores,
+			&i.CpuTotalCores,
+			&i.MemoryUsedBytes,
+			&i.MemoryTotalBytes,
+			&i.DiskUsedBytes,
+			&i.DiskTotalBytes,
+			&i.AgentName,
+			&i.WorkspaceName,
+			&i.Username,
+			&i.TemplateName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTemplateDAUs = `-- name: GetTemplateDAUs :many
 select
 	(created_at at TIME ZONE 'UTC')::date as date,
@@ -65,6 +158,58 @@ func (q *sqlQuerier) GetTemplateDAUs(ctx context.Context, templateID uuid.UUID)
 	return items, nil
 }
 
+const getWorkspaceAgentResourceStats = `-- name: GetWorkspaceAgentResourceStats :many
+SELECT
+	id, created_at, agent_id, workspace_id, template_id, cpu_used_cores, cpu_total_cores, memory_used_bytes, memory_total_bytes, disk_used_bytes, disk_total_bytes
+FROM
+	workspace_agent_resource_stats
+WHERE
+	agent_id = $1
+	AND created_at > $2
+ORDER BY
+	created_at ASC
+`
+
+type GetWorkspaceAgentResourceStatsParams struct {
+	AgentID      uuid.UUID `db:"agent_id" json:"agent_id"`
+	CreatedAfter time.Time `db:"created_after" json:"created_after"`
+}
+
+func (q *sqlQuerier) GetWorkspaceAgentResourceStats(ctx context.Context, arg GetWorkspaceAgentResourceStatsParams) ([]WorkspaceAgentResourceStat, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentResourceStats, arg.AgentID, arg.CreatedAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentResourceStat
+	for rows.Next() {
+		var i WorkspaceAgentResourceStat
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.AgentID,
+			&i.WorkspaceID,
+			&i.TemplateID,
+			&i.CpuUsedCores,
+			&i.CpuTotalCores,
+			&i.MemoryUsedBytes,
+			&i.MemoryTotalBytes,
+			&i.DiskUsedBytes,
+			&i.DiskTotalBytes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const insertAgentStat = `-- name: InsertAgentStat :one
 INSERT INTO
 	agent_stats (
@@ -113,6 +258,70 @@ func (q *sqlQuerier) InsertAgentStat(ctx context.Context, arg InsertAgentStatPar
 	return i, err
 }
 
+const insertWorkspaceAgentResourceStat = `-- name: InsertWorkspaceAgentResourceStat :one
+INSERT INTO
+	workspace_agent_resource_stats (
+		id,
+		created_at,
+		agent_id,
+		workspace_id,
+		template_id,
+		cpu_used_cores,
+		cpu_total_cores,
+		memory_used_bytes,
+		memory_total_bytes,
+		disk_used_bytes,
+		disk_total_bytes
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at, agent_id, workspace_id, template_id, cpu_used_cores, cpu_total_cores, memory_used_bytes, memory_total_bytes, disk_used_bytes, disk_total_bytes
+`
+
+type InsertWorkspaceAgentResourceStatParams struct {
+	ID               uuid.UUID `db:"id" json:"id"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	AgentID          uuid.UUID `db:"agent_id" json:"agent_id"`
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	TemplateID       uuid.UUID `db:"template_id" json:"template_id"`
+	CpuUsedCores     float64   `db:"cpu_used_cores" json:"cpu_used_cores"`
+	CpuTotalCores    float64   `db:"cpu_total_cores" json:"cpu_total_cores"`
+	MemoryUsedBytes  int64     `db:"memory_used_bytes" json:"memory_used_bytes"`
+	MemoryTotalBytes int64     `db:"memory_total_bytes" json:"memory_total_bytes"`
+	DiskUsedBytes    int64     `db:"disk_used_bytes" json:"disk_used_bytes"`
+	DiskTotalBytes   int64     `db:"disk_total_bytes" json:"disk_total_bytes"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentResourceStat(ctx context.Context, arg InsertWorkspaceAgentResourceStatParams) (WorkspaceAgentResourceStat, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceAgentResourceStat,
+		arg.ID,
+		arg.CreatedAt,
+		arg.AgentID,
+		arg.WorkspaceID,
+		arg.TemplateID,
+		arg.CpuUsedCores,
+		arg.CpuTotalCores,
+		arg.MemoryUsedBytes,
+		arg.MemoryTotalBytes,
+		arg.DiskUsedBytes,
+		arg.DiskTotalBytes,
+	)
+	var i WorkspaceAgentResourceStat
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.AgentID,
+		&i.WorkspaceID,
+		&i.TemplateID,
+		&i.CpuUsedCores,
+		&i.CpuTotalCores,
+		&i.MemoryUsedBytes,
+		&i.MemoryTotalBytes,
+		&i.DiskUsedBytes,
+		&i.DiskTotalBytes,
+	)
+	return i, err
+}
+
 const deleteAPIKeyByID = `-- name: DeleteAPIKeyByID :exec
 DELETE
 FROM
@@ -128,7 +337,7 @@ func (q *sqlQuerier) DeleteAPIKeyByID(ctx context.Context, id string) error {
 
 const getAPIKeyByID = `-- name: GetAPIKeyByID :one
 SELECT
-	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address
+	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name
 FROM
 	api_keys
 WHERE
@@ -151,12 +360,59 @@ func (q *sqlQuerier) GetAPIKeyByID(ctx context.Context, id string) (APIKey, erro
 		&i.LoginType,
 		&i.LifetimeSeconds,
 		&i.IPAddress,
+		&i.Scope,
+		&i.TokenName,
 	)
 	return i, err
 }
 
+const getAPIKeysByUserID = `-- name: GetAPIKeysByUserID :many
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name FROM api_keys WHERE login_type = $1 AND user_id = $2 ORDER BY created_at ASC
+`
+
+type GetAPIKeysByUserIDParams struct {
+	LoginType LoginType `db:"login_type" json:"login_type"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) GetAPIKeysByUserID(ctx context.Context, arg GetAPIKeysByUserIDParams) ([]APIKey, error) {
+	rows, err := q.db.QueryContext(ctx, getAPIKeysByUserID, arg.LoginType, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedSecret,
+			&i.UserID,
+			&i.LastUsed,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LoginType,
+			&i.LifetimeSeconds,
+			&i.IPAddress,
+			&i.Scope,
+			&i.TokenName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAPIKeysLastUsedAfter = `-- name: GetAPIKeysLastUsedAfter :many
-SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address FROM api_keys WHERE last_used > $1
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name FROM api_keys WHERE last_used > $1
 `
 
 func (q *sqlQuerier) GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.Time) ([]APIKey, error) {
@@ -179,6 +435,8 @@ func (q *sqlQuerier) GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.
 			&i.LoginType,
 			&i.LifetimeSeconds,
 			&i.IPAddress,
+			&i.Scope,
+			&i.TokenName,
 		); err != nil {
 			return nil, err
 		}
@@ -205,7 +463,9 @@ INSERT INTO
 		expires_at,
 		created_at,
 		updated_at,
-		login_type
+		login_type,
+		scope,
+		token_name
 	)
 VALUES
 	($1,
@@ -214,7 +474,7 @@ VALUES
 	     WHEN 0 THEN 86400
 		 ELSE $2::bigint
 	 END
-	 , $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address
+	 , $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name
 `
 
 type InsertAPIKeyParams struct {
@@ -228,6 +488,8 @@ type InsertAPIKeyParams struct {
 	CreatedAt       time.Time   `db:"created_at" json:"created_at"`
 	UpdatedAt       time.Time   `db:"updated_at" json:"updated_at"`
 	LoginType       LoginType   `db:"login_type" json:"login_type"`
+	Scope           APIKeyScope `db:"scope" json:"scope"`
+	TokenName       string      `db:"token_name" json:"token_name"`
 }
 
 func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (APIKey, error) {
@@ -242,6 +504,8 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.LoginType,
+		arg.Scope,
+		arg.TokenName,
 	)
 	var i APIKey
 	err := row.Scan(
@@ -255,6 +519,8 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 		&i.LoginType,
 		&i.LifetimeSeconds,
 		&i.IPAddress,
+		&i.Scope,
+		&i.TokenName,
 	)
 	return i, err
 }
@@ -287,6 +553,73 @@ func (q *sqlQuerier) UpdateAPIKeyByID(ctx context.Context, arg UpdateAPIKeyByIDP
 	return err
 }
 
+const getAuditLogCount = `-- name: GetAuditLogCount :one
+SELECT
+	COUNT(*)
+FROM
+	audit_logs
+WHERE
+	-- Filter action
+	CASE
+		WHEN $1 :: text != '' THEN
+			action = $1 :: audit_action
+		ELSE true
+	END
+	-- Filter resource_type
+	AND CASE
+		WHEN $2 :: text != '' THEN
+			resource_type = $2 :: resource_type
+		ELSE true
+	END
+	-- Filter by username
+	AND CASE
+		WHEN $3 :: text != '' THEN
+			user_id = (SELECT id FROM users WHERE LOWER(username) = LOWER($3))
+		ELSE true
+	END
+	-- Filter by time range
+	AND CASE
+		WHEN $4 :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			"time" >= $4
+		ELSE true
+	END
+	AND CASE
+		WHEN $5 :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			"time" < $5
+		ELSE true
+	END
+	-- Filter by status code
+	AND CASE
+		WHEN $6 :: int != 0 THEN
+			status_code = $6
+		ELSE true
+	END
+`
+
+type GetAuditLogCountParams struct {
+	Action       string    `db:"action" json:"action"`
+	ResourceType string    `db:"resource_type" json:"resource_type"`
+	Username     string    `db:"username" json:"username"`
+	DateFrom     time.Time `db:"date_from" json:"date_from"`
+	DateTo       time.Time `db:"date_to" json:"date_to"`
+	StatusCode   int32     `db:"status_code" json:"status_code"`
+}
+
+// GetAuditLogCount returns the number of audit logs matching the filters.
+func (q *sqlQuerier) GetAuditLogCount(ctx context.Context, arg GetAuditLogCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAuditLogCount,
+		arg.Action,
+		arg.ResourceType,
+		arg.Username,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.StatusCode,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getAuditLogsBefore = `-- name: GetAuditLogsBefore :many
 SELECT
 	id, time, user_id, organization_id, ip, user_agent, resource_type, resource_id, resource_target, action, diff, status_code, additional_fields, request_id, resource_icon
@@ -347,6 +680,117 @@ func (q *sqlQuerier) GetAuditLogsBefore(ctx context.Context, arg GetAuditLogsBef
 	return items, nil
 }
 
+const getAuditLogsOffset = `-- name: GetAuditLogsOffset :many
+SELECT
+	id, time, user_id, organization_id, ip, user_agent, resource_type, resource_id, resource_target, action, diff, status_code, additional_fields, request_id, resource_icon
+FROM
+	audit_logs
+WHERE
+	-- Filter action
+	CASE
+		WHEN $1 :: text != '' THEN
+			action = $1 :: audit_action
+		ELSE true
+	END
+	-- Filter resource_type
+	AND CASE
+		WHEN $2 :: text != '' THEN
+			resource_type = $2 :: resource_type
+		ELSE true
+	END
+	-- Filter by username
+	AND CASE
+		WHEN $3 :: text != '' THEN
+			user_id = (SELECT id FROM users WHERE LOWER(username) = LOWER($3))
+		ELSE true
+	END
+	-- Filter by time range
+	AND CASE
+		WHEN $4 :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			"time" >= $4
+		ELSE true
+	END
+	AND CASE
+		WHEN $5 :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			"time" < $5
+		ELSE true
+	END
+	-- Filter by status code
+	AND CASE
+		WHEN $6 :: int != 0 THEN
+			status_code = $6
+		ELSE true
+	END
+ORDER BY
+	"time" DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF($7 :: int, 0)
+OFFSET
+	$8
+`
+
+type GetAuditLogsOffsetParams struct {
+	Action       string    `db:"action" json:"action"`
+	ResourceType string    `db:"resource_type" json:"resource_type"`
+	Username     string    `db:"username" json:"username"`
+	DateFrom     time.Time `db:"date_from" json:"date_from"`
+	DateTo       time.Time `db:"date_to" json:"date_to"`
+	StatusCode   int32     `db:"status_code" json:"status_code"`
+	LimitOpt     int32     `db:"limit_opt" json:"limit_opt"`
+	OffsetOpt    int32     `db:"offset_opt" json:"offset_opt"`
+}
+
+// GetAuditLogsOffset returns a page of audit logs matching the filters,
+// ordered from newest to oldest.
+func (q *sqlQuerier) GetAuditLogsOffset(ctx context.Context, arg GetAuditLogsOffsetParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditLogsOffset,
+		arg.Action,
+		arg.ResourceType,
+		arg.Username,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.StatusCode,
+		arg.LimitOpt,
+		arg.OffsetOpt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Time,
+			&i.UserID,
+			&i.OrganizationID,
+			&i.Ip,
+			&i.UserAgent,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.ResourceTarget,
+			&i.Action,
+			&i.Diff,
+			&i.StatusCode,
+			&i.AdditionalFields,
+			&i.RequestID,
+			&i.ResourceIcon,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const insertAuditLog = `-- name: InsertAuditLog :one
 INSERT INTO
 	audit_logs (
@@ -587,6 +1031,288 @@ func (q *sqlQuerier) UpdateGitSSHKey(ctx context.Context, arg UpdateGitSSHKeyPar
 	return err
 }
 
+const deleteGroupByID = `-- name: DeleteGroupByID :exec
+DELETE FROM
+	groups
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) DeleteGroupByID(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteGroupByID, id)
+	return err
+}
+
+const deleteGroupMember = `-- name: DeleteGroupMember :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = $1
+AND
+	group_id = $2
+`
+
+type DeleteGroupMemberParams struct {
+	UserID  uuid.UUID `db:"user_id" json:"user_id"`
+	GroupID uuid.UUID `db:"group_id" json:"group_id"`
+}
+
+func (q *sqlQuerier) DeleteGroupMember(ctx context.Context, arg DeleteGroupMemberParams) error {
+	_, err := q.db.ExecContext(ctx, deleteGroupMember, arg.UserID, arg.GroupID)
+	return err
+}
+
+const getGroupByID = `-- name: GetGroupByID :one
+SELECT
+	id, name, organization_id
+FROM
+	groups
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetGroupByID(ctx context.Context, id uuid.UUID) (Group, error) {
+	row := q.db.QueryRowContext(ctx, getGroupByID, id)
+	var i Group
+	err := row.Scan(&i.ID, &i.Name, &i.OrganizationID)
+	return i, err
+}
+
+const getGroupByOrgAndName = `-- name: GetGroupByOrgAndName :one
+SELECT
+	id, name, organization_id
+FROM
+	groups
+WHERE
+	organization_id = $1
+AND
+	name = $2
+LIMIT
+	1
+`
+
+type GetGroupByOrgAndNameParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Name           string    `db:"name" json:"name"`
+}
+
+func (q *sqlQuerier) GetGroupByOrgAndName(ctx context.Context, arg GetGroupByOrgAndNameParams) (Group, error) {
+	row := q.db.QueryRowContext(ctx, getGroupByOrgAndName, arg.OrganizationID, arg.Name)
+	var i Group
+	err := row.Scan(&i.ID, &i.Name, &i.OrganizationID)
+	return i, err
+}
+
+const getGroupMembers = `-- name: GetGroupMembers :many
+SELECT
+	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type
+FROM
+	users
+JOIN
+	group_members
+ON
+	users.id = group_members.user_id
+WHERE
+	group_members.group_id = $1
+`
+
+func (q *sqlQuerier) GetGroupMembers(ctx context.Context, groupID uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getGroupMembers, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Username,
+			&i.HashedPassword,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			pq.Array(&i.RBACRoles),
+			&i.LoginType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroupsByOrganizationID = `-- name: GetGroupsByOrganizationID :many
+SELECT
+	id, name, organization_id
+FROM
+	groups
+WHERE
+	organization_id = $1
+ORDER BY
+	name ASC
+`
+
+func (q *sqlQuerier) GetGroupsByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]Group, error) {
+	rows, err := q.db.QueryContext(ctx, getGroupsByOrganizationID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Group
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(&i.ID, &i.Name, &i.OrganizationID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserGroups = `-- name: GetUserGroups :many
+SELECT
+	groups.id, groups.name, groups.organization_id
+FROM
+	groups
+JOIN
+	group_members
+ON
+	groups.id = group_members.group_id
+WHERE
+	group_members.user_id = $1
+AND
+	groups.organization_id = $2
+`
+
+type GetUserGroupsParams struct {
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+}
+
+// Returns the groups within organization_id that user_id is an explicit
+// member of.
+func (q *sqlQuerier) GetUserGroups(ctx context.Context, arg GetUserGroupsParams) ([]Group, error) {
+	rows, err := q.db.QueryContext(ctx, getUserGroups, arg.UserID, arg.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Group
+	for rows.Next() {
+		var i Group
+		if err := rows.Scan(&i.ID, &i.Name, &i.OrganizationID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertAllUsersGroup = `-- name: InsertAllUsersGroup :one
+INSERT INTO groups (
+	id,
+	name,
+	organization_id
+)
+VALUES
+	( $1, 'Everyone', $1) RETURNING id, name, organization_id
+`
+
+// We use the organization_id as the id
+// for simplicity since all users is
+// every member of the org.
+func (q *sqlQuerier) InsertAllUsersGroup(ctx context.Context, organizationID uuid.UUID) (Group, error) {
+	row := q.db.QueryRowContext(ctx, insertAllUsersGroup, organizationID)
+	var i Group
+	err := row.Scan(&i.ID, &i.Name, &i.OrganizationID)
+	return i, err
+}
+
+const insertGroup = `-- name: InsertGroup :one
+INSERT INTO groups (
+	id,
+	name,
+	organization_id
+)
+VALUES
+	( $1, $2, $3) RETURNING id, name, organization_id
+`
+
+type InsertGroupParams struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	Name           string    `db:"name" json:"name"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+}
+
+func (q *sqlQuerier) InsertGroup(ctx context.Context, arg InsertGroupParams) (Group, error) {
+	row := q.db.QueryRowContext(ctx, insertGroup, arg.ID, arg.Name, arg.OrganizationID)
+	var i Group
+	err := row.Scan(&i.ID, &i.Name, &i.OrganizationID)
+	return i, err
+}
+
+const insertGroupMember = `-- name: InsertGroupMember :exec
+INSERT INTO group_members (
+	user_id,
+	group_id
+)
+VALUES ( $1, $2)
+`
+
+type InsertGroupMemberParams struct {
+	UserID  uuid.UUID `db:"user_id" json:"user_id"`
+	GroupID uuid.UUID `db:"group_id" json:"group_id"`
+}
+
+func (q *sqlQuerier) InsertGroupMember(ctx context.Context, arg InsertGroupMemberParams) error {
+	_, err := q.db.ExecContext(ctx, insertGroupMember, arg.UserID, arg.GroupID)
+	return err
+}
+
+const updateGroupByID = `-- name: UpdateGroupByID :one
+UPDATE
+	groups
+SET
+	name = $1
+WHERE
+	id = $2
+RETURNING id, name, organization_id
+`
+
+type UpdateGroupByIDParams struct {
+	Name string    `db:"name" json:"name"`
+	ID   uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateGroupByID(ctx context.Context, arg UpdateGroupByIDParams) (Group, error) {
+	row := q.db.QueryRowContext(ctx, updateGroupByID, arg.Name, arg.ID)
+	var i Group
+	err := row.Scan(&i.ID, &i.Name, &i.OrganizationID)
+	return i, err
+}
+
 const deleteLicense = `-- name: DeleteLicense :one
 DELETE
 FROM licenses
@@ -1039,7 +1765,7 @@ func (q *sqlQuerier) InsertOrganization(ctx context.Context, arg InsertOrganizat
 
 const getParameterSchemasByJobID = `-- name: GetParameterSchemasByJobID :many
 SELECT
-	id, created_at, job_id, name, description, default_source_scheme, default_source_value, allow_override_source, default_destination_scheme, allow_override_destination, default_refresh, redisplay_value, validation_error, validation_condition, validation_type_system, validation_value_type, index
+	id, created_at, job_id, name, description, default_source_scheme, default_source_value, allow_override_source, default_destination_scheme, allow_override_destination, default_refresh, redisplay_value, validation_error, validation_condition, validation_type_system, validation_value_type, index, mutable
 FROM
 	parameter_schemas
 WHERE
@@ -1075,6 +1801,7 @@ func (q *sqlQuerier) GetParameterSchemasByJobID(ctx context.Context, jobID uuid.
 			&i.ValidationTypeSystem,
 			&i.ValidationValueType,
 			&i.Index,
+			&i.Mutable,
 		); err != nil {
 			return nil, err
 		}
@@ -1090,7 +1817,7 @@ func (q *sqlQuerier) GetParameterSchemasByJobID(ctx context.Context, jobID uuid.
 }
 
 const getParameterSchemasCreatedAfter = `-- name: GetParameterSchemasCreatedAfter :many
-SELECT id, created_at, job_id, name, description, default_source_scheme, default_source_value, allow_override_source, default_destination_scheme, allow_override_destination, default_refresh, redisplay_value, validation_error, validation_condition, validation_type_system, validation_value_type, index FROM parameter_schemas WHERE created_at > $1
+SELECT id, created_at, job_id, name, description, default_source_scheme, default_source_value, allow_override_source, default_destination_scheme, allow_override_destination, default_refresh, redisplay_value, validation_error, validation_condition, validation_type_system, validation_value_type, index, mutable FROM parameter_schemas WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetParameterSchemasCreatedAfter(ctx context.Context, createdAt time.Time) ([]ParameterSchema, error) {
@@ -1120,6 +1847,7 @@ func (q *sqlQuerier) GetParameterSchemasCreatedAfter(ctx context.Context, create
 			&i.ValidationTypeSystem,
 			&i.ValidationValueType,
 			&i.Index,
+			&i.Mutable,
 		); err != nil {
 			return nil, err
 		}
@@ -1153,7 +1881,8 @@ INSERT INTO
 		validation_condition,
 		validation_type_system,
 		validation_value_type,
-		index
+		index,
+		mutable
 	)
 VALUES
 	(
@@ -1173,8 +1902,9 @@ VALUES
 		$14,
 		$15,
 		$16,
-		$17
-	) RETURNING id, created_at, job_id, name, description, default_source_scheme, default_source_value, allow_override_source, default_destination_scheme, allow_override_destination, default_refresh, redisplay_value, validation_error, validation_condition, validation_type_system, validation_value_type, index
+		$17,
+		$18
+	) RETURNING id, created_at, job_id, name, description, default_source_scheme, default_source_value, allow_override_source, default_destination_scheme, allow_override_destination, default_refresh, redisplay_value, validation_error, validation_condition, validation_type_system, validation_value_type, index, mutable
 `
 
 type InsertParameterSchemaParams struct {
@@ -1195,6 +1925,7 @@ type InsertParameterSchemaParams struct {
 	ValidationTypeSystem     ParameterTypeSystem        `db:"validation_type_system" json:"validation_type_system"`
 	ValidationValueType      string                     `db:"validation_value_type" json:"validation_value_type"`
 	Index                    int32                      `db:"index" json:"index"`
+	Mutable                  bool                       `db:"mutable" json:"mutable"`
 }
 
 func (q *sqlQuerier) InsertParameterSchema(ctx context.Context, arg InsertParameterSchemaParams) (ParameterSchema, error) {
@@ -1216,6 +1947,7 @@ func (q *sqlQuerier) InsertParameterSchema(ctx context.Context, arg InsertParame
 		arg.ValidationTypeSystem,
 		arg.ValidationValueType,
 		arg.Index,
+		arg.Mutable,
 	)
 	var i ParameterSchema
 	err := row.Scan(
@@ -1236,6 +1968,7 @@ func (q *sqlQuerier) InsertParameterSchema(ctx context.Context, arg InsertParame
 		&i.ValidationTypeSystem,
 		&i.ValidationValueType,
 		&i.Index,
+		&i.Mutable,
 	)
 	return i, err
 }
@@ -1443,7 +2176,7 @@ func (q *sqlQuerier) ParameterValues(ctx context.Context, arg ParameterValuesPar
 
 const getProvisionerDaemonByID = `-- name: GetProvisionerDaemonByID :one
 SELECT
-	id, created_at, updated_at, name, provisioners
+	id, created_at, updated_at, name, provisioners, tags
 FROM
 	provisioner_daemons
 WHERE
@@ -1459,13 +2192,14 @@ func (q *sqlQuerier) GetProvisionerDaemonByID(ctx context.Context, id uuid.UUID)
 		&i.UpdatedAt,
 		&i.Name,
 		pq.Array(&i.Provisioners),
+		&i.Tags,
 	)
 	return i, err
 }
 
 const getProvisionerDaemons = `-- name: GetProvisionerDaemons :many
 SELECT
-	id, created_at, updated_at, name, provisioners
+	id, created_at, updated_at, name, provisioners, tags
 FROM
 	provisioner_daemons
 `
@@ -1485,6 +2219,7 @@ func (q *sqlQuerier) GetProvisionerDaemons(ctx context.Context) ([]ProvisionerDa
 			&i.UpdatedAt,
 			&i.Name,
 			pq.Array(&i.Provisioners),
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -1505,10 +2240,11 @@ INSERT INTO
 		id,
 		created_at,
 		"name",
-		provisioners
+		provisioners,
+		tags
 	)
 VALUES
-	($1, $2, $3, $4) RETURNING id, created_at, updated_at, name, provisioners
+	($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at, name, provisioners, tags
 `
 
 type InsertProvisionerDaemonParams struct {
@@ -1516,6 +2252,7 @@ type InsertProvisionerDaemonParams struct {
 	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
 	Name         string            `db:"name" json:"name"`
 	Provisioners []ProvisionerType `db:"provisioners" json:"provisioners"`
+	Tags         StringMap         `db:"tags" json:"tags"`
 }
 
 func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProvisionerDaemonParams) (ProvisionerDaemon, error) {
@@ -1524,6 +2261,7 @@ func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProv
 		arg.CreatedAt,
 		arg.Name,
 		pq.Array(arg.Provisioners),
+		arg.Tags,
 	)
 	var i ProvisionerDaemon
 	err := row.Scan(
@@ -1532,6 +2270,7 @@ func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProv
 		&i.UpdatedAt,
 		&i.Name,
 		pq.Array(&i.Provisioners),
+		&i.Tags,
 	)
 	return i, err
 }
@@ -1689,29 +2428,39 @@ WHERE
 			AND nested.canceled_at IS NULL
 			AND nested.completed_at IS NULL
 			AND nested.provisioner = ANY($3 :: provisioner_type [ ])
+			AND nested.tags <@ $4 :: jsonb
 		ORDER BY
 			nested.created_at FOR
 		UPDATE
 			SKIP LOCKED
 		LIMIT
 			1
-	) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id
+	) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags
 `
 
 type AcquireProvisionerJobParams struct {
 	StartedAt sql.NullTime      `db:"started_at" json:"started_at"`
 	WorkerID  uuid.NullUUID     `db:"worker_id" json:"worker_id"`
 	Types     []ProvisionerType `db:"types" json:"types"`
+	Tags      json.RawMessage   `db:"tags" json:"tags"`
 }
 
 // Acquires the lock for a single job that isn't started, completed,
 // canceled, and that matches an array of provisioner types.
 //
+// A job is only acquired if its tags are a subset of the tags
+// the provisioner daemon was started with.
+//
 // SKIP LOCKED is used to jump over locked rows. This prevents
 // multiple provisioners from acquiring the same jobs. See:
 // https://www.postgresql.org/docs/9.5/sql-select.html#SQL-FOR-UPDATE-SHARE
 func (q *sqlQuerier) AcquireProvisionerJob(ctx context.Context, arg AcquireProvisionerJobParams) (ProvisionerJob, error) {
-	row := q.db.QueryRowContext(ctx, acquireProvisionerJob, arg.StartedAt, arg.WorkerID, pq.Array(arg.Types))
+	row := q.db.QueryRowContext(ctx, acquireProvisionerJob,
+		arg.StartedAt,
+		arg.WorkerID,
+		pq.Array(arg.Types),
+		arg.Tags,
+	)
 	var i ProvisionerJob
 	err := row.Scan(
 		&i.ID,
@@ -1729,13 +2478,66 @@ func (q *sqlQuerier) AcquireProvisionerJob(ctx context.Context, arg AcquireProvi
 		&i.Type,
 		&i.Input,
 		&i.WorkerID,
+		&i.Tags,
 	)
 	return i, err
 }
 
+const getActiveProvisionerJobs = `-- name: GetActiveProvisionerJobs :many
+SELECT
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags
+FROM
+	provisioner_jobs
+WHERE
+	completed_at IS NULL
+ORDER BY
+	created_at
+`
+
+// Active jobs are pending or running.
+func (q *sqlQuerier) GetActiveProvisionerJobs(ctx context.Context) ([]ProvisionerJob, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveProvisionerJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProvisionerJob
+	for rows.Next() {
+		var i ProvisionerJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.StartedAt,
+			&i.CanceledAt,
+			&i.CompletedAt,
+			&i.Error,
+			&i.OrganizationID,
+			&i.InitiatorID,
+			&i.Provisioner,
+			&i.StorageMethod,
+			&i.StorageSource,
+			&i.Type,
+			&i.Input,
+			&i.WorkerID,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProvisionerJobByID = `-- name: GetProvisionerJobByID :one
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags
 FROM
 	provisioner_jobs
 WHERE
@@ -1761,13 +2563,70 @@ func (q *sqlQuerier) GetProvisionerJobByID(ctx context.Context, id uuid.UUID) (P
 		&i.Type,
 		&i.Input,
 		&i.WorkerID,
+		&i.Tags,
 	)
 	return i, err
 }
 
+const getProvisionerJobQueuePositionsByIDs = `-- name: GetProvisionerJobQueuePositionsByIDs :many
+WITH queued_jobs AS (
+	SELECT
+		id,
+		ROW_NUMBER() OVER (PARTITION BY provisioner, tags ORDER BY created_at) AS queue_position,
+		COUNT(*) OVER (PARTITION BY provisioner, tags) AS queue_size
+	FROM
+		provisioner_jobs
+	WHERE
+		started_at IS NULL
+		AND canceled_at IS NULL
+		AND completed_at IS NULL
+)
+SELECT
+	id,
+	queue_position,
+	queue_size
+FROM
+	queued_jobs
+WHERE
+	id = ANY($1 :: uuid [ ])
+`
+
+type GetProvisionerJobQueuePositionsByIDsRow struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	QueuePosition int64     `db:"queue_position" json:"queue_position"`
+	QueueSize     int64     `db:"queue_size" json:"queue_size"`
+}
+
+// Queued jobs haven't been acquired by a provisioner daemon yet. Daemons
+// acquire them in the order they were created, and only jobs of their
+// provisioner type and tags, so a job is queued behind the jobs with the same
+// provisioner and tags that were created before it.
+func (q *sqlQuerier) GetProvisionerJobQueuePositionsByIDs(ctx context.Context, ids []uuid.UUID) ([]GetProvisionerJobQueuePositionsByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getProvisionerJobQueuePositionsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProvisionerJobQueuePositionsByIDsRow
+	for rows.Next() {
+		var i GetProvisionerJobQueuePositionsByIDsRow
+		if err := rows.Scan(&i.ID, &i.QueuePosition, &i.QueueSize); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProvisionerJobsByIDs = `-- name: GetProvisionerJobsByIDs :many
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags
 FROM
 	provisioner_jobs
 WHERE
@@ -1799,6 +2658,7 @@ func (q *sqlQuerier) GetProvisionerJobsByIDs(ctx context.Context, ids []uuid.UUI
 			&i.Type,
 			&i.Input,
 			&i.WorkerID,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -1814,7 +2674,7 @@ func (q *sqlQuerier) GetProvisionerJobsByIDs(ctx context.Context, ids []uuid.UUI
 }
 
 const getProvisionerJobsCreatedAfter = `-- name: GetProvisionerJobsCreatedAfter :many
-SELECT id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id FROM provisioner_jobs WHERE created_at > $1
+SELECT id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags FROM provisioner_jobs WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, createdAt time.Time) ([]ProvisionerJob, error) {
@@ -1842,6 +2702,59 @@ func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, created
 			&i.Type,
 			&i.Input,
 			&i.WorkerID,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRunningProvisionerJobs = `-- name: GetRunningProvisionerJobs :many
+SELECT
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags
+FROM
+	provisioner_jobs
+WHERE
+	started_at IS NOT NULL
+	AND completed_at IS NULL
+`
+
+// Running jobs have been acquired by a provisioner daemon, but haven't
+// completed yet. Canceled jobs are running until the daemon fails them.
+func (q *sqlQuerier) GetRunningProvisionerJobs(ctx context.Context) ([]ProvisionerJob, error) {
+	rows, err := q.db.QueryContext(ctx, getRunningProvisionerJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProvisionerJob
+	for rows.Next() {
+		var i ProvisionerJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.StartedAt,
+			&i.CanceledAt,
+			&i.CompletedAt,
+			&i.Error,
+			&i.OrganizationID,
+			&i.InitiatorID,
+			&i.Provisioner,
+			&i.StorageMethod,
+			&i.StorageSource,
+			&i.Type,
+			&i.Input,
+			&i.WorkerID,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -1868,10 +2781,11 @@ INSERT INTO
 		storage_method,
 		storage_source,
 		"type",
-		"input"
+		"input",
+		tags
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags
 `
 
 type InsertProvisionerJobParams struct {
@@ -1885,6 +2799,7 @@ type InsertProvisionerJobParams struct {
 	StorageSource  string                   `db:"storage_source" json:"storage_source"`
 	Type           ProvisionerJobType       `db:"type" json:"type"`
 	Input          json.RawMessage          `db:"input" json:"input"`
+	Tags           StringMap                `db:"tags" json:"tags"`
 }
 
 func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisionerJobParams) (ProvisionerJob, error) {
@@ -1899,7 +2814,55 @@ func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisi
 		arg.StorageSource,
 		arg.Type,
 		arg.Input,
+		arg.Tags,
+	)
+	var i ProvisionerJob
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.StartedAt,
+		&i.CanceledAt,
+		&i.CompletedAt,
+		&i.Error,
+		&i.OrganizationID,
+		&i.InitiatorID,
+		&i.Provisioner,
+		&i.StorageMethod,
+		&i.StorageSource,
+		&i.Type,
+		&i.Input,
+		&i.WorkerID,
+		&i.Tags,
 	)
+	return i, err
+}
+
+const releaseProvisionerJob = `-- name: ReleaseProvisionerJob :one
+UPDATE
+	provisioner_jobs
+SET
+	started_at = NULL,
+	updated_at = $1,
+	worker_id = NULL,
+	"input" = $2
+WHERE
+	id = $3
+	AND canceled_at IS NULL
+	AND completed_at IS NULL RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags
+`
+
+type ReleaseProvisionerJobParams struct {
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+	Input     json.RawMessage `db:"input" json:"input"`
+	ID        uuid.UUID       `db:"id" json:"id"`
+}
+
+// Puts a running job back in the queue, so a provisioner daemon acquires it
+// again. Jobs that were canceled or completed in the meantime aren't
+// released.
+func (q *sqlQuerier) ReleaseProvisionerJob(ctx context.Context, arg ReleaseProvisionerJobParams) (ProvisionerJob, error) {
+	row := q.db.QueryRowContext(ctx, releaseProvisionerJob, arg.UpdatedAt, arg.Input, arg.ID)
 	var i ProvisionerJob
 	err := row.Scan(
 		&i.ID,
@@ -1917,6 +2880,7 @@ func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisi
 		&i.Type,
 		&i.Input,
 		&i.WorkerID,
+		&i.Tags,
 	)
 	return i, err
 }
@@ -1940,13 +2904,14 @@ func (q *sqlQuerier) UpdateProvisionerJobByID(ctx context.Context, arg UpdatePro
 	return err
 }
 
-const updateProvisionerJobWithCancelByID = `-- name: UpdateProvisionerJobWithCancelByID :exec
+const updateProvisionerJobWithCancelByID = `-- name: UpdateProvisionerJobWithCancelByID :one
 UPDATE
 	provisioner_jobs
 SET
-	canceled_at = $2
+	canceled_at = $2,
+	completed_at = CASE WHEN started_at IS NULL THEN $2 ELSE completed_at END
 WHERE
-	id = $1
+	id = $1 RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, storage_source, type, input, worker_id, tags
 `
 
 type UpdateProvisionerJobWithCancelByIDParams struct {
@@ -1954,9 +2919,30 @@ type UpdateProvisionerJobWithCancelByIDParams struct {
 	CanceledAt sql.NullTime `db:"canceled_at" json:"canceled_at"`
 }
 
-func (q *sqlQuerier) UpdateProvisionerJobWithCancelByID(ctx context.Context, arg UpdateProvisionerJobWithCancelByIDParams) error {
-	_, err := q.db.ExecContext(ctx, updateProvisionerJobWithCancelByID, arg.ID, arg.CanceledAt)
-	return err
+// Jobs that no provisioner daemon has acquired yet are completed as they're
+// canceled, since there's no daemon to finish them.
+func (q *sqlQuerier) UpdateProvisionerJobWithCancelByID(ctx context.Context, arg UpdateProvisionerJobWithCancelByIDParams) (ProvisionerJob, error) {
+	row := q.db.QueryRowContext(ctx, updateProvisionerJobWithCancelByID, arg.ID, arg.CanceledAt)
+	var i ProvisionerJob
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.StartedAt,
+		&i.CanceledAt,
+		&i.CompletedAt,
+		&i.Error,
+		&i.OrganizationID,
+		&i.InitiatorID,
+		&i.Provisioner,
+		&i.StorageMethod,
+		&i.StorageSource,
+		&i.Type,
+		&i.Input,
+		&i.WorkerID,
+		&i.Tags,
+	)
+	return i, err
 }
 
 const updateProvisionerJobWithCompleteByID = `-- name: UpdateProvisionerJobWithCompleteByID :exec
@@ -2009,7 +2995,7 @@ func (q *sqlQuerier) InsertDeploymentID(ctx context.Context, value string) error
 
 const getTemplateByID = `-- name: GetTemplateByID :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, inactivity_ttl, user_acl, group_acl, wait_for_agent_ready, session_recording, max_job_duration
 FROM
 	templates
 WHERE
@@ -2035,13 +3021,19 @@ func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Templat
 		&i.MinAutostartInterval,
 		&i.CreatedBy,
 		&i.Icon,
+		&i.InactivityTtl,
+		&i.UserACL,
+		&i.GroupACL,
+		&i.WaitForAgentReady,
+		&i.SessionRecording,
+		&i.MaxJobDuration,
 	)
 	return i, err
 }
 
 const getTemplateByOrganizationAndName = `-- name: GetTemplateByOrganizationAndName :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, inactivity_ttl, user_acl, group_acl, wait_for_agent_ready, session_recording, max_job_duration
 FROM
 	templates
 WHERE
@@ -2075,12 +3067,18 @@ func (q *sqlQuerier) GetTemplateByOrganizationAndName(ctx context.Context, arg G
 		&i.MinAutostartInterval,
 		&i.CreatedBy,
 		&i.Icon,
+		&i.InactivityTtl,
+		&i.UserACL,
+		&i.GroupACL,
+		&i.WaitForAgentReady,
+		&i.SessionRecording,
+		&i.MaxJobDuration,
 	)
 	return i, err
 }
 
 const getTemplates = `-- name: GetTemplates :many
-SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon FROM templates
+SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, inactivity_ttl, user_acl, group_acl, wait_for_agent_ready, session_recording, max_job_duration FROM templates
 ORDER BY (name, id) ASC
 `
 
@@ -2107,6 +3105,12 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 			&i.MinAutostartInterval,
 			&i.CreatedBy,
 			&i.Icon,
+			&i.InactivityTtl,
+			&i.UserACL,
+			&i.GroupACL,
+			&i.WaitForAgentReady,
+			&i.SessionRecording,
+			&i.MaxJobDuration,
 		); err != nil {
 			return nil, err
 		}
@@ -2123,7 +3127,7 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 
 const getTemplatesWithFilter = `-- name: GetTemplatesWithFilter :many
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, inactivity_ttl, user_acl, group_acl, wait_for_agent_ready, session_recording, max_job_duration
 FROM
 	templates
 WHERE
@@ -2185,6 +3189,12 @@ func (q *sqlQuerier) GetTemplatesWithFilter(ctx context.Context, arg GetTemplate
 			&i.MinAutostartInterval,
 			&i.CreatedBy,
 			&i.Icon,
+			&i.InactivityTtl,
+			&i.UserACL,
+			&i.GroupACL,
+			&i.WaitForAgentReady,
+			&i.SessionRecording,
+			&i.MaxJobDuration,
 		); err != nil {
 			return nil, err
 		}
@@ -2213,10 +3223,12 @@ INSERT INTO
 		max_ttl,
 		min_autostart_interval,
 		created_by,
-		icon
+		icon,
+		user_acl,
+		group_acl
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, inactivity_ttl, user_acl, group_acl, wait_for_agent_ready, session_recording, max_job_duration
 `
 
 type InsertTemplateParams struct {
@@ -2232,6 +3244,8 @@ type InsertTemplateParams struct {
 	MinAutostartInterval int64           `db:"min_autostart_interval" json:"min_autostart_interval"`
 	CreatedBy            uuid.UUID       `db:"created_by" json:"created_by"`
 	Icon                 string          `db:"icon" json:"icon"`
+	UserACL              TemplateACL     `db:"user_acl" json:"user_acl"`
+	GroupACL             TemplateACL     `db:"group_acl" json:"group_acl"`
 }
 
 func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParams) (Template, error) {
@@ -2248,6 +3262,8 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		arg.MinAutostartInterval,
 		arg.CreatedBy,
 		arg.Icon,
+		arg.UserACL,
+		arg.GroupACL,
 	)
 	var i Template
 	err := row.Scan(
@@ -2264,10 +3280,37 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		&i.MinAutostartInterval,
 		&i.CreatedBy,
 		&i.Icon,
+		&i.InactivityTtl,
+		&i.UserACL,
+		&i.GroupACL,
+		&i.WaitForAgentReady,
+		&i.SessionRecording,
+		&i.MaxJobDuration,
 	)
 	return i, err
 }
 
+const updateTemplateACLByID = `-- name: UpdateTemplateACLByID :exec
+UPDATE
+	templates
+SET
+	group_acl = $1,
+	user_acl = $2
+WHERE
+	id = $3
+`
+
+type UpdateTemplateACLByIDParams struct {
+	GroupACL TemplateACL `db:"group_acl" json:"group_acl"`
+	UserACL  TemplateACL `db:"user_acl" json:"user_acl"`
+	ID       uuid.UUID   `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateTemplateACLByID(ctx context.Context, arg UpdateTemplateACLByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateTemplateACLByID, arg.GroupACL, arg.UserACL, arg.ID)
+	return err
+}
+
 const updateTemplateActiveVersionByID = `-- name: UpdateTemplateActiveVersionByID :exec
 UPDATE
 	templates
@@ -2319,11 +3362,15 @@ SET
 	max_ttl = $4,
 	min_autostart_interval = $5,
 	name = $6,
-	icon = $7
+	icon = $7,
+	inactivity_ttl = $8,
+	wait_for_agent_ready = $9,
+	session_recording = $10,
+	max_job_duration = $11
 WHERE
 	id = $1
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, max_ttl, min_autostart_interval, created_by, icon, inactivity_ttl, user_acl, group_acl, wait_for_agent_ready, session_recording, max_job_duration
 `
 
 type UpdateTemplateMetaByIDParams struct {
@@ -2334,6 +3381,10 @@ type UpdateTemplateMetaByIDParams struct {
 	MinAutostartInterval int64     `db:"min_autostart_interval" json:"min_autostart_interval"`
 	Name                 string    `db:"name" json:"name"`
 	Icon                 string    `db:"icon" json:"icon"`
+	InactivityTtl        int64     `db:"inactivity_ttl" json:"inactivity_ttl"`
+	WaitForAgentReady    bool      `db:"wait_for_agent_ready" json:"wait_for_agent_ready"`
+	SessionRecording     bool      `db:"session_recording" json:"session_recording"`
+	MaxJobDuration       int64     `db:"max_job_duration" json:"max_job_duration"`
 }
 
 func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTemplateMetaByIDParams) error {
@@ -2345,13 +3396,17 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		arg.MinAutostartInterval,
 		arg.Name,
 		arg.Icon,
+		arg.InactivityTtl,
+		arg.WaitForAgentReady,
+		arg.SessionRecording,
+		arg.MaxJobDuration,
 	)
 	return err
 }
 
 const getTemplateVersionByID = `-- name: GetTemplateVersionByID :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -2371,13 +3426,14 @@ func (q *sqlQuerier) GetTemplateVersionByID(ctx context.Context, id uuid.UUID) (
 		&i.Readme,
 		&i.JobID,
 		&i.CreatedBy,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
 
 const getTemplateVersionByJobID = `-- name: GetTemplateVersionByJobID :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -2397,13 +3453,14 @@ func (q *sqlQuerier) GetTemplateVersionByJobID(ctx context.Context, jobID uuid.U
 		&i.Readme,
 		&i.JobID,
 		&i.CreatedBy,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
 
 const getTemplateVersionByTemplateIDAndName = `-- name: GetTemplateVersionByTemplateIDAndName :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -2429,13 +3486,14 @@ func (q *sqlQuerier) GetTemplateVersionByTemplateIDAndName(ctx context.Context,
 		&i.Readme,
 		&i.JobID,
 		&i.CreatedBy,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
 
 const getTemplateVersionsByTemplateID = `-- name: GetTemplateVersionsByTemplateID :many
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -2499,6 +3557,7 @@ func (q *sqlQuerier) GetTemplateVersionsByTemplateID(ctx context.Context, arg Ge
 			&i.Readme,
 			&i.JobID,
 			&i.CreatedBy,
+			&i.GitCommitSHA,
 		); err != nil {
 			return nil, err
 		}
@@ -2514,7 +3573,7 @@ func (q *sqlQuerier) GetTemplateVersionsByTemplateID(ctx context.Context, arg Ge
 }
 
 const getTemplateVersionsCreatedAfter = `-- name: GetTemplateVersionsCreatedAfter :many
-SELECT id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by FROM template_versions WHERE created_at > $1
+SELECT id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_commit_sha FROM template_versions WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetTemplateVersionsCreatedAfter(ctx context.Context, createdAt time.Time) ([]TemplateVersion, error) {
@@ -2536,6 +3595,7 @@ func (q *sqlQuerier) GetTemplateVersionsCreatedAfter(ctx context.Context, create
 			&i.Readme,
 			&i.JobID,
 			&i.CreatedBy,
+			&i.GitCommitSHA,
 		); err != nil {
 			return nil, err
 		}
@@ -2564,7 +3624,7 @@ INSERT INTO
 		created_by
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_commit_sha
 `
 
 type InsertTemplateVersionParams struct {
@@ -2602,6 +3662,7 @@ func (q *sqlQuerier) InsertTemplateVersion(ctx context.Context, arg InsertTempla
 		&i.Readme,
 		&i.JobID,
 		&i.CreatedBy,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
@@ -2648,6 +3709,27 @@ func (q *sqlQuerier) UpdateTemplateVersionDescriptionByJobID(ctx context.Context
 	return err
 }
 
+const updateTemplateVersionGitCommitSHAByJobID = `-- name: UpdateTemplateVersionGitCommitSHAByJobID :exec
+UPDATE
+	template_versions
+SET
+	git_commit_sha = $2,
+	updated_at = $3
+WHERE
+	job_id = $1
+`
+
+type UpdateTemplateVersionGitCommitSHAByJobIDParams struct {
+	JobID        uuid.UUID `db:"job_id" json:"job_id"`
+	GitCommitSHA string    `db:"git_commit_sha" json:"git_commit_sha"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateTemplateVersionGitCommitSHAByJobID(ctx context.Context, arg UpdateTemplateVersionGitCommitSHAByJobIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateTemplateVersionGitCommitSHAByJobID, arg.JobID, arg.GitCommitSHA, arg.UpdatedAt)
+	return err
+}
+
 const getUserLinkByLinkedID = `-- name: GetUserLinkByLinkedID :one
 SELECT
 	user_id, login_type, linked_id, oauth_access_token, oauth_refresh_token, oauth_expiry
@@ -2838,7 +3920,28 @@ SELECT
 			array_append(users.rbac_roles, 'member'),
 		-- All org_members get the org-member role for their orgs
 			array_append(organization_members.roles, 'organization-member:'||organization_members.organization_id::text)) :: text[]
-		AS roles
+		AS roles,
+	array_cat(
+		-- All groups the user is explicitly a member of
+		(
+			SELECT
+				array_agg(group_members.group_id :: text)
+			FROM
+				group_members
+			WHERE
+				group_members.user_id = users.id
+		),
+		-- All org_members are in the "Everyone" group of their orgs, which
+		-- shares the organization's ID
+		(
+			SELECT
+				array_agg(org_members.organization_id :: text)
+			FROM
+				organization_members AS org_members
+			WHERE
+				org_members.user_id = users.id
+		)) :: text[]
+		AS groups
 FROM
 	users
 LEFT JOIN organization_members
@@ -2852,6 +3955,7 @@ type GetAuthorizationUserRolesRow struct {
 	Username string     `db:"username" json:"username"`
 	Status   UserStatus `db:"status" json:"status"`
 	Roles    []string   `db:"roles" json:"roles"`
+	Groups   []string   `db:"groups" json:"groups"`
 }
 
 // This function returns roles for authorization purposes. Implied member roles
@@ -2864,6 +3968,7 @@ func (q *sqlQuerier) GetAuthorizationUserRoles(ctx context.Context, userID uuid.
 		&i.Username,
 		&i.Status,
 		pq.Array(&i.Roles),
+		pq.Array(&i.Groups),
 	)
 	return i, err
 }
@@ -3270,7 +4375,7 @@ func (q *sqlQuerier) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusP
 
 const getWorkspaceAgentByAuthToken = `-- name: GetWorkspaceAgentByAuthToken :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, lifecycle_state, shutdown_script
 FROM
 	workspace_agents
 WHERE
@@ -3301,13 +4406,15 @@ func (q *sqlQuerier) GetWorkspaceAgentByAuthToken(ctx context.Context, authToken
 		&i.ResourceMetadata,
 		&i.Directory,
 		&i.Version,
+		&i.LifecycleState,
+		&i.ShutdownScript,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByID = `-- name: GetWorkspaceAgentByID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, lifecycle_state, shutdown_script
 FROM
 	workspace_agents
 WHERE
@@ -3336,13 +4443,15 @@ func (q *sqlQuerier) GetWorkspaceAgentByID(ctx context.Context, id uuid.UUID) (W
 		&i.ResourceMetadata,
 		&i.Directory,
 		&i.Version,
+		&i.LifecycleState,
+		&i.ShutdownScript,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByInstanceID = `-- name: GetWorkspaceAgentByInstanceID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, lifecycle_state, shutdown_script
 FROM
 	workspace_agents
 WHERE
@@ -3373,13 +4482,60 @@ func (q *sqlQuerier) GetWorkspaceAgentByInstanceID(ctx context.Context, authInst
 		&i.ResourceMetadata,
 		&i.Directory,
 		&i.Version,
+		&i.LifecycleState,
+		&i.ShutdownScript,
 	)
 	return i, err
 }
 
+const getWorkspaceAgentStartupLogsAfter = `-- name: GetWorkspaceAgentStartupLogsAfter :many
+SELECT
+	agent_id, created_at, output, id
+FROM
+	workspace_agent_startup_logs
+WHERE
+	agent_id = $1
+	AND (
+		id > $2
+	) ORDER BY id ASC
+`
+
+type GetWorkspaceAgentStartupLogsAfterParams struct {
+	AgentID uuid.UUID `db:"agent_id" json:"agent_id"`
+	AfterID int64     `db:"after_id" json:"after_id"`
+}
+
+func (q *sqlQuerier) GetWorkspaceAgentStartupLogsAfter(ctx context.Context, arg GetWorkspaceAgentStartupLogsAfterParams) ([]WorkspaceAgentStartupLog, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentStartupLogsAfter, arg.AgentID, arg.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentStartupLog
+	for rows.Next() {
+		var i WorkspaceAgentStartupLog
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.CreatedAt,
+			&i.Output,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspaceAgentsByResourceIDs = `-- name: GetWorkspaceAgentsByResourceIDs :many
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, lifecycle_state, shutdown_script
 FROM
 	workspace_agents
 WHERE
@@ -3414,6 +4570,8 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 			&i.ResourceMetadata,
 			&i.Directory,
 			&i.Version,
+			&i.LifecycleState,
+			&i.ShutdownScript,
 		); err != nil {
 			return nil, err
 		}
@@ -3429,7 +4587,7 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 }
 
 const getWorkspaceAgentsCreatedAfter = `-- name: GetWorkspaceAgentsCreatedAfter :many
-SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version FROM workspace_agents WHERE created_at > $1
+SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, lifecycle_state, shutdown_script FROM workspace_agents WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceAgent, error) {
@@ -3460,6 +4618,8 @@ func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, created
 			&i.ResourceMetadata,
 			&i.Directory,
 			&i.Version,
+			&i.LifecycleState,
+			&i.ShutdownScript,
 		); err != nil {
 			return nil, err
 		}
@@ -3490,10 +4650,11 @@ INSERT INTO
 		startup_script,
 		directory,
 		instance_metadata,
-		resource_metadata
+		resource_metadata,
+		shutdown_script
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, lifecycle_state, shutdown_script
 `
 
 type InsertWorkspaceAgentParams struct {
@@ -3511,6 +4672,7 @@ type InsertWorkspaceAgentParams struct {
 	Directory            string                `db:"directory" json:"directory"`
 	InstanceMetadata     pqtype.NullRawMessage `db:"instance_metadata" json:"instance_metadata"`
 	ResourceMetadata     pqtype.NullRawMessage `db:"resource_metadata" json:"resource_metadata"`
+	ShutdownScript       sql.NullString        `db:"shutdown_script" json:"shutdown_script"`
 }
 
 func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspaceAgentParams) (WorkspaceAgent, error) {
@@ -3529,6 +4691,7 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		arg.Directory,
 		arg.InstanceMetadata,
 		arg.ResourceMetadata,
+		arg.ShutdownScript,
 	)
 	var i WorkspaceAgent
 	err := row.Scan(
@@ -3550,10 +4713,56 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		&i.ResourceMetadata,
 		&i.Directory,
 		&i.Version,
+		&i.LifecycleState,
+		&i.ShutdownScript,
 	)
 	return i, err
 }
 
+const insertWorkspaceAgentStartupLogs = `-- name: InsertWorkspaceAgentStartupLogs :many
+INSERT INTO
+	workspace_agent_startup_logs (agent_id, created_at, output)
+SELECT
+	$1 :: uuid AS agent_id,
+	unnest($2 :: timestamptz [ ]) AS created_at,
+	unnest($3 :: VARCHAR(1024) [ ]) AS output
+RETURNING workspace_agent_startup_logs.agent_id, workspace_agent_startup_logs.created_at, workspace_agent_startup_logs.output, workspace_agent_startup_logs.id
+`
+
+type InsertWorkspaceAgentStartupLogsParams struct {
+	AgentID   uuid.UUID   `db:"agent_id" json:"agent_id"`
+	CreatedAt []time.Time `db:"created_at" json:"created_at"`
+	Output    []string    `db:"output" json:"output"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentStartupLogs(ctx context.Context, arg InsertWorkspaceAgentStartupLogsParams) ([]WorkspaceAgentStartupLog, error) {
+	rows, err := q.db.QueryContext(ctx, insertWorkspaceAgentStartupLogs, arg.AgentID, pq.Array(arg.CreatedAt), pq.Array(arg.Output))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentStartupLog
+	for rows.Next() {
+		var i WorkspaceAgentStartupLog
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.CreatedAt,
+			&i.Output,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateWorkspaceAgentConnectionByID = `-- name: UpdateWorkspaceAgentConnectionByID :exec
 UPDATE
 	workspace_agents
@@ -3585,6 +4794,25 @@ func (q *sqlQuerier) UpdateWorkspaceAgentConnectionByID(ctx context.Context, arg
 	return err
 }
 
+const updateWorkspaceAgentLifecycleStateByID = `-- name: UpdateWorkspaceAgentLifecycleStateByID :exec
+UPDATE
+	workspace_agents
+SET
+	lifecycle_state = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAgentLifecycleStateByIDParams struct {
+	ID             uuid.UUID                    `db:"id" json:"id"`
+	LifecycleState WorkspaceAgentLifecycleState `db:"lifecycle_state" json:"lifecycle_state"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentLifecycleStateByID(ctx context.Context, arg UpdateWorkspaceAgentLifecycleStateByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentLifecycleStateByID, arg.ID, arg.LifecycleState)
+	return err
+}
+
 const updateWorkspaceAgentVersionByID = `-- name: UpdateWorkspaceAgentVersionByID :exec
 UPDATE
 	workspace_agents
@@ -3605,7 +4833,7 @@ func (q *sqlQuerier) UpdateWorkspaceAgentVersionByID(ctx context.Context, arg Up
 }
 
 const getWorkspaceAppByAgentIDAndName = `-- name: GetWorkspaceAppByAgentIDAndName :one
-SELECT id, created_at, agent_id, name, icon, command, url, relative_path FROM workspace_apps WHERE agent_id = $1 AND name = $2
+SELECT id, created_at, agent_id, name, icon, command, url, relative_path, sharing_level FROM workspace_apps WHERE agent_id = $1 AND name = $2
 `
 
 type GetWorkspaceAppByAgentIDAndNameParams struct {
@@ -3625,12 +4853,13 @@ func (q *sqlQuerier) GetWorkspaceAppByAgentIDAndName(ctx context.Context, arg Ge
 		&i.Command,
 		&i.Url,
 		&i.RelativePath,
+		&i.SharingLevel,
 	)
 	return i, err
 }
 
 const getWorkspaceAppsByAgentID = `-- name: GetWorkspaceAppsByAgentID :many
-SELECT id, created_at, agent_id, name, icon, command, url, relative_path FROM workspace_apps WHERE agent_id = $1 ORDER BY name ASC
+SELECT id, created_at, agent_id, name, icon, command, url, relative_path, sharing_level FROM workspace_apps WHERE agent_id = $1 ORDER BY name ASC
 `
 
 func (q *sqlQuerier) GetWorkspaceAppsByAgentID(ctx context.Context, agentID uuid.UUID) ([]WorkspaceApp, error) {
@@ -3651,6 +4880,7 @@ func (q *sqlQuerier) GetWorkspaceAppsByAgentID(ctx context.Context, agentID uuid
 			&i.Command,
 			&i.Url,
 			&i.RelativePath,
+			&i.SharingLevel,
 		); err != nil {
 			return nil, err
 		}
@@ -3666,7 +4896,7 @@ func (q *sqlQuerier) GetWorkspaceAppsByAgentID(ctx context.Context, agentID uuid
 }
 
 const getWorkspaceAppsByAgentIDs = `-- name: GetWorkspaceAppsByAgentIDs :many
-SELECT id, created_at, agent_id, name, icon, command, url, relative_path FROM workspace_apps WHERE agent_id = ANY($1 :: uuid [ ]) ORDER BY name ASC
+SELECT id, created_at, agent_id, name, icon, command, url, relative_path, sharing_level FROM workspace_apps WHERE agent_id = ANY($1 :: uuid [ ]) ORDER BY name ASC
 `
 
 func (q *sqlQuerier) GetWorkspaceAppsByAgentIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceApp, error) {
@@ -3687,6 +4917,7 @@ func (q *sqlQuerier) GetWorkspaceAppsByAgentIDs(ctx context.Context, ids []uuid.
 			&i.Command,
 			&i.Url,
 			&i.RelativePath,
+			&i.SharingLevel,
 		); err != nil {
 			return nil, err
 		}
@@ -3702,7 +4933,7 @@ func (q *sqlQuerier) GetWorkspaceAppsByAgentIDs(ctx context.Context, ids []uuid.
 }
 
 const getWorkspaceAppsCreatedAfter = `-- name: GetWorkspaceAppsCreatedAfter :many
-SELECT id, created_at, agent_id, name, icon, command, url, relative_path FROM workspace_apps WHERE created_at > $1 ORDER BY name ASC
+SELECT id, created_at, agent_id, name, icon, command, url, relative_path, sharing_level FROM workspace_apps WHERE created_at > $1 ORDER BY name ASC
 `
 
 func (q *sqlQuerier) GetWorkspaceAppsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceApp, error) {
@@ -3723,6 +4954,7 @@ func (q *sqlQuerier) GetWorkspaceAppsCreatedAfter(ctx context.Context, createdAt
 			&i.Command,
 			&i.Url,
 			&i.RelativePath,
+			&i.SharingLevel,
 		); err != nil {
 			return nil, err
 		}
@@ -3747,21 +4979,23 @@ INSERT INTO
         icon,
         command,
         url,
-        relative_path
+        relative_path,
+        sharing_level
     )
 VALUES
-    ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, agent_id, name, icon, command, url, relative_path
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, agent_id, name, icon, command, url, relative_path, sharing_level
 `
 
 type InsertWorkspaceAppParams struct {
-	ID           uuid.UUID      `db:"id" json:"id"`
-	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
-	AgentID      uuid.UUID      `db:"agent_id" json:"agent_id"`
-	Name         string         `db:"name" json:"name"`
-	Icon         string         `db:"icon" json:"icon"`
-	Command      sql.NullString `db:"command" json:"command"`
-	Url          sql.NullString `db:"url" json:"url"`
-	RelativePath bool           `db:"relative_path" json:"relative_path"`
+	ID           uuid.UUID       `db:"id" json:"id"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+	AgentID      uuid.UUID       `db:"agent_id" json:"agent_id"`
+	Name         string          `db:"name" json:"name"`
+	Icon         string          `db:"icon" json:"icon"`
+	Command      sql.NullString  `db:"command" json:"command"`
+	Url          sql.NullString  `db:"url" json:"url"`
+	RelativePath bool            `db:"relative_path" json:"relative_path"`
+	SharingLevel AppSharingLevel `db:"sharing_level" json:"sharing_level"`
 }
 
 func (q *sqlQuerier) InsertWorkspaceApp(ctx context.Context, arg InsertWorkspaceAppParams) (WorkspaceApp, error) {
@@ -3774,6 +5008,7 @@ func (q *sqlQuerier) InsertWorkspaceApp(ctx context.Context, arg InsertWorkspace
 		arg.Command,
 		arg.Url,
 		arg.RelativePath,
+		arg.SharingLevel,
 	)
 	var i WorkspaceApp
 	err := row.Scan(
@@ -3785,10 +5020,63 @@ func (q *sqlQuerier) InsertWorkspaceApp(ctx context.Context, arg InsertWorkspace
 		&i.Command,
 		&i.Url,
 		&i.RelativePath,
+		&i.SharingLevel,
 	)
 	return i, err
 }
 
+const getWorkspaceBuildParameters = `-- name: GetWorkspaceBuildParameters :many
+SELECT
+	workspace_build_id, name, value
+FROM
+	workspace_build_parameters
+WHERE
+	workspace_build_id = $1
+ORDER BY
+	"name"
+`
+
+func (q *sqlQuerier) GetWorkspaceBuildParameters(ctx context.Context, workspaceBuildID uuid.UUID) ([]WorkspaceBuildParameter, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildParameters, workspaceBuildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceBuildParameter
+	for rows.Next() {
+		var i WorkspaceBuildParameter
+		if err := rows.Scan(&i.WorkspaceBuildID, &i.Name, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceBuildParameter = `-- name: InsertWorkspaceBuildParameter :exec
+INSERT INTO
+	workspace_build_parameters (workspace_build_id, "name", value)
+VALUES
+	($1, $2, $3)
+`
+
+type InsertWorkspaceBuildParameterParams struct {
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	Name             string    `db:"name" json:"name"`
+	Value            string    `db:"value" json:"value"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceBuildParameter(ctx context.Context, arg InsertWorkspaceBuildParameterParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceBuildParameter, arg.WorkspaceBuildID, arg.Name, arg.Value)
+	return err
+}
+
 const getLatestWorkspaceBuildByWorkspaceID = `-- name: GetLatestWorkspaceBuildByWorkspaceID :one
 SELECT
 	id, created_at, updated_at, workspace_id, template_version_id, name, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason
@@ -4288,6 +5576,257 @@ func (q *sqlQuerier) UpdateWorkspaceBuildByID(ctx context.Context, arg UpdateWor
 	return err
 }
 
+const getWorkspaceConnectionCount = `-- name: GetWorkspaceConnectionCount :one
+SELECT
+	COUNT(*)
+FROM
+	workspace_connections
+LEFT JOIN
+	users ON users.id = workspace_connections.user_id
+WHERE
+	-- Filter by workspace
+	CASE
+		WHEN $1 :: uuid != '00000000-00000000-00000000-00000000' THEN
+			workspace_connections.workspace_id = $1
+		ELSE true
+	END
+	-- Filter by username
+	AND CASE
+		WHEN $2 :: text != '' THEN
+			LOWER(users.username) = LOWER($2)
+		ELSE true
+	END
+	-- Filter by type
+	AND CASE
+		WHEN $3 :: text != '' THEN
+			workspace_connections.type = $3 :: workspace_connection_type
+		ELSE true
+	END
+	-- Filter by time range
+	AND CASE
+		WHEN $4 :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			workspace_connections.started_at >= $4
+		ELSE true
+	END
+	AND CASE
+		WHEN $5 :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			workspace_connections.started_at < $5
+		ELSE true
+	END
+`
+
+type GetWorkspaceConnectionCountParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	Username    string    `db:"username" json:"username"`
+	Type        string    `db:"type" json:"type"`
+	DateFrom    time.Time `db:"date_from" json:"date_from"`
+	DateTo      time.Time `db:"date_to" json:"date_to"`
+}
+
+// GetWorkspaceConnectionCount returns the number of connections matching the
+// filters.
+func (q *sqlQuerier) GetWorkspaceConnectionCount(ctx context.Context, arg GetWorkspaceConnectionCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceConnectionCount,
+		arg.WorkspaceID,
+		arg.Username,
+		arg.Type,
+		arg.DateFrom,
+		arg.DateTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getWorkspaceConnectionsOffset = `-- name: GetWorkspaceConnectionsOffset :many
+SELECT
+	workspace_connections.id, workspace_connections.workspace_id, workspace_connections.agent_id, workspace_connections.user_id, workspace_connections.type, workspace_connections.remote_addr, workspace_connections.target, workspace_connections.started_at, workspace_connections.ended_at, workspace_connections.rx_bytes, workspace_connections.tx_bytes,
+	COALESCE(users.username, '') AS username,
+	workspaces.name AS workspace_name,
+	workspace_agents.name AS agent_name
+FROM
+	workspace_connections
+LEFT JOIN
+	users ON users.id = workspace_connections.user_id
+JOIN
+	workspaces ON workspaces.id = workspace_connections.workspace_id
+JOIN
+	workspace_agents ON workspace_agents.id = workspace_connections.agent_id
+WHERE
+	-- Filter by workspace
+	CASE
+		WHEN $1 :: uuid != '00000000-00000000-00000000-00000000' THEN
+			workspace_connections.workspace_id = $1
+		ELSE true
+	END
+	-- Filter by username
+	AND CASE
+		WHEN $2 :: text != '' THEN
+			LOWER(users.username) = LOWER($2)
+		ELSE true
+	END
+	-- Filter by type
+	AND CASE
+		WHEN $3 :: text != '' THEN
+			workspace_connections.type = $3 :: workspace_connection_type
+		ELSE true
+	END
+	-- Filter by time range
+	AND CASE
+		WHEN $4 :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			workspace_connections.started_at >= $4
+		ELSE true
+	END
+	AND CASE
+		WHEN $5 :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			workspace_connections.started_at < $5
+		ELSE true
+	END
+ORDER BY
+	workspace_connections.started_at DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF($6 :: int, 0)
+OFFSET
+	$7
+`
+
+type GetWorkspaceConnectionsOffsetParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	Username    string    `db:"username" json:"username"`
+	Type        string    `db:"type" json:"type"`
+	DateFrom    time.Time `db:"date_from" json:"date_from"`
+	DateTo      time.Time `db:"date_to" json:"date_to"`
+	LimitOpt    int32     `db:"limit_opt" json:"limit_opt"`
+	OffsetOpt   int32     `db:"offset_opt" json:"offset_opt"`
+}
+
+type GetWorkspaceConnectionsOffsetRow struct {
+	ID            uuid.UUID               `db:"id" json:"id"`
+	WorkspaceID   uuid.UUID               `db:"workspace_id" json:"workspace_id"`
+	AgentID       uuid.UUID               `db:"agent_id" json:"agent_id"`
+	UserID        uuid.NullUUID           `db:"user_id" json:"user_id"`
+	Type          WorkspaceConnectionType `db:"type" json:"type"`
+	RemoteAddr    string                  `db:"remote_addr" json:"remote_addr"`
+	Target        string                  `db:"target" json:"target"`
+	StartedAt     time.Time               `db:"started_at" json:"started_at"`
+	EndedAt       sql.NullTime            `db:"ended_at" json:"ended_at"`
+	RxBytes       int64                   `db:"rx_bytes" json:"rx_bytes"`
+	TxBytes       int64                   `db:"tx_bytes" json:"tx_bytes"`
+	Username      string                  `db:"username" json:"username"`
+	WorkspaceName string                  `db:"workspace_name" json:"workspace_name"`
+	AgentName     string                  `db:"agent_name" json:"agent_name"`
+}
+
+// GetWorkspaceConnectionsOffset returns a page of connections matching the
+// filters, ordered from newest to oldest.
+func (q *sqlQuerier) GetWorkspaceConnectionsOffset(ctx context.Context, arg GetWorkspaceConnectionsOffsetParams) ([]GetWorkspaceConnectionsOffsetRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceConnectionsOffset,
+		arg.WorkspaceID,
+		arg.Username,
+		arg.Type,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.LimitOpt,
+		arg.OffsetOpt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkspaceConnectionsOffsetRow
+	for rows.Next() {
+		var i GetWorkspaceConnectionsOffsetRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkspaceID,
+			&i.AgentID,
+			&i.UserID,
+			&i.Type,
+			&i.RemoteAddr,
+			&i.Target,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.RxBytes,
+			&i.TxBytes,
+			&i.Username,
+			&i.WorkspaceName,
+			&i.AgentName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertWorkspaceConnection = `-- name: UpsertWorkspaceConnection :one
+INSERT INTO
+	workspace_connections (id, workspace_id, agent_id, user_id, type, remote_addr, target, started_at, ended_at, rx_bytes, tx_bytes)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (id) DO UPDATE SET
+	ended_at = EXCLUDED.ended_at,
+	rx_bytes = EXCLUDED.rx_bytes,
+	tx_bytes = EXCLUDED.tx_bytes
+WHERE
+	workspace_connections.agent_id = EXCLUDED.agent_id
+RETURNING id, workspace_id, agent_id, user_id, type, remote_addr, target, started_at, ended_at, rx_bytes, tx_bytes
+`
+
+type UpsertWorkspaceConnectionParams struct {
+	ID          uuid.UUID               `db:"id" json:"id"`
+	WorkspaceID uuid.UUID               `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID               `db:"agent_id" json:"agent_id"`
+	UserID      uuid.NullUUID           `db:"user_id" json:"user_id"`
+	Type        WorkspaceConnectionType `db:"type" json:"type"`
+	RemoteAddr  string                  `db:"remote_addr" json:"remote_addr"`
+	Target      string                  `db:"target" json:"target"`
+	StartedAt   time.Time               `db:"started_at" json:"started_at"`
+	EndedAt     sql.NullTime            `db:"ended_at" json:"ended_at"`
+	RxBytes     int64                   `db:"rx_bytes" json:"rx_bytes"`
+	TxBytes     int64                   `db:"tx_bytes" json:"tx_bytes"`
+}
+
+// UpsertWorkspaceConnection records a connection when it starts and updates
+// it when it ends. Only the agent that reported a connection can update it.
+func (q *sqlQuerier) UpsertWorkspaceConnection(ctx context.Context, arg UpsertWorkspaceConnectionParams) (WorkspaceConnection, error) {
+	row := q.db.QueryRowContext(ctx, upsertWorkspaceConnection,
+		arg.ID,
+		arg.WorkspaceID,
+		arg.AgentID,
+		arg.UserID,
+		arg.Type,
+		arg.RemoteAddr,
+		arg.Target,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.RxBytes,
+		arg.TxBytes,
+	)
+	var i WorkspaceConnection
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.UserID,
+		&i.Type,
+		&i.RemoteAddr,
+		&i.Target,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.RxBytes,
+		&i.TxBytes,
+	)
+	return i, err
+}
+
 const getWorkspaceResourceByID = `-- name: GetWorkspaceResourceByID :one
 SELECT
 	id, created_at, job_id, transition, type, name
@@ -4671,9 +6210,31 @@ func (q *sqlQuerier) GetWorkspaceOwnerCountsByTemplateIDs(ctx context.Context, i
 
 const getWorkspaces = `-- name: GetWorkspaces :many
 SELECT
-    id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at
 FROM
-    workspaces
+	workspaces
+LEFT JOIN LATERAL (
+	SELECT
+		workspace_builds.transition,
+		workspace_builds.template_version_id,
+		provisioner_jobs.id AS provisioner_job_id,
+		provisioner_jobs.started_at,
+		provisioner_jobs.canceled_at,
+		provisioner_jobs.completed_at,
+		provisioner_jobs.error
+	FROM
+		workspace_builds
+	LEFT JOIN
+		provisioner_jobs
+	ON
+		provisioner_jobs.id = workspace_builds.job_id
+	WHERE
+		workspace_builds.workspace_id = workspaces.id
+	ORDER BY
+		build_number DESC
+	LIMIT
+		1
+) latest_build ON TRUE
 WHERE
     -- Optionally include deleted workspaces
 	workspaces.deleted = $1
@@ -4709,15 +6270,99 @@ WHERE
 		    name ILIKE '%' || $6 || '%'
 		ELSE true
 	END
+	-- Filter by the status of the latest build. This matches the status
+	-- displayed for workspaces.
+	AND CASE $7 :: text
+		WHEN 'pending' THEN
+			latest_build.provisioner_job_id IS NOT NULL
+			AND latest_build.started_at IS NULL
+			AND latest_build.canceled_at IS NULL
+		WHEN 'failed' THEN
+			latest_build.completed_at IS NOT NULL
+			AND COALESCE(latest_build.error, '') != ''
+		WHEN 'running' THEN
+			latest_build.transition = 'start' :: workspace_transition
+			AND latest_build.completed_at IS NOT NULL
+			AND latest_build.canceled_at IS NULL
+			AND COALESCE(latest_build.error, '') = ''
+		WHEN 'stopped' THEN
+			latest_build.transition = 'stop' :: workspace_transition
+			AND latest_build.completed_at IS NOT NULL
+			AND latest_build.canceled_at IS NULL
+			AND COALESCE(latest_build.error, '') = ''
+		ELSE true
+	END
+	-- Filter by the template version name of the latest build
+	AND CASE
+		WHEN $8 :: text != '' THEN
+			latest_build.template_version_id = ANY(SELECT id FROM template_versions WHERE lower(name) = lower($8))
+		ELSE true
+	END
+	-- Filter by whether the latest build uses the active template version.
+	-- The value is either 'true' or 'false'.
+	AND CASE
+		WHEN $9 :: text != '' THEN
+			(latest_build.template_version_id != (SELECT active_version_id FROM templates WHERE templates.id = workspaces.template_id)) = ($9 = 'true')
+		ELSE true
+	END
+	-- Filter by last_used_at
+	AND CASE
+		WHEN $10 :: timestamptz > '0001-01-01 00:00:00Z' THEN
+			workspaces.last_used_at < $10
+		ELSE true
+	END
+	AND CASE
+		WHEN $11 :: timestamptz > '0001-01-01 00:00:00Z' THEN
+			workspaces.last_used_at >= $11
+		ELSE true
+	END
+	-- Filter by the connection status of the agents in the latest build.
+	-- Workspaces match if any of their agents has the status.
+	AND CASE
+		WHEN $12 :: text != '' THEN
+			EXISTS (
+				SELECT
+					1
+				FROM
+					workspace_resources
+				JOIN
+					workspace_agents
+				ON
+					workspace_agents.resource_id = workspace_resources.id
+				WHERE
+					workspace_resources.job_id = latest_build.provisioner_job_id
+					AND $12 = (
+						CASE
+							WHEN workspace_agents.first_connected_at IS NULL THEN
+								'connecting'
+							WHEN workspace_agents.disconnected_at > workspace_agents.last_connected_at THEN
+								'disconnected'
+							WHEN NOW() - workspace_agents.last_connected_at > INTERVAL '1 second' * $13 :: bigint THEN
+								'disconnected'
+							ELSE
+								'connected'
+						END
+					)
+			)
+		ELSE true
+	END
+
 `
 
 type GetWorkspacesParams struct {
-	Deleted       bool        `db:"deleted" json:"deleted"`
-	OwnerID       uuid.UUID   `db:"owner_id" json:"owner_id"`
-	OwnerUsername string      `db:"owner_username" json:"owner_username"`
-	TemplateName  string      `db:"template_name" json:"template_name"`
-	TemplateIds   []uuid.UUID `db:"template_ids" json:"template_ids"`
-	Name          string      `db:"name" json:"name"`
+	Deleted                               bool        `db:"deleted" json:"deleted"`
+	OwnerID                               uuid.UUID   `db:"owner_id" json:"owner_id"`
+	OwnerUsername                         string      `db:"owner_username" json:"owner_username"`
+	TemplateName                          string      `db:"template_name" json:"template_name"`
+	TemplateIds                           []uuid.UUID `db:"template_ids" json:"template_ids"`
+	Name                                  string      `db:"name" json:"name"`
+	Status                                string      `db:"status" json:"status"`
+	TemplateVersion                       string      `db:"template_version" json:"template_version"`
+	Outdated                              string      `db:"outdated" json:"outdated"`
+	LastUsedBefore                        time.Time   `db:"last_used_before" json:"last_used_before"`
+	LastUsedAfter                         time.Time   `db:"last_used_after" json:"last_used_after"`
+	HasAgent                              string      `db:"has_agent" json:"has_agent"`
+	AgentInactiveDisconnectTimeoutSeconds int64       `db:"agent_inactive_disconnect_timeout_seconds" json:"agent_inactive_disconnect_timeout_seconds"`
 }
 
 func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams) ([]Workspace, error) {
@@ -4728,6 +6373,13 @@ func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams)
 		arg.TemplateName,
 		pq.Array(arg.TemplateIds),
 		arg.Name,
+		arg.Status,
+		arg.TemplateVersion,
+		arg.Outdated,
+		arg.LastUsedBefore,
+		arg.LastUsedAfter,
+		arg.HasAgent,
+		arg.AgentInactiveDisconnectTimeoutSeconds,
 	)
 	if err != nil {
 		return nil, err
@@ -4930,3 +6582,131 @@ func (q *sqlQuerier) UpdateWorkspaceTTL(ctx context.Context, arg UpdateWorkspace
 	_, err := q.db.ExecContext(ctx, updateWorkspaceTTL, arg.ID, arg.Ttl)
 	return err
 }
+
+const getWorkspaceSessionRecordingByID = `-- name: GetWorkspaceSessionRecordingByID :one
+SELECT
+	id, created_at, workspace_id, agent_id, type, started_at, ended_at, size, data
+FROM
+	workspace_session_recordings
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceSessionRecordingByID(ctx context.Context, id uuid.UUID) (WorkspaceSessionRecording, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceSessionRecordingByID, id)
+	var i WorkspaceSessionRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.Type,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Size,
+		&i.Data,
+	)
+	return i, err
+}
+
+const getWorkspaceSessionRecordingsByWorkspaceID = `-- name: GetWorkspaceSessionRecordingsByWorkspaceID :many
+SELECT
+	id, created_at, workspace_id, agent_id, type, started_at, ended_at, size
+FROM
+	workspace_session_recordings
+WHERE
+	workspace_id = $1
+ORDER BY
+	started_at DESC
+`
+
+type GetWorkspaceSessionRecordingsByWorkspaceIDRow struct {
+	ID          uuid.UUID                     `db:"id" json:"id"`
+	CreatedAt   time.Time                     `db:"created_at" json:"created_at"`
+	WorkspaceID uuid.UUID                     `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID                     `db:"agent_id" json:"agent_id"`
+	Type        WorkspaceSessionRecordingType `db:"type" json:"type"`
+	StartedAt   time.Time                     `db:"started_at" json:"started_at"`
+	EndedAt     time.Time                     `db:"ended_at" json:"ended_at"`
+	Size        int64                         `db:"size" json:"size"`
+}
+
+// GetWorkspaceSessionRecordingsByWorkspaceID leaves out the data of the
+// recordings, which are fetched one at a time.
+func (q *sqlQuerier) GetWorkspaceSessionRecordingsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]GetWorkspaceSessionRecordingsByWorkspaceIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceSessionRecordingsByWorkspaceID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkspaceSessionRecordingsByWorkspaceIDRow
+	for rows.Next() {
+		var i GetWorkspaceSessionRecordingsByWorkspaceIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.AgentID,
+			&i.Type,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceSessionRecording = `-- name: InsertWorkspaceSessionRecording :one
+INSERT INTO
+	workspace_session_recordings (id, created_at, workspace_id, agent_id, type, started_at, ended_at, size, data)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, workspace_id, agent_id, type, started_at, ended_at, size, data
+`
+
+type InsertWorkspaceSessionRecordingParams struct {
+	ID          uuid.UUID                     `db:"id" json:"id"`
+	CreatedAt   time.Time                     `db:"created_at" json:"created_at"`
+	WorkspaceID uuid.UUID                     `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID                     `db:"agent_id" json:"agent_id"`
+	Type        WorkspaceSessionRecordingType `db:"type" json:"type"`
+	StartedAt   time.Time                     `db:"started_at" json:"started_at"`
+	EndedAt     time.Time                     `db:"ended_at" json:"ended_at"`
+	Size        int64                         `db:"size" json:"size"`
+	Data        []byte                        `db:"data" json:"data"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceSessionRecording(ctx context.Context, arg InsertWorkspaceSessionRecordingParams) (WorkspaceSessionRecording, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceSessionRecording,
+		arg.ID,
+		arg.CreatedAt,
+		arg.WorkspaceID,
+		arg.AgentID,
+		arg.Type,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.Size,
+		arg.Data,
+	)
+	var i WorkspaceSessionRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.Type,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.Size,
+		&i.Data,
+	)
+	return i, err
+}
@@ -26,3 +26,61 @@ order by
 
 -- name: DeleteOldAgentStats :exec
 DELETE FROM AGENT_STATS WHERE created_at  < now() - interval '30 days';
+
+-- name: InsertWorkspaceAgentResourceStat :one
+INSERT INTO
+	workspace_agent_resource_stats (
+		id,
+		created_at,
+		agent_id,
+		workspace_id,
+		template_id,
+		cpu_used_cores,
+		cpu_total_cores,
+		memory_used_bytes,
+		memory_total_bytes,
+		disk_used_bytes,
+		disk_total_bytes
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *;
+
+-- name: GetWorkspaceAgentResourceStats :many
+SELECT
+	*
+FROM
+	workspace_agent_resource_stats
+WHERE
+	agent_id = $1
+	AND created_at > @created_after
+ORDER BY
+	created_at ASC;
+
+-- name: GetLatestWorkspaceAgentResourceStats :many
+-- Returns the latest sample of each agent of a workspace that isn't deleted,
+-- with the names that label its metrics.
+SELECT
+	DISTINCT ON (workspace_agent_resource_stats.agent_id)
+	workspace_agent_resource_stats.*,
+	workspace_agents.name AS agent_name,
+	workspaces.name AS workspace_name,
+	users.username AS username,
+	templates.name AS template_name
+FROM
+	workspace_agent_resource_stats
+JOIN
+	workspace_agents ON workspace_agents.id = workspace_agent_resource_stats.agent_id
+JOIN
+	workspaces ON workspaces.id = workspace_agent_resource_stats.workspace_id
+JOIN
+	users ON users.id = workspaces.owner_id
+JOIN
+	templates ON templates.id = workspace_agent_resource_stats.template_id
+WHERE
+	workspaces.deleted = false
+	AND workspace_agent_resource_stats.created_at > @created_after
+ORDER BY
+	workspace_agent_resource_stats.agent_id, workspace_agent_resource_stats.created_at DESC;
+
+-- name: DeleteOldWorkspaceAgentResourceStats :exec
+DELETE FROM workspace_agent_resource_stats WHERE created_at < now() - interval '1 day';
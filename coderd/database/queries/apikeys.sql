@@ -11,6 +11,9 @@ LIMIT
 -- name: GetAPIKeysLastUsedAfter :many
 SELECT * FROM api_keys WHERE last_used > $1;
 
+-- name: GetAPIKeysByUserID :many
+SELECT * FROM api_keys WHERE login_type = @login_type AND user_id = @user_id ORDER BY created_at ASC;
+
 -- name: InsertAPIKey :one
 INSERT INTO
 	api_keys (
@@ -23,7 +26,9 @@ INSERT INTO
 		expires_at,
 		created_at,
 		updated_at,
-		login_type
+		login_type,
+		scope,
+		token_name
 	)
 VALUES
 	(@id,
@@ -32,7 +37,7 @@ VALUES
 	     WHEN 0 THEN 86400
 		 ELSE @lifetime_seconds::bigint
 	 END
-	 , @hashed_secret, @ip_address, @user_id, @last_used, @expires_at, @created_at, @updated_at, @login_type) RETURNING *;
+	 , @hashed_secret, @ip_address, @user_id, @last_used, @expires_at, @created_at, @updated_at, @login_type, @scope, @token_name) RETURNING *;
 
 -- name: UpdateAPIKeyByID :exec
 UPDATE
@@ -12,6 +12,100 @@ ORDER BY
 LIMIT
 	sqlc.arg(row_limit);
 
+-- GetAuditLogsOffset returns a page of audit logs matching the filters,
+-- ordered from newest to oldest.
+-- name: GetAuditLogsOffset :many
+SELECT
+	*
+FROM
+	audit_logs
+WHERE
+	-- Filter action
+	CASE
+		WHEN @action :: text != '' THEN
+			action = @action :: audit_action
+		ELSE true
+	END
+	-- Filter resource_type
+	AND CASE
+		WHEN @resource_type :: text != '' THEN
+			resource_type = @resource_type :: resource_type
+		ELSE true
+	END
+	-- Filter by username
+	AND CASE
+		WHEN @username :: text != '' THEN
+			user_id = (SELECT id FROM users WHERE LOWER(username) = LOWER(@username))
+		ELSE true
+	END
+	-- Filter by time range
+	AND CASE
+		WHEN @date_from :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			"time" >= @date_from
+		ELSE true
+	END
+	AND CASE
+		WHEN @date_to :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			"time" < @date_to
+		ELSE true
+	END
+	-- Filter by status code
+	AND CASE
+		WHEN @status_code :: int != 0 THEN
+			status_code = @status_code
+		ELSE true
+	END
+ORDER BY
+	"time" DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF(@limit_opt :: int, 0)
+OFFSET
+	@offset_opt;
+
+-- GetAuditLogCount returns the number of audit logs matching the filters.
+-- name: GetAuditLogCount :one
+SELECT
+	COUNT(*)
+FROM
+	audit_logs
+WHERE
+	-- Filter action
+	CASE
+		WHEN @action :: text != '' THEN
+			action = @action :: audit_action
+		ELSE true
+	END
+	-- Filter resource_type
+	AND CASE
+		WHEN @resource_type :: text != '' THEN
+			resource_type = @resource_type :: resource_type
+		ELSE true
+	END
+	-- Filter by username
+	AND CASE
+		WHEN @username :: text != '' THEN
+			user_id = (SELECT id FROM users WHERE LOWER(username) = LOWER(@username))
+		ELSE true
+	END
+	-- Filter by time range
+	AND CASE
+		WHEN @date_from :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			"time" >= @date_from
+		ELSE true
+	END
+	AND CASE
+		WHEN @date_to :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			"time" < @date_to
+		ELSE true
+	END
+	-- Filter by status code
+	AND CASE
+		WHEN @status_code :: int != 0 THEN
+			status_code = @status_code
+		ELSE true
+	END;
+
 -- name: InsertAuditLog :one
 INSERT INTO
 	audit_logs (
@@ -30,7 +30,8 @@ INSERT INTO
 		validation_condition,
 		validation_type_system,
 		validation_value_type,
-		index
+		index,
+		mutable
 	)
 VALUES
 	(
@@ -50,5 +51,6 @@ VALUES
 		$14,
 		$15,
 		$16,
-		$17
+		$17,
+		$18
 	) RETURNING *;
@@ -1,6 +1,9 @@
 -- Acquires the lock for a single job that isn't started, completed,
 -- canceled, and that matches an array of provisioner types.
 --
+-- A job is only acquired if its tags are a subset of the tags
+-- the provisioner daemon was started with.
+--
 -- SKIP LOCKED is used to jump over locked rows. This prevents
 -- multiple provisioners from acquiring the same jobs. See:
 -- https://www.postgresql.org/docs/9.5/sql-select.html#SQL-FOR-UPDATE-SHARE
@@ -22,6 +25,7 @@ WHERE
 			AND nested.canceled_at IS NULL
 			AND nested.completed_at IS NULL
 			AND nested.provisioner = ANY(@types :: provisioner_type [ ])
+			AND nested.tags <@ @tags :: jsonb
 		ORDER BY
 			nested.created_at FOR
 		UPDATE
@@ -30,6 +34,17 @@ WHERE
 			1
 	) RETURNING *;
 
+-- Active jobs are pending or running.
+-- name: GetActiveProvisionerJobs :many
+SELECT
+	*
+FROM
+	provisioner_jobs
+WHERE
+	completed_at IS NULL
+ORDER BY
+	created_at;
+
 -- name: GetProvisionerJobByID :one
 SELECT
 	*
@@ -38,6 +53,32 @@ FROM
 WHERE
 	id = $1;
 
+-- Queued jobs haven't been acquired by a provisioner daemon yet. Daemons
+-- acquire them in the order they were created, and only jobs of their
+-- provisioner type and tags, so a job is queued behind the jobs with the same
+-- provisioner and tags that were created before it.
+-- name: GetProvisionerJobQueuePositionsByIDs :many
+WITH queued_jobs AS (
+	SELECT
+		id,
+		ROW_NUMBER() OVER (PARTITION BY provisioner, tags ORDER BY created_at) AS queue_position,
+		COUNT(*) OVER (PARTITION BY provisioner, tags) AS queue_size
+	FROM
+		provisioner_jobs
+	WHERE
+		started_at IS NULL
+		AND canceled_at IS NULL
+		AND completed_at IS NULL
+)
+SELECT
+	id,
+	queue_position,
+	queue_size
+FROM
+	queued_jobs
+WHERE
+	id = ANY(@ids :: uuid [ ]);
+
 -- name: GetProvisionerJobsByIDs :many
 SELECT
 	*
@@ -49,6 +90,17 @@ WHERE
 -- name: GetProvisionerJobsCreatedAfter :many
 SELECT * FROM provisioner_jobs WHERE created_at > $1;
 
+-- Running jobs have been acquired by a provisioner daemon, but haven't
+-- completed yet. Canceled jobs are running until the daemon fails them.
+-- name: GetRunningProvisionerJobs :many
+SELECT
+	*
+FROM
+	provisioner_jobs
+WHERE
+	started_at IS NOT NULL
+	AND completed_at IS NULL;
+
 -- name: InsertProvisionerJob :one
 INSERT INTO
 	provisioner_jobs (
@@ -61,10 +113,27 @@ INSERT INTO
 		storage_method,
 		storage_source,
 		"type",
-		"input"
+		"input",
+		tags
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *;
+
+-- Puts a running job back in the queue, so a provisioner daemon acquires it
+-- again. Jobs that were canceled or completed in the meantime aren't
+-- released.
+-- name: ReleaseProvisionerJob :one
+UPDATE
+	provisioner_jobs
+SET
+	started_at = NULL,
+	updated_at = @updated_at,
+	worker_id = NULL,
+	"input" = @input
+WHERE
+	id = @id
+	AND canceled_at IS NULL
+	AND completed_at IS NULL RETURNING *;
 
 -- name: UpdateProvisionerJobByID :exec
 UPDATE
@@ -74,13 +143,16 @@ SET
 WHERE
 	id = $1;
 
--- name: UpdateProvisionerJobWithCancelByID :exec
+-- Jobs that no provisioner daemon has acquired yet are completed as they're
+-- canceled, since there's no daemon to finish them.
+-- name: UpdateProvisionerJobWithCancelByID :one
 UPDATE
 	provisioner_jobs
 SET
-	canceled_at = $2
+	canceled_at = $2,
+	completed_at = CASE WHEN started_at IS NULL THEN $2 ELSE completed_at END
 WHERE
-	id = $1;
+	id = $1 RETURNING *;
 
 -- name: UpdateProvisionerJobWithCompleteByID :exec
 UPDATE
@@ -85,6 +85,15 @@ SET
 WHERE
 	id = $1;
 
+-- name: UpdateTemplateVersionGitCommitSHAByJobID :exec
+UPDATE
+	template_versions
+SET
+	git_commit_sha = $2,
+	updated_at = $3
+WHERE
+	job_id = $1;
+
 -- name: UpdateTemplateVersionDescriptionByJobID :exec
 UPDATE
 	template_versions
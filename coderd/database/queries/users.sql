@@ -161,7 +161,28 @@ SELECT
 			array_append(users.rbac_roles, 'member'),
 		-- All org_members get the org-member role for their orgs
 			array_append(organization_members.roles, 'organization-member:'||organization_members.organization_id::text)) :: text[]
-		AS roles
+		AS roles,
+	array_cat(
+		-- All groups the user is explicitly a member of
+		(
+			SELECT
+				array_agg(group_members.group_id :: text)
+			FROM
+				group_members
+			WHERE
+				group_members.user_id = users.id
+		),
+		-- All org_members are in the "Everyone" group of their orgs, which
+		-- shares the organization's ID
+		(
+			SELECT
+				array_agg(org_members.organization_id :: text)
+			FROM
+				organization_members AS org_members
+			WHERE
+				org_members.user_id = users.id
+		)) :: text[]
+		AS groups
 FROM
 	users
 LEFT JOIN organization_members
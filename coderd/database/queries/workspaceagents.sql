@@ -26,6 +26,17 @@ WHERE
 ORDER BY
 	created_at DESC;
 
+-- name: GetWorkspaceAgentStartupLogsAfter :many
+SELECT
+	*
+FROM
+	workspace_agent_startup_logs
+WHERE
+	agent_id = $1
+	AND (
+		id > @after_id
+	) ORDER BY id ASC;
+
 -- name: GetWorkspaceAgentsByResourceIDs :many
 SELECT
 	*
@@ -53,10 +64,20 @@ INSERT INTO
 		startup_script,
 		directory,
 		instance_metadata,
-		resource_metadata
+		resource_metadata,
+		shutdown_script
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING *;
+
+-- name: InsertWorkspaceAgentStartupLogs :many
+INSERT INTO
+	workspace_agent_startup_logs (agent_id, created_at, output)
+SELECT
+	@agent_id :: uuid AS agent_id,
+	unnest(@created_at :: timestamptz [ ]) AS created_at,
+	unnest(@output :: VARCHAR(1024) [ ]) AS output
+RETURNING workspace_agent_startup_logs.*;
 
 -- name: UpdateWorkspaceAgentConnectionByID :exec
 UPDATE
@@ -69,6 +90,14 @@ SET
 WHERE
 	id = $1;
 
+-- name: UpdateWorkspaceAgentLifecycleStateByID :exec
+UPDATE
+	workspace_agents
+SET
+	lifecycle_state = $2
+WHERE
+	id = $1;
+
 -- name: UpdateWorkspaceAgentVersionByID :exec
 UPDATE
 	workspace_agents
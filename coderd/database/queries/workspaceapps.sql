@@ -20,7 +20,8 @@ INSERT INTO
         icon,
         command,
         url,
-        relative_path
+        relative_path,
+        sharing_level
     )
 VALUES
-    ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
@@ -0,0 +1,15 @@
+-- name: InsertWorkspaceBuildParameter :exec
+INSERT INTO
+	workspace_build_parameters (workspace_build_id, "name", value)
+VALUES
+	($1, $2, $3);
+
+-- name: GetWorkspaceBuildParameters :many
+SELECT
+	*
+FROM
+	workspace_build_parameters
+WHERE
+	workspace_build_id = $1
+ORDER BY
+	"name";
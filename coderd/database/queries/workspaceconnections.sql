@@ -0,0 +1,108 @@
+-- UpsertWorkspaceConnection records a connection when it starts and updates
+-- it when it ends. Only the agent that reported a connection can update it.
+-- name: UpsertWorkspaceConnection :one
+INSERT INTO
+	workspace_connections (id, workspace_id, agent_id, user_id, type, remote_addr, target, started_at, ended_at, rx_bytes, tx_bytes)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (id) DO UPDATE SET
+	ended_at = EXCLUDED.ended_at,
+	rx_bytes = EXCLUDED.rx_bytes,
+	tx_bytes = EXCLUDED.tx_bytes
+WHERE
+	workspace_connections.agent_id = EXCLUDED.agent_id
+RETURNING *;
+
+-- GetWorkspaceConnectionsOffset returns a page of connections matching the
+-- filters, ordered from newest to oldest.
+-- name: GetWorkspaceConnectionsOffset :many
+SELECT
+	workspace_connections.*,
+	COALESCE(users.username, '') AS username,
+	workspaces.name AS workspace_name,
+	workspace_agents.name AS agent_name
+FROM
+	workspace_connections
+LEFT JOIN
+	users ON users.id = workspace_connections.user_id
+JOIN
+	workspaces ON workspaces.id = workspace_connections.workspace_id
+JOIN
+	workspace_agents ON workspace_agents.id = workspace_connections.agent_id
+WHERE
+	-- Filter by workspace
+	CASE
+		WHEN @workspace_id :: uuid != '00000000-00000000-00000000-00000000' THEN
+			workspace_connections.workspace_id = @workspace_id
+		ELSE true
+	END
+	-- Filter by username
+	AND CASE
+		WHEN @username :: text != '' THEN
+			LOWER(users.username) = LOWER(@username)
+		ELSE true
+	END
+	-- Filter by type
+	AND CASE
+		WHEN @type :: text != '' THEN
+			workspace_connections.type = @type :: workspace_connection_type
+		ELSE true
+	END
+	-- Filter by time range
+	AND CASE
+		WHEN @date_from :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			workspace_connections.started_at >= @date_from
+		ELSE true
+	END
+	AND CASE
+		WHEN @date_to :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			workspace_connections.started_at < @date_to
+		ELSE true
+	END
+ORDER BY
+	workspace_connections.started_at DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF(@limit_opt :: int, 0)
+OFFSET
+	@offset_opt;
+
+-- GetWorkspaceConnectionCount returns the number of connections matching the
+-- filters.
+-- name: GetWorkspaceConnectionCount :one
+SELECT
+	COUNT(*)
+FROM
+	workspace_connections
+LEFT JOIN
+	users ON users.id = workspace_connections.user_id
+WHERE
+	-- Filter by workspace
+	CASE
+		WHEN @workspace_id :: uuid != '00000000-00000000-00000000-00000000' THEN
+			workspace_connections.workspace_id = @workspace_id
+		ELSE true
+	END
+	-- Filter by username
+	AND CASE
+		WHEN @username :: text != '' THEN
+			LOWER(users.username) = LOWER(@username)
+		ELSE true
+	END
+	-- Filter by type
+	AND CASE
+		WHEN @type :: text != '' THEN
+			workspace_connections.type = @type :: workspace_connection_type
+		ELSE true
+	END
+	-- Filter by time range
+	AND CASE
+		WHEN @date_from :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			workspace_connections.started_at >= @date_from
+		ELSE true
+	END
+	AND CASE
+		WHEN @date_to :: timestamp with time zone != '0001-01-01 00:00:00Z' THEN
+			workspace_connections.started_at < @date_to
+		ELSE true
+	END;
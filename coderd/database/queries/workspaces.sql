@@ -10,9 +10,31 @@ LIMIT
 
 -- name: GetWorkspaces :many
 SELECT
-    *
+	workspaces.*
 FROM
-    workspaces
+	workspaces
+LEFT JOIN LATERAL (
+	SELECT
+		workspace_builds.transition,
+		workspace_builds.template_version_id,
+		provisioner_jobs.id AS provisioner_job_id,
+		provisioner_jobs.started_at,
+		provisioner_jobs.canceled_at,
+		provisioner_jobs.completed_at,
+		provisioner_jobs.error
+	FROM
+		workspace_builds
+	LEFT JOIN
+		provisioner_jobs
+	ON
+		provisioner_jobs.id = workspace_builds.job_id
+	WHERE
+		workspace_builds.workspace_id = workspaces.id
+	ORDER BY
+		build_number DESC
+	LIMIT
+		1
+) latest_build ON TRUE
 WHERE
     -- Optionally include deleted workspaces
 	workspaces.deleted = @deleted
@@ -48,6 +70,82 @@ WHERE
 		    name ILIKE '%' || @name || '%'
 		ELSE true
 	END
+	-- Filter by the status of the latest build. This matches the status
+	-- displayed for workspaces.
+	AND CASE @status :: text
+		WHEN 'pending' THEN
+			latest_build.provisioner_job_id IS NOT NULL
+			AND latest_build.started_at IS NULL
+			AND latest_build.canceled_at IS NULL
+		WHEN 'failed' THEN
+			latest_build.completed_at IS NOT NULL
+			AND COALESCE(latest_build.error, '') != ''
+		WHEN 'running' THEN
+			latest_build.transition = 'start' :: workspace_transition
+			AND latest_build.completed_at IS NOT NULL
+			AND latest_build.canceled_at IS NULL
+			AND COALESCE(latest_build.error, '') = ''
+		WHEN 'stopped' THEN
+			latest_build.transition = 'stop' :: workspace_transition
+			AND latest_build.completed_at IS NOT NULL
+			AND latest_build.canceled_at IS NULL
+			AND COALESCE(latest_build.error, '') = ''
+		ELSE true
+	END
+	-- Filter by the template version name of the latest build
+	AND CASE
+		WHEN @template_version :: text != '' THEN
+			latest_build.template_version_id = ANY(SELECT id FROM template_versions WHERE lower(name) = lower(@template_version))
+		ELSE true
+	END
+	-- Filter by whether the latest build uses the active template version.
+	-- The value is either 'true' or 'false'.
+	AND CASE
+		WHEN @outdated :: text != '' THEN
+			(latest_build.template_version_id != (SELECT active_version_id FROM templates WHERE templates.id = workspaces.template_id)) = (@outdated = 'true')
+		ELSE true
+	END
+	-- Filter by last_used_at
+	AND CASE
+		WHEN @last_used_before :: timestamptz > '0001-01-01 00:00:00Z' THEN
+			workspaces.last_used_at < @last_used_before
+		ELSE true
+	END
+	AND CASE
+		WHEN @last_used_after :: timestamptz > '0001-01-01 00:00:00Z' THEN
+			workspaces.last_used_at >= @last_used_after
+		ELSE true
+	END
+	-- Filter by the connection status of the agents in the latest build.
+	-- Workspaces match if any of their agents has the status.
+	AND CASE
+		WHEN @has_agent :: text != '' THEN
+			EXISTS (
+				SELECT
+					1
+				FROM
+					workspace_resources
+				JOIN
+					workspace_agents
+				ON
+					workspace_agents.resource_id = workspace_resources.id
+				WHERE
+					workspace_resources.job_id = latest_build.provisioner_job_id
+					AND @has_agent = (
+						CASE
+							WHEN workspace_agents.first_connected_at IS NULL THEN
+								'connecting'
+							WHEN workspace_agents.disconnected_at > workspace_agents.last_connected_at THEN
+								'disconnected'
+							WHEN NOW() - workspace_agents.last_connected_at > INTERVAL '1 second' * @agent_inactive_disconnect_timeout_seconds :: bigint THEN
+								'disconnected'
+							ELSE
+								'connected'
+						END
+					)
+			)
+		ELSE true
+	END
 ;
 
 -- name: GetWorkspaceByOwnerIDAndName :one
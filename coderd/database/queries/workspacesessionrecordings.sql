@@ -0,0 +1,25 @@
+-- name: InsertWorkspaceSessionRecording :one
+INSERT INTO
+	workspace_session_recordings (id, created_at, workspace_id, agent_id, type, started_at, ended_at, size, data)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
+
+-- name: GetWorkspaceSessionRecordingByID :one
+SELECT
+	*
+FROM
+	workspace_session_recordings
+WHERE
+	id = $1;
+
+-- GetWorkspaceSessionRecordingsByWorkspaceID leaves out the data of the
+-- recordings, which are fetched one at a time.
+-- name: GetWorkspaceSessionRecordingsByWorkspaceID :many
+SELECT
+	id, created_at, workspace_id, agent_id, type, started_at, ended_at, size
+FROM
+	workspace_session_recordings
+WHERE
+	workspace_id = $1
+ORDER BY
+	started_at DESC;
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/rbac"
+)
+
+// StringMap is a map[string]string stored as a JSON object in
+// a jsonb column.
+type StringMap map[string]string
+
+func (m *StringMap) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	switch src := src.(type) {
+	case []byte:
+		err := json.Unmarshal(src, m)
+		if err != nil {
+			return err
+		}
+	case string:
+		err := json.Unmarshal([]byte(src), m)
+		if err != nil {
+			return err
+		}
+	default:
+		return xerrors.Errorf("unsupported scan type for StringMap: %T", src)
+	}
+	return nil
+}
+
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
+
+// TemplateACL is a map of user or group IDs to the RBAC actions they are
+// granted on a template. It is stored as a JSON object in a jsonb column.
+type TemplateACL map[string][]rbac.Action
+
+func (t *TemplateACL) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case []byte:
+		return json.Unmarshal(v, t)
+	}
+
+	return xerrors.Errorf("unexpected type %T", src)
+}
+
+func (t TemplateACL) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(t)
+}
@@ -0,0 +1,290 @@
+package coderd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+func (api *API) postGroupByOrganization(rw http.ResponseWriter, r *http.Request) {
+	org := httpmw.OrganizationParam(r)
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceGroup.InOrg(org.ID)) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	var req codersdk.CreateGroupRequest
+	if !httpapi.Read(rw, r, &req) {
+		return
+	}
+
+	if req.Name == database.AllUsersGroup {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("%q is a reserved keyword and cannot be used for a group name.", database.AllUsersGroup),
+		})
+		return
+	}
+
+	group, err := api.Database.InsertGroup(r.Context(), database.InsertGroupParams{
+		ID:             uuid.New(),
+		Name:           req.Name,
+		OrganizationID: org.ID,
+	})
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Group with name %q already exists.", req.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating group.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(rw, http.StatusCreated, convertGroup(group, nil))
+}
+
+func (api *API) patchGroup(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	group := httpmw.GroupParam(r)
+	if !api.Authorize(r, rbac.ActionUpdate, group) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.PatchGroupRequest
+	if !httpapi.Read(rw, r, &req) {
+		return
+	}
+
+	// Membership of the "Everyone" group is implied by organization
+	// membership, so it cannot be renamed or modified.
+	if group.ID == group.OrganizationID {
+		httpapi.Write(rw, http.StatusForbidden, codersdk.Response{
+			Message: fmt.Sprintf("Cannot modify the %q group.", database.AllUsersGroup),
+		})
+		return
+	}
+
+	if req.Name == database.AllUsersGroup {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("%q is a reserved group name.", database.AllUsersGroup),
+		})
+		return
+	}
+
+	users := make([]string, 0, len(req.AddUsers)+len(req.RemoveUsers))
+	users = append(users, req.AddUsers...)
+	users = append(users, req.RemoveUsers...)
+
+	for _, id := range users {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("ID %q must be a valid user UUID.", id),
+			})
+			return
+		}
+		// TODO: It would be nice to enforce this at the schema level
+		// but unfortunately our org_members table does not have an ID.
+		_, err = api.Database.GetOrganizationMemberByUserID(ctx, database.GetOrganizationMemberByUserIDParams{
+			OrganizationID: group.OrganizationID,
+			UserID:         userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			httpapi.Write(rw, http.StatusPreconditionFailed, codersdk.Response{
+				Message: fmt.Sprintf("User %q must be a member of organization %q", id, group.OrganizationID),
+			})
+			return
+		}
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching organization member.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	err := api.Database.InTx(func(tx database.Store) error {
+		var err error
+		if req.Name != "" && req.Name != group.Name {
+			group, err = tx.UpdateGroupByID(ctx, database.UpdateGroupByIDParams{
+				ID:   group.ID,
+				Name: req.Name,
+			})
+			if err != nil {
+				return xerrors.Errorf("update group by ID: %w", err)
+			}
+		}
+
+		for _, id := range req.AddUsers {
+			err := tx.InsertGroupMember(ctx, database.InsertGroupMemberParams{
+				GroupID: group.ID,
+				UserID:  uuid.MustParse(id),
+			})
+			if database.IsUniqueViolation(err, database.UniqueGroupMembersUserIDGroupIDKey) {
+				// The user is already a member.
+				continue
+			}
+			if err != nil {
+				return xerrors.Errorf("insert group member %q: %w", id, err)
+			}
+		}
+		for _, id := range req.RemoveUsers {
+			err := tx.DeleteGroupMember(ctx, database.DeleteGroupMemberParams{
+				GroupID: group.ID,
+				UserID:  uuid.MustParse(id),
+			})
+			if err != nil {
+				return xerrors.Errorf("delete group member %q: %w", id, err)
+			}
+		}
+		return nil
+	})
+	if database.IsUniqueViolation(err, database.UniqueGroupsNameOrganizationIDKey) {
+		httpapi.Write(rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A group named %q already exists.", req.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating group.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	members, err := api.Database.GetGroupMembers(ctx, group.ID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching group members.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(rw, http.StatusOK, convertGroup(group, members))
+}
+
+func (api *API) deleteGroup(rw http.ResponseWriter, r *http.Request) {
+	group := httpmw.GroupParam(r)
+	if !api.Authorize(r, rbac.ActionDelete, group) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	if group.ID == group.OrganizationID {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("%q is a reserved group and cannot be deleted.", database.AllUsersGroup),
+		})
+		return
+	}
+
+	err := api.Database.DeleteGroupByID(r.Context(), group.ID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting group.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(rw, http.StatusOK, codersdk.Response{
+		Message: "Successfully deleted group!",
+	})
+}
+
+func (api *API) group(rw http.ResponseWriter, r *http.Request) {
+	group := httpmw.GroupParam(r)
+	if !api.Authorize(r, rbac.ActionRead, group) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	members, err := api.Database.GetGroupMembers(r.Context(), group.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching group members.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(rw, http.StatusOK, convertGroup(group, members))
+}
+
+func (api *API) groupsByOrganization(rw http.ResponseWriter, r *http.Request) {
+	org := httpmw.OrganizationParam(r)
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceGroup.InOrg(org.ID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	groups, err := api.Database.GetGroupsByOrganizationID(r.Context(), org.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching groups.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Filter groups based on rbac permissions
+	groups, err = AuthorizeFilter(api.httpAuth, r, rbac.ActionRead, groups)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching groups.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resp := make([]codersdk.Group, 0, len(groups))
+	for _, group := range groups {
+		members, err := api.Database.GetGroupMembers(r.Context(), group.ID)
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching group members.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+
+		resp = append(resp, convertGroup(group, members))
+	}
+
+	httpapi.Write(rw, http.StatusOK, resp)
+}
+
+func convertGroup(g database.Group, users []database.User) codersdk.Group {
+	// It's ridiculous to query all the orgs of a user here
+	// especially since as of the writing of this comment there
+	// is only one org. So we pretend everyone is only part of
+	// the group's organization.
+	orgs := make(map[uuid.UUID][]uuid.UUID)
+	for _, user := range users {
+		orgs[user.ID] = []uuid.UUID{g.OrganizationID}
+	}
+
+	return codersdk.Group{
+		ID:             g.ID,
+		Name:           g.Name,
+		OrganizationID: g.OrganizationID,
+		Members:        convertUsers(users, orgs),
+	}
+}
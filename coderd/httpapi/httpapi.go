@@ -37,7 +37,7 @@ func init() {
 		}
 		return UsernameValid(str)
 	}
-	for _, tag := range []string{"username", "template_name", "workspace_name"} {
+	for _, tag := range []string{"username", "template_name", "workspace_name", "token_name"} {
 		err := validate.RegisterValidation(tag, nameValidator)
 		if err != nil {
 			panic(err)
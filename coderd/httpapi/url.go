@@ -0,0 +1,98 @@
+package httpapi
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// appURLSeparator separates the parts of a subdomain application URL.
+// Usernames and workspace names cannot contain consecutive hyphens, so
+// this is safe to split on.
+const appURLSeparator = "--"
+
+// ApplicationURL is a parsed subdomain application URL, formatted as:
+// app--agent--workspace--user
+type ApplicationURL struct {
+	AppName       string
+	AgentName     string
+	WorkspaceName string
+	Username      string
+}
+
+// String returns the subdomain for the application URL. It does not
+// include the wildcard hostname.
+func (a ApplicationURL) String() string {
+	return strings.Join([]string{a.AppName, a.AgentName, a.WorkspaceName, a.Username}, appURLSeparator)
+}
+
+// ParseSubdomainAppURL parses an application from the subdomain of a
+// hostname. The subdomain must be formatted as app--agent--workspace--user.
+func ParseSubdomainAppURL(subdomain string) (ApplicationURL, error) {
+	parts := strings.Split(subdomain, appURLSeparator)
+	if len(parts) != 4 {
+		return ApplicationURL{}, xerrors.Errorf("invalid application url %q, must be formatted as app%[2]sagent%[2]sworkspace%[2]suser", subdomain, appURLSeparator)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return ApplicationURL{}, xerrors.Errorf("invalid application url %q, all parts must be non-empty", subdomain)
+		}
+	}
+	return ApplicationURL{
+		AppName:       parts[0],
+		AgentName:     parts[1],
+		WorkspaceName: parts[2],
+		Username:      parts[3],
+	}, nil
+}
+
+// ValidateWildcardHostname ensures the hostname is a wildcard pattern
+// usable for subdomain applications, e.g. "*.coder.example.com".
+func ValidateWildcardHostname(pattern string) error {
+	if !strings.HasPrefix(pattern, "*.") {
+		return xerrors.Errorf("wildcard hostname %q must start with \"*.\"", pattern)
+	}
+	base := strings.TrimPrefix(pattern, "*.")
+	if base == "" || strings.Contains(base, "*") {
+		return xerrors.Errorf("wildcard hostname %q must contain a single leading wildcard", pattern)
+	}
+	if strings.ContainsAny(base, "/?#@") {
+		return xerrors.Errorf("wildcard hostname %q must be a hostname, not a URL", pattern)
+	}
+	return nil
+}
+
+// ExecuteHostnamePattern matches a hostname against a wildcard pattern
+// (e.g. "*.coder.example.com") and returns the subdomain that replaced
+// the wildcard. If the pattern does not include a port, any port on the
+// hostname is ignored.
+func ExecuteHostnamePattern(pattern, hostname string) (string, bool) {
+	if ValidateWildcardHostname(pattern) != nil {
+		return "", false
+	}
+	pattern = strings.ToLower(pattern)
+	hostname = strings.ToLower(hostname)
+	if _, _, err := net.SplitHostPort(pattern); err != nil {
+		if host, _, err := net.SplitHostPort(hostname); err == nil {
+			hostname = host
+		}
+	}
+	suffix := strings.TrimPrefix(pattern, "*")
+	if !strings.HasSuffix(hostname, suffix) {
+		return "", false
+	}
+	subdomain := strings.TrimSuffix(hostname, suffix)
+	// The wildcard only matches a single label.
+	if subdomain == "" || strings.Contains(subdomain, ".") {
+		return "", false
+	}
+	return subdomain, true
+}
+
+// SubdomainAppHost returns the hostname that serves the application
+// using the wildcard pattern.
+func SubdomainAppHost(pattern string, app ApplicationURL) string {
+	return fmt.Sprintf("%s%s", app.String(), strings.TrimPrefix(pattern, "*"))
+}
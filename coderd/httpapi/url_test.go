@@ -0,0 +1,88 @@
+package httpapi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/httpapi"
+)
+
+func TestParseSubdomainAppURL(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name      string
+		Subdomain string
+		Expected  httpapi.ApplicationURL
+		ExpectErr bool
+	}{
+		{
+			Name:      "Valid",
+			Subdomain: "code-server--main--dev--kyle",
+			Expected: httpapi.ApplicationURL{
+				AppName:       "code-server",
+				AgentName:     "main",
+				WorkspaceName: "dev",
+				Username:      "kyle",
+			},
+		},
+		{Name: "TooFewParts", Subdomain: "app--agent--workspace", ExpectErr: true},
+		{Name: "TooManyParts", Subdomain: "app--agent--workspace--user--extra", ExpectErr: true},
+		{Name: "EmptyPart", Subdomain: "app----workspace--user", ExpectErr: true},
+		{Name: "Empty", Subdomain: "", ExpectErr: true},
+	}
+	for _, c := range testCases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			app, err := httpapi.ParseSubdomainAppURL(c.Subdomain)
+			if c.ExpectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.Expected, app)
+			require.Equal(t, c.Subdomain, app.String())
+		})
+	}
+}
+
+func TestExecuteHostnamePattern(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name      string
+		Pattern   string
+		Hostname  string
+		Subdomain string
+		Match     bool
+	}{
+		{"Match", "*.coder.com", "app--a--w--u.coder.com", "app--a--w--u", true},
+		{"MatchIgnoresPort", "*.coder.com", "app--a--w--u.coder.com:8080", "app--a--w--u", true},
+		{"MatchWithPort", "*.coder.com:8080", "app--a--w--u.coder.com:8080", "app--a--w--u", true},
+		{"MismatchedPort", "*.coder.com:8080", "app--a--w--u.coder.com:9090", "", false},
+		{"CaseInsensitive", "*.Coder.com", "APP--a--w--u.coder.COM", "app--a--w--u", true},
+		{"AccessURL", "*.coder.com", "coder.com", "", false},
+		{"OtherDomain", "*.coder.com", "app.example.com", "", false},
+		{"MultipleLabels", "*.coder.com", "a.b.coder.com", "", false},
+		{"InvalidPattern", "coder.com", "app.coder.com", "", false},
+	}
+	for _, c := range testCases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			subdomain, ok := httpapi.ExecuteHostnamePattern(c.Pattern, c.Hostname)
+			require.Equal(t, c.Match, ok)
+			require.Equal(t, c.Subdomain, subdomain)
+		})
+	}
+}
+
+func TestValidateWildcardHostname(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, httpapi.ValidateWildcardHostname("*.coder.com"))
+	require.NoError(t, httpapi.ValidateWildcardHostname("*.coder.com:8080"))
+	require.Error(t, httpapi.ValidateWildcardHostname("coder.com"))
+	require.Error(t, httpapi.ValidateWildcardHostname("*."))
+	require.Error(t, httpapi.ValidateWildcardHostname("*.*.coder.com"))
+	require.Error(t, httpapi.ValidateWildcardHostname("*.coder.com/path"))
+}
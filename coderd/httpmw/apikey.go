@@ -33,6 +33,13 @@ func APIKey(r *http.Request) database.APIKey {
 	return apiKey
 }
 
+// APIKeyOptional may return an API key from the ExtractAPIKeyOptional
+// handler.
+func APIKeyOptional(r *http.Request) (database.APIKey, bool) {
+	apiKey, ok := r.Context().Value(apiKeyContextKey{}).(database.APIKey)
+	return apiKey, ok
+}
+
 // User roles are the 'subject' field of Authorize()
 type userRolesKey struct{}
 
@@ -60,15 +67,42 @@ const (
 
 // ExtractAPIKey requires authentication using a valid API key.
 // It handles extending an API key if it comes close to expiry,
-// updating the last used time in the database.
+// updating the last used time in the database. API keys that are
+// scoped to connecting to workspace applications are rejected.
 // nolint:revive
 func ExtractAPIKey(db database.Store, oauth *OAuth2Configs, redirectToLogin bool) func(http.Handler) http.Handler {
+	return extractAPIKey(db, oauth, redirectToLogin, false, false)
+}
+
+// ExtractAPIKeyApplicationConnect behaves like ExtractAPIKey, but also
+// accepts API keys with the application_connect scope. It must only
+// be used for routes that proxy to workspace applications.
+// nolint:revive
+func ExtractAPIKeyApplicationConnect(db database.Store, oauth *OAuth2Configs, redirectToLogin bool) func(http.Handler) http.Handler {
+	return extractAPIKey(db, oauth, redirectToLogin, false, true)
+}
+
+// ExtractAPIKeyOptional behaves like ExtractAPIKeyApplicationConnect,
+// but continues without an API key in the context if the request is
+// unauthenticated. Use APIKeyOptional to check whether a key was provided.
+func ExtractAPIKeyOptional(db database.Store, oauth *OAuth2Configs) func(http.Handler) http.Handler {
+	return extractAPIKey(db, oauth, false, true, true)
+}
+
+// nolint:revive
+func extractAPIKey(db database.Store, oauth *OAuth2Configs, redirectToLogin, optional, allowApplicationConnect bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			// Write wraps writing a response to redirect if the handler
 			// specified it should. This redirect is used for user-facing
 			// pages like workspace applications.
 			write := func(code int, response codersdk.Response) {
+				if optional && code == http.StatusUnauthorized {
+					// An invalid or missing API key is treated as an
+					// anonymous request.
+					next.ServeHTTP(rw, r)
+					return
+				}
 				if redirectToLogin {
 					q := r.URL.Query()
 					q.Add("message", response.Message)
@@ -151,7 +185,8 @@ func ExtractAPIKey(db database.Store, oauth *OAuth2Configs, redirectToLogin bool
 			changed := false
 
 			var link database.UserLink
-			if key.LoginType != database.LoginTypePassword {
+			// Only OAuth logins are associated with a user link.
+			if key.LoginType == database.LoginTypeGithub || key.LoginType == database.LoginTypeOIDC {
 				link, err = db.GetUserLinkByUserIDLoginType(r.Context(), database.GetUserLinkByUserIDLoginTypeParams{
 					UserID:    key.UserID,
 					LoginType: key.LoginType,
@@ -208,6 +243,13 @@ func ExtractAPIKey(db database.Store, oauth *OAuth2Configs, redirectToLogin bool
 				return
 			}
 
+			if key.Scope == database.APIKeyScopeApplicationConnect && !allowApplicationConnect {
+				write(http.StatusForbidden, codersdk.Response{
+					Message: fmt.Sprintf("API key with scope %q can only be used to connect to workspace applications.", key.Scope),
+				})
+				return
+			}
+
 			// Only update LastUsed once an hour to prevent database spam.
 			if now.Sub(key.LastUsed) > time.Hour {
 				key.LastUsed = now
@@ -227,9 +269,10 @@ func ExtractAPIKey(db database.Store, oauth *OAuth2Configs, redirectToLogin bool
 				changed = true
 			}
 			// Only update the ExpiresAt once an hour to prevent database spam.
-			// We extend the ExpiresAt to reduce re-authentication.
+			// We extend the ExpiresAt to reduce re-authentication. Tokens
+			// have a fixed expiry, so they are never extended.
 			apiKeyLifetime := time.Duration(key.LifetimeSeconds) * time.Second
-			if key.ExpiresAt.Sub(now) <= apiKeyLifetime-time.Hour {
+			if key.LoginType != database.LoginTypeToken && key.ExpiresAt.Sub(now) <= apiKeyLifetime-time.Hour {
 				key.ExpiresAt = now.Add(apiKeyLifetime)
 				changed = true
 			}
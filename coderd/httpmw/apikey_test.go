@@ -67,6 +67,23 @@ func TestAPIKey(t *testing.T) {
 		require.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
 	})
 
+	t.Run("NoCookieOptional", func(t *testing.T) {
+		t.Parallel()
+		var (
+			db = databasefake.New()
+			r  = httptest.NewRequest("GET", "/", nil)
+			rw = httptest.NewRecorder()
+		)
+		httpmw.ExtractAPIKeyOptional(db, nil)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			_, ok := httpmw.APIKeyOptional(r)
+			require.False(t, ok)
+			rw.WriteHeader(http.StatusOK)
+		})).ServeHTTP(rw, r)
+		res := rw.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
 	t.Run("InvalidFormat", func(t *testing.T) {
 		t.Parallel()
 		var (
@@ -438,6 +455,78 @@ func TestAPIKey(t *testing.T) {
 		require.Equal(t, token.Expiry, gotAPIKey.ExpiresAt)
 	})
 
+	t.Run("TokenExpiryNotExtended", func(t *testing.T) {
+		t.Parallel()
+		var (
+			db         = databasefake.New()
+			id, secret = randomAPIKeyParts()
+			hashed     = sha256.Sum256([]byte(secret))
+			r          = httptest.NewRequest("GET", "/", nil)
+			rw         = httptest.NewRecorder()
+			user       = createUser(r.Context(), t, db)
+		)
+		r.AddCookie(&http.Cookie{
+			Name:  codersdk.SessionTokenKey,
+			Value: fmt.Sprintf("%s-%s", id, secret),
+		})
+
+		sentAPIKey, err := db.InsertAPIKey(r.Context(), database.InsertAPIKeyParams{
+			ID:           id,
+			HashedSecret: hashed[:],
+			LastUsed:     database.Now(),
+			ExpiresAt:    database.Now().Add(time.Minute),
+			UserID:       user.ID,
+			LoginType:    database.LoginTypeToken,
+		})
+		require.NoError(t, err)
+		httpmw.ExtractAPIKey(db, nil, false)(successHandler).ServeHTTP(rw, r)
+		res := rw.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		gotAPIKey, err := db.GetAPIKeyByID(r.Context(), id)
+		require.NoError(t, err)
+
+		require.Equal(t, sentAPIKey.ExpiresAt, gotAPIKey.ExpiresAt)
+	})
+
+	t.Run("ApplicationConnectScope", func(t *testing.T) {
+		t.Parallel()
+		var (
+			db         = databasefake.New()
+			id, secret = randomAPIKeyParts()
+			hashed     = sha256.Sum256([]byte(secret))
+			r          = httptest.NewRequest("GET", "/", nil)
+			user       = createUser(r.Context(), t, db)
+		)
+		r.AddCookie(&http.Cookie{
+			Name:  codersdk.SessionTokenKey,
+			Value: fmt.Sprintf("%s-%s", id, secret),
+		})
+
+		_, err := db.InsertAPIKey(r.Context(), database.InsertAPIKeyParams{
+			ID:           id,
+			HashedSecret: hashed[:],
+			ExpiresAt:    database.Now().AddDate(0, 0, 1),
+			UserID:       user.ID,
+			LoginType:    database.LoginTypePassword,
+			Scope:        database.APIKeyScopeApplicationConnect,
+		})
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		httpmw.ExtractAPIKey(db, nil, false)(successHandler).ServeHTTP(rw, r)
+		res := rw.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		rw = httptest.NewRecorder()
+		httpmw.ExtractAPIKeyApplicationConnect(db, nil, false)(successHandler).ServeHTTP(rw, r)
+		res = rw.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
 	t.Run("RemoteIPUpdates", func(t *testing.T) {
 		t.Parallel()
 		var (
@@ -45,7 +45,22 @@ func ExtractTemplateVersionParam(db database.Store) func(http.Handler) http.Hand
 				return
 			}
 
+			// The parent template is needed to authorize access to the
+			// version. Versions are not always attached to a template.
+			var template database.Template
+			if templateVersion.TemplateID.Valid {
+				template, err = db.GetTemplateByID(r.Context(), templateVersion.TemplateID.UUID)
+				if err != nil {
+					httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+						Message: "Internal error fetching template.",
+						Detail:  err.Error(),
+					})
+					return
+				}
+			}
+
 			ctx := context.WithValue(r.Context(), templateVersionParamContextKey{}, templateVersion)
+			ctx = context.WithValue(ctx, templateParamContextKey{}, template)
 			chi.RouteContext(ctx).URLParams.Add("organization", templateVersion.OrganizationID.String())
 			next.ServeHTTP(rw, r.WithContext(ctx))
 		})
@@ -0,0 +1,187 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// runJobReaper fails running provisioner jobs that were orphaned by their
+// provisioner daemon, or that ran for longer than the maximum job duration
+// of their template, until ctx is done.
+//
+// Provisioner daemons heartbeat the jobs they run by calling UpdateJob, so
+// a job that hasn't been updated in a while belongs to a daemon that died
+// or lost its connection. Without the reaper those jobs stay running
+// forever, and their workspaces can't be built again.
+func (api *API) runJobReaper(ctx context.Context) {
+	defer close(api.jobReaperDone)
+
+	ticker := time.NewTicker(api.ProvisionerJobReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := api.reapProvisionerJobs(ctx, database.Now())
+		if err != nil && ctx.Err() == nil {
+			api.Logger.Error(ctx, "reap provisioner jobs", slog.Error(err))
+		}
+	}
+}
+
+// reapProvisionerJobs fails the running provisioner jobs that are orphaned
+// or have exceeded the maximum job duration at now.
+func (api *API) reapProvisionerJobs(ctx context.Context, now time.Time) error {
+	jobs, err := api.Database.GetRunningProvisionerJobs(ctx)
+	if err != nil {
+		return xerrors.Errorf("get running provisioner jobs: %w", err)
+	}
+	for _, job := range jobs {
+		logger := api.Logger.With(slog.F("job_id", job.ID))
+
+		var reason string
+		if now.Sub(job.UpdatedAt) > api.ProvisionerJobHeartbeatTimeout {
+			reason = fmt.Sprintf("Job was orphaned: its provisioner daemon hasn't reported progress in %s.", api.ProvisionerJobHeartbeatTimeout)
+		} else {
+			maxJobDuration, err := api.provisionerJobMaxDuration(ctx, job)
+			if err != nil {
+				logger.Warn(ctx, "get max job duration", slog.Error(err))
+				continue
+			}
+			if maxJobDuration > 0 && now.Sub(job.StartedAt.Time) > maxJobDuration {
+				reason = fmt.Sprintf("Job exceeded the maximum job duration of %s set on the template.", maxJobDuration)
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		logger.Warn(ctx, "failing provisioner job", slog.F("reason", reason))
+		err = api.failProvisionerJob(ctx, job.ID, reason)
+		if err != nil {
+			logger.Error(ctx, "fail provisioner job", slog.Error(err))
+		}
+	}
+	return nil
+}
+
+// provisionerJobMaxDuration returns the maximum job duration of the template
+// a job belongs to. Zero means the job may run for as long as it needs.
+func (api *API) provisionerJobMaxDuration(ctx context.Context, job database.ProvisionerJob) (time.Duration, error) {
+	var templateID uuid.NullUUID
+	switch job.Type {
+	case database.ProvisionerJobTypeWorkspaceBuild:
+		build, err := api.Database.GetWorkspaceBuildByJobID(ctx, job.ID)
+		if err != nil {
+			return 0, xerrors.Errorf("get workspace build: %w", err)
+		}
+		workspace, err := api.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+		if err != nil {
+			return 0, xerrors.Errorf("get workspace: %w", err)
+		}
+		templateID = uuid.NullUUID{UUID: workspace.TemplateID, Valid: true}
+	case database.ProvisionerJobTypeTemplateVersionImport:
+		version, err := api.Database.GetTemplateVersionByJobID(ctx, job.ID)
+		if err != nil {
+			return 0, xerrors.Errorf("get template version: %w", err)
+		}
+		templateID = version.TemplateID
+	case database.ProvisionerJobTypeTemplateVersionDryRun:
+		var input templateVersionDryRunJob
+		err := json.Unmarshal(job.Input, &input)
+		if err != nil {
+			return 0, xerrors.Errorf("unmarshal dry-run input: %w", err)
+		}
+		version, err := api.Database.GetTemplateVersionByID(ctx, input.TemplateVersionID)
+		if err != nil {
+			return 0, xerrors.Errorf("get template version: %w", err)
+		}
+		templateID = version.TemplateID
+	}
+	// Versions that were imported before their template was created
+	// don't have a template yet.
+	if !templateID.Valid {
+		return 0, nil
+	}
+	template, err := api.Database.GetTemplateByID(ctx, templateID.UUID)
+	if err != nil {
+		return 0, xerrors.Errorf("get template: %w", err)
+	}
+	return time.Duration(template.MaxJobDuration), nil
+}
+
+// failProvisionerJob marks a running job as failed with the reason as its
+// error, and writes the reason as the final line of its logs.
+//
+// The provisioner state of workspace builds is left alone. Builds start
+// with the state of the previous build, so the resources it tracks can
+// still be updated or destroyed by the next build.
+func (api *API) failProvisionerJob(ctx context.Context, jobID uuid.UUID, reason string) error {
+	var failed bool
+	err := api.Database.InTx(func(db database.Store) error {
+		// The daemon may have finished the job since it was listed.
+		job, err := db.GetProvisionerJobByID(ctx, jobID)
+		if err != nil {
+			return xerrors.Errorf("get provisioner job: %w", err)
+		}
+		if job.CompletedAt.Valid {
+			return nil
+		}
+		err = db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
+			ID:        jobID,
+			UpdatedAt: database.Now(),
+			CompletedAt: sql.NullTime{
+				Time:  database.Now(),
+				Valid: true,
+			},
+			Error: sql.NullString{
+				String: reason,
+				Valid:  true,
+			},
+		})
+		if err != nil {
+			return xerrors.Errorf("update provisioner job: %w", err)
+		}
+		failed = true
+		return nil
+	})
+	if err != nil || !failed {
+		return err
+	}
+
+	logs, err := api.Database.InsertProvisionerJobLogs(ctx, database.InsertProvisionerJobLogsParams{
+		JobID:     jobID,
+		ID:        []uuid.UUID{uuid.New()},
+		CreatedAt: []time.Time{database.Now()},
+		Source:    []database.LogSource{database.LogSourceProvisionerDaemon},
+		Level:     []database.LogLevel{database.LogLevelError},
+		Stage:     []string{"Cleaning Up"},
+		Output:    []string{reason},
+	})
+	if err != nil {
+		return xerrors.Errorf("insert provisioner job logs: %w", err)
+	}
+	for _, msg := range []provisionerJobLogsMessage{{Logs: logs}, {EndOfLogs: true}} {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return xerrors.Errorf("marshal job log: %w", err)
+		}
+		err = api.Pubsub.Publish(provisionerJobLogsChannel(jobID), data)
+		if err != nil {
+			return xerrors.Errorf("publish job log: %w", err)
+		}
+	}
+	return nil
+}
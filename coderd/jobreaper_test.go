@@ -0,0 +1,101 @@
+package coderd_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisionerd/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestJobReaper(t *testing.T) {
+	t.Parallel()
+	t.Run("Orphaned", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{
+			ProvisionerDaemonPSK:           "psk",
+			ProvisionerJobHeartbeatTimeout: time.Second,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		before := time.Now()
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// The daemon acquires the job and never reports progress, as if it
+		// died while running it.
+		daemon, err := client.ServeProvisionerDaemon(ctx, "", []codersdk.ProvisionerType{codersdk.ProvisionerTypeEcho}, nil, "psk")
+		require.NoError(t, err)
+		job, err := daemon.AcquireJob(ctx, &proto.Empty{})
+		require.NoError(t, err)
+		require.Equal(t, version.Job.ID.String(), job.JobId)
+
+		version = awaitTemplateVersionJobFailed(ctx, t, client, version)
+		require.Contains(t, version.Job.Error, "orphaned")
+
+		logs, err := client.TemplateVersionLogsAfter(ctx, version.ID, before)
+		require.NoError(t, err)
+		var last codersdk.ProvisionerJobLog
+		for log := range logs {
+			last = log
+		}
+		require.Equal(t, codersdk.LogLevelError, last.Level)
+		require.Equal(t, version.Job.Error, last.Output)
+
+		// The daemon is told to stop if it comes back.
+		res, err := daemon.UpdateJob(ctx, &proto.UpdateJobRequest{JobId: job.JobId})
+		require.NoError(t, err)
+		require.True(t, res.Canceled)
+		_, err = daemon.CompleteJob(ctx, &proto.CompletedJob{JobId: job.JobId})
+		require.Error(t, err)
+	})
+
+	t.Run("MaxJobDuration", func(t *testing.T) {
+		t.Parallel()
+		client, closer := coderdtest.NewWithProvisionerCloser(t, &coderdtest.Options{
+			ProvisionerDaemonPSK: "psk",
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		require.NoError(t, closer.Close())
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			MaxJobDurationMillis: 1,
+		})
+		require.NoError(t, err)
+
+		// The daemon keeps reporting progress, but the job takes too long.
+		version = coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+		daemon, err := client.ServeProvisionerDaemon(ctx, "", []codersdk.ProvisionerType{codersdk.ProvisionerTypeEcho}, nil, "psk")
+		require.NoError(t, err)
+		job, err := daemon.AcquireJob(ctx, &proto.Empty{})
+		require.NoError(t, err)
+		require.Equal(t, version.Job.ID.String(), job.JobId)
+
+		version = awaitTemplateVersionJobFailed(ctx, t, client, version)
+		require.Contains(t, version.Job.Error, "maximum job duration")
+	})
+}
+
+func awaitTemplateVersionJobFailed(ctx context.Context, t *testing.T, client *codersdk.Client, version codersdk.TemplateVersion) codersdk.TemplateVersion {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		var err error
+		version, err = client.TemplateVersion(ctx, version.ID)
+		return assert.NoError(t, err) && version.Job.CompletedAt != nil
+	}, testutil.WaitLong, testutil.IntervalFast)
+	require.Equal(t, codersdk.ProvisionerJobFailed, version.Job.Status)
+	return version
+}
@@ -84,6 +84,10 @@ func (c *Cache) refresh(ctx context.Context) error {
 	if err != nil {
 		return xerrors.Errorf("delete old stats: %w", err)
 	}
+	err = c.database.DeleteOldWorkspaceAgentResourceStats(ctx)
+	if err != nil {
+		return xerrors.Errorf("delete old resource stats: %w", err)
+	}
 
 	templates, err := c.database.GetTemplates(ctx)
 	if err != nil {
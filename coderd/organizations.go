@@ -68,6 +68,10 @@ func (api *API) postOrganizations(rw http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			return xerrors.Errorf("create organization: %w", err)
 		}
+		_, err = store.InsertAllUsersGroup(r.Context(), organization.ID)
+		if err != nil {
+			return xerrors.Errorf("create %q group: %w", database.AllUsersGroup, err)
+		}
 		_, err = store.InsertOrganizationMember(r.Context(), database.InsertOrganizationMemberParams{
 			OrganizationID: organization.ID,
 			UserID:         apiKey.UserID,
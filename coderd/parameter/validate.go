@@ -4,8 +4,17 @@ import (
 	"sort"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
 )
 
 // Contains parses possible values for a conditional.
@@ -41,3 +50,156 @@ func Contains(condition string) ([]string, bool, error) {
 	sort.Strings(possible)
 	return possible, true, nil
 }
+
+// ErrConditionNotEvaluated is wrapped by the errors of conditions that can't
+// be evaluated, for example because they call a Terraform function that isn't
+// available here. Such values aren't known to be invalid, so they should be
+// accepted, since Terraform checks the condition when the workspace is built.
+var ErrConditionNotEvaluated = xerrors.New("condition can't be evaluated")
+
+// functions are the Terraform functions available to validation conditions.
+// Functions that read files or depend on the environment aren't available.
+var functions = map[string]function.Function{
+	"abs":        stdlib.AbsoluteFunc,
+	"can":        tryfunc.CanFunc,
+	"ceil":       stdlib.CeilFunc,
+	"chomp":      stdlib.ChompFunc,
+	"coalesce":   stdlib.CoalesceFunc,
+	"compact":    stdlib.CompactFunc,
+	"concat":     stdlib.ConcatFunc,
+	"contains":   stdlib.ContainsFunc,
+	"distinct":   stdlib.DistinctFunc,
+	"element":    stdlib.ElementFunc,
+	"flatten":    stdlib.FlattenFunc,
+	"floor":      stdlib.FloorFunc,
+	"format":     stdlib.FormatFunc,
+	"formatdate": stdlib.FormatDateFunc,
+	"formatlist": stdlib.FormatListFunc,
+	"indent":     stdlib.IndentFunc,
+	"join":       stdlib.JoinFunc,
+	"jsondecode": stdlib.JSONDecodeFunc,
+	"jsonencode": stdlib.JSONEncodeFunc,
+	"keys":       stdlib.KeysFunc,
+	"length":     lengthFunc,
+	"log":        stdlib.LogFunc,
+	"lookup":     stdlib.LookupFunc,
+	"lower":      stdlib.LowerFunc,
+	"max":        stdlib.MaxFunc,
+	"min":        stdlib.MinFunc,
+	"parseint":   stdlib.ParseIntFunc,
+	"pow":        stdlib.PowFunc,
+	"range":      stdlib.RangeFunc,
+	"regex":      stdlib.RegexFunc,
+	"regexall":   stdlib.RegexAllFunc,
+	"replace":    stdlib.ReplaceFunc,
+	"reverse":    stdlib.ReverseListFunc,
+	"signum":     stdlib.SignumFunc,
+	"slice":      stdlib.SliceFunc,
+	"sort":       stdlib.SortFunc,
+	"split":      stdlib.SplitFunc,
+	"strrev":     stdlib.ReverseFunc,
+	"substr":     stdlib.SubstrFunc,
+	"title":      stdlib.TitleFunc,
+	"tobool":     stdlib.MakeToFunc(cty.Bool),
+	"tonumber":   stdlib.MakeToFunc(cty.Number),
+	"tostring":   stdlib.MakeToFunc(cty.String),
+	"trim":       stdlib.TrimFunc,
+	"trimprefix": stdlib.TrimPrefixFunc,
+	"trimspace":  stdlib.TrimSpaceFunc,
+	"trimsuffix": stdlib.TrimSuffixFunc,
+	"try":        tryfunc.TryFunc,
+	"upper":      stdlib.UpperFunc,
+	"values":     stdlib.ValuesFunc,
+}
+
+// lengthFunc returns the length of a string or a collection, like the
+// Terraform function of the same name.
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{
+		Name:             "value",
+		Type:             cty.DynamicPseudoType,
+		AllowDynamicType: true,
+	}},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+		return stdlib.Length(args[0])
+	},
+})
+
+// Validate checks a value against the type and the validation condition of
+// a parameter schema. The returned error describes why the value is invalid,
+// or wraps ErrConditionNotEvaluated if the condition couldn't be evaluated.
+// Terraform only sets a type system when a variable has a validation block,
+// but the type of the variable is always checked.
+func Validate(schema database.ParameterSchema, value string) error {
+	typedValue, err := convertValue(schema.ValidationValueType, value)
+	if err != nil {
+		return err
+	}
+	if schema.ValidationTypeSystem != database.ParameterTypeSystemHCL || schema.ValidationCondition == "" {
+		return nil
+	}
+
+	expression, diags := hclsyntax.ParseExpression([]byte(schema.ValidationCondition), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return xerrors.Errorf("parse condition: %s: %w", diags.Error(), ErrConditionNotEvaluated)
+	}
+	result, diags := expression.Value(&hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				schema.Name: typedValue,
+			}),
+		},
+		Functions: functions,
+	})
+	if diags.HasErrors() {
+		return xerrors.Errorf("evaluate condition: %s: %w", diags.Error(), ErrConditionNotEvaluated)
+	}
+	result, err = convert.Convert(result, cty.Bool)
+	if err != nil || result.IsNull() || !result.IsKnown() {
+		return xerrors.Errorf("condition %q must evaluate to true or false: %w", schema.ValidationCondition, ErrConditionNotEvaluated)
+	}
+	if result.False() {
+		if schema.ValidationError != "" {
+			return xerrors.New(schema.ValidationError)
+		}
+		return xerrors.Errorf("value doesn't satisfy the condition %q", schema.ValidationCondition)
+	}
+	return nil
+}
+
+// convertValue converts the string value of a parameter to its Terraform
+// type. Collections and objects are expected to be JSON encoded.
+func convertValue(valueType, value string) (cty.Value, error) {
+	typ := cty.String
+	if valueType != "" {
+		expression, diags := hclsyntax.ParseExpression([]byte(valueType), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return cty.NilVal, xerrors.Errorf("parse type %q: %s", valueType, diags.Error())
+		}
+		typ, diags = typeexpr.TypeConstraint(expression)
+		if diags.HasErrors() {
+			return cty.NilVal, xerrors.Errorf("parse type %q: %s", valueType, diags.Error())
+		}
+	}
+
+	switch {
+	case typ == cty.DynamicPseudoType:
+		return cty.StringVal(value), nil
+	case typ.IsPrimitiveType():
+		converted, err := convert.Convert(cty.StringVal(value), typ)
+		if err != nil {
+			return cty.NilVal, xerrors.Errorf("%q is not a valid %s", value, typ.FriendlyName())
+		}
+		return converted, nil
+	default:
+		converted, err := ctyjson.Unmarshal([]byte(value), typ)
+		if err != nil {
+			return cty.NilVal, xerrors.Errorf("%q is not a valid %s: %w", value, typ.FriendlyName(), err)
+		}
+		return converted, nil
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/parameter"
 )
 
@@ -18,3 +20,118 @@ func TestValidate(t *testing.T) {
 		require.Len(t, values, 2)
 	})
 }
+
+func TestValidateValue(t *testing.T) {
+	t.Parallel()
+
+	schema := func(valueType, condition string) database.ParameterSchema {
+		return database.ParameterSchema{
+			Name:                 "example",
+			ValidationTypeSystem: database.ParameterTypeSystemHCL,
+			ValidationValueType:  valueType,
+			ValidationCondition:  condition,
+		}
+	}
+	for _, tc := range []struct {
+		Name   string
+		Schema database.ParameterSchema
+		Value  string
+		Error  string
+		// NotEvaluated is set when the condition can't be evaluated.
+		NotEvaluated bool
+	}{{
+		Name:   "NoCondition",
+		Schema: schema("string", ""),
+		Value:  "anything",
+	}, {
+		Name:   "Contains",
+		Schema: schema("string", `contains(["us-east1-a", "us-central1-a"], var.example)`),
+		Value:  "us-east1-a",
+	}, {
+		Name:   "NotContains",
+		Schema: schema("string", `contains(["us-east1-a", "us-central1-a"], var.example)`),
+		Value:  "eu-west1-a",
+		Error:  "doesn't satisfy the condition",
+	}, {
+		Name: "CustomError",
+		Schema: database.ParameterSchema{
+			Name:                 "example",
+			ValidationTypeSystem: database.ParameterTypeSystemHCL,
+			ValidationValueType:  "string",
+			ValidationCondition:  `length(var.example) <= 3`,
+			ValidationError:      "Must be at most 3 characters.",
+		},
+		Value: "abcd",
+		Error: "Must be at most 3 characters.",
+	}, {
+		Name:   "Regex",
+		Schema: schema("string", `can(regex("^[a-z]+$", var.example))`),
+		Value:  "Invalid!",
+		Error:  "doesn't satisfy the condition",
+	}, {
+		Name:   "Number",
+		Schema: schema("number", `var.example >= 1 && var.example <= 8`),
+		Value:  "4",
+	}, {
+		Name:   "NumberOutOfRange",
+		Schema: schema("number", `var.example >= 1 && var.example <= 8`),
+		Value:  "16",
+		Error:  "doesn't satisfy the condition",
+	}, {
+		Name:   "NotNumber",
+		Schema: schema("number", ""),
+		Value:  "four",
+		Error:  "is not a valid number",
+	}, {
+		Name:   "Bool",
+		Schema: schema("bool", "var.example"),
+		Value:  "true",
+	}, {
+		Name:   "NotBool",
+		Schema: schema("bool", ""),
+		Value:  "yes",
+		Error:  "is not a valid bool",
+	}, {
+		Name:   "List",
+		Schema: schema("list(string)", "length(var.example) == 2"),
+		Value:  `["a", "b"]`,
+	}, {
+		Name:         "NotCondition",
+		Schema:       schema("string", `upper(var.example)`),
+		Value:        "value",
+		Error:        "must evaluate to true or false",
+		NotEvaluated: true,
+	}, {
+		Name:         "UnknownFunction",
+		Schema:       schema("string", `cidrhost(var.example, 1) != ""`),
+		Value:        "10.0.0.0/8",
+		Error:        "unknown function",
+		NotEvaluated: true,
+	}, {
+		Name:         "InvalidCondition",
+		Schema:       schema("string", `var.example ==`),
+		Value:        "value",
+		Error:        "parse condition",
+		NotEvaluated: true,
+	}, {
+		Name: "NoTypeSystem",
+		Schema: database.ParameterSchema{
+			Name:                 "example",
+			ValidationTypeSystem: database.ParameterTypeSystemNone,
+			ValidationCondition:  "false",
+		},
+		Value: "anything",
+	}} {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			err := parameter.Validate(tc.Schema, tc.Value)
+			if tc.Error == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.Error)
+			require.Equal(t, tc.NotEvaluated, xerrors.Is(err, parameter.ErrConditionNotEvaluated))
+		})
+	}
+}
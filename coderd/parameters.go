@@ -1,6 +1,7 @@
 package coderd
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
+	"cdr.dev/slog"
+
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/parameter"
@@ -54,6 +57,36 @@ func (api *API) postParameter(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	jobID, err := api.parameterSchemaJobID(r.Context(), scope, scopeID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching parameter schemas.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if jobID != uuid.Nil {
+		schemas, err := api.Database.GetParameterSchemasByJobID(r.Context(), jobID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching parameter schemas.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		validations := api.validateParameterValues(r.Context(), jobID, schemas, []codersdk.CreateParameterRequest{createRequest})
+		if len(validations) > 0 {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message:     "Invalid parameter value.",
+				Validations: validations,
+			})
+			return
+		}
+	}
+
 	parameterValue, err := api.Database.InsertParameterValue(r.Context(), database.InsertParameterValueParams{
 		ID:                uuid.New(),
 		Name:              createRequest.Name,
@@ -145,6 +178,24 @@ func (api *API) deleteParameter(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if scope == database.ParameterScopeWorkspace {
+		// Deleting an immutable parameter would allow it to be set to a
+		// different value by the next build.
+		mutable, err := api.parameterMutable(r.Context(), scope, scopeID, name)
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching parameter schemas.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !mutable {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Parameter %q isn't mutable and can't be deleted from the workspace.", name),
+			})
+			return
+		}
+	}
 	err = api.Database.DeleteParameterValueByID(r.Context(), parameterValue.ID)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
@@ -181,6 +232,7 @@ func convertParameterSchema(parameterSchema database.ParameterSchema) (codersdk.
 		AllowOverrideDestination: parameterSchema.AllowOverrideDestination,
 		DefaultRefresh:           parameterSchema.DefaultRefresh,
 		RedisplayValue:           parameterSchema.RedisplayValue,
+		Mutable:                  parameterSchema.Mutable,
 		ValidationError:          parameterSchema.ValidationError,
 		ValidationCondition:      parameterSchema.ValidationCondition,
 		ValidationTypeSystem:     string(parameterSchema.ValidationTypeSystem),
@@ -215,7 +267,19 @@ func (api *API) parameterRBACResource(rw http.ResponseWriter, r *http.Request, s
 	case database.ParameterScopeWorkspace:
 		resource, err = api.Database.GetWorkspaceByID(ctx, scopeID)
 	case database.ParameterScopeImportJob:
-		resource, err = api.Database.GetTemplateVersionByJobID(ctx, scopeID)
+		var version database.TemplateVersion
+		version, err = api.Database.GetTemplateVersionByJobID(ctx, scopeID)
+		if err != nil {
+			break
+		}
+		var template database.Template
+		if version.TemplateID.Valid {
+			template, err = api.Database.GetTemplateByID(ctx, version.TemplateID.UUID)
+			if err != nil {
+				break
+			}
+		}
+		resource = version.RBACObject(template)
 	case database.ParameterScopeTemplate:
 		resource, err = api.Database.GetTemplateByID(ctx, scopeID)
 	default:
@@ -238,6 +302,96 @@ func (api *API) parameterRBACResource(rw http.ResponseWriter, r *http.Request, s
 	return resource, true
 }
 
+// parameterSchemaJobID returns the template version import job whose schemas
+// apply to parameters in a scope. It returns uuid.Nil when there is none, e.g.
+// for a workspace that was never built.
+func (api *API) parameterSchemaJobID(ctx context.Context, scope database.ParameterScope, scopeID uuid.UUID) (uuid.UUID, error) {
+	var versionID uuid.UUID
+	switch scope {
+	case database.ParameterScopeImportJob:
+		return scopeID, nil
+	case database.ParameterScopeTemplate:
+		template, err := api.Database.GetTemplateByID(ctx, scopeID)
+		if err != nil {
+			return uuid.Nil, xerrors.Errorf("get template: %w", err)
+		}
+		versionID = template.ActiveVersionID
+	case database.ParameterScopeWorkspace:
+		build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, scopeID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, nil
+		}
+		if err != nil {
+			return uuid.Nil, xerrors.Errorf("get latest workspace build: %w", err)
+		}
+		versionID = build.TemplateVersionID
+	default:
+		return uuid.Nil, nil
+	}
+	version, err := api.Database.GetTemplateVersionByID(ctx, versionID)
+	if err != nil {
+		return uuid.Nil, xerrors.Errorf("get template version: %w", err)
+	}
+	return version.JobID, nil
+}
+
+// parameterMutable returns whether the schema of a parameter in a scope is
+// mutable. Parameters without a schema are mutable.
+func (api *API) parameterMutable(ctx context.Context, scope database.ParameterScope, scopeID uuid.UUID, name string) (bool, error) {
+	jobID, err := api.parameterSchemaJobID(ctx, scope, scopeID)
+	if err != nil {
+		return false, err
+	}
+	if jobID == uuid.Nil {
+		return true, nil
+	}
+	schemas, err := api.Database.GetParameterSchemasByJobID(ctx, jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("get parameter schemas: %w", err)
+	}
+	for _, schema := range schemas {
+		if schema.Name == name {
+			return schema.Mutable, nil
+		}
+	}
+	return true, nil
+}
+
+// validateParameterValues checks parameter values against the validation
+// conditions of the schemas of a template version import job. Values without
+// a schema aren't checked. The field of each validation error is the name of
+// the invalid parameter.
+func (api *API) validateParameterValues(ctx context.Context, jobID uuid.UUID, schemas []database.ParameterSchema, values []codersdk.CreateParameterRequest) []codersdk.ValidationError {
+	var validations []codersdk.ValidationError
+	for _, value := range values {
+		if value.SourceScheme != codersdk.ParameterSourceSchemeData {
+			continue
+		}
+		for _, schema := range schemas {
+			if schema.Name != value.Name {
+				continue
+			}
+			err := parameter.Validate(schema, value.SourceValue)
+			if xerrors.Is(err, parameter.ErrConditionNotEvaluated) {
+				// Terraform checks the value when the workspace is built.
+				api.Logger.Warn(ctx, "skip parameter validation",
+					slog.F("job_id", jobID), slog.F("parameter", value.Name), slog.Error(err))
+				continue
+			}
+			if err != nil {
+				validations = append(validations, codersdk.ValidationError{
+					Field:  value.Name,
+					Detail: err.Error(),
+				})
+			}
+		}
+	}
+	return validations
+}
+
 func readScopeAndID(rw http.ResponseWriter, r *http.Request) (database.ParameterScope, uuid.UUID, bool) {
 	scope := database.ParameterScope(chi.URLParam(r, "scope"))
 	switch scope {
@@ -267,3 +421,58 @@ func readScopeAndID(rw http.ResponseWriter, r *http.Request) (database.Parameter
 
 	return scope, uid, true
 }
+
+// validateParameterChanges checks that the values of a workspace build only
+// change existing workspace parameters that are mutable in the schemas of a
+// template version import job. Parameters the workspace doesn't have a value
+// for yet can always be set, and values can only change on start builds. The
+// field of each validation error is the name of the parameter.
+func (api *API) validateParameterChanges(ctx context.Context, workspaceID uuid.UUID, schemas []database.ParameterSchema, transition codersdk.WorkspaceTransition, values []codersdk.CreateParameterRequest) ([]codersdk.ValidationError, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	existing, err := api.Database.ParameterValues(ctx, database.ParameterValuesParams{
+		Scopes:   []database.ParameterScope{database.ParameterScopeWorkspace},
+		ScopeIds: []uuid.UUID{workspaceID},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace parameters: %w", err)
+	}
+	existingByName := map[string]database.ParameterValue{}
+	for _, value := range existing {
+		existingByName[value.Name] = value
+	}
+	mutable := map[string]bool{}
+	for _, schema := range schemas {
+		mutable[schema.Name] = schema.Mutable
+	}
+
+	var validations []codersdk.ValidationError
+	for _, value := range values {
+		old, ok := existingByName[value.Name]
+		if !ok {
+			continue
+		}
+		if old.SourceValue == value.SourceValue &&
+			string(old.SourceScheme) == string(value.SourceScheme) &&
+			string(old.DestinationScheme) == string(value.DestinationScheme) {
+			continue
+		}
+		switch {
+		case transition != codersdk.WorkspaceTransitionStart:
+			validations = append(validations, codersdk.ValidationError{
+				Field:  value.Name,
+				Detail: "parameters can only be changed by start builds",
+			})
+		case !mutable[value.Name]:
+			validations = append(validations, codersdk.ValidationError{
+				Field:  value.Name,
+				Detail: "parameter isn't mutable and can't be changed after the workspace was created",
+			})
+		}
+	}
+	return validations, nil
+}
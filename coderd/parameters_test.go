@@ -81,6 +81,36 @@ func TestPostParameter(t *testing.T) {
 		require.ErrorAs(t, err, &apiErr)
 		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
 	})
+
+	t.Run("InvalidValue", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := createValidatedTemplateVersion(t, client, user)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateParameter(ctx, codersdk.ParameterTemplate, template.ID, codersdk.CreateParameterRequest{
+			Name:              "instances",
+			SourceValue:       "8",
+			SourceScheme:      codersdk.ParameterSourceSchemeData,
+			DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Equal(t, []codersdk.ValidationError{{Field: "instances", Detail: "At most 4 instances are allowed."}}, apiErr.Validations)
+
+		_, err = client.CreateParameter(ctx, codersdk.ParameterTemplate, template.ID, codersdk.CreateParameterRequest{
+			Name:              "instances",
+			SourceValue:       "2",
+			SourceScheme:      codersdk.ParameterSourceSchemeData,
+			DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+		})
+		require.NoError(t, err)
+	})
 }
 
 func TestParameters(t *testing.T) {
@@ -179,3 +209,30 @@ func createTemplate(t *testing.T, client *codersdk.Client, user codersdk.CreateF
 	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
 	return template
 }
+
+// createValidatedTemplateVersion creates a template version with an
+// "instances" parameter that must be a number no greater than 4.
+func createValidatedTemplateVersion(t *testing.T, client *codersdk.Client, user codersdk.CreateFirstUserResponse) codersdk.TemplateVersion {
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: []*proto.Parse_Response{{
+			Type: &proto.Parse_Response_Complete{
+				Complete: &proto.Parse_Complete{
+					ParameterSchemas: []*proto.ParameterSchema{{
+						Name: "instances",
+						DefaultDestination: &proto.ParameterDestination{
+							Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+						},
+						ValidationTypeSystem: proto.ParameterSchema_HCL,
+						ValidationValueType:  "number",
+						ValidationCondition:  "var.instances <= 4",
+						ValidationError:      "At most 4 instances are allowed.",
+					}},
+				},
+			},
+		}},
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision:       echo.ProvisionComplete,
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	return version
+}
@@ -104,3 +104,68 @@ func Workspaces(ctx context.Context, registerer prometheus.Registerer, db databa
 	}()
 	return cancelFunc, nil
 }
+
+// AgentResources tracks the latest resource usage reported by each agent
+// with labels on the workspace and template. Agents that haven't reported
+// within the last hour are left out.
+func AgentResources(ctx context.Context, registerer prometheus.Registerer, db database.Store, duration time.Duration) (context.CancelFunc, error) {
+	if duration == 0 {
+		duration = time.Minute
+	}
+
+	labels := []string{"username", "workspace_name", "agent_name", "template_name"}
+	newGauge := func(name, help string) (*prometheus.GaugeVec, error) {
+		gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "coderd",
+			Subsystem: "agents",
+			Name:      name,
+			Help:      help,
+		}, labels)
+		return gauge, registerer.Register(gauge)
+	}
+	gauges := map[string]*prometheus.GaugeVec{}
+	for _, metric := range []struct{ name, help string }{
+		{"cpu_used_cores", "The average number of CPU cores used by the workspace between samples."},
+		{"cpu_total_cores", "The CPU limit of the workspace, or the number of cores of the host if it isn't limited."},
+		{"memory_used_bytes", "The memory used by the workspace."},
+		{"memory_total_bytes", "The memory limit of the workspace, or the memory of the host if it isn't limited."},
+		{"disk_used_bytes", "The disk space used on the filesystem of the home directory."},
+		{"disk_total_bytes", "The size of the filesystem of the home directory."},
+	} {
+		gauge, err := newGauge(metric.name, metric.help)
+		if err != nil {
+			return nil, err
+		}
+		gauges[metric.name] = gauge
+	}
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	ticker := time.NewTicker(duration)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			stats, err := db.GetLatestWorkspaceAgentResourceStats(ctx, database.Now().Add(-time.Hour))
+			if err != nil {
+				continue
+			}
+			for _, gauge := range gauges {
+				gauge.Reset()
+			}
+			for _, stat := range stats {
+				values := []string{stat.Username, stat.WorkspaceName, stat.AgentName, stat.TemplateName}
+				gauges["cpu_used_cores"].WithLabelValues(values...).Set(stat.CpuUsedCores)
+				gauges["cpu_total_cores"].WithLabelValues(values...).Set(stat.CpuTotalCores)
+				gauges["memory_used_bytes"].WithLabelValues(values...).Set(float64(stat.MemoryUsedBytes))
+				gauges["memory_total_bytes"].WithLabelValues(values...).Set(float64(stat.MemoryTotalBytes))
+				gauges["disk_used_bytes"].WithLabelValues(values...).Set(float64(stat.DiskUsedBytes))
+				gauges["disk_total_bytes"].WithLabelValues(values...).Set(float64(stat.DiskTotalBytes))
+			}
+		}
+	}()
+	return cancelFunc, nil
+}
@@ -122,7 +122,7 @@ func TestWorkspaces(t *testing.T) {
 
 	insertCanceled := func(db database.Store) {
 		job := insertRunning(db)
-		_ = db.UpdateProvisionerJobWithCancelByID(context.Background(), database.UpdateProvisionerJobWithCancelByIDParams{
+		_, _ = db.UpdateProvisionerJobWithCancelByID(context.Background(), database.UpdateProvisionerJobWithCancelByIDParams{
 			ID: job.ID,
 			CanceledAt: sql.NullTime{
 				Time:  database.Now(),
@@ -228,3 +228,78 @@ func TestWorkspaces(t *testing.T) {
 		})
 	}
 }
+
+func TestAgentResources(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := databasefake.New()
+	user, err := db.InsertUser(ctx, database.InsertUserParams{
+		ID:       uuid.New(),
+		Username: "alice",
+	})
+	require.NoError(t, err)
+	template, err := db.InsertTemplate(ctx, database.InsertTemplateParams{
+		ID:   uuid.New(),
+		Name: "docker",
+	})
+	require.NoError(t, err)
+	workspace, err := db.InsertWorkspace(ctx, database.InsertWorkspaceParams{
+		ID:         uuid.New(),
+		OwnerID:    user.ID,
+		TemplateID: template.ID,
+		Name:       "dev",
+	})
+	require.NoError(t, err)
+	agent, err := db.InsertWorkspaceAgent(ctx, database.InsertWorkspaceAgentParams{
+		ID:   uuid.New(),
+		Name: "main",
+	})
+	require.NoError(t, err)
+	insertStat := func(createdAt time.Time, memoryUsed int64) {
+		_, err := db.InsertWorkspaceAgentResourceStat(ctx, database.InsertWorkspaceAgentResourceStatParams{
+			ID:               uuid.New(),
+			CreatedAt:        createdAt,
+			AgentID:          agent.ID,
+			WorkspaceID:      workspace.ID,
+			TemplateID:       template.ID,
+			CpuUsedCores:     0.5,
+			CpuTotalCores:    2,
+			MemoryUsedBytes:  memoryUsed,
+			MemoryTotalBytes: 4096,
+			DiskUsedBytes:    10,
+			DiskTotalBytes:   100,
+		})
+		require.NoError(t, err)
+	}
+	insertStat(database.Now().Add(-2*time.Minute), 1024)
+	insertStat(database.Now().Add(-time.Minute), 2048)
+
+	registry := prometheus.NewRegistry()
+	cancel, err := prometheusmetrics.AgentResources(ctx, registry, db, time.Millisecond)
+	require.NoError(t, err)
+	t.Cleanup(cancel)
+
+	require.Eventually(t, func() bool {
+		metrics, err := registry.Gather()
+		assert.NoError(t, err)
+		for _, metric := range metrics {
+			if metric.GetName() != "coderd_agents_memory_used_bytes" || len(metric.Metric) != 1 {
+				continue
+			}
+			labels := map[string]string{}
+			for _, label := range metric.Metric[0].Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+			assert.Equal(t, map[string]string{
+				"username":       "alice",
+				"workspace_name": "dev",
+				"agent_name":     "main",
+				"template_name":  "docker",
+			}, labels)
+			// The latest sample is used.
+			return metric.Metric[0].Gauge.GetValue() == 2048
+		}
+		return false
+	}, testutil.WaitShort, testutil.IntervalFast)
+}
@@ -2,6 +2,7 @@ package coderd
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -10,13 +11,16 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/yamux"
 	"github.com/moby/moby/pkg/namesgenerator"
 	"github.com/tabbed/pqtype"
 	"golang.org/x/xerrors"
 	protobuf "google.golang.org/protobuf/proto"
+	"nhooyr.io/websocket"
 	"storj.io/drpc/drpcmux"
 	"storj.io/drpc/drpcserver"
 
@@ -27,6 +31,7 @@ import (
 	"github.com/coder/coder/coderd/parameter"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/telemetry"
+	"github.com/coder/coder/coderd/tracing"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisionerd/proto"
 	"github.com/coder/coder/provisionersdk"
@@ -82,44 +87,195 @@ func (api *API) ListenProvisionerDaemon(ctx context.Context) (client proto.DRPCP
 		return nil, xerrors.Errorf("insert provisioner daemon %q: %w", name, err)
 	}
 
-	mux := drpcmux.New()
-	err = proto.DRPCRegisterProvisionerDaemon(mux, &provisionerdServer{
+	server, err := api.newProvisionerDaemonServer(ctx, daemon, false)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		err := server.Serve(ctx, serverSession)
+		if err != nil && !xerrors.Is(err, io.EOF) {
+			api.Logger.Debug(ctx, "provisioner daemon disconnected", slog.Error(err))
+		}
+		// close the sessions so we don't leak goroutines serving them.
+		_ = clientSession.Close()
+		_ = serverSession.Close()
+	}()
+
+	return proto.NewDRPCProvisionerDaemonClient(provisionersdk.Conn(clientSession)), nil
+}
+
+// serveProvisionerDaemon accepts a connection from an external provisioner
+// daemon. Daemons authenticate with the pre-shared key configured on the
+// server instead of a session token, and only acquire jobs whose tags are
+// a subset of the tags they connect with.
+func (api *API) serveProvisionerDaemon(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if api.ProvisionerDaemonPSK == "" {
+		httpapi.Write(rw, http.StatusForbidden, codersdk.Response{
+			Message: "External provisioner daemons are disabled.",
+			Detail:  "Set a provisioner daemon pre-shared key on the server to enable them.",
+		})
+		return
+	}
+	psk := r.Header.Get(codersdk.ProvisionerDaemonPSKHeader)
+	if subtle.ConstantTimeCompare([]byte(psk), []byte(api.ProvisionerDaemonPSK)) != 1 {
+		httpapi.Write(rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Invalid provisioner daemon pre-shared key.",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	provisioners := make([]database.ProvisionerType, 0)
+	for _, provisioner := range query["provisioner"] {
+		switch codersdk.ProvisionerType(provisioner) {
+		case codersdk.ProvisionerTypeEcho, codersdk.ProvisionerTypeTerraform:
+			provisioners = append(provisioners, database.ProvisionerType(provisioner))
+		default:
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Unknown provisioner type %q.", provisioner),
+				Validations: []codersdk.ValidationError{
+					{Field: "provisioner", Detail: "Must be one of: echo, terraform"},
+				},
+			})
+			return
+		}
+	}
+	if len(provisioners) == 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "At least one provisioner type must be provided.",
+		})
+		return
+	}
+	tags := database.StringMap{}
+	for _, tag := range query["tag"] {
+		parts := strings.SplitN(tag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid tag %q.", tag),
+				Validations: []codersdk.ValidationError{
+					{Field: "tag", Detail: "Must be in the format key=value"},
+				},
+			})
+			return
+		}
+		tags[parts[0]] = parts[1]
+	}
+	name := query.Get("name")
+	if name == "" {
+		name = namesgenerator.GetRandomName(1)
+	}
+
+	daemon, err := api.Database.InsertProvisionerDaemon(ctx, database.InsertProvisionerDaemonParams{
+		ID:           uuid.New(),
+		CreatedAt:    database.Now(),
+		Name:         name,
+		Provisioners: provisioners,
+		Tags:         tags,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting provisioner daemon.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	server, err := api.newProvisionerDaemonServer(ctx, daemon, true)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating provisioner daemon server.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	conn, err := websocket.Accept(rw, r, &websocket.AcceptOptions{
+		// Need to disable compression to avoid a data-race.
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Job payloads and completions can be far larger than the
+	// default websocket read limit.
+	conn.SetReadLimit(provisionersdk.MaxMessageSize)
+
+	ctx, wsNetConn := websocketNetConn(ctx, conn, websocket.MessageBinary)
+	defer wsNetConn.Close() // Also closes conn.
+
+	config := yamux.DefaultConfig()
+	config.LogOutput = io.Discard
+	session, err := yamux.Server(wsNetConn, config)
+	if err != nil {
+		_ = conn.Close(websocket.StatusAbnormalClosure, err.Error())
+		return
+	}
+
+	// end span so we don't get long lived trace data
+	tracing.EndHTTPSpan(r, 200)
+
+	api.Logger.Info(ctx, "external provisioner daemon connected",
+		slog.F("id", daemon.ID), slog.F("name", daemon.Name), slog.F("tags", daemon.Tags))
+	err = server.Serve(ctx, session)
+	if err != nil && !xerrors.Is(err, io.EOF) && !xerrors.Is(err, context.Canceled) {
+		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("serve: %s", err))
+		return
+	}
+	_ = conn.Close(websocket.StatusGoingAway, "")
+}
+
+// newProvisionerDaemonServer returns a dRPC server that serves jobs to
+// the provisioner daemon provided. External daemons run outside of the
+// server, so they aren't sent the credentials for template git repositories.
+func (api *API) newProvisionerDaemonServer(ctx context.Context, daemon database.ProvisionerDaemon, external bool) (*drpcserver.Server, error) {
+	// A nil map marshals to null, which would never match a job's tags.
+	if daemon.Tags == nil {
+		daemon.Tags = database.StringMap{}
+	}
+	server := &provisionerdServer{
 		AccessURL:    api.AccessURL,
 		ID:           daemon.ID,
 		Database:     api.Database,
 		Pubsub:       api.Pubsub,
 		Provisioners: daemon.Provisioners,
+		Tags:         daemon.Tags,
 		Telemetry:    api.Telemetry,
 		Logger:       api.Logger.Named(fmt.Sprintf("provisionerd-%s", daemon.Name)),
-	})
+
+		StartShutdownScripts: api.startShutdownScripts,
+	}
+	if !external {
+		server.TemplateGitUsername = api.TemplateGitUsername
+		server.TemplateGitPassword = api.TemplateGitPassword
+	}
+	server.TemplateGitHosts = api.TemplateGitHosts
+	mux := drpcmux.New()
+	err := proto.DRPCRegisterProvisionerDaemon(mux, server)
 	if err != nil {
-		return nil, err
+		return nil, xerrors.Errorf("register provisioner daemon: %w", err)
 	}
-	server := drpcserver.NewWithOptions(mux, drpcserver.Options{
+	return drpcserver.NewWithOptions(mux, drpcserver.Options{
 		Log: func(err error) {
 			if xerrors.Is(err, io.EOF) {
 				return
 			}
 			api.Logger.Debug(ctx, "drpc server error", slog.Error(err))
 		},
-	})
-	go func() {
-		err := server.Serve(ctx, serverSession)
-		if err != nil && !xerrors.Is(err, io.EOF) {
-			api.Logger.Debug(ctx, "provisioner daemon disconnected", slog.Error(err))
-		}
-		// close the sessions so we don't leak goroutines serving them.
-		_ = clientSession.Close()
-		_ = serverSession.Close()
-	}()
-
-	return proto.NewDRPCProvisionerDaemonClient(provisionersdk.Conn(clientSession)), nil
+	}), nil
 }
 
 // The input for a "workspace_provision" job.
 type workspaceProvisionJob struct {
 	WorkspaceBuildID uuid.UUID `json:"workspace_build_id"`
 	DryRun           bool      `json:"dry_run"`
+	// ShutdownScriptsRan is set once the shutdown scripts of a stop or
+	// delete build have run.
+	ShutdownScriptsRan bool `json:"shutdown_scripts_ran,omitempty"`
 }
 
 // The input for a "template_version_dry_run" job.
@@ -129,19 +285,41 @@ type templateVersionDryRunJob struct {
 	ParameterValues   []database.ParameterValue `json:"parameter_values"`
 }
 
+// The storage source of jobs with the "git" storage method.
+type gitStorageSource struct {
+	URL          string `json:"url"`
+	Ref          string `json:"ref"`
+	Subdirectory string `json:"subdirectory"`
+}
+
 // Implementation of the provisioner daemon protobuf server.
 type provisionerdServer struct {
 	AccessURL    *url.URL
 	ID           uuid.UUID
 	Logger       slog.Logger
 	Provisioners []database.ProvisionerType
+	Tags         database.StringMap
 	Database     database.Store
 	Pubsub       database.Pubsub
 	Telemetry    telemetry.Reporter
+	// StartShutdownScripts is called before a stop or delete build is
+	// handed to the provisioner daemon. If it returns true, the job is held
+	// while scripts run, and put back in the queue once they finish.
+	StartShutdownScripts func(ctx context.Context, job database.ProvisionerJob, build database.WorkspaceBuild) bool
+	// TemplateGitUsername and TemplateGitPassword authenticate the
+	// provisioner daemon to git repositories of template versions on
+	// TemplateGitHosts.
+	TemplateGitUsername string
+	TemplateGitPassword string
+	TemplateGitHosts    []string
 }
 
 // AcquireJob queries the database to lock a job.
 func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty) (*proto.AcquiredJob, error) {
+	tags, err := json.Marshal(server.Tags)
+	if err != nil {
+		return nil, xerrors.Errorf("marshal tags: %w", err)
+	}
 	// This marks the job as locked in the database.
 	job, err := server.Database.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
 		StartedAt: sql.NullTime{
@@ -153,6 +331,7 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 			Valid: true,
 		},
 		Types: server.Provisioners,
+		Tags:  tags,
 	})
 	if errors.Is(err, sql.ErrNoRows) {
 		// The provisioner daemon assumes no jobs are available if
@@ -194,6 +373,9 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 		Provisioner: string(job.Provisioner),
 		UserName:    user.Username,
 	}
+	// Builds and dry-runs check out the commit their template version was
+	// imported at, so commits pushed to the ref since don't affect them.
+	var gitCommitSHA string
 	switch job.Type {
 	case database.ProvisionerJobTypeWorkspaceBuild:
 		var input workspaceProvisionJob
@@ -213,6 +395,7 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 		if err != nil {
 			return nil, failJob(fmt.Sprintf("get template version: %s", err))
 		}
+		gitCommitSHA = templateVersion.GitCommitSHA
 		template, err := server.Database.GetTemplateByID(ctx, templateVersion.TemplateID.UUID)
 		if err != nil {
 			return nil, failJob(fmt.Sprintf("get template: %s", err))
@@ -222,6 +405,23 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 			return nil, failJob(fmt.Sprintf("get owner: %s", err))
 		}
 
+		if workspaceBuild.Transition != database.WorkspaceTransitionStart && !input.DryRun && !input.ShutdownScriptsRan && server.StartShutdownScripts != nil {
+			// Agents get a chance to save state before their resources
+			// are torn down. The scripts run without blocking the
+			// provisioner daemon, which acquires the job again later.
+			if server.StartShutdownScripts(ctx, job, workspaceBuild) {
+				return &proto.AcquiredJob{}, nil
+			}
+		}
+
+		parameterSchemas, err := server.Database.GetParameterSchemasByJobID(ctx, templateVersion.JobID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+		if err != nil {
+			return nil, failJob(fmt.Sprintf("get parameter schemas: %s", err))
+		}
+
 		// Compute parameters for the workspace to consume.
 		parameters, err := parameter.Compute(ctx, server.Database, parameter.ComputeScope{
 			TemplateImportJobID: templateVersion.JobID,
@@ -239,6 +439,10 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 		if err != nil {
 			return nil, failJob(fmt.Sprintf("compute parameters: %s", err))
 		}
+		err = insertWorkspaceBuildParameters(ctx, server.Database, workspaceBuild.ID, parameterSchemas, parameters)
+		if err != nil {
+			return nil, failJob(fmt.Sprintf("insert workspace build parameters: %s", err))
+		}
 
 		// Convert types to their corresponding protobuf types.
 		protoParameters, err := convertComputedParameterValues(parameters)
@@ -278,6 +482,7 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 		if err != nil {
 			return nil, failJob(fmt.Sprintf("get template version: %s", err))
 		}
+		gitCommitSHA = templateVersion.GitCommitSHA
 
 		// Compute parameters for the dry-run to consume.
 		parameters, err := parameter.Compute(ctx, server.Database, parameter.ComputeScope{
@@ -323,6 +528,26 @@ func (server *provisionerdServer) AcquireJob(ctx context.Context, _ *proto.Empty
 			return nil, failJob(fmt.Sprintf("get file by hash: %s", err))
 		}
 		protoJob.TemplateSourceArchive = file.Data
+	case database.ProvisionerStorageMethodGit:
+		var source gitStorageSource
+		err = json.Unmarshal([]byte(job.StorageSource), &source)
+		if err != nil {
+			return nil, failJob(fmt.Sprintf("unmarshal git storage source: %s", err))
+		}
+		if gitCommitSHA != "" {
+			source.Ref = gitCommitSHA
+		}
+		protoJob.TemplateSourceGit = &proto.AcquiredJob_TemplateSourceGit{
+			Url:          source.URL,
+			Ref:          source.Ref,
+			Subdirectory: source.Subdirectory,
+		}
+		// The author of a template version chooses the repository, so the
+		// credentials are only sent to hosts the deployment trusts with them.
+		if gitHostAllowed(server.TemplateGitHosts, source.URL) {
+			protoJob.TemplateSourceGit.Username = server.TemplateGitUsername
+			protoJob.TemplateSourceGit.Password = server.TemplateGitPassword
+		}
 	default:
 		return nil, failJob(fmt.Sprintf("unsupported storage method: %s", job.StorageMethod))
 	}
@@ -349,6 +574,14 @@ func (server *provisionerdServer) UpdateJob(ctx context.Context, request *proto.
 	if job.WorkerID.UUID.String() != server.ID.String() {
 		return nil, xerrors.New("you don't own this job")
 	}
+	if job.CompletedAt.Valid {
+		// The job was failed by coderd, e.g. because it exceeded the
+		// maximum job duration of its template. Canceling it stops the
+		// provisioner daemon from working on it.
+		return &proto.UpdateJobResponse{
+			Canceled: true,
+		}, nil
+	}
 	err = server.Database.UpdateProvisionerJobByID(ctx, database.UpdateProvisionerJobByIDParams{
 		ID:        parsedID,
 		UpdatedAt: database.Now(),
@@ -435,7 +668,8 @@ func (server *provisionerdServer) UpdateJob(ctx context.Context, request *proto.
 				AllowOverrideDestination: protoParameter.AllowOverrideDestination,
 				AllowOverrideSource:      protoParameter.AllowOverrideSource,
 
-				Index: int32(index),
+				Mutable: protoParameter.Mutable,
+				Index:   int32(index),
 			}
 
 			// It's possible a parameter doesn't define a default source!
@@ -585,6 +819,9 @@ func (server *provisionerdServer) CompleteJob(ctx context.Context, completed *pr
 	if job.WorkerID.UUID.String() != server.ID.String() {
 		return nil, xerrors.Errorf("you don't have permission to update this job")
 	}
+	if job.CompletedAt.Valid {
+		return nil, xerrors.Errorf("job already completed")
+	}
 
 	telemetrySnapshot := &telemetry.Snapshot{}
 	// Items are added to this snapshot as they complete!
@@ -610,6 +847,17 @@ func (server *provisionerdServer) CompleteJob(ctx context.Context, completed *pr
 			}
 		}
 
+		if jobType.TemplateImport.GitCommitSha != "" {
+			err = server.Database.UpdateTemplateVersionGitCommitSHAByJobID(ctx, database.UpdateTemplateVersionGitCommitSHAByJobIDParams{
+				JobID:        jobID,
+				GitCommitSHA: jobType.TemplateImport.GitCommitSha,
+				UpdatedAt:    database.Now(),
+			})
+			if err != nil {
+				return nil, xerrors.Errorf("update template version git commit: %w", err)
+			}
+		}
+
 		err = server.Database.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
 			ID:        jobID,
 			UpdatedAt: database.Now(),
@@ -802,6 +1050,10 @@ func insertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 				String: prAgent.StartupScript,
 				Valid:  prAgent.StartupScript != "",
 			},
+			ShutdownScript: sql.NullString{
+				String: prAgent.ShutdownScript,
+				Valid:  prAgent.ShutdownScript != "",
+			},
 		})
 		if err != nil {
 			return xerrors.Errorf("insert agent: %w", err)
@@ -809,6 +1061,10 @@ func insertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 		snapshot.WorkspaceAgents = append(snapshot.WorkspaceAgents, telemetry.ConvertWorkspaceAgent(dbAgent))
 
 		for _, app := range prAgent.Apps {
+			sharingLevel, err := convertAppSharingLevel(app.SharingLevel)
+			if err != nil {
+				return xerrors.Errorf("convert app sharing level: %w", err)
+			}
 			dbApp, err := db.InsertWorkspaceApp(ctx, database.InsertWorkspaceAppParams{
 				ID:        uuid.New(),
 				CreatedAt: database.Now(),
@@ -824,6 +1080,7 @@ func insertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 					Valid:  app.Url != "",
 				},
 				RelativePath: app.RelativePath,
+				SharingLevel: sharingLevel,
 			})
 			if err != nil {
 				return xerrors.Errorf("insert app: %w", err)
@@ -853,6 +1110,46 @@ func insertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 	return nil
 }
 
+// insertWorkspaceBuildParameters records the parameter values a workspace
+// build was provisioned with, so the history of a workspace shows when they
+// changed. Values of parameters that aren't redisplayed are secrets, and
+// aren't recorded.
+func insertWorkspaceBuildParameters(ctx context.Context, db database.Store, buildID uuid.UUID, schemas []database.ParameterSchema, parameters []parameter.ComputedValue) error {
+	redisplay := map[uuid.UUID]bool{}
+	for _, schema := range schemas {
+		redisplay[schema.ID] = schema.RedisplayValue
+	}
+	for _, param := range parameters {
+		if !redisplay[param.SchemaID] {
+			continue
+		}
+		err := db.InsertWorkspaceBuildParameter(ctx, database.InsertWorkspaceBuildParameterParams{
+			WorkspaceBuildID: buildID,
+			Name:             param.Name,
+			Value:            param.SourceValue,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert %q: %w", param.Name, err)
+		}
+	}
+	return nil
+}
+
+func convertProvisionerDaemon(daemon database.ProvisionerDaemon) codersdk.ProvisionerDaemon {
+	provisioners := make([]codersdk.ProvisionerType, 0, len(daemon.Provisioners))
+	for _, provisioner := range daemon.Provisioners {
+		provisioners = append(provisioners, codersdk.ProvisionerType(provisioner))
+	}
+	return codersdk.ProvisionerDaemon{
+		ID:           daemon.ID,
+		CreatedAt:    daemon.CreatedAt,
+		UpdatedAt:    daemon.UpdatedAt,
+		Name:         daemon.Name,
+		Provisioners: provisioners,
+		Tags:         daemon.Tags,
+	}
+}
+
 func convertValidationTypeSystem(typeSystem sdkproto.ParameterSchema_TypeSystem) (database.ParameterTypeSystem, error) {
 	switch typeSystem {
 	case sdkproto.ParameterSchema_None:
@@ -912,6 +1209,19 @@ func convertLogSource(logSource proto.LogSource) (database.LogSource, error) {
 	}
 }
 
+func convertAppSharingLevel(sharingLevel sdkproto.AppSharingLevel) (database.AppSharingLevel, error) {
+	switch sharingLevel {
+	case sdkproto.AppSharingLevel_OWNER:
+		return database.AppSharingLevelOwner, nil
+	case sdkproto.AppSharingLevel_AUTHENTICATED:
+		return database.AppSharingLevelAuthenticated, nil
+	case sdkproto.AppSharingLevel_PUBLIC:
+		return database.AppSharingLevelPublic, nil
+	default:
+		return database.AppSharingLevel(""), xerrors.Errorf("unknown app sharing level: %d", sharingLevel)
+	}
+}
+
 func convertComputedParameterValues(parameters []parameter.ComputedValue) ([]*sdkproto.ParameterValue, error) {
 	protoParameters := make([]*sdkproto.ParameterValue, len(parameters))
 	for i, computedParameter := range parameters {
@@ -3,6 +3,7 @@ package coderd_test
 import (
 	"context"
 	"crypto/rand"
+	"net/http"
 	"runtime"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -74,3 +76,76 @@ func TestProvisionerDaemonsByOrganization(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestServeProvisionerDaemon(t *testing.T) {
+	t.Parallel()
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.ServeProvisionerDaemon(ctx, "", []codersdk.ProvisionerType{codersdk.ProvisionerTypeEcho}, nil, "psk")
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidPSK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{ProvisionerDaemonPSK: "psk"})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.ServeProvisionerDaemon(ctx, "", []codersdk.ProvisionerType{codersdk.ProvisionerTypeEcho}, nil, "wrong")
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{ProvisionerDaemonPSK: "psk"})
+		user := coderdtest.CreateFirstUser(t, client)
+		_ = coderdtest.NewExternalProvisionerDaemon(t, client, "psk", map[string]string{
+			"environment": "cloud",
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		data, err := echo.Tar(nil)
+		require.NoError(t, err)
+		file, err := client.Upload(ctx, codersdk.ContentTypeTar, data)
+		require.NoError(t, err)
+		version, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageSource: file.Hash,
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			ProvisionerTags: map[string]string{
+				"environment": "on-prem",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"environment": "on-prem"}, version.Job.Tags)
+
+		// The daemon's tags don't match, so the job must not be acquired.
+		require.Never(t, func() bool {
+			version, err := client.TemplateVersion(ctx, version.ID)
+			return assert.NoError(t, err) && version.Job.Status != codersdk.ProvisionerJobPending
+		}, testutil.IntervalSlow, testutil.IntervalFast)
+
+		_ = coderdtest.NewExternalProvisionerDaemon(t, client, "psk", map[string]string{
+			"environment": "on-prem",
+			"region":      "us-east",
+		})
+		version = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, version.Job.Status)
+
+		daemons, err := client.ProvisionerDaemons(ctx)
+		require.NoError(t, err)
+		require.Len(t, daemons, 2)
+	})
+}
@@ -11,12 +11,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 	"nhooyr.io/websocket"
 
 	"cdr.dev/slog"
 
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -286,6 +288,147 @@ func (api *API) provisionerJobResources(rw http.ResponseWriter, r *http.Request,
 	httpapi.Write(rw, http.StatusOK, apiResources)
 }
 
+// activeProvisionerJobs lists the pending and running provisioner jobs of
+// the deployment, in the order they were created.
+func (api *API) activeProvisionerJobs(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceProvisionerJob) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	jobs, err := api.Database.GetActiveProvisionerJobs(ctx)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner jobs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	daemons, err := api.Database.GetProvisionerDaemons(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner daemons.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	daemonsByID := make(map[uuid.UUID]database.ProvisionerDaemon, len(daemons))
+	for _, daemon := range daemons {
+		daemonsByID[daemon.ID] = daemon
+	}
+	queuePositions, err := getProvisionerJobQueuePositions(ctx, api.Database, jobs...)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner job queue positions.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiJobs := make([]codersdk.ActiveProvisionerJob, 0, len(jobs))
+	for _, job := range jobs {
+		apiJob := codersdk.ActiveProvisionerJob{
+			Job:            convertProvisionerJob(job, queuePositions[job.ID]),
+			Type:           codersdk.ProvisionerJobType(job.Type),
+			OrganizationID: job.OrganizationID,
+			InitiatorID:    job.InitiatorID,
+		}
+		if daemon, ok := daemonsByID[job.WorkerID.UUID]; ok && job.WorkerID.Valid {
+			apiDaemon := convertProvisionerDaemon(daemon)
+			apiJob.Daemon = &apiDaemon
+		}
+		apiJobs = append(apiJobs, apiJob)
+	}
+
+	httpapi.Write(rw, http.StatusOK, apiJobs)
+}
+
+// getProvisionerJobWithQueuePosition fetches a job along with its position
+// in the queue, which is only set for pending jobs.
+func getProvisionerJobWithQueuePosition(ctx context.Context, db database.Store, id uuid.UUID) (database.ProvisionerJob, database.GetProvisionerJobQueuePositionsByIDsRow, error) {
+	job, err := db.GetProvisionerJobByID(ctx, id)
+	if err != nil {
+		return database.ProvisionerJob{}, database.GetProvisionerJobQueuePositionsByIDsRow{}, err
+	}
+	queuePositions, err := getProvisionerJobQueuePositions(ctx, db, job)
+	if err != nil {
+		return database.ProvisionerJob{}, database.GetProvisionerJobQueuePositionsByIDsRow{}, err
+	}
+	return job, queuePositions[job.ID], nil
+}
+
+// getProvisionerJobsWithQueuePositions fetches jobs along with the positions
+// of the pending ones in the queue, keyed by job ID.
+func getProvisionerJobsWithQueuePositions(ctx context.Context, db database.Store, ids []uuid.UUID) ([]database.ProvisionerJob, map[uuid.UUID]database.GetProvisionerJobQueuePositionsByIDsRow, error) {
+	jobs, err := db.GetProvisionerJobsByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	queuePositions, err := getProvisionerJobQueuePositions(ctx, db, jobs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return jobs, queuePositions, nil
+}
+
+// getProvisionerJobQueuePositions returns the positions of the pending jobs
+// in the queue, keyed by job ID.
+func getProvisionerJobQueuePositions(ctx context.Context, db database.Store, jobs ...database.ProvisionerJob) (map[uuid.UUID]database.GetProvisionerJobQueuePositionsByIDsRow, error) {
+	ids := make([]uuid.UUID, 0, len(jobs))
+	for _, job := range jobs {
+		if ConvertProvisionerJobStatus(job) == codersdk.ProvisionerJobPending {
+			ids = append(ids, job.ID)
+		}
+	}
+	queuePositions := make(map[uuid.UUID]database.GetProvisionerJobQueuePositionsByIDsRow, len(ids))
+	if len(ids) == 0 {
+		return queuePositions, nil
+	}
+	rows, err := db.GetProvisionerJobQueuePositionsByIDs(ctx, ids)
+	if err != nil {
+		return nil, xerrors.Errorf("get provisioner job queue positions: %w", err)
+	}
+	for _, row := range rows {
+		queuePositions[row.ID] = row
+	}
+	return queuePositions, nil
+}
+
+// cancelProvisionerJob marks a job as canceled. Jobs that no provisioner
+// daemon has acquired yet are also completed, since there's no daemon to
+// finish them.
+func (api *API) cancelProvisionerJob(ctx context.Context, job database.ProvisionerJob) error {
+	job, err := api.Database.UpdateProvisionerJobWithCancelByID(ctx, database.UpdateProvisionerJobWithCancelByIDParams{
+		ID: job.ID,
+		CanceledAt: sql.NullTime{
+			Time:  database.Now(),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return xerrors.Errorf("update provisioner job: %w", err)
+	}
+	if !job.CompletedAt.Valid {
+		return nil
+	}
+
+	// Close the log streams of the job, which would otherwise wait for a
+	// provisioner daemon to end them.
+	data, err := json.Marshal(provisionerJobLogsMessage{EndOfLogs: true})
+	if err != nil {
+		return xerrors.Errorf("marshal job log: %w", err)
+	}
+	err = api.Pubsub.Publish(provisionerJobLogsChannel(job.ID), data)
+	if err != nil {
+		return xerrors.Errorf("publish end of job logs: %w", err)
+	}
+	return nil
+}
+
 func convertProvisionerJobLogs(provisionerJobLogs []database.ProvisionerJobLog) []codersdk.ProvisionerJobLog {
 	sdk := make([]codersdk.ProvisionerJobLog, 0, len(provisionerJobLogs))
 	for _, log := range provisionerJobLogs {
@@ -305,12 +448,15 @@ func convertProvisionerJobLog(provisionerJobLog database.ProvisionerJobLog) code
 	}
 }
 
-func convertProvisionerJob(provisionerJob database.ProvisionerJob) codersdk.ProvisionerJob {
+func convertProvisionerJob(provisionerJob database.ProvisionerJob, queuePosition database.GetProvisionerJobQueuePositionsByIDsRow) codersdk.ProvisionerJob {
 	job := codersdk.ProvisionerJob{
 		ID:            provisionerJob.ID,
 		CreatedAt:     provisionerJob.CreatedAt,
 		Error:         provisionerJob.Error.String,
 		StorageSource: provisionerJob.StorageSource,
+		Tags:          provisionerJob.Tags,
+		QueuePosition: int(queuePosition.QueuePosition),
+		QueueSize:     int(queuePosition.QueueSize),
 	}
 	// Applying values optional to the struct.
 	if provisionerJob.StartedAt.Valid {
@@ -250,7 +250,7 @@ func TestConvertProvisionerJob_Unit(t *testing.T) {
 		testCase := testCase
 		t.Run(testCase.name, func(t *testing.T) {
 			t.Parallel()
-			actual := convertProvisionerJob(testCase.input)
+			actual := convertProvisionerJob(testCase.input, database.GetProvisionerJobQueuePositionsByIDsRow{})
 			assert.Equal(t, testCase.expected, actual)
 		})
 	}
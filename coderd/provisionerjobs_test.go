@@ -2,6 +2,7 @@ package coderd_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionersdk/proto"
 	"github.com/coder/coder/testutil"
@@ -124,3 +126,50 @@ func TestProvisionerJobLogs(t *testing.T) {
 		require.Greater(t, len(logs), 1)
 	})
 }
+
+func TestActiveProvisionerJobs(t *testing.T) {
+	t.Parallel()
+	t.Run("Forbidden", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := member.ActiveProvisionerJobs(ctx)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		first := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		second := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		canceled := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := client.CancelTemplateVersion(ctx, canceled.ID)
+		require.NoError(t, err)
+
+		jobs, err := client.ActiveProvisionerJobs(ctx)
+		require.NoError(t, err)
+		require.Len(t, jobs, 2)
+		for i, version := range []codersdk.TemplateVersion{first, second} {
+			require.Equal(t, version.Job.ID, jobs[i].Job.ID)
+			require.Equal(t, codersdk.ProvisionerJobTypeTemplateVersionImport, jobs[i].Type)
+			require.Equal(t, user.OrganizationID, jobs[i].OrganizationID)
+			require.Equal(t, user.UserID, jobs[i].InitiatorID)
+			require.Equal(t, i+1, jobs[i].Job.QueuePosition)
+			require.Equal(t, 2, jobs[i].Job.QueueSize)
+			// Pending jobs haven't been acquired by a daemon.
+			require.Nil(t, jobs[i].Daemon)
+		}
+	})
+}
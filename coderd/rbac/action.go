@@ -9,3 +9,7 @@ const (
 	ActionUpdate = "update"
 	ActionDelete = "delete"
 )
+
+// ActionWildcard grants every action. It is used in ACL lists, where
+// actions are granted directly rather than through roles.
+const ActionWildcard Action = WildcardSymbol
@@ -19,7 +19,8 @@ type subject struct {
 	// For the unit test we want to pass in the roles directly, instead of just
 	// by name. This allows us to test custom roles that do not exist in the product,
 	// but test edge cases of the implementation.
-	Roles []Role `json:"roles"`
+	Roles  []Role   `json:"roles"`
+	Groups []string `json:"groups"`
 }
 
 type fakeObject struct {
@@ -42,7 +43,7 @@ func TestFilterError(t *testing.T) {
 	auth, err := NewAuthorizer()
 	require.NoError(t, err)
 
-	_, err = Filter(context.Background(), auth, uuid.NewString(), []string{}, ActionRead, []Object{ResourceUser, ResourceWorkspace})
+	_, err = Filter(context.Background(), auth, uuid.NewString(), []string{}, []string{}, ActionRead, []Object{ResourceUser, ResourceWorkspace})
 	require.ErrorContains(t, err, "object types must be uniform")
 }
 
@@ -158,7 +159,7 @@ func TestFilter(t *testing.T) {
 			var allowedCount int
 			for i, obj := range localObjects {
 				obj.Type = tc.ObjectType
-				err := auth.ByRoleName(ctx, tc.SubjectID, tc.Roles, ActionRead, obj.RBACObject())
+				err := auth.ByRoleName(ctx, tc.SubjectID, tc.Roles, nil, ActionRead, obj.RBACObject())
 				obj.Allowed = err == nil
 				if err == nil {
 					allowedCount++
@@ -167,7 +168,7 @@ func TestFilter(t *testing.T) {
 			}
 
 			// Run by filter
-			list, err := Filter(ctx, auth, tc.SubjectID, tc.Roles, tc.Action, localObjects)
+			list, err := Filter(ctx, auth, tc.SubjectID, tc.Roles, nil, tc.Action, localObjects)
 			require.NoError(t, err)
 			require.Equal(t, allowedCount, len(list), "expected number of allowed")
 			for _, obj := range list {
@@ -490,6 +491,63 @@ func TestAuthorizeDomain(t *testing.T) {
 		}))
 }
 
+// TestAuthorizeACL ensures user and group ACLs grant access to objects the
+// subject's roles do not.
+func TestAuthorizeACL(t *testing.T) {
+	t.Parallel()
+	defOrg := uuid.New()
+	unusedID := uuid.New()
+	groupID := uuid.NewString()
+
+	user := subject{
+		UserID: "me",
+		Roles: []Role{
+			must(RoleByName(RoleMember())),
+			must(RoleByName(RoleOrgMember(defOrg))),
+		},
+		Groups: []string{groupID},
+	}
+
+	testAuthorize(t, "ACL", user,
+		cases(func(c authTestCase) authTestCase {
+			c.actions = []Action{ActionRead}
+			return c
+		}, []authTestCase{
+			// No ACL
+			{resource: ResourceTemplate.InOrg(defOrg), allow: false},
+
+			// User ACL
+			{resource: ResourceTemplate.InOrg(defOrg).WithACLUserList(map[string][]Action{
+				user.UserID: {ActionRead},
+			}), allow: true},
+			{resource: ResourceTemplate.InOrg(defOrg).WithACLUserList(map[string][]Action{
+				user.UserID: {ActionWildcard},
+			}), allow: true},
+			{resource: ResourceTemplate.InOrg(defOrg).WithACLUserList(map[string][]Action{
+				user.UserID: {ActionUpdate},
+			}), allow: false},
+			{resource: ResourceTemplate.InOrg(defOrg).WithACLUserList(map[string][]Action{
+				"not-me": {ActionRead},
+			}), allow: false},
+
+			// Group ACL
+			{resource: ResourceTemplate.InOrg(defOrg).WithGroupACL(map[string][]Action{
+				groupID: {ActionRead},
+			}), allow: true},
+			{resource: ResourceTemplate.InOrg(defOrg).WithGroupACL(map[string][]Action{
+				uuid.NewString(): {ActionRead},
+			}), allow: false},
+
+			// ACLs do not apply outside of the subject's organizations.
+			{resource: ResourceTemplate.InOrg(unusedID).WithACLUserList(map[string][]Action{
+				user.UserID: {ActionRead},
+			}), allow: false},
+			{resource: ResourceTemplate.InOrg(unusedID).WithGroupACL(map[string][]Action{
+				groupID: {ActionRead},
+			}), allow: false},
+		}))
+}
+
 // TestAuthorizeLevels ensures level overrides are acting appropriately
 func TestAuthorizeLevels(t *testing.T) {
 	t.Parallel()
@@ -642,7 +700,7 @@ func testAuthorize(t *testing.T, name string, subject subject, sets ...[]authTes
 				for _, a := range c.actions {
 					ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
 					t.Cleanup(cancel)
-					authError := authorizer.Authorize(ctx, subject.UserID, subject.Roles, a, c.resource)
+					authError := authorizer.Authorize(ctx, subject.UserID, subject.Roles, subject.Groups, a, c.resource)
 					// Logging only
 					if authError != nil {
 						var uerr *UnauthorizedError
@@ -666,7 +724,7 @@ func testAuthorize(t *testing.T, name string, subject subject, sets ...[]authTes
 						assert.Error(t, authError, "expected unauthorized")
 					}
 
-					partialAuthz, err := authorizer.Prepare(ctx, subject.UserID, subject.Roles, a, c.resource.Type)
+					partialAuthz, err := authorizer.Prepare(ctx, subject.UserID, subject.Roles, subject.Groups, a, c.resource.Type)
 					require.NoError(t, err, "make prepared authorizer")
 
 					// Also check the rego policy can form a valid partial query result.
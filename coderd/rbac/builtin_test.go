@@ -77,7 +77,7 @@ func BenchmarkRBACFilter(b *testing.B) {
 		b.Run(c.Name, func(b *testing.B) {
 			objects := benchmarkSetup(orgs, users, b.N)
 			b.ResetTimer()
-			allowed, err := rbac.Filter(context.Background(), authorizer, c.UserID.String(), c.Roles, rbac.ActionRead, objects)
+			allowed, err := rbac.Filter(context.Background(), authorizer, c.UserID.String(), c.Roles, nil, rbac.ActionRead, objects)
 			require.NoError(b, err)
 			var _ = allowed
 		})
@@ -101,6 +101,7 @@ type authSubject struct {
 	Name   string
 	UserID string
 	Roles  []string
+	Groups []string
 }
 
 func TestRolePermissions(t *testing.T) {
@@ -118,13 +119,15 @@ func TestRolePermissions(t *testing.T) {
 
 	// Subjects to user
 	memberMe := authSubject{Name: "member_me", UserID: currentUser.String(), Roles: []string{rbac.RoleMember()}}
-	orgMemberMe := authSubject{Name: "org_member_me", UserID: currentUser.String(), Roles: []string{rbac.RoleMember(), rbac.RoleOrgMember(orgID)}}
+	// Org members are implicitly in the "Everyone" group, which has the
+	// same ID as the organization.
+	orgMemberMe := authSubject{Name: "org_member_me", UserID: currentUser.String(), Roles: []string{rbac.RoleMember(), rbac.RoleOrgMember(orgID)}, Groups: []string{orgID.String()}}
 
 	owner := authSubject{Name: "owner", UserID: adminID.String(), Roles: []string{rbac.RoleMember(), rbac.RoleOwner()}}
 	orgAdmin := authSubject{Name: "org_admin", UserID: adminID.String(), Roles: []string{rbac.RoleMember(), rbac.RoleOrgMember(orgID), rbac.RoleOrgAdmin(orgID)}}
 
-	otherOrgMember := authSubject{Name: "org_member_other", UserID: uuid.NewString(), Roles: []string{rbac.RoleMember(), rbac.RoleOrgMember(otherOrg)}}
-	otherOrgAdmin := authSubject{Name: "org_admin_other", UserID: uuid.NewString(), Roles: []string{rbac.RoleMember(), rbac.RoleOrgMember(otherOrg), rbac.RoleOrgAdmin(otherOrg)}}
+	otherOrgMember := authSubject{Name: "org_member_other", UserID: uuid.NewString(), Roles: []string{rbac.RoleMember(), rbac.RoleOrgMember(otherOrg)}, Groups: []string{otherOrg.String()}}
+	otherOrgAdmin := authSubject{Name: "org_admin_other", UserID: uuid.NewString(), Roles: []string{rbac.RoleMember(), rbac.RoleOrgMember(otherOrg), rbac.RoleOrgAdmin(otherOrg)}, Groups: []string{otherOrg.String()}}
 
 	templateAdmin := authSubject{Name: "template-admin", UserID: templateAdminID.String(), Roles: []string{rbac.RoleMember(), rbac.RoleTemplateAdmin()}}
 	userAdmin := authSubject{Name: "user-admin", UserID: templateAdminID.String(), Roles: []string{rbac.RoleMember(), rbac.RoleUserAdmin()}}
@@ -194,14 +197,54 @@ func TestRolePermissions(t *testing.T) {
 			},
 		},
 		{
-			Name:     "ReadTemplates",
+			Name:    "ReadTemplates",
+			Actions: []rbac.Action{rbac.ActionRead},
+			Resource: rbac.ResourceTemplate.InOrg(orgID).WithGroupACL(map[string][]rbac.Action{
+				orgID.String(): {rbac.ActionRead},
+			}),
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner, orgMemberMe, orgAdmin, templateAdmin},
+				false: {memberMe, otherOrgAdmin, otherOrgMember, userAdmin},
+			},
+		},
+		{
+			Name:     "ReadTemplatesWithoutACL",
 			Actions:  []rbac.Action{rbac.ActionRead},
 			Resource: rbac.ResourceTemplate.InOrg(orgID),
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner, orgAdmin, templateAdmin},
+				false: {memberMe, orgMemberMe, otherOrgAdmin, otherOrgMember, userAdmin},
+			},
+		},
+		{
+			Name:    "TemplateUserACL",
+			Actions: []rbac.Action{rbac.ActionCreate, rbac.ActionRead, rbac.ActionUpdate, rbac.ActionDelete},
+			Resource: rbac.ResourceTemplate.InOrg(orgID).WithACLUserList(map[string][]rbac.Action{
+				currentUser.String(): {rbac.ActionWildcard},
+			}),
 			AuthorizeMap: map[bool][]authSubject{
 				true:  {owner, orgMemberMe, orgAdmin, templateAdmin},
 				false: {memberMe, otherOrgAdmin, otherOrgMember, userAdmin},
 			},
 		},
+		{
+			Name:     "Groups",
+			Actions:  []rbac.Action{rbac.ActionCreate, rbac.ActionUpdate, rbac.ActionDelete},
+			Resource: rbac.ResourceGroup.InOrg(orgID),
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner, orgAdmin},
+				false: {memberMe, orgMemberMe, otherOrgAdmin, otherOrgMember, templateAdmin, userAdmin},
+			},
+		},
+		{
+			Name:     "ReadGroups",
+			Actions:  []rbac.Action{rbac.ActionRead},
+			Resource: rbac.ResourceGroup.InOrg(orgID),
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner, orgAdmin, orgMemberMe, templateAdmin},
+				false: {memberMe, otherOrgAdmin, otherOrgMember, userAdmin},
+			},
+		},
 		{
 			Name:     "Files",
 			Actions:  []rbac.Action{rbac.ActionCreate},
@@ -319,6 +362,33 @@ func TestRolePermissions(t *testing.T) {
 				false: {memberMe, otherOrgAdmin, otherOrgMember, templateAdmin},
 			},
 		},
+		{
+			Name:     "WorkspaceSessionRecording",
+			Actions:  []rbac.Action{rbac.ActionRead},
+			Resource: rbac.ResourceWorkspaceSessionRecording,
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner},
+				false: {orgAdmin, memberMe, orgMemberMe, otherOrgAdmin, otherOrgMember, templateAdmin, userAdmin},
+			},
+		},
+		{
+			Name:     "ProvisionerJob",
+			Actions:  []rbac.Action{rbac.ActionRead},
+			Resource: rbac.ResourceProvisionerJob,
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner},
+				false: {orgAdmin, memberMe, orgMemberMe, otherOrgAdmin, otherOrgMember, templateAdmin, userAdmin},
+			},
+		},
+		{
+			Name:     "WorkspaceConnection",
+			Actions:  []rbac.Action{rbac.ActionRead},
+			Resource: rbac.ResourceWorkspaceConnection,
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner},
+				false: {orgAdmin, memberMe, orgMemberMe, otherOrgAdmin, otherOrgMember, templateAdmin, userAdmin},
+			},
+		},
 	}
 
 	for _, c := range testCases {
@@ -335,7 +405,7 @@ func TestRolePermissions(t *testing.T) {
 					for _, subj := range subjs {
 						delete(remainingSubjs, subj.Name)
 						msg := fmt.Sprintf("%s as %q doing %q on %q", c.Name, subj.Name, action, c.Resource.Type)
-						err := auth.ByRoleName(context.Background(), subj.UserID, subj.Roles, action, c.Resource)
+						err := auth.ByRoleName(context.Background(), subj.UserID, subj.Roles, subj.Groups, action, c.Resource)
 						if result {
 							assert.NoError(t, err, fmt.Sprintf("Should pass: %s", msg))
 						} else {
@@ -53,6 +53,14 @@ var (
 		Type: "provisioner_daemon",
 	}
 
+	// ResourceProvisionerJob is the queue of provisioner jobs across the
+	// deployment. Jobs are otherwise read through the workspace build or
+	// template version they belong to.
+	//	read = list pending and running jobs
+	ResourceProvisionerJob = Object{
+		Type: "provisioner_job",
+	}
+
 	// ResourceOrganization CRUD. Has an org owner on all but 'create'.
 	//	create/delete = make or delete organizations
 	// 	read = view org information (Can add user owner for read)
@@ -116,6 +124,14 @@ var (
 		Type: WildcardSymbol,
 	}
 
+	// ResourceGroup CRUD. Org admins only.
+	//	create/delete = Make or delete a new group.
+	//	update = Update the name or members of a group.
+	//	read = Read groups and their members.
+	ResourceGroup = Object{
+		Type: "group",
+	}
+
 	// ResourceLicense is the license in the 'licenses' table.
 	// ResourceLicense is site wide.
 	// 	create/delete = add or remove license from site.
@@ -124,6 +140,21 @@ var (
 	ResourceLicense = Object{
 		Type: "license",
 	}
+
+	// ResourceWorkspaceSessionRecording is a recording of an interactive
+	// session in a workspace. Recordings are site wide and only readable by
+	// admins, not by the owner of the workspace.
+	//	read = list and download recordings
+	ResourceWorkspaceSessionRecording = Object{
+		Type: "workspace_session_recording",
+	}
+
+	// ResourceWorkspaceConnection is the log of connections made to
+	// workspaces. Like the audit log, it's site wide.
+	//	read = list connections
+	ResourceWorkspaceConnection = Object{
+		Type: "workspace_connection",
+	}
 )
 
 // Object is used to create objects for authz checks when you have none in
@@ -138,7 +169,11 @@ type Object struct {
 
 	// Type is "workspace", "project", "app", etc
 	Type string `json:"type"`
-	// TODO: SharedUsers?
+
+	// ACLUserList and ACLGroupList grant actions on the object to
+	// individual users and groups, keyed by their IDs.
+	ACLUserList  map[string][]Action `json:"acl_user_list"`
+	ACLGroupList map[string][]Action `json:"acl_group_list"`
 }
 
 func (z Object) RBACObject() Object {
@@ -157,17 +192,43 @@ func (z Object) All() Object {
 // InOrg adds an org OwnerID to the resource
 func (z Object) InOrg(orgID uuid.UUID) Object {
 	return Object{
-		Owner: z.Owner,
-		OrgID: orgID.String(),
-		Type:  z.Type,
+		Owner:        z.Owner,
+		OrgID:        orgID.String(),
+		Type:         z.Type,
+		ACLUserList:  z.ACLUserList,
+		ACLGroupList: z.ACLGroupList,
 	}
 }
 
 // WithOwner adds an OwnerID to the resource
 func (z Object) WithOwner(ownerID string) Object {
 	return Object{
-		Owner: ownerID,
-		OrgID: z.OrgID,
-		Type:  z.Type,
+		Owner:        ownerID,
+		OrgID:        z.OrgID,
+		Type:         z.Type,
+		ACLUserList:  z.ACLUserList,
+		ACLGroupList: z.ACLGroupList,
+	}
+}
+
+// WithACLUserList adds an ACL list to a given object
+func (z Object) WithACLUserList(acl map[string][]Action) Object {
+	return Object{
+		Owner:        z.Owner,
+		OrgID:        z.OrgID,
+		Type:         z.Type,
+		ACLUserList:  acl,
+		ACLGroupList: z.ACLGroupList,
+	}
+}
+
+// WithGroupACL adds a group ACL list to a given object
+func (z Object) WithGroupACL(groups map[string][]Action) Object {
+	return Object{
+		Owner:        z.Owner,
+		OrgID:        z.OrgID,
+		Type:         z.Type,
+		ACLUserList:  z.ACLUserList,
+		ACLGroupList: groups,
 	}
 }
@@ -23,11 +23,12 @@ type PartialAuthorizer struct {
 	alwaysTrue bool
 }
 
-func newPartialAuthorizer(ctx context.Context, subjectID string, roles []Role, action Action, objectType string) (*PartialAuthorizer, error) {
+func newPartialAuthorizer(ctx context.Context, subjectID string, roles []Role, groups []string, action Action, objectType string) (*PartialAuthorizer, error) {
 	input := map[string]interface{}{
 		"subject": authSubject{
-			ID:    subjectID,
-			Roles: roles,
+			ID:     subjectID,
+			Roles:  roles,
+			Groups: groups,
 		},
 		"object": map[string]string{
 			"type": objectType,
@@ -43,6 +44,8 @@ func newPartialAuthorizer(ctx context.Context, subjectID string, roles []Role, a
 		rego.Unknowns([]string{
 			"input.object.owner",
 			"input.object.org_owner",
+			"input.object.acl_user_list",
+			"input.object.acl_group_list",
 		}),
 		rego.Input(input),
 	).Partial(ctx)
@@ -132,6 +132,25 @@ user := num {
     num := number(allow)
 }
 
+# ACLs grant actions on a single object to individual users and groups,
+# regardless of the subject's roles. A site level negation still applies.
+#
+# acl_allow has no default, as a default value would prevent the policy
+# from compressing into queries with partial evaluation.
+acl_allow {
+	# The subject must be a member of the object's organization, if any.
+	org_mem
+	perms := input.object.acl_user_list[input.subject.id]
+	[input.action, "*"][_] in perms
+}
+
+acl_allow {
+	org_mem
+	group := input.subject.groups[_]
+	perms := input.object.acl_group_list[group]
+	[input.action, "*"][_] in perms
+}
+
 # The allow block is quite simple. Any set with `-1` cascades down in levels.
 # Authorization looks for any `allow` statement that is true. Multiple can be true!
 # Note that the absence of `allow` means "unauthorized".
@@ -156,3 +175,8 @@ allow {
 	org_mem
 	user = 1
 }
+
+allow {
+	not site = -1
+	acl_allow
+}
@@ -0,0 +1,201 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// maxSessionRecordingSize is the largest recording an agent can upload. The
+// agent stops recording output once it's reached.
+const maxSessionRecordingSize = 100 << 20
+
+func (api *API) postWorkspaceAgentSessionRecording(rw http.ResponseWriter, r *http.Request) {
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != codersdk.SessionRecordingContentType {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Unsupported content type header %q.", contentType),
+		})
+		return
+	}
+
+	parser := httpapi.NewQueryParamParser()
+	queryParams := r.URL.Query()
+	recordingType := httpapi.ParseCustom(parser, queryParams, "", "type", parseSessionRecordingType)
+	startedAt := httpapi.ParseCustom(parser, queryParams, time.Time{}, "started_at", parseSessionRecordingTime)
+	endedAt := httpapi.ParseCustom(parser, queryParams, time.Time{}, "ended_at", parseSessionRecordingTime)
+	if len(parser.Errors) > 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: parser.Errors,
+		})
+		return
+	}
+	if startedAt.IsZero() || endedAt.IsZero() || endedAt.Before(startedAt) {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "A recording must have a start time before its end time.",
+		})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, maxSessionRecordingSize)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to read recording from request.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	workspace, err := api.workspaceByAgent(r.Context(), workspaceAgent)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	_, err = api.Database.InsertWorkspaceSessionRecording(r.Context(), database.InsertWorkspaceSessionRecordingParams{
+		ID:          uuid.New(),
+		CreatedAt:   database.Now(),
+		WorkspaceID: workspace.ID,
+		AgentID:     workspaceAgent.ID,
+		Type:        recordingType,
+		StartedAt:   startedAt,
+		EndedAt:     endedAt,
+		Size:        int64(len(data)),
+		Data:        data,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(rw, http.StatusCreated, nil)
+}
+
+func (api *API) workspaceSessionRecordings(rw http.ResponseWriter, r *http.Request) {
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceWorkspaceSessionRecording) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	recordings, err := api.Database.GetWorkspaceSessionRecordingsByWorkspaceID(r.Context(), workspace.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session recordings.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiRecordings := make([]codersdk.WorkspaceSessionRecording, 0, len(recordings))
+	for _, recording := range recordings {
+		apiRecordings = append(apiRecordings, codersdk.WorkspaceSessionRecording{
+			ID:          recording.ID,
+			CreatedAt:   recording.CreatedAt,
+			WorkspaceID: recording.WorkspaceID,
+			AgentID:     recording.AgentID,
+			Type:        codersdk.WorkspaceSessionRecordingType(recording.Type),
+			StartedAt:   recording.StartedAt,
+			EndedAt:     recording.EndedAt,
+			Size:        recording.Size,
+		})
+	}
+	httpapi.Write(rw, http.StatusOK, apiRecordings)
+}
+
+func (api *API) workspaceSessionRecording(rw http.ResponseWriter, r *http.Request) {
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceWorkspaceSessionRecording) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	rawID := chi.URLParam(r, "sessionrecording")
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid UUID %q.", rawID),
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	recording, err := api.Database.GetWorkspaceSessionRecordingByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.Header().Set("Content-Type", codersdk.SessionRecordingContentType)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", recording.ID.String()+".cast"))
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(recording.Data)
+}
+
+// workspaceByAgent returns the workspace an agent belongs to.
+func (api *API) workspaceByAgent(ctx context.Context, workspaceAgent database.WorkspaceAgent) (database.Workspace, error) {
+	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
+	if err != nil {
+		return database.Workspace{}, xerrors.Errorf("get workspace resource: %w", err)
+	}
+	build, err := api.Database.GetWorkspaceBuildByJobID(ctx, resource.JobID)
+	if err != nil {
+		return database.Workspace{}, xerrors.Errorf("get workspace build: %w", err)
+	}
+	workspace, err := api.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		return database.Workspace{}, xerrors.Errorf("get workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+func parseSessionRecordingType(v string) (database.WorkspaceSessionRecordingType, error) {
+	recordingType := database.WorkspaceSessionRecordingType(v)
+	switch recordingType {
+	case database.WorkspaceSessionRecordingTypeSsh, database.WorkspaceSessionRecordingTypeReconnectingPty:
+		return recordingType, nil
+	default:
+		return "", xerrors.Errorf("invalid session recording type %q", v)
+	}
+}
+
+func parseSessionRecordingTime(v string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, v)
+}
@@ -0,0 +1,118 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/util/ptr"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceSessionRecordings(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, member, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	metadata, err := agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.False(t, metadata.SessionRecording)
+
+	_, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+		SessionRecording: ptr.Ref(true),
+	})
+	require.NoError(t, err)
+	metadata, err = agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.True(t, metadata.SessionRecording)
+
+	startedAt := time.Now().Add(-time.Minute)
+	data := []byte(`{"version":2,"width":80,"height":24,"timestamp":0}` + "\n" + `[0.1,"o","hello"]` + "\n")
+	err = agentClient.PostWorkspaceAgentSessionRecording(ctx, agent.SessionRecording{
+		Type:      agent.SessionRecordingTypeSSH,
+		StartedAt: startedAt,
+		EndedAt:   time.Now(),
+		Data:      data,
+	})
+	require.NoError(t, err)
+
+	recordings, err := client.WorkspaceSessionRecordings(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Len(t, recordings, 1)
+	require.Equal(t, workspace.ID, recordings[0].WorkspaceID)
+	require.Equal(t, codersdk.WorkspaceSessionRecordingTypeSSH, recordings[0].Type)
+	require.Equal(t, int64(len(data)), recordings[0].Size)
+	require.WithinDuration(t, startedAt, recordings[0].StartedAt, time.Millisecond)
+
+	downloaded, err := client.WorkspaceSessionRecording(ctx, recordings[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, data, downloaded)
+
+	// Recordings are only available to admins, not to the owner of the
+	// workspace.
+	_, err = member.WorkspaceSessionRecordings(ctx, workspace.ID)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	_, err = member.WorkspaceSessionRecording(ctx, recordings[0].ID)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+	t.Run("InvalidType", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := agentClient.PostWorkspaceAgentSessionRecording(ctx, agent.SessionRecording{
+			Type:      "unknown",
+			StartedAt: startedAt,
+			EndedAt:   time.Now(),
+			Data:      data,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
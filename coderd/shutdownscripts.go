@@ -0,0 +1,349 @@
+package coderd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+)
+
+// shutdownScriptsPollInterval is how often a job that is held for shutdown
+// scripts is heartbeated and checked for cancellation.
+const shutdownScriptsPollInterval = time.Second
+
+// startShutdownScripts asks the connected agents of the workspace to run
+// their shutdown scripts before a stop or delete build is provisioned. It
+// returns false if there are no scripts to run.
+//
+// Otherwise the scripts run in the background while the job stays acquired,
+// and the job is put back in the queue once they finish. Output and exit
+// status are written to the logs of the build. Failures never prevent the
+// build from running.
+func (api *API) startShutdownScripts(ctx context.Context, job database.ProvisionerJob, build database.WorkspaceBuild) bool {
+	logger := api.Logger.With(slog.F("job_id", job.ID), slog.F("workspace_build_id", build.ID))
+	if build.BuildNumber <= 1 {
+		return false
+	}
+	// The agents of the previous build are the ones currently running.
+	previousBuild, err := api.Database.GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx, database.GetWorkspaceBuildByWorkspaceIDAndBuildNumberParams{
+		WorkspaceID: build.WorkspaceID,
+		BuildNumber: build.BuildNumber - 1,
+	})
+	if err != nil {
+		logger.Warn(ctx, "get previous workspace build", slog.Error(err))
+		return false
+	}
+	if previousBuild.Transition != database.WorkspaceTransitionStart {
+		return false
+	}
+	resources, err := api.Database.GetWorkspaceResourcesByJobID(ctx, previousBuild.JobID)
+	if err != nil {
+		logger.Warn(ctx, "get workspace resources", slog.Error(err))
+		return false
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	dbAgents, err := api.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil {
+		logger.Warn(ctx, "get workspace agents", slog.Error(err))
+		return false
+	}
+
+	agents := make([]database.WorkspaceAgent, 0, len(dbAgents))
+	for _, dbAgent := range dbAgents {
+		if dbAgent.ShutdownScript.String == "" {
+			continue
+		}
+		output := api.newShutdownScriptLogWriter(ctx, job.ID, dbAgent)
+		apiAgent, err := convertWorkspaceAgent(api.DERPMap, api.TailnetCoordinator, dbAgent, nil, api.AgentInactiveDisconnectTimeout)
+		if err != nil {
+			output.log(database.LogLevelError, fmt.Sprintf("Failed to read agent: %s", err))
+			continue
+		}
+		if apiAgent.Status != codersdk.WorkspaceAgentConnected {
+			output.log(database.LogLevelWarn, fmt.Sprintf("Skipped, the agent is %s.", apiAgent.Status))
+			continue
+		}
+		agents = append(agents, dbAgent)
+	}
+	if len(agents) == 0 {
+		return false
+	}
+
+	api.shutdownScriptsWaitGroup.Add(1)
+	go func() {
+		defer api.shutdownScriptsWaitGroup.Done()
+		api.runShutdownScripts(api.shutdownScriptsCtx, job, agents)
+	}()
+	return true
+}
+
+// runShutdownScripts runs the shutdown scripts of agents while heartbeating
+// the job, so it isn't reaped. The scripts are stopped if the job is
+// canceled, and the job is put back in the queue when they finish.
+func (api *API) runShutdownScripts(ctx context.Context, job database.ProvisionerJob, agents []database.WorkspaceAgent) {
+	logger := api.Logger.With(slog.F("job_id", job.ID))
+	scriptsCtx, cancelScripts := context.WithCancel(ctx)
+	defer cancelScripts()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for _, dbAgent := range agents {
+			dbAgent := dbAgent
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				api.runShutdownScript(ctx, scriptsCtx, job.ID, dbAgent)
+			}()
+		}
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(shutdownScriptsPollInterval)
+	defer ticker.Stop()
+	canceled := false
+wait:
+	for {
+		select {
+		case <-done:
+			break wait
+		case <-ctx.Done():
+			// coderd is shutting down. The build still runs, without the
+			// remaining scripts.
+			<-done
+			break wait
+		case <-ticker.C:
+		}
+		err := api.Database.UpdateProvisionerJobByID(ctx, database.UpdateProvisionerJobByIDParams{
+			ID:        job.ID,
+			UpdatedAt: database.Now(),
+		})
+		if err != nil {
+			logger.Warn(ctx, "heartbeat provisioner job", slog.Error(err))
+			continue
+		}
+		current, err := api.Database.GetProvisionerJobByID(ctx, job.ID)
+		if err != nil {
+			logger.Warn(ctx, "get provisioner job", slog.Error(err))
+			continue
+		}
+		if current.CanceledAt.Valid && !canceled {
+			canceled = true
+			cancelScripts()
+		}
+	}
+
+	// The context may be done, but the job must not be left acquired.
+	releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var input workspaceProvisionJob
+	err := json.Unmarshal(job.Input, &input)
+	if err != nil {
+		logger.Error(releaseCtx, "unmarshal job input", slog.Error(err))
+		return
+	}
+	input.ShutdownScriptsRan = true
+	data, err := json.Marshal(input)
+	if err != nil {
+		logger.Error(releaseCtx, "marshal job input", slog.Error(err))
+		return
+	}
+	_, err = api.Database.ReleaseProvisionerJob(releaseCtx, database.ReleaseProvisionerJobParams{
+		ID:        job.ID,
+		UpdatedAt: database.Now(),
+		Input:     data,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// The job was canceled, and no provisioner daemon is running it to
+		// acknowledge the cancellation.
+		err = api.failProvisionerJob(releaseCtx, job.ID, "Job was canceled while running shutdown scripts.")
+		if err != nil {
+			logger.Error(releaseCtx, "fail canceled provisioner job", slog.Error(err))
+		}
+		return
+	}
+	if err != nil {
+		logger.Error(releaseCtx, "release provisioner job", slog.Error(err))
+	}
+}
+
+// runShutdownScript runs the shutdown script of a single agent. Output is
+// logged using ctx, and the script is stopped when scriptCtx is done.
+func (api *API) runShutdownScript(ctx, scriptCtx context.Context, jobID uuid.UUID, dbAgent database.WorkspaceAgent) {
+	output := api.newShutdownScriptLogWriter(ctx, jobID, dbAgent)
+	timeoutCtx, cancel := context.WithTimeout(scriptCtx, api.AgentShutdownScriptTimeout)
+	defer cancel()
+	exitCode, err := api.requestShutdownScript(timeoutCtx, dbAgent.ID, output)
+	output.flush()
+	switch {
+	case timeoutCtx.Err() != nil && scriptCtx.Err() == nil:
+		output.log(database.LogLevelError, fmt.Sprintf("Shutdown script timed out after %s.", api.AgentShutdownScriptTimeout))
+	case scriptCtx.Err() != nil:
+		output.log(database.LogLevelWarn, "Shutdown script was stopped.")
+	case err != nil:
+		output.log(database.LogLevelError, fmt.Sprintf("Failed to run shutdown script: %s", err))
+	case exitCode != 0:
+		output.log(database.LogLevelError, fmt.Sprintf("Shutdown script exited with code %d.", exitCode))
+	default:
+		output.log(database.LogLevelInfo, "Shutdown script exited with code 0.")
+	}
+}
+
+// requestShutdownScript dials the agent and waits for it to run its shutdown
+// script. The session is closed when the context is canceled, which stops the
+// script.
+func (api *API) requestShutdownScript(ctx context.Context, agentID uuid.UUID, output *shutdownScriptLogWriter) (int, error) {
+	// Agent connections are relayed using the address of the request that
+	// dialed them. coderd itself is the client here.
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, api.AccessURL.String(), nil)
+	if err != nil {
+		return 0, xerrors.Errorf("create request: %w", err)
+	}
+	r.RemoteAddr = "127.0.0.1:0"
+	conn, release, err := api.workspaceAgentCache.Acquire(r, agentID)
+	if err != nil {
+		return 0, xerrors.Errorf("dial workspace agent: %w", err)
+	}
+	defer release()
+	sshClient, err := conn.SSHClient()
+	if err != nil {
+		return 0, xerrors.Errorf("ssh: %w", err)
+	}
+	defer sshClient.Close()
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return 0, xerrors.Errorf("create session: %w", err)
+	}
+	defer session.Close()
+	session.Stdout = output
+	session.Stderr = output
+
+	err = session.RequestSubsystem(agent.ShutdownScriptSubsystem)
+	if err != nil {
+		return 0, xerrors.Errorf("request shutdown script: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+	select {
+	case <-ctx.Done():
+		_ = session.Close()
+		return 0, ctx.Err()
+	case err = <-done:
+	}
+	var exitErr *ssh.ExitError
+	if xerrors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	return 0, err
+}
+
+func (api *API) newShutdownScriptLogWriter(ctx context.Context, jobID uuid.UUID, dbAgent database.WorkspaceAgent) *shutdownScriptLogWriter {
+	return &shutdownScriptLogWriter{
+		ctx:    ctx,
+		api:    api,
+		jobID:  jobID,
+		stage:  fmt.Sprintf("Running shutdown script for %q", dbAgent.Name),
+		logger: api.Logger.With(slog.F("job_id", jobID), slog.F("agent_id", dbAgent.ID)),
+	}
+}
+
+// shutdownScriptLogWriter writes shutdown script output to the logs of a
+// provisioner job, line by line.
+type shutdownScriptLogWriter struct {
+	ctx    context.Context
+	api    *API
+	jobID  uuid.UUID
+	stage  string
+	logger slog.Logger
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (w *shutdownScriptLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	var lines []string
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(string(w.partial[:i]), "\r"))
+		w.partial = w.partial[i+1:]
+	}
+	if len(lines) > 0 {
+		w.insertLocked(database.LogLevelInfo, lines...)
+	}
+	// Failing to store the logs shouldn't stop the script.
+	return len(p), nil
+}
+
+// flush writes any remaining partial line.
+func (w *shutdownScriptLogWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.insertLocked(database.LogLevelInfo, string(w.partial))
+		w.partial = nil
+	}
+}
+
+func (w *shutdownScriptLogWriter) log(level database.LogLevel, line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.insertLocked(level, line)
+}
+
+func (w *shutdownScriptLogWriter) insertLocked(level database.LogLevel, lines ...string) {
+	params := database.InsertProvisionerJobLogsParams{
+		JobID: w.jobID,
+	}
+	for _, line := range lines {
+		params.ID = append(params.ID, uuid.New())
+		params.CreatedAt = append(params.CreatedAt, database.Now())
+		params.Level = append(params.Level, level)
+		params.Stage = append(params.Stage, w.stage)
+		params.Source = append(params.Source, database.LogSourceProvisionerDaemon)
+		// Postgres rejects text that isn't valid UTF-8.
+		params.Output = append(params.Output, strings.ToValidUTF8(line, "\uFFFD"))
+	}
+	logs, err := w.api.Database.InsertProvisionerJobLogs(w.ctx, params)
+	if err != nil {
+		w.logger.Warn(w.ctx, "insert shutdown script logs", slog.Error(err))
+		return
+	}
+	data, err := json.Marshal(provisionerJobLogsMessage{Logs: logs})
+	if err != nil {
+		w.logger.Warn(w.ctx, "marshal shutdown script logs", slog.Error(err))
+		return
+	}
+	err = w.api.Pubsub.Publish(provisionerJobLogsChannel(w.jobID), data)
+	if err != nil {
+		w.logger.Warn(w.ctx, "publish shutdown script logs", slog.Error(err))
+	}
+}
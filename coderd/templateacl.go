@@ -0,0 +1,264 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// defaultTemplateGroupACL grants every member of the organization
+// permission to use new templates through the "Everyone" group.
+func defaultTemplateGroupACL(organizationID uuid.UUID) database.TemplateACL {
+	return database.TemplateACL{
+		organizationID.String(): convertTemplateRole(codersdk.TemplateRoleUse),
+	}
+}
+
+func (api *API) templateACL(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionRead, template) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(template.UserACL))
+	for id := range template.UserACL {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			api.Logger.Warn(ctx, "invalid user ID in template ACL", slog.F("template_id", template.ID), slog.F("id", id))
+			continue
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	dbUsers, err := api.Database.GetUsersByIDs(ctx, userIDs)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching users.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	users := make([]codersdk.TemplateUser, 0, len(dbUsers))
+	for _, user := range dbUsers {
+		users = append(users, codersdk.TemplateUser{
+			User: convertUser(user, []uuid.UUID{template.OrganizationID}),
+			Role: convertToTemplateRole(template.UserACL[user.ID.String()]),
+		})
+	}
+
+	groups := make([]codersdk.TemplateGroup, 0, len(template.GroupACL))
+	for id, actions := range template.GroupACL {
+		groupID, err := uuid.Parse(id)
+		if err != nil {
+			api.Logger.Warn(ctx, "invalid group ID in template ACL", slog.F("template_id", template.ID), slog.F("id", id))
+			continue
+		}
+		group, err := api.Database.GetGroupByID(ctx, groupID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The group was deleted.
+			continue
+		}
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching group.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		members, err := api.Database.GetGroupMembers(ctx, group.ID)
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching group members.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		groups = append(groups, codersdk.TemplateGroup{
+			Group: convertGroup(group, members),
+			Role:  convertToTemplateRole(actions),
+		})
+	}
+
+	httpapi.Write(rw, http.StatusOK, codersdk.TemplateACL{
+		Users:  users,
+		Groups: groups,
+	})
+}
+
+func (api *API) patchTemplateACL(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionUpdate, template) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateTemplateACL
+	if !httpapi.Read(rw, r, &req) {
+		return
+	}
+
+	validErrs := api.validateTemplateACLPerms(ctx, template.OrganizationID, req)
+	if len(validErrs) > 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid request to update template ACL.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	err := api.Database.InTx(func(tx database.Store) error {
+		template, err := tx.GetTemplateByID(ctx, template.ID)
+		if err != nil {
+			return xerrors.Errorf("get template by ID: %w", err)
+		}
+		if template.UserACL == nil {
+			template.UserACL = database.TemplateACL{}
+		}
+		if template.GroupACL == nil {
+			template.GroupACL = database.TemplateACL{}
+		}
+
+		for id, role := range req.UserPerms {
+			if role == codersdk.TemplateRoleDeleted {
+				delete(template.UserACL, id)
+				continue
+			}
+			template.UserACL[id] = convertTemplateRole(role)
+		}
+		for id, role := range req.GroupPerms {
+			if role == codersdk.TemplateRoleDeleted {
+				delete(template.GroupACL, id)
+				continue
+			}
+			template.GroupACL[id] = convertTemplateRole(role)
+		}
+
+		err = tx.UpdateTemplateACLByID(ctx, database.UpdateTemplateACLByIDParams{
+			ID:       template.ID,
+			UserACL:  template.UserACL,
+			GroupACL: template.GroupACL,
+		})
+		if err != nil {
+			return xerrors.Errorf("update template ACL by ID: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating template ACL.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(rw, http.StatusOK, codersdk.Response{
+		Message: "Successfully updated template ACL list.",
+	})
+}
+
+// validateTemplateACLPerms ensures every user is a member and every group
+// belongs to the template's organization, and that every role is known.
+func (api *API) validateTemplateACLPerms(ctx context.Context, organizationID uuid.UUID, req codersdk.UpdateTemplateACL) []codersdk.ValidationError {
+	var validErrs []codersdk.ValidationError
+	for id, role := range req.UserPerms {
+		field := fmt.Sprintf("user_perms[%s]", id)
+		if err := validateTemplateRole(role); err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: field, Detail: err.Error()})
+			continue
+		}
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: field, Detail: "User ID must be a valid UUID."})
+			continue
+		}
+		if role == codersdk.TemplateRoleDeleted {
+			continue
+		}
+		_, err = api.Database.GetOrganizationMemberByUserID(ctx, database.GetOrganizationMemberByUserIDParams{
+			OrganizationID: organizationID,
+			UserID:         userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: field, Detail: "User must be a member of the template's organization."})
+			continue
+		}
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: field, Detail: err.Error()})
+		}
+	}
+
+	for id, role := range req.GroupPerms {
+		field := fmt.Sprintf("group_perms[%s]", id)
+		if err := validateTemplateRole(role); err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: field, Detail: err.Error()})
+			continue
+		}
+		groupID, err := uuid.Parse(id)
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: field, Detail: "Group ID must be a valid UUID."})
+			continue
+		}
+		if role == codersdk.TemplateRoleDeleted {
+			continue
+		}
+		group, err := api.Database.GetGroupByID(ctx, groupID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && group.OrganizationID != organizationID) {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: field, Detail: "Group must belong to the template's organization."})
+			continue
+		}
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: field, Detail: err.Error()})
+		}
+	}
+
+	return validErrs
+}
+
+func validateTemplateRole(role codersdk.TemplateRole) error {
+	actions := convertTemplateRole(role)
+	if actions == nil && role != codersdk.TemplateRoleDeleted {
+		return xerrors.Errorf("role %q is not a valid template role", role)
+	}
+
+	return nil
+}
+
+// convertTemplateRole returns the RBAC actions a template role grants.
+func convertTemplateRole(role codersdk.TemplateRole) []rbac.Action {
+	switch role {
+	case codersdk.TemplateRoleAdmin:
+		return []rbac.Action{rbac.ActionWildcard}
+	case codersdk.TemplateRoleUse:
+		return []rbac.Action{rbac.ActionRead}
+	}
+
+	return nil
+}
+
+// convertToTemplateRole returns the template role that grants the actions.
+func convertToTemplateRole(actions []rbac.Action) codersdk.TemplateRole {
+	switch {
+	case len(actions) == 1 && actions[0] == rbac.ActionRead:
+		return codersdk.TemplateRoleUse
+	case len(actions) == 1 && actions[0] == rbac.ActionWildcard:
+		return codersdk.TemplateRoleAdmin
+	}
+
+	return ""
+}
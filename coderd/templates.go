@@ -229,6 +229,8 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 			MaxTtl:               int64(maxTTL),
 			MinAutostartInterval: int64(minAutostartInterval),
 			CreatedBy:            apiKey.UserID,
+			UserACL:              database.TemplateACL{},
+			GroupACL:             defaultTemplateGroupACL(organization.ID),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert template: %s", err)
@@ -415,6 +417,15 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 	if req.MinAutostartIntervalMillis < 0 {
 		validErrs = append(validErrs, codersdk.ValidationError{Field: "min_autostart_interval_ms", Detail: "Must be a positive integer."})
 	}
+	if req.InactivityTTLMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if req.InactivityTTLMillis > 0 && req.InactivityTTLMillis < ttlMin.Milliseconds() {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be at least " + ttlMin.String() + "."})
+	}
+	if req.MaxJobDurationMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "max_job_duration_ms", Detail: "Must be a positive integer."})
+	}
 	if req.MaxTTLMillis > maxTTLDefault.Milliseconds() {
 		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
 			Message: "Invalid create template request.",
@@ -449,11 +460,24 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			count = uint32(workspaceCounts[0].Count)
 		}
 
+		waitForAgentReady := template.WaitForAgentReady
+		if req.WaitForAgentReady != nil {
+			waitForAgentReady = *req.WaitForAgentReady
+		}
+		sessionRecording := template.SessionRecording
+		if req.SessionRecording != nil {
+			sessionRecording = *req.SessionRecording
+		}
+
 		if req.Name == template.Name &&
 			req.Description == template.Description &&
 			req.Icon == template.Icon &&
 			req.MaxTTLMillis == time.Duration(template.MaxTtl).Milliseconds() &&
-			req.MinAutostartIntervalMillis == time.Duration(template.MinAutostartInterval).Milliseconds() {
+			req.MinAutostartIntervalMillis == time.Duration(template.MinAutostartInterval).Milliseconds() &&
+			req.InactivityTTLMillis == time.Duration(template.InactivityTtl).Milliseconds() &&
+			req.MaxJobDurationMillis == time.Duration(template.MaxJobDuration).Milliseconds() &&
+			waitForAgentReady == template.WaitForAgentReady &&
+			sessionRecording == template.SessionRecording {
 			return nil
 		}
 
@@ -463,6 +487,8 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		icon := req.Icon
 		maxTTL := time.Duration(req.MaxTTLMillis) * time.Millisecond
 		minAutostartInterval := time.Duration(req.MinAutostartIntervalMillis) * time.Millisecond
+		inactivityTTL := time.Duration(req.InactivityTTLMillis) * time.Millisecond
+		maxJobDuration := time.Duration(req.MaxJobDurationMillis) * time.Millisecond
 
 		if name == "" {
 			name = template.Name
@@ -482,6 +508,10 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			Icon:                 icon,
 			MaxTtl:               int64(maxTTL),
 			MinAutostartInterval: int64(minAutostartInterval),
+			InactivityTtl:        int64(inactivityTTL),
+			WaitForAgentReady:    waitForAgentReady,
+			SessionRecording:     sessionRecording,
+			MaxJobDuration:       int64(maxJobDuration),
 		}); err != nil {
 			return err
 		}
@@ -630,6 +660,8 @@ func (api *API) autoImportTemplate(ctx context.Context, opts autoImportTemplateO
 			MaxTtl:               int64(maxTTLDefault),
 			MinAutostartInterval: int64(minAutostartIntervalDefault),
 			CreatedBy:            opts.userID,
+			UserACL:              database.TemplateACL{},
+			GroupACL:             defaultTemplateGroupACL(opts.orgID),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert template: %w", err)
@@ -723,6 +755,10 @@ func convertTemplate(template database.Template, workspaceOwnerCount uint32, cre
 		Icon:                       template.Icon,
 		MaxTTLMillis:               time.Duration(template.MaxTtl).Milliseconds(),
 		MinAutostartIntervalMillis: time.Duration(template.MinAutostartInterval).Milliseconds(),
+		InactivityTTLMillis:        time.Duration(template.InactivityTtl).Milliseconds(),
+		WaitForAgentReady:          template.WaitForAgentReady,
+		SessionRecording:           template.SessionRecording,
+		MaxJobDurationMillis:       time.Duration(template.MaxJobDuration).Milliseconds(),
 		CreatedByID:                template.CreatedBy,
 		CreatedByName:              createdByName,
 	}
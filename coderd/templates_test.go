@@ -307,6 +307,10 @@ func TestPatchTemplateMeta(t *testing.T) {
 			Icon:                       "/icons/new-icon.png",
 			MaxTTLMillis:               12 * time.Hour.Milliseconds(),
 			MinAutostartIntervalMillis: time.Minute.Milliseconds(),
+			InactivityTTLMillis:        time.Hour.Milliseconds(),
+			MaxJobDurationMillis:       30 * time.Minute.Milliseconds(),
+			WaitForAgentReady:          ptr.Ref(true),
+			SessionRecording:           ptr.Ref(true),
 		}
 		// It is unfortunate we need to sleep, but the test can fail if the
 		// updatedAt is too close together.
@@ -323,6 +327,10 @@ func TestPatchTemplateMeta(t *testing.T) {
 		assert.Equal(t, req.Icon, updated.Icon)
 		assert.Equal(t, req.MaxTTLMillis, updated.MaxTTLMillis)
 		assert.Equal(t, req.MinAutostartIntervalMillis, updated.MinAutostartIntervalMillis)
+		assert.Equal(t, req.InactivityTTLMillis, updated.InactivityTTLMillis)
+		assert.Equal(t, req.MaxJobDurationMillis, updated.MaxJobDurationMillis)
+		assert.True(t, updated.WaitForAgentReady)
+		assert.True(t, updated.SessionRecording)
 
 		// Extra paranoid: did it _really_ happen?
 		updated, err = client.Template(ctx, template.ID)
@@ -333,6 +341,10 @@ func TestPatchTemplateMeta(t *testing.T) {
 		assert.Equal(t, req.Icon, updated.Icon)
 		assert.Equal(t, req.MaxTTLMillis, updated.MaxTTLMillis)
 		assert.Equal(t, req.MinAutostartIntervalMillis, updated.MinAutostartIntervalMillis)
+		assert.Equal(t, req.InactivityTTLMillis, updated.InactivityTTLMillis)
+		assert.Equal(t, req.MaxJobDurationMillis, updated.MaxJobDurationMillis)
+		assert.True(t, updated.WaitForAgentReady)
+		assert.True(t, updated.SessionRecording)
 	})
 
 	t.Run("NoMaxTTL", func(t *testing.T) {
@@ -416,6 +428,28 @@ func TestPatchTemplateMeta(t *testing.T) {
 		assert.Equal(t, updated.MaxTTLMillis, template.MaxTTLMillis)
 	})
 
+	t.Run("InactivityTTLTooShort", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		req := codersdk.UpdateTemplateMeta{
+			InactivityTTLMillis: time.Second.Milliseconds(),
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.UpdateTemplateMeta(ctx, template.ID, req)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "inactivity_ttl_ms", apiErr.Validations[0].Field)
+	})
+
 	t.Run("NotModified", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,500 @@
+package coderd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/diff"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// templateVersionDiff returns the changes from the template version in the
+// URL to the other template version in the URL.
+func (api *API) templateVersionDiff(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx      = r.Context()
+		from     = httpmw.TemplateVersionParam(r)
+		template = httpmw.TemplateParam(r)
+		toID     = chi.URLParam(r, "othertemplateversion")
+	)
+	// The diff includes the source files of both versions, which only
+	// users who can edit a template may read.
+	if !api.Authorize(r, rbac.ActionUpdate, from.RBACObject(template)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	toUUID, err := uuid.Parse(toID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Template version ID %q must be a valid UUID.", toID),
+			Detail:  err.Error(),
+		})
+		return
+	}
+	to, err := api.Database.GetTemplateVersionByID(ctx, toUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	var toTemplate database.Template
+	if to.TemplateID.Valid {
+		toTemplate, err = api.Database.GetTemplateByID(ctx, to.TemplateID.UUID)
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching template.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+	if !api.Authorize(r, rbac.ActionUpdate, to.RBACObject(toTemplate)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	fromJob, err := api.Database.GetProvisionerJobByID(ctx, from.JobID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	toJob, err := api.Database.GetProvisionerJobByID(ctx, to.JobID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// The source of versions in git repositories isn't stored, and the
+	// repositories are only reachable from provisioner daemons.
+	for _, job := range []database.ProvisionerJob{fromJob, toJob} {
+		if job.StorageMethod != database.ProvisionerStorageMethodFile {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Template versions stored with the %q storage method can't be diffed.", job.StorageMethod),
+			})
+			return
+		}
+	}
+
+	fromFiles, err := api.templateVersionSourceFiles(ctx, fromJob)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error reading template version source.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	toFiles, err := api.templateVersionSourceFiles(ctx, toJob)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error reading template version source.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	files, err := diffTemplateVersionFiles(fromFiles, toFiles)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error diffing template version source.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	fromSchemas, err := api.Database.GetParameterSchemasByJobID(ctx, fromJob.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error listing parameter schemas.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	toSchemas, err := api.Database.GetParameterSchemasByJobID(ctx, toJob.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error listing parameter schemas.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	fromResources, err := api.Database.GetWorkspaceResourcesByJobID(ctx, fromJob.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error listing resources.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	toResources, err := api.Database.GetWorkspaceResourcesByJobID(ctx, toJob.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error listing resources.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(fromResources)+len(toResources))
+	for _, resource := range fromResources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	for _, resource := range toResources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := api.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error listing workspace agents.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	metadata, err := api.Database.GetWorkspaceResourceMetadataByResourceIDs(ctx, resourceIDs)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error listing resource metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(rw, http.StatusOK, codersdk.TemplateVersionDiff{
+		From:             from.ID,
+		To:               to.ID,
+		Files:            files,
+		ParameterSchemas: diffParameterSchemas(fromSchemas, toSchemas),
+		Resources:        diffWorkspaceResources(fromResources, toResources, agents, metadata),
+	})
+}
+
+// templateVersionSourceFiles returns the contents of the regular files in
+// the source archive of an import job by their path.
+func (api *API) templateVersionSourceFiles(ctx context.Context, job database.ProvisionerJob) (map[string][]byte, error) {
+	file, err := api.Database.GetFileByHash(ctx, job.StorageSource)
+	if err != nil {
+		return nil, xerrors.Errorf("get file: %w", err)
+	}
+	files := map[string][]byte{}
+	reader := tar.NewReader(bytes.NewReader(file.Data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("read archive: %w", err)
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, xerrors.Errorf("read %s: %w", header.Name, err)
+		}
+		files[path.Clean(header.Name)] = data
+	}
+}
+
+// diffTemplateVersionFiles returns a unified diff of each file that differs
+// between two template version sources, sorted by path.
+func diffTemplateVersionFiles(from, to map[string][]byte) ([]codersdk.TemplateVersionFileDiff, error) {
+	paths := make([]string, 0, len(from)+len(to))
+	for name := range from {
+		paths = append(paths, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			paths = append(paths, name)
+		}
+	}
+	sort.Strings(paths)
+
+	diffs := make([]codersdk.TemplateVersionFileDiff, 0)
+	for _, name := range paths {
+		fromData, inFrom := from[name]
+		toData, inTo := to[name]
+		fileDiff := codersdk.TemplateVersionFileDiff{
+			Path: name,
+		}
+		switch {
+		case !inFrom:
+			fileDiff.Status = codersdk.TemplateVersionDiffStatusAdded
+		case !inTo:
+			fileDiff.Status = codersdk.TemplateVersionDiffStatusRemoved
+		case bytes.Equal(fromData, toData):
+			continue
+		default:
+			fileDiff.Status = codersdk.TemplateVersionDiffStatusModified
+		}
+		if isBinary(fromData) || isBinary(toData) {
+			fileDiff.Binary = true
+			diffs = append(diffs, fileDiff)
+			continue
+		}
+
+		// Added and removed files are diffed against an empty file, named
+		// /dev/null like git does.
+		fromName, toName := "a/"+name, "b/"+name
+		if !inFrom {
+			fromName = "/dev/null"
+		}
+		if !inTo {
+			toName = "/dev/null"
+		}
+		var buf bytes.Buffer
+		err := diff.Text(fromName, toName, fromData, toData, &buf)
+		if err != nil {
+			return nil, xerrors.Errorf("diff %s: %w", name, err)
+		}
+		fileDiff.Diff = buf.String()
+		diffs = append(diffs, fileDiff)
+	}
+	return diffs, nil
+}
+
+// isBinary reports whether data looks like the contents of a binary file.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) != -1 || !utf8.Valid(data)
+}
+
+// diffParameterSchemas returns the parameter schemas that were added,
+// removed or modified between two import jobs, sorted by name.
+func diffParameterSchemas(from, to []database.ParameterSchema) []codersdk.TemplateVersionParameterSchemaDiff {
+	fromByName := make(map[string]database.ParameterSchema, len(from))
+	for _, schema := range from {
+		fromByName[schema.Name] = schema
+	}
+	toByName := make(map[string]database.ParameterSchema, len(to))
+	for _, schema := range to {
+		toByName[schema.Name] = schema
+	}
+
+	diffs := make([]codersdk.TemplateVersionParameterSchemaDiff, 0)
+	for name := range fromByName {
+		if _, ok := toByName[name]; !ok {
+			diffs = append(diffs, codersdk.TemplateVersionParameterSchemaDiff{
+				Name:   name,
+				Status: codersdk.TemplateVersionDiffStatusRemoved,
+			})
+		}
+	}
+	for name, toSchema := range toByName {
+		fromSchema, ok := fromByName[name]
+		if !ok {
+			diffs = append(diffs, codersdk.TemplateVersionParameterSchemaDiff{
+				Name:   name,
+				Status: codersdk.TemplateVersionDiffStatusAdded,
+			})
+			continue
+		}
+		fields := parameterSchemaChangedFields(fromSchema, toSchema)
+		if len(fields) == 0 {
+			continue
+		}
+		diffs = append(diffs, codersdk.TemplateVersionParameterSchemaDiff{
+			Name:   name,
+			Status: codersdk.TemplateVersionDiffStatusModified,
+			Fields: fields,
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+// parameterSchemaChangedFields returns the JSON names of the fields that
+// differ between two versions of a parameter schema.
+func parameterSchemaChangedFields(from, to database.ParameterSchema) []string {
+	fields := []struct {
+		name    string
+		changed bool
+	}{
+		{"description", from.Description != to.Description},
+		{"default_source_scheme", from.DefaultSourceScheme != to.DefaultSourceScheme},
+		{"default_source_value", from.DefaultSourceValue != to.DefaultSourceValue},
+		{"allow_override_source", from.AllowOverrideSource != to.AllowOverrideSource},
+		{"default_destination_scheme", from.DefaultDestinationScheme != to.DefaultDestinationScheme},
+		{"allow_override_destination", from.AllowOverrideDestination != to.AllowOverrideDestination},
+		{"default_refresh", from.DefaultRefresh != to.DefaultRefresh},
+		{"redisplay_value", from.RedisplayValue != to.RedisplayValue},
+		{"validation_error", from.ValidationError != to.ValidationError},
+		{"validation_condition", from.ValidationCondition != to.ValidationCondition},
+		{"validation_type_system", from.ValidationTypeSystem != to.ValidationTypeSystem},
+		{"validation_value_type", from.ValidationValueType != to.ValidationValueType},
+	}
+	var changed []string
+	for _, field := range fields {
+		if field.changed {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// diffResource is a resource of an import job along with its agents and
+// metadata by name.
+type diffResource struct {
+	resource database.WorkspaceResource
+	agents   map[string]database.WorkspaceAgent
+	metadata map[string]database.WorkspaceResourceMetadatum
+}
+
+// diffWorkspaceResources returns the resources that were added, removed or
+// modified between two import jobs, sorted by type and name. Imports record
+// the resources of both the start and stop transitions, so a resource is only
+// counted once, with the agents and metadata of both.
+func diffWorkspaceResources(from, to []database.WorkspaceResource, agents []database.WorkspaceAgent, metadata []database.WorkspaceResourceMetadatum) []codersdk.TemplateVersionResourceDiff {
+	agentsByResourceID := make(map[uuid.UUID][]database.WorkspaceAgent)
+	for _, agent := range agents {
+		agentsByResourceID[agent.ResourceID] = append(agentsByResourceID[agent.ResourceID], agent)
+	}
+	metadataByResourceID := make(map[uuid.UUID][]database.WorkspaceResourceMetadatum)
+	for _, field := range metadata {
+		metadataByResourceID[field.WorkspaceResourceID] = append(metadataByResourceID[field.WorkspaceResourceID], field)
+	}
+	byKey := func(resources []database.WorkspaceResource) map[string]diffResource {
+		resourcesByKey := make(map[string]diffResource, len(resources))
+		for _, resource := range resources {
+			key := resource.Type + "." + resource.Name
+			diff, ok := resourcesByKey[key]
+			if !ok {
+				diff = diffResource{
+					resource: resource,
+					agents:   map[string]database.WorkspaceAgent{},
+					metadata: map[string]database.WorkspaceResourceMetadatum{},
+				}
+				resourcesByKey[key] = diff
+			}
+			for _, agent := range agentsByResourceID[resource.ID] {
+				if _, ok := diff.agents[agent.Name]; !ok {
+					diff.agents[agent.Name] = agent
+				}
+			}
+			for _, field := range metadataByResourceID[resource.ID] {
+				if _, ok := diff.metadata[field.Key]; !ok {
+					diff.metadata[field.Key] = field
+				}
+			}
+		}
+		return resourcesByKey
+	}
+	fromByKey := byKey(from)
+	toByKey := byKey(to)
+
+	diffs := make([]codersdk.TemplateVersionResourceDiff, 0)
+	for key, resource := range fromByKey {
+		if _, ok := toByKey[key]; !ok {
+			diffs = append(diffs, codersdk.TemplateVersionResourceDiff{
+				Type:   resource.resource.Type,
+				Name:   resource.resource.Name,
+				Status: codersdk.TemplateVersionDiffStatusRemoved,
+			})
+		}
+	}
+	for key, toResource := range toByKey {
+		fromResource, ok := fromByKey[key]
+		if !ok {
+			diffs = append(diffs, codersdk.TemplateVersionResourceDiff{
+				Type:   toResource.resource.Type,
+				Name:   toResource.resource.Name,
+				Status: codersdk.TemplateVersionDiffStatusAdded,
+			})
+			continue
+		}
+		fields := workspaceResourceChangedFields(fromResource, toResource)
+		if len(fields) == 0 {
+			continue
+		}
+		diffs = append(diffs, codersdk.TemplateVersionResourceDiff{
+			Type:   toResource.resource.Type,
+			Name:   toResource.resource.Name,
+			Status: codersdk.TemplateVersionDiffStatusModified,
+			Fields: fields,
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Type != diffs[j].Type {
+			return diffs[i].Type < diffs[j].Type
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+// workspaceResourceChangedFields returns the fields that differ between two
+// versions of a resource, sorted. Agents are named "agents.<name>" when
+// they're added or removed, and "agents.<name>.<field>" when the JSON field
+// of the agent changed. Metadata is named "metadata.<key>".
+func workspaceResourceChangedFields(from, to diffResource) []string {
+	var changed []string
+	for name := range from.agents {
+		if _, ok := to.agents[name]; !ok {
+			changed = append(changed, "agents."+name)
+		}
+	}
+	for name, toAgent := range to.agents {
+		fromAgent, ok := from.agents[name]
+		if !ok {
+			changed = append(changed, "agents."+name)
+			continue
+		}
+		fields := []struct {
+			name    string
+			changed bool
+		}{
+			{"operating_system", fromAgent.OperatingSystem != toAgent.OperatingSystem},
+			{"architecture", fromAgent.Architecture != toAgent.Architecture},
+			{"directory", fromAgent.Directory != toAgent.Directory},
+			{"environment_variables", !bytes.Equal(fromAgent.EnvironmentVariables.RawMessage, toAgent.EnvironmentVariables.RawMessage)},
+			{"startup_script", fromAgent.StartupScript != toAgent.StartupScript},
+			{"shutdown_script", fromAgent.ShutdownScript != toAgent.ShutdownScript},
+		}
+		for _, field := range fields {
+			if field.changed {
+				changed = append(changed, "agents."+name+"."+field.name)
+			}
+		}
+	}
+	for key, fromField := range from.metadata {
+		toField, ok := to.metadata[key]
+		if !ok || fromField.Value != toField.Value || fromField.Sensitive != toField.Sensitive {
+			changed = append(changed, "metadata."+key)
+		}
+	}
+	for key := range to.metadata {
+		if _, ok := from.metadata[key]; !ok {
+			changed = append(changed, "metadata."+key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
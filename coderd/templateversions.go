@@ -7,6 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -23,12 +26,13 @@ import (
 
 func (api *API) templateVersion(rw http.ResponseWriter, r *http.Request) {
 	templateVersion := httpmw.TemplateVersionParam(r)
-	if !api.Authorize(r, rbac.ActionRead, templateVersion) {
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionRead, templateVersion.RBACObject(template)) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
 
-	job, err := api.Database.GetProvisionerJobByID(r.Context(), templateVersion.JobID)
+	job, queuePosition, err := getProvisionerJobWithQueuePosition(r.Context(), api.Database, templateVersion.JobID)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching provisioner job.",
@@ -46,12 +50,13 @@ func (api *API) templateVersion(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(rw, http.StatusOK, convertTemplateVersion(templateVersion, convertProvisionerJob(job), createdByName))
+	httpapi.Write(rw, http.StatusOK, convertTemplateVersion(templateVersion, convertProvisionerJob(job, queuePosition), createdByName))
 }
 
 func (api *API) patchCancelTemplateVersion(rw http.ResponseWriter, r *http.Request) {
 	templateVersion := httpmw.TemplateVersionParam(r)
-	if !api.Authorize(r, rbac.ActionUpdate, templateVersion) {
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionUpdate, templateVersion.RBACObject(template)) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
@@ -76,13 +81,7 @@ func (api *API) patchCancelTemplateVersion(rw http.ResponseWriter, r *http.Reque
 		})
 		return
 	}
-	err = api.Database.UpdateProvisionerJobWithCancelByID(r.Context(), database.UpdateProvisionerJobWithCancelByIDParams{
-		ID: job.ID,
-		CanceledAt: sql.NullTime{
-			Time:  database.Now(),
-			Valid: true,
-		},
-	})
+	err = api.cancelProvisionerJob(r.Context(), job)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error updating provisioner job.",
@@ -97,7 +96,8 @@ func (api *API) patchCancelTemplateVersion(rw http.ResponseWriter, r *http.Reque
 
 func (api *API) templateVersionSchema(rw http.ResponseWriter, r *http.Request) {
 	templateVersion := httpmw.TemplateVersionParam(r)
-	if !api.Authorize(r, rbac.ActionRead, templateVersion) {
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionRead, templateVersion.RBACObject(template)) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
@@ -145,7 +145,8 @@ func (api *API) templateVersionSchema(rw http.ResponseWriter, r *http.Request) {
 func (api *API) templateVersionParameters(rw http.ResponseWriter, r *http.Request) {
 	apiKey := httpmw.APIKey(r)
 	templateVersion := httpmw.TemplateVersionParam(r)
-	if !api.Authorize(r, rbac.ActionRead, templateVersion) {
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionRead, templateVersion.RBACObject(template)) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
@@ -189,7 +190,8 @@ func (api *API) templateVersionParameters(rw http.ResponseWriter, r *http.Reques
 func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Request) {
 	apiKey := httpmw.APIKey(r)
 	templateVersion := httpmw.TemplateVersionParam(r)
-	if !api.Authorize(r, rbac.ActionRead, templateVersion) {
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionRead, templateVersion.RBACObject(template)) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
@@ -263,6 +265,7 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 		StorageSource:  job.StorageSource,
 		Type:           database.ProvisionerJobTypeTemplateVersionDryRun,
 		Input:          input,
+		Tags:           job.Tags,
 	})
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
@@ -272,7 +275,7 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	httpapi.Write(rw, http.StatusCreated, convertProvisionerJob(provisionerJob))
+	httpapi.Write(rw, http.StatusCreated, convertProvisionerJob(provisionerJob, database.GetProvisionerJobQueuePositionsByIDsRow{}))
 }
 
 func (api *API) templateVersionDryRun(rw http.ResponseWriter, r *http.Request) {
@@ -281,7 +284,16 @@ func (api *API) templateVersionDryRun(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(rw, http.StatusOK, convertProvisionerJob(job))
+	queuePositions, err := getProvisionerJobQueuePositions(r.Context(), api.Database, job)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner job queue position.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(rw, http.StatusOK, convertProvisionerJob(job, queuePositions[job.ID]))
 }
 
 func (api *API) templateVersionDryRunResources(rw http.ResponseWriter, r *http.Request) {
@@ -328,13 +340,7 @@ func (api *API) patchTemplateVersionDryRunCancel(rw http.ResponseWriter, r *http
 		return
 	}
 
-	err := api.Database.UpdateProvisionerJobWithCancelByID(r.Context(), database.UpdateProvisionerJobWithCancelByIDParams{
-		ID: job.ID,
-		CanceledAt: sql.NullTime{
-			Time:  database.Now(),
-			Valid: true,
-		},
-	})
+	err := api.cancelProvisionerJob(r.Context(), job)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error updating provisioner job.",
@@ -351,9 +357,10 @@ func (api *API) patchTemplateVersionDryRunCancel(rw http.ResponseWriter, r *http
 func (api *API) fetchTemplateVersionDryRunJob(rw http.ResponseWriter, r *http.Request) (database.ProvisionerJob, bool) {
 	var (
 		templateVersion = httpmw.TemplateVersionParam(r)
+		template        = httpmw.TemplateParam(r)
 		jobID           = chi.URLParam(r, "jobID")
 	)
-	if !api.Authorize(r, rbac.ActionRead, templateVersion) {
+	if !api.Authorize(r, rbac.ActionRead, templateVersion.RBACObject(template)) {
 		httpapi.ResourceNotFound(rw)
 		return database.ProvisionerJob{}, false
 	}
@@ -465,7 +472,7 @@ func (api *API) templateVersionsByTemplate(rw http.ResponseWriter, r *http.Reque
 		for _, version := range versions {
 			jobIDs = append(jobIDs, version.JobID)
 		}
-		jobs, err := store.GetProvisionerJobsByIDs(r.Context(), jobIDs)
+		jobs, queuePositions, err := getProvisionerJobsWithQueuePositions(r.Context(), store, jobIDs)
 		if err != nil {
 			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 				Message: "Internal error fetching provisioner job.",
@@ -494,7 +501,7 @@ func (api *API) templateVersionsByTemplate(rw http.ResponseWriter, r *http.Reque
 				})
 				return err
 			}
-			apiVersions = append(apiVersions, convertTemplateVersion(version, convertProvisionerJob(job), createdByName))
+			apiVersions = append(apiVersions, convertTemplateVersion(version, convertProvisionerJob(job, queuePositions[job.ID]), createdByName))
 		}
 
 		return nil
@@ -534,7 +541,7 @@ func (api *API) templateVersionByName(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	job, err := api.Database.GetProvisionerJobByID(r.Context(), templateVersion.JobID)
+	job, queuePosition, err := getProvisionerJobWithQueuePosition(r.Context(), api.Database, templateVersion.JobID)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching provisioner job.",
@@ -552,7 +559,7 @@ func (api *API) templateVersionByName(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(rw, http.StatusOK, convertTemplateVersion(templateVersion, convertProvisionerJob(job), createdByName))
+	httpapi.Write(rw, http.StatusOK, convertTemplateVersion(templateVersion, convertProvisionerJob(job, queuePosition), createdByName))
 }
 
 func (api *API) patchActiveTemplateVersion(rw http.ResponseWriter, r *http.Request) {
@@ -636,30 +643,88 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 		}
 	}
 
-	file, err := api.Database.GetFileByHash(r.Context(), req.StorageSource)
-	if errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(rw, http.StatusNotFound, codersdk.Response{
-			Message: "File not found.",
-		})
-		return
-	}
-	if err != nil {
-		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching file.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-
 	// Making a new template version is the same permission as creating a new template.
 	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceTemplate.InOrg(organization.ID)) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
 
-	if !api.Authorize(r, rbac.ActionRead, file) {
-		httpapi.ResourceNotFound(rw)
-		return
+	var (
+		storageSource string
+		err           error
+	)
+	switch req.StorageMethod {
+	case codersdk.ProvisionerStorageMethodGit:
+		err = validateGitURL(req.StorageSource)
+		if err != nil {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid git repository URL.",
+				Validations: []codersdk.ValidationError{{
+					Field:  "storage_source",
+					Detail: err.Error(),
+				}},
+			})
+			return
+		}
+		if len(api.TemplateGitHosts) > 0 && !gitHostAllowed(api.TemplateGitHosts, req.StorageSource) {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Git repository host isn't allowed.",
+				Validations: []codersdk.ValidationError{{
+					Field:  "storage_source",
+					Detail: fmt.Sprintf("Must be on one of the hosts: %s.", strings.Join(api.TemplateGitHosts, ", ")),
+				}},
+			})
+			return
+		}
+		subdirectory := path.Clean(req.GitSubdirectory)
+		if path.IsAbs(subdirectory) || subdirectory == ".." || strings.HasPrefix(subdirectory, "../") {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid git subdirectory.",
+				Validations: []codersdk.ValidationError{{
+					Field:  "git_subdirectory",
+					Detail: "Must be a relative path inside of the repository.",
+				}},
+			})
+			return
+		}
+		if subdirectory == "." {
+			subdirectory = ""
+		}
+		var source []byte
+		source, err = json.Marshal(gitStorageSource{
+			URL:          req.StorageSource,
+			Ref:          req.GitRef,
+			Subdirectory: subdirectory,
+		})
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error marshaling git storage source.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		storageSource = string(source)
+	default:
+		var file database.File
+		file, err = api.Database.GetFileByHash(r.Context(), req.StorageSource)
+		if errors.Is(err, sql.ErrNoRows) {
+			httpapi.Write(rw, http.StatusNotFound, codersdk.Response{
+				Message: "File not found.",
+			})
+			return
+		}
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching file.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !api.Authorize(r, rbac.ActionRead, file) {
+			httpapi.ResourceNotFound(rw)
+			return
+		}
+		storageSource = file.Hash
 	}
 
 	var templateVersion database.TemplateVersion
@@ -736,10 +801,11 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 			OrganizationID: organization.ID,
 			InitiatorID:    apiKey.UserID,
 			Provisioner:    database.ProvisionerType(req.Provisioner),
-			StorageMethod:  database.ProvisionerStorageMethodFile,
-			StorageSource:  file.Hash,
+			StorageMethod:  database.ProvisionerStorageMethod(req.StorageMethod),
+			StorageSource:  storageSource,
 			Type:           database.ProvisionerJobTypeTemplateVersionImport,
 			Input:          []byte{'{', '}'},
+			Tags:           req.ProvisionerTags,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -788,7 +854,7 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 		return
 	}
 
-	httpapi.Write(rw, http.StatusCreated, convertTemplateVersion(templateVersion, convertProvisionerJob(provisionerJob), createdByName))
+	httpapi.Write(rw, http.StatusCreated, convertTemplateVersion(templateVersion, convertProvisionerJob(provisionerJob, database.GetProvisionerJobQueuePositionsByIDsRow{}), createdByName))
 }
 
 // templateVersionResources returns the workspace agent resources associated
@@ -798,7 +864,8 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 // return agents associated with any particular workspace.
 func (api *API) templateVersionResources(rw http.ResponseWriter, r *http.Request) {
 	templateVersion := httpmw.TemplateVersionParam(r)
-	if !api.Authorize(r, rbac.ActionRead, templateVersion) {
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionRead, templateVersion.RBACObject(template)) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
@@ -820,7 +887,8 @@ func (api *API) templateVersionResources(rw http.ResponseWriter, r *http.Request
 // Eg: Logs returned from 'terraform plan' when uploading a new terraform file.
 func (api *API) templateVersionLogs(rw http.ResponseWriter, r *http.Request) {
 	templateVersion := httpmw.TemplateVersionParam(r)
-	if !api.Authorize(r, rbac.ActionRead, templateVersion) {
+	template := httpmw.TemplateParam(r)
+	if !api.Authorize(r, rbac.ActionRead, templateVersion.RBACObject(template)) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
@@ -836,6 +904,67 @@ func (api *API) templateVersionLogs(rw http.ResponseWriter, r *http.Request) {
 	api.provisionerJobLogs(rw, r, job)
 }
 
+// validateGitURL checks that a git repository is fetched over HTTP(S) or SSH.
+// Git supports other transports, like "ext::" and "file://", that run
+// commands or read files on the host of the provisioner daemon.
+func validateGitURL(raw string) error {
+	if strings.Contains(raw, "://") {
+		repository, err := url.Parse(raw)
+		if err != nil {
+			return xerrors.Errorf("parse url: %w", err)
+		}
+		switch repository.Scheme {
+		case "https", "http", "ssh":
+		default:
+			return xerrors.Errorf("unsupported scheme %q, must be one of: https, http, ssh", repository.Scheme)
+		}
+		if repository.Host == "" || strings.HasPrefix(repository.Host, "-") {
+			return xerrors.New("must have a valid host")
+		}
+		return nil
+	}
+	// Git treats "[user@]host:path" as an SSH URL when there's no slash
+	// before the colon, "transport::address" as a remote helper, and a
+	// drive letter followed by a colon as a Windows path.
+	host, _, ok := strings.Cut(raw, ":")
+	if !ok || len(host) < 2 || strings.HasPrefix(host, "-") || strings.ContainsAny(host, `/\`) || strings.Contains(raw, "::") {
+		return xerrors.New(`must be an https, http or ssh URL, or an scp-like URL such as "git@github.com:org/repo.git"`)
+	}
+	return nil
+}
+
+// gitURLHost returns the lowercase host of a git repository URL, without its
+// port or user.
+func gitURLHost(raw string) string {
+	if strings.Contains(raw, "://") {
+		repository, err := url.Parse(raw)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(repository.Hostname())
+	}
+	host, _, _ := strings.Cut(raw, ":")
+	if index := strings.LastIndex(host, "@"); index >= 0 {
+		host = host[index+1:]
+	}
+	return strings.ToLower(host)
+}
+
+// gitHostAllowed returns whether the host of a git repository URL is one of
+// hosts.
+func gitHostAllowed(hosts []string, raw string) bool {
+	host := gitURLHost(raw)
+	if host == "" {
+		return false
+	}
+	for _, allowed := range hosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
 func getUsernameByUserID(ctx context.Context, db database.Store, userID uuid.NullUUID) (string, error) {
 	if !userID.Valid {
 		return "", nil
@@ -859,5 +988,6 @@ func convertTemplateVersion(version database.TemplateVersion, job codersdk.Provi
 		Readme:         version.Readme,
 		CreatedByID:    version.CreatedBy.UUID,
 		CreatedByName:  createdByName,
+		GitCommitSHA:   version.GitCommitSHA,
 	}
 }
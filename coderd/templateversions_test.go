@@ -1,7 +1,11 @@
 package coderd_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"testing"
 	"time"
@@ -34,6 +38,48 @@ func TestTemplateVersion(t *testing.T) {
 	})
 }
 
+func TestTemplateVersionQueuePosition(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+	first := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+	second := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	version, err := client.TemplateVersion(ctx, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, version.Job.QueuePosition)
+	require.Equal(t, 2, version.Job.QueueSize)
+
+	// Jobs with other tags are acquired by other daemons, so they're in a
+	// queue of their own.
+	data, err := echo.Tar(nil)
+	require.NoError(t, err)
+	file, err := client.Upload(ctx, codersdk.ContentTypeTar, data)
+	require.NoError(t, err)
+	tagged, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+		StorageSource:   file.Hash,
+		StorageMethod:   codersdk.ProvisionerStorageMethodFile,
+		Provisioner:     codersdk.ProvisionerTypeEcho,
+		ProvisionerTags: map[string]string{"cluster": "other"},
+	})
+	require.NoError(t, err)
+	version, err = client.TemplateVersion(ctx, tagged.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, version.Job.QueuePosition)
+	require.Equal(t, 1, version.Job.QueueSize)
+
+	// Jobs leave the queue once they're canceled.
+	err = client.CancelTemplateVersion(ctx, first.ID)
+	require.NoError(t, err)
+	version, err = client.TemplateVersion(ctx, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, version.Job.QueuePosition)
+	require.Equal(t, 1, version.Job.QueueSize)
+}
+
 func TestPostTemplateVersionsByOrganization(t *testing.T) {
 	t.Parallel()
 	t.Run("InvalidTemplate", func(t *testing.T) {
@@ -74,6 +120,93 @@ func TestPostTemplateVersionsByOrganization(t *testing.T) {
 		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
 	})
 
+	t.Run("Git", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		url, commit := coderdtest.CreateGitRepository(t, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		version, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodGit,
+			StorageSource: url,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+		})
+		require.NoError(t, err)
+		job := coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, job.Job.Status, job.Job.Error)
+		require.Equal(t, commit, job.GitCommitSHA)
+
+		// Builds check out the commit the version was imported at.
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, build.Job.Status, build.Job.Error)
+	})
+
+	t.Run("InvalidGitSubdirectory", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod:   codersdk.ProvisionerStorageMethodGit,
+			StorageSource:   "https://github.com/coder/coder",
+			GitSubdirectory: "../templates",
+			Provisioner:     codersdk.ProvisionerTypeEcho,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidGitURL", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		for _, url := range []string{"file:///etc", "ext::sh -c id", "/srv/templates.git"} {
+			_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+				StorageMethod: codersdk.ProvisionerStorageMethodGit,
+				StorageSource: url,
+				Provisioner:   codersdk.ProvisionerTypeEcho,
+			})
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr, url)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode(), url)
+		}
+	})
+
+	t.Run("GitHostNotAllowed", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{
+			TemplateGitHosts: []string{"git.example.com"},
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		for _, url := range []string{"https://attacker.example.com/templates.git", "git@attacker.example.com:templates.git"} {
+			_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+				StorageMethod: codersdk.ProvisionerStorageMethodGit,
+				StorageSource: url,
+				Provisioner:   codersdk.ProvisionerTypeEcho,
+			})
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr, url)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode(), url)
+		}
+	})
+
 	t.Run("WithParameters", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, nil)
@@ -159,6 +292,23 @@ func TestPatchCancelTemplateVersion(t *testing.T) {
 			return assert.NoError(t, err) && version.Job.Status == codersdk.ProvisionerJobFailed
 		}, testutil.WaitShort, testutil.IntervalFast)
 	})
+	t.Run("Pending", func(t *testing.T) {
+		t.Parallel()
+		// No provisioner daemon, so the job is never acquired.
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := client.CancelTemplateVersion(ctx, version.ID)
+		require.NoError(t, err)
+		version, err = client.TemplateVersion(ctx, version.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.ProvisionerJobCanceled, version.Job.Status)
+		require.NotNil(t, version.Job.CompletedAt)
+	})
 	// TODO(Cian): until we are able to test cancellation properly, validating
 	// Running -> Canceling is the best we can do for now.
 	t.Run("Canceling", func(t *testing.T) {
@@ -451,6 +601,218 @@ func TestTemplateVersionLogs(t *testing.T) {
 	}
 }
 
+func TestTemplateVersionDiff(t *testing.T) {
+	t.Parallel()
+	t.Run("Diff", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		schemas := func(schemas ...*proto.ParameterSchema) []*proto.Parse_Response {
+			for _, schema := range schemas {
+				schema.DefaultDestination = &proto.ParameterDestination{
+					Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+				}
+			}
+			return []*proto.Parse_Response{{
+				Type: &proto.Parse_Response_Complete{
+					Complete: &proto.Parse_Complete{
+						ParameterSchemas: schemas,
+					},
+				},
+			}}
+		}
+		resources := func(resources ...*proto.Resource) []*proto.Provision_Response {
+			for _, resource := range resources {
+				resource.Type = "example"
+			}
+			return []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: resources,
+					},
+				},
+			}}
+		}
+
+		from := createTemplateVersionWithFiles(t, client, user.OrganizationID, &echo.Responses{
+			Parse: schemas(
+				&proto.ParameterSchema{Name: "region", Description: "Region"},
+				&proto.ParameterSchema{Name: "size"},
+			),
+			Provision: resources(
+				&proto.Resource{Name: "a"},
+				&proto.Resource{
+					Name: "b",
+					Agents: []*proto.Agent{{
+						Name:            "main",
+						OperatingSystem: "linux",
+						StartupScript:   "echo start",
+					}, {
+						Name:            "old",
+						OperatingSystem: "linux",
+					}},
+					Metadata: []*proto.Resource_Metadata{{Key: "cpu", Value: "1"}},
+				},
+				&proto.Resource{Name: "same"},
+			),
+		}, map[string]string{
+			"main.tf": "one\ntwo\nthree\n",
+			"old.tf":  "old\n",
+		})
+		to := createTemplateVersionWithFiles(t, client, user.OrganizationID, &echo.Responses{
+			Parse: schemas(
+				&proto.ParameterSchema{Name: "region", Description: "Where to deploy"},
+				&proto.ParameterSchema{Name: "zone"},
+			),
+			Provision: resources(
+				&proto.Resource{
+					Name: "b",
+					Agents: []*proto.Agent{{
+						Name:            "main",
+						OperatingSystem: "linux",
+						StartupScript:   "echo started",
+					}},
+					Metadata: []*proto.Resource_Metadata{{Key: "cpu", Value: "2"}},
+				},
+				&proto.Resource{Name: "c"},
+				&proto.Resource{Name: "same"},
+			),
+		}, map[string]string{
+			"main.tf": "one\n2\nthree\n",
+			"new.tf":  "new\n",
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		diff, err := client.TemplateVersionDiff(ctx, from.ID, to.ID)
+		require.NoError(t, err)
+		require.Equal(t, from.ID, diff.From)
+		require.Equal(t, to.ID, diff.To)
+
+		files := map[string]codersdk.TemplateVersionFileDiff{}
+		for _, file := range diff.Files {
+			files[file.Path] = file
+		}
+		require.Equal(t, codersdk.TemplateVersionDiffStatusModified, files["main.tf"].Status)
+		require.Contains(t, files["main.tf"].Diff, "--- a/main.tf\n+++ b/main.tf\n")
+		require.Contains(t, files["main.tf"].Diff, "-two\n+2\n")
+		require.Equal(t, codersdk.TemplateVersionDiffStatusRemoved, files["old.tf"].Status)
+		require.Contains(t, files["old.tf"].Diff, "-old\n")
+		require.Equal(t, codersdk.TemplateVersionDiffStatusAdded, files["new.tf"].Status)
+		require.Contains(t, files["new.tf"].Diff, "+new\n")
+
+		require.Equal(t, []codersdk.TemplateVersionParameterSchemaDiff{
+			{Name: "region", Status: codersdk.TemplateVersionDiffStatusModified, Fields: []string{"description"}},
+			{Name: "size", Status: codersdk.TemplateVersionDiffStatusRemoved},
+			{Name: "zone", Status: codersdk.TemplateVersionDiffStatusAdded},
+		}, diff.ParameterSchemas)
+		require.Equal(t, []codersdk.TemplateVersionResourceDiff{
+			{Type: "example", Name: "a", Status: codersdk.TemplateVersionDiffStatusRemoved},
+			{Type: "example", Name: "b", Status: codersdk.TemplateVersionDiffStatusModified, Fields: []string{
+				"agents.main.startup_script", "agents.old", "metadata.cpu",
+			}},
+			{Type: "example", Name: "c", Status: codersdk.TemplateVersionDiffStatusAdded},
+		}, diff.Resources)
+	})
+	t.Run("Same", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		diff, err := client.TemplateVersionDiff(ctx, version.ID, version.ID)
+		require.NoError(t, err)
+		require.Empty(t, diff.Files)
+		require.Empty(t, diff.ParameterSchemas)
+		require.Empty(t, diff.Resources)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.TemplateVersionDiff(ctx, version.ID, uuid.New())
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+	t.Run("Member", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Members can use the template, but not read its source.
+		_, err := member.TemplateVersion(ctx, version.ID)
+		require.NoError(t, err)
+		_, err = member.TemplateVersionDiff(ctx, version.ID, version.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
+
+// createTemplateVersionWithFiles creates a template version from the echo
+// responses, with files added to its source archive, and waits for its
+// import to complete.
+func createTemplateVersionWithFiles(t *testing.T, client *codersdk.Client, organizationID uuid.UUID, res *echo.Responses, files map[string]string) codersdk.TemplateVersion {
+	t.Helper()
+	data, err := echo.Tar(res)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	reader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteHeader(header))
+		_, err = io.Copy(writer, reader)
+		require.NoError(t, err)
+	}
+	for name, content := range files {
+		require.NoError(t, writer.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0o644,
+			Size: int64(len(content)),
+		}))
+		_, err = writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	file, err := client.Upload(ctx, codersdk.ContentTypeTar, buf.Bytes())
+	require.NoError(t, err)
+	version, err := client.CreateTemplateVersion(ctx, organizationID, codersdk.CreateTemplateVersionRequest{
+		StorageMethod: codersdk.ProvisionerStorageMethodFile,
+		StorageSource: file.Hash,
+		Provisioner:   codersdk.ProvisionerTypeEcho,
+	})
+	require.NoError(t, err)
+	return coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+}
+
 func TestTemplateVersionsByTemplate(t *testing.T) {
 	t.Parallel()
 	t.Run("Get", func(t *testing.T) {
@@ -15,6 +15,7 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/xerrors"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
@@ -50,9 +51,18 @@ func (api *API) userAuthMethods(rw http.ResponseWriter, _ *http.Request) {
 
 func (api *API) userOAuth2Github(rw http.ResponseWriter, r *http.Request) {
 	var (
-		ctx   = r.Context()
-		state = httpmw.OAuth2(r)
+		ctx         = r.Context()
+		state       = httpmw.OAuth2(r)
+		auditParams = &audit.RequestParams{
+			Audit:        api.Auditor,
+			Log:          api.Logger,
+			Request:      r,
+			Action:       database.AuditActionLogin,
+			ResourceType: database.ResourceTypeAPIKey,
+		}
+		aReq, commitAudit = audit.InitRequest[database.APIKey](rw, auditParams)
 	)
+	defer commitAudit()
 
 	oauthClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(state.Token))
 	memberships, err := api.GithubOAuth2Config.ListOrganizationMemberships(ctx, oauthClient)
@@ -88,6 +98,7 @@ func (api *API) userOAuth2Github(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	auditParams.ResourceTarget = ghUser.GetLogin()
 
 	// The default if no teams are specified is to allow all.
 	if len(api.GithubOAuth2Config.AllowTeams) > 0 {
@@ -137,7 +148,7 @@ func (api *API) userOAuth2Github(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie, err := api.oauthLogin(r, oauthLoginParams{
+	cookie, key, err := api.oauthLogin(r, oauthLoginParams{
 		State:        state,
 		LinkedID:     githubLinkedID(ghUser),
 		LoginType:    database.LoginTypeGithub,
@@ -160,6 +171,9 @@ func (api *API) userOAuth2Github(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	auditParams.Actor = key.UserID
+	auditParams.ResourceID = key.UserID
+	aReq.New = *key
 
 	http.SetCookie(rw, cookie)
 
@@ -177,13 +191,26 @@ type OIDCConfig struct {
 	// EmailDomain is the domain to enforce when a user authenticates.
 	EmailDomain  string
 	AllowSignups bool
+	// GroupField is the name of the claim containing the names of the groups
+	// a user belongs to. Group membership is only synced when the claim is
+	// present in the ID token.
+	GroupField string
 }
 
 func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 	var (
-		ctx   = r.Context()
-		state = httpmw.OAuth2(r)
+		ctx         = r.Context()
+		state       = httpmw.OAuth2(r)
+		auditParams = &audit.RequestParams{
+			Audit:        api.Auditor,
+			Log:          api.Logger,
+			Request:      r,
+			Action:       database.AuditActionLogin,
+			ResourceType: database.ResourceTypeAPIKey,
+		}
+		aReq, commitAudit = audit.InitRequest[database.APIKey](rw, auditParams)
 	)
+	defer commitAudit()
 
 	// See the example here: https://github.com/coreos/go-oidc
 	rawIDToken, ok := state.Token.Extra("id_token").(string)
@@ -222,6 +249,7 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	auditParams.ResourceTarget = claims.Email
 	if !claims.Verified {
 		httpapi.Write(rw, http.StatusForbidden, codersdk.Response{
 			Message: fmt.Sprintf("Verify the %q email address on your OIDC provider to authenticate!", claims.Email),
@@ -249,13 +277,52 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	cookie, err := api.oauthLogin(r, oauthLoginParams{
+	var (
+		usingGroups bool
+		groups      []string
+	)
+	if api.OIDCConfig.GroupField != "" {
+		var rawClaims map[string]interface{}
+		err = idToken.Claims(&rawClaims)
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Failed to extract OIDC claims.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if groupsRaw, ok := rawClaims[api.OIDCConfig.GroupField]; ok {
+			usingGroups = true
+			// The group claim must be a list of group names.
+			groupsList, ok := groupsRaw.([]interface{})
+			if !ok {
+				httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+					Message: fmt.Sprintf("The %q OIDC claim must be a list of group names.", api.OIDCConfig.GroupField),
+				})
+				return
+			}
+			for _, groupRaw := range groupsList {
+				group, ok := groupRaw.(string)
+				if !ok {
+					httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+						Message: fmt.Sprintf("The %q OIDC claim must be a list of group names.", api.OIDCConfig.GroupField),
+					})
+					return
+				}
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	cookie, key, err := api.oauthLogin(r, oauthLoginParams{
 		State:        state,
 		LinkedID:     oidcLinkedID(idToken),
 		LoginType:    database.LoginTypeOIDC,
 		AllowSignups: api.OIDCConfig.AllowSignups,
 		Email:        claims.Email,
 		Username:     claims.Username,
+		UsingGroups:  usingGroups,
+		Groups:       groups,
 	})
 	var httpErr httpError
 	if xerrors.As(err, &httpErr) {
@@ -272,6 +339,9 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	auditParams.Actor = key.UserID
+	auditParams.ResourceID = key.UserID
+	aReq.New = *key
 
 	http.SetCookie(rw, cookie)
 
@@ -292,6 +362,12 @@ type oauthLoginParams struct {
 	AllowSignups bool
 	Email        string
 	Username     string
+
+	// UsingGroups is true if the identity provider supplied the user's
+	// groups, in which case the user's membership of existing groups with
+	// the same names is synced.
+	UsingGroups bool
+	Groups      []string
 }
 
 type httpError struct {
@@ -308,7 +384,9 @@ func (e httpError) Error() string {
 	return e.msg
 }
 
-func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cookie, error) {
+// oauthLogin finds or creates the user linked to the identity provider and
+// returns a session cookie for them alongside the created API key.
+func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cookie, *database.APIKey, error) {
 	var (
 		ctx  = r.Context()
 		user database.User
@@ -434,21 +512,90 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 			}
 		}
 
+		if params.UsingGroups {
+			err = syncGroupMembership(ctx, tx, user.ID, params.Groups)
+			if err != nil {
+				return xerrors.Errorf("sync group membership: %w", err)
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
-		return nil, xerrors.Errorf("in tx: %w", err)
+		return nil, nil, xerrors.Errorf("in tx: %w", err)
 	}
 
-	cookie, err := api.createAPIKey(r, createAPIKeyParams{
+	cookie, key, err := api.createAPIKey(r, createAPIKeyParams{
 		UserID:    user.ID,
 		LoginType: params.LoginType,
 	})
 	if err != nil {
-		return nil, xerrors.Errorf("create API key: %w", err)
+		return nil, nil, xerrors.Errorf("create API key: %w", err)
+	}
+
+	return cookie, key, nil
+}
+
+// syncGroupMembership makes the user a member of exactly the groups named,
+// in every organization the user belongs to. Groups that do not exist are
+// ignored rather than created.
+func syncGroupMembership(ctx context.Context, tx database.Store, userID uuid.UUID, groupNames []string) error {
+	wanted := make(map[string]struct{}, len(groupNames))
+	for _, name := range groupNames {
+		wanted[name] = struct{}{}
+	}
+
+	orgs, err := tx.GetOrganizationsByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("get organizations by user ID: %w", err)
+	}
+
+	for _, org := range orgs {
+		current, err := tx.GetUserGroups(ctx, database.GetUserGroupsParams{
+			UserID:         userID,
+			OrganizationID: org.ID,
+		})
+		if err != nil {
+			return xerrors.Errorf("get user groups: %w", err)
+		}
+		isMember := make(map[uuid.UUID]struct{}, len(current))
+		for _, group := range current {
+			isMember[group.ID] = struct{}{}
+		}
+
+		groups, err := tx.GetGroupsByOrganizationID(ctx, org.ID)
+		if err != nil {
+			return xerrors.Errorf("get groups by organization ID: %w", err)
+		}
+		for _, group := range groups {
+			// Membership of the "Everyone" group is implied.
+			if group.ID == group.OrganizationID {
+				continue
+			}
+			_, want := wanted[group.Name]
+			_, member := isMember[group.ID]
+			switch {
+			case want && !member:
+				err = tx.InsertGroupMember(ctx, database.InsertGroupMemberParams{
+					UserID:  userID,
+					GroupID: group.ID,
+				})
+				if err != nil {
+					return xerrors.Errorf("insert group member %q: %w", group.Name, err)
+				}
+			case !want && member:
+				err = tx.DeleteGroupMember(ctx, database.DeleteGroupMemberParams{
+					UserID:  userID,
+					GroupID: group.ID,
+				})
+				if err != nil {
+					return xerrors.Errorf("delete group member %q: %w", group.Name, err)
+				}
+			}
+		}
 	}
 
-	return cookie, nil
+	return nil
 }
 
 // githubLinkedID returns the unique ID for a GitHub user.
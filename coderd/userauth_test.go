@@ -20,7 +20,9 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -214,7 +216,9 @@ func TestUserOAuth2Github(t *testing.T) {
 	})
 	t.Run("Signup", func(t *testing.T) {
 		t.Parallel()
+		auditor := audit.NewMock()
 		client := coderdtest.New(t, &coderdtest.Options{
+			Auditor: auditor,
 			GithubOAuth2Config: &coderd.GithubOAuth2Config{
 				OAuth2Config:       &oauth2Config{},
 				AllowOrganizations: []string{"coder"},
@@ -249,6 +253,12 @@ func TestUserOAuth2Github(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "kyle@coder.com", user.Email)
 		require.Equal(t, "kyle", user.Username)
+
+		alogs := auditor.AuditLogs()
+		require.Len(t, alogs, 1)
+		require.Equal(t, database.AuditActionLogin, alogs[0].Action)
+		require.Equal(t, user.ID, alogs[0].UserID)
+		require.Equal(t, "kyle", alogs[0].ResourceTarget)
 	})
 	t.Run("SignupAllowedTeam", func(t *testing.T) {
 		t.Parallel()
@@ -21,6 +21,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
@@ -749,10 +750,21 @@ func (api *API) organizationByUserAndName(rw http.ResponseWriter, r *http.Reques
 
 // Authenticates the user with an email and password.
 func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
+	auditParams := &audit.RequestParams{
+		Audit:        api.Auditor,
+		Log:          api.Logger,
+		Request:      r,
+		Action:       database.AuditActionLogin,
+		ResourceType: database.ResourceTypeAPIKey,
+	}
+	aReq, commitAudit := audit.InitRequest[database.APIKey](rw, auditParams)
+	defer commitAudit()
+
 	var loginWithPassword codersdk.LoginWithPasswordRequest
 	if !httpapi.Read(rw, r, &loginWithPassword) {
 		return
 	}
+	auditParams.ResourceTarget = loginWithPassword.Email
 
 	user, err := api.Database.GetUserByEmailOrUsername(r.Context(), database.GetUserByEmailOrUsernameParams{
 		Email: loginWithPassword.Email,
@@ -763,6 +775,10 @@ func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	// If the user doesn't exist, the failed login is recorded without an
+	// actor.
+	auditParams.Actor = user.ID
+	auditParams.ResourceID = user.ID
 
 	// If the user doesn't exist, it will be a default struct.
 	equal, err := userpassword.Compare(string(user.HashedPassword), loginWithPassword.Password)
@@ -796,7 +812,7 @@ func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie, err := api.createAPIKey(r, createAPIKeyParams{
+	cookie, key, err := api.createAPIKey(r, createAPIKeyParams{
 		UserID:    user.ID,
 		LoginType: database.LoginTypePassword,
 	})
@@ -807,6 +823,7 @@ func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	aReq.New = *key
 
 	http.SetCookie(rw, cookie)
 
@@ -817,7 +834,20 @@ func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
 
 // Creates a new session key, used for logging in via the CLI.
 func (api *API) postAPIKey(rw http.ResponseWriter, r *http.Request) {
-	user := httpmw.UserParam(r)
+	var (
+		user        = httpmw.UserParam(r)
+		auditParams = &audit.RequestParams{
+			Audit:        api.Auditor,
+			Log:          api.Logger,
+			Request:      r,
+			Action:       database.AuditActionCreate,
+			ResourceType: database.ResourceTypeAPIKey,
+			ResourceID:   user.ID,
+			Actor:        httpmw.APIKey(r).UserID,
+		}
+		aReq, commitAudit = audit.InitRequest[database.APIKey](rw, auditParams)
+	)
+	defer commitAudit()
 
 	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
 		httpapi.ResourceNotFound(rw)
@@ -825,7 +855,7 @@ func (api *API) postAPIKey(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	lifeTime := time.Hour * 24 * 7
-	cookie, err := api.createAPIKey(r, createAPIKeyParams{
+	cookie, key, err := api.createAPIKey(r, createAPIKeyParams{
 		UserID:    user.ID,
 		LoginType: database.LoginTypePassword,
 		// All api generated keys will last 1 week. Browser login tokens have
@@ -840,6 +870,8 @@ func (api *API) postAPIKey(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	auditParams.ResourceTarget = key.ID
+	aReq.New = *key
 
 	// We intentionally do not set the cookie on the response here.
 	// Setting the cookie will couple the browser sesion to the API
@@ -926,12 +958,16 @@ type createAPIKeyParams struct {
 	// Optional.
 	ExpiresAt       time.Time
 	LifetimeSeconds int64
+	Scope           database.APIKeyScope
+	TokenName       string
 }
 
-func (api *API) createAPIKey(r *http.Request, params createAPIKeyParams) (*http.Cookie, error) {
+// createAPIKey inserts a new API key for the user. It returns the session
+// cookie containing the key's secret alongside the inserted key.
+func (api *API) createAPIKey(r *http.Request, params createAPIKeyParams) (*http.Cookie, *database.APIKey, error) {
 	keyID, keySecret, err := generateAPIKeyIDSecret()
 	if err != nil {
-		return nil, xerrors.Errorf("generate API key: %w", err)
+		return nil, nil, xerrors.Errorf("generate API key: %w", err)
 	}
 	hashed := sha256.Sum256([]byte(keySecret))
 
@@ -944,6 +980,10 @@ func (api *API) createAPIKey(r *http.Request, params createAPIKeyParams) (*http.
 		}
 	}
 
+	if params.Scope == "" {
+		params.Scope = database.APIKeyScopeAll
+	}
+
 	host, _, _ := net.SplitHostPort(r.RemoteAddr)
 	ip := net.ParseIP(host)
 	if ip == nil {
@@ -967,9 +1007,11 @@ func (api *API) createAPIKey(r *http.Request, params createAPIKeyParams) (*http.
 		UpdatedAt:    database.Now(),
 		HashedSecret: hashed[:],
 		LoginType:    params.LoginType,
+		Scope:        params.Scope,
+		TokenName:    params.TokenName,
 	})
 	if err != nil {
-		return nil, xerrors.Errorf("insert API key: %w", err)
+		return nil, nil, xerrors.Errorf("insert API key: %w", err)
 	}
 
 	api.Telemetry.Report(&telemetry.Snapshot{
@@ -985,7 +1027,7 @@ func (api *API) createAPIKey(r *http.Request, params createAPIKeyParams) (*http.
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 		Secure:   api.SecureAuthCookie,
-	}, nil
+	}, &key, nil
 }
 
 type createUserRequest struct {
@@ -1008,6 +1050,10 @@ func (api *API) createUser(ctx context.Context, store database.Store, req create
 			if err != nil {
 				return xerrors.Errorf("create organization: %w", err)
 			}
+			_, err = tx.InsertAllUsersGroup(ctx, organization.ID)
+			if err != nil {
+				return xerrors.Errorf("create %q group: %w", database.AllUsersGroup, err)
+			}
 			req.OrganizationID = organization.ID
 			orgRoles = append(orgRoles, rbac.RoleOrgAdmin(req.OrganizationID))
 		}
@@ -1179,5 +1225,7 @@ func convertAPIKey(k database.APIKey) codersdk.APIKey {
 		UpdatedAt:       k.UpdatedAt,
 		LoginType:       codersdk.LoginType(k.LoginType),
 		LifetimeSeconds: k.LifetimeSeconds,
+		Scope:           codersdk.APIKeyScope(k.Scope),
+		TokenName:       k.TokenName,
 	}
 }
@@ -14,7 +14,9 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/coder/coder/coderd"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
@@ -150,7 +152,8 @@ func TestPostLogin(t *testing.T) {
 
 	t.Run("BadPassword", func(t *testing.T) {
 		t.Parallel()
-		client := coderdtest.New(t, nil)
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
 
 		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 		defer cancel()
@@ -161,7 +164,7 @@ func TestPostLogin(t *testing.T) {
 			Password:         "testpass",
 			OrganizationName: "testorg",
 		}
-		_, err := client.CreateFirstUser(ctx, req)
+		first, err := client.CreateFirstUser(ctx, req)
 		require.NoError(t, err)
 		_, err = client.LoginWithPassword(ctx, codersdk.LoginWithPasswordRequest{
 			Email:    req.Email,
@@ -170,6 +173,14 @@ func TestPostLogin(t *testing.T) {
 		var apiErr *codersdk.Error
 		require.ErrorAs(t, err, &apiErr)
 		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode())
+
+		alogs := auditor.AuditLogs()
+		require.Len(t, alogs, 1)
+		require.Equal(t, database.AuditActionLogin, alogs[0].Action)
+		require.Equal(t, database.ResourceTypeAPIKey, alogs[0].ResourceType)
+		require.Equal(t, req.Email, alogs[0].ResourceTarget)
+		require.Equal(t, first.UserID, alogs[0].UserID)
+		require.EqualValues(t, http.StatusUnauthorized, alogs[0].StatusCode)
 	})
 
 	t.Run("Suspended", func(t *testing.T) {
@@ -1102,8 +1113,9 @@ func TestPostAPIKey(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		t.Parallel()
-		client := coderdtest.New(t, nil)
-		_ = coderdtest.CreateFirstUser(t, client)
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		user := coderdtest.CreateFirstUser(t, client)
 
 		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 		defer cancel()
@@ -1112,6 +1124,14 @@ func TestPostAPIKey(t *testing.T) {
 		require.NotNil(t, apiKey)
 		require.GreaterOrEqual(t, len(apiKey.Key), 2)
 		require.NoError(t, err)
+
+		alogs := auditor.AuditLogs()
+		require.NotEmpty(t, alogs)
+		alog := alogs[len(alogs)-1]
+		require.Equal(t, database.AuditActionCreate, alog.Action)
+		require.Equal(t, database.ResourceTypeAPIKey, alog.ResourceType)
+		require.Equal(t, user.UserID, alog.UserID)
+		require.Equal(t, strings.Split(apiKey.Key, "-")[0], alog.ResourceTarget)
 	})
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -136,12 +137,30 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
+	workspace, err := api.workspaceByAgent(r.Context(), workspaceAgent)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	template, err := api.Database.GetTemplateByID(r.Context(), workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
 
 	httpapi.Write(rw, http.StatusOK, agent.Metadata{
 		DERPMap:              api.DERPMap,
 		EnvironmentVariables: apiAgent.EnvironmentVariables,
 		StartupScript:        apiAgent.StartupScript,
+		ShutdownScript:       apiAgent.ShutdownScript,
 		Directory:            apiAgent.Directory,
+		SessionRecording:     template.SessionRecording,
 	})
 }
 
@@ -185,6 +204,301 @@ func (api *API) postWorkspaceAgentVersion(rw http.ResponseWriter, r *http.Reques
 	httpapi.Write(rw, http.StatusOK, nil)
 }
 
+func (api *API) postWorkspaceAgentLifecycle(rw http.ResponseWriter, r *http.Request) {
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	var req codersdk.PostWorkspaceAgentLifecycleRequest
+	if !httpapi.Read(rw, r, &req) {
+		return
+	}
+
+	state := database.WorkspaceAgentLifecycleState(req.State)
+	switch state {
+	case database.WorkspaceAgentLifecycleStateCreated,
+		database.WorkspaceAgentLifecycleStateStarting,
+		database.WorkspaceAgentLifecycleStateStartTimeout,
+		database.WorkspaceAgentLifecycleStateStartError,
+		database.WorkspaceAgentLifecycleStateReady,
+		database.WorkspaceAgentLifecycleStateShuttingDown,
+		database.WorkspaceAgentLifecycleStateOff:
+	default:
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid lifecycle state.",
+			Detail:  fmt.Sprintf("invalid lifecycle state %q", req.State),
+		})
+		return
+	}
+
+	err := api.Database.UpdateWorkspaceAgentLifecycleStateByID(r.Context(), database.UpdateWorkspaceAgentLifecycleStateByIDParams{
+		ID:             workspaceAgent.ID,
+		LifecycleState: state,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update lifecycle state.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if startupScriptFinished(state) {
+		// The agent sends all startup logs before it reports the startup
+		// script has finished, so followers can stop.
+		data, err := json.Marshal(workspaceAgentStartupLogsMessage{EndOfLogs: true})
+		if err == nil {
+			err = api.Pubsub.Publish(workspaceAgentStartupLogsChannel(workspaceAgent.ID), data)
+		}
+		if err != nil {
+			api.Logger.Warn(r.Context(), "publish end of startup logs",
+				slog.F("agent_id", workspaceAgent.ID), slog.Error(err))
+		}
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (api *API) patchWorkspaceAgentStartupLogs(rw http.ResponseWriter, r *http.Request) {
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	var req codersdk.PatchWorkspaceAgentStartupLogs
+	if !httpapi.Read(rw, r, &req) {
+		return
+	}
+	if len(req.Logs) == 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "No logs provided.",
+		})
+		return
+	}
+
+	createdAt := make([]time.Time, 0, len(req.Logs))
+	output := make([]string, 0, len(req.Logs))
+	for _, log := range req.Logs {
+		createdAt = append(createdAt, log.CreatedAt)
+		output = append(output, log.Output)
+	}
+	logs, err := api.Database.InsertWorkspaceAgentStartupLogs(r.Context(), database.InsertWorkspaceAgentStartupLogsParams{
+		AgentID:   workspaceAgent.ID,
+		CreatedAt: createdAt,
+		Output:    output,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to insert startup logs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	data, err := json.Marshal(workspaceAgentStartupLogsMessage{
+		Logs: convertWorkspaceAgentStartupLogs(logs),
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to marshal startup logs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	err = api.Pubsub.Publish(workspaceAgentStartupLogsChannel(workspaceAgent.ID), data)
+	if err != nil {
+		// Followers miss these logs, but they're stored and can be fetched
+		// again.
+		api.Logger.Warn(r.Context(), "publish startup logs",
+			slog.F("agent_id", workspaceAgent.ID), slog.Error(err))
+	}
+
+	httpapi.Write(rw, http.StatusOK, nil)
+}
+
+// workspaceAgentStartupLogs returns the startup script output of an agent.
+// With "follow", output is streamed over a websocket as it arrives.
+func (api *API) workspaceAgentStartupLogs(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+		workspace      = httpmw.WorkspaceParam(r)
+		follow         = r.URL.Query().Has("follow")
+		afterRaw       = r.URL.Query().Get("after")
+	)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var after int64
+	if afterRaw != "" {
+		var err error
+		after, err = strconv.ParseInt(afterRaw, 10, 64)
+		if err != nil {
+			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query param \"after\" must be an integer.",
+				Validations: []codersdk.ValidationError{
+					{Field: "after", Detail: "Must be an integer"},
+				},
+			})
+			return
+		}
+	}
+
+	// If we are following logs, subscribe before querying the database so
+	// no logs are missed in between. Duplicates are skipped by ID.
+	var (
+		bufferedLogs = make(chan []codersdk.WorkspaceAgentStartupLog, 128)
+		// refetch is notified when logs couldn't be buffered, so they are
+		// queried from the database instead.
+		refetch   = make(chan struct{}, 1)
+		endOfLogs = make(chan struct{}, 1)
+	)
+	if follow {
+		closeSubscribe, err := api.Pubsub.Subscribe(workspaceAgentStartupLogsChannel(workspaceAgent.ID), func(ctx context.Context, message []byte) {
+			var msg workspaceAgentStartupLogsMessage
+			err := json.Unmarshal(message, &msg)
+			if err != nil {
+				api.Logger.Warn(ctx, "invalid startup logs on channel", slog.Error(err))
+				return
+			}
+			if msg.EndOfLogs {
+				select {
+				case endOfLogs <- struct{}{}:
+				default:
+				}
+				return
+			}
+			select {
+			case bufferedLogs <- msg.Logs:
+			default:
+				// Don't block the pubsub if the consumer isn't keeping up.
+				select {
+				case refetch <- struct{}{}:
+				default:
+				}
+			}
+		})
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error watching startup logs.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		defer closeSubscribe()
+	}
+
+	logs, err := api.Database.GetWorkspaceAgentStartupLogsAfter(ctx, database.GetWorkspaceAgentStartupLogsAfterParams{
+		AgentID: workspaceAgent.ID,
+		AfterID: after,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching startup logs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if !follow {
+		httpapi.Write(rw, http.StatusOK, convertWorkspaceAgentStartupLogs(logs))
+		return
+	}
+
+	// The startup script may have finished before we subscribed, in which
+	// case the end of the logs was already published.
+	currentAgent, err := api.Database.GetWorkspaceAgentByID(ctx, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if startupScriptFinished(currentAgent.LifecycleState) {
+		endOfLogs <- struct{}{}
+	}
+
+	api.websocketWaitMutex.Lock()
+	api.websocketWaitGroup.Add(1)
+	api.websocketWaitMutex.Unlock()
+	defer api.websocketWaitGroup.Done()
+	conn, err := websocket.Accept(rw, r, nil)
+	if err != nil {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx, wsNetConn := websocketNetConn(ctx, conn, websocket.MessageText)
+	defer wsNetConn.Close() // Also closes conn.
+
+	// The Go stdlib JSON encoder appends a newline character after message write.
+	encoder := json.NewEncoder(wsNetConn)
+	send := func(logs []codersdk.WorkspaceAgentStartupLog) error {
+		for _, log := range logs {
+			if log.ID <= after {
+				continue
+			}
+			err := encoder.Encode(log)
+			if err != nil {
+				return err
+			}
+			after = log.ID
+		}
+		return nil
+	}
+	// sendStored sends the logs in the database that weren't sent yet.
+	sendStored := func() error {
+		logs, err := api.Database.GetWorkspaceAgentStartupLogsAfter(ctx, database.GetWorkspaceAgentStartupLogsAfterParams{
+			AgentID: workspaceAgent.ID,
+			AfterID: after,
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return send(convertWorkspaceAgentStartupLogs(logs))
+	}
+	err = send(convertWorkspaceAgentStartupLogs(logs))
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case logs := <-bufferedLogs:
+			// An earlier batch may have been dropped before this one was
+			// buffered. Refetch first, otherwise sending this batch would
+			// move after past the dropped logs.
+			select {
+			case <-refetch:
+				err = sendStored()
+			default:
+			}
+			if err == nil {
+				err = send(logs)
+			}
+		case <-refetch:
+			err = sendStored()
+		case <-endOfLogs:
+			// Send anything that wasn't delivered through the pubsub, and
+			// close the connection.
+			err = sendStored()
+			if err != nil {
+				api.Logger.Debug(ctx, "send remaining startup logs", slog.Error(err))
+			}
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
 func (api *API) workspaceAgentListen(rw http.ResponseWriter, r *http.Request) {
 	api.websocketWaitMutex.Lock()
 	api.websocketWaitGroup.Add(1)
@@ -409,6 +723,101 @@ func (api *API) workspaceAgentTurn(rw http.ResponseWriter, r *http.Request) {
 	api.Logger.Debug(ctx, "completed turn connection", slog.F("remote-address", r.RemoteAddr), slog.F("local-address", localAddress))
 }
 
+// workspaceAgentListeningPorts returns the TCP ports that processes in the
+// workspace are listening on, as reported by the agent.
+func (api *API) workspaceAgentListeningPorts(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	apiAgent, err := convertWorkspaceAgent(api.DERPMap, api.TailnetCoordinator, workspaceAgent, nil, api.AgentInactiveDisconnectTimeout)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error reading workspace agent.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if apiAgent.Status != codersdk.WorkspaceAgentConnected {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Agent state is %q, it must be in the %q state.", apiAgent.Status, codersdk.WorkspaceAgentConnected),
+		})
+		return
+	}
+
+	agentConn, release, err := api.workspaceAgentCache.Acquire(r, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error dialing workspace agent.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer release()
+
+	portsResponse, err := agentConn.ListeningPorts(ctx)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching listening ports.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ports := make([]codersdk.WorkspaceAgentListeningPort, 0, len(portsResponse.Ports))
+	for _, port := range portsResponse.Ports {
+		ports = append(ports, codersdk.WorkspaceAgentListeningPort{
+			ProcessName: port.ProcessName,
+			Network:     port.Network,
+			Port:        port.Port,
+		})
+	}
+	httpapi.Write(rw, http.StatusOK, codersdk.WorkspaceAgentListeningPortsResponse{
+		Ports: ports,
+	})
+}
+
+// workspaceAgentResourceStats returns the resource usage samples of an
+// agent that haven't been pruned yet, oldest first.
+func (api *API) workspaceAgentResourceStats(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	stats, err := api.Database.GetWorkspaceAgentResourceStats(ctx, database.GetWorkspaceAgentResourceStatsParams{
+		AgentID:      workspaceAgent.ID,
+		CreatedAfter: database.Now().Add(-24 * time.Hour),
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching agent resource stats.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiStats := make([]codersdk.WorkspaceAgentResourceStats, 0, len(stats))
+	for _, stat := range stats {
+		apiStats = append(apiStats, codersdk.WorkspaceAgentResourceStats{
+			CreatedAt:        stat.CreatedAt,
+			CPUUsedCores:     stat.CpuUsedCores,
+			CPUTotalCores:    stat.CpuTotalCores,
+			MemoryUsedBytes:  stat.MemoryUsedBytes,
+			MemoryTotalBytes: stat.MemoryTotalBytes,
+			DiskUsedBytes:    stat.DiskUsedBytes,
+			DiskTotalBytes:   stat.DiskTotalBytes,
+		})
+	}
+	httpapi.Write(rw, http.StatusOK, apiStats)
+}
+
 // workspaceAgentPTY spawns a PTY and pipes it over a WebSocket.
 // This is used for the web terminal.
 func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
@@ -470,6 +879,8 @@ func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 
 	_, wsNetConn := websocketNetConn(r.Context(), conn, websocket.MessageBinary)
 	defer wsNetConn.Close() // Also closes conn.
+	// The agent reports the PTY by its ID, which attributes it to this user.
+	defer api.agentClients.addPTY(workspaceAgent.ID, reconnect.String(), httpmw.APIKey(r).UserID)()
 
 	agentConn, release, err := api.workspaceAgentCache.Acquire(r, workspaceAgent.ID)
 	if err != nil {
@@ -649,27 +1060,70 @@ func (api *API) workspaceAgentClientCoordinate(rw http.ResponseWriter, r *http.R
 		return
 	}
 	defer conn.Close(websocket.StatusNormalClosure, "")
-	err = api.TailnetCoordinator.ServeClient(websocket.NetConn(r.Context(), conn, websocket.MessageBinary), uuid.New(), workspaceAgent.ID)
+	// Connections the agent reports from the addresses of this client's
+	// node are attributed to this user.
+	clientID := uuid.New()
+	defer api.agentClients.addPeer(workspaceAgent.ID, clientID, httpmw.APIKey(r).UserID)()
+	err = api.TailnetCoordinator.ServeClient(websocket.NetConn(r.Context(), conn, websocket.MessageBinary), clientID, workspaceAgent.ID)
 	if err != nil {
 		_ = conn.Close(websocket.StatusInternalError, err.Error())
 		return
 	}
 }
 
+func workspaceAgentStartupLogsChannel(agentID uuid.UUID) string {
+	return fmt.Sprintf("workspace-agent-startup-logs:%s", agentID)
+}
+
+// workspaceAgentStartupLogsMessage is the message type published on the
+// workspaceAgentStartupLogsChannel() channel.
+type workspaceAgentStartupLogsMessage struct {
+	EndOfLogs bool                                `json:"end_of_logs,omitempty"`
+	Logs      []codersdk.WorkspaceAgentStartupLog `json:"logs,omitempty"`
+}
+
+// startupScriptFinished returns whether an agent in a lifecycle state has
+// finished running its startup script. Agents that timed out are still
+// running it.
+func startupScriptFinished(state database.WorkspaceAgentLifecycleState) bool {
+	switch state {
+	case database.WorkspaceAgentLifecycleStateStartError,
+		database.WorkspaceAgentLifecycleStateReady,
+		database.WorkspaceAgentLifecycleStateShuttingDown,
+		database.WorkspaceAgentLifecycleStateOff:
+		return true
+	default:
+		return false
+	}
+}
+
+func convertWorkspaceAgentStartupLogs(logs []database.WorkspaceAgentStartupLog) []codersdk.WorkspaceAgentStartupLog {
+	sdk := make([]codersdk.WorkspaceAgentStartupLog, 0, len(logs))
+	for _, log := range logs {
+		sdk = append(sdk, codersdk.WorkspaceAgentStartupLog{
+			ID:        log.ID,
+			CreatedAt: log.CreatedAt,
+			Output:    log.Output,
+		})
+	}
+	return sdk
+}
+
 func convertApps(dbApps []database.WorkspaceApp) []codersdk.WorkspaceApp {
 	apps := make([]codersdk.WorkspaceApp, 0)
 	for _, dbApp := range dbApps {
 		apps = append(apps, codersdk.WorkspaceApp{
-			ID:      dbApp.ID,
-			Name:    dbApp.Name,
-			Command: dbApp.Command.String,
-			Icon:    dbApp.Icon,
+			ID:           dbApp.ID,
+			Name:         dbApp.Name,
+			Command:      dbApp.Command.String,
+			Icon:         dbApp.Icon,
+			SharingLevel: codersdk.WorkspaceAppSharingLevel(dbApp.SharingLevel),
 		})
 	}
 	return apps
 }
 
-func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator *tailnet.Coordinator, dbAgent database.WorkspaceAgent, apps []codersdk.WorkspaceApp, agentInactiveDisconnectTimeout time.Duration) (codersdk.WorkspaceAgent, error) {
+func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordinator, dbAgent database.WorkspaceAgent, apps []codersdk.WorkspaceApp, agentInactiveDisconnectTimeout time.Duration) (codersdk.WorkspaceAgent, error) {
 	var envs map[string]string
 	if dbAgent.EnvironmentVariables.Valid {
 		err := json.Unmarshal(dbAgent.EnvironmentVariables.RawMessage, &envs)
@@ -687,7 +1141,9 @@ func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator *tailnet.Coordi
 		Architecture:         dbAgent.Architecture,
 		OperatingSystem:      dbAgent.OperatingSystem,
 		StartupScript:        dbAgent.StartupScript.String,
+		ShutdownScript:       dbAgent.ShutdownScript.String,
 		Version:              dbAgent.Version,
+		LifecycleState:       codersdk.WorkspaceAgentLifecycle(dbAgent.LifecycleState),
 		EnvironmentVariables: envs,
 		Directory:            dbAgent.Directory,
 		Apps:                 apps,
@@ -817,21 +1273,16 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 			return
 		}
 
-		repJSON, err := json.Marshal(rep)
-		if err != nil {
-			httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
-				Message: "Failed to marshal stat json.",
-				Detail:  err.Error(),
-			})
-			return
-		}
-
 		// Avoid inserting duplicate rows to preserve DB space.
 		// We will see duplicate reports when on idle connections
 		// (e.g. web terminal left open) or when there are no connections at
 		// all.
 		// We also don't want to update the workspace last used at on duplicate
 		// reports.
+		// Resource usage changes on every report, so it's left out of the
+		// comparison and recorded separately.
+		resources := rep.Resources
+		rep.Resources = nil
 		var updateDB = !reflect.DeepEqual(lastReport, rep)
 
 		api.Logger.Debug(ctx, "read stats report",
@@ -841,11 +1292,44 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 			slog.F("workspace", workspace.ID),
 			slog.F("update_db", updateDB),
 			slog.F("payload", rep),
+			slog.F("resources", resources),
 		)
 
+		if resources != nil {
+			_, err = api.Database.InsertWorkspaceAgentResourceStat(ctx, database.InsertWorkspaceAgentResourceStatParams{
+				ID:               uuid.New(),
+				CreatedAt:        database.Now(),
+				AgentID:          workspaceAgent.ID,
+				WorkspaceID:      build.WorkspaceID,
+				TemplateID:       workspace.TemplateID,
+				CpuUsedCores:     resources.CPUUsedCores,
+				CpuTotalCores:    resources.CPUTotalCores,
+				MemoryUsedBytes:  resources.MemoryUsedBytes,
+				MemoryTotalBytes: resources.MemoryTotalBytes,
+				DiskUsedBytes:    resources.DiskUsedBytes,
+				DiskTotalBytes:   resources.DiskTotalBytes,
+			})
+			if err != nil {
+				httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+					Message: "Failed to insert agent resource stat.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+		}
+
 		if updateDB {
 			lastReport = rep
 
+			repJSON, err := json.Marshal(rep)
+			if err != nil {
+				httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+					Message: "Failed to marshal stat json.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+
 			_, err = api.Database.InsertAgentStat(ctx, database.InsertAgentStatParams{
 				ID:          uuid.New(),
 				CreatedAt:   time.Now(),
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"net"
+	"net/http"
 	"runtime"
 	"strings"
 	"testing"
@@ -11,12 +13,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/agent"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/peer"
 	"github.com/coder/coder/provisioner/echo"
@@ -429,3 +433,430 @@ func TestWorkspaceAgentPTY(t *testing.T) {
 	expectLine(matchEchoCommand)
 	expectLine(matchEchoOutput)
 }
+
+func TestWorkspaceAgentListeningPorts(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" {
+		t.Skip("Listening ports are only supported on Linux.")
+	}
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		FetchMetadata:     agentClient.WorkspaceAgentMetadata,
+		CoordinatorDialer: agentClient.ListenWorkspaceAgentTailnet,
+		WebRTCDialer:      agentClient.ListenWorkspaceAgent,
+		Logger:            slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	defer func() {
+		_ = agentCloser.Close()
+	}()
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.LatestBuild.ID)
+
+	// The agent runs in this process, so it sees this listener.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	tcpAddr, valid := listener.Addr().(*net.TCPAddr)
+	require.True(t, valid)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	res, err := client.WorkspaceAgentListeningPorts(ctx, resources[0].Agents[0].ID)
+	require.NoError(t, err)
+	found := false
+	for _, port := range res.Ports {
+		if int(port.Port) == tcpAddr.Port {
+			found = true
+			break
+		}
+	}
+	require.True(t, found, "port %d not found in %+v", tcpAddr.Port, res.Ports)
+}
+
+func TestWorkspaceAgentResourceStats(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" {
+		t.Skip("Resource usage is only supported on Linux.")
+	}
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		Logger:            slogtest.Make(t, nil),
+		StatsReporter:     agentClient.AgentReportStats,
+		WebRTCDialer:      agentClient.ListenWorkspaceAgent,
+		FetchMetadata:     agentClient.WorkspaceAgentMetadata,
+		CoordinatorDialer: agentClient.ListenWorkspaceAgentTailnet,
+	})
+	defer func() {
+		_ = agentCloser.Close()
+	}()
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	var stats []codersdk.WorkspaceAgentResourceStats
+	require.Eventually(t, func() bool {
+		var err error
+		stats, err = client.WorkspaceAgentResourceStats(ctx, resources[0].Agents[0].ID)
+		return assert.NoError(t, err) && len(stats) >= 2
+	}, testutil.WaitLong, testutil.IntervalMedium)
+	require.True(t, stats[0].CreatedAt.Before(stats[1].CreatedAt))
+	require.Greater(t, stats[1].CPUTotalCores, float64(0))
+	require.Greater(t, stats[1].MemoryTotalBytes, int64(0))
+	require.Greater(t, stats[1].DiskTotalBytes, int64(0))
+}
+
+func TestWorkspaceAgentStartupLogs(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	resources, err := client.WorkspaceResourcesByBuild(ctx, workspace.LatestBuild.ID)
+	require.NoError(t, err)
+	agentID := resources[0].Agents[0].ID
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	err = agentClient.PatchWorkspaceAgentStartupLogs(ctx, []agent.StartupLog{
+		{CreatedAt: database.Now(), Output: "first"},
+		{CreatedAt: database.Now(), Output: "second"},
+	})
+	require.NoError(t, err)
+
+	logs, err := client.WorkspaceAgentStartupLogs(ctx, agentID, 0)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, "first", logs[0].Output)
+	require.Equal(t, "second", logs[1].Output)
+
+	after, err := client.WorkspaceAgentStartupLogs(ctx, agentID, logs[0].ID)
+	require.NoError(t, err)
+	require.Len(t, after, 1)
+	require.Equal(t, "second", after[0].Output)
+
+	// Following returns existing output first, then streams new output.
+	follow, err := client.WorkspaceAgentStartupLogsAfter(ctx, agentID, logs[0].ID)
+	require.NoError(t, err)
+	log := <-follow
+	require.Equal(t, "second", log.Output)
+
+	err = agentClient.PatchWorkspaceAgentStartupLogs(ctx, []agent.StartupLog{
+		{CreatedAt: database.Now(), Output: "third"},
+	})
+	require.NoError(t, err)
+	log = <-follow
+	require.Equal(t, "third", log.Output)
+
+	// Following stops once the startup script has finished.
+	err = agentClient.PostWorkspaceAgentLifecycle(ctx, agent.LifecycleStateReady)
+	require.NoError(t, err)
+	_, ok := <-follow
+	require.False(t, ok, "follow should stop after the agent is ready")
+}
+
+func TestWorkspaceAgentLifecycle(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	resources, err := client.WorkspaceResourcesByBuild(ctx, workspace.LatestBuild.ID)
+	require.NoError(t, err)
+	agentID := resources[0].Agents[0].ID
+	require.Equal(t, codersdk.WorkspaceAgentLifecycleCreated, resources[0].Agents[0].LifecycleState)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	for _, state := range []agent.LifecycleState{
+		agent.LifecycleStateStarting,
+		agent.LifecycleStateReady,
+	} {
+		err = agentClient.PostWorkspaceAgentLifecycle(ctx, state)
+		require.NoError(t, err)
+		workspaceAgent, err := client.WorkspaceAgent(ctx, agentID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.WorkspaceAgentLifecycle(state), workspaceAgent.LifecycleState)
+	}
+
+	err = agentClient.PostWorkspaceAgentLifecycle(ctx, "bogus")
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+}
+
+func TestWorkspaceAgentShutdownScript(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The shutdown script uses POSIX shell syntax.")
+	}
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:             uuid.NewString(),
+							Name:           "dev",
+							ShutdownScript: "echo saving workspace; exit 3",
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		FetchMetadata:     agentClient.WorkspaceAgentMetadata,
+		CoordinatorDialer: agentClient.ListenWorkspaceAgentTailnet,
+		WebRTCDialer:      agentClient.ListenWorkspaceAgent,
+		Logger:            slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	defer func() {
+		_ = agentCloser.Close()
+	}()
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.LatestBuild.ID)
+	require.Equal(t, "echo saving workspace; exit 3", resources[0].Agents[0].ShutdownScript)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	stopBuild, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransitionStop,
+	})
+	require.NoError(t, err)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, stopBuild.ID)
+
+	logs, err := client.WorkspaceBuildLogsBefore(ctx, stopBuild.ID, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	var output []string
+	for _, log := range logs {
+		if log.Stage == `Running shutdown script for "dev"` {
+			output = append(output, log.Output)
+		}
+	}
+	require.Equal(t, []string{"saving workspace", "Shutdown script exited with code 3."}, output)
+}
+
+func TestWorkspaceAgentShutdownScriptCanceled(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The shutdown script uses POSIX shell syntax.")
+	}
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:             uuid.NewString(),
+							Name:           "dev",
+							ShutdownScript: "echo saving workspace; sleep 60",
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		FetchMetadata:     agentClient.WorkspaceAgentMetadata,
+		CoordinatorDialer: agentClient.ListenWorkspaceAgentTailnet,
+		WebRTCDialer:      agentClient.ListenWorkspaceAgent,
+		Logger:            slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	defer func() {
+		_ = agentCloser.Close()
+	}()
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	stopBuild, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransitionStop,
+	})
+	require.NoError(t, err)
+
+	shutdownScriptOutput := func() []string {
+		logs, err := client.WorkspaceBuildLogsBefore(ctx, stopBuild.ID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		var output []string
+		for _, log := range logs {
+			if log.Stage == `Running shutdown script for "dev"` {
+				output = append(output, log.Output)
+			}
+		}
+		return output
+	}
+	// The provisioner daemon isn't blocked, and the job is heartbeated
+	// while the script runs.
+	require.Eventually(t, func() bool {
+		return len(shutdownScriptOutput()) > 0
+	}, testutil.WaitLong, testutil.IntervalFast)
+	build, err := client.WorkspaceBuild(ctx, stopBuild.ID)
+	require.NoError(t, err)
+	require.Equal(t, codersdk.ProvisionerJobRunning, build.Job.Status)
+
+	err = client.CancelWorkspaceBuild(ctx, stopBuild.ID)
+	require.NoError(t, err)
+	build = coderdtest.AwaitWorkspaceBuildJob(t, client, stopBuild.ID)
+	require.Equal(t, codersdk.ProvisionerJobCanceled, build.Job.Status)
+	require.Equal(t, []string{"saving workspace", "Shutdown script was stopped."}, shutdownScriptOutput())
+}
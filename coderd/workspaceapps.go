@@ -1,15 +1,21 @@
 package coderd
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
@@ -17,37 +23,419 @@ import (
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/tracing"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/cryptorand"
 	"github.com/coder/coder/site"
 )
 
+// appAuthStateKey is the cookie and query parameter that ties the session
+// token of a subdomain application to the browser that signed in for it.
+const appAuthStateKey = "coder_application_connect_state"
+
 // workspaceAppsProxyPath proxies requests to a workspace application
 // through a relative URL path.
 func (api *API) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request) {
 	workspace := httpmw.WorkspaceParam(r)
 	agent := httpmw.WorkspaceAgentParam(r)
 
-	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+	app, ok := api.workspaceAppByAgentIDAndName(rw, r, agent.ID, chi.URLParam(r, "workspaceapp"))
+	if !ok {
+		return
+	}
+	if !api.authorizeWorkspaceApp(r, app.SharingLevel, workspace) {
+		if _, ok := httpmw.APIKeyOptional(r); !ok {
+			redirectToLogin(rw, r)
+			return
+		}
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	path := chi.URLParam(r, "*")
+	if !strings.HasSuffix(r.URL.Path, "/") && path == "" {
+		// Web applications typically request paths relative to the
+		// root URL. This allows for routing behind a proxy or subpath.
+		// See https://github.com/coder/code-server/issues/241 for examples.
+		r.URL.Path += "/"
+		http.Redirect(rw, r, r.URL.String(), http.StatusTemporaryRedirect)
+		return
+	}
+
+	api.proxyWorkspaceApplication(rw, r, workspace, agent, app, path)
+}
+
+// redirectAnonymousUserMe sends anonymous requests for applications of the
+// "me" user to sign in, since the user can't be resolved without an API key.
+// Public applications are opened with the username of their owner.
+func redirectAnonymousUserMe(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := httpmw.APIKeyOptional(r); !ok && chi.URLParam(r, "user") == "me" {
+			redirectToLogin(rw, r)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// redirectToLogin sends the user to sign in, and back to the request
+// afterwards.
+func redirectToLogin(rw http.ResponseWriter, r *http.Request) {
+	loginURL := url.URL{
+		Path: "/login",
+		RawQuery: url.Values{
+			"redirect": {r.URL.Path + "?" + r.URL.RawQuery},
+		}.Encode(),
+	}
+	http.Redirect(rw, r, loginURL.String(), http.StatusTemporaryRedirect)
+}
+
+// handleSubdomainApplications routes requests for hostnames matching the
+// wildcard app hostname (e.g. app--agent--workspace--user.coder.com) to
+// workspace applications. All other requests are passed to next. The
+// middlewares are only run for application requests.
+func (api *API) handleSubdomainApplications(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if api.AppHostname == "" {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			subdomain, ok := httpapi.ExecuteHostnamePattern(api.AppHostname, httpapi.RequestHost(r))
+			if !ok {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			appURL, err := httpapi.ParseSubdomainAppURL(subdomain)
+			if err != nil {
+				// The wildcard may also match the access URL or other
+				// hosts, so these are served as usual.
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			if token := r.URL.Query().Get(codersdk.AppSessionTokenQueryKey); token != "" {
+				// The token was issued by workspaceApplicationAuth. Cookies
+				// on the access URL aren't sent to application hostnames,
+				// so the token is stored on this hostname instead.
+				valid, err := api.validateAppSessionToken(r, token)
+				if err != nil {
+					httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+						Message: "Internal error validating session token.",
+						Detail:  err.Error(),
+					})
+					return
+				}
+				if !valid {
+					httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+						Message: "Invalid application session token. Open the application again to sign in.",
+					})
+					return
+				}
+				http.SetCookie(rw, &http.Cookie{
+					Name:     appAuthStateKey,
+					Value:    "",
+					Path:     "/",
+					MaxAge:   -1,
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+					Secure:   api.SecureAuthCookie,
+				})
+				http.SetCookie(rw, &http.Cookie{
+					Name:     codersdk.SessionTokenKey,
+					Value:    token,
+					Path:     "/",
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+					Secure:   api.SecureAuthCookie,
+				})
+				query := r.URL.Query()
+				query.Del(codersdk.AppSessionTokenQueryKey)
+				query.Del(appAuthStateKey)
+				r.URL.RawQuery = query.Encode()
+				http.Redirect(rw, r, r.URL.String(), http.StatusTemporaryRedirect)
+				return
+			}
+
+			chi.Chain(middlewares...).HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				api.workspaceAppsProxySubdomain(rw, r, appURL)
+			}).ServeHTTP(rw, r)
+		})
+	}
+}
+
+// workspaceAppsProxySubdomain proxies requests to a workspace application
+// served on its own hostname.
+func (api *API) workspaceAppsProxySubdomain(rw http.ResponseWriter, r *http.Request, appURL httpapi.ApplicationURL) {
+	user, err := api.Database.GetUserByEmailOrUsername(r.Context(), database.GetUserByEmailOrUsernameParams{
+		Username: appURL.Username,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspace, err := api.Database.GetWorkspaceByOwnerIDAndName(r.Context(), database.GetWorkspaceByOwnerIDAndNameParams{
+		OwnerID: user.ID,
+		Name:    appURL.WorkspaceName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	agent, err := api.workspaceAgentByName(r.Context(), workspace, appURL.AgentName)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	app, ok := api.workspaceAppByAgentIDAndName(rw, r, agent.ID, appURL.AppName)
+	if !ok {
+		return
+	}
+
+	if !api.authorizeWorkspaceApp(r, app.SharingLevel, workspace) {
+		if _, ok := httpmw.APIKeyOptional(r); ok {
+			httpapi.ResourceNotFound(rw)
+			return
+		}
+		// Sign in with the access URL, which redirects back to this
+		// hostname with a session token. The state ties the token to
+		// this browser, so a link with someone else's token can't sign
+		// the browser in as them.
+		state, err := cryptorand.String(32)
+		if err != nil {
+			httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error generating sign in state.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		http.SetCookie(rw, &http.Cookie{
+			Name:     appAuthStateKey,
+			Value:    state,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   api.SecureAuthCookie,
+		})
+		query := r.URL.Query()
+		query.Set(appAuthStateKey, state)
+		redirectURI := url.URL{
+			Scheme:   api.AccessURL.Scheme,
+			Host:     httpapi.RequestHost(r),
+			Path:     r.URL.Path,
+			RawQuery: query.Encode(),
+		}
+		authURL := *api.AccessURL
+		authURL.Path = "/api/v2/applications/auth-redirect"
+		authURL.RawQuery = url.Values{
+			"redirect_uri": {redirectURI.String()},
+		}.Encode()
+		http.Redirect(rw, r, authURL.String(), http.StatusTemporaryRedirect)
+		return
+	}
+
+	api.proxyWorkspaceApplication(rw, r, workspace, agent, app, r.URL.Path)
+}
+
+// workspaceApplicationAuth issues a session token for a subdomain
+// application and redirects back to it. Session cookies for the access
+// URL aren't sent to application hostnames.
+func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request) {
+	apiKey := httpmw.APIKey(r)
+	if api.AppHostname == "" {
+		httpapi.Write(rw, http.StatusNotFound, codersdk.Response{
+			Message: "Subdomain applications are not enabled.",
+		})
+		return
+	}
+	redirectURI, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil || (redirectURI.Scheme != "http" && redirectURI.Scheme != "https") {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid redirect URI.",
+			Validations: []codersdk.ValidationError{
+				{Field: "redirect_uri", Detail: "Must be an absolute HTTP or HTTPS URL."},
+			},
+		})
+		return
+	}
+	// Only application hostnames may receive a token, otherwise this
+	// could be used to send a session token to an arbitrary site.
+	subdomain, ok := httpapi.ExecuteHostnamePattern(api.AppHostname, redirectURI.Host)
+	if ok {
+		_, err = httpapi.ParseSubdomainAppURL(subdomain)
+	}
+	if !ok || err != nil {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid redirect URI.",
+			Validations: []codersdk.ValidationError{
+				{Field: "redirect_uri", Detail: "Must be a workspace application URL."},
+			},
+		})
+		return
+	}
+
+	// The key is handed to the application's origin, so it must not be
+	// usable for anything but connecting to workspace applications.
+	cookie, _, err := api.createAPIKey(r, createAPIKeyParams{
+		UserID:          apiKey.UserID,
+		LoginType:       apiKey.LoginType,
+		ExpiresAt:       apiKey.ExpiresAt,
+		LifetimeSeconds: apiKey.LifetimeSeconds,
+		Scope:           database.APIKeyScopeApplicationConnect,
+	})
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to create API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	query := redirectURI.Query()
+	query.Set(codersdk.AppSessionTokenQueryKey, cookie.Value)
+	redirectURI.RawQuery = query.Encode()
+	http.Redirect(rw, r, redirectURI.String(), http.StatusTemporaryRedirect)
+}
+
+// validateAppSessionToken returns whether a session token passed to an
+// application hostname completes a sign in started by this browser, and is
+// an unexpired API key that can only connect to workspace applications.
+func (api *API) validateAppSessionToken(r *http.Request, token string) (bool, error) {
+	cookie, err := r.Cookie(appAuthStateKey)
+	if err != nil || cookie.Value == "" ||
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.URL.Query().Get(appAuthStateKey))) != 1 {
+		return false, nil
+	}
+	// API keys are formatted: ID-SECRET
+	keyID, keySecret, ok := strings.Cut(token, "-")
+	if !ok {
+		return false, nil
+	}
+	key, err := api.Database.GetAPIKeyByID(r.Context(), keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("get api key: %w", err)
+	}
+	hashed := sha256.Sum256([]byte(keySecret))
+	if subtle.ConstantTimeCompare(key.HashedSecret, hashed[:]) != 1 {
+		return false, nil
+	}
+	return key.ExpiresAt.After(database.Now()) && key.Scope == database.APIKeyScopeApplicationConnect, nil
+}
 
+// appHost returns the wildcard hostname used for subdomain applications.
+func (api *API) appHost(rw http.ResponseWriter, _ *http.Request) {
+	httpapi.Write(rw, http.StatusOK, codersdk.AppHostResponse{
+		Host: api.AppHostname,
+	})
+}
+
+// authorizeWorkspaceApp returns whether the request is permitted to access
+// an application with the sharing level. The workspace owner, and anyone
+// else with execution rights on the workspace, may access every app.
+func (api *API) authorizeWorkspaceApp(r *http.Request, sharingLevel database.AppSharingLevel, workspace database.Workspace) bool {
+	if sharingLevel == database.AppSharingLevelPublic {
+		return true
+	}
+	if _, ok := httpmw.APIKeyOptional(r); !ok {
+		return false
+	}
+	if sharingLevel == database.AppSharingLevelAuthenticated {
+		return true
+	}
+	return api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC())
+}
+
+// workspaceAppByAgentIDAndName fetches an application, writing an error
+// response if it cannot be found. Port numbers resolve to an owner-only
+// application for that port.
+func (api *API) workspaceAppByAgentIDAndName(rw http.ResponseWriter, r *http.Request, agentID uuid.UUID, name string) (database.WorkspaceApp, bool) {
 	app, err := api.Database.GetWorkspaceAppByAgentIDAndName(r.Context(), database.GetWorkspaceAppByAgentIDAndNameParams{
-		AgentID: agent.ID,
-		Name:    chi.URLParam(r, "workspaceapp"),
+		AgentID: agentID,
+		Name:    name,
 	})
 	if errors.Is(err, sql.ErrNoRows) {
+		// A port number that isn't the name of an application proxies to
+		// that port in the workspace, so discovered ports can be opened
+		// without defining an application for each one.
+		if port, err := strconv.ParseUint(name, 10, 16); err == nil && port > 0 {
+			return database.WorkspaceApp{
+				AgentID: agentID,
+				Name:    name,
+				Url: sql.NullString{
+					String: fmt.Sprintf("http://127.0.0.1:%d", port),
+					Valid:  true,
+				},
+				SharingLevel: database.AppSharingLevelOwner,
+			}, true
+		}
 		httpapi.Write(rw, http.StatusNotFound, codersdk.Response{
 			Message: "Application not found.",
 		})
-		return
+		return database.WorkspaceApp{}, false
 	}
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching workspace application.",
 			Detail:  err.Error(),
 		})
-		return
+		return database.WorkspaceApp{}, false
+	}
+	return app, true
+}
+
+// workspaceAgentByName returns the agent with the name from the latest
+// build of the workspace.
+func (api *API) workspaceAgentByName(ctx context.Context, workspace database.Workspace, name string) (database.WorkspaceAgent, error) {
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return database.WorkspaceAgent{}, xerrors.Errorf("get latest workspace build: %w", err)
+	}
+	resources, err := api.Database.GetWorkspaceResourcesByJobID(ctx, build.JobID)
+	if err != nil {
+		return database.WorkspaceAgent{}, xerrors.Errorf("get workspace resources: %w", err)
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
 	}
+	agents, err := api.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil {
+		return database.WorkspaceAgent{}, xerrors.Errorf("get workspace agents: %w", err)
+	}
+	for _, agent := range agents {
+		if agent.Name == name {
+			return agent, nil
+		}
+	}
+	return database.WorkspaceAgent{}, sql.ErrNoRows
+}
+
+// proxyWorkspaceApplication proxies the request to the application through
+// the workspace agent. The path is the request path relative to the root
+// of the application.
+func (api *API) proxyWorkspaceApplication(rw http.ResponseWriter, r *http.Request, workspace database.Workspace, agent database.WorkspaceAgent, app database.WorkspaceApp, path string) {
 	if !app.Url.Valid {
 		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
 			Message: fmt.Sprintf("Application %s does not have a url.", app.Name),
@@ -75,15 +463,6 @@ func (api *API) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request)
 		}))
 		api.siteHandler.ServeHTTP(w, r)
 	}
-	path := chi.URLParam(r, "*")
-	if !strings.HasSuffix(r.URL.Path, "/") && path == "" {
-		// Web applications typically request paths relative to the
-		// root URL. This allows for routing behind a proxy or subpath.
-		// See https://github.com/coder/code-server/issues/241 for examples.
-		r.URL.Path += "/"
-		http.Redirect(rw, r, r.URL.String(), http.StatusTemporaryRedirect)
-		return
-	}
 	if r.URL.RawQuery == "" && appURL.RawQuery != "" {
 		// If the application defines a default set of query parameters,
 		// we should always respect them. The reverse proxy will merge
@@ -106,6 +485,15 @@ func (api *API) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request)
 	}
 	defer release()
 
+	// App traffic counts as workspace activity for inactivity-based
+	// autostop.
+	api.activityBumpWorkspace(r.Context(), workspace)
+
+	traffic, logEnd := api.logWorkspaceAppConnection(r, workspace, agent, app)
+	defer logEnd()
+	traffic.wrapRequest(r)
+	proxy.ModifyResponse = traffic.wrapResponse
+
 	// This strips the session token from a workspace app request.
 	cookieHeaders := r.Header.Values("Cookie")[:]
 	r.Header.Del("Cookie")
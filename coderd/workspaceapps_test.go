@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -67,6 +68,10 @@ func TestWorkspaceAppsProxyPath(t *testing.T) {
 							}, {
 								Name: "fake",
 								Url:  "http://127.0.0.2",
+							}, {
+								Name:         "public",
+								Url:          fmt.Sprintf("http://127.0.0.1:%d", tcpAddr.Port),
+								SharingLevel: proto.AppSharingLevel_PUBLIC,
 							}},
 						}},
 					}},
@@ -114,6 +119,30 @@ func TestWorkspaceAppsProxyPath(t *testing.T) {
 		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
 	})
 
+	t.Run("PublicWithoutAuth", func(t *testing.T) {
+		t.Parallel()
+		client := codersdk.New(client.URL)
+		client.HTTPClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		resp, err := client.Request(ctx, http.MethodGet, "/@"+user.UserID.String()+"/"+workspace.Name+"/apps/public/", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Apps that aren't public still require signing in.
+		resp, err = client.Request(ctx, http.MethodGet, "/@"+user.UserID.String()+"/"+workspace.Name+"/apps/example/", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		location, err := resp.Location()
+		require.NoError(t, err)
+		require.Equal(t, "/login", location.Path)
+	})
+
 	t.Run("RedirectsWithSlash", func(t *testing.T) {
 		t.Parallel()
 
@@ -167,4 +196,271 @@ func TestWorkspaceAppsProxyPath(t *testing.T) {
 		defer resp.Body.Close()
 		require.Equal(t, http.StatusOK, resp.StatusCode)
 	})
+
+	t.Run("ProxiesPort", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		resp, err := client.Request(ctx, http.MethodGet, fmt.Sprintf("/@me/%s/apps/%d/", workspace.Name, tcpAddr.Port), nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestWorkspaceAppsProxySubdomain(t *testing.T) {
+	t.Parallel()
+	// #nosec
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	server := http.Server{
+		ReadHeaderTimeout: time.Minute,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := r.Cookie(codersdk.SessionTokenKey)
+			assert.ErrorIs(t, err, http.ErrNoCookie)
+			_, _ = w.Write([]byte(r.URL.Path))
+		}),
+	}
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = ln.Close()
+	})
+	go server.Serve(ln)
+	tcpAddr, _ := ln.Addr().(*net.TCPAddr)
+	appURL := fmt.Sprintf("http://127.0.0.1:%d", tcpAddr.Port)
+
+	const appHostname = "*.apps.coder.test"
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerD: true,
+		AppHostname:         appHostname,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:           echo.ParseComplete,
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: "dev",
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							Apps: []*proto.App{{
+								Name: "owner",
+								Url:  appURL,
+							}, {
+								Name:         "authenticated",
+								Url:          appURL,
+								SharingLevel: proto.AppSharingLevel_AUTHENTICATED,
+							}, {
+								Name:         "public",
+								Url:          appURL,
+								SharingLevel: proto.AppSharingLevel_PUBLIC,
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SessionToken = authToken
+	agentCloser := agent.New(agent.Options{
+		FetchMetadata:     agentClient.WorkspaceAgentMetadata,
+		CoordinatorDialer: agentClient.ListenWorkspaceAgentTailnet,
+		WebRTCDialer:      agentClient.ListenWorkspaceAgent,
+		Logger:            slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	me, err := client.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+	otherClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+	appHost := func(app string) string {
+		return fmt.Sprintf("%s--dev--%s--%s.apps.coder.test", app, workspace.Name, me.Username)
+	}
+	// request sends a request to the coderd server with the application
+	// hostname. An empty session token makes an anonymous request.
+	request := func(t *testing.T, host, path, sessionToken string, cookies ...*http.Cookie) *http.Response {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.URL.String()+path, nil)
+		require.NoError(t, err)
+		req.Host = host
+		if sessionToken != "" {
+			req.AddCookie(&http.Cookie{
+				Name:  codersdk.SessionTokenKey,
+				Value: sessionToken,
+			})
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		httpClient := &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		resp, err := httpClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = resp.Body.Close()
+		})
+		return resp
+	}
+
+	t.Run("Proxies", func(t *testing.T) {
+		t.Parallel()
+		resp := request(t, appHost("owner"), "/some/path", client.SessionToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "/some/path", string(body))
+	})
+
+	t.Run("RedirectsWithoutAuth", func(t *testing.T) {
+		t.Parallel()
+		resp := request(t, appHost("owner"), "/", "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		location, err := resp.Location()
+		require.NoError(t, err)
+		require.Equal(t, client.URL.Host, location.Host)
+		require.Equal(t, "/api/v2/applications/auth-redirect", location.Path)
+		redirectURI, err := url.Parse(location.Query().Get("redirect_uri"))
+		require.NoError(t, err)
+		require.Equal(t, appHost("owner"), redirectURI.Host)
+	})
+
+	t.Run("OwnerSharingLevel", func(t *testing.T) {
+		t.Parallel()
+		resp := request(t, appHost("owner"), "/", otherClient.SessionToken)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("AuthenticatedSharingLevel", func(t *testing.T) {
+		t.Parallel()
+		resp := request(t, appHost("authenticated"), "/", otherClient.SessionToken)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = request(t, appHost("authenticated"), "/", "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	})
+
+	t.Run("PublicSharingLevel", func(t *testing.T) {
+		t.Parallel()
+		resp := request(t, appHost("public"), "/", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+		resp := request(t, appHost("nope"), "/", client.SessionToken)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("SetsSessionCookie", func(t *testing.T) {
+		t.Parallel()
+		// Signing in starts on the application hostname, which stores
+		// the state the token must be returned with.
+		resp := request(t, appHost("owner"), "/path?other=true", "")
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		cookies := resp.Cookies()
+		require.Len(t, cookies, 1)
+		stateCookie := cookies[0]
+		location, err := resp.Location()
+		require.NoError(t, err)
+
+		resp = request(t, client.URL.Host, location.RequestURI(), client.SessionToken)
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		location, err = resp.Location()
+		require.NoError(t, err)
+		token := location.Query().Get(codersdk.AppSessionTokenQueryKey)
+		require.NotEmpty(t, token)
+
+		resp = request(t, appHost("owner"), location.RequestURI(), "", stateCookie)
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		location, err = resp.Location()
+		require.NoError(t, err)
+		require.Equal(t, "/path", location.Path)
+		require.Equal(t, "other=true", location.RawQuery)
+		var sessionCookie *http.Cookie
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == codersdk.SessionTokenKey {
+				sessionCookie = cookie
+			}
+		}
+		require.NotNil(t, sessionCookie)
+		require.Equal(t, token, sessionCookie.Value)
+	})
+
+	t.Run("RejectsSessionTokenFromAnotherBrowser", func(t *testing.T) {
+		t.Parallel()
+		redirectURI := "http://" + appHost("owner") + "/path?" + url.Values{"coder_application_connect_state": {"state"}}.Encode()
+		resp := request(t, client.URL.Host, "/api/v2/applications/auth-redirect?redirect_uri="+url.QueryEscape(redirectURI), client.SessionToken)
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		location, err := resp.Location()
+		require.NoError(t, err)
+
+		// Without the state cookie, the token was sent by someone else.
+		resp = request(t, appHost("owner"), location.RequestURI(), "")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Empty(t, resp.Cookies())
+
+		// Tokens must be valid API keys, even with the state.
+		resp = request(t, appHost("owner"), "/path?coder_application_connect_state=state&"+codersdk.AppSessionTokenQueryKey+"=token", "", &http.Cookie{
+			Name:  "coder_application_connect_state",
+			Value: "state",
+		})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Empty(t, resp.Cookies())
+	})
+
+	t.Run("AuthRedirect", func(t *testing.T) {
+		t.Parallel()
+		redirectURI := "http://" + appHost("owner") + "/path"
+		resp := request(t, client.URL.Host, "/api/v2/applications/auth-redirect?redirect_uri="+url.QueryEscape(redirectURI), client.SessionToken)
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		location, err := resp.Location()
+		require.NoError(t, err)
+		require.Equal(t, appHost("owner"), location.Host)
+		require.NotEmpty(t, location.Query().Get(codersdk.AppSessionTokenQueryKey))
+
+		// The issued token must authenticate the owner.
+		resp = request(t, appHost("owner"), "/", location.Query().Get(codersdk.AppSessionTokenQueryKey))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("AuthRedirectInvalidURI", func(t *testing.T) {
+		t.Parallel()
+		resp := request(t, client.URL.Host, "/api/v2/applications/auth-redirect?redirect_uri="+url.QueryEscape("https://example.com"), client.SessionToken)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("AppHost", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		host, err := client.AppHost(ctx)
+		require.NoError(t, err)
+		require.Equal(t, appHostname, host.Host)
+	})
 }
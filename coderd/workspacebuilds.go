@@ -14,6 +14,7 @@ import (
 	"golang.org/x/exp/slices"
 	"golang.org/x/xerrors"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
@@ -30,7 +31,7 @@ func (api *API) workspaceBuild(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job, err := api.Database.GetProvisionerJobByID(r.Context(), workspaceBuild.JobID)
+	job, queuePosition, err := getProvisionerJobWithQueuePosition(r.Context(), api.Database, workspaceBuild.JobID)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching provisioner job.",
@@ -50,7 +51,7 @@ func (api *API) workspaceBuild(rw http.ResponseWriter, r *http.Request) {
 
 	httpapi.Write(rw, http.StatusOK,
 		convertWorkspaceBuild(findUser(workspace.OwnerID, users), findUser(workspaceBuild.InitiatorID, users),
-			workspace, workspaceBuild, job))
+			workspace, workspaceBuild, job, queuePosition))
 }
 
 func (api *API) workspaceBuilds(rw http.ResponseWriter, r *http.Request) {
@@ -116,7 +117,7 @@ func (api *API) workspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 	for _, build := range builds {
 		jobIDs = append(jobIDs, build.JobID)
 	}
-	jobs, err := api.Database.GetProvisionerJobsByIDs(r.Context(), jobIDs)
+	jobs, queuePositions, err := getProvisionerJobsWithQueuePositions(r.Context(), api.Database, jobIDs)
 	if errors.Is(err, sql.ErrNoRows) {
 		err = nil
 	}
@@ -156,7 +157,7 @@ func (api *API) workspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		}
 		apiBuilds = append(apiBuilds,
 			convertWorkspaceBuild(findUser(workspace.OwnerID, users), findUser(build.InitiatorID, users),
-				workspace, build, job))
+				workspace, build, job, queuePositions[job.ID]))
 	}
 
 	httpapi.Write(rw, http.StatusOK, apiBuilds)
@@ -213,7 +214,7 @@ func (api *API) workspaceBuildByBuildNumber(rw http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	job, err := api.Database.GetProvisionerJobByID(r.Context(), workspaceBuild.JobID)
+	job, queuePosition, err := getProvisionerJobWithQueuePosition(r.Context(), api.Database, workspaceBuild.JobID)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching provisioner job.",
@@ -233,7 +234,7 @@ func (api *API) workspaceBuildByBuildNumber(rw http.ResponseWriter, r *http.Requ
 
 	httpapi.Write(rw, http.StatusOK,
 		convertWorkspaceBuild(findUser(workspace.OwnerID, users), findUser(workspaceBuild.InitiatorID, users),
-			workspace, workspaceBuild, job))
+			workspace, workspaceBuild, job, queuePosition))
 }
 
 func (api *API) workspaceBuildByName(rw http.ResponseWriter, r *http.Request) {
@@ -259,7 +260,7 @@ func (api *API) workspaceBuildByName(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	job, err := api.Database.GetProvisionerJobByID(r.Context(), workspaceBuild.JobID)
+	job, queuePosition, err := getProvisionerJobWithQueuePosition(r.Context(), api.Database, workspaceBuild.JobID)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching provisioner job.",
@@ -278,7 +279,7 @@ func (api *API) workspaceBuildByName(rw http.ResponseWriter, r *http.Request) {
 
 	httpapi.Write(rw, http.StatusOK,
 		convertWorkspaceBuild(findUser(workspace.OwnerID, users), findUser(workspaceBuild.InitiatorID, users),
-			workspace, workspaceBuild, job))
+			workspace, workspaceBuild, job, queuePosition))
 }
 
 func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
@@ -289,19 +290,40 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Rbac action depends on the transition
-	var action rbac.Action
+	// Rbac and audit actions depend on the transition
+	var (
+		action      rbac.Action
+		auditAction database.AuditAction
+	)
 	switch createBuild.Transition {
 	case codersdk.WorkspaceTransitionDelete:
 		action = rbac.ActionDelete
-	case codersdk.WorkspaceTransitionStart, codersdk.WorkspaceTransitionStop:
+		auditAction = database.AuditActionDelete
+	case codersdk.WorkspaceTransitionStart:
+		action = rbac.ActionUpdate
+		auditAction = database.AuditActionStart
+	case codersdk.WorkspaceTransitionStop:
 		action = rbac.ActionUpdate
+		auditAction = database.AuditActionStop
 	default:
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: fmt.Sprintf("Transition %q not supported.", createBuild.Transition),
 		})
 		return
 	}
+
+	auditParams := &audit.RequestParams{
+		Audit:          api.Auditor,
+		Log:            api.Logger,
+		Request:        r,
+		Action:         auditAction,
+		ResourceType:   database.ResourceTypeWorkspaceBuild,
+		ResourceTarget: workspace.Name,
+		Actor:          apiKey.UserID,
+	}
+	aReq, commitAudit := audit.InitRequest[database.WorkspaceBuild](rw, auditParams)
+	defer commitAudit()
+
 	if !api.Authorize(r, action, workspace) {
 		httpapi.ResourceNotFound(rw)
 		return
@@ -344,7 +366,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	templateVersionJobStatus := convertProvisionerJob(templateVersionJob).Status
+	templateVersionJobStatus := ConvertProvisionerJobStatus(templateVersionJob)
 	switch templateVersionJobStatus {
 	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning:
 		httpapi.Write(rw, http.StatusNotAcceptable, codersdk.Response{
@@ -363,6 +385,41 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	parameterSchemas, err := api.Database.GetParameterSchemasByJobID(r.Context(), templateVersion.JobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching parameter schemas.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	validations := api.validateParameterValues(r.Context(), templateVersion.JobID, parameterSchemas, createBuild.ParameterValues)
+	if len(validations) > 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid parameter values.",
+			Validations: validations,
+		})
+		return
+	}
+	validations, err = api.validateParameterChanges(r.Context(), workspace.ID, parameterSchemas, createBuild.Transition, createBuild.ParameterValues)
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error validating parameter changes.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if len(validations) > 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Parameters can't be changed.",
+			Validations: validations,
+		})
+		return
+	}
+
 	template, err := api.Database.GetTemplateByID(r.Context(), templateVersion.TemplateID.UUID)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
@@ -377,7 +434,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 	priorHistory, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(r.Context(), workspace.ID)
 	if err == nil {
 		priorJob, err := api.Database.GetProvisionerJobByID(r.Context(), priorHistory.JobID)
-		if err == nil && convertProvisionerJob(priorJob).Status.Active() {
+		if err == nil && ConvertProvisionerJobStatus(priorJob).Active() {
 			httpapi.Write(rw, http.StatusConflict, codersdk.Response{
 				Message: "A workspace build is already active.",
 			})
@@ -453,6 +510,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 			StorageMethod:  templateVersionJob.StorageMethod,
 			StorageSource:  templateVersionJob.StorageSource,
 			Input:          input,
+			Tags:           templateVersionJob.Tags,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -489,6 +547,8 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	auditParams.ResourceID = workspaceBuild.ID
+	aReq.New = workspaceBuild
 
 	users, err := api.Database.GetUsersByIDs(r.Context(), []uuid.UUID{
 		workspace.OwnerID,
@@ -504,7 +564,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 
 	httpapi.Write(rw, http.StatusCreated,
 		convertWorkspaceBuild(findUser(workspace.OwnerID, users), findUser(workspaceBuild.InitiatorID, users),
-			workspace, workspaceBuild, provisionerJob))
+			workspace, workspaceBuild, provisionerJob, database.GetProvisionerJobQueuePositionsByIDsRow{}))
 }
 
 func (api *API) patchCancelWorkspaceBuild(rw http.ResponseWriter, r *http.Request) {
@@ -542,13 +602,7 @@ func (api *API) patchCancelWorkspaceBuild(rw http.ResponseWriter, r *http.Reques
 		})
 		return
 	}
-	err = api.Database.UpdateProvisionerJobWithCancelByID(r.Context(), database.UpdateProvisionerJobWithCancelByIDParams{
-		ID: job.ID,
-		CanceledAt: sql.NullTime{
-			Time:  database.Now(),
-			Valid: true,
-		},
-	})
+	err = api.cancelProvisionerJob(r.Context(), job)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error updating provisioner job.",
@@ -633,12 +687,42 @@ func (api *API) workspaceBuildState(rw http.ResponseWriter, r *http.Request) {
 	_, _ = rw.Write(workspaceBuild.ProvisionerState)
 }
 
+func (api *API) workspaceBuildParameters(rw http.ResponseWriter, r *http.Request) {
+	workspaceBuild := httpmw.WorkspaceBuildParam(r)
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	params, err := api.Database.GetWorkspaceBuildParameters(r.Context(), workspaceBuild.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	apiParams := make([]codersdk.WorkspaceBuildParameter, 0, len(params))
+	for _, param := range params {
+		apiParams = append(apiParams, codersdk.WorkspaceBuildParameter{
+			Name:  param.Name,
+			Value: param.Value,
+		})
+	}
+	httpapi.Write(rw, http.StatusOK, apiParams)
+}
+
 func convertWorkspaceBuild(
 	workspaceOwner *database.User,
 	buildInitiator *database.User,
 	workspace database.Workspace,
 	workspaceBuild database.WorkspaceBuild,
 	job database.ProvisionerJob,
+	queuePosition database.GetProvisionerJobQueuePositionsByIDsRow,
 ) codersdk.WorkspaceBuild {
 	//nolint:unconvert
 	if workspace.ID != workspaceBuild.WorkspaceID {
@@ -671,7 +755,7 @@ func convertWorkspaceBuild(
 		Transition:         codersdk.WorkspaceTransition(workspaceBuild.Transition),
 		InitiatorID:        workspaceBuild.InitiatorID,
 		InitiatorUsername:  initiatorName,
-		Job:                convertProvisionerJob(job),
+		Job:                convertProvisionerJob(job, queuePosition),
 		Deadline:           codersdk.NewNullTime(workspaceBuild.Deadline, !workspaceBuild.Deadline.IsZero()),
 		Reason:             codersdk.BuildReason(workspaceBuild.Reason),
 	}
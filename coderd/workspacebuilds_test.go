@@ -413,3 +413,100 @@ func TestWorkspaceBuildState(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, wantState, gotState)
 }
+
+func TestWorkspaceBuildParameters(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	schema := func(name string, mutable, redisplay bool) *proto.ParameterSchema {
+		return &proto.ParameterSchema{
+			Name:                name,
+			Mutable:             mutable,
+			RedisplayValue:      redisplay,
+			AllowOverrideSource: true,
+			DefaultDestination: &proto.ParameterDestination{
+				Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+			},
+		}
+	}
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: []*proto.Parse_Response{{
+			Type: &proto.Parse_Response_Complete{
+				Complete: &proto.Parse_Complete{
+					ParameterSchemas: []*proto.ParameterSchema{
+						schema("size", true, true),
+						schema("region", false, true),
+						schema("secret", true, false),
+					},
+				},
+			},
+		}},
+		ProvisionDryRun: echo.ProvisionComplete,
+		Provision:       echo.ProvisionComplete,
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	param := func(name, value string) codersdk.CreateParameterRequest {
+		return codersdk.CreateParameterRequest{
+			Name:              name,
+			SourceValue:       value,
+			SourceScheme:      codersdk.ParameterSourceSchemeData,
+			DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+		}
+	}
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(req *codersdk.CreateWorkspaceRequest) {
+		req.ParameterValues = []codersdk.CreateParameterRequest{
+			param("size", "small"),
+			param("region", "eu"),
+			param("secret", "hunter2"),
+		}
+	})
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// Values of parameters that aren't redisplayed aren't recorded.
+	params, err := client.WorkspaceBuildParameters(ctx, workspace.LatestBuild.ID)
+	require.NoError(t, err)
+	require.Equal(t, []codersdk.WorkspaceBuildParameter{
+		{Name: "region", Value: "eu"},
+		{Name: "size", Value: "small"},
+	}, params)
+
+	_, err = client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition:      codersdk.WorkspaceTransitionStart,
+		ParameterValues: []codersdk.CreateParameterRequest{param("region", "us")},
+	})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	require.Len(t, apiErr.Validations, 1)
+	require.Equal(t, "region", apiErr.Validations[0].Field)
+
+	_, err = client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition:      codersdk.WorkspaceTransitionStop,
+		ParameterValues: []codersdk.CreateParameterRequest{param("size", "large")},
+	})
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+	// Unchanged immutable values can be sent along with changes.
+	build, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition:      codersdk.WorkspaceTransitionStart,
+		ParameterValues: []codersdk.CreateParameterRequest{param("size", "large"), param("region", "eu")},
+	})
+	require.NoError(t, err)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+
+	params, err = client.WorkspaceBuildParameters(ctx, build.ID)
+	require.NoError(t, err)
+	require.Equal(t, []codersdk.WorkspaceBuildParameter{
+		{Name: "region", Value: "eu"},
+		{Name: "size", Value: "large"},
+	}, params)
+
+	err = client.DeleteParameter(ctx, codersdk.ParameterWorkspace, workspace.ID, "region")
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+}
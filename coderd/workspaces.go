@@ -83,13 +83,14 @@ func (api *API) workspace(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var (
-		group    errgroup.Group
-		job      database.ProvisionerJob
-		template database.Template
-		users    []database.User
+		group         errgroup.Group
+		job           database.ProvisionerJob
+		queuePosition database.GetProvisionerJobQueuePositionsByIDsRow
+		template      database.Template
+		users         []database.User
 	)
 	group.Go(func() (err error) {
-		job, err = api.Database.GetProvisionerJobByID(r.Context(), build.JobID)
+		job, queuePosition, err = getProvisionerJobWithQueuePosition(r.Context(), api.Database, build.JobID)
 		return err
 	})
 	group.Go(func() (err error) {
@@ -109,7 +110,7 @@ func (api *API) workspace(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(rw, http.StatusOK, convertWorkspace(workspace, build, job, template,
+	httpapi.Write(rw, http.StatusOK, convertWorkspace(workspace, build, job, queuePosition, template,
 		findUser(workspace.OwnerID, users), findUser(build.InitiatorID, users)))
 }
 
@@ -132,6 +133,7 @@ func (api *API) workspaces(rw http.ResponseWriter, r *http.Request) {
 		filter.OwnerID = apiKey.UserID
 		filter.OwnerUsername = ""
 	}
+	filter.AgentInactiveDisconnectTimeoutSeconds = int64(api.AgentInactiveDisconnectTimeout.Seconds())
 
 	workspaces, err := api.Database.GetWorkspaces(r.Context(), filter)
 	if err != nil {
@@ -217,7 +219,7 @@ func (api *API) workspaceByOwnerAndName(rw http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
-	job, err := api.Database.GetProvisionerJobByID(r.Context(), build.JobID)
+	job, queuePosition, err := getProvisionerJobWithQueuePosition(r.Context(), api.Database, build.JobID)
 	if err != nil {
 		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching provisioner job.",
@@ -243,7 +245,7 @@ func (api *API) workspaceByOwnerAndName(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	httpapi.Write(rw, http.StatusOK, convertWorkspace(workspace, build, job, template, &owner, &initiator))
+	httpapi.Write(rw, http.StatusOK, convertWorkspace(workspace, build, job, queuePosition, template, &owner, &initiator))
 }
 
 // Create a new workspace for the currently authenticated user.
@@ -349,7 +351,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		})
 		return
 	}
-	templateVersionJobStatus := convertProvisionerJob(templateVersionJob).Status
+	templateVersionJobStatus := ConvertProvisionerJobStatus(templateVersionJob)
 	switch templateVersionJobStatus {
 	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning:
 		httpapi.Write(rw, http.StatusNotAcceptable, codersdk.Response{
@@ -368,6 +370,26 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
+	parameterSchemas, err := api.Database.GetParameterSchemasByJobID(r.Context(), templateVersion.JobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching parameter schemas.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	validations := api.validateParameterValues(r.Context(), templateVersion.JobID, parameterSchemas, createWorkspace.ParameterValues)
+	if len(validations) > 0 {
+		httpapi.Write(rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid parameter values.",
+			Validations: validations,
+		})
+		return
+	}
+
 	var provisionerJob database.ProvisionerJob
 	var workspaceBuild database.WorkspaceBuild
 	err = api.Database.InTx(func(db database.Store) error {
@@ -422,6 +444,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 			StorageMethod:  templateVersionJob.StorageMethod,
 			StorageSource:  templateVersionJob.StorageSource,
 			Input:          input,
+			Tags:           templateVersionJob.Tags,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
@@ -466,7 +489,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		WorkspaceBuilds: []telemetry.WorkspaceBuild{telemetry.ConvertWorkspaceBuild(workspaceBuild)},
 	})
 
-	httpapi.Write(rw, http.StatusCreated, convertWorkspace(workspace, workspaceBuild, templateVersionJob, template,
+	httpapi.Write(rw, http.StatusCreated, convertWorkspace(workspace, workspaceBuild, templateVersionJob, database.GetProvisionerJobQueuePositionsByIDsRow{}, template,
 		findUser(apiKey.UserID, users), findUser(workspaceBuild.InitiatorID, users)))
 }
 
@@ -777,13 +800,14 @@ func (api *API) watchWorkspace(rw http.ResponseWriter, r *http.Request) {
 				return
 			}
 			var (
-				group    errgroup.Group
-				job      database.ProvisionerJob
-				template database.Template
-				users    []database.User
+				group         errgroup.Group
+				job           database.ProvisionerJob
+				queuePosition database.GetProvisionerJobQueuePositionsByIDsRow
+				template      database.Template
+				users         []database.User
 			)
 			group.Go(func() (err error) {
-				job, err = api.Database.GetProvisionerJobByID(r.Context(), build.JobID)
+				job, queuePosition, err = getProvisionerJobWithQueuePosition(r.Context(), api.Database, build.JobID)
 				return err
 			})
 			group.Go(func() (err error) {
@@ -803,7 +827,7 @@ func (api *API) watchWorkspace(rw http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			_ = wsjson.Write(ctx, c, convertWorkspace(workspace, build, job, template,
+			_ = wsjson.Write(ctx, c, convertWorkspace(workspace, build, job, queuePosition, template,
 				findUser(workspace.OwnerID, users), findUser(build.InitiatorID, users)))
 		case <-ctx.Done():
 			return
@@ -848,7 +872,7 @@ func convertWorkspaces(ctx context.Context, db database.Store, workspaces []data
 	for _, build := range workspaceBuilds {
 		jobIDs = append(jobIDs, build.JobID)
 	}
-	jobs, err := db.GetProvisionerJobsByIDs(ctx, jobIDs)
+	jobs, queuePositions, err := getProvisionerJobsWithQueuePositions(ctx, db, jobIDs)
 	if errors.Is(err, sql.ErrNoRows) {
 		err = nil
 	}
@@ -908,7 +932,7 @@ func convertWorkspaces(ctx context.Context, db database.Store, workspaces []data
 		if !exists {
 			return nil, xerrors.Errorf("build initiator not found for workspace: %q", workspace.Name)
 		}
-		apiWorkspaces = append(apiWorkspaces, convertWorkspace(workspace, build, job, template, &owner, &initiator))
+		apiWorkspaces = append(apiWorkspaces, convertWorkspace(workspace, build, job, queuePositions[job.ID], template, &owner, &initiator))
 	}
 	return apiWorkspaces, nil
 }
@@ -917,6 +941,7 @@ func convertWorkspace(
 	workspace database.Workspace,
 	workspaceBuild database.WorkspaceBuild,
 	job database.ProvisionerJob,
+	queuePosition database.GetProvisionerJobQueuePositionsByIDsRow,
 	template database.Template,
 	owner *database.User,
 	initiator *database.User,
@@ -934,7 +959,7 @@ func convertWorkspace(
 		OwnerID:           workspace.OwnerID,
 		OwnerName:         owner.Username,
 		TemplateID:        workspace.TemplateID,
-		LatestBuild:       convertWorkspaceBuild(owner, initiator, workspace, workspaceBuild, job),
+		LatestBuild:       convertWorkspaceBuild(owner, initiator, workspace, workspaceBuild, job, queuePosition),
 		TemplateName:      template.Name,
 		TemplateIcon:      template.Icon,
 		Outdated:          workspaceBuild.TemplateVersionID.String() != template.ActiveVersionID.String(),
@@ -942,6 +967,8 @@ func convertWorkspace(
 		AutostartSchedule: autostartSchedule,
 		TTLMillis:         ttlMillis,
 		LastUsedAt:        workspace.LastUsedAt,
+
+		TemplateWaitForAgentReady: template.WaitForAgentReady,
 	}
 }
 
@@ -1062,15 +1089,63 @@ func workspaceSearchQuery(query string) (database.GetWorkspacesParams, []codersd
 	// other parsing.
 	parser := httpapi.NewQueryParamParser()
 	filter := database.GetWorkspacesParams{
-		Deleted:       false,
-		OwnerUsername: parser.String(searchParams, "", "owner"),
-		TemplateName:  parser.String(searchParams, "", "template"),
-		Name:          parser.String(searchParams, "", "name"),
+		Deleted:         false,
+		OwnerUsername:   parser.String(searchParams, "", "owner"),
+		TemplateName:    parser.String(searchParams, "", "template"),
+		Name:            parser.String(searchParams, "", "name"),
+		Status:          httpapi.ParseCustom(parser, searchParams, "", "status", parseWorkspaceStatusFilter),
+		TemplateVersion: parser.String(searchParams, "", "template_version"),
+		Outdated:        httpapi.ParseCustom(parser, searchParams, "", "outdated", parseWorkspaceBoolFilter),
+		LastUsedBefore:  httpapi.ParseCustom(parser, searchParams, time.Time{}, "last_used_before", parseWorkspaceTimeFilter),
+		LastUsedAfter:   httpapi.ParseCustom(parser, searchParams, time.Time{}, "last_used_after", parseWorkspaceTimeFilter),
+		HasAgent:        httpapi.ParseCustom(parser, searchParams, "", "has_agent", parseWorkspaceAgentFilter),
 	}
 
 	return filter, parser.Errors
 }
 
+func parseWorkspaceStatusFilter(v string) (string, error) {
+	switch v {
+	case "running", "stopped", "failed", "pending":
+		return v, nil
+	}
+	return "", xerrors.Errorf("%q is not a valid status, must be one of running, stopped, failed or pending", v)
+}
+
+func parseWorkspaceAgentFilter(v string) (string, error) {
+	switch v {
+	case string(codersdk.WorkspaceAgentConnected), string(codersdk.WorkspaceAgentDisconnected):
+		return v, nil
+	}
+	return "", xerrors.Errorf("%q is not a valid agent status, must be connected or disconnected", v)
+}
+
+// parseWorkspaceBoolFilter normalizes a boolean to "true" or "false", which is
+// how optional boolean filters are passed to the database.
+func parseWorkspaceBoolFilter(v string) (string, error) {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return "", xerrors.Errorf("%q is not a valid boolean", v)
+	}
+	return strconv.FormatBool(b), nil
+}
+
+// parseWorkspaceTimeFilter parses dates formatted as YYYY-MM-DD in UTC, or
+// quoted RFC3339 timestamps.
+func parseWorkspaceTimeFilter(v string) (time.Time, error) {
+	// The search query is lowercased, but RFC3339 requires uppercase
+	// separators.
+	t, err := time.Parse(time.RFC3339, strings.ToUpper(v))
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("%q is not a valid time, must be formatted as YYYY-MM-DD or RFC3339", v)
+	}
+	return t, nil
+}
+
 // splitQueryParameterByDelimiter takes a query string and splits it into the individual elements
 // of the query. Each element is separated by a delimiter. All quoted strings are
 // kept as a single element.
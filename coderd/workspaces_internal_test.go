@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coder/coder/coderd/database"
 
@@ -117,6 +118,37 @@ func TestSearchWorkspace(t *testing.T) {
 				OwnerUsername: "foo",
 			},
 		},
+		{
+			Name:  "Status",
+			Query: "status:Running has_agent:connected",
+			Expected: database.GetWorkspacesParams{
+				Status:   "running",
+				HasAgent: "connected",
+			},
+		},
+		{
+			Name:  "TemplateVersion",
+			Query: "template:docker template_version:v1.2",
+			Expected: database.GetWorkspacesParams{
+				TemplateName:    "docker",
+				TemplateVersion: "v1.2",
+			},
+		},
+		{
+			Name:  "Outdated",
+			Query: "outdated:1",
+			Expected: database.GetWorkspacesParams{
+				Outdated: "true",
+			},
+		},
+		{
+			Name:  "LastUsed",
+			Query: `last_used_after:2022-09-01 last_used_before:"2022-10-01T12:00:00Z"`,
+			Expected: database.GetWorkspacesParams{
+				LastUsedAfter:  time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC),
+				LastUsedBefore: time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
 
 		// Failures
 		{
@@ -129,6 +161,26 @@ func TestSearchWorkspace(t *testing.T) {
 			Query:                 `owner:name:extra`,
 			ExpectedErrorContains: "can only contain 1 ':'",
 		},
+		{
+			Name:                  "InvalidStatus",
+			Query:                 `status:sleeping`,
+			ExpectedErrorContains: "not a valid status",
+		},
+		{
+			Name:                  "InvalidAgentStatus",
+			Query:                 `has_agent:connecting`,
+			ExpectedErrorContains: "not a valid agent status",
+		},
+		{
+			Name:                  "InvalidOutdated",
+			Query:                 `outdated:maybe`,
+			ExpectedErrorContains: "not a valid boolean",
+		},
+		{
+			Name:                  "InvalidLastUsed",
+			Query:                 `last_used_before:yesterday`,
+			ExpectedErrorContains: "not a valid time",
+		},
 	}
 
 	for _, c := range testCases {
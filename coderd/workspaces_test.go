@@ -11,8 +11,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
@@ -340,6 +342,34 @@ func TestPostWorkspacesByOrganization(t *testing.T) {
 		require.Equal(t, apiErr.Validations[0].Field, "schedule")
 		require.Equal(t, apiErr.Validations[0].Detail, "Minimum autostart interval 1m0s below template minimum 1h0m0s")
 	})
+
+	t.Run("InvalidParameter", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := createValidatedTemplateVersion(t, client, user)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateWorkspace(ctx, template.OrganizationID, codersdk.CreateWorkspaceRequest{
+			TemplateID: template.ID,
+			Name:       "testing",
+			ParameterValues: []codersdk.CreateParameterRequest{{
+				Name:              "instances",
+				SourceValue:       "many",
+				SourceScheme:      codersdk.ParameterSourceSchemeData,
+				DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+			}},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "instances", apiErr.Validations[0].Field)
+		require.Contains(t, apiErr.Validations[0].Detail, "is not a valid number")
+	})
 }
 
 func TestWorkspaceByOwnerAndName(t *testing.T) {
@@ -726,6 +756,93 @@ func TestWorkspaceFilterManual(t *testing.T) {
 		require.Len(t, ws, 1)
 		require.Equal(t, workspace.ID, ws[0].ID)
 	})
+	t.Run("Status", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		running := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, running.LatestBuild.ID)
+		stopped := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, stopped.LatestBuild.ID)
+		build := coderdtest.CreateWorkspaceBuild(t, client, stopped, database.WorkspaceTransitionStop)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		ws, err := client.Workspaces(ctx, codersdk.WorkspaceFilter{
+			FilterQuery: "status:running",
+		})
+		require.NoError(t, err)
+		require.Len(t, ws, 1)
+		require.Equal(t, running.ID, ws[0].ID)
+
+		ws, err = client.Workspaces(ctx, codersdk.WorkspaceFilter{
+			FilterQuery: "status:stopped",
+		})
+		require.NoError(t, err)
+		require.Len(t, ws, 1)
+		require.Equal(t, stopped.ID, ws[0].ID)
+
+		ws, err = client.Workspaces(ctx, codersdk.WorkspaceFilter{
+			FilterQuery: "status:failed",
+		})
+		require.NoError(t, err)
+		require.Len(t, ws, 0)
+
+		_, err = client.Workspaces(ctx, codersdk.WorkspaceFilter{
+			FilterQuery: "status:sleeping",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+	t.Run("Outdated", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		outdated := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, outdated.LatestBuild.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		version2 := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, version2.ID)
+		err := client.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: version2.ID,
+		})
+		require.NoError(t, err)
+		current := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, current.LatestBuild.ID)
+
+		ws, err := client.Workspaces(ctx, codersdk.WorkspaceFilter{
+			FilterQuery: "outdated:true",
+		})
+		require.NoError(t, err)
+		require.Len(t, ws, 1)
+		require.Equal(t, outdated.ID, ws[0].ID)
+
+		ws, err = client.Workspaces(ctx, codersdk.WorkspaceFilter{
+			FilterQuery: "outdated:false",
+		})
+		require.NoError(t, err)
+		require.Len(t, ws, 1)
+		require.Equal(t, current.ID, ws[0].ID)
+
+		ws, err = client.Workspaces(ctx, codersdk.WorkspaceFilter{
+			FilterQuery: fmt.Sprintf("template_version:%s", version.Name),
+		})
+		require.NoError(t, err)
+		require.Len(t, ws, 1)
+		require.Equal(t, outdated.ID, ws[0].ID)
+	})
 }
 
 func TestPostWorkspaceBuild(t *testing.T) {
@@ -821,6 +938,40 @@ func TestPostWorkspaceBuild(t *testing.T) {
 		require.Equal(t, workspace.LatestBuild.BuildNumber+1, build.BuildNumber)
 	})
 
+	t.Run("InvalidParameter", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := createValidatedTemplateVersion(t, client, user)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(req *codersdk.CreateWorkspaceRequest) {
+			req.ParameterValues = []codersdk.CreateParameterRequest{{
+				Name:              "instances",
+				SourceValue:       "2",
+				SourceScheme:      codersdk.ParameterSourceSchemeData,
+				DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+			}}
+		})
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+			ParameterValues: []codersdk.CreateParameterRequest{{
+				Name:              "instances",
+				SourceValue:       "16",
+				SourceScheme:      codersdk.ParameterSourceSchemeData,
+				DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
+			}},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Equal(t, []codersdk.ValidationError{{Field: "instances", Detail: "At most 4 instances are allowed."}}, apiErr.Validations)
+	})
+
 	t.Run("WithState", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, &coderdtest.Options{
@@ -850,7 +1001,8 @@ func TestPostWorkspaceBuild(t *testing.T) {
 
 	t.Run("Delete", func(t *testing.T) {
 		t.Parallel()
-		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true})
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerD: true, Auditor: auditor})
 		user := coderdtest.CreateFirstUser(t, client)
 		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
 		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
@@ -866,6 +1018,14 @@ func TestPostWorkspaceBuild(t *testing.T) {
 		})
 		require.NoError(t, err)
 		require.Equal(t, workspace.LatestBuild.BuildNumber+1, build.BuildNumber)
+
+		alogs := auditor.AuditLogs()
+		require.NotEmpty(t, alogs)
+		alog := alogs[len(alogs)-1]
+		require.Equal(t, database.AuditActionDelete, alog.Action)
+		require.Equal(t, database.ResourceTypeWorkspaceBuild, alog.ResourceType)
+		require.Equal(t, build.ID, alog.ResourceID)
+		require.Equal(t, workspace.Name, alog.ResourceTarget)
 		coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
 
 		workspaces, err := client.Workspaces(ctx, codersdk.WorkspaceFilter{
@@ -1,7 +1,9 @@
 package codersdk
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
 	"net/netip"
 	"time"
 
@@ -16,6 +18,9 @@ const (
 	ResourceTypeTemplateVersion ResourceType = "template_version"
 	ResourceTypeUser            ResourceType = "user"
 	ResourceTypeWorkspace       ResourceType = "workspace"
+	ResourceTypeGitSSHKey       ResourceType = "git_ssh_key"
+	ResourceTypeAPIKey          ResourceType = "api_key"
+	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
 )
 
 type AuditAction string
@@ -24,6 +29,9 @@ const (
 	AuditActionCreate AuditAction = "create"
 	AuditActionWrite  AuditAction = "write"
 	AuditActionDelete AuditAction = "delete"
+	AuditActionStart  AuditAction = "start"
+	AuditActionStop   AuditAction = "stop"
+	AuditActionLogin  AuditAction = "login"
 )
 
 type AuditDiff map[string]AuditDiffField
@@ -54,3 +62,39 @@ type AuditLog struct {
 
 	User *User `json:"user"`
 }
+
+type AuditLogsRequest struct {
+	// SearchQuery filters the audit logs. It supports the same key:value
+	// grammar as workspace searches, e.g. "action:create username:admin".
+	SearchQuery string `json:"q,omitempty"`
+	Pagination
+}
+
+type AuditLogResponse struct {
+	AuditLogs []AuditLog `json:"audit_logs"`
+	// Count is the total number of audit logs matching the search query,
+	// ignoring pagination.
+	Count int64 `json:"count"`
+}
+
+// AuditLogs retrieves audit logs matching the search query.
+func (c *Client) AuditLogs(ctx context.Context, req AuditLogsRequest) (AuditLogResponse, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/audit", nil, req.Pagination.asRequestOption(), func(r *http.Request) {
+		q := r.URL.Query()
+		if req.SearchQuery != "" {
+			q.Set("q", req.SearchQuery)
+		}
+		r.URL.RawQuery = q.Encode()
+	})
+	if err != nil {
+		return AuditLogResponse{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return AuditLogResponse{}, readBodyAsError(res)
+	}
+
+	var logRes AuditLogResponse
+	return logRes, json.NewDecoder(res.Body).Decode(&logRes)
+}
@@ -0,0 +1,92 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/agent"
+)
+
+type WorkspaceConnectionType string
+
+const (
+	WorkspaceConnectionTypeSSH             WorkspaceConnectionType = "ssh"
+	WorkspaceConnectionTypeReconnectingPTY WorkspaceConnectionType = "reconnecting_pty"
+	WorkspaceConnectionTypePortForward     WorkspaceConnectionType = "port_forward"
+	WorkspaceConnectionTypeWorkspaceApp    WorkspaceConnectionType = "workspace_app"
+)
+
+// WorkspaceConnection is a connection a user made to a workspace.
+type WorkspaceConnection struct {
+	ID            uuid.UUID `json:"id"`
+	WorkspaceID   uuid.UUID `json:"workspace_id"`
+	WorkspaceName string    `json:"workspace_name"`
+	AgentID       uuid.UUID `json:"agent_id"`
+	AgentName     string    `json:"agent_name"`
+	// UserID is nil when the user that made the connection couldn't be
+	// determined.
+	UserID     *uuid.UUID              `json:"user_id,omitempty"`
+	Username   string                  `json:"username"`
+	Type       WorkspaceConnectionType `json:"type"`
+	RemoteAddr string                  `json:"remote_addr"`
+	// Target is the forwarded address of port forwards, the ID of
+	// reconnecting PTYs, or the name of the app for workspace apps.
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"started_at"`
+	// EndedAt is nil while the connection is open.
+	EndedAt *time.Time `json:"ended_at,omitempty"`
+	RxBytes int64      `json:"rx_bytes"`
+	TxBytes int64      `json:"tx_bytes"`
+}
+
+type WorkspaceConnectionsRequest struct {
+	// SearchQuery filters the connections, e.g. "type:ssh username:admin".
+	SearchQuery string `json:"q,omitempty"`
+	Pagination
+}
+
+type WorkspaceConnectionsResponse struct {
+	Connections []WorkspaceConnection `json:"connections"`
+	// Count is the total number of connections matching the search query,
+	// ignoring pagination.
+	Count int64 `json:"count"`
+}
+
+// WorkspaceConnections retrieves connections matching the search query,
+// newest first.
+func (c *Client) WorkspaceConnections(ctx context.Context, req WorkspaceConnectionsRequest) (WorkspaceConnectionsResponse, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/connections", nil, req.Pagination.asRequestOption(), func(r *http.Request) {
+		q := r.URL.Query()
+		if req.SearchQuery != "" {
+			q.Set("q", req.SearchQuery)
+		}
+		r.URL.RawQuery = q.Encode()
+	})
+	if err != nil {
+		return WorkspaceConnectionsResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceConnectionsResponse{}, readBodyAsError(res)
+	}
+	var connections WorkspaceConnectionsResponse
+	return connections, json.NewDecoder(res.Body).Decode(&connections)
+}
+
+// PostWorkspaceAgentConnection reports a connection made to the agent. It's
+// used by the agent, which authenticates with its own token.
+func (c *Client) PostWorkspaceAgentConnection(ctx context.Context, connection agent.Connection) error {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/connections", connection)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
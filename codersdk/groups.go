@@ -0,0 +1,126 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// CreateGroupRequest provides options when creating a group.
+type CreateGroupRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// Group is a named set of organization members. Groups are used to share
+// templates with many users at once.
+type Group struct {
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	// Members are the users explicitly added to the group. Members of the
+	// "Everyone" group are implied by organization membership and are not
+	// listed.
+	Members []User `json:"members"`
+}
+
+// PatchGroupRequest renames a group and adds or removes members. Users are
+// referenced by ID.
+type PatchGroupRequest struct {
+	AddUsers    []string `json:"add_users"`
+	RemoveUsers []string `json:"remove_users"`
+	Name        string   `json:"name"`
+}
+
+// CreateGroup creates a new group inside an organization.
+func (c *Client) CreateGroup(ctx context.Context, orgID uuid.UUID, req CreateGroupRequest) (Group, error) {
+	res, err := c.Request(ctx, http.MethodPost,
+		fmt.Sprintf("/api/v2/organizations/%s/groups", orgID.String()),
+		req,
+	)
+	if err != nil {
+		return Group{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return Group{}, readBodyAsError(res)
+	}
+	var resp Group
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// GroupsByOrganization lists all groups inside of an organization.
+func (c *Client) GroupsByOrganization(ctx context.Context, orgID uuid.UUID) ([]Group, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/organizations/%s/groups", orgID.String()),
+		nil,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+
+	var groups []Group
+	return groups, json.NewDecoder(res.Body).Decode(&groups)
+}
+
+// Group returns a single group.
+func (c *Client) Group(ctx context.Context, group uuid.UUID) (Group, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/groups/%s", group.String()),
+		nil,
+	)
+	if err != nil {
+		return Group{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Group{}, readBodyAsError(res)
+	}
+	var resp Group
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// PatchGroup renames a group and updates its members.
+func (c *Client) PatchGroup(ctx context.Context, group uuid.UUID, req PatchGroupRequest) (Group, error) {
+	res, err := c.Request(ctx, http.MethodPatch,
+		fmt.Sprintf("/api/v2/groups/%s", group.String()),
+		req,
+	)
+	if err != nil {
+		return Group{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Group{}, readBodyAsError(res)
+	}
+	var resp Group
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// DeleteGroup deletes a group. The "Everyone" group cannot be deleted.
+func (c *Client) DeleteGroup(ctx context.Context, group uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodDelete,
+		fmt.Sprintf("/api/v2/groups/%s", group.String()),
+		nil,
+	)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
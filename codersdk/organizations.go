@@ -15,6 +15,7 @@ type ProvisionerStorageMethod string
 
 const (
 	ProvisionerStorageMethodFile ProvisionerStorageMethod = "file"
+	ProvisionerStorageMethodGit  ProvisionerStorageMethod = "git"
 )
 
 type ProvisionerType string
@@ -37,12 +38,23 @@ type CreateTemplateVersionRequest struct {
 	// TemplateID optionally associates a version with a template.
 	TemplateID uuid.UUID `json:"template_id,omitempty"`
 
-	StorageMethod ProvisionerStorageMethod `json:"storage_method" validate:"oneof=file,required"`
-	StorageSource string                   `json:"storage_source" validate:"required"`
-	Provisioner   ProvisionerType          `json:"provisioner" validate:"oneof=terraform echo,required"`
+	StorageMethod ProvisionerStorageMethod `json:"storage_method" validate:"oneof=file git,required"`
+	// StorageSource is the hash of an uploaded file for the "file" storage
+	// method, or the URL of a repository for the "git" storage method.
+	StorageSource string          `json:"storage_source" validate:"required"`
+	Provisioner   ProvisionerType `json:"provisioner" validate:"oneof=terraform echo,required"`
+	// GitRef is the branch, tag or commit of the repository to import. The
+	// default branch is imported when it's empty.
+	GitRef string `json:"git_ref,omitempty"`
+	// GitSubdirectory is the directory of the repository the template is
+	// in, relative to its root.
+	GitSubdirectory string `json:"git_subdirectory,omitempty"`
 	// ParameterValues allows for additional parameters to be provided
 	// during the dry-run provision stage.
 	ParameterValues []CreateParameterRequest `json:"parameter_values,omitempty"`
+	// ProvisionerTags restricts the import job, and any workspace builds
+	// of this version, to provisioner daemons started with matching tags.
+	ProvisionerTags map[string]string `json:"tags,omitempty"`
 }
 
 // CreateTemplateRequest provides options when creating a template.
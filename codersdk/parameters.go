@@ -73,6 +73,7 @@ type ParameterSchema struct {
 	AllowOverrideDestination bool                       `json:"allow_override_destination"`
 	DefaultRefresh           string                     `json:"default_refresh"`
 	RedisplayValue           bool                       `json:"redisplay_value"`
+	Mutable                  bool                       `json:"mutable"`
 	ValidationError          string                     `json:"validation_error"`
 	ValidationCondition      string                     `json:"validation_condition"`
 	ValidationTypeSystem     string                     `json:"validation_type_system"`
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
@@ -12,8 +13,12 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/yamux"
 	"golang.org/x/xerrors"
 	"nhooyr.io/websocket"
+
+	"github.com/coder/coder/provisionerd/proto"
+	"github.com/coder/coder/provisionersdk"
 )
 
 type LogSource string
@@ -33,12 +38,17 @@ const (
 	LogLevelError LogLevel = "error"
 )
 
+// ProvisionerDaemonPSKHeader is the header external provisioner daemons
+// use to present the pre-shared key configured on the server.
+const ProvisionerDaemonPSKHeader = "Coder-Provisioner-Daemon-PSK"
+
 type ProvisionerDaemon struct {
 	ID           uuid.UUID         `json:"id"`
 	CreatedAt    time.Time         `json:"created_at"`
 	UpdatedAt    sql.NullTime      `json:"updated_at"`
 	Name         string            `json:"name"`
 	Provisioners []ProvisionerType `json:"provisioners"`
+	Tags         map[string]string `json:"tags"`
 }
 
 // ProvisionerJobStatus represents the at-time state of a job.
@@ -71,6 +81,32 @@ type ProvisionerJob struct {
 	Status        ProvisionerJobStatus `json:"status"`
 	WorkerID      *uuid.UUID           `json:"worker_id,omitempty"`
 	StorageSource string               `json:"storage_source"`
+	Tags          map[string]string    `json:"tags"`
+	// QueuePosition is the position of a pending job in the queue of jobs
+	// with the same provisioner and tags waiting for a provisioner daemon,
+	// starting at 1. It's zero once the job has been acquired.
+	QueuePosition int `json:"queue_position,omitempty"`
+	// QueueSize is the number of jobs in the queue of a pending job.
+	QueueSize int `json:"queue_size,omitempty"`
+}
+
+type ProvisionerJobType string
+
+const (
+	ProvisionerJobTypeTemplateVersionImport ProvisionerJobType = "template_version_import"
+	ProvisionerJobTypeWorkspaceBuild        ProvisionerJobType = "workspace_build"
+	ProvisionerJobTypeTemplateVersionDryRun ProvisionerJobType = "template_version_dry_run"
+)
+
+// ActiveProvisionerJob is a pending or running provisioner job, along with
+// the provisioner daemon running it.
+type ActiveProvisionerJob struct {
+	Job            ProvisionerJob     `json:"job"`
+	Type           ProvisionerJobType `json:"type"`
+	OrganizationID uuid.UUID          `json:"organization_id"`
+	InitiatorID    uuid.UUID          `json:"initiator_id"`
+	// Daemon is nil while the job is pending.
+	Daemon *ProvisionerDaemon `json:"daemon,omitempty"`
 }
 
 type ProvisionerJobLog struct {
@@ -150,3 +186,65 @@ func (c *Client) provisionerJobLogsAfter(ctx context.Context, path string, after
 	}()
 	return logs, nil
 }
+
+// ActiveProvisionerJobs lists the pending and running provisioner jobs of the
+// deployment, in the order they were created.
+func (c *Client) ActiveProvisionerJobs(ctx context.Context) ([]ActiveProvisionerJob, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/provisionerjobs", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+
+	var jobs []ActiveProvisionerJob
+	return jobs, json.NewDecoder(res.Body).Decode(&jobs)
+}
+
+// ServeProvisionerDaemon connects to coderd as an external provisioner daemon.
+// The daemon authenticates with the pre-shared key configured on the server,
+// and will only be handed jobs whose tags are a subset of the tags provided.
+func (c *Client) ServeProvisionerDaemon(ctx context.Context, name string, provisioners []ProvisionerType, tags map[string]string, psk string) (proto.DRPCProvisionerDaemonClient, error) {
+	serverURL, err := c.URL.Parse("/api/v2/provisionerdaemons/serve")
+	if err != nil {
+		return nil, xerrors.Errorf("parse url: %w", err)
+	}
+	query := serverURL.Query()
+	if name != "" {
+		query.Set("name", name)
+	}
+	for _, provisioner := range provisioners {
+		query.Add("provisioner", string(provisioner))
+	}
+	for key, value := range tags {
+		query.Add("tag", fmt.Sprintf("%s=%s", key, value))
+	}
+	serverURL.RawQuery = query.Encode()
+	headers := http.Header{}
+	headers.Set(ProvisionerDaemonPSKHeader, psk)
+	conn, res, err := websocket.Dial(ctx, serverURL.String(), &websocket.DialOptions{
+		HTTPClient: c.HTTPClient,
+		HTTPHeader: headers,
+		// Need to disable compression to avoid a data-race.
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, err
+		}
+		return nil, readBodyAsError(res)
+	}
+	// Job payloads and completions can be far larger than the
+	// default websocket read limit.
+	conn.SetReadLimit(provisionersdk.MaxMessageSize)
+	config := yamux.DefaultConfig()
+	config.LogOutput = io.Discard
+	session, err := yamux.Client(websocket.NetConn(ctx, conn, websocket.MessageBinary), config)
+	if err != nil {
+		return nil, xerrors.Errorf("multiplex client: %w", err)
+	}
+	return proto.NewDRPCProvisionerDaemonClient(provisionersdk.Conn(session)), nil
+}
@@ -0,0 +1,88 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/agent"
+)
+
+// SessionRecordingContentType is the content type of session recordings,
+// which are stored in the asciicast v2 format.
+const SessionRecordingContentType = "application/x-asciicast"
+
+type WorkspaceSessionRecordingType string
+
+const (
+	WorkspaceSessionRecordingTypeSSH             WorkspaceSessionRecordingType = "ssh"
+	WorkspaceSessionRecordingTypeReconnectingPTY WorkspaceSessionRecordingType = "reconnecting_pty"
+)
+
+// WorkspaceSessionRecording describes a recording of an interactive session
+// in a workspace. The recording itself is downloaded separately.
+type WorkspaceSessionRecording struct {
+	ID          uuid.UUID                     `json:"id"`
+	CreatedAt   time.Time                     `json:"created_at"`
+	WorkspaceID uuid.UUID                     `json:"workspace_id"`
+	AgentID     uuid.UUID                     `json:"agent_id"`
+	Type        WorkspaceSessionRecordingType `json:"type"`
+	StartedAt   time.Time                     `json:"started_at"`
+	EndedAt     time.Time                     `json:"ended_at"`
+	Size        int64                         `json:"size"`
+}
+
+// WorkspaceSessionRecordings lists the session recordings of a workspace,
+// newest first.
+func (c *Client) WorkspaceSessionRecordings(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceSessionRecording, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/session-recordings", workspaceID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var recordings []WorkspaceSessionRecording
+	return recordings, json.NewDecoder(res.Body).Decode(&recordings)
+}
+
+// WorkspaceSessionRecording downloads a session recording in the asciicast
+// v2 format.
+func (c *Client) WorkspaceSessionRecording(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/session-recordings/%s", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// PostWorkspaceAgentSessionRecording uploads a session recording. It's used
+// by the agent, which authenticates with its own token.
+func (c *Client) PostWorkspaceAgentSessionRecording(ctx context.Context, recording agent.SessionRecording) error {
+	query := url.Values{}
+	query.Set("type", string(recording.Type))
+	query.Set("started_at", recording.StartedAt.Format(time.RFC3339Nano))
+	query.Set("ended_at", recording.EndedAt.Format(time.RFC3339Nano))
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/session-recordings?"+query.Encode(), recording.Data, func(r *http.Request) {
+		r.Header.Set("Content-Type", SessionRecordingContentType)
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return readBodyAsError(res)
+	}
+	return nil
+}
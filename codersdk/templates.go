@@ -26,6 +26,10 @@ type Template struct {
 	Icon                       string          `json:"icon"`
 	MaxTTLMillis               int64           `json:"max_ttl_ms"`
 	MinAutostartIntervalMillis int64           `json:"min_autostart_interval_ms"`
+	InactivityTTLMillis        int64           `json:"inactivity_ttl_ms"`
+	WaitForAgentReady          bool            `json:"wait_for_agent_ready"`
+	SessionRecording           bool            `json:"session_recording"`
+	MaxJobDurationMillis       int64           `json:"max_job_duration_ms"`
 	CreatedByID                uuid.UUID       `json:"created_by_id"`
 	CreatedByName              string          `json:"created_by_name"`
 }
@@ -40,6 +44,51 @@ type UpdateTemplateMeta struct {
 	Icon                       string `json:"icon,omitempty"`
 	MaxTTLMillis               int64  `json:"max_ttl_ms,omitempty"`
 	MinAutostartIntervalMillis int64  `json:"min_autostart_interval_ms,omitempty"`
+	InactivityTTLMillis        int64  `json:"inactivity_ttl_ms,omitempty"`
+	MaxJobDurationMillis       int64  `json:"max_job_duration_ms,omitempty"`
+	// WaitForAgentReady is left unchanged when nil.
+	WaitForAgentReady *bool `json:"wait_for_agent_ready,omitempty"`
+	// SessionRecording is left unchanged when nil.
+	SessionRecording *bool `json:"session_recording,omitempty"`
+}
+
+// TemplateRole is the level of access granted to a user or group on a
+// template.
+type TemplateRole string
+
+const (
+	// TemplateRoleAdmin allows managing the template and its permissions.
+	TemplateRoleAdmin TemplateRole = "admin"
+	// TemplateRoleUse allows viewing the template and creating workspaces
+	// from it.
+	TemplateRoleUse TemplateRole = "use"
+	// TemplateRoleDeleted removes a user or group from the template's access
+	// control list.
+	TemplateRoleDeleted TemplateRole = ""
+)
+
+// TemplateACL is the access control list of a template.
+type TemplateACL struct {
+	Users  []TemplateUser  `json:"users"`
+	Groups []TemplateGroup `json:"group"`
+}
+
+type TemplateGroup struct {
+	Group
+	Role TemplateRole `json:"role"`
+}
+
+type TemplateUser struct {
+	User
+	Role TemplateRole `json:"role"`
+}
+
+// UpdateTemplateACL updates the access control list of a template. Users
+// and groups are referenced by ID. Setting a role to TemplateRoleDeleted
+// removes the entry.
+type UpdateTemplateACL struct {
+	UserPerms  map[string]TemplateRole `json:"user_perms,omitempty"`
+	GroupPerms map[string]TemplateRole `json:"group_perms,omitempty"`
 }
 
 // Template returns a single template.
@@ -84,6 +133,33 @@ func (c *Client) UpdateTemplateMeta(ctx context.Context, templateID uuid.UUID, r
 	return updated, json.NewDecoder(res.Body).Decode(&updated)
 }
 
+// TemplateACL returns the users and groups that have access to a template.
+func (c *Client) TemplateACL(ctx context.Context, templateID uuid.UUID) (TemplateACL, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templates/%s/acl", templateID), nil)
+	if err != nil {
+		return TemplateACL{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return TemplateACL{}, readBodyAsError(res)
+	}
+	var acl TemplateACL
+	return acl, json.NewDecoder(res.Body).Decode(&acl)
+}
+
+// UpdateTemplateACL grants or revokes access to a template.
+func (c *Client) UpdateTemplateACL(ctx context.Context, templateID uuid.UUID, req UpdateTemplateACL) error {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/templates/%s/acl", templateID), req)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
 // UpdateActiveTemplateVersion updates the active template version to the ID provided.
 // The template version must be attached to the template.
 func (c *Client) UpdateActiveTemplateVersion(ctx context.Context, template uuid.UUID, req UpdateActiveTemplateVersion) error {
@@ -172,4 +248,23 @@ type AgentStatsReportResponse struct {
 	RxBytes int64 `json:"rx_bytes"`
 	// TxBytes is the number of received bytes.
 	TxBytes int64 `json:"tx_bytes"`
+	// Resources is the resource usage of the workspace. It's nil when the
+	// agent can't read it, e.g. on operating systems other than Linux.
+	Resources *AgentResourceStats `json:"resources,omitempty"`
+}
+
+// AgentResourceStats is a sample of the resource usage of a workspace.
+type AgentResourceStats struct {
+	// CPUUsedCores is the average number of CPU cores used since the
+	// previous sample.
+	CPUUsedCores float64 `json:"cpu_used_cores"`
+	// CPUTotalCores is the CPU limit of the workspace, or the number of
+	// cores of the host if it isn't limited.
+	CPUTotalCores    float64 `json:"cpu_total_cores"`
+	MemoryUsedBytes  int64   `json:"memory_used_bytes"`
+	MemoryTotalBytes int64   `json:"memory_total_bytes"`
+	// DiskUsedBytes and DiskTotalBytes are the usage of the filesystem of
+	// the home directory.
+	DiskUsedBytes  int64 `json:"disk_used_bytes"`
+	DiskTotalBytes int64 `json:"disk_total_bytes"`
 }
@@ -22,6 +22,55 @@ type TemplateVersion struct {
 	Readme         string         `json:"readme"`
 	CreatedByID    uuid.UUID      `json:"created_by_id"`
 	CreatedByName  string         `json:"created_by_name"`
+	// GitCommitSHA is the commit the version was imported at, for versions
+	// stored in a git repository.
+	GitCommitSHA string `json:"git_commit_sha,omitempty"`
+}
+
+type TemplateVersionDiffStatus string
+
+const (
+	TemplateVersionDiffStatusAdded    TemplateVersionDiffStatus = "added"
+	TemplateVersionDiffStatusRemoved  TemplateVersionDiffStatus = "removed"
+	TemplateVersionDiffStatusModified TemplateVersionDiffStatus = "modified"
+)
+
+// TemplateVersionDiff describes the changes from one template version to
+// another. Unchanged files, parameter schemas and resources are omitted.
+type TemplateVersionDiff struct {
+	From  uuid.UUID                 `json:"from"`
+	To    uuid.UUID                 `json:"to"`
+	Files []TemplateVersionFileDiff `json:"files"`
+	// ParameterSchemas and Resources are compared between the import jobs
+	// of the versions.
+	ParameterSchemas []TemplateVersionParameterSchemaDiff `json:"parameter_schemas"`
+	Resources        []TemplateVersionResourceDiff        `json:"resources"`
+}
+
+type TemplateVersionFileDiff struct {
+	Path   string                    `json:"path"`
+	Status TemplateVersionDiffStatus `json:"status"`
+	// Binary is true when either version of the file isn't text, in which
+	// case Diff is empty.
+	Binary bool `json:"binary"`
+	// Diff is a unified diff of the file.
+	Diff string `json:"diff"`
+}
+
+type TemplateVersionParameterSchemaDiff struct {
+	Name   string                    `json:"name"`
+	Status TemplateVersionDiffStatus `json:"status"`
+	// Fields are the names of the fields that changed for modified schemas.
+	Fields []string `json:"fields,omitempty"`
+}
+
+type TemplateVersionResourceDiff struct {
+	Type   string                    `json:"type"`
+	Name   string                    `json:"name"`
+	Status TemplateVersionDiffStatus `json:"status"`
+	// Fields are the agents and metadata that changed for modified
+	// resources, such as "agents.main.startup_script" or "metadata.cpu".
+	Fields []string `json:"fields,omitempty"`
 }
 
 // TemplateVersion returns a template version by ID.
@@ -93,6 +142,21 @@ func (c *Client) TemplateVersionResources(ctx context.Context, version uuid.UUID
 	return resources, json.NewDecoder(res.Body).Decode(&resources)
 }
 
+// TemplateVersionDiff returns the changes from one template version to
+// another.
+func (c *Client) TemplateVersionDiff(ctx context.Context, from, to uuid.UUID) (TemplateVersionDiff, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/diff/%s", from, to), nil)
+	if err != nil {
+		return TemplateVersionDiff{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return TemplateVersionDiff{}, readBodyAsError(res)
+	}
+	var diff TemplateVersionDiff
+	return diff, json.NewDecoder(res.Body).Decode(&diff)
+}
+
 // TemplateVersionLogsBefore returns logs that occurred before a specific time.
 func (c *Client) TemplateVersionLogsBefore(ctx context.Context, version uuid.UUID, before time.Time) ([]ProvisionerJobLog, error) {
 	return c.provisionerJobLogsBefore(ctx, fmt.Sprintf("/api/v2/templateversions/%s/logs", version), before)
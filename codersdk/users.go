@@ -28,6 +28,7 @@ const (
 	LoginTypePassword LoginType = "password"
 	LoginTypeGithub   LoginType = "github"
 	LoginTypeOIDC     LoginType = "oidc"
+	LoginTypeToken    LoginType = "token"
 )
 
 type UsersRequest struct {
@@ -53,14 +54,37 @@ type User struct {
 }
 
 type APIKey struct {
-	ID              string    `json:"id" validate:"required"`
-	UserID          uuid.UUID `json:"user_id" validate:"required"`
-	LastUsed        time.Time `json:"last_used" validate:"required"`
-	ExpiresAt       time.Time `json:"expires_at" validate:"required"`
-	CreatedAt       time.Time `json:"created_at" validate:"required"`
-	UpdatedAt       time.Time `json:"updated_at" validate:"required"`
-	LoginType       LoginType `json:"login_type" validate:"required"`
-	LifetimeSeconds int64     `json:"lifetime_seconds" validate:"required"`
+	ID              string      `json:"id" validate:"required"`
+	UserID          uuid.UUID   `json:"user_id" validate:"required"`
+	LastUsed        time.Time   `json:"last_used" validate:"required"`
+	ExpiresAt       time.Time   `json:"expires_at" validate:"required"`
+	CreatedAt       time.Time   `json:"created_at" validate:"required"`
+	UpdatedAt       time.Time   `json:"updated_at" validate:"required"`
+	LoginType       LoginType   `json:"login_type" validate:"required"`
+	LifetimeSeconds int64       `json:"lifetime_seconds" validate:"required"`
+	Scope           APIKeyScope `json:"scope" validate:"required"`
+	TokenName       string      `json:"token_name"`
+}
+
+// APIKeyScope restricts the routes an API key can be used for.
+type APIKeyScope string
+
+const (
+	// APIKeyScopeAll allows the API key to be used for every route.
+	APIKeyScopeAll APIKeyScope = "all"
+	// APIKeyScopeApplicationConnect only allows the API key to be used to
+	// connect to workspace applications.
+	APIKeyScopeApplicationConnect APIKeyScope = "application_connect"
+)
+
+// CreateTokenRequest creates a long-lived API key for a user.
+type CreateTokenRequest struct {
+	// TokenName is optional, but must be unique for the user when set.
+	TokenName string `json:"token_name" validate:"omitempty,token_name"`
+	// Lifetime defaults to 30 days when unset.
+	Lifetime time.Duration `json:"lifetime"`
+	// Scope defaults to APIKeyScopeAll when unset.
+	Scope APIKeyScope `json:"scope" validate:"omitempty,oneof=all application_connect"`
 }
 
 type CreateFirstUserRequest struct {
@@ -334,6 +358,48 @@ func (c *Client) CreateAPIKey(ctx context.Context, user string) (*GenerateAPIKey
 	return apiKey, json.NewDecoder(res.Body).Decode(apiKey)
 }
 
+// CreateToken generates a long-lived API key for the user ID provided.
+func (c *Client) CreateToken(ctx context.Context, user string, req CreateTokenRequest) (*GenerateAPIKeyResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/users/%s/keys/tokens", user), req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return nil, readBodyAsError(res)
+	}
+	apiKey := &GenerateAPIKeyResponse{}
+	return apiKey, json.NewDecoder(res.Body).Decode(apiKey)
+}
+
+// Tokens returns the tokens of the user ID provided. Session keys created
+// by logging in are not included.
+func (c *Client) Tokens(ctx context.Context, user string) ([]APIKey, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/keys/tokens", user), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var apiKeys []APIKey
+	return apiKeys, json.NewDecoder(res.Body).Decode(&apiKeys)
+}
+
+// DeleteAPIKey revokes an API key of the user ID provided.
+func (c *Client) DeleteAPIKey(ctx context.Context, user string, id string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/users/%s/keys/%s", user, id), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
 func (c *Client) GetAPIKey(ctx context.Context, user string, id string) (*APIKey, error) {
 	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/keys/%s", user, id), nil)
 	if err != nil {
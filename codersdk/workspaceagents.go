@@ -64,6 +64,45 @@ type PostWorkspaceAgentVersionRequest struct {
 	Version string `json:"version"`
 }
 
+type PostWorkspaceAgentLifecycleRequest struct {
+	State WorkspaceAgentLifecycle `json:"state"`
+}
+
+// WorkspaceAgentStartupLog is a line of output from an agent's startup script.
+type WorkspaceAgentStartupLog struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Output    string    `json:"output"`
+}
+
+type PatchWorkspaceAgentStartupLogs struct {
+	Logs []agent.StartupLog `json:"logs"`
+}
+
+// WorkspaceAgentListeningPort is a TCP port that a process in the workspace
+// is listening on.
+type WorkspaceAgentListeningPort struct {
+	ProcessName string `json:"process_name"`
+	Network     string `json:"network"`
+	Port        uint16 `json:"port"`
+}
+
+type WorkspaceAgentListeningPortsResponse struct {
+	Ports []WorkspaceAgentListeningPort `json:"ports"`
+}
+
+// WorkspaceAgentResourceStats is a sample of the resource usage of the
+// workspace of an agent.
+type WorkspaceAgentResourceStats struct {
+	CreatedAt        time.Time `json:"created_at"`
+	CPUUsedCores     float64   `json:"cpu_used_cores"`
+	CPUTotalCores    float64   `json:"cpu_total_cores"`
+	MemoryUsedBytes  int64     `json:"memory_used_bytes"`
+	MemoryTotalBytes int64     `json:"memory_total_bytes"`
+	DiskUsedBytes    int64     `json:"disk_used_bytes"`
+	DiskTotalBytes   int64     `json:"disk_total_bytes"`
+}
+
 // AuthWorkspaceGoogleInstanceIdentity uses the Google Compute Engine Metadata API to
 // fetch a signed JWT, and exchange it for a session token for a workspace agent.
 //
@@ -478,6 +517,131 @@ func (c *Client) PostWorkspaceAgentVersion(ctx context.Context, version string)
 	return nil
 }
 
+// PostWorkspaceAgentLifecycle reports the lifecycle state of the agent.
+func (c *Client) PostWorkspaceAgentLifecycle(ctx context.Context, state agent.LifecycleState) error {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/report-lifecycle", PostWorkspaceAgentLifecycleRequest{
+		State: WorkspaceAgentLifecycle(state),
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// PatchWorkspaceAgentStartupLogs sends startup script output to coderd.
+// It's used by the agent, which authenticates with its own token.
+func (c *Client) PatchWorkspaceAgentStartupLogs(ctx context.Context, logs []agent.StartupLog) error {
+	res, err := c.Request(ctx, http.MethodPatch, "/api/v2/workspaceagents/me/startup-logs", PatchWorkspaceAgentStartupLogs{
+		Logs: logs,
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WorkspaceAgentStartupLogs returns the startup script output of an agent
+// with an ID greater than after. Pass zero to fetch all output.
+func (c *Client) WorkspaceAgentStartupLogs(ctx context.Context, agentID uuid.UUID, after int64) ([]WorkspaceAgentStartupLog, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/startup-logs?after=%d", agentID, after), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var logs []WorkspaceAgentStartupLog
+	return logs, json.NewDecoder(res.Body).Decode(&logs)
+}
+
+// WorkspaceAgentListeningPorts returns the TCP ports that processes in the
+// workspace of an agent are listening on.
+func (c *Client) WorkspaceAgentListeningPorts(ctx context.Context, agentID uuid.UUID) (WorkspaceAgentListeningPortsResponse, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/listening-ports", agentID), nil)
+	if err != nil {
+		return WorkspaceAgentListeningPortsResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentListeningPortsResponse{}, readBodyAsError(res)
+	}
+	var ports WorkspaceAgentListeningPortsResponse
+	return ports, json.NewDecoder(res.Body).Decode(&ports)
+}
+
+// WorkspaceAgentResourceStats returns the recent resource usage samples of
+// an agent, oldest first.
+func (c *Client) WorkspaceAgentResourceStats(ctx context.Context, agentID uuid.UUID) ([]WorkspaceAgentResourceStats, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/resource-stats", agentID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var stats []WorkspaceAgentResourceStats
+	return stats, json.NewDecoder(res.Body).Decode(&stats)
+}
+
+// WorkspaceAgentStartupLogsAfter streams the startup script output of an
+// agent with an ID greater than after. The channel is closed when the
+// context is canceled or the connection is lost.
+func (c *Client) WorkspaceAgentStartupLogsAfter(ctx context.Context, agentID uuid.UUID, after int64) (<-chan WorkspaceAgentStartupLog, error) {
+	followURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/workspaceagents/%s/startup-logs?follow&after=%d", agentID, after))
+	if err != nil {
+		return nil, err
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, xerrors.Errorf("create cookie jar: %w", err)
+	}
+	jar.SetCookies(followURL, []*http.Cookie{{
+		Name:  SessionTokenKey,
+		Value: c.SessionToken,
+	}})
+	httpClient := &http.Client{
+		Jar: jar,
+	}
+	conn, res, err := websocket.Dial(ctx, followURL.String(), &websocket.DialOptions{
+		HTTPClient:      httpClient,
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, err
+		}
+		return nil, readBodyAsError(res)
+	}
+	logs := make(chan WorkspaceAgentStartupLog)
+	decoder := json.NewDecoder(websocket.NetConn(ctx, conn, websocket.MessageText))
+	go func() {
+		defer close(logs)
+		var log WorkspaceAgentStartupLog
+		for {
+			err = decoder.Decode(&log)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case logs <- log:
+			}
+		}
+	}()
+	return logs, nil
+}
+
 // WorkspaceAgentReconnectingPTY spawns a PTY that reconnects using the token provided.
 // It communicates using `agent.ReconnectingPTYRequest` marshaled as JSON.
 // Responses are PTY output that can be rendered.
@@ -594,6 +758,16 @@ func (c *Client) AgentReportStats(
 						RxBytes:  s.RxBytes,
 						TxBytes:  s.TxBytes,
 					}
+					if s.Resources != nil {
+						resp.Resources = &AgentResourceStats{
+							CPUUsedCores:     s.Resources.CPUUsedCores,
+							CPUTotalCores:    s.Resources.CPUTotalCores,
+							MemoryUsedBytes:  s.Resources.MemoryUsedBytes,
+							MemoryTotalBytes: s.Resources.MemoryTotalBytes,
+							DiskUsedBytes:    s.Resources.DiskUsedBytes,
+							DiskTotalBytes:   s.Resources.DiskTotalBytes,
+						}
+					}
 
 					err = wsjson.Write(ctx, conn, resp)
 					if err != nil {
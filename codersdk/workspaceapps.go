@@ -1,9 +1,26 @@
 package codersdk
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+
 	"github.com/google/uuid"
 )
 
+// AppSessionTokenQueryKey is the query parameter used to pass a session
+// token to a subdomain application after authenticating with the access
+// URL. The token is moved into a cookie on the application's hostname.
+const AppSessionTokenQueryKey = "coder_application_connect_api_key"
+
+type WorkspaceAppSharingLevel string
+
+const (
+	WorkspaceAppSharingLevelOwner         WorkspaceAppSharingLevel = "owner"
+	WorkspaceAppSharingLevelAuthenticated WorkspaceAppSharingLevel = "authenticated"
+	WorkspaceAppSharingLevelPublic        WorkspaceAppSharingLevel = "public"
+)
+
 type WorkspaceApp struct {
 	ID uuid.UUID `json:"id"`
 	// Name is a unique identifier attached to an agent.
@@ -12,4 +29,32 @@ type WorkspaceApp struct {
 	// Icon is a relative path or external URL that specifies
 	// an icon to be displayed in the dashboard.
 	Icon string `json:"icon,omitempty"`
+	// SharingLevel determines who can access the app in addition
+	// to the workspace owner.
+	SharingLevel WorkspaceAppSharingLevel `json:"sharing_level"`
+}
+
+// AppHostResponse is the response for the app host endpoint.
+type AppHostResponse struct {
+	// Host is the wildcard hostname used to serve applications on
+	// subdomains, e.g. "*.coder.example.com". It is empty if subdomain
+	// applications are disabled.
+	Host string `json:"host"`
+}
+
+// AppHost returns the wildcard hostname used to serve workspace
+// applications on subdomains.
+func (c *Client) AppHost(ctx context.Context) (AppHostResponse, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/applications/host", nil)
+	if err != nil {
+		return AppHostResponse{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return AppHostResponse{}, readBodyAsError(res)
+	}
+
+	var host AppHostResponse
+	return host, json.NewDecoder(res.Body).Decode(&host)
 }
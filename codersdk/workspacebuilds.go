@@ -54,6 +54,13 @@ type WorkspaceBuild struct {
 	Reason             BuildReason         `db:"reason" json:"reason"`
 }
 
+// WorkspaceBuildParameter is the value of a parameter a workspace build was
+// provisioned with.
+type WorkspaceBuildParameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 // WorkspaceBuild returns a single workspace build for a workspace.
 // If history is "", the latest version is returned.
 func (c *Client) WorkspaceBuild(ctx context.Context, id uuid.UUID) (WorkspaceBuild, error) {
@@ -96,6 +103,22 @@ func (c *Client) WorkspaceResourcesByBuild(ctx context.Context, build uuid.UUID)
 	return resources, json.NewDecoder(res.Body).Decode(&resources)
 }
 
+// WorkspaceBuildParameters returns the parameter values a workspace build was
+// provisioned with. Values of parameters that aren't redisplayed aren't
+// recorded.
+func (c *Client) WorkspaceBuildParameters(ctx context.Context, build uuid.UUID) ([]WorkspaceBuildParameter, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspacebuilds/%s/parameters", build), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var params []WorkspaceBuildParameter
+	return params, json.NewDecoder(res.Body).Decode(&params)
+}
+
 // WorkspaceBuildLogsBefore returns logs that occurred before a specific time.
 func (c *Client) WorkspaceBuildLogsBefore(ctx context.Context, build uuid.UUID, before time.Time) ([]ProvisionerJobLog, error) {
 	return c.provisionerJobLogsBefore(ctx, fmt.Sprintf("/api/v2/workspacebuilds/%s/logs", build), before)
@@ -18,6 +18,20 @@ const (
 	WorkspaceAgentDisconnected WorkspaceAgentStatus = "disconnected"
 )
 
+// WorkspaceAgentLifecycle is the stage an agent is in, as reported by the
+// agent itself.
+type WorkspaceAgentLifecycle string
+
+const (
+	WorkspaceAgentLifecycleCreated      WorkspaceAgentLifecycle = "created"
+	WorkspaceAgentLifecycleStarting     WorkspaceAgentLifecycle = "starting"
+	WorkspaceAgentLifecycleStartTimeout WorkspaceAgentLifecycle = "start_timeout"
+	WorkspaceAgentLifecycleStartError   WorkspaceAgentLifecycle = "start_error"
+	WorkspaceAgentLifecycleReady        WorkspaceAgentLifecycle = "ready"
+	WorkspaceAgentLifecycleShuttingDown WorkspaceAgentLifecycle = "shutting_down"
+	WorkspaceAgentLifecycleOff          WorkspaceAgentLifecycle = "off"
+)
+
 type WorkspaceResource struct {
 	ID         uuid.UUID                   `json:"id"`
 	CreatedAt  time.Time                   `json:"created_at"`
@@ -41,23 +55,25 @@ type DERPRegion struct {
 }
 
 type WorkspaceAgent struct {
-	ID                   uuid.UUID            `json:"id"`
-	CreatedAt            time.Time            `json:"created_at"`
-	UpdatedAt            time.Time            `json:"updated_at"`
-	FirstConnectedAt     *time.Time           `json:"first_connected_at,omitempty"`
-	LastConnectedAt      *time.Time           `json:"last_connected_at,omitempty"`
-	DisconnectedAt       *time.Time           `json:"disconnected_at,omitempty"`
-	Status               WorkspaceAgentStatus `json:"status"`
-	Name                 string               `json:"name"`
-	ResourceID           uuid.UUID            `json:"resource_id"`
-	InstanceID           string               `json:"instance_id,omitempty"`
-	Architecture         string               `json:"architecture"`
-	EnvironmentVariables map[string]string    `json:"environment_variables"`
-	OperatingSystem      string               `json:"operating_system"`
-	StartupScript        string               `json:"startup_script,omitempty"`
-	Directory            string               `json:"directory,omitempty"`
-	Version              string               `json:"version"`
-	Apps                 []WorkspaceApp       `json:"apps"`
+	ID                   uuid.UUID               `json:"id"`
+	CreatedAt            time.Time               `json:"created_at"`
+	UpdatedAt            time.Time               `json:"updated_at"`
+	FirstConnectedAt     *time.Time              `json:"first_connected_at,omitempty"`
+	LastConnectedAt      *time.Time              `json:"last_connected_at,omitempty"`
+	DisconnectedAt       *time.Time              `json:"disconnected_at,omitempty"`
+	Status               WorkspaceAgentStatus    `json:"status"`
+	LifecycleState       WorkspaceAgentLifecycle `json:"lifecycle_state"`
+	Name                 string                  `json:"name"`
+	ResourceID           uuid.UUID               `json:"resource_id"`
+	InstanceID           string                  `json:"instance_id,omitempty"`
+	Architecture         string                  `json:"architecture"`
+	EnvironmentVariables map[string]string       `json:"environment_variables"`
+	OperatingSystem      string                  `json:"operating_system"`
+	StartupScript        string                  `json:"startup_script,omitempty"`
+	ShutdownScript       string                  `json:"shutdown_script,omitempty"`
+	Directory            string                  `json:"directory,omitempty"`
+	Version              string                  `json:"version"`
+	Apps                 []WorkspaceApp          `json:"apps"`
 	// DERPLatency is mapped by region name (e.g. "New York City", "Seattle").
 	DERPLatency map[string]DERPRegion `json:"latency,omitempty"`
 }
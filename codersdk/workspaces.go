@@ -31,6 +31,10 @@ type Workspace struct {
 	AutostartSchedule *string        `json:"autostart_schedule,omitempty"`
 	TTLMillis         *int64         `json:"ttl_ms,omitempty"`
 	LastUsedAt        time.Time      `json:"last_used_at"`
+
+	// TemplateWaitForAgentReady is copied from the template so clients that
+	// can't read the template still know whether to wait for the agent.
+	TemplateWaitForAgentReady bool `json:"template_wait_for_agent_ready"`
 }
 
 // CreateWorkspaceBuildRequest provides options to update the latest workspace build.
@@ -2,6 +2,7 @@ package audit
 
 import (
 	"context"
+	"io"
 
 	"golang.org/x/xerrors"
 
@@ -56,3 +57,20 @@ func (a *auditor) Export(ctx context.Context, alog database.AuditLog) error {
 
 	return nil
 }
+
+// Close closes the backends that hold resources, such as connections or
+// background deliveries.
+func (a *auditor) Close() error {
+	var firstErr error
+	for _, backend := range a.backends {
+		closer, ok := backend.(io.Closer)
+		if !ok {
+			continue
+		}
+		err := closer.Close()
+		if err != nil && firstErr == nil {
+			firstErr = xerrors.Errorf("close backend: %w", err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,202 @@
+package backends
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit"
+)
+
+const (
+	// syslogFacilityLogAudit is the "log audit" facility from RFC 5424.
+	syslogFacilityLogAudit = 13
+	syslogSeverityWarning  = 4
+	syslogSeverityInfo     = 6
+	// syslogEnterpriseID is used in the structured data ID. It is the private
+	// enterprise number reserved for documentation in RFC 5612.
+	syslogEnterpriseID = 32473
+
+	syslogWriteTimeout = 5 * time.Second
+)
+
+type SyslogOptions struct {
+	// Address is the host:port of the syslog receiver.
+	Address string
+	// TLSConfig enables TLS for the connection when set.
+	TLSConfig *tls.Config
+	// Hostname is sent as the HOSTNAME of each message. It defaults to the
+	// hostname of the machine.
+	Hostname string
+}
+
+type syslogBackend struct {
+	opts SyslogOptions
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslog creates a backend that sends audit logs to a syslog receiver over
+// TCP, optionally with TLS. Messages are formatted according to RFC 5424 and
+// framed with octet counting as described in RFC 6587.
+func NewSyslog(opts SyslogOptions) (audit.Backend, error) {
+	if opts.Address == "" {
+		return nil, xerrors.New("syslog address must be set")
+	}
+	if _, _, err := net.SplitHostPort(opts.Address); err != nil {
+		return nil, xerrors.Errorf("parse syslog address: %w", err)
+	}
+	if opts.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "-"
+		}
+		opts.Hostname = hostname
+	}
+	return &syslogBackend{opts: opts}, nil
+}
+
+func (*syslogBackend) Decision() audit.FilterDecision {
+	return audit.FilterDecisionExport
+}
+
+func (b *syslogBackend) Export(ctx context.Context, alog database.AuditLog) error {
+	msg, err := formatSyslogMessage(b.opts.Hostname, alog)
+	if err != nil {
+		return xerrors.Errorf("format syslog message: %w", err)
+	}
+	frame := []byte(strconv.Itoa(len(msg)) + " " + msg)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The connection may have been closed by the receiver since the last
+	// export, so reconnect once before giving up.
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		if b.conn == nil {
+			b.conn, err = b.dial(ctx)
+			if err != nil {
+				return xerrors.Errorf("dial syslog: %w", err)
+			}
+		}
+		_ = b.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+		_, err = b.conn.Write(frame)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		_ = b.conn.Close()
+		b.conn = nil
+	}
+	return xerrors.Errorf("write syslog message: %w", lastErr)
+}
+
+func (b *syslogBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn == nil {
+		return nil
+	}
+	err := b.conn.Close()
+	b.conn = nil
+	return err
+}
+
+func (b *syslogBackend) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogWriteTimeout}
+	if b.opts.TLSConfig != nil {
+		tlsDialer := &tls.Dialer{
+			NetDialer: dialer,
+			Config:    b.opts.TLSConfig,
+		}
+		return tlsDialer.DialContext(ctx, "tcp", b.opts.Address)
+	}
+	return dialer.DialContext(ctx, "tcp", b.opts.Address)
+}
+
+// formatSyslogMessage formats an audit log as an RFC 5424 message. The audit
+// log is included as structured data and as the JSON encoded message.
+func formatSyslogMessage(hostname string, alog database.AuditLog) (string, error) {
+	severity := syslogSeverityInfo
+	if alog.StatusCode >= 400 {
+		severity = syslogSeverityWarning
+	}
+	priority := syslogFacilityLogAudit*8 + severity
+
+	body, err := json.Marshal(alog)
+	if err != nil {
+		return "", xerrors.Errorf("marshal audit log: %w", err)
+	}
+
+	ip := "-"
+	if alog.Ip.Valid {
+		ip = alog.Ip.IPNet.IP.String()
+	}
+	params := []struct {
+		name  string
+		value string
+	}{
+		{"id", alog.ID.String()},
+		{"request_id", alog.RequestID.String()},
+		{"user_id", alog.UserID.String()},
+		{"organization_id", alog.OrganizationID.String()},
+		{"ip", ip},
+		{"action", string(alog.Action)},
+		{"resource_type", string(alog.ResourceType)},
+		{"resource_id", alog.ResourceID.String()},
+		{"resource_target", alog.ResourceTarget},
+		{"status_code", strconv.Itoa(int(alog.StatusCode))},
+	}
+	var sd strings.Builder
+	_, _ = fmt.Fprintf(&sd, "[audit@%d", syslogEnterpriseID)
+	for _, param := range params {
+		_, _ = fmt.Fprintf(&sd, ` %s="%s"`, param.name, escapeSyslogParamValue(param.value))
+	}
+	sd.WriteString("]")
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	return fmt.Sprintf("<%d>1 %s %s coder - audit %s %s",
+		priority,
+		alog.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(hostname),
+		sd.String(),
+		body,
+	), nil
+}
+
+// escapeSyslogParamValue escapes the characters RFC 5424 requires to be
+// escaped in structured data parameter values.
+func escapeSyslogParamValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
+
+// syslogHeaderField ensures a header field only contains printable US-ASCII
+// characters and is not empty.
+func syslogHeaderField(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, v)
+	if v == "" {
+		return "-"
+	}
+	// HOSTNAME is limited to 255 characters.
+	if len(v) > 255 {
+		v = v[:255]
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package backends_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/enterprise/audit/audittest"
+	"github.com/coder/coder/enterprise/audit/backends"
+	"github.com/coder/coder/testutil"
+)
+
+func TestSyslogBackend(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		messages := make(chan string, 2)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				// Messages are framed with octet counting.
+				length, err := reader.ReadString(' ')
+				if err != nil {
+					return
+				}
+				n, err := strconv.Atoi(strings.TrimSpace(length))
+				if err != nil {
+					return
+				}
+				msg := make([]byte, n)
+				_, err = io.ReadFull(reader, msg)
+				if err != nil {
+					return
+				}
+				messages <- string(msg)
+			}
+		}()
+
+		backend, err := backends.NewSyslog(backends.SyslogOptions{
+			Address:  listener.Addr().String(),
+			Hostname: "coder-test",
+		})
+		require.NoError(t, err)
+		defer backend.(io.Closer).Close()
+
+		alog := audittest.RandomLog()
+		require.NoError(t, backend.Export(ctx, alog))
+		alog.StatusCode = 500
+		alog.ResourceTarget = `quote"d]`
+		require.NoError(t, backend.Export(ctx, alog))
+
+		var msg string
+		select {
+		case msg = <-messages:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for message")
+		}
+		// Facility 13 (log audit) and severity 6 (info).
+		require.True(t, strings.HasPrefix(msg, "<110>1 "), msg)
+		require.Contains(t, msg, " coder-test coder - audit [audit@32473 ")
+		require.Contains(t, msg, `id="`+alog.ID.String()+`"`)
+		require.Contains(t, msg, `action="delete"`)
+
+		select {
+		case msg = <-messages:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for message")
+		}
+		// Failed requests are logged with severity 4 (warning).
+		require.True(t, strings.HasPrefix(msg, "<108>1 "), msg)
+		require.Contains(t, msg, `resource_target="quote\"d\]"`)
+	})
+
+	t.Run("InvalidAddress", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := backends.NewSyslog(backends.SyslogOptions{
+			Address: "localhost",
+		})
+		require.Error(t, err)
+	})
+}
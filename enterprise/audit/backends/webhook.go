@@ -0,0 +1,335 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit"
+)
+
+const (
+	// WebhookSignatureHeader contains the hex encoded HMAC-SHA256 of the
+	// request body, prefixed with "sha256=".
+	WebhookSignatureHeader = "X-Coder-Signature"
+
+	defaultWebhookQueueSize     = 10000
+	defaultWebhookBatchSize     = 100
+	defaultWebhookFlushInterval = 5 * time.Second
+	defaultWebhookRetryInterval = time.Second
+	maxWebhookRetryInterval     = time.Minute
+)
+
+type WebhookOptions struct {
+	URL *url.URL
+	// Secret signs each request body with HMAC-SHA256. Requests are not
+	// signed when it is empty.
+	Secret string
+	// QueueDir persists undelivered audit logs so they survive restarts.
+	// Undelivered audit logs are only kept in memory when it is empty.
+	QueueDir string
+	// QueueSize is the maximum number of undelivered audit logs. Exports
+	// fail when the queue is full.
+	QueueSize int
+	// BatchSize is the maximum number of audit logs sent in a request.
+	BatchSize int
+	// FlushInterval is how often queued audit logs are sent.
+	FlushInterval time.Duration
+	// RetryInterval is the initial delay after a failed delivery. It doubles
+	// on every consecutive failure.
+	RetryInterval time.Duration
+	HTTPClient    *http.Client
+	Logger        slog.Logger
+}
+
+type webhookBackend struct {
+	opts WebhookOptions
+
+	mu    sync.Mutex
+	queue []webhookQueueItem
+
+	notify    chan struct{}
+	closed    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type webhookQueueItem struct {
+	alog database.AuditLog
+	// file is the path of the persisted audit log. It is empty when the
+	// queue is in memory.
+	file string
+}
+
+// NewWebhook creates a backend that delivers audit logs to a URL in batches.
+// Delivery happens in the background, so Close must be called to stop it.
+func NewWebhook(opts WebhookOptions) (audit.Backend, error) {
+	if opts.URL == nil {
+		return nil, xerrors.New("webhook url must be set")
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultWebhookQueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultWebhookBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultWebhookFlushInterval
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = defaultWebhookRetryInterval
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+
+	b := &webhookBackend{
+		opts:   opts,
+		notify: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if opts.QueueDir != "" {
+		err := os.MkdirAll(opts.QueueDir, 0o700)
+		if err != nil {
+			return nil, xerrors.Errorf("create queue dir: %w", err)
+		}
+		b.queue, err = loadWebhookQueue(opts.QueueDir)
+		if err != nil {
+			return nil, xerrors.Errorf("load queue: %w", err)
+		}
+	}
+
+	go b.run()
+	return b, nil
+}
+
+func (*webhookBackend) Decision() audit.FilterDecision {
+	return audit.FilterDecisionExport
+}
+
+// Export queues the audit log for delivery. It only returns an error if the
+// audit log could not be queued.
+func (b *webhookBackend) Export(_ context.Context, alog database.AuditLog) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.queue) >= b.opts.QueueSize {
+		return xerrors.Errorf("webhook queue is full (%d audit logs)", b.opts.QueueSize)
+	}
+
+	item := webhookQueueItem{alog: alog}
+	if b.opts.QueueDir != "" {
+		data, err := json.Marshal(alog)
+		if err != nil {
+			return xerrors.Errorf("marshal audit log: %w", err)
+		}
+		// Files are named by time so the queue can be restored in order.
+		item.file = filepath.Join(b.opts.QueueDir, fmt.Sprintf("%020d-%s.json", alog.Time.UnixNano(), alog.ID))
+		// Write to a temporary file first so a crash can't leave a partial
+		// audit log in the queue.
+		err = os.WriteFile(item.file+".tmp", data, 0o600)
+		if err != nil {
+			return xerrors.Errorf("write audit log: %w", err)
+		}
+		err = os.Rename(item.file+".tmp", item.file)
+		if err != nil {
+			return xerrors.Errorf("rename audit log: %w", err)
+		}
+	}
+	b.queue = append(b.queue, item)
+
+	if len(b.queue) >= b.opts.BatchSize {
+		select {
+		case b.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close stops delivering audit logs after a final attempt to deliver the
+// queue. Undelivered audit logs remain in the queue directory.
+func (b *webhookBackend) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+	})
+	<-b.done
+	return nil
+}
+
+func (b *webhookBackend) run() {
+	defer close(b.done)
+
+	failures := 0
+	timer := time.NewTimer(b.opts.FlushInterval)
+	defer timer.Stop()
+	for {
+		// Don't deliver early while backing off from a failure.
+		notify := b.notify
+		if failures > 0 {
+			notify = nil
+		}
+		select {
+		case <-b.closed:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := b.flush(ctx)
+			cancel()
+			if err != nil {
+				b.opts.Logger.Warn(context.Background(), "deliver audit logs to webhook on close", slog.Error(err))
+			}
+			return
+		case <-notify:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-timer.C:
+		}
+
+		err := b.flush(context.Background())
+		wait := b.opts.FlushInterval
+		if err != nil {
+			failures++
+			wait = b.opts.RetryInterval << (failures - 1)
+			if wait <= 0 || wait > maxWebhookRetryInterval {
+				wait = maxWebhookRetryInterval
+			}
+			b.opts.Logger.Warn(context.Background(), "deliver audit logs to webhook",
+				slog.F("failures", failures),
+				slog.F("retry_in", wait),
+				slog.Error(err),
+			)
+		} else {
+			failures = 0
+		}
+		timer.Reset(wait)
+	}
+}
+
+// flush delivers queued audit logs in batches until the queue is empty or a
+// delivery fails.
+func (b *webhookBackend) flush(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		n := len(b.queue)
+		if n > b.opts.BatchSize {
+			n = b.opts.BatchSize
+		}
+		batch := make([]webhookQueueItem, n)
+		copy(batch, b.queue)
+		b.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := b.send(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		// Items are only appended, so the batch is still at the head of the
+		// queue.
+		b.mu.Lock()
+		b.queue = b.queue[len(batch):]
+		b.mu.Unlock()
+		for _, item := range batch {
+			if item.file == "" {
+				continue
+			}
+			err = os.Remove(item.file)
+			if err != nil && !os.IsNotExist(err) {
+				b.opts.Logger.Warn(ctx, "remove delivered audit log", slog.F("file", item.file), slog.Error(err))
+			}
+		}
+	}
+}
+
+func (b *webhookBackend) send(ctx context.Context, batch []webhookQueueItem) error {
+	alogs := make([]database.AuditLog, 0, len(batch))
+	for _, item := range batch {
+		alogs = append(alogs, item.alog)
+	}
+	body, err := json.Marshal(alogs)
+	if err != nil {
+		return xerrors.Errorf("marshal audit logs: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.opts.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.opts.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+WebhookSignature(b.opts.Secret, body))
+	}
+
+	res, err := b.opts.HTTPClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("send request: %w", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<20))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return xerrors.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+// WebhookSignature returns the hex encoded HMAC-SHA256 of the body. Receivers
+// should compare it to the WebhookSignatureHeader with hmac.Equal.
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func loadWebhookQueue(dir string) ([]webhookQueueItem, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("read dir: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	queue := make([]webhookQueueItem, 0, len(names))
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, xerrors.Errorf("read %q: %w", file, err)
+		}
+		var alog database.AuditLog
+		err = json.Unmarshal(data, &alog)
+		if err != nil {
+			return nil, xerrors.Errorf("unmarshal %q: %w", file, err)
+		}
+		queue = append(queue, webhookQueueItem{alog: alog, file: file})
+	}
+	return queue, nil
+}
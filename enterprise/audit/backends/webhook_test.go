@@ -0,0 +1,223 @@
+package backends_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit/audittest"
+	"github.com/coder/coder/enterprise/audit/backends"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWebhookBackend(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		const secret = "hunter2"
+		receiver := newWebhookReceiver(t, func(rw http.ResponseWriter, r *http.Request, body []byte) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Equal(t, "sha256="+backends.WebhookSignature(secret, body), r.Header.Get(backends.WebhookSignatureHeader))
+			rw.WriteHeader(http.StatusNoContent)
+		})
+
+		backend, err := backends.NewWebhook(backends.WebhookOptions{
+			URL:           receiver.url,
+			Secret:        secret,
+			BatchSize:     2,
+			FlushInterval: testutil.IntervalFast,
+		})
+		require.NoError(t, err)
+		defer backend.(io.Closer).Close()
+
+		sent := []database.AuditLog{audittest.RandomLog(), audittest.RandomLog(), audittest.RandomLog()}
+		for _, alog := range sent {
+			require.NoError(t, backend.Export(ctx, alog))
+		}
+
+		require.Eventually(t, func() bool {
+			return len(receiver.received()) == len(sent)
+		}, testutil.WaitShort, testutil.IntervalFast)
+		for i, alog := range receiver.received() {
+			require.Equal(t, sent[i].ID, alog.ID)
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		var attempts int64
+		receiver := newWebhookReceiver(t, func(rw http.ResponseWriter, r *http.Request, body []byte) {
+			// Fail the first two deliveries.
+			if atomic.AddInt64(&attempts, 1) <= 2 {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			rw.WriteHeader(http.StatusOK)
+		})
+
+		backend, err := backends.NewWebhook(backends.WebhookOptions{
+			URL:           receiver.url,
+			FlushInterval: testutil.IntervalFast,
+			RetryInterval: testutil.IntervalFast,
+		})
+		require.NoError(t, err)
+		defer backend.(io.Closer).Close()
+
+		alog := audittest.RandomLog()
+		require.NoError(t, backend.Export(ctx, alog))
+
+		require.Eventually(t, func() bool {
+			return len(receiver.received()) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Equal(t, alog.ID, receiver.received()[0].ID)
+		require.EqualValues(t, 3, atomic.LoadInt64(&attempts))
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		backend, err := backends.NewWebhook(backends.WebhookOptions{
+			URL:           &url.URL{Scheme: "http", Host: "127.0.0.1:1"},
+			QueueSize:     1,
+			FlushInterval: time.Hour,
+		})
+		require.NoError(t, err)
+		defer backend.(io.Closer).Close()
+
+		require.NoError(t, backend.Export(ctx, audittest.RandomLog()))
+		require.Error(t, backend.Export(ctx, audittest.RandomLog()))
+	})
+
+	t.Run("PersistQueue", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		dir := t.TempDir()
+		failing := newWebhookReceiver(t, func(rw http.ResponseWriter, r *http.Request, body []byte) {
+			rw.WriteHeader(http.StatusInternalServerError)
+		})
+		backend, err := backends.NewWebhook(backends.WebhookOptions{
+			URL:           failing.url,
+			QueueDir:      dir,
+			FlushInterval: time.Hour,
+		})
+		require.NoError(t, err)
+
+		first := audittest.RandomLog()
+		second := audittest.RandomLog()
+		second.Time = first.Time.Add(time.Second)
+		require.NoError(t, backend.Export(ctx, first))
+		require.NoError(t, backend.Export(ctx, second))
+		// Closing attempts a final delivery, which fails.
+		require.NoError(t, backend.(io.Closer).Close())
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+
+		receiver := newWebhookReceiver(t, func(rw http.ResponseWriter, r *http.Request, body []byte) {
+			rw.WriteHeader(http.StatusOK)
+		})
+		backend, err = backends.NewWebhook(backends.WebhookOptions{
+			URL:           receiver.url,
+			QueueDir:      dir,
+			FlushInterval: testutil.IntervalFast,
+		})
+		require.NoError(t, err)
+		defer backend.(io.Closer).Close()
+
+		require.Eventually(t, func() bool {
+			return len(receiver.received()) == 2
+		}, testutil.WaitShort, testutil.IntervalFast)
+		received := receiver.received()
+		require.Equal(t, []uuid.UUID{first.ID, second.ID}, []uuid.UUID{received[0].ID, received[1].ID})
+
+		require.Eventually(t, func() bool {
+			entries, err := os.ReadDir(dir)
+			return err == nil && len(entries) == 0
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+}
+
+type webhookReceiver struct {
+	url *url.URL
+
+	mu    sync.Mutex
+	alogs []database.AuditLog
+}
+
+// newWebhookReceiver starts a server that records the audit logs of every
+// request that the handler responds to with a 2xx status code.
+func newWebhookReceiver(t *testing.T, handler func(rw http.ResponseWriter, r *http.Request, body []byte)) *webhookReceiver {
+	t.Helper()
+
+	receiver := &webhookReceiver{}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: rw}
+		handler(rec, r, body)
+		if rec.status < 200 || rec.status >= 300 {
+			return
+		}
+
+		var alogs []database.AuditLog
+		if !assert.NoError(t, json.Unmarshal(body, &alogs)) {
+			return
+		}
+		receiver.mu.Lock()
+		receiver.alogs = append(receiver.alogs, alogs...)
+		receiver.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	var err error
+	receiver.url, err = url.Parse(srv.URL)
+	require.NoError(t, err)
+	return receiver
+}
+
+func (r *webhookReceiver) received() []database.AuditLog {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]database.AuditLog(nil), r.alogs...)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
@@ -179,6 +179,32 @@ func runDiffValuesTests(t *testing.T, table Table, tests []diffTest) {
 func Test_diff(t *testing.T) {
 	t.Parallel()
 
+	runDiffTests(t, []diffTest{
+		{
+			name: "Create",
+			left: audit.Empty[database.APIKey](),
+			right: database.APIKey{
+				ID:              "abcdefghij",
+				HashedSecret:    []byte("a very secret hash"),
+				UserID:          uuid.UUID{1},
+				LastUsed:        time.Now(),
+				ExpiresAt:       time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+				CreatedAt:       time.Now(),
+				UpdatedAt:       time.Now(),
+				LoginType:       database.LoginTypePassword,
+				LifetimeSeconds: 86400,
+			},
+			exp: audit.Map{
+				"id":               audit.OldNew{Old: "", New: "abcdefghij"},
+				"hashed_secret":    audit.OldNew{Old: ([]byte)(nil), New: ([]byte)(nil), Secret: true},
+				"user_id":          audit.OldNew{Old: "", New: uuid.UUID{1}.String()},
+				"expires_at":       audit.OldNew{Old: time.Time{}, New: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)},
+				"login_type":       audit.OldNew{Old: database.LoginType(""), New: database.LoginTypePassword},
+				"lifetime_seconds": audit.OldNew{Old: int64(0), New: int64(86400)},
+			},
+		},
+	})
+
 	runDiffTests(t, []diffTest{
 		{
 			name: "Create",
@@ -377,6 +403,37 @@ func Test_diff(t *testing.T) {
 			},
 		},
 	})
+
+	runDiffTests(t, []diffTest{
+		{
+			name: "Create",
+			left: audit.Empty[database.WorkspaceBuild](),
+			right: database.WorkspaceBuild{
+				ID:                uuid.UUID{1},
+				CreatedAt:         time.Now(),
+				UpdatedAt:         time.Now(),
+				WorkspaceID:       uuid.UUID{2},
+				TemplateVersionID: uuid.UUID{3},
+				Name:              "happy_build",
+				BuildNumber:       2,
+				Transition:        database.WorkspaceTransitionStart,
+				InitiatorID:       uuid.UUID{4},
+				ProvisionerState:  []byte("terraform state"),
+				JobID:             uuid.UUID{5},
+				Reason:            database.BuildReasonInitiator,
+			},
+			exp: audit.Map{
+				"id":                  audit.OldNew{Old: "", New: uuid.UUID{1}.String()},
+				"workspace_id":        audit.OldNew{Old: "", New: uuid.UUID{2}.String()},
+				"template_version_id": audit.OldNew{Old: "", New: uuid.UUID{3}.String()},
+				"name":                audit.OldNew{Old: "", New: "happy_build"},
+				"build_number":        audit.OldNew{Old: int32(0), New: int32(2)},
+				"transition":          audit.OldNew{Old: database.WorkspaceTransition(""), New: database.WorkspaceTransitionStart},
+				"initiator_id":        audit.OldNew{Old: "", New: uuid.UUID{4}.String()},
+				"reason":              audit.OldNew{Old: database.BuildReason(""), New: database.BuildReasonInitiator},
+			},
+		},
+	})
 }
 
 func runDiffTests(t *testing.T, tests []diffTest) {
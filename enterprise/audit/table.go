@@ -26,6 +26,20 @@ type Table map[string]map[string]Action
 // AuditableResources contains a definitive list of all auditable resources and
 // which fields are auditable.
 var AuditableResources = auditMap(map[any]map[string]Action{
+	&database.APIKey{}: {
+		"id":               ActionTrack,
+		"hashed_secret":    ActionSecret, // We don't want to expose secrets, even hashed.
+		"user_id":          ActionTrack,
+		"last_used":        ActionIgnore, // Changes on every request, not helpful in a diff.
+		"expires_at":       ActionTrack,
+		"created_at":       ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":       ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"login_type":       ActionTrack,
+		"lifetime_seconds": ActionTrack,
+		"ip_address":       ActionIgnore, // Already recorded in the audit log itself.
+		"scope":            ActionTrack,
+		"token_name":       ActionTrack,
+	},
 	&database.GitSSHKey{}: {
 		"user_id":     ActionTrack,
 		"created_at":  ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
@@ -61,6 +75,12 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"max_ttl":                ActionTrack,
 		"min_autostart_interval": ActionTrack,
 		"created_by":             ActionTrack,
+		"inactivity_ttl":         ActionTrack,
+		"user_acl":               ActionTrack,
+		"group_acl":              ActionTrack,
+		"wait_for_agent_ready":   ActionTrack,
+		"session_recording":      ActionTrack,
+		"max_job_duration":       ActionTrack,
 	},
 	&database.TemplateVersion{}: {
 		"id":              ActionTrack,
@@ -72,6 +92,7 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"readme":          ActionTrack,
 		"job_id":          ActionIgnore, // Not helpful in a diff because jobs aren't tracked in audit logs.
 		"created_by":      ActionTrack,
+		"git_commit_sha":  ActionTrack,
 	},
 	&database.User{}: {
 		"id":              ActionTrack,
@@ -97,6 +118,21 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"ttl":                ActionTrack,
 		"last_used_at":       ActionIgnore,
 	},
+	&database.WorkspaceBuild{}: {
+		"id":                  ActionTrack,
+		"created_at":          ActionIgnore, // Never changes.
+		"updated_at":          ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"workspace_id":        ActionTrack,
+		"template_version_id": ActionTrack,
+		"name":                ActionTrack,
+		"build_number":        ActionTrack,
+		"transition":          ActionTrack,
+		"initiator_id":        ActionTrack,
+		"provisioner_state":   ActionIgnore, // Large and may contain secrets.
+		"job_id":              ActionIgnore, // Not helpful in a diff because jobs aren't tracked in audit logs.
+		"deadline":            ActionIgnore, // Set by the provisioner after the build is created.
+		"reason":              ActionTrack,
+	},
 })
 
 // auditMap converts a map of struct pointers to a map of struct names as
@@ -0,0 +1,77 @@
+package coderd
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd"
+	agplaudit "github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit"
+	"github.com/coder/coder/enterprise/audit/backends"
+)
+
+// newAuditBackends returns the backends audit logs are stored and exported
+// to. Audit logs are always stored in the Coder database.
+func newAuditBackends(options *coderd.Options) ([]audit.Backend, error) {
+	auditBackends := []audit.Backend{
+		backends.NewPostgres(options.Database, true),
+	}
+
+	export := options.AuditExport
+	if export.WebhookURL != nil {
+		webhook, err := backends.NewWebhook(backends.WebhookOptions{
+			URL:       export.WebhookURL,
+			Secret:    export.WebhookSecret,
+			QueueDir:  export.WebhookQueueDir,
+			QueueSize: export.WebhookQueueSize,
+			Logger:    options.Logger.Named("audit_webhook"),
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("create webhook backend: %w", err)
+		}
+		auditBackends = append(auditBackends, webhook)
+	}
+	if export.SyslogAddress != "" {
+		syslog, err := backends.NewSyslog(backends.SyslogOptions{
+			Address:   export.SyslogAddress,
+			TLSConfig: export.SyslogTLSConfig,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("create syslog backend: %w", err)
+		}
+		auditBackends = append(auditBackends, syslog)
+	}
+
+	return auditBackends, nil
+}
+
+// licensedAuditor drops audit logs unless the deployment is entitled to the
+// audit log feature. It starts out unentitled until the features service
+// has synced the licenses.
+type licensedAuditor struct {
+	agplaudit.Auditor
+
+	entitled atomic.Bool
+}
+
+func (a *licensedAuditor) setEntitled(entitled bool) {
+	a.entitled.Store(entitled)
+}
+
+func (a *licensedAuditor) Export(ctx context.Context, alog database.AuditLog) error {
+	if !a.entitled.Load() {
+		return nil
+	}
+	return a.Auditor.Export(ctx, alog)
+}
+
+func (a *licensedAuditor) Close() error {
+	if closer, ok := a.Auditor.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
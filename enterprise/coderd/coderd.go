@@ -9,21 +9,49 @@ import (
 
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/enterprise/audit"
+	"github.com/coder/coder/enterprise/tailnet"
 )
 
 const EnvAuditLogEnable = "CODER_AUDIT_LOG_ENABLE"
 
-func NewEnterprise(options *coderd.Options) *coderd.API {
+func NewEnterprise(options *coderd.Options) (*coderd.API, error) {
 	var eOpts = *options
 	if eOpts.Authorizer == nil {
 		var err error
 		eOpts.Authorizer, err = rbac.NewAuthorizer()
 		if err != nil {
-			// This should never happen, as the unit tests would fail if the
-			// default built in authorizer failed.
-			panic(xerrors.Errorf("rego authorize panic: %w", err))
+			return nil, xerrors.Errorf("create authorizer: %w", err)
 		}
 	}
+	if eOpts.TailnetCoordinator == nil {
+		// Node updates are shared over pubsub so agents and clients
+		// connected to different replicas can reach each other.
+		coordinator, err := tailnet.NewCoordinator(eOpts.Logger.Named("tailnet_coordinator"), eOpts.Pubsub)
+		if err != nil {
+			return nil, xerrors.Errorf("create tailnet coordinator: %w", err)
+		}
+		eOpts.TailnetCoordinator = coordinator
+	}
+	en := Enablements{AuditLogs: true}
+	auditLog := os.Getenv(EnvAuditLogEnable)
+	auditLog = strings.ToLower(auditLog)
+	if auditLog == "disable" || auditLog == "false" || auditLog == "0" || auditLog == "no" {
+		en.AuditLogs = false
+	}
+	// Audit logs are only exported while the license includes them, so the
+	// auditor is toggled by the features service as entitlements sync.
+	var auditor *licensedAuditor
+	if eOpts.Auditor == nil && en.AuditLogs {
+		auditBackends, err := newAuditBackends(&eOpts)
+		if err != nil {
+			return nil, xerrors.Errorf("create audit backends: %w", err)
+		}
+		auditor = &licensedAuditor{
+			Auditor: audit.NewAuditor(audit.DefaultFilter, auditBackends...),
+		}
+		eOpts.Auditor = auditor
+	}
 	eOpts.LicenseHandler = newLicenseAPI(
 		eOpts.Logger,
 		eOpts.Database,
@@ -32,18 +60,13 @@ func NewEnterprise(options *coderd.Options) *coderd.API {
 			Authorizer: eOpts.Authorizer,
 			Logger:     eOpts.Logger,
 		}).handler()
-	en := Enablements{AuditLogs: true}
-	auditLog := os.Getenv(EnvAuditLogEnable)
-	auditLog = strings.ToLower(auditLog)
-	if auditLog == "disable" || auditLog == "false" || auditLog == "0" || auditLog == "no" {
-		en.AuditLogs = false
-	}
 	eOpts.FeaturesService = newFeaturesService(
 		context.Background(),
 		eOpts.Logger,
 		eOpts.Database,
 		eOpts.Pubsub,
 		en,
+		auditor,
 	)
-	return coderd.New(&eOpts)
+	return coderd.New(&eOpts), nil
 }
@@ -29,6 +29,8 @@ type featuresService struct {
 	keys           map[string]ed25519.PublicKey
 	enablements    Enablements
 	resyncInterval time.Duration
+	// auditor is nil when audit logging is disabled.
+	auditor *licensedAuditor
 
 	mu           sync.RWMutex
 	entitlements entitlements
@@ -42,6 +44,7 @@ func newFeaturesService(
 	db database.Store,
 	pubsub database.Pubsub,
 	enablements Enablements,
+	auditor *licensedAuditor,
 ) agpl.FeaturesService {
 	fs := &featuresService{
 		logger:         logger,
@@ -50,6 +53,7 @@ func newFeaturesService(
 		keys:           keys,
 		enablements:    enablements,
 		resyncInterval: 10 * time.Minute,
+		auditor:        auditor,
 		entitlements: entitlements{
 			activeUsers: numericalEntitlement{
 				entitlementLimit: entitlementLimit{
@@ -239,6 +243,11 @@ func (s *featuresService) syncEntitlements(ctx context.Context) {
 		s.mu.Lock()
 		s.entitlements = ents
 		s.mu.Unlock()
+		if s.auditor != nil {
+			// Keep exporting during the grace period so an expiring license
+			// doesn't silently drop audit logs.
+			s.auditor.setEntitled(ents.auditLogs.state != notEntitled)
+		}
 		s.logger.Debug(ctx, "synced licensed entitlements")
 
 		select {
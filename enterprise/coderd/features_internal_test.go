@@ -19,6 +19,7 @@ import (
 	"cdr.dev/slog/sloggers/slogtest"
 
 	"github.com/coder/coder/coderd"
+	agplaudit "github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/databasefake"
 	"github.com/coder/coder/codersdk"
@@ -280,6 +281,55 @@ func TestFeaturesServiceSyncEntitlements(t *testing.T) {
 		require.NoError(t, err)
 		testutil.Eventually(ctx, t, userLimitIs(uut, 295), testutil.IntervalFast)
 	})
+
+	// This tests that audit logs are only exported while licensed.
+	t.Run("Auditor", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		logger := slogtest.Make(t, nil)
+		pubsub := database.NewPubsubInMemory()
+		db := databasefake.New()
+		mock := agplaudit.NewMock()
+		auditor := &licensedAuditor{Auditor: mock}
+		uut := &featuresService{
+			logger:         logger,
+			database:       db,
+			pubsub:         pubsub,
+			keys:           map[string]ed25519.PublicKey{keyID: pub},
+			enablements:    Enablements{AuditLogs: true},
+			resyncInterval: time.Hour, // no resyncs during test
+			auditor:        auditor,
+			entitlements:   entitlements{},
+		}
+
+		// Nothing is exported before the licenses are synced.
+		err := auditor.Export(ctx, database.AuditLog{})
+		require.NoError(t, err)
+		require.Empty(t, mock.AuditLogs())
+
+		l := putLicense(ctx, t, db, priv, keyID, 300, time.Hour, 2*time.Hour)
+		go uut.syncEntitlements(ctx)
+		testutil.Eventually(ctx, t, func(_ context.Context) bool {
+			return auditor.entitled.Load()
+		}, testutil.IntervalFast)
+
+		err = auditor.Export(ctx, database.AuditLog{})
+		require.NoError(t, err)
+		require.Len(t, mock.AuditLogs(), 1)
+
+		_, err = db.DeleteLicense(ctx, l.ID)
+		require.NoError(t, err)
+		err = pubsub.Publish(PubSubEventLicenses, []byte("delete"))
+		require.NoError(t, err)
+		testutil.Eventually(ctx, t, func(_ context.Context) bool {
+			return !auditor.entitled.Load()
+		}, testutil.IntervalFast)
+
+		err = auditor.Export(ctx, database.AuditLog{})
+		require.NoError(t, err)
+		require.Len(t, mock.AuditLogs(), 1)
+	})
 }
 
 func requestEntitlements(t *testing.T, uut coderd.FeaturesService) codersdk.Entitlements {
@@ -0,0 +1,467 @@
+package tailnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/database"
+	agpl "github.com/coder/coder/tailnet"
+)
+
+const (
+	// pubsubEvent is the channel node updates are exchanged on.
+	pubsubEvent = "tailnet_coordinator"
+
+	// eventClientHello is published when a client connects to an agent
+	// that isn't connected to this coordinator. The coordinator serving
+	// the agent responds with an agent update.
+	eventClientHello = "clienthello"
+	// eventClientUpdate is published when a client sends a new node for
+	// an agent that isn't connected to this coordinator.
+	eventClientUpdate = "clientupdate"
+	// eventAgentHello is published when an agent connects. Coordinators
+	// serving clients for the agent respond with client updates.
+	eventAgentHello = "agenthello"
+	// eventAgentUpdate is published when an agent sends a new node.
+	eventAgentUpdate = "agentupdate"
+)
+
+// NewCoordinator creates a new high availability coordinator that exchanges
+// node updates with other Coder replicas over pubsub.
+func NewCoordinator(logger slog.Logger, pubsub database.Pubsub) (agpl.Coordinator, error) {
+	coord := &haCoordinator{
+		id:                       uuid.New(),
+		log:                      logger,
+		pubsub:                   pubsub,
+		nodes:                    map[uuid.UUID]*agpl.Node{},
+		agentSockets:             map[uuid.UUID]net.Conn{},
+		agentToConnectionSockets: map[uuid.UUID]map[uuid.UUID]net.Conn{},
+	}
+
+	cancelSub, err := pubsub.Subscribe(pubsubEvent, coord.handlePubsubMessage)
+	if err != nil {
+		return nil, xerrors.Errorf("subscribe to %q: %w", pubsubEvent, err)
+	}
+	coord.cancelSub = cancelSub
+
+	return coord, nil
+}
+
+// haCoordinator is a tailnet coordinator that can be run across multiple
+// Coder replicas. Agents and clients connected to this coordinator are
+// served from memory, and node updates for peers connected to other
+// replicas are exchanged over pubsub.
+type haCoordinator struct {
+	id        uuid.UUID
+	log       slog.Logger
+	pubsub    database.Pubsub
+	cancelSub func()
+
+	mutex  sync.Mutex
+	closed bool
+
+	// nodes maps agent and connection IDs to their respective node. Nodes of
+	// agents connected to other replicas are cached while a client on this
+	// replica is connected to them.
+	nodes map[uuid.UUID]*agpl.Node
+	// agentSockets maps agent IDs to their open websocket.
+	agentSockets map[uuid.UUID]net.Conn
+	// agentToConnectionSockets maps agent IDs to connection IDs of conns that
+	// are subscribed to updates for that agent.
+	agentToConnectionSockets map[uuid.UUID]map[uuid.UUID]net.Conn
+}
+
+// Node returns an in-memory node by ID.
+func (c *haCoordinator) Node(id uuid.UUID) *agpl.Node {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	node := c.nodes[id]
+	return node
+}
+
+// ServeClient accepts a WebSocket connection that wants to connect to an agent
+// with the specified ID.
+func (c *haCoordinator) ServeClient(conn net.Conn, id uuid.UUID, agent uuid.UUID) error {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return xerrors.New("coordinator is closed")
+	}
+	connectionSockets, ok := c.agentToConnectionSockets[agent]
+	if !ok {
+		connectionSockets = map[uuid.UUID]net.Conn{}
+		c.agentToConnectionSockets[agent] = connectionSockets
+	}
+	// Insert this connection into a map so the agent can publish node
+	// updates. This must happen before asking other replicas for the agent's
+	// node, otherwise the reply could be missed.
+	connectionSockets[id] = conn
+	// When a new connection is requested, we update it with the latest
+	// node of the agent. This allows the connection to establish.
+	node, ok := c.nodes[agent]
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		// Clean all traces of this connection from the map.
+		delete(c.nodes, id)
+		connectionSockets, ok := c.agentToConnectionSockets[agent]
+		if !ok {
+			return
+		}
+		delete(connectionSockets, id)
+		if len(connectionSockets) != 0 {
+			return
+		}
+		delete(c.agentToConnectionSockets, agent)
+		// Nodes of remote agents are only cached while a client on
+		// this replica is connected to them.
+		if _, ok := c.agentSockets[agent]; !ok {
+			delete(c.nodes, agent)
+		}
+	}()
+
+	if ok {
+		data, err := json.Marshal([]*agpl.Node{node})
+		if err != nil {
+			return xerrors.Errorf("marshal node: %w", err)
+		}
+		_, err = conn.Write(data)
+		if err != nil {
+			return xerrors.Errorf("write nodes: %w", err)
+		}
+	} else {
+		// The agent may be connected to another replica, so ask it
+		// to publish the agent's node.
+		err := c.publish(eventClientHello, agent, nil)
+		if err != nil {
+			return xerrors.Errorf("publish client hello: %w", err)
+		}
+	}
+
+	decoder := json.NewDecoder(conn)
+	for {
+		err := c.handleNextClientMessage(id, agent, decoder)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return xerrors.Errorf("handle next client message: %w", err)
+		}
+	}
+}
+
+func (c *haCoordinator) handleNextClientMessage(id, agent uuid.UUID, decoder *json.Decoder) error {
+	var node agpl.Node
+	err := decoder.Decode(&node)
+	if err != nil {
+		return xerrors.Errorf("read json: %w", err)
+	}
+
+	c.mutex.Lock()
+	// Update the node of this client in our in-memory map. If an agent entirely
+	// shuts down and reconnects, it needs to be aware of all clients attempting
+	// to establish connections.
+	c.nodes[id] = &node
+	agentSocket, ok := c.agentSockets[agent]
+	c.mutex.Unlock()
+	if !ok {
+		// The agent isn't connected to this replica, so send the node
+		// to whichever replica it is connected to.
+		err = c.publish(eventClientUpdate, agent, &node)
+		if err != nil {
+			return xerrors.Errorf("publish client update: %w", err)
+		}
+		return nil
+	}
+
+	// Write the new node from this client to the actively connected agent.
+	data, err := json.Marshal([]*agpl.Node{&node})
+	if err != nil {
+		return xerrors.Errorf("marshal nodes: %w", err)
+	}
+	_, err = agentSocket.Write(data)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return xerrors.Errorf("write json: %w", err)
+	}
+	return nil
+}
+
+// ServeAgent accepts a WebSocket connection to an agent that listens to
+// incoming connections and publishes node updates.
+func (c *haCoordinator) ServeAgent(conn net.Conn, id uuid.UUID) error {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return xerrors.New("coordinator is closed")
+	}
+	// If an old agent socket is connected, we close it to avoid any leaks. This
+	// shouldn't ever occur because we expect one agent to be running.
+	oldAgentSocket, ok := c.agentSockets[id]
+	if ok {
+		_ = oldAgentSocket.Close()
+	}
+	// The socket must be registered before asking other replicas for client
+	// nodes, otherwise their replies could be missed.
+	c.agentSockets[id] = conn
+	// Publish all nodes on this replica that want to connect to this agent.
+	nodes := c.nodesSubscribedToAgent(id)
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		// Only remove the agent if it wasn't replaced by a newer socket.
+		if c.agentSockets[id] == conn {
+			delete(c.agentSockets, id)
+			delete(c.nodes, id)
+		}
+	}()
+
+	if len(nodes) > 0 {
+		data, err := json.Marshal(nodes)
+		if err != nil {
+			return xerrors.Errorf("marshal json: %w", err)
+		}
+		_, err = conn.Write(data)
+		if err != nil {
+			return xerrors.Errorf("write nodes: %w", err)
+		}
+	}
+	// Ask other replicas to send the nodes of clients connected to them.
+	err := c.publish(eventAgentHello, id, nil)
+	if err != nil {
+		return xerrors.Errorf("publish agent hello: %w", err)
+	}
+
+	decoder := json.NewDecoder(conn)
+	for {
+		node, err := c.handleAgentUpdate(id, decoder)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return xerrors.Errorf("handle next agent message: %w", err)
+		}
+
+		err = c.publish(eventAgentUpdate, id, node)
+		if err != nil {
+			return xerrors.Errorf("publish agent update: %w", err)
+		}
+	}
+}
+
+// nodesSubscribedToAgent returns the nodes of all connections on this replica
+// that want to connect to the agent. The mutex must be held.
+func (c *haCoordinator) nodesSubscribedToAgent(agentID uuid.UUID) []*agpl.Node {
+	sockets, ok := c.agentToConnectionSockets[agentID]
+	if !ok {
+		return nil
+	}
+
+	nodes := make([]*agpl.Node, 0, len(sockets))
+	for targetID := range sockets {
+		node, ok := c.nodes[targetID]
+		if !ok {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+// handleAgentUpdate reads the next node from an agent and sends it to every
+// connection on this replica that is subscribed to the agent.
+func (c *haCoordinator) handleAgentUpdate(id uuid.UUID, decoder *json.Decoder) (*agpl.Node, error) {
+	var node agpl.Node
+	err := decoder.Decode(&node)
+	if err != nil {
+		return nil, xerrors.Errorf("decode json: %w", err)
+	}
+
+	c.sendAgentNode(id, &node)
+	return &node, nil
+}
+
+// sendAgentNode stores the node of an agent and writes it to every connection
+// on this replica that is subscribed to the agent.
+func (c *haCoordinator) sendAgentNode(id uuid.UUID, node *agpl.Node) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	connectionSockets, ok := c.agentToConnectionSockets[id]
+	if !ok {
+		// Agents connected to this replica always have their node
+		// stored. Nodes of remote agents are only cached while a
+		// client on this replica is connected to them.
+		if _, ok := c.agentSockets[id]; ok {
+			c.nodes[id] = node
+		}
+		return
+	}
+	c.nodes[id] = node
+
+	data, err := json.Marshal([]*agpl.Node{node})
+	if err != nil {
+		c.log.Error(context.Background(), "marshal agent node", slog.Error(err))
+		return
+	}
+
+	// Publish the new node to every listening socket.
+	var wg sync.WaitGroup
+	wg.Add(len(connectionSockets))
+	for _, connectionSocket := range connectionSockets {
+		connectionSocket := connectionSocket
+		go func() {
+			defer wg.Done()
+			_, _ = connectionSocket.Write(data)
+		}()
+	}
+	wg.Wait()
+}
+
+// Close closes all of the open connections in the coordinator and stops the
+// coordinator from accepting new connections.
+func (c *haCoordinator) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.cancelSub()
+
+	wg := sync.WaitGroup{}
+
+	wg.Add(len(c.agentSockets))
+	for _, socket := range c.agentSockets {
+		socket := socket
+		go func() {
+			_ = socket.Close()
+			wg.Done()
+		}()
+	}
+
+	for _, connMap := range c.agentToConnectionSockets {
+		wg.Add(len(connMap))
+		for _, socket := range connMap {
+			socket := socket
+			go func() {
+				_ = socket.Close()
+				wg.Done()
+			}()
+		}
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// publish sends an event to all other replicas. Messages are formatted as:
+// <event>|<coordinator id>|<agent id>|<node json>
+func (c *haCoordinator) publish(event string, agentID uuid.UUID, node *agpl.Node) error {
+	var nodeJSON []byte
+	if node != nil {
+		var err error
+		nodeJSON, err = json.Marshal(node)
+		if err != nil {
+			return xerrors.Errorf("marshal node: %w", err)
+		}
+	}
+
+	message := []byte(fmt.Sprintf("%s|%s|%s|", event, c.id, agentID))
+	message = append(message, nodeJSON...)
+	err := c.pubsub.Publish(pubsubEvent, message)
+	if err != nil {
+		return xerrors.Errorf("publish message: %w", err)
+	}
+	return nil
+}
+
+// handlePubsubMessage handles an event published by another replica.
+func (c *haCoordinator) handlePubsubMessage(ctx context.Context, message []byte) {
+	parts := bytes.SplitN(message, []byte("|"), 4)
+	if len(parts) != 4 {
+		c.log.Error(ctx, "invalid coordinator pubsub message", slog.F("msg", string(message)))
+		return
+	}
+
+	var (
+		event         = string(parts[0])
+		coordinatorID = parts[1]
+		agentID       = parts[2]
+		nodeJSON      = parts[3]
+	)
+	if string(coordinatorID) == c.id.String() {
+		// Messages published by this replica are already handled.
+		return
+	}
+
+	agentUUID, err := uuid.ParseBytes(agentID)
+	if err != nil {
+		c.log.Error(ctx, "invalid agent id", slog.F("id", string(agentID)))
+		return
+	}
+
+	switch event {
+	case eventClientHello:
+		c.mutex.Lock()
+		_, ok := c.agentSockets[agentUUID]
+		node := c.nodes[agentUUID]
+		c.mutex.Unlock()
+		if !ok || node == nil {
+			return
+		}
+		err = c.publish(eventAgentUpdate, agentUUID, node)
+		if err != nil {
+			c.log.Error(ctx, "publish agent update", slog.Error(err))
+		}
+	case eventClientUpdate:
+		c.mutex.Lock()
+		agentSocket, ok := c.agentSockets[agentUUID]
+		c.mutex.Unlock()
+		if !ok {
+			return
+		}
+		// We get a single node over pubsub, so turn into an array.
+		_, err = agentSocket.Write(bytes.Join([][]byte{[]byte("["), nodeJSON, []byte("]")}, []byte{}))
+		if err != nil && !errors.Is(err, io.EOF) {
+			c.log.Error(ctx, "write client node to agent", slog.Error(err))
+		}
+	case eventAgentHello:
+		c.mutex.Lock()
+		nodes := c.nodesSubscribedToAgent(agentUUID)
+		c.mutex.Unlock()
+		for _, node := range nodes {
+			err = c.publish(eventClientUpdate, agentUUID, node)
+			if err != nil {
+				c.log.Error(ctx, "publish client update", slog.Error(err))
+			}
+		}
+	case eventAgentUpdate:
+		var node agpl.Node
+		err = json.Unmarshal(nodeJSON, &node)
+		if err != nil {
+			c.log.Error(ctx, "unmarshal agent node", slog.Error(err))
+			return
+		}
+		c.sendAgentNode(agentUUID, &node)
+	default:
+		c.log.Error(ctx, "unknown coordinator pubsub event", slog.F("event", event))
+	}
+}
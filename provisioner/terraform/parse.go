@@ -8,6 +8,8 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"github.com/mitchellh/go-wordwrap"
 	"golang.org/x/xerrors"
@@ -93,9 +95,59 @@ func convertVariableToParameter(variable *tfconfig.Variable) (*proto.ParameterSc
 		schema.ValidationTypeSystem = proto.ParameterSchema_HCL
 	}
 
+	mutable, err := variableMutable(variable)
+	if err != nil {
+		return nil, xerrors.Errorf("parse variable %q mutability: %w", variable.Name, err)
+	}
+	schema.Mutable = mutable
+
 	return schema, nil
 }
 
+// mutableMarker is the comment that marks a variable as mutable when it's
+// written in the variable block. Terraform rejects unknown arguments in
+// variable blocks, so the marker can't be an argument.
+const mutableMarker = "coder:mutable"
+
+// variableMutable returns whether the block of a variable contains the
+// mutable marker comment. Variables in JSON files are never mutable.
+func variableMutable(variable *tfconfig.Variable) (bool, error) {
+	if filepath.Ext(variable.Pos.Filename) != ".tf" {
+		return false, nil
+	}
+	filedata, err := os.ReadFile(variable.Pos.Filename)
+	if err != nil {
+		return false, xerrors.Errorf("read file %q: %w", variable.Pos.Filename, err)
+	}
+	file, diags := hclsyntax.ParseConfig(filedata, variable.Pos.Filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return false, xerrors.Errorf("parse file %q: %s", variable.Pos.Filename, diags.Error())
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return false, nil
+	}
+	for _, block := range body.Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 || block.Labels[0] != variable.Name {
+			continue
+		}
+		tokens, _ := hclsyntax.LexConfig(block.Body.SrcRange.SliceBytes(filedata), variable.Pos.Filename, block.Body.SrcRange.Start)
+		for _, token := range tokens {
+			if token.Type != hclsyntax.TokenComment {
+				continue
+			}
+			comment := strings.TrimSpace(string(token.Bytes))
+			comment = strings.TrimPrefix(comment, "#")
+			comment = strings.TrimPrefix(comment, "//")
+			comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+			if strings.TrimSpace(comment) == mutableMarker {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // formatDiagnostics returns a nicely formatted string containing all of the
 // error details within the tfconfig.Diagnostics. We need to use this because
 // the default format doesn't provide much useful information.
@@ -108,6 +108,41 @@ func TestParse(t *testing.T) {
 			},
 			ErrorContains: `The ";" character is not valid.`,
 		},
+		{
+			Name: "mutable-variable",
+			Files: map[string]string{
+				"main.tf": `variable "A" {
+				# coder:mutable
+				description = "Testing!"
+			}
+			variable "B" {
+				# Not mutable.
+			}`,
+			},
+			Response: &proto.Parse_Response{
+				Type: &proto.Parse_Response_Complete{
+					Complete: &proto.Parse_Complete{
+						ParameterSchemas: []*proto.ParameterSchema{{
+							Name:                "A",
+							RedisplayValue:      true,
+							AllowOverrideSource: true,
+							Description:         "Testing!",
+							DefaultDestination: &proto.ParameterDestination{
+								Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+							},
+							Mutable: true,
+						}, {
+							Name:                "B",
+							RedisplayValue:      true,
+							AllowOverrideSource: true,
+							DefaultDestination: &proto.ParameterDestination{
+								Scheme: proto.ParameterDestination_PROVISIONER_VARIABLE,
+							},
+						}},
+					},
+				},
+			},
+		},
 		{
 			Name: "multiple-variables",
 			Files: map[string]string{
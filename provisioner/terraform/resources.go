@@ -21,6 +21,7 @@ type agentAttributes struct {
 	Token           string            `mapstructure:"token"`
 	Env             map[string]string `mapstructure:"env"`
 	StartupScript   string            `mapstructure:"startup_script"`
+	ShutdownScript  string            `mapstructure:"shutdown_script"`
 }
 
 // A mapping of attributes on the "coder_app" resource.
@@ -31,6 +32,7 @@ type agentAppAttributes struct {
 	URL          string `mapstructure:"url"`
 	Command      string `mapstructure:"command"`
 	RelativePath bool   `mapstructure:"relative_path"`
+	Share        string `mapstructure:"share"`
 }
 
 // A mapping of attributes on the "coder_metadata" resource.
@@ -104,6 +106,7 @@ func ConvertResources(module *tfjson.StateModule, rawGraph string) ([]*proto.Res
 			Id:              attrs.ID,
 			Env:             attrs.Env,
 			StartupScript:   attrs.StartupScript,
+			ShutdownScript:  attrs.ShutdownScript,
 			OperatingSystem: attrs.OperatingSystem,
 			Architecture:    attrs.Architecture,
 			Directory:       attrs.Directory,
@@ -215,6 +218,17 @@ func ConvertResources(module *tfjson.StateModule, rawGraph string) ([]*proto.Res
 			// Default to the resource name if none is set!
 			attrs.Name = resource.Name
 		}
+		var sharingLevel proto.AppSharingLevel
+		switch strings.ToLower(attrs.Share) {
+		case "", "owner":
+			sharingLevel = proto.AppSharingLevel_OWNER
+		case "authenticated":
+			sharingLevel = proto.AppSharingLevel_AUTHENTICATED
+		case "public":
+			sharingLevel = proto.AppSharingLevel_PUBLIC
+		default:
+			return nil, xerrors.Errorf("app %q has an unknown share level %q", attrs.Name, attrs.Share)
+		}
 		for _, agents := range resourceAgents {
 			for _, agent := range agents {
 				// Find agents with the matching ID and associate them!
@@ -227,6 +241,7 @@ func ConvertResources(module *tfjson.StateModule, rawGraph string) ([]*proto.Res
 					Url:          attrs.URL,
 					Icon:         attrs.Icon,
 					RelativePath: attrs.RelativePath,
+					SharingLevel: sharingLevel,
 				})
 			}
 		}
@@ -111,7 +111,8 @@ func TestConvertResources(t *testing.T) {
 				Apps: []*proto.App{{
 					Name: "app1",
 				}, {
-					Name: "app2",
+					Name:         "app2",
+					SharingLevel: proto.AppSharingLevel_AUTHENTICATED,
 				}},
 				Auth: &proto.Agent_Token{},
 			}},
@@ -2,7 +2,7 @@ terraform {
   required_providers {
     coder = {
       source  = "coder/coder"
-      version = "0.4.2"
+      version = "0.5.0"
     }
   }
 }
@@ -18,6 +18,7 @@ resource "coder_app" "app1" {
 
 resource "coder_app" "app2" {
   agent_id = coder_agent.dev1.id
+  share    = "authenticated"
 }
 
 resource "null_resource" "dev" {
@@ -6,8 +6,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -331,6 +336,139 @@ func TestProvisionerd(t *testing.T) {
 		require.NoError(t, closer.Close())
 	})
 
+	t.Run("TemplateImportGit", func(t *testing.T) {
+		t.Parallel()
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git isn't installed")
+		}
+		url, commit := createGitRepository(t, map[string]string{
+			"README.md":          "# Not the template",
+			"template/test.txt":  "content",
+			"template/README.md": "# A cool template",
+		}, "coder", "token")
+		var (
+			didAcquireJob atomic.Bool
+			didReadme     atomic.Bool
+			completeChan  = make(chan struct{})
+			completedJob  *proto.CompletedJob
+		)
+
+		closer := createProvisionerd(t, func(ctx context.Context) (proto.DRPCProvisionerDaemonClient, error) {
+			return createProvisionerDaemonClient(t, provisionerDaemonTestServer{
+				acquireJob: func(ctx context.Context, _ *proto.Empty) (*proto.AcquiredJob, error) {
+					if !didAcquireJob.CAS(false, true) {
+						return &proto.AcquiredJob{}, nil
+					}
+
+					return &proto.AcquiredJob{
+						JobId:       "test",
+						Provisioner: "someprovisioner",
+						TemplateSourceGit: &proto.AcquiredJob_TemplateSourceGit{
+							Url:          url,
+							Subdirectory: "template",
+							Username:     "coder",
+							Password:     "token",
+						},
+						Type: &proto.AcquiredJob_TemplateImport_{
+							TemplateImport: &proto.AcquiredJob_TemplateImport{
+								Metadata: &sdkproto.Provision_Metadata{},
+							},
+						},
+					}, nil
+				},
+				updateJob: func(ctx context.Context, update *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error) {
+					if string(update.Readme) == "# A cool template" {
+						didReadme.Store(true)
+					}
+					return &proto.UpdateJobResponse{}, nil
+				},
+				completeJob: func(ctx context.Context, job *proto.CompletedJob) (*proto.Empty, error) {
+					completedJob = job
+					close(completeChan)
+					return &proto.Empty{}, nil
+				},
+			}), nil
+		}, provisionerd.Provisioners{
+			"someprovisioner": createProvisionerClient(t, provisionerTestServer{
+				parse: func(request *sdkproto.Parse_Request, stream sdkproto.DRPCProvisioner_ParseStream) error {
+					data, err := os.ReadFile(filepath.Join(request.Directory, "test.txt"))
+					require.NoError(t, err)
+					require.Equal(t, "content", string(data))
+					_, err = os.Stat(filepath.Join(request.Directory, ".git"))
+					require.ErrorIs(t, err, os.ErrNotExist)
+
+					return stream.Send(&sdkproto.Parse_Response{
+						Type: &sdkproto.Parse_Response_Complete{
+							Complete: &sdkproto.Parse_Complete{},
+						},
+					})
+				},
+				provision: func(stream sdkproto.DRPCProvisioner_ProvisionStream) error {
+					_, err := stream.Recv()
+					require.NoError(t, err)
+					return stream.Send(&sdkproto.Provision_Response{
+						Type: &sdkproto.Provision_Response_Complete{
+							Complete: &sdkproto.Provision_Complete{},
+						},
+					})
+				},
+			}),
+		})
+		require.Condition(t, closedWithin(completeChan, testutil.WaitShort))
+		require.True(t, didReadme.Load())
+		require.Equal(t, commit, completedJob.GetTemplateImport().GitCommitSha)
+		require.NoError(t, closer.Close())
+	})
+
+	t.Run("TemplateImportGitFileURL", func(t *testing.T) {
+		t.Parallel()
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git isn't installed")
+		}
+		bare := t.TempDir()
+		out, err := exec.Command("git", "init", "--quiet", "--bare", bare).CombinedOutput()
+		require.NoError(t, err, string(out))
+		var (
+			didAcquireJob atomic.Bool
+			failChan      = make(chan struct{})
+			failedJob     *proto.FailedJob
+		)
+
+		closer := createProvisionerd(t, func(ctx context.Context) (proto.DRPCProvisionerDaemonClient, error) {
+			return createProvisionerDaemonClient(t, provisionerDaemonTestServer{
+				acquireJob: func(ctx context.Context, _ *proto.Empty) (*proto.AcquiredJob, error) {
+					if !didAcquireJob.CAS(false, true) {
+						return &proto.AcquiredJob{}, nil
+					}
+
+					return &proto.AcquiredJob{
+						JobId:       "test",
+						Provisioner: "someprovisioner",
+						TemplateSourceGit: &proto.AcquiredJob_TemplateSourceGit{
+							Url: "file://" + filepath.ToSlash(bare),
+						},
+						Type: &proto.AcquiredJob_TemplateImport_{
+							TemplateImport: &proto.AcquiredJob_TemplateImport{
+								Metadata: &sdkproto.Provision_Metadata{},
+							},
+						},
+					}, nil
+				},
+				updateJob: noopUpdateJob,
+				failJob: func(ctx context.Context, job *proto.FailedJob) (*proto.Empty, error) {
+					failedJob = job
+					close(failChan)
+					return &proto.Empty{}, nil
+				},
+			}), nil
+		}, provisionerd.Provisioners{
+			"someprovisioner": createProvisionerClient(t, provisionerTestServer{}),
+		})
+		require.Condition(t, closedWithin(failChan, testutil.WaitShort))
+		require.Contains(t, failedJob.Error, "not allowed")
+		require.NoError(t, closer.Close())
+	})
+
 	t.Run("TemplateDryRun", func(t *testing.T) {
 		t.Parallel()
 		var (
@@ -925,6 +1063,58 @@ func TestProvisionerd(t *testing.T) {
 	})
 }
 
+// Creates a bare git repository with a commit of the files provided, serves
+// it over HTTP, and returns its URL and the commit. The server requires the
+// username and password provided, if any.
+func createGitRepository(t *testing.T, files map[string]string, username, password string) (string, string) {
+	t.Helper()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@coder.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@coder.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	for name, content := range files {
+		path := filepath.Join(work, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	git(work, "init", "--quiet")
+	git(work, "add", ".")
+	git(work, "commit", "--quiet", "--message", "Initial commit")
+	commit := git(work, "rev-parse", "HEAD")
+	bare := filepath.Join(dir, "repository.git")
+	git(dir, "clone", "--quiet", "--bare", work, bare)
+
+	path, err := exec.LookPath("git")
+	require.NoError(t, err)
+	backend := &cgi.Handler{
+		Path: path,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + dir, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if username != "" || password != "" {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != username || pass != password {
+				rw.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		backend.ServeHTTP(rw, r)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/repository.git", commit
+}
+
 // Creates an in-memory tar of the files provided.
 func createTar(t *testing.T, files map[string]string) []byte {
 	var buffer bytes.Buffer
@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/provisionerd/proto"
+)
+
+// cloneTemplateSource fetches the git repository of the job at its ref, and
+// copies the template source in its subdirectory to the work directory. It
+// returns the commit that was fetched.
+//
+// The repository is fetched into a scratch directory rather than the work
+// directory, so the template source is written through the filesystem of the
+// runner like an unpacked archive is.
+func (r *Runner) cloneTemplateSource(source *proto.AcquiredJob_TemplateSourceGit) (string, error) {
+	dir, err := os.MkdirTemp("", "coder-template-git-")
+	if err != nil {
+		return "", xerrors.Errorf("create clone directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref := source.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	r.logger.Info(r.notStopped, "fetching template source repository",
+		slog.F("url", source.Url),
+		slog.F("ref", ref),
+	)
+	_, err = r.git(dir, source, "init", "--quiet")
+	if err != nil {
+		return "", err
+	}
+	_, err = r.git(dir, source, "fetch", "--quiet", "--depth", "1", "--", source.Url, ref)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.git(dir, source, "checkout", "--quiet", "FETCH_HEAD")
+	if err != nil {
+		return "", err
+	}
+	commit, err := r.git(dir, source, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	root := filepath.Join(dir, filepath.FromSlash(source.Subdirectory))
+	if root != dir && !strings.HasPrefix(root, dir+string(filepath.Separator)) {
+		return "", xerrors.Errorf("subdirectory %q is outside of the repository", source.Subdirectory)
+	}
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(r.workDirectory, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return r.filesystem.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode().IsRegular():
+			return r.copyFile(path, target, info.Mode().Perm())
+		default:
+			// Symlinks and other special files aren't copied, just like
+			// they aren't unpacked from archives.
+			return nil
+		}
+	})
+	if err != nil {
+		return "", xerrors.Errorf("copy template source: %w", err)
+	}
+	return commit, nil
+}
+
+// copyFile copies a file from the host filesystem to the filesystem of the
+// runner.
+func (r *Runner) copyFile(source, target string, mode os.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := r.filesystem.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// git runs a git command in dir and returns its trimmed output.
+//
+// Credentials are sent as an HTTP authorization header set through the
+// environment, so they never show up in the arguments of the process or in
+// the configuration of the repository. Coderd only sends credentials for
+// repositories on the hosts they're configured for. The header is scoped to
+// the host of the repository, and redirects aren't followed, so it's never
+// sent anywhere else.
+func (r *Runner) git(dir string, source *proto.AcquiredJob_TemplateSourceGit, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	// #nosec
+	cmd := exec.CommandContext(r.notStopped, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		// Fail instead of waiting for credentials on a terminal.
+		"GIT_TERMINAL_PROMPT=0",
+		// Other transports, like "ext::" and "file://", run commands or
+		// read files on the host of the daemon.
+		"GIT_ALLOW_PROTOCOL=http:https:ssh",
+	)
+	repository, err := url.Parse(source.Url)
+	if err == nil && (repository.Scheme == "https" || repository.Scheme == "http") &&
+		(source.Username != "" || source.Password != "") {
+		host := (&url.URL{Scheme: repository.Scheme, Host: repository.Host, Path: "/"}).String()
+		credentials := base64.StdEncoding.EncodeToString([]byte(source.Username + ":" + source.Password))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=2",
+			"GIT_CONFIG_KEY_0=http."+host+".extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+			"GIT_CONFIG_KEY_1=http.followRedirects",
+			"GIT_CONFIG_VALUE_1=false",
+		)
+	}
+	err = cmd.Run()
+	if err != nil {
+		return "", xerrors.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
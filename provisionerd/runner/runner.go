@@ -41,6 +41,9 @@ type Runner struct {
 
 	// closed when the Runner is finished sending any updates/failed/complete.
 	done chan any
+	// the commit the template source was cloned at, if it came from git.
+	gitCommitSHA string
+
 	// active as long as we are not canceled
 	notCanceled context.Context
 	cancel      context.CancelFunc
@@ -295,6 +298,62 @@ func (r *Runner) do() (*proto.CompletedJob, *proto.FailedJob) {
 		return nil, r.failedJobf("write log: %s", err)
 	}
 
+	if source := r.job.TemplateSourceGit; source != nil {
+		r.gitCommitSHA, err = r.cloneTemplateSource(source)
+		if err != nil {
+			return nil, r.failedJobf("clone template source: %s", err)
+		}
+		_, err = r.update(r.notStopped, &proto.UpdateJobRequest{
+			JobId: r.job.JobId,
+			Logs: []*proto.Log{{
+				Source:    proto.LogSource_PROVISIONER_DAEMON,
+				Level:     sdkproto.LogLevel_INFO,
+				Stage:     "Setting up",
+				CreatedAt: time.Now().UTC().UnixMilli(),
+				Output:    fmt.Sprintf("Cloned %s at %s", source.Url, r.gitCommitSHA),
+			}},
+		})
+		if err != nil {
+			return nil, r.failedJobf("write log: %s", err)
+		}
+	} else {
+		failedJob := r.unpackTemplateSourceArchive()
+		if failedJob != nil {
+			return nil, failedJob
+		}
+	}
+
+	switch jobType := r.job.Type.(type) {
+	case *proto.AcquiredJob_TemplateImport_:
+		r.logger.Debug(context.Background(), "acquired job is template import")
+
+		failedJob := r.runReadmeParse()
+		if failedJob != nil {
+			return nil, failedJob
+		}
+		return r.runTemplateImport()
+	case *proto.AcquiredJob_TemplateDryRun_:
+		r.logger.Debug(context.Background(), "acquired job is template dry-run",
+			slog.F("workspace_name", jobType.TemplateDryRun.Metadata.WorkspaceName),
+			slog.F("parameters", jobType.TemplateDryRun.ParameterValues),
+		)
+		return r.runTemplateDryRun()
+	case *proto.AcquiredJob_WorkspaceBuild_:
+		r.logger.Debug(context.Background(), "acquired job is workspace provision",
+			slog.F("workspace_name", jobType.WorkspaceBuild.WorkspaceName),
+			slog.F("state_length", len(jobType.WorkspaceBuild.State)),
+			slog.F("parameters", jobType.WorkspaceBuild.ParameterValues),
+		)
+		return r.runWorkspaceBuild()
+	default:
+		return nil, r.failedJobf("unknown job type %q; ensure your provisioner daemon is up-to-date",
+			reflect.TypeOf(r.job.Type).String())
+	}
+}
+
+// unpackTemplateSourceArchive writes the files of the template source archive
+// of the job to the work directory.
+func (r *Runner) unpackTemplateSourceArchive() *proto.FailedJob {
 	r.logger.Info(r.notStopped, "unpacking template source archive",
 		slog.F("size_bytes", len(r.job.TemplateSourceArchive)))
 	reader := tar.NewReader(bytes.NewBuffer(r.job.TemplateSourceArchive))
@@ -304,12 +363,12 @@ func (r *Runner) do() (*proto.CompletedJob, *proto.FailedJob) {
 			break
 		}
 		if err != nil {
-			return nil, r.failedJobf("read template source archive: %s", err)
+			return r.failedJobf("read template source archive: %s", err)
 		}
 		// #nosec
 		headerPath := filepath.Join(r.workDirectory, header.Name)
 		if !strings.HasPrefix(headerPath, filepath.Clean(r.workDirectory)) {
-			return nil, r.failedJobf("tar attempts to target relative upper directory")
+			return r.failedJobf("tar attempts to target relative upper directory")
 		}
 		mode := header.FileInfo().Mode()
 		if mode == 0 {
@@ -319,13 +378,13 @@ func (r *Runner) do() (*proto.CompletedJob, *proto.FailedJob) {
 		case tar.TypeDir:
 			err = r.filesystem.MkdirAll(headerPath, mode)
 			if err != nil {
-				return nil, r.failedJobf("mkdir %q: %s", headerPath, err)
+				return r.failedJobf("mkdir %q: %s", headerPath, err)
 			}
 			r.logger.Debug(context.Background(), "extracted directory", slog.F("path", headerPath))
 		case tar.TypeReg:
 			file, err := r.filesystem.OpenFile(headerPath, os.O_CREATE|os.O_RDWR, mode)
 			if err != nil {
-				return nil, r.failedJobf("create file %q (mode %s): %s", headerPath, mode, err)
+				return r.failedJobf("create file %q (mode %s): %s", headerPath, mode, err)
 			}
 			// Max file size of 10MiB.
 			size, err := io.CopyN(file, reader, 10<<20)
@@ -334,11 +393,11 @@ func (r *Runner) do() (*proto.CompletedJob, *proto.FailedJob) {
 			}
 			if err != nil {
 				_ = file.Close()
-				return nil, r.failedJobf("copy file %q: %s", headerPath, err)
+				return r.failedJobf("copy file %q: %s", headerPath, err)
 			}
 			err = file.Close()
 			if err != nil {
-				return nil, r.failedJobf("close file %q: %s", headerPath, err)
+				return r.failedJobf("close file %q: %s", headerPath, err)
 			}
 			r.logger.Debug(context.Background(), "extracted file",
 				slog.F("size_bytes", size),
@@ -347,33 +406,7 @@ func (r *Runner) do() (*proto.CompletedJob, *proto.FailedJob) {
 			)
 		}
 	}
-
-	switch jobType := r.job.Type.(type) {
-	case *proto.AcquiredJob_TemplateImport_:
-		r.logger.Debug(context.Background(), "acquired job is template import")
-
-		failedJob := r.runReadmeParse()
-		if failedJob != nil {
-			return nil, failedJob
-		}
-		return r.runTemplateImport()
-	case *proto.AcquiredJob_TemplateDryRun_:
-		r.logger.Debug(context.Background(), "acquired job is template dry-run",
-			slog.F("workspace_name", jobType.TemplateDryRun.Metadata.WorkspaceName),
-			slog.F("parameters", jobType.TemplateDryRun.ParameterValues),
-		)
-		return r.runTemplateDryRun()
-	case *proto.AcquiredJob_WorkspaceBuild_:
-		r.logger.Debug(context.Background(), "acquired job is workspace provision",
-			slog.F("workspace_name", jobType.WorkspaceBuild.WorkspaceName),
-			slog.F("state_length", len(jobType.WorkspaceBuild.State)),
-			slog.F("parameters", jobType.WorkspaceBuild.ParameterValues),
-		)
-		return r.runWorkspaceBuild()
-	default:
-		return nil, r.failedJobf("unknown job type %q; ensure your provisioner daemon is up-to-date",
-			reflect.TypeOf(r.job.Type).String())
-	}
+	return nil
 }
 
 // heartbeat periodically sends updates on the job, which keeps coder server from assuming the job
@@ -547,6 +580,7 @@ func (r *Runner) runTemplateImport() (*proto.CompletedJob, *proto.FailedJob) {
 			TemplateImport: &proto.CompletedJob_TemplateImport{
 				StartResources: startResources,
 				StopResources:  stopResources,
+				GitCommitSha:   r.gitCommitSHA,
 			},
 		},
 	}, nil
@@ -10,6 +10,8 @@ export interface APIKey {
   readonly updated_at: string
   readonly login_type: LoginType
   readonly lifetime_seconds: number
+  readonly scope: APIKeyScope
+  readonly token_name: string
 }
 
 // From codersdk/workspaceagents.go
@@ -18,6 +20,15 @@ export interface AWSInstanceIdentityToken {
   readonly document: string
 }
 
+// From codersdk/provisionerdaemons.go
+export interface ActiveProvisionerJob {
+  readonly job: ProvisionerJob
+  readonly type: ProvisionerJobType
+  readonly organization_id: string
+  readonly initiator_id: string
+  readonly daemon?: ProvisionerDaemon
+}
+
 // From codersdk/licenses.go
 export interface AddLicenseRequest {
   readonly license: string
@@ -29,11 +40,27 @@ export interface AgentGitSSHKey {
   readonly private_key: string
 }
 
+// From codersdk/templates.go
+export interface AgentResourceStats {
+  readonly cpu_used_cores: number
+  readonly cpu_total_cores: number
+  readonly memory_used_bytes: number
+  readonly memory_total_bytes: number
+  readonly disk_used_bytes: number
+  readonly disk_total_bytes: number
+}
+
 // From codersdk/templates.go
 export interface AgentStatsReportResponse {
   readonly num_comms: number
   readonly rx_bytes: number
   readonly tx_bytes: number
+  readonly resources?: AgentResourceStats
+}
+
+// From codersdk/workspaceapps.go
+export interface AppHostResponse {
+  readonly host: string
 }
 
 // From codersdk/roles.go
@@ -76,6 +103,17 @@ export interface AuditLog {
   readonly user?: User
 }
 
+// From codersdk/audit.go
+export interface AuditLogResponse {
+  readonly audit_logs: AuditLog[]
+  readonly count: number
+}
+
+// From codersdk/audit.go
+export interface AuditLogsRequest extends Pagination {
+  readonly q?: string
+}
+
 // From codersdk/users.go
 export interface AuthMethods {
   readonly password: boolean
@@ -116,6 +154,11 @@ export interface CreateFirstUserResponse {
   readonly organization_id: string
 }
 
+// From codersdk/groups.go
+export interface CreateGroupRequest {
+  readonly name: string
+}
+
 // From codersdk/users.go
 export interface CreateOrganizationRequest {
   readonly name: string
@@ -153,7 +196,17 @@ export interface CreateTemplateVersionRequest {
   readonly storage_method: ProvisionerStorageMethod
   readonly storage_source: string
   readonly provisioner: ProvisionerType
+  readonly git_ref?: string
+  readonly git_subdirectory?: string
   readonly parameter_values?: CreateParameterRequest[]
+  readonly tags?: Record<string, string>
+}
+
+// From codersdk/users.go
+export interface CreateTokenRequest {
+  readonly token_name: string
+  readonly lifetime: number
+  readonly scope: APIKeyScope
 }
 
 // From codersdk/users.go
@@ -227,6 +280,14 @@ export interface GoogleInstanceIdentityToken {
   readonly json_web_token: string
 }
 
+// From codersdk/groups.go
+export interface Group {
+  readonly id: string
+  readonly name: string
+  readonly organization_id: string
+  readonly members: User[]
+}
+
 // From codersdk/licenses.go
 export interface License {
   readonly id: number
@@ -296,6 +357,7 @@ export interface ParameterSchema {
   readonly allow_override_destination: boolean
   readonly default_refresh: string
   readonly redisplay_value: boolean
+  readonly mutable: boolean
   readonly validation_error: string
   readonly validation_condition: string
   readonly validation_type_system: string
@@ -303,6 +365,25 @@ export interface ParameterSchema {
   readonly validation_contains?: string[]
 }
 
+// From codersdk/groups.go
+export interface PatchGroupRequest {
+  readonly add_users: string[]
+  readonly remove_users: string[]
+  readonly name: string
+}
+
+// From codersdk/workspaceagents.go
+export interface PatchWorkspaceAgentStartupLogs {
+  // Named type "github.com/coder/coder/agent.StartupLog" unknown, using "any"
+  // eslint-disable-next-line @typescript-eslint/no-explicit-any
+  readonly logs: any[]
+}
+
+// From codersdk/workspaceagents.go
+export interface PostWorkspaceAgentLifecycleRequest {
+  readonly state: WorkspaceAgentLifecycle
+}
+
 // From codersdk/workspaceagents.go
 export interface PostWorkspaceAgentVersionRequest {
   readonly version: string
@@ -315,6 +396,7 @@ export interface ProvisionerDaemon {
   readonly updated_at?: string
   readonly name: string
   readonly provisioners: ProvisionerType[]
+  readonly tags: Record<string, string>
 }
 
 // From codersdk/provisionerdaemons.go
@@ -327,6 +409,9 @@ export interface ProvisionerJob {
   readonly status: ProvisionerJobStatus
   readonly worker_id?: string
   readonly storage_source: string
+  readonly tags: Record<string, string>
+  readonly queue_position?: number
+  readonly queue_size?: number
 }
 
 // From codersdk/provisionerdaemons.go
@@ -371,15 +456,35 @@ export interface Template {
   readonly icon: string
   readonly max_ttl_ms: number
   readonly min_autostart_interval_ms: number
+  readonly inactivity_ttl_ms: number
+  readonly wait_for_agent_ready: boolean
+  readonly session_recording: boolean
+  readonly max_job_duration_ms: number
   readonly created_by_id: string
   readonly created_by_name: string
 }
 
+// From codersdk/templates.go
+export interface TemplateACL {
+  readonly users: TemplateUser[]
+  readonly group: TemplateGroup[]
+}
+
 // From codersdk/templates.go
 export interface TemplateDAUsResponse {
   readonly entries: DAUEntry[]
 }
 
+// From codersdk/templates.go
+export interface TemplateGroup extends Group {
+  readonly role: TemplateRole
+}
+
+// From codersdk/templates.go
+export interface TemplateUser extends User {
+  readonly role: TemplateRole
+}
+
 // From codersdk/templateversions.go
 export interface TemplateVersion {
   readonly id: string
@@ -392,6 +497,39 @@ export interface TemplateVersion {
   readonly readme: string
   readonly created_by_id: string
   readonly created_by_name: string
+  readonly git_commit_sha?: string
+}
+
+// From codersdk/templateversions.go
+export interface TemplateVersionDiff {
+  readonly from: string
+  readonly to: string
+  readonly files: TemplateVersionFileDiff[]
+  readonly parameter_schemas: TemplateVersionParameterSchemaDiff[]
+  readonly resources: TemplateVersionResourceDiff[]
+}
+
+// From codersdk/templateversions.go
+export interface TemplateVersionFileDiff {
+  readonly path: string
+  readonly status: TemplateVersionDiffStatus
+  readonly binary: boolean
+  readonly diff: string
+}
+
+// From codersdk/templateversions.go
+export interface TemplateVersionParameterSchemaDiff {
+  readonly name: string
+  readonly status: TemplateVersionDiffStatus
+  readonly fields?: string[]
+}
+
+// From codersdk/templateversions.go
+export interface TemplateVersionResourceDiff {
+  readonly type: string
+  readonly name: string
+  readonly status: TemplateVersionDiffStatus
+  readonly fields?: string[]
 }
 
 // From codersdk/templates.go
@@ -409,6 +547,12 @@ export interface UpdateRoles {
   readonly roles: string[]
 }
 
+// From codersdk/templates.go
+export interface UpdateTemplateACL {
+  readonly user_perms?: Record<string, TemplateRole>
+  readonly group_perms?: Record<string, TemplateRole>
+}
+
 // From codersdk/templates.go
 export interface UpdateTemplateMeta {
   readonly name?: string
@@ -416,6 +560,10 @@ export interface UpdateTemplateMeta {
   readonly icon?: string
   readonly max_ttl_ms?: number
   readonly min_autostart_interval_ms?: number
+  readonly inactivity_ttl_ms?: number
+  readonly max_job_duration_ms?: number
+  readonly wait_for_agent_ready?: boolean
+  readonly session_recording?: boolean
 }
 
 // From codersdk/users.go
@@ -515,6 +663,7 @@ export interface Workspace {
   readonly autostart_schedule?: string
   readonly ttl_ms?: number
   readonly last_used_at: string
+  readonly template_wait_for_agent_ready: boolean
 }
 
 // From codersdk/workspaceresources.go
@@ -526,6 +675,7 @@ export interface WorkspaceAgent {
   readonly last_connected_at?: string
   readonly disconnected_at?: string
   readonly status: WorkspaceAgentStatus
+  readonly lifecycle_state: WorkspaceAgentLifecycle
   readonly name: string
   readonly resource_id: string
   readonly instance_id?: string
@@ -533,6 +683,7 @@ export interface WorkspaceAgent {
   readonly environment_variables: Record<string, string>
   readonly operating_system: string
   readonly startup_script?: string
+  readonly shutdown_script?: string
   readonly directory?: string
   readonly version: string
   readonly apps: WorkspaceApp[]
@@ -564,6 +715,18 @@ export interface WorkspaceAgentInstanceMetadata {
   readonly vnc: boolean
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentListeningPort {
+  readonly process_name: string
+  readonly network: string
+  readonly port: number
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentListeningPortsResponse {
+  readonly ports: WorkspaceAgentListeningPort[]
+}
+
 // From codersdk/workspaceresources.go
 export interface WorkspaceAgentResourceMetadata {
   readonly memory_total: number
@@ -573,12 +736,31 @@ export interface WorkspaceAgentResourceMetadata {
   readonly cpu_mhz: number
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentResourceStats {
+  readonly created_at: string
+  readonly cpu_used_cores: number
+  readonly cpu_total_cores: number
+  readonly memory_used_bytes: number
+  readonly memory_total_bytes: number
+  readonly disk_used_bytes: number
+  readonly disk_total_bytes: number
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentStartupLog {
+  readonly id: number
+  readonly created_at: string
+  readonly output: string
+}
+
 // From codersdk/workspaceapps.go
 export interface WorkspaceApp {
   readonly id: string
   readonly name: string
   readonly command?: string
   readonly icon?: string
+  readonly sharing_level: WorkspaceAppSharingLevel
 }
 
 // From codersdk/workspacebuilds.go
@@ -601,11 +783,46 @@ export interface WorkspaceBuild {
   readonly reason: BuildReason
 }
 
+// From codersdk/workspacebuilds.go
+export interface WorkspaceBuildParameter {
+  readonly name: string
+  readonly value: string
+}
+
 // From codersdk/workspaces.go
 export interface WorkspaceBuildsRequest extends Pagination {
   readonly WorkspaceID: string
 }
 
+// From codersdk/connections.go
+export interface WorkspaceConnection {
+  readonly id: string
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly agent_id: string
+  readonly agent_name: string
+  readonly user_id?: string
+  readonly username: string
+  readonly type: WorkspaceConnectionType
+  readonly remote_addr: string
+  readonly target: string
+  readonly started_at: string
+  readonly ended_at?: string
+  readonly rx_bytes: number
+  readonly tx_bytes: number
+}
+
+// From codersdk/connections.go
+export interface WorkspaceConnectionsRequest extends Pagination {
+  readonly q?: string
+}
+
+// From codersdk/connections.go
+export interface WorkspaceConnectionsResponse {
+  readonly connections: WorkspaceConnection[]
+  readonly count: number
+}
+
 // From codersdk/workspaces.go
 export interface WorkspaceFilter {
   readonly q?: string
@@ -635,8 +852,23 @@ export interface WorkspaceResourceMetadata {
   readonly sensitive: boolean
 }
 
+// From codersdk/sessionrecordings.go
+export interface WorkspaceSessionRecording {
+  readonly id: string
+  readonly created_at: string
+  readonly workspace_id: string
+  readonly agent_id: string
+  readonly type: WorkspaceSessionRecordingType
+  readonly started_at: string
+  readonly ended_at: string
+  readonly size: number
+}
+
+// From codersdk/users.go
+export type APIKeyScope = "all" | "application_connect"
+
 // From codersdk/audit.go
-export type AuditAction = "create" | "delete" | "write"
+export type AuditAction = "create" | "delete" | "login" | "start" | "stop" | "write"
 
 // From codersdk/workspacebuilds.go
 export type BuildReason = "autostart" | "autostop" | "initiator"
@@ -651,7 +883,7 @@ export type LogLevel = "debug" | "error" | "info" | "trace" | "warn"
 export type LogSource = "provisioner" | "provisioner_daemon"
 
 // From codersdk/users.go
-export type LoginType = "github" | "oidc" | "password"
+export type LoginType = "github" | "oidc" | "password" | "token"
 
 // From codersdk/parameters.go
 export type ParameterDestinationScheme = "environment_variable" | "none" | "provisioner_variable"
@@ -674,20 +906,63 @@ export type ProvisionerJobStatus =
   | "running"
   | "succeeded"
 
+// From codersdk/provisionerdaemons.go
+export type ProvisionerJobType =
+  | "template_version_dry_run"
+  | "template_version_import"
+  | "workspace_build"
+
 // From codersdk/organizations.go
-export type ProvisionerStorageMethod = "file"
+export type ProvisionerStorageMethod = "file" | "git"
 
 // From codersdk/organizations.go
 export type ProvisionerType = "echo" | "terraform"
 
 // From codersdk/audit.go
-export type ResourceType = "organization" | "template" | "template_version" | "user" | "workspace"
+export type ResourceType =
+  | "api_key"
+  | "git_ssh_key"
+  | "organization"
+  | "template"
+  | "template_version"
+  | "user"
+  | "workspace"
+  | "workspace_build"
+
+// From codersdk/templates.go
+export type TemplateRole = "" | "admin" | "use"
+
+// From codersdk/templateversions.go
+export type TemplateVersionDiffStatus = "added" | "modified" | "removed"
 
 // From codersdk/users.go
 export type UserStatus = "active" | "suspended"
 
+// From codersdk/workspaceresources.go
+export type WorkspaceAgentLifecycle =
+  | "created"
+  | "off"
+  | "ready"
+  | "shutting_down"
+  | "start_error"
+  | "start_timeout"
+  | "starting"
+
 // From codersdk/workspaceresources.go
 export type WorkspaceAgentStatus = "connected" | "connecting" | "disconnected"
 
+// From codersdk/workspaceapps.go
+export type WorkspaceAppSharingLevel = "authenticated" | "owner" | "public"
+
+// From codersdk/connections.go
+export type WorkspaceConnectionType =
+  | "port_forward"
+  | "reconnecting_pty"
+  | "ssh"
+  | "workspace_app"
+
+// From codersdk/sessionrecordings.go
+export type WorkspaceSessionRecordingType = "reconnecting_pty" | "ssh"
+
 // From codersdk/workspacebuilds.go
 export type WorkspaceTransition = "delete" | "start" | "stop"
@@ -21,6 +21,7 @@ const createParameterSchema = (partial: Partial<ParameterSchema>): ParameterSche
     name: "parameter name",
     description: "Some description!",
     redisplay_value: false,
+    mutable: false,
     validation_condition: "",
     validation_contains: [],
     validation_error: "",
@@ -67,6 +67,8 @@ export const TemplateSettingsForm: FC<TemplateSettingsForm> = ({
       description: template.description,
       // on display, convert from ms => hours
       max_ttl_ms: template.max_ttl_ms / MS_HOUR_CONVERSION,
+      // not editable in this form yet, but must be sent back unchanged
+      inactivity_ttl_ms: template.inactivity_ttl_ms,
       icon: template.icon,
     },
     validationSchema,
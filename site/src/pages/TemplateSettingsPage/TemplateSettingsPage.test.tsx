@@ -31,7 +31,7 @@ const fillAndSubmitForm = async ({
   description,
   max_ttl_ms,
   icon,
-}: Omit<Required<UpdateTemplateMeta>, "min_autostart_interval_ms">) => {
+}: Omit<Required<UpdateTemplateMeta>, "min_autostart_interval_ms" | "inactivity_ttl_ms">) => {
   const nameField = await screen.findByLabelText(FormLanguage.nameLabel)
   await userEvent.clear(nameField)
   await userEvent.type(nameField, name)
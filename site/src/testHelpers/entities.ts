@@ -114,6 +114,7 @@ export const MockProvisioner: TypesGen.ProvisionerDaemon = {
   id: "test-provisioner",
   name: "Test Provisioner",
   provisioners: ["echo"],
+  tags: {},
 }
 
 export const MockProvisionerJob: TypesGen.ProvisionerJob = {
@@ -121,6 +122,7 @@ export const MockProvisionerJob: TypesGen.ProvisionerJob = {
   id: "test-provisioner-job",
   status: "succeeded",
   storage_source: "asdf",
+  tags: {},
   completed_at: "2022-05-17T17:39:01.382927298Z",
 }
 
@@ -174,6 +176,10 @@ export const MockTemplate: TypesGen.Template = {
   description: "This is a test description.",
   max_ttl_ms: 24 * 60 * 60 * 1000,
   min_autostart_interval_ms: 60 * 60 * 1000,
+  inactivity_ttl_ms: 0,
+  wait_for_agent_ready: false,
+  session_recording: false,
+  max_job_duration_ms: 0,
   created_by_id: "test-creator-id",
   created_by_name: "test_creator",
   icon: "/icon/code.svg",
@@ -230,6 +236,7 @@ export const MockWorkspace: TypesGen.Workspace = {
   template_id: MockTemplate.id,
   template_name: MockTemplate.name,
   template_icon: MockTemplate.icon,
+  template_wait_for_agent_ready: MockTemplate.wait_for_agent_ready,
   outdated: false,
   owner_id: MockUser.id,
   owner_name: MockUser.username,
@@ -297,6 +304,7 @@ export const MockWorkspaceApp: TypesGen.WorkspaceApp = {
   id: "test-app",
   name: "test-app",
   icon: "",
+  sharing_level: "owner",
 }
 
 export const MockWorkspaceAgent: TypesGen.WorkspaceAgent = {
@@ -309,6 +317,7 @@ export const MockWorkspaceAgent: TypesGen.WorkspaceAgent = {
   operating_system: "linux",
   resource_id: "",
   status: "connected",
+  lifecycle_state: "ready",
   updated_at: "",
   version: MockBuildInfo.version,
   latency: {